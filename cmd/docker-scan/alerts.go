@@ -0,0 +1,178 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/history"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/docker/scan-cli-plugin/internal/theme"
+	"github.com/spf13/cobra"
+)
+
+type alertsOptions struct {
+	provider   string
+	severity   string
+	since      string
+	jsonFormat bool
+}
+
+// imageAlert lists the vulnerabilities newly affecting an image since its last scan
+type imageAlert struct {
+	Image       string                 `json:"image"`
+	Digest      string                 `json:"digest,omitempty"`
+	LastScanned time.Time              `json:"lastScanned"`
+	Introduced  []report.Vulnerability `json:"introduced"`
+}
+
+func newAlertsCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags alertsOptions
+	cmd := &cobra.Command{
+		Use:   "alerts [OPTIONS] [IMAGE]",
+		Short: "Check the reports of the scans recorded with --history against the current advisories, and list the images newly affected since their last scan",
+		Args:  cli.RequiresMaxArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exitCodeError(runAlerts(ctx, dockerCli, flags, args, time.Now()),
+				options{exitCodeOnVuln: defaultExitCodeOnVuln, exitCodeOnError: defaultExitCodeOnError})
+		},
+	}
+	cmd.Flags().StringVar(&flags.provider, "provider", "", "Scan provider able to scan an SBOM, overrides the provider of the configuration defaults (binary|trivy|grype)")
+	cmd.Flags().StringVar(&flags.severity, "severity", "", "Only alert on vulnerabilities of provided level or higher (low|medium|high|critical)")
+	cmd.Flags().StringVar(&flags.since, "since", "", "Only check the images scanned since a date like 2021-03-01, or for a duration like 72h")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the alerts in JSON format")
+	return cmd
+}
+
+func runAlerts(ctx context.Context, dockerCli command.Cli, flags alertsOptions, args []string, now time.Time) error {
+	if flags.severity != "" && report.SeverityLevel(flags.severity) < 0 {
+		return fmt.Errorf("--severity takes only low, medium, high or critical values")
+	}
+	since, err := parseSince(flags.since, now)
+	if err != nil {
+		return err
+	}
+	entries, err := alertEntries(args, since)
+	if err != nil {
+		return err
+	}
+	providerOut := bytes.NewBuffer(nil)
+	scanProvider, err := configureProvider(ctx, dockerCli, options{provider: flags.provider, jsonFormat: true},
+		hubAuthConfig(dockerCli), provider.WithStreams(providerOut, dockerCli.Err()))
+	if err != nil {
+		return err
+	}
+	scanner, ok := scanProvider.(provider.SBOMScanner)
+	if !ok {
+		return fmt.Errorf("checking the alerts requires the Snyk binary, Trivy or Grype, use --provider binary, trivy or grype")
+	}
+	alerts := []imageAlert{}
+	for _, entry := range entries {
+		providerOut.Reset()
+		introduced, err := newVulnerabilities(scanner, providerOut, entry.Report)
+		if err != nil {
+			return fmt.Errorf("cannot check the last scan of %s: %s", entry.Image, err)
+		}
+		if flags.severity != "" {
+			introduced = report.Filter(report.Report{Vulnerabilities: introduced}, report.AtLeast(flags.severity)).Vulnerabilities
+		}
+		if len(introduced) > 0 {
+			alerts = append(alerts, imageAlert{Image: entry.Image, Digest: entry.Digest, LastScanned: entry.Time, Introduced: introduced})
+		}
+	}
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(alerts); err != nil {
+			return err
+		}
+	} else {
+		writeAlerts(dockerCli.Out(), alerts, len(entries))
+	}
+	if len(alerts) > 0 {
+		return provider.NewVulnerabilitiesFoundError()
+	}
+	return nil
+}
+
+// alertEntries returns the last scan of each image of the history whose report was saved, the ones of the image
+// when given
+func alertEntries(args []string, since time.Time) ([]history.Entry, error) {
+	entries, err := history.Read(history.DefaultPath())
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		entries = history.Select(entries, args[0], since)
+	}
+	var selected []history.Entry
+	for _, entry := range history.Latest(entries) {
+		if entry.Time.Before(since) || entry.Report == "" {
+			continue
+		}
+		if _, err := os.Stat(entry.Report); err == nil {
+			selected = append(selected, entry)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no scan report recorded, scan the images with --history to record their results")
+	}
+	return selected, nil
+}
+
+// newVulnerabilities scans the packages of the report again, and returns the vulnerabilities not in the report
+func newVulnerabilities(scanner provider.SBOMScanner, providerOut *bytes.Buffer, reportPath string) ([]report.Vulnerability, error) {
+	sbomPath, previous, cleanup, err := refreshInput(reportPath)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	if err := scanner.ScanSBOM(sbomPath); err != nil && !provider.IsVulnerabilitiesFoundError(err) {
+		return nil, err
+	}
+	current, err := report.Parse(providerOut.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	introduced, _ := report.Diff(previous, current)
+	return introduced, nil
+}
+
+func writeAlerts(out io.Writer, alerts []imageAlert, checked int) {
+	if len(alerts) == 0 {
+		fmt.Fprintf(out, "No image newly affected since its last scan, %d image(s) checked\n", checked)
+		return
+	}
+	for _, alert := range alerts {
+		fmt.Fprintf(out, "%s, last scanned on %s: %d new vulnerabilities\n", alert.Image,
+			alert.LastScanned.Local().Format("2006-01-02 15:04"), len(alert.Introduced))
+		for _, vuln := range alert.Introduced {
+			fmt.Fprintf(out, "  + %s %s in %s@%s (%s)\n", theme.Current().Severity(vuln.Severity, "["+vuln.Severity+"]"),
+				vuln.Title, vuln.PackageName, vuln.Version, vuln.ID)
+		}
+	}
+	fmt.Fprintf(out, "%d of the %d image(s) checked are newly affected, scan them again to update their reports\n", len(alerts), checked)
+}
@@ -0,0 +1,182 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/debug"
+	"github.com/docker/scan-cli-plugin/internal/fingerprint"
+	"github.com/docker/scan-cli-plugin/internal/licenses"
+	"github.com/docker/scan-cli-plugin/internal/policy"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/docker/scan-cli-plugin/internal/secrets"
+	"github.com/docker/scan-cli-plugin/internal/yara"
+)
+
+// layerAnalyzers run over the files of the image layers, they are set up before the scan
+// so that misconfigurations are reported early
+type layerAnalyzers struct {
+	malware  *yara.Scanner
+	binaries *fingerprint.Database
+	secrets  bool
+	licenses *licenseAnalyzer
+	// integrity reports the layers inconsistent with the image history
+	integrity bool
+	// squash reports the files and findings which would disappear if the image were squashed
+	squash bool
+}
+
+// licenseAnalyzer lists the packages of the image with their licenses, and reports the denied ones
+type licenseAnalyzer struct {
+	denied []string
+	// policyOnly scans the licenses for the license rules of the policy, without reporting them
+	policyOnly bool
+}
+
+func newLayerAnalyzers(flags options) (layerAnalyzers, error) {
+	var analyzers layerAnalyzers
+	if len(flags.yaraRules) > 0 {
+		scanner, err := yara.NewScanner(flags.yaraRules)
+		if err != nil {
+			return analyzers, err
+		}
+		analyzers.malware = scanner
+	}
+	if flags.binarySignatures != "" && !flags.binaries {
+		return analyzers, fmt.Errorf("--binaries flag is mandatory to use --binary-signatures flag")
+	}
+	if flags.binaries {
+		signatures := fingerprint.DefaultSignatures
+		if flags.binarySignatures != "" {
+			loaded, err := fingerprint.LoadSignatures(flags.binarySignatures)
+			if err != nil {
+				return analyzers, err
+			}
+			signatures = loaded
+		}
+		db, err := fingerprint.NewDatabase(signatures)
+		if err != nil {
+			return analyzers, err
+		}
+		analyzers.binaries = db
+	}
+	analyzers.secrets = hasScope(flags, report.ScopeSecrets)
+	analyzers.integrity = flags.verifyLayers
+	analyzers.squash = flags.simulateSquash
+	licenses, err := newLicenseAnalyzer(flags)
+	analyzers.licenses = licenses
+	return analyzers, err
+}
+
+func newLicenseAnalyzer(flags options) (*licenseAnalyzer, error) {
+	if !flags.licenses {
+		if len(flags.deniedLicenses) > 0 {
+			return nil, fmt.Errorf("--licenses flag is mandatory to use --deny-license flag")
+		}
+		if flags.policy == "" {
+			return nil, nil
+		}
+		scanPolicy, err := policy.Load(flags.policy)
+		if err != nil || !scanPolicy.RequiresLicenses() {
+			return nil, err
+		}
+		return &licenseAnalyzer{policyOnly: true}, nil
+	}
+	if len(flags.deniedLicenses) > 0 {
+		return &licenseAnalyzer{denied: flags.deniedLicenses}, nil
+	}
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	if conf.Licenses == nil {
+		return &licenseAnalyzer{}, nil
+	}
+	return &licenseAnalyzer{denied: conf.Licenses.Deny}, nil
+}
+
+func (a layerAnalyzers) enabled() bool {
+	return a.malware != nil || a.binaries != nil || a.secrets || a.licenses != nil || a.integrity || a.squash
+}
+
+// analyzeLayers extracts the image layers once and runs the enabled analyzers over them
+func analyzeLayers(ctx context.Context, dockerCli command.Cli, flags options, analyzers layerAnalyzers, ref string, results *scanResults) error {
+	if !analyzers.enabled() {
+		return nil
+	}
+	defer debug.Time("analyzing image layers", "image", ref)()
+	extracted, err := extractImage(ctx, dockerCli, flags, ref)
+	if err != nil {
+		return err
+	}
+	//nolint: errcheck
+	defer extracted.Remove()
+	if analyzers.integrity {
+		// an empty list reports that the layers have been verified
+		results.integrityWarnings = append([]string{}, extracted.IntegrityWarnings...)
+	}
+	if analyzers.malware != nil {
+		malware, err := analyzers.malware.ScanLayers(ctx, extracted)
+		if err != nil {
+			return err
+		}
+		// an empty list reports that the image has been scanned for malware
+		results.malware = append([]report.Vulnerability{}, malware...)
+	}
+	if analyzers.binaries != nil {
+		binaries, err := fingerprint.Scan(extracted, analyzers.binaries)
+		if err != nil {
+			return err
+		}
+		results.binaries = binaries
+	}
+	if analyzers.secrets {
+		secretFindings, err := secrets.Scan(extracted)
+		if err != nil {
+			return err
+		}
+		results.secrets = secretFindings
+	}
+	if analyzers.licenses != nil {
+		packages, err := licenses.Scan(extracted)
+		if err != nil {
+			return err
+		}
+		results.licensedPackages = packages
+		if !analyzers.licenses.policyOnly {
+			results.packages = packages
+			results.licenseIssues = report.DeniedLicenses(packages, analyzers.licenses.denied)
+		}
+	}
+	analyzed := append(append(append([]report.Vulnerability{}, results.malware...), results.secrets...), results.licenseIssues...)
+	emitEvents(dockerCli, flags, report.LayerEvents(results.ref, extracted.LayerIDs, analyzed)...)
+	emitEvents(dockerCli, flags, report.FindingEvents(results.ref, analyzed)...)
+	if analyzers.squash {
+		// runs last to locate the findings of the other analyzers
+		hidden, err := extracted.HiddenFiles()
+		if err != nil {
+			return err
+		}
+		simulation := report.SimulateSquash(hidden, results.findings(), results.binaries)
+		results.squash = &simulation
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/pkg/term"
+	"github.com/docker/scan-cli-plugin/internal/attest"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+// cosignPasswordEnv holds the password of the private key, like for cosign
+const cosignPasswordEnv = "COSIGN_PASSWORD"
+
+type attestOptions struct {
+	key            string
+	dockerFilePath string
+}
+
+type verifyAttestationOptions struct {
+	key        string
+	jsonFormat bool
+}
+
+func newAttestCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags attestOptions
+	cmd := &cobra.Command{
+		Use:   "attest [OPTIONS] IMAGE",
+		Short: "Sign the scan results of an image and attach them to the image in its registry, as a cosign attestation",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAttest(ctx, dockerCli, flags, args[0])
+		},
+	}
+	cmd.Flags().StringVar(&flags.key, "key", "", "Private key signing the attestation, like the cosign.key generated by cosign generate-key-pair")
+	cmd.Flags().StringVarP(&flags.dockerFilePath, "file", "f", "", "Dockerfile associated with image, provides more detailed results")
+	return cmd
+}
+
+func newVerifyAttestationCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags verifyAttestationOptions
+	cmd := &cobra.Command{
+		Use:   "verify-attestation [OPTIONS] IMAGE",
+		Short: "Verify the scan results attested with docker scan attest, and show them",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerifyAttestation(ctx, dockerCli, flags, args[0])
+		},
+	}
+	cmd.Flags().StringVar(&flags.key, "key", "", "Public key of the signing key, like the cosign.pub generated by cosign generate-key-pair")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the verified in-toto statements in JSON format")
+	return cmd
+}
+
+func runAttest(ctx context.Context, dockerCli command.Cli, flags attestOptions, image string) error {
+	if flags.key == "" {
+		return fmt.Errorf("the private key signing the attestation is required, set it with --key")
+	}
+	key, err := attest.LoadPrivateKey(flags.key, func() ([]byte, error) {
+		return keyPassword(dockerCli)
+	})
+	if err != nil {
+		return err
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return err
+	}
+	repository, err := registryRepository(ctx, dockerCli, named)
+	if err != nil {
+		return err
+	}
+	digest, err := registryDigest(ctx, dockerCli, repository, named)
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+	scanReport, err := scanImageReport(ctx, dockerCli, flags.dockerFilePath, image)
+	if err != nil {
+		return err
+	}
+	result := collectorResult(ctx, dockerCli, scanResults{ref: image, report: &scanReport})
+	// the attestation is readable by anyone pulling the image, unlike the results pushed to a collector
+	result.Host = ""
+	statement, err := attest.NewStatement(named.Name(), digest, result, started)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return err
+	}
+	envelope, err := attest.Sign(key, payload)
+	if err != nil {
+		return err
+	}
+	if err := attest.Attach(ctx, repository, digest, envelope); err != nil {
+		return fmt.Errorf("failed to attach the attestation: %s", err)
+	}
+	fmt.Fprintf(dockerCli.Out(), "Scan results of %s@%s attested in %s:%s\n", named.Name(), digest, named.Name(), attest.AttestationTag(digest))
+	return nil
+}
+
+func runVerifyAttestation(ctx context.Context, dockerCli command.Cli, flags verifyAttestationOptions, image string) error {
+	if flags.key == "" {
+		return fmt.Errorf("the public key verifying the attestation is required, set it with --key")
+	}
+	key, err := attest.LoadPublicKey(flags.key)
+	if err != nil {
+		return err
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return err
+	}
+	repository, err := registryRepository(ctx, dockerCli, named)
+	if err != nil {
+		return err
+	}
+	digest, err := registryDigest(ctx, dockerCli, repository, named)
+	if err != nil {
+		return err
+	}
+	envelopes, err := attest.Attestations(ctx, repository, digest)
+	if err != nil {
+		return fmt.Errorf("failed to get the attestations: %s", err)
+	}
+	statements := attest.Verify(envelopes, key, digest)
+	if len(statements) == 0 {
+		return fmt.Errorf("no scan results attestation of %s@%s is signed by the key", named.Name(), digest)
+	}
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statements)
+	}
+	for _, statement := range statements {
+		metadata := statement.Predicate.Metadata
+		scanner := statement.Predicate.Scanner
+		fmt.Fprintf(dockerCli.Out(), "Verified scan results of %s@%s, scanned on %s with docker scan %s\n",
+			named.Name(), digest, metadata.ScanFinishedOn.Format(time.RFC3339), scanner.Version)
+		fmt.Fprint(dockerCli.Out(), "  ")
+		report.WriteCounts(dockerCli.Out(), scanner.Result.Findings)
+	}
+	return nil
+}
+
+// keyPassword returns the password of the private key from the environment, or asks it in a terminal
+func keyPassword(dockerCli command.Cli) ([]byte, error) {
+	if password, ok := os.LookupEnv(cosignPasswordEnv); ok {
+		return []byte(password), nil
+	}
+	if !dockerCli.In().IsTerminal() {
+		return nil, fmt.Errorf("the private key is encrypted, set its password with the %s environment variable", cosignPasswordEnv)
+	}
+	fmt.Fprint(dockerCli.Err(), "Enter the password of the private key: ")
+	fd := dockerCli.In().FD()
+	state, err := term.SaveState(fd)
+	if err != nil {
+		return nil, err
+	}
+	if err := term.DisableEcho(fd, state); err != nil {
+		return nil, err
+	}
+	defer term.RestoreTerminal(fd, state) //nolint:errcheck
+	line, err := bufio.NewReader(dockerCli.In()).ReadString('\n')
+	fmt.Fprintln(dockerCli.Err())
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
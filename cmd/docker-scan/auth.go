@@ -0,0 +1,337 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/authentication"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+type authLoginOptions struct {
+	profile          string
+	token            string
+	org              string
+	hubUsername      string
+	hubPasswordStdin bool
+}
+
+func newAuthCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage the credentials used to scan images",
+		Args:  cli.NoArgs,
+	}
+	cmd.AddCommand(
+		newAuthLoginCmd(ctx, dockerCli),
+		newAuthStatusCmd(dockerCli),
+		newAuthTestCmd(ctx, dockerCli),
+		newAuthLogoutCmd(dockerCli),
+		newAuthProfilesCmd(dockerCli),
+		newAuthMigrateCmd(dockerCli),
+	)
+	return cmd
+}
+
+func newAuthLoginCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags authLoginOptions
+	cmd := &cobra.Command{
+		Use:   "login [OPTIONS]",
+		Short: "Authenticate to the scan provider, for the default account or for a named profile",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthLogin(ctx, dockerCli, flags)
+		},
+	}
+	cmd.Flags().StringVar(&flags.profile, "profile", "", "Name of the profile to create or update")
+	cmd.Flags().StringVar(&flags.token, "token", "", "Authentication token of the scan provider, web based authentication if empty")
+	cmd.Flags().StringVar(&flags.org, "org", "", "Snyk organization the scans of the profile run in")
+	cmd.Flags().StringVar(&flags.hubUsername, "hub-username", "", "Docker Hub account of the profile, instead of the docker login one")
+	cmd.Flags().BoolVar(&flags.hubPasswordStdin, "hub-password-stdin", false, "Read the Docker Hub password or access token of the profile from stdin")
+	return cmd
+}
+
+func newAuthStatusCmd(dockerCli command.Cli) *cobra.Command {
+	var profile string
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Display which credentials are used to scan images",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthStatus(dockerCli, profile)
+		},
+	}
+	cmd.Flags().StringVar(&profile, "profile", "", "Display the credentials of a profile")
+	return cmd
+}
+
+func newAuthTestCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var (
+		profile    string
+		jsonFormat bool
+	)
+	cmd := &cobra.Command{
+		Use:   "test [OPTIONS]",
+		Short: "Check the credentials used to scan images with an authenticated call, without scanning",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthTest(ctx, dockerCli, profile, jsonFormat)
+		},
+	}
+	cmd.Flags().StringVar(&profile, "profile", "", "Check the credentials of a profile")
+	cmd.Flags().BoolVar(&jsonFormat, "json", false, "Display the result in JSON format")
+	return cmd
+}
+
+func newAuthLogoutCmd(dockerCli command.Cli) *cobra.Command {
+	var profile string
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Remove the stored scan provider token and DockerScanID",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthLogout(dockerCli, profile)
+		},
+	}
+	cmd.Flags().StringVar(&profile, "profile", "", "Remove the credentials and the settings of a profile")
+	return cmd
+}
+
+func newAuthProfilesCmd(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "profiles",
+		Short: "List the profiles selected with --profile",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthProfiles(dockerCli)
+		},
+	}
+}
+
+func newAuthMigrateCmd(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Copy the token of the Snyk CLI configuration file to the Docker credentials store",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthMigrate(dockerCli)
+		},
+	}
+}
+
+func runAuthMigrate(dockerCli command.Cli) error {
+	if authentication.PlaintextStore(dockerCli.ConfigFile(), authentication.ProviderServerAddress) {
+		return fmt.Errorf("no credentials store is configured in the Docker CLI configuration, the token would be saved in plain text in %s: configure a credsStore first",
+			dockerCli.ConfigFile().Filename)
+	}
+	store := authentication.NewProviderTokenStore(dockerCli.ConfigFile().GetCredentialsStore(authentication.ProviderServerAddress))
+	token, err := store.ConfigstoreToken()
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return fmt.Errorf("no Snyk token found in %s", authentication.SnykConfigstorePath())
+	}
+	if err := store.Migrate(); err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Out(), "Copied the Snyk token of %s to the Docker credentials store\n", authentication.SnykConfigstorePath())
+	return nil
+}
+
+func runAuthLogin(ctx context.Context, dockerCli command.Cli, flags authLoginOptions) error {
+	loginFlags := options{
+		login:           true,
+		token:           flags.token,
+		profile:         flags.profile,
+		exitCodeOnVuln:  defaultExitCodeOnVuln,
+		exitCodeOnError: defaultExitCodeOnError,
+	}
+	if flags.profile == "" {
+		if flags.org != "" || flags.hubUsername != "" {
+			return fmt.Errorf("--org and --hub-username flags require --profile")
+		}
+		return exitCodeError(runAuthentication(ctx, dockerCli, loginFlags, nil), loginFlags)
+	}
+	if err := validateProfileName(flags.profile); err != nil {
+		return err
+	}
+	if flags.hubUsername != "" {
+		if !flags.hubPasswordStdin {
+			return fmt.Errorf("--hub-username flag requires --hub-password-stdin")
+		}
+		password, err := ioutil.ReadAll(dockerCli.In())
+		if err != nil {
+			return err
+		}
+		if err := storeProfileHubAuthConfig(dockerCli, flags.profile, flags.hubUsername, strings.TrimSpace(string(password))); err != nil {
+			return err
+		}
+	}
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	if conf.Profiles == nil {
+		conf.Profiles = map[string]config.ProfileConfig{}
+	}
+	conf.Profiles[flags.profile] = config.ProfileConfig{Org: flags.org, HubUsername: flags.hubUsername}
+	if err := config.SaveConfigFile(conf); err != nil {
+		return err
+	}
+	if err := runAuthentication(ctx, dockerCli, loginFlags, nil); err != nil {
+		return exitCodeError(err, loginFlags)
+	}
+	fmt.Fprintf(dockerCli.Out(), "Saved the credentials of the profile %s, select it with --profile %s\n", flags.profile, flags.profile)
+	return nil
+}
+
+// authOptions returns the provider options holding the credentials of the default account or of a profile
+func authOptions(dockerCli command.Cli, profile string, options ...provider.Ops) (provider.Options, error) {
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return provider.Options{}, err
+	}
+	opts, err := profileOptions(dockerCli, conf, profile)
+	if err != nil {
+		return provider.Options{}, err
+	}
+	oidcOpts, err := oidcOptions(context.Background(), conf, profile, conf.CACert)
+	if err != nil {
+		return provider.Options{}, err
+	}
+	// the options of the caller, like the organization of --org, replace the ones of the profile
+	opts = append([]provider.Ops{providerTokenStore(dockerCli), hubAuthConfig(dockerCli), provider.WithCACert(conf.CACert)}, opts...)
+	return provider.NewProvider(append(append(opts, oidcOpts...), options...)...)
+}
+
+func runAuthStatus(dockerCli command.Cli, profile string) error {
+	opts, err := authOptions(dockerCli, profile)
+	if err != nil {
+		return err
+	}
+	status, err := provider.GetAuthStatus(opts)
+	if err != nil {
+		return err
+	}
+	if !status.IsAuthenticated() {
+		return fmt.Errorf(`Not authenticated, please login to Docker Hub using the Docker Login command
+or authenticate to the scan provider using docker scan --login`)
+	}
+	if profile != "" {
+		fmt.Fprintf(dockerCli.Out(), "Profile:            %s\n", profile)
+	}
+	fmt.Fprintf(dockerCli.Out(), "Authenticated with: %s\n", status.Source)
+	if status.Username != "" {
+		fmt.Fprintf(dockerCli.Out(), "Docker Hub user:    %s\n", status.Username)
+	}
+	return nil
+}
+
+func runAuthTest(ctx context.Context, dockerCli command.Cli, profile string, jsonFormat bool) error {
+	opts, err := authOptions(dockerCli, profile, provider.WithContext(ctx), provider.WithRetryPolicy(provider.DefaultRetryPolicy))
+	if err != nil {
+		return err
+	}
+	check, checkErr := provider.CheckAuth(opts)
+	if jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(check); err != nil {
+			return err
+		}
+	} else if checkErr == nil {
+		writeAuthCheck(dockerCli.Out(), check)
+	}
+	return exitCodeError(checkErr, options{exitCodeOnVuln: defaultExitCodeOnVuln, exitCodeOnError: defaultExitCodeOnError})
+}
+
+func writeAuthCheck(out io.Writer, check provider.AuthCheck) {
+	fmt.Fprintf(out, "Authenticated with: %s\n", check.Source)
+	if check.Username != "" {
+		fmt.Fprintf(out, "Docker Hub user:    %s\n", check.Username)
+	}
+	if check.User != "" {
+		fmt.Fprintf(out, "Snyk user:          %s\n", check.User)
+	}
+	if check.Org != "" {
+		fmt.Fprintf(out, "Organization:       %s\n", check.Org)
+	}
+	fmt.Fprintln(out, "Credentials are valid")
+}
+
+func runAuthLogout(dockerCli command.Cli, profile string) error {
+	opts, err := authOptions(dockerCli, profile)
+	if err != nil {
+		return err
+	}
+	if err := provider.Logout(opts); err != nil {
+		return err
+	}
+	if profile == "" {
+		fmt.Fprintln(dockerCli.Out(), "Removed the stored scan credentials")
+		return nil
+	}
+	if err := eraseProfileHubAuthConfig(dockerCli, profile); err != nil {
+		return err
+	}
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	delete(conf.Profiles, profile)
+	if err := config.SaveConfigFile(conf); err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Out(), "Removed the profile %s and its credentials\n", profile)
+	return nil
+}
+
+func runAuthProfiles(dockerCli command.Cli) error {
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	var names []string
+	for name := range conf.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profile := conf.Profiles[name]
+		details := []string{name}
+		if profile.Org != "" {
+			details = append(details, "org: "+profile.Org)
+		}
+		if profile.HubUsername != "" {
+			details = append(details, "Docker Hub user: "+profile.HubUsername)
+		}
+		fmt.Fprintln(dockerCli.Out(), strings.Join(details, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/budget"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+func validateBudget(flags options, args []string) error {
+	if flags.budget < 0 {
+		return fmt.Errorf("--budget must be a positive number of images")
+	}
+	if flags.budget == 0 {
+		return nil
+	}
+	if flags.jsonFormat && len(args) > 1 {
+		return fmt.Errorf("--json flag can only be used to scan a single image")
+	}
+	return budget.ValidateOrder(flags.budgetOrder)
+}
+
+// runBudgetScan scans the most important images within the budget, and reports the deferred ones
+func runBudgetScan(ctx context.Context, cmd *cobra.Command, dockerCli command.Cli, flags options, args []string) error {
+	if err := validateBudget(flags, args); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return runScan(ctx, cmd, dockerCli, flags, args)
+	}
+	priorities, err := budgetPriorities(flags)
+	if err != nil {
+		return err
+	}
+	scan, deferred := budget.Plan(budgetCandidates(ctx, dockerCli, args, priorities), flags.budget, flags.budgetOrder)
+	var scanErr error
+	for _, candidate := range scan {
+		scanErr = worstScanError(scanErr, runScan(ctx, cmd, dockerCli, flags, []string{candidate.Ref}))
+	}
+	if len(deferred) > 0 {
+		fmt.Fprintf(dockerCli.Err(), "\nDeferred %d image(s) to stay within the scan budget of %d:\n", len(deferred), flags.budget)
+		for _, candidate := range deferred {
+			fmt.Fprintf(dockerCli.Err(), "  %s\n", candidate.Ref)
+		}
+	}
+	return scanErr
+}
+
+// budgetPriorities reads the image priorities of the scan configuration, required by the policy order
+func budgetPriorities(flags options) ([]string, error) {
+	if flags.budgetOrder != budget.PolicyOrder {
+		return nil, nil
+	}
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	if conf.Budget == nil || len(conf.Budget.Priorities) == 0 {
+		return nil, fmt.Errorf("--budget-order %s requires the budget priorities of the scan configuration", budget.PolicyOrder)
+	}
+	return conf.Budget.Priorities, nil
+}
+
+// budgetCandidates looks up the creation date of the images, images which can't be inspected are kept without date
+func budgetCandidates(ctx context.Context, dockerCli command.Cli, refs []string, priorities []string) []budget.Candidate {
+	var candidates []budget.Candidate
+	for _, ref := range refs {
+		candidate := budget.Candidate{Ref: ref, Priority: budget.Priority(ref, priorities)}
+		if inspect, _, err := dockerCli.Client().ImageInspectWithRaw(ctx, ref); err == nil {
+			candidate.Created, _ = time.Parse(time.RFC3339Nano, inspect.Created)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// worstScanError keeps a scan failure over found vulnerabilities, and found vulnerabilities over a successful scan
+func worstScanError(current, err error) error {
+	switch {
+	case err == nil:
+		return current
+	case current == nil, provider.IsVulnerabilitiesFoundError(current):
+		return err
+	default:
+		return current
+	}
+}
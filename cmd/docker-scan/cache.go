@@ -0,0 +1,148 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/cache"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+// cacheMaxAge is how long cached results are used, as the provider vulnerability database is continuously updated
+// and Snyk doesn't expose its version to key the results on
+const cacheMaxAge = 24 * time.Hour
+
+func newCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of scan results",
+		Args:  cli.NoArgs,
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "purge",
+		Short: "Remove all the cached scan results",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cache.New().Purge()
+		},
+	})
+	return cmd
+}
+
+// scanCache replays the provider output of a previous scan of the same image with the same provider and flags
+type scanCache struct {
+	enabled  bool
+	out      io.Writer
+	recorded bytes.Buffer
+}
+
+func newScanCache(flags options, out io.Writer) *scanCache {
+	return &scanCache{enabled: !flags.noCache && !config.ReadOnly(), out: out}
+}
+
+// writer returns the stream the provider writes its output to, recorded to be cached
+func (c *scanCache) writer() io.Writer {
+	if !c.enabled {
+		return c.out
+	}
+	return io.MultiWriter(c.out, &c.recorded)
+}
+
+func (c *scanCache) scan(ctx context.Context, dockerCli command.Cli, flags options, scanProvider provider.Provider, ref string) error {
+	if !c.enabled {
+		return scanProvider.Scan(ref)
+	}
+	key, err := scanCacheKey(ctx, dockerCli, flags, scanProvider, ref)
+	if err != nil {
+		// remote or unknown images are not cached
+		return scanProvider.Scan(ref)
+	}
+	store := cache.New()
+	now := time.Now()
+	if entry, ok := store.Get(key, now, cacheMaxAge); ok {
+		fmt.Fprintf(dockerCli.Err(), "Using the cached results of the scan of %s made at %s, use --no-cache to scan again\n", ref, entry.CreatedAt.Format(time.RFC3339))
+		if _, err := c.out.Write(entry.Output); err != nil {
+			return err
+		}
+		if entry.VulnerabilitiesFound {
+			return provider.NewVulnerabilitiesFoundError()
+		}
+		return nil
+	}
+	scanErr := scanProvider.Scan(ref)
+	if scanErr == nil || provider.IsVulnerabilitiesFoundError(scanErr) {
+		entry := cache.Entry{
+			Image:                ref,
+			Output:               c.recorded.Bytes(),
+			VulnerabilitiesFound: scanErr != nil,
+			CreatedAt:            now,
+		}
+		if err := store.Put(key, entry); err != nil {
+			fmt.Fprintf(dockerCli.Err(), "Failed to cache the scan results: %s\n", err)
+		}
+	}
+	return scanErr
+}
+
+// scanCacheKey identifies the image by its digest, and the provider version, identity and flags changing its output
+func scanCacheKey(ctx context.Context, dockerCli command.Cli, flags options, scanProvider provider.Provider, ref string) (string, error) {
+	inspect, _, err := dockerCli.Client().ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	version, err := provider.VersionKey(scanProvider)
+	if err != nil {
+		return "", err
+	}
+	identity, err := scanIdentity(dockerCli, flags.profile)
+	if err != nil {
+		return "", err
+	}
+	dockerfile := ""
+	if flags.dockerFilePath != "" {
+		buf, err := ioutil.ReadFile(flags.dockerFilePath)
+		if err != nil {
+			return "", err
+		}
+		dockerfile = string(buf)
+	}
+	providerFlags := fmt.Sprintf("json=%t group-issues=%t exclude-base=%t dependency-tree=%t severity=%s scope=%s",
+		flags.jsonFormat || needsReport(flags), flags.groupIssues, flags.excludeBase, flags.dependencyTree,
+		flags.severity, strings.Join(flags.scopes, ","))
+	return cache.Key(inspect.ID, version, identity, providerFlags, dockerfile), nil
+}
+
+// scanIdentity returns the digest of the account and organization the scans run under, the results
+// of a Snyk organization may differ from another one with its ignore rules and settings
+func scanIdentity(dockerCli command.Cli, profile string) (string, error) {
+	opts, err := authOptions(dockerCli, profile)
+	if err != nil {
+		return "", err
+	}
+	return provider.Identity(opts)
+}
@@ -0,0 +1,152 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/theme"
+	"github.com/spf13/cobra"
+)
+
+// configSetting is a setting of the docker scan configuration managed with docker scan config
+type configSetting struct {
+	get func(conf config.Config) string
+	set func(conf *config.Config, value string) error
+}
+
+var configSettings = map[string]configSetting{
+	"theme": {
+		get: func(conf config.Config) string {
+			return conf.Theme
+		},
+		set: func(conf *config.Config, value string) error {
+			if _, err := theme.Get(value); err != nil {
+				return err
+			}
+			conf.Theme = value
+			return nil
+		},
+	},
+	"metrics-file": {
+		get: func(conf config.Config) string {
+			return conf.MetricsFile
+		},
+		set: func(conf *config.Config, value string) error {
+			conf.MetricsFile = value
+			return nil
+		},
+	},
+}
+
+func newConfigCmd(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the settings of the docker scan configuration",
+		Args:  cli.NoArgs,
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "set KEY=VALUE [KEY=VALUE...]",
+			Short: "Change settings of the docker scan configuration, like theme=colorblind",
+			Args:  cli.RequiresMinArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runConfigSet(args)
+			},
+		},
+		&cobra.Command{
+			Use:   "get KEY",
+			Short: "Display a setting of the docker scan configuration",
+			Args:  cli.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runConfigGet(dockerCli, args[0])
+			},
+		},
+	)
+	return cmd
+}
+
+func runConfigSet(args []string) error {
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid setting %q, expected KEY=VALUE", arg)
+		}
+		setting, err := lookupSetting(parts[0])
+		if err != nil {
+			return err
+		}
+		if err := setting.set(&conf, parts[1]); err != nil {
+			return err
+		}
+	}
+	return config.SaveConfigFile(conf)
+}
+
+func runConfigGet(dockerCli command.Cli, key string) error {
+	setting, err := lookupSetting(key)
+	if err != nil {
+		return err
+	}
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(dockerCli.Out(), setting.get(conf))
+	return nil
+}
+
+func lookupSetting(key string) (configSetting, error) {
+	setting, ok := configSettings[key]
+	if !ok {
+		var keys []string
+		for key := range configSettings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return configSetting{}, fmt.Errorf("unknown setting %q, expected one of %s", key, strings.Join(keys, ", "))
+	}
+	return setting, nil
+}
+
+// applyTheme colors the output with the theme of the configuration, only in a terminal and unless NO_COLOR is set
+func applyTheme(dockerCli command.Cli) {
+	if !dockerCli.Out().IsTerminal() || os.Getenv("NO_COLOR") != "" {
+		return
+	}
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		// the commands reading the configuration report the error
+		return
+	}
+	selected, err := theme.Get(conf.Theme)
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Warning: %s\n", err)
+		return
+	}
+	theme.Set(selected)
+}
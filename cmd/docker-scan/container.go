@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	"github.com/spf13/cobra"
+)
+
+type containerOptions struct {
+	runtimeChecks  bool
+	dockerFilePath string
+	severity       string
+	jsonFormat     bool
+	forceOptIn     bool
+	// imageOnly scans the image of the container without the changes made to its filesystem
+	imageOnly bool
+}
+
+func newContainerCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags containerOptions
+	cmd := &cobra.Command{
+		Use:   "container [OPTIONS] CONTAINER",
+		Short: "Scan the image of a container with the changes made to its filesystem, and check the runtime configuration of the container",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scanFlags := options{
+				dockerFilePath:  flags.dockerFilePath,
+				severity:        flags.severity,
+				jsonFormat:      flags.jsonFormat,
+				forceOptIn:      flags.forceOptIn,
+				failOn:          failOnAll,
+				exitCodeOnVuln:  defaultExitCodeOnVuln,
+				exitCodeOnError: defaultExitCodeOnError,
+			}
+			return exitCodeError(runContainerScan(ctx, cmd, dockerCli, flags, scanFlags, args[0]), scanFlags)
+		},
+	}
+	cmd.Flags().BoolVar(&flags.runtimeChecks, "runtime-checks", false, "Check the privileges, capabilities, mounts and published ports of the container against the CIS Docker Benchmark")
+	cmd.Flags().StringVarP(&flags.dockerFilePath, "file", "f", "", "Dockerfile associated with the image of the container, provides more detailed results")
+	cmd.Flags().StringVar(&flags.severity, "severity", "", "Only report vulnerabilities of provided level or higher (low|medium|high)")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output results in JSON format")
+	cmd.Flags().BoolVar(&flags.forceOptIn, "accept-license", false, "Accept using a third party scanning provider")
+	cmd.Flags().BoolVar(&flags.imageOnly, "image-only", false, "Scan the image of the container without the changes made to its filesystem")
+	return cmd
+}
+
+// runContainerScan scans the image the container was created from, by ID as its tag may have moved since, with the
+// changes made to the filesystem of the container
+func runContainerScan(ctx context.Context, cmd *cobra.Command, dockerCli command.Cli, flags containerOptions, scanFlags options, container string) error {
+	inspect, err := dockerCli.Client().ContainerInspect(ctx, container)
+	if err != nil {
+		return err
+	}
+	if flags.runtimeChecks {
+		scanFlags.runtimeChecks = inspect.ID
+	}
+	image, cleanup, err := containerImage(ctx, dockerCli, flags, inspect)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	_, err = scanImage(ctx, cmd, dockerCli, scanFlags, []string{image})
+	return err
+}
+
+// containerImage returns the image of the container, or a temporary commit of the container when its filesystem was
+// changed, with the function removing the commit. The container is not paused during the commit.
+func containerImage(ctx context.Context, dockerCli command.Cli, flags containerOptions, inspect types.ContainerJSON) (string, func(), error) {
+	noCleanup := func() {}
+	name := strings.TrimPrefix(inspect.Name, "/")
+	// the changes of the containers which can't be listed, like Windows containers, are not scanned
+	changes, err := dockerCli.Client().ContainerDiff(ctx, inspect.ID)
+	if err != nil || flags.imageOnly || len(changes) == 0 {
+		if !flags.jsonFormat {
+			fmt.Fprintf(dockerCli.Err(), "Scanning the image %s (%s) of the container %s\n", inspect.Config.Image, inspect.Image, name)
+		}
+		return inspect.Image, noCleanup, nil
+	}
+	commit, err := dockerCli.Client().ContainerCommit(ctx, inspect.ID, types.ContainerCommitOptions{
+		Comment: "temporary commit of docker scan container",
+		Pause:   false,
+	})
+	if err != nil {
+		return "", noCleanup, fmt.Errorf("failed to commit the changes of the container %s: %s", name, err)
+	}
+	if !flags.jsonFormat {
+		fmt.Fprintf(dockerCli.Err(), "Scanning the image %s (%s) of the container %s with the %d changes made to its filesystem\n",
+			inspect.Config.Image, inspect.Image, name, len(changes))
+	}
+	return commit.ID, func() {
+		// the context may be canceled when the scan is interrupted
+		if _, err := dockerCli.Client().ImageRemove(context.Background(), commit.ID, types.ImageRemoveOptions{Force: true, PruneChildren: true}); err != nil {
+			fmt.Fprintf(dockerCli.Err(), "Failed to remove the temporary commit %s of the container: %s\n", commit.ID, err)
+		}
+	}, nil
+}
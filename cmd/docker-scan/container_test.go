@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"gotest.tools/v3/assert"
+)
+
+// fakeContainerClient is a docker client for a container with the given changes, recording the commits and removals
+type fakeContainerClient struct {
+	client.APIClient
+	changes   []containertypes.ContainerChangeResponseItem
+	commitErr error
+	commits   []string
+	removed   []string
+}
+
+func (c *fakeContainerClient) ContainerDiff(_ context.Context, container string) ([]containertypes.ContainerChangeResponseItem, error) {
+	return c.changes, nil
+}
+
+func (c *fakeContainerClient) ContainerCommit(_ context.Context, container string, _ types.ContainerCommitOptions) (types.IDResponse, error) {
+	if c.commitErr != nil {
+		return types.IDResponse{}, c.commitErr
+	}
+	c.commits = append(c.commits, container)
+	return types.IDResponse{ID: "sha256:commit"}, nil
+}
+
+func (c *fakeContainerClient) ImageRemove(_ context.Context, image string, _ types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error) {
+	c.removed = append(c.removed, image)
+	return nil, nil
+}
+
+type fakeClientCli struct {
+	fakeStreamsCli
+	client client.APIClient
+}
+
+func (c fakeClientCli) Client() client.APIClient {
+	return c.client
+}
+
+func newFakeClientCli(apiClient client.APIClient) command.Cli {
+	return fakeClientCli{fakeStreamsCli: fakeStreamsCli{err: bytes.NewBuffer(nil)}, client: apiClient}
+}
+
+func containerInspect() types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: "0123", Name: "/web", Image: "sha256:image"},
+		Config:            &containertypes.Config{Image: "nginx:1.21"},
+	}
+}
+
+func TestContainerImageWithoutChanges(t *testing.T) {
+	apiClient := &fakeContainerClient{}
+	image, cleanup, err := containerImage(context.Background(), newFakeClientCli(apiClient), containerOptions{}, containerInspect())
+	assert.NilError(t, err)
+	assert.Equal(t, image, "sha256:image")
+	cleanup()
+	assert.Assert(t, apiClient.commits == nil)
+	assert.Assert(t, apiClient.removed == nil)
+}
+
+func TestContainerImageWithChanges(t *testing.T) {
+	apiClient := &fakeContainerClient{changes: []containertypes.ContainerChangeResponseItem{{Kind: 1, Path: "/etc/nginx/nginx.conf"}}}
+	image, cleanup, err := containerImage(context.Background(), newFakeClientCli(apiClient), containerOptions{}, containerInspect())
+	assert.NilError(t, err)
+	assert.Equal(t, image, "sha256:commit")
+	assert.DeepEqual(t, apiClient.commits, []string{"0123"})
+	assert.Assert(t, apiClient.removed == nil)
+
+	cleanup()
+	assert.DeepEqual(t, apiClient.removed, []string{"sha256:commit"})
+}
+
+func TestContainerImageOnly(t *testing.T) {
+	apiClient := &fakeContainerClient{changes: []containertypes.ContainerChangeResponseItem{{Kind: 1, Path: "/etc/nginx/nginx.conf"}}}
+	image, _, err := containerImage(context.Background(), newFakeClientCli(apiClient), containerOptions{imageOnly: true}, containerInspect())
+	assert.NilError(t, err)
+	assert.Equal(t, image, "sha256:image")
+	assert.Assert(t, apiClient.commits == nil)
+}
+
+func TestContainerImageCommitFailure(t *testing.T) {
+	apiClient := &fakeContainerClient{
+		changes:   []containertypes.ContainerChangeResponseItem{{Kind: 1, Path: "/etc/nginx/nginx.conf"}},
+		commitErr: errors.New("no space left on device"),
+	}
+	_, cleanup, err := containerImage(context.Background(), newFakeClientCli(apiClient), containerOptions{}, containerInspect())
+	assert.Error(t, err, "failed to commit the changes of the container web: no space left on device")
+	cleanup()
+	assert.Assert(t, apiClient.removed == nil)
+}
@@ -0,0 +1,122 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/cache"
+	"github.com/docker/scan-cli-plugin/internal/nvd"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type cveOptions struct {
+	jsonFormat bool
+}
+
+// cveResult holds the details of a CVE and the cached scans reporting it
+type cveResult struct {
+	nvd.CVE
+	CachedScans []report.CachedMatch `json:"cachedScans"`
+}
+
+func newCVECmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags cveOptions
+	cmd := &cobra.Command{
+		Use:   "cve [OPTIONS] CVE-ID",
+		Short: "Show the details of a CVE from the NVD, and the cached scan results reporting it",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCVE(ctx, dockerCli, flags, args[0])
+		},
+	}
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the details in JSON format")
+	return cmd
+}
+
+func runCVE(ctx context.Context, dockerCli command.Cli, flags cveOptions, id string) error {
+	id, err := nvd.NormalizeID(id)
+	if err != nil {
+		return err
+	}
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	httpClient, err := proxy.NewHTTPClient(conf.CACert)
+	if err != nil {
+		return err
+	}
+	cve, err := nvd.Lookup(ctx, httpClient, nvd.DefaultURL, os.Getenv("NVD_API_KEY"), id)
+	if err != nil {
+		return fmt.Errorf("cannot get the details of %s: %s", id, err)
+	}
+	matches, err := cachedMatches(id)
+	if err != nil {
+		return err
+	}
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(cveResult{CVE: cve, CachedScans: matches})
+	}
+	report.WriteCVE(dockerCli.Out(), cve, matches)
+	return nil
+}
+
+// cachedMatches returns the cached scans reporting the CVE, the most recent first. Only the scans made with a JSON
+// output, like the ones filtered or exported by the plugin, can be searched.
+func cachedMatches(id string) ([]report.CachedMatch, error) {
+	entries, err := cache.New().Entries()
+	if err != nil {
+		return nil, err
+	}
+	matches := []report.CachedMatch{}
+	for _, entry := range entries {
+		scanReport, err := report.Parse(entry.Output)
+		if err != nil {
+			continue
+		}
+		var findings []report.Vulnerability
+		for _, vuln := range scanReport.Vulnerabilities {
+			if report.MatchesID(vuln, id) {
+				findings = append(findings, vuln)
+			}
+		}
+		if len(findings) == 0 {
+			continue
+		}
+		image := entry.Image
+		if image == "" {
+			image = scanReport.Path
+		}
+		matches = append(matches, report.CachedMatch{Image: image, ScannedAt: entry.CreatedAt, Findings: findings})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ScannedAt.After(matches[j].ScannedAt)
+	})
+	return matches, nil
+}
@@ -0,0 +1,46 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/pkg/term"
+	"github.com/docker/scan-cli-plugin/internal"
+	"github.com/docker/scan-cli-plugin/internal/debug"
+	"github.com/docker/scan-cli-plugin/internal/progress"
+)
+
+// enableDebug writes the debug logs to the error stream when --debug is set
+func enableDebug(dockerCli command.Cli, flags options) {
+	if !flags.debug {
+		return
+	}
+	debug.Enable(dockerCli.Err())
+	debug.Log("starting docker scan", "version", internal.Version, "args", strings.Join(debug.Redact(os.Args[1:]), " "))
+}
+
+// startProgress shows a spinner on the terminal while the provider runs, when its output is not printed as it comes.
+// The returned function stops the spinner.
+func startProgress(dockerCli command.Cli, flags options, message string) func() {
+	if flags.debug || !(flags.jsonFormat || needsReport(flags)) || !term.IsTerminal(os.Stderr.Fd()) {
+		return func() {}
+	}
+	return progress.StartSpinner(dockerCli.Err(), message).Stop
+}
@@ -0,0 +1,184 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	jsonOutputFormat = "json"
+
+	// binaryProvider runs the Snyk binary
+	binaryProvider = "binary"
+	// imageProvider runs the Snyk image
+	imageProvider = "image"
+	// trivyProvider runs the Trivy binary instead of Snyk
+	trivyProvider = "trivy"
+	// grypeProvider runs the Grype binary instead of Snyk
+	grypeProvider = "grype"
+	// hubProvider gets the results of the Docker Hub scans instead of scanning locally
+	hubProvider = "hub"
+)
+
+var providerNames = []string{binaryProvider, imageProvider, trivyProvider, grypeProvider, hubProvider}
+
+// applyConfigDefaults sets the flags which are not set on the command line to the defaults
+// of the docker scan configuration file
+func applyConfigDefaults(cmd *cobra.Command, flags *options) error {
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	if err := validateNotifications(conf.Notifications); err != nil {
+		return err
+	}
+	if !flags.noNotify {
+		flags.notifications = conf.Notifications
+	}
+	if !cmd.Flags().Changed("metrics-file") {
+		flags.metricsFile = conf.MetricsFile
+	}
+	if conf.Defaults != nil {
+		if err := applyDefaults(cmd.Flags().Changed, flags, *conf.Defaults); err != nil {
+			return err
+		}
+	}
+	return applyIgnoreFile(flags)
+}
+
+// applyDefaults sets the flags not changed on the command line to the given defaults
+func applyDefaults(changed func(string) bool, flags *options, defaults config.DefaultsConfig) error {
+	if defaults.Provider != "" && !isProviderName(defaults.Provider) {
+		return fmt.Errorf("invalid provider %q in the defaults of the docker scan configuration, expected one of %s",
+			defaults.Provider, strings.Join(providerNames, ", "))
+	}
+	if defaults.Provider != "" && !changed("provider") {
+		flags.provider = defaults.Provider
+	}
+	if defaults.Severity != "" && !changed("severity") {
+		flags.severity = defaults.Severity
+	}
+	if len(defaults.ExcludeCVEs) > 0 && !changed("exclude-cve") {
+		flags.excludedCVEs = defaults.ExcludeCVEs
+	}
+	if len(defaults.NonRuntimePaths) > 0 && !changed("non-runtime-path") {
+		flags.nonRuntimePaths = defaults.NonRuntimePaths
+	}
+	applyResultsDefaults(changed, flags, defaults)
+	applySnykDefaults(changed, flags, defaults)
+	if err := applyTimingDefaults(changed, flags, defaults); err != nil {
+		return err
+	}
+	applyDefaultFormat(changed, flags, defaults.Format)
+	return nil
+}
+
+// applyResultsDefaults sets where the results are recorded besides the output, unless their flags are set
+func applyResultsDefaults(changed func(string) bool, flags *options, defaults config.DefaultsConfig) {
+	if defaults.JSONFile != "" && !changed("json-file") {
+		flags.jsonFile = defaults.JSONFile
+	}
+	if defaults.History && !changed("history") {
+		flags.history = true
+	}
+	if defaults.GitHubSummary && !changed("github-summary") {
+		flags.githubSummary = true
+	}
+}
+
+// applySnykDefaults sets the Snyk organization and project name of the defaults, unless their flags are set
+func applySnykDefaults(changed func(string) bool, flags *options, defaults config.DefaultsConfig) {
+	if defaults.Org != "" && !changed("org") {
+		flags.org = defaults.Org
+	}
+	if defaults.ProjectName != "" && !changed("project-name") {
+		flags.projectName = defaults.ProjectName
+	}
+}
+
+// applyTimingDefaults sets the timeout and the retry policy of the defaults, unless their flags are set
+func applyTimingDefaults(changed func(string) bool, flags *options, defaults config.DefaultsConfig) error {
+	if defaults.Timeout != "" && !changed("timeout") {
+		timeout, err := time.ParseDuration(defaults.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q in the defaults of the docker scan configuration: %s", defaults.Timeout, err)
+		}
+		flags.timeout = timeout
+	}
+	if defaults.Retries != nil && !changed("retries") {
+		flags.retries = *defaults.Retries
+	}
+	if defaults.RetryDelay != "" && !changed("retry-delay") {
+		delay, err := time.ParseDuration(defaults.RetryDelay)
+		if err != nil {
+			return fmt.Errorf("invalid retry delay %q in the defaults of the docker scan configuration: %s", defaults.RetryDelay, err)
+		}
+		flags.retryDelay = delay
+	}
+	return nil
+}
+
+// applyDefaultFormat sets the default output format, unless any output flag is set
+func applyDefaultFormat(changed func(string) bool, flags *options, format string) {
+	if format == "" || changed("json") || changed("format") || changed("quiet") || changed("summary") {
+		return
+	}
+	if format == jsonOutputFormat {
+		flags.jsonFormat = true
+	} else {
+		flags.format = format
+	}
+}
+
+// selectedProvider returns the image provider with --isolated, the provider of the --provider flag, otherwise the one of
+// the docker scan configuration, empty to run Snyk as chosen automatically
+func selectedProvider(flags options, conf config.Config) string {
+	if flags.isolated {
+		return imageProvider
+	}
+	if flags.provider != "" || conf.Defaults == nil {
+		return flags.provider
+	}
+	return conf.Defaults.Provider
+}
+
+// usesSnyk returns true unless another provider than Snyk is selected
+func usesSnyk(provider string) bool {
+	return provider != trivyProvider && provider != grypeProvider && provider != hubProvider
+}
+
+func isProviderName(name string) bool {
+	for _, provider := range providerNames {
+		if provider == name {
+			return true
+		}
+	}
+	return false
+}
+
+func validateProvider(flags options) error {
+	if flags.provider != "" && !isProviderName(flags.provider) {
+		return fmt.Errorf("--provider takes only %s values", strings.Join(providerNames, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/archive"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type diffOptions struct {
+	dockerFilePath string
+	provider       string
+	severity       string
+	noCache        bool
+	jsonFormat     bool
+}
+
+// diffResult lists the vulnerabilities added, removed and kept by an image compared to another one
+type diffResult struct {
+	Base      string                 `json:"base"`
+	Target    string                 `json:"target"`
+	Added     []report.Vulnerability `json:"added"`
+	Removed   []report.Vulnerability `json:"removed"`
+	Unchanged []report.Vulnerability `json:"unchanged"`
+}
+
+func newDiffCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags diffOptions
+	cmd := &cobra.Command{
+		Use:   "diff [OPTIONS] IMAGE|REPORT IMAGE|REPORT",
+		Short: "Compare the vulnerabilities of an image, like a new release, to the ones of another image or of JSON reports",
+		Args:  cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiff(ctx, dockerCli, flags, args[0], args[1])
+		},
+	}
+	cmd.Flags().StringVarP(&flags.dockerFilePath, "file", "f", "", "Dockerfile associated with the images, provides more detailed results")
+	cmd.Flags().StringVar(&flags.provider, "provider", "", "Scan provider, overrides the provider of the configuration defaults (binary|image|trivy|grype|hub)")
+	cmd.Flags().StringVar(&flags.severity, "severity", "", "Only report vulnerabilities of provided level or higher (low|medium|high)")
+	cmd.Flags().BoolVar(&flags.noCache, "no-cache", false, "Scan the images again instead of using the cached results of previous scans")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the differences in JSON format")
+	return cmd
+}
+
+func runDiff(ctx context.Context, dockerCli command.Cli, flags diffOptions, base, target string) error {
+	baseReport, err := diffScan(ctx, dockerCli, flags, base)
+	if err != nil {
+		return err
+	}
+	targetReport, err := diffScan(ctx, dockerCli, flags, target)
+	if err != nil {
+		return err
+	}
+	added, removed := report.Diff(baseReport, targetReport)
+	unchanged := report.Unchanged(baseReport, targetReport)
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diffResult{
+			Base:      base,
+			Target:    target,
+			Added:     nonNil(added),
+			Removed:   nonNil(removed),
+			Unchanged: nonNil(unchanged),
+		})
+	}
+	report.WriteImageDiff(dockerCli.Out(), base, target, added, removed, unchanged)
+	return nil
+}
+
+// diffScan scans the image, or reuses the cached results of a previous scan with the same flags. A JSON report saved
+// with --json-file, compressed or not, is read instead of scanning an image.
+func diffScan(ctx context.Context, dockerCli command.Cli, flags diffOptions, image string) (report.Report, error) {
+	if info, err := os.Stat(image); err == nil && !info.IsDir() {
+		return readReport(image)
+	}
+	opts := options{
+		dockerFilePath: flags.dockerFilePath,
+		provider:       flags.provider,
+		severity:       flags.severity,
+		noCache:        flags.noCache,
+		jsonFormat:     true,
+	}
+	providerOut := bytes.NewBuffer(nil)
+	scanCache := newScanCache(opts, providerOut)
+	scanProvider, err := configureProvider(ctx, dockerCli, opts, hubAuthConfig(dockerCli), provider.WithStreams(scanCache.writer(), dockerCli.Err()))
+	if err != nil {
+		return report.Report{}, err
+	}
+	// vulnerabilities are expected, provider failures are reported in the JSON output
+	if err := scanCache.scan(ctx, dockerCli, opts, scanProvider, image); err != nil &&
+		!provider.IsVulnerabilitiesFoundError(err) && !provider.IsProviderFailedError(err) {
+		return report.Report{}, fmt.Errorf("cannot scan %s: %s", image, err)
+	}
+	scanReport, err := report.Parse(providerOut.Bytes())
+	if err != nil {
+		return report.Report{}, fmt.Errorf("cannot scan %s: %s", image, err)
+	}
+	return scanReport, nil
+}
+
+// readReport reads a JSON report of docker scan, compressed with zstd or not
+func readReport(path string) (report.Report, error) {
+	document, err := archive.ReadFile(path)
+	if err != nil {
+		return report.Report{}, err
+	}
+	scanReport, err := report.Parse(document)
+	if err != nil {
+		return report.Report{}, fmt.Errorf("%s is not a JSON report of docker scan: %s", path, err)
+	}
+	return scanReport, nil
+}
@@ -0,0 +1,86 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/context/docker"
+	"github.com/spf13/cobra"
+)
+
+// selectContext makes the docker CLI use the engine of the context given with docker scan --context, like
+// docker --context but without changing the current context of the CLI. It must be called before the docker CLI is
+// initialized, and returns whether a context was selected.
+func selectContext(cmd *cobra.Command) (bool, error) {
+	name, err := cmd.Flags().GetString("context")
+	if err != nil || name == "" {
+		return false, nil
+	}
+	global := cmd.Root().Flags().Lookup("context")
+	if global == nil {
+		return false, fmt.Errorf("--context is not supported by this docker CLI, use DOCKER_CONTEXT=%s instead", name)
+	}
+	if global.Changed && global.Value.String() != name {
+		return false, fmt.Errorf("conflicting contexts: docker --context %s and docker scan --context %s", global.Value.String(), name)
+	}
+	if host := cmd.Root().Flags().Lookup("host"); host != nil && host.Changed {
+		return false, fmt.Errorf("conflicting options: either specify --host or --context")
+	}
+	return true, global.Value.Set(name)
+}
+
+// exportDockerHost gives the engine of the selected context to the providers running on the host, which reach the
+// daemon through DOCKER_HOST, with the TLS client certificates of the context through DOCKER_CERT_PATH and
+// DOCKER_TLS_VERIFY. The providers don't support the ssh connection helper of the docker CLI, such contexts are
+// rejected.
+func exportDockerHost(dockerCli command.Cli) error {
+	endpoint := dockerCli.DockerEndpoint()
+	if endpoint.Host == "" {
+		return nil
+	}
+	if strings.HasPrefix(endpoint.Host, "ssh://") {
+		return fmt.Errorf("context %q reaches its engine through ssh, which the providers can't use: "+
+			"forward the engine socket and create a context with a tcp or unix endpoint instead", dockerCli.CurrentContext())
+	}
+	variables := map[string]string{"DOCKER_HOST": endpoint.Host, "DOCKER_CERT_PATH": "", "DOCKER_TLS_VERIFY": ""}
+	if endpoint.TLSData != nil {
+		// the context store keeps the ca.pem, cert.pem and key.pem files DOCKER_CERT_PATH expects
+		tlsPath := dockerCli.ContextStore().GetStorageInfo(dockerCli.CurrentContext()).TLSPath
+		variables["DOCKER_CERT_PATH"] = filepath.Join(tlsPath, docker.DockerEndpoint)
+		if !endpoint.SkipTLSVerify {
+			variables["DOCKER_TLS_VERIFY"] = "1"
+		}
+	}
+	for name, value := range variables {
+		// the variables of the environment don't apply to the engine of the context
+		if value == "" {
+			if err := os.Unsetenv(name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
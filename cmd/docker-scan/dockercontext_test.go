@@ -0,0 +1,188 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/context"
+	"github.com/docker/cli/cli/context/docker"
+	"github.com/docker/cli/cli/context/store"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+)
+
+// newContextCommands returns the scan command under a docker root command with the global --context and --host flags
+func newContextCommands() (*cobra.Command, *cobra.Command) {
+	root := &cobra.Command{Use: "docker"}
+	root.Flags().String("context", "", "")
+	root.Flags().StringSlice("host", nil, "")
+	scan := &cobra.Command{Use: "scan"}
+	scan.Flags().String("context", "", "")
+	root.AddCommand(scan)
+	return root, scan
+}
+
+func TestSelectContext(t *testing.T) {
+	testCases := []struct {
+		name          string
+		globalContext string
+		host          string
+		scanContext   string
+		selected      bool
+		expectedErr   string
+	}{
+		{name: "no context"},
+		{name: "scan context", scanContext: "prod-1", selected: true},
+		{name: "same contexts", globalContext: "prod-1", scanContext: "prod-1", selected: true},
+		{name: "global context only", globalContext: "prod-1"},
+		{
+			name:          "conflicting contexts",
+			globalContext: "prod-1",
+			scanContext:   "prod-2",
+			expectedErr:   "conflicting contexts: docker --context prod-1 and docker scan --context prod-2",
+		},
+		{
+			name:        "conflicting host",
+			host:        "tcp://prod-1:2376",
+			scanContext: "prod-2",
+			expectedErr: "conflicting options: either specify --host or --context",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			root, scan := newContextCommands()
+			if testCase.globalContext != "" {
+				assert.NilError(t, root.Flags().Set("context", testCase.globalContext))
+			}
+			if testCase.host != "" {
+				assert.NilError(t, root.Flags().Set("host", testCase.host))
+			}
+			if testCase.scanContext != "" {
+				assert.NilError(t, scan.Flags().Set("context", testCase.scanContext))
+			}
+			selected, err := selectContext(scan)
+			if testCase.expectedErr != "" {
+				assert.Error(t, err, testCase.expectedErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, selected, testCase.selected)
+			if testCase.selected {
+				assert.Equal(t, root.Flags().Lookup("context").Value.String(), testCase.scanContext)
+			}
+		})
+	}
+}
+
+func TestSelectContextWithoutGlobalFlag(t *testing.T) {
+	root := &cobra.Command{Use: "docker"}
+	scan := &cobra.Command{Use: "scan"}
+	scan.Flags().String("context", "", "")
+	root.AddCommand(scan)
+	assert.NilError(t, scan.Flags().Set("context", "prod-1"))
+
+	_, err := selectContext(scan)
+	assert.Error(t, err, "--context is not supported by this docker CLI, use DOCKER_CONTEXT=prod-1 instead")
+}
+
+// fakeContextCli is a docker CLI connected to the endpoint of a context
+type fakeContextCli struct {
+	command.Cli
+	endpoint docker.Endpoint
+	tlsPath  string
+}
+
+func (c fakeContextCli) DockerEndpoint() docker.Endpoint {
+	return c.endpoint
+}
+
+func (c fakeContextCli) CurrentContext() string {
+	return "prod-1"
+}
+
+func (c fakeContextCli) ContextStore() store.Store {
+	return fakeContextStore{tlsPath: c.tlsPath}
+}
+
+type fakeContextStore struct {
+	store.Store
+	tlsPath string
+}
+
+func (s fakeContextStore) GetStorageInfo(string) store.StorageInfo {
+	return store.StorageInfo{TLSPath: s.tlsPath}
+}
+
+func TestExportDockerHost(t *testing.T) {
+	tlsPath := filepath.Join("contexts", "tls", "0123")
+	testCases := []struct {
+		name      string
+		endpoint  docker.Endpoint
+		host      string
+		certPath  string
+		tlsVerify string
+	}{
+		{
+			name:     "unix socket",
+			endpoint: docker.Endpoint{EndpointMeta: docker.EndpointMeta{Host: "unix:///var/run/docker.sock"}},
+			host:     "unix:///var/run/docker.sock",
+		},
+		{
+			name: "tls",
+			endpoint: docker.Endpoint{
+				EndpointMeta: docker.EndpointMeta{Host: "tcp://prod-1:2376"},
+				TLSData:      &context.TLSData{CA: []byte("ca"), Cert: []byte("cert"), Key: []byte("key")},
+			},
+			host:      "tcp://prod-1:2376",
+			certPath:  filepath.Join(tlsPath, "docker"),
+			tlsVerify: "1",
+		},
+		{
+			name: "tls without verification",
+			endpoint: docker.Endpoint{
+				EndpointMeta: docker.EndpointMeta{Host: "tcp://prod-1:2376", SkipTLSVerify: true},
+				TLSData:      &context.TLSData{Cert: []byte("cert"), Key: []byte("key")},
+			},
+			host:     "tcp://prod-1:2376",
+			certPath: filepath.Join(tlsPath, "docker"),
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Setenv("DOCKER_HOST", "tcp://localhost:2375")
+			t.Setenv("DOCKER_CERT_PATH", "/home/user/.docker")
+			t.Setenv("DOCKER_TLS_VERIFY", "1")
+
+			assert.NilError(t, exportDockerHost(fakeContextCli{endpoint: testCase.endpoint, tlsPath: tlsPath}))
+			assert.Equal(t, os.Getenv("DOCKER_HOST"), testCase.host)
+			assert.Equal(t, os.Getenv("DOCKER_CERT_PATH"), testCase.certPath)
+			assert.Equal(t, os.Getenv("DOCKER_TLS_VERIFY"), testCase.tlsVerify)
+		})
+	}
+}
+
+func TestExportDockerHostRejectsSSH(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "")
+	endpoint := docker.Endpoint{EndpointMeta: docker.EndpointMeta{Host: "ssh://user@prod-1"}}
+	err := exportDockerHost(fakeContextCli{endpoint: endpoint})
+	assert.ErrorContains(t, err, `context "prod-1" reaches its engine through ssh`)
+	assert.Equal(t, os.Getenv("DOCKER_HOST"), "")
+}
@@ -0,0 +1,83 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/dockerfile"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+func validateBuildArgs(flags options) error {
+	if len(flags.buildArgs) > 0 && flags.dockerFilePath == "" {
+		return fmt.Errorf("--file flag is mandatory to use --build-arg flag")
+	}
+	for _, buildArg := range flags.buildArgs {
+		if strings.HasPrefix(buildArg, "=") || buildArg == "" {
+			return fmt.Errorf("invalid build arg %q, expected NAME=VALUE or NAME", buildArg)
+		}
+	}
+	return nil
+}
+
+// parseDockerfile reads the stages of the Dockerfile given with --file, resolving its ARG instructions with the
+// --build-arg values. Like docker build, a build arg without value takes the value of the environment variable.
+func parseDockerfile(flags options) ([]dockerfile.Stage, error) {
+	buildArgs := map[string]string{}
+	for _, buildArg := range flags.buildArgs {
+		if index := strings.Index(buildArg, "="); index >= 0 {
+			buildArgs[buildArg[:index]] = buildArg[index+1:]
+		} else if value, ok := os.LookupEnv(buildArg); ok {
+			buildArgs[buildArg] = value
+		}
+	}
+	return dockerfile.ParseFile(flags.dockerFilePath, buildArgs)
+}
+
+// dockerfileLocator returns the function locating the instructions the provider attributed the vulnerabilities to in
+// the Dockerfile given with --file, the vulnerabilities of the files copied from a previous stage being located in
+// this stage. It is nil without Dockerfile.
+func dockerfileLocator(flags options) func(instruction string) (string, int, bool) {
+	if flags.dockerFilePath == "" {
+		return nil
+	}
+	stages, err := parseDockerfile(flags)
+	if err != nil {
+		return nil
+	}
+	return func(instruction string) (string, int, bool) {
+		location, ok := dockerfile.Locate(stages, instruction)
+		return location.Stage, location.Line, ok
+	}
+}
+
+// locateInDockerfile sets the stage and the line of the Dockerfile instructions of the vulnerabilities
+func locateInDockerfile(flags options, scanReport *report.Report) {
+	locate := dockerfileLocator(flags)
+	if locate == nil {
+		return
+	}
+	for i, vuln := range scanReport.Vulnerabilities {
+		if stage, line, ok := locate(vuln.DockerfileInstruction); ok {
+			scanReport.Vulnerabilities[i].DockerfileStage = stage
+			scanReport.Vulnerabilities[i].DockerfileLine = line
+		}
+	}
+}
@@ -0,0 +1,323 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/exploit"
+	"github.com/docker/scan-cli-plugin/internal/hub"
+	"github.com/docker/scan-cli-plugin/internal/nvd"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
+	"github.com/spf13/cobra"
+)
+
+// doctorTimeout bounds each connection attempt, an unreachable endpoint often hanging instead of being refused
+const doctorTimeout = 10 * time.Second
+
+// doctorResult tells the addresses of the host and whether each endpoint called by the plugin can be reached
+type doctorResult struct {
+	IPv4      bool             `json:"ipv4"`
+	IPv6      bool             `json:"ipv6"`
+	Endpoints []endpointResult `json:"endpoints"`
+	Hints     []string         `json:"hints,omitempty"`
+}
+
+// endpointResult is the connectivity to an endpoint, through its proxy if any: the addresses it resolves to, the
+// connections over IPv4 and IPv6, and an HTTPS request
+type endpointResult struct {
+	Name  string   `json:"name"`
+	URL   string   `json:"url"`
+	Proxy string   `json:"proxy,omitempty"`
+	Host  string   `json:"host"`
+	IPv4  []string `json:"ipv4,omitempty"`
+	IPv6  []string `json:"ipv6,omitempty"`
+	DNS   string   `json:"dns"`
+	TCPv4 string   `json:"tcpv4,omitempty"`
+	TCPv6 string   `json:"tcpv6,omitempty"`
+	HTTPS string   `json:"https"`
+	OK    bool     `json:"ok"`
+	Hints []string `json:"hints,omitempty"`
+}
+
+func newDoctorCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var jsonFormat bool
+	cmd := &cobra.Command{
+		Use:   "doctor [OPTIONS]",
+		Short: "Diagnose the connectivity to the endpoints called by docker scan, over IPv4, IPv6 and the proxy",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exitCodeError(runDoctor(ctx, dockerCli, jsonFormat), options{
+				exitCodeOnVuln:  defaultExitCodeOnVuln,
+				exitCodeOnError: defaultExitCodeOnError,
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&jsonFormat, "json", false, "Output the diagnostics in JSON format")
+	return cmd
+}
+
+func runDoctor(ctx context.Context, dockerCli command.Cli, jsonFormat bool) error {
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	httpClient, err := proxy.NewHTTPClient(conf.CACert)
+	if err != nil {
+		return err
+	}
+	result := doctorResult{}
+	result.IPv4, result.IPv6 = hostAddresses()
+	failed := 0
+	for _, endpoint := range doctorEndpoints(conf) {
+		checked := checkEndpoint(ctx, httpClient, result, endpoint[0], endpoint[1])
+		if !checked.OK {
+			failed++
+		}
+		result.Endpoints = append(result.Endpoints, checked)
+	}
+	if !result.IPv4 && result.IPv6 && !bridgeHasIPv6(ctx, dockerCli) {
+		result.Hints = append(result.Hints, "the Snyk container runs on the default bridge network of the engine, which has no IPv6: "+
+			`enable "ipv6" with a "fixed-cidr-v6" in the daemon configuration, or install the Snyk binary`)
+	}
+	if jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		writeDoctorResult(dockerCli.Out(), result)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of the %d endpoints can't be reached", failed, len(result.Endpoints))
+	}
+	return nil
+}
+
+// doctorEndpoints returns the names and URLs of the endpoints called by the plugin and the provider
+func doctorEndpoints(conf config.Config) [][2]string {
+	endpoints := [][2]string{
+		{"Docker Hub", hub.GetInstance().APIHubBaseURL},
+		{"Snyk API", provider.SnykAPI()},
+	}
+	if artifact, err := provider.SnykArtifact(runtime.GOOS, runtime.GOARCH); err == nil {
+		endpoints = append(endpoints, [2]string{"Snyk downloads", provider.ReleaseURL(provider.SnykVersion, artifact)})
+	}
+	endpoints = append(endpoints, [2]string{"NVD", nvd.DefaultURL}, [2]string{"EPSS", exploit.DefaultEPSSURL},
+		[2]string{"CISA KEV", exploit.DefaultKEVURL})
+	if conf.Results != nil && conf.Results.URL != "" {
+		endpoints = append(endpoints, [2]string{"Results collector", conf.Results.URL})
+	}
+	return endpoints
+}
+
+// hostAddresses tells whether the host has IPv4 and IPv6 addresses other than the loopback and link-local ones
+func hostAddresses() (bool, bool) {
+	addresses, err := net.InterfaceAddrs()
+	if err != nil {
+		return false, false
+	}
+	var ipv4, ipv6 bool
+	for _, address := range addresses {
+		ipNet, ok := address.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			ipv4 = true
+		} else {
+			ipv6 = true
+		}
+	}
+	return ipv4, ipv6
+}
+
+func bridgeHasIPv6(ctx context.Context, dockerCli command.Cli) bool {
+	bridge, err := dockerCli.Client().NetworkInspect(ctx, "bridge", types.NetworkInspectOptions{})
+	// without an engine the provider can't run in a container
+	return err != nil || bridge.EnableIPv6
+}
+
+func checkEndpoint(ctx context.Context, httpClient *http.Client, host doctorResult, name, rawURL string) endpointResult {
+	result := endpointResult{Name: name, URL: rawURL}
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		result.HTTPS = err.Error()
+		return result
+	}
+	dialed := target
+	proxyURL, err := proxy.URL(target)
+	if err != nil {
+		result.HTTPS = fmt.Sprintf("invalid proxy: %s", err)
+		result.Hints = append(result.Hints, "fix the proxy URL of HTTPS_PROXY or HTTP_PROXY")
+		return result
+	}
+	if proxyURL != nil {
+		result.Proxy = proxyURL.Redacted()
+		dialed = proxyURL
+	}
+	hostname, port := dialedHost(dialed)
+	result.Host = net.JoinHostPort(hostname, port)
+	resolveEndpoint(ctx, &result, hostname)
+	result.TCPv4 = dialEndpoint(ctx, "tcp4", result.IPv4, port)
+	result.TCPv6 = dialEndpoint(ctx, "tcp6", result.IPv6, port)
+	result.HTTPS = requestEndpoint(ctx, httpClient, rawURL)
+	result.OK = strings.HasPrefix(result.HTTPS, "HTTP ")
+	result.Hints = append(result.Hints, endpointHints(host, result)...)
+	return result
+}
+
+// dialedHost returns the host connected to in the ASCII form used by DNS, and its port
+func dialedHost(dialed *url.URL) (string, string) {
+	port := dialed.Port()
+	if port == "" {
+		port = "443"
+		if dialed.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return proxy.ASCIIHost(dialed.Hostname()), port
+}
+
+func resolveEndpoint(ctx context.Context, result *endpointResult, hostname string) {
+	resolveCtx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+	addresses, err := net.DefaultResolver.LookupIPAddr(resolveCtx, hostname)
+	if err != nil {
+		result.DNS = err.Error()
+		return
+	}
+	for _, address := range addresses {
+		if address.IP.To4() != nil {
+			result.IPv4 = append(result.IPv4, address.IP.String())
+		} else {
+			result.IPv6 = append(result.IPv6, address.IP.String())
+		}
+	}
+	result.DNS = fmt.Sprintf("%d IPv4 and %d IPv6 addresses", len(result.IPv4), len(result.IPv6))
+}
+
+// dialEndpoint connects to the first address of the family, the result is empty when the host has no such address
+func dialEndpoint(ctx context.Context, network string, addresses []string, port string) string {
+	if len(addresses) == 0 {
+		return ""
+	}
+	dialer := net.Dialer{Timeout: doctorTimeout}
+	conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addresses[0], port))
+	if err != nil {
+		return err.Error()
+	}
+	conn.Close() //nolint:errcheck
+	return "ok"
+}
+
+// requestEndpoint makes an HTTPS request, any HTTP status proving the endpoint can be reached
+func requestEndpoint(ctx context.Context, httpClient *http.Client, rawURL string) string {
+	requestCtx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(requestCtx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return err.Error()
+	}
+	client := *httpClient
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err.Error()
+	}
+	resp.Body.Close() //nolint:errcheck
+	return fmt.Sprintf("HTTP %d", resp.StatusCode)
+}
+
+// endpointHints tells what to check to fix the connectivity to an endpoint which can't be reached
+func endpointHints(host doctorResult, result endpointResult) []string {
+	target := "the endpoint"
+	if result.Proxy != "" {
+		target = "the proxy"
+	}
+	switch {
+	case result.OK:
+		return nil
+	case len(result.IPv4) == 0 && len(result.IPv6) == 0:
+		return []string{fmt.Sprintf("%s %s can't be resolved, check the DNS configuration", target, result.Host)}
+	case !host.IPv4 && len(result.IPv6) == 0:
+		return []string{fmt.Sprintf("%s %s has no IPv6 address and this host has no IPv4 address: use a proxy reachable "+
+			"over IPv6 with HTTPS_PROXY, or a DNS64 and NAT64 gateway", target, result.Host)}
+	case result.TCPv4 != "ok" && result.TCPv6 != "ok":
+		return []string{fmt.Sprintf("the connection to %s %s failed, check the firewall rules or use a proxy with HTTPS_PROXY", target, result.Host)}
+	case strings.Contains(result.HTTPS, "x509") || strings.Contains(result.HTTPS, "certificate"):
+		return []string{"the certificate of the endpoint isn't trusted: when a proxy or a firewall inspects the TLS " +
+			"traffic, add its CA certificate with the caCert configuration"}
+	case result.Proxy != "":
+		return []string{fmt.Sprintf("the request through the proxy %s failed, check the proxy rules and credentials", result.Proxy)}
+	}
+	return []string{"the request failed although the connection succeeded, check the firewall rules"}
+}
+
+func writeDoctorResult(out io.Writer, result doctorResult) {
+	fmt.Fprintf(out, "Host addresses: IPv4 %s, IPv6 %s\n", yesNo(result.IPv4), yesNo(result.IPv6))
+	for _, endpoint := range result.Endpoints {
+		fmt.Fprintf(out, "\n%s (%s)\n", endpoint.Name, endpoint.URL)
+		proxyName := "none"
+		if endpoint.Proxy != "" {
+			proxyName = endpoint.Proxy
+		}
+		fmt.Fprintf(out, "  Proxy:  %s\n", proxyName)
+		fmt.Fprintf(out, "  Host:   %s\n", endpoint.Host)
+		fmt.Fprintf(out, "  DNS:    %s\n", endpoint.DNS)
+		fmt.Fprintf(out, "  IPv4:   %s\n", connectionStatus(endpoint.TCPv4))
+		fmt.Fprintf(out, "  IPv6:   %s\n", connectionStatus(endpoint.TCPv6))
+		fmt.Fprintf(out, "  HTTPS:  %s\n", endpoint.HTTPS)
+		for _, hint := range endpoint.Hints {
+			fmt.Fprintf(out, "  Hint:   %s\n", hint)
+		}
+	}
+	for _, hint := range result.Hints {
+		fmt.Fprintf(out, "\nHint: %s\n", hint)
+	}
+}
+
+func connectionStatus(status string) string {
+	if status == "" {
+		return "no address"
+	}
+	return status
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
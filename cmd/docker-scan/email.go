@@ -0,0 +1,97 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/email"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+const defaultSMTPPort = 587
+
+// sendEmailReport sends the HTML report of the scan to the distribution list of the SMTP configuration
+func sendEmailReport(dockerCli command.Cli, flags options, results scanResults) error {
+	if !flags.email || results.report == nil {
+		return nil
+	}
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	if conf.SMTP == nil || conf.SMTP.Host == "" || conf.SMTP.From == "" || len(conf.SMTP.To) == 0 {
+		return fmt.Errorf("--email requires the host, from and to fields to be set in the smtp section of the docker scan configuration file")
+	}
+	findings := results.findings()
+	html := bytes.NewBuffer(nil)
+	if err := report.WriteHTML(html, results.ref, time.Now(), findings); err != nil {
+		return err
+	}
+	server, err := smtpServer(flags, conf)
+	if err != nil {
+		return err
+	}
+	message := email.Message{
+		From:    conf.SMTP.From,
+		To:      conf.SMTP.To,
+		Subject: fmt.Sprintf("docker scan report of %s: %d findings", results.ref, len(findings)),
+		HTML:    html.Bytes(),
+	}
+	if flags.format == pdfFormat {
+		document := bytes.NewBuffer(nil)
+		if err := report.WritePDF(document, results.ref, time.Now(), findings); err != nil {
+			return err
+		}
+		message.Attachments = append(message.Attachments, email.Attachment{
+			Name:        "scan-report.pdf",
+			ContentType: "application/pdf",
+			Content:     document.Bytes(),
+		})
+	}
+	if err := email.Send(server, message); err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Err(), "Scan report sent to %d recipient(s)\n", len(message.To))
+	return nil
+}
+
+// smtpServer returns the SMTP server of the configuration, its password is read from DOCKER_SCAN_SMTP_PASSWORD
+func smtpServer(flags options, conf config.Config) (email.Server, error) {
+	server := email.Server{
+		Host:     conf.SMTP.Host,
+		Port:     conf.SMTP.Port,
+		Username: conf.SMTP.Username,
+		Password: os.Getenv("DOCKER_SCAN_SMTP_PASSWORD"),
+	}
+	if server.Port == 0 {
+		server.Port = defaultSMTPPort
+	}
+	if caCert := caCertPath(flags, conf); caCert != "" {
+		var err error
+		if server.RootCAs, err = proxy.CertPool(caCert); err != nil {
+			return email.Server{}, err
+		}
+	}
+	return server, nil
+}
@@ -0,0 +1,110 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/misconfig"
+	"github.com/docker/scan-cli-plugin/internal/policy"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type engineOptions struct {
+	severity   string
+	policy     string
+	jsonFormat bool
+}
+
+// engineResult holds the configuration issues of the engine and the policy evaluation
+type engineResult struct {
+	Engine string                 `json:"engine"`
+	Issues []report.Vulnerability `json:"issues"`
+	Policy []policy.Result        `json:"policy,omitempty"`
+}
+
+func newEngineCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags engineOptions
+	cmd := &cobra.Command{
+		Use:   "engine [OPTIONS]",
+		Short: "Audit the configuration of the Docker engine against the CIS Docker Benchmark",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exitCodeError(runEngineAudit(ctx, dockerCli, flags), options{
+				exitCodeOnVuln:  defaultExitCodeOnVuln,
+				exitCodeOnError: defaultExitCodeOnError,
+			})
+		},
+	}
+	cmd.Flags().StringVar(&flags.severity, "severity", "", "Only report issues of provided level or higher (low|medium|high)")
+	cmd.Flags().StringVar(&flags.policy, "policy", "", "Evaluate the issues against the rules of a policy file, the exit code follows the policy evaluation")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the issues in JSON format")
+	return cmd
+}
+
+// runEngineAudit reports the configuration issues of the engine, and fails when issues are found or when the policy fails
+func runEngineAudit(ctx context.Context, dockerCli command.Cli, flags engineOptions) error {
+	if flags.severity != "" && flags.severity != "low" && flags.severity != "medium" && flags.severity != "high" {
+		return fmt.Errorf("--severity takes only 'low', 'medium' or 'high' values")
+	}
+	var enginePolicy *policy.Policy
+	if flags.policy != "" {
+		loaded, err := policy.Load(flags.policy)
+		if err != nil {
+			return err
+		}
+		enginePolicy = &loaded
+	}
+	info, err := dockerCli.Client().Info(ctx)
+	if err != nil {
+		return err
+	}
+	result := engineResult{Engine: info.Name, Issues: misconfig.CheckEngineInfo(info)}
+	if flags.severity != "" {
+		result.Issues = report.Filter(report.Report{Vulnerabilities: result.Issues}, report.AtLeast(flags.severity)).Vulnerabilities
+	}
+	if enginePolicy != nil {
+		result.Policy = policy.Evaluate(*enginePolicy, policy.Input{Findings: result.Issues})
+	}
+	if err := writeEngineResult(dockerCli, flags, result); err != nil {
+		return err
+	}
+	if (enginePolicy != nil && !policy.Passed(result.Policy)) || (enginePolicy == nil && len(result.Issues) > 0) {
+		return provider.NewVulnerabilitiesFoundError()
+	}
+	return nil
+}
+
+func writeEngineResult(dockerCli command.Cli, flags engineOptions, result engineResult) error {
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	fmt.Fprintf(dockerCli.Out(), "Auditing the Docker engine %s\n", result.Engine)
+	report.WriteFindings(dockerCli.Out(), "Engine configuration issues", result.Issues)
+	if result.Policy != nil {
+		policy.WriteResults(dockerCli.Out(), result.Policy)
+	}
+	return nil
+}
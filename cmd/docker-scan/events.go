@@ -0,0 +1,56 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// streamsEvents returns true if the steps of the scan are streamed as newline-delimited JSON with --format ndjson
+func streamsEvents(flags options) bool {
+	return flags.format == ndjsonFormat
+}
+
+// emitEvents writes the events to the output as soon as they happen with --format ndjson. A consumer closing the
+// output doesn't stop the scan, whose exit code still tells its result.
+func emitEvents(dockerCli command.Cli, flags options, events ...report.Event) {
+	if !streamsEvents(flags) {
+		return
+	}
+	for _, event := range events {
+		if err := report.WriteEvent(dockerCli.Out(), event); err != nil {
+			return
+		}
+	}
+}
+
+// scanStartedEvent is the first event of the scan of the image
+func scanStartedEvent(flags options, ref string) report.Event {
+	provider := flags.provider
+	if provider == "" {
+		provider = "snyk"
+	}
+	return report.Event{Type: report.ScanStartedEvent, Image: imageName(flags, ref), Provider: provider}
+}
+
+// writeSummaryEvent writes the last event of the scan, the findings having been streamed as they were found
+func writeSummaryEvent(out io.Writer, results scanResults) error {
+	return report.WriteEvent(out, report.SummaryEventOf(results.ref, results.findings()))
+}
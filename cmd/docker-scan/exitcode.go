@@ -0,0 +1,112 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+)
+
+const (
+	defaultExitCodeOnVuln  = 1
+	defaultExitCodeOnError = 2
+	// quotaExceededExitCode is returned when the scan provider refuses the scan because the test limit of the account
+	// is reached, so that CI tells it from vulnerabilities or failures
+	quotaExceededExitCode = 3
+	// binaryNotFoundExitCode is returned when the binary of the scan provider can't be found
+	binaryNotFoundExitCode = 4
+	// notAuthenticatedExitCode is returned when the credentials to Docker Hub or to the scan provider are missing
+	// or refused
+	notAuthenticatedExitCode = 5
+	// rateLimitedExitCode is returned when Docker Hub rate limits the requests
+	rateLimitedExitCode = 6
+	// imagePullFailedExitCode is returned when the image of the scan provider can't be pulled
+	imagePullFailedExitCode = 7
+	// invalidFlagsExitCode matches the exit code used by the Docker CLI for invalid flags
+	invalidFlagsExitCode = 125
+)
+
+func validateExitCodes(flags options) error {
+	if flags.exitCodeOnVuln < 0 || flags.exitCodeOnVuln > 125 {
+		return cli.StatusError{
+			Status:     "--exit-code-on-vuln takes a value between 0 and 125",
+			StatusCode: invalidFlagsExitCode,
+		}
+	}
+	if flags.exitCodeOnError < 1 || flags.exitCodeOnError > 125 {
+		return cli.StatusError{
+			Status:     "--exit-code-on-error takes a value between 1 and 125",
+			StatusCode: invalidFlagsExitCode,
+		}
+	}
+	return nil
+}
+
+// exitCodeError converts the result of a command to the exit code contract of the plugin:
+// 0 if no vulnerabilities were found, --exit-code-on-vuln if some were found
+// and --exit-code-on-error on any failure, except when the quota of the provider is exceeded or the failure is
+// classified, in which case the error message tells how to solve it
+func exitCodeError(err error, flags options) error {
+	switch {
+	case err == nil:
+		return nil
+	case provider.IsVulnerabilitiesFoundError(err):
+		if flags.exitCodeOnVuln == 0 {
+			return nil
+		}
+		return cli.StatusError{StatusCode: flags.exitCodeOnVuln}
+	case provider.IsQuotaExceededError(err):
+		return cli.StatusError{
+			Status:     fmt.Sprintf("%s\n%s", err, provider.Remediation(err)),
+			StatusCode: quotaExceededExitCode,
+		}
+	case provider.IsProviderFailedError(err):
+		// The provider has already reported the failure on the error stream
+		return cli.StatusError{StatusCode: flags.exitCodeOnError}
+	}
+	if statusErr, ok := err.(cli.StatusError); ok {
+		return statusErr
+	}
+	if exitCode := classifiedExitCode(err); exitCode != 0 {
+		return cli.StatusError{
+			Status:     fmt.Sprintf("%s\n%s", err, provider.Remediation(err)),
+			StatusCode: exitCode,
+		}
+	}
+	return cli.StatusError{
+		Status:     fmt.Sprint(err),
+		StatusCode: flags.exitCodeOnError,
+	}
+}
+
+// classifiedExitCode returns the exit code dedicated to the class of the error, 0 if the error is not classified
+func classifiedExitCode(err error) int {
+	switch {
+	case errors.Is(err, provider.ErrBinaryNotFound):
+		return binaryNotFoundExitCode
+	case errors.Is(err, provider.ErrNotAuthenticated):
+		return notAuthenticatedExitCode
+	case errors.Is(err, provider.ErrRateLimited):
+		return rateLimitedExitCode
+	case errors.Is(err, provider.ErrImagePullFailed):
+		return imagePullFailedExitCode
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type explainOptions struct {
+	dockerFilePath string
+	yaraRules      []string
+	jsonFormat     bool
+}
+
+func newExplainCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags explainOptions
+	cmd := &cobra.Command{
+		Use:   "explain [OPTIONS] IMAGE ID",
+		Short: "Show which analyzer reported a CVE or a finding, and the package, file and layer which triggered it",
+		Args:  cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExplain(ctx, dockerCli, flags, args[0], args[1])
+		},
+	}
+	cmd.Flags().StringVarP(&flags.dockerFilePath, "file", "f", "", "Dockerfile associated with the image, attributes the vulnerabilities to its instructions")
+	cmd.Flags().StringSliceVar(&flags.yaraRules, "yara-rules", nil, "Also scan the image layers for malware with the given YARA rules files (requires yara)")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the explanations in JSON format")
+	return cmd
+}
+
+func runExplain(ctx context.Context, dockerCli command.Cli, flags explainOptions, ref string, id string) error {
+	results, err := explainScan(ctx, dockerCli, flags, ref)
+	if err != nil {
+		return err
+	}
+	explanations := explainResults(results, id)
+	if len(explanations) == 0 {
+		return fmt.Errorf("no finding matches %s in %s", id, ref)
+	}
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(explanations)
+	}
+	report.WriteExplanations(dockerCli.Out(), id, explanations)
+	return nil
+}
+
+// explainScan scans the image with all the analyzers, attributing the vulnerabilities to the image layers
+func explainScan(ctx context.Context, dockerCli command.Cli, flags explainOptions, ref string) (scanResults, error) {
+	scanFlags := options{
+		dockerFilePath: flags.dockerFilePath,
+		yaraRules:      flags.yaraRules,
+		groupBy:        groupByLayer,
+		scopes:         report.Scopes,
+		licenses:       true,
+	}
+	providerOut := bytes.NewBuffer(nil)
+	scanProvider, err := configureProvider(ctx, dockerCli, scanFlags, hubAuthConfig(dockerCli), provider.WithStreams(providerOut, dockerCli.Err()))
+	if err != nil {
+		return scanResults{}, err
+	}
+	analyzers, err := newLayerAnalyzers(scanFlags)
+	if err != nil {
+		return scanResults{}, err
+	}
+	if err := scanProvider.Scan(ref); err != nil && !provider.IsVulnerabilitiesFoundError(err) {
+		return scanResults{}, err
+	}
+	return analyzeImage(ctx, dockerCli, scanFlags, ref, providerOut.Bytes(), analyzers)
+}
+
+// explainResults returns the explanation of each finding matching id, whatever the analyzer which reported it
+func explainResults(results scanResults, id string) []report.Explanation {
+	explanations := report.ExplainLayerGroups(results.layers, id)
+	explanations = append(explanations, report.ExplainFindings("image configuration checks", results.misconfigurations, id)...)
+	explanations = append(explanations, report.ExplainFindings("secrets scanner", results.secrets, id)...)
+	explanations = append(explanations, report.ExplainFindings("license scanner", results.licenseIssues, id)...)
+	return append(explanations, report.ExplainFindings("YARA malware rules", results.malware, id)...)
+}
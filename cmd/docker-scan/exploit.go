@@ -0,0 +1,101 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/exploit"
+	"github.com/docker/scan-cli-plugin/internal/policy"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+const (
+	enrichEPSS = "epss"
+	enrichKEV  = "kev"
+	sortByEPSS = "epss"
+)
+
+var enrichSources = []string{enrichEPSS, enrichKEV}
+
+func validateEnrich(flags options) error {
+	for _, source := range flags.enrich {
+		if !contains(enrichSources, source) {
+			return fmt.Errorf("--enrich takes only %s values", strings.Join(enrichSources, ", "))
+		}
+	}
+	if flags.sortBy != "" && flags.sortBy != sortByEPSS {
+		return fmt.Errorf("--sort-by takes only the %s value", sortByEPSS)
+	}
+	return nil
+}
+
+// enrichFindings adds the EPSS scores and the known exploited flags selected with --enrich to the vulnerabilities, the
+// EPSS scores being added to sort them with --sort-by epss, and both being added for the exploit rules of the policy
+func enrichFindings(ctx context.Context, flags options, scanReport *report.Report) error {
+	sources := map[string]bool{}
+	for _, source := range flags.enrich {
+		sources[source] = true
+	}
+	sources[enrichEPSS] = sources[enrichEPSS] || flags.sortBy == sortByEPSS
+	if flags.policy != "" {
+		scanPolicy, err := policy.Load(flags.policy)
+		if err != nil {
+			return err
+		}
+		sources[enrichEPSS] = sources[enrichEPSS] || scanPolicy.RequiresEPSS()
+		sources[enrichKEV] = sources[enrichKEV] || scanPolicy.RequiresKEV()
+	}
+	if !sources[enrichEPSS] && !sources[enrichKEV] {
+		return nil
+	}
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	httpClient, err := proxy.NewHTTPClient(caCertPath(flags, conf))
+	if err != nil {
+		return err
+	}
+	var enrichers []exploit.Enricher
+	if sources[enrichEPSS] {
+		enrichers = append(enrichers, exploit.EPSS{Client: httpClient, URL: exploit.DefaultEPSSURL})
+	}
+	if sources[enrichKEV] {
+		enrichers = append(enrichers, exploit.KEV{Client: httpClient, URL: exploit.DefaultKEVURL, CacheDir: exploit.DefaultCacheDir()})
+	}
+	if err := exploit.Enrich(ctx, scanReport.Vulnerabilities, enrichers...); err != nil {
+		return err
+	}
+	if flags.sortBy == sortByEPSS {
+		report.SortByEPSS(scanReport.Vulnerabilities)
+	}
+	return nil
+}
+
+func enriched(vulns []report.Vulnerability) bool {
+	for _, vuln := range vulns {
+		if vuln.Exploit != nil {
+			return true
+		}
+	}
+	return false
+}
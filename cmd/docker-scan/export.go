@@ -0,0 +1,84 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/export"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+type exportTarget struct {
+	format string
+	path   string
+}
+
+// exportTargets parses the --export values, formatted as FORMAT=PATH
+func exportTargets(flags options) ([]exportTarget, error) {
+	var targets []exportTarget
+	for _, value := range flags.exports {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[1] == "" || !contains(export.Formats, parts[0]) {
+			return nil, fmt.Errorf("--export takes FORMAT=PATH values, with FORMAT one of %s", strings.Join(export.Formats, ", "))
+		}
+		targets = append(targets, exportTarget{format: parts[0], path: parts[1]})
+	}
+	return targets, nil
+}
+
+func writeExports(flags options, results scanResults) error {
+	targets, err := exportTargets(flags)
+	if err != nil || results.report == nil {
+		return err
+	}
+	scan := export.Scan{
+		Image:     results.ref,
+		ScannedAt: time.Now(),
+		Findings:  results.findings(),
+	}
+	for _, target := range targets {
+		if err := writeExport(target, scan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeExport(target exportTarget, scan export.Scan) error {
+	f, err := os.Create(target.path)
+	if err != nil {
+		return fmt.Errorf("failed to export the results: %s", err)
+	}
+	defer f.Close() //nolint:errcheck
+	return export.Write(f, target.format, scan)
+}
+
+// findings returns all the findings of the scan, from the provider and from the plugin analyzers
+func (r scanResults) findings() []report.Vulnerability {
+	var findings []report.Vulnerability
+	if r.report != nil {
+		findings = append(findings, r.report.Vulnerabilities...)
+	}
+	findings = append(findings, r.misconfigurations...)
+	findings = append(findings, r.secrets...)
+	findings = append(findings, r.licenseIssues...)
+	return append(findings, r.malware...)
+}
@@ -0,0 +1,70 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+)
+
+type exportRootfsOptions struct {
+	output string
+	input  string
+}
+
+func newExportRootfsCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags exportRootfsOptions
+	cmd := &cobra.Command{
+		Use:   "export-rootfs [OPTIONS] [IMAGE]",
+		Short: "Export the merged filesystem of an image, as seen by the analyzers of docker scan",
+		Args:  cli.RequiresMaxArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExportRootfs(ctx, dockerCli, flags, args)
+		},
+	}
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Directory where the filesystem is exported, must be empty")
+	cmd.Flags().StringVar(&flags.input, "input", "", "Export an image archive created by docker save, or an OCI image layout directory or archive, instead of an image of the engine")
+	return cmd
+}
+
+func runExportRootfs(ctx context.Context, dockerCli command.Cli, flags exportRootfsOptions, args []string) error {
+	if flags.output == "" {
+		return fmt.Errorf("--output flag is mandatory")
+	}
+	if (len(args) == 0) == (flags.input == "") {
+		return fmt.Errorf(`"docker scan export-rootfs" requires either an image or the --input flag`)
+	}
+	ref := flags.input
+	if len(args) == 1 {
+		ref = args[0]
+	}
+	extracted, err := extractImage(ctx, dockerCli, options{input: flags.input}, ref)
+	if err != nil {
+		return err
+	}
+	//nolint: errcheck
+	defer extracted.Remove()
+	if err := extracted.MergeLayers(flags.output); err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Out(), "Exported the filesystem of %s to %s\n", ref, flags.output)
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"time"
+
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/findingage"
+	"github.com/docker/scan-cli-plugin/internal/policy"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// trackFindingAge records when the vulnerabilities were first seen in the image repository, with --track-age or when
+// the policy has rules on their age
+func trackFindingAge(flags options, results *scanResults) error {
+	if results.report == nil || !tracksAge(flags) {
+		return nil
+	}
+	image := imageName(flags, results.ref)
+	now := time.Now()
+	var tracked []report.Vulnerability
+	var err error
+	if !config.ReadOnly() {
+		tracked, err = findingage.Track(findingage.DefaultPath(), image, results.report.Vulnerabilities, now)
+	}
+	// a read-only configuration only reports the age of the findings already tracked
+	if config.ReadOnly() || config.IsReadOnly(err) {
+		tracked, err = findingage.Lookup(findingage.DefaultPath(), image, results.report.Vulnerabilities, now)
+	}
+	if err != nil {
+		return err
+	}
+	results.report.Vulnerabilities = tracked
+	return nil
+}
+
+func tracksAge(flags options) bool {
+	if flags.trackAge || flags.policy == "" {
+		return flags.trackAge
+	}
+	scanPolicy, err := policy.Load(flags.policy)
+	return err == nil && scanPolicy.RequiresAge()
+}
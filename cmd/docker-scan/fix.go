@@ -0,0 +1,272 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/internal/dockerfile"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type fixOptions struct {
+	dockerFilePath string
+	buildArgs      []string
+	upgrade        string
+	noPin          bool
+	write          bool
+	edit           bool
+}
+
+func newFixCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags fixOptions
+	cmd := &cobra.Command{
+		Use:   "fix [OPTIONS] IMAGE",
+		Short: "Rewrite the FROM instructions of a Dockerfile to the recommended base image, pinned by digest",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateFix(flags); err != nil {
+				return err
+			}
+			return runFix(ctx, dockerCli, flags, args[0])
+		},
+	}
+	cmd.Flags().StringVarP(&flags.dockerFilePath, "file", "f", "", "Dockerfile to fix, used to build the image")
+	cmd.Flags().StringArrayVar(&flags.buildArgs, "build-arg", nil, "Set the value of an ARG instruction of the Dockerfile, like docker build")
+	cmd.Flags().StringVar(&flags.upgrade, "upgrade", report.MinorUpgrade, "Kind of recommended base image to upgrade to, minor, major or alternative")
+	cmd.Flags().BoolVar(&flags.noPin, "no-pin", false, "Do not pin the base images to their digest")
+	cmd.Flags().BoolVar(&flags.write, "write", false, "Rewrite the Dockerfile instead of printing a patch")
+	cmd.Flags().BoolVar(&flags.edit, "edit", false, "Open the patch in $VISUAL or $EDITOR for review")
+	return cmd
+}
+
+func validateFix(flags fixOptions) error {
+	if flags.dockerFilePath == "" {
+		return fmt.Errorf("--file flag is mandatory to fix a Dockerfile")
+	}
+	switch flags.upgrade {
+	case report.MinorUpgrade, report.MajorUpgrade, report.AlternativeImage:
+		return validateBuildArgs(options{dockerFilePath: flags.dockerFilePath, buildArgs: flags.buildArgs})
+	}
+	return fmt.Errorf("invalid --upgrade value %q, expected minor, major or alternative", flags.upgrade)
+}
+
+func runFix(ctx context.Context, dockerCli command.Cli, flags fixOptions, image string) error {
+	content, err := ioutil.ReadFile(flags.dockerFilePath)
+	if err != nil {
+		return err
+	}
+	stages, err := parseDockerfile(options{dockerFilePath: flags.dockerFilePath, buildArgs: flags.buildArgs})
+	if err != nil {
+		return err
+	}
+	recommendations, err := scanRecommendations(ctx, dockerCli, flags, image)
+	if err != nil {
+		return err
+	}
+	images, baseLines, err := fixedBaseImages(ctx, dockerCli, flags, stages, recommendations)
+	if err != nil {
+		return err
+	}
+	fixed, changes := dockerfile.RewriteBaseImages(content, images)
+	for _, line := range baseLines {
+		if !changedLine(changes, line) {
+			return fmt.Errorf("cannot rewrite the FROM instruction line %d of %s, its image uses build args or spans several lines: change it to %s", line, flags.dockerFilePath, images[line])
+		}
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(dockerCli.Out(), "Nothing to fix in %s\n", flags.dockerFilePath)
+		return nil
+	}
+	patch := dockerfile.Patch(flags.dockerFilePath, content, fixed)
+	if flags.edit {
+		if err := openInEditor(dockerCli, patch); err != nil {
+			return err
+		}
+	}
+	if !flags.write {
+		if !flags.edit {
+			fmt.Fprint(dockerCli.Out(), patch)
+		}
+		return nil
+	}
+	info, err := os.Stat(flags.dockerFilePath)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(flags.dockerFilePath, fixed, info.Mode()); err != nil {
+		return err
+	}
+	for _, change := range changes {
+		fmt.Fprintf(dockerCli.Out(), "%s:%d: %s -> %s\n", flags.dockerFilePath, change.Line, change.Before, change.After)
+	}
+	return nil
+}
+
+// scanRecommendations scans the image with its Dockerfile and returns the recommended base images
+func scanRecommendations(ctx context.Context, dockerCli command.Cli, flags fixOptions, image string) (report.BaseImageRecommendations, error) {
+	providerOut := bytes.NewBuffer(nil)
+	scanProvider, err := configureProvider(ctx, dockerCli, options{
+		dockerFilePath: flags.dockerFilePath,
+		buildArgs:      flags.buildArgs,
+		jsonFormat:     true,
+	}, hubAuthConfig(dockerCli), provider.WithStreams(providerOut, dockerCli.Err()))
+	if err != nil {
+		return report.BaseImageRecommendations{}, err
+	}
+	// vulnerabilities are expected, only provider failures matter
+	if err := scanProvider.Scan(image); err != nil && !provider.IsVulnerabilitiesFoundError(err) && !provider.IsProviderFailedError(err) {
+		return report.BaseImageRecommendations{}, err
+	}
+	scanReport, err := report.Parse(providerOut.Bytes())
+	if err != nil {
+		return report.BaseImageRecommendations{}, err
+	}
+	return report.Recommendations(scanReport), nil
+}
+
+// fixedBaseImages returns the new image of the FROM instructions by line: the recommended upgrade for the base image
+// detected by the provider, and every other image pinned to its digest unless --no-pin. The lines of the detected base
+// image are returned too, as they must be rewritten.
+func fixedBaseImages(ctx context.Context, dockerCli command.Cli, flags fixOptions, stages []dockerfile.Stage, recommendations report.BaseImageRecommendations) (map[int]string, []int, error) {
+	upgrade, ok := recommendedUpgrade(recommendations, flags.upgrade)
+	if !ok {
+		return nil, nil, fmt.Errorf("no %s upgrade recommended for the base image %q of %s", flags.upgrade, recommendations.BaseImage, flags.dockerFilePath)
+	}
+	images := map[int]string{}
+	var baseLines []int
+	stageNames := map[string]bool{}
+	for _, stage := range stages {
+		image := stage.BaseImage
+		switch {
+		case stageNames[strings.ToLower(image)] || strings.EqualFold(image, "scratch") || strings.Contains(image, "$"):
+		case sameImage(image, recommendations.BaseImage):
+			baseLines = append(baseLines, stage.Line)
+			images[stage.Line] = pinImage(ctx, dockerCli, flags, upgrade.Image)
+		case !flags.noPin:
+			images[stage.Line] = pinImage(ctx, dockerCli, flags, image)
+		}
+		if stage.Name != "" {
+			stageNames[strings.ToLower(stage.Name)] = true
+		}
+	}
+	if len(baseLines) == 0 {
+		return nil, nil, fmt.Errorf("the base image %q is not the image of a FROM instruction of %s", recommendations.BaseImage, flags.dockerFilePath)
+	}
+	return images, baseLines, nil
+}
+
+// recommendedUpgrade returns the recommendation of the kind with the fewest vulnerabilities
+func recommendedUpgrade(recommendations report.BaseImageRecommendations, kind string) (report.BaseImageRecommendation, bool) {
+	var best report.BaseImageRecommendation
+	found := false
+	for _, recommendation := range recommendations.Recommendations {
+		if recommendation.Kind == kind && (!found || recommendation.Vulnerabilities < best.Vulnerabilities) {
+			best, found = recommendation, true
+		}
+	}
+	return best, found
+}
+
+// sameImage tells whether both references name the same image, the default registry and tag omitted or not
+func sameImage(image, other string) bool {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return false
+	}
+	otherNamed, err := reference.ParseNormalizedNamed(other)
+	if err != nil {
+		return false
+	}
+	return reference.TagNameOnly(named).String() == reference.TagNameOnly(otherNamed).String()
+}
+
+// pinImage returns the image with the digest of its tag in the registry, or the image as is when --no-pin is set or
+// the digest is not found
+func pinImage(ctx context.Context, dockerCli command.Cli, flags fixOptions, image string) string {
+	named, err := reference.ParseNormalizedNamed(image)
+	if flags.noPin || err != nil {
+		return image
+	}
+	if _, ok := named.(reference.Canonical); ok {
+		return image
+	}
+	repository, err := registryRepository(ctx, dockerCli, named)
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Warning: cannot pin %s: %s\n", image, err)
+		return image
+	}
+	digest, err := registryDigest(ctx, dockerCli, repository, named)
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Warning: cannot pin %s: %s\n", image, err)
+		return image
+	}
+	return reference.FamiliarString(reference.TagNameOnly(named)) + "@" + digest
+}
+
+func changedLine(changes []dockerfile.Change, line int) bool {
+	for _, change := range changes {
+		if change.Line == line {
+			return true
+		}
+	}
+	return false
+}
+
+// openInEditor opens the patch in the editor of the user, vi by default, and waits until it is closed
+func openInEditor(dockerCli command.Cli, patch string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	file, err := ioutil.TempFile("", "docker-scan-fix-*.patch")
+	if err != nil {
+		return err
+	}
+	//nolint: errcheck
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(patch); err != nil {
+		file.Close() //nolint: errcheck
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	args := append(strings.Fields(editor), file.Name())
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = dockerCli.In()
+	cmd.Stdout = dockerCli.Out()
+	cmd.Stderr = dockerCli.Err()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("cannot open the patch in %s: %s", editor, err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/scan-cli-plugin/internal/fixtures"
+	"github.com/spf13/cobra"
+)
+
+type testdataGenerateOptions struct {
+	repository string
+	output     string
+	list       bool
+}
+
+func newTestdataCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "testdata",
+		Short:  "Manage the fixture images of the e2e tests and the demos",
+		Args:   cli.NoArgs,
+		Hidden: true,
+	}
+	cmd.AddCommand(newTestdataGenerateCmd(ctx, dockerCli))
+	return cmd
+}
+
+func newTestdataGenerateCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags testdataGenerateOptions
+	cmd := &cobra.Command{
+		Use:   "generate [OPTIONS] [FIXTURE...]",
+		Short: "Build the small deterministic images with pinned vulnerable packages used by the e2e tests, all of them by default",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.list {
+				return listFixtures(dockerCli)
+			}
+			return runTestdataGenerate(ctx, dockerCli, flags, args)
+		},
+	}
+	cmd.Flags().StringVar(&flags.repository, "repository", fixtures.DefaultRepository, "Repository prefix of the fixture images")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Write the images to an archive for docker load instead of loading them in the engine")
+	cmd.Flags().BoolVar(&flags.list, "list", false, "List the fixtures")
+	return cmd
+}
+
+func runTestdataGenerate(ctx context.Context, dockerCli command.Cli, flags testdataGenerateOptions, names []string) error {
+	selected, err := fixtures.Find(names)
+	if err != nil {
+		return err
+	}
+	archive := bytes.NewBuffer(nil)
+	images, err := fixtures.WriteArchive(archive, selected, flags.repository)
+	if err != nil {
+		return err
+	}
+	if flags.output != "" {
+		if err := ioutil.WriteFile(flags.output, archive.Bytes(), 0644); err != nil {
+			return err
+		}
+	} else if err := loadImages(ctx, dockerCli, archive); err != nil {
+		return err
+	}
+	for _, image := range images {
+		fmt.Fprintf(dockerCli.Out(), "%s\t%s\n", image.Tag, image.ID)
+	}
+	return nil
+}
+
+// loadImages loads the image archive in the engine, like docker load
+func loadImages(ctx context.Context, dockerCli command.Cli, archive *bytes.Buffer) error {
+	response, err := dockerCli.Client().ImageLoad(ctx, archive, true)
+	if err != nil {
+		return err
+	}
+	//nolint: errcheck
+	defer response.Body.Close()
+	if !response.JSON {
+		_, err := ioutil.ReadAll(response.Body)
+		return err
+	}
+	return jsonmessage.DisplayJSONMessagesStream(response.Body, ioutil.Discard, 0, false, nil)
+}
+
+func listFixtures(dockerCli command.Cli) error {
+	w := tabwriter.NewWriter(dockerCli.Out(), 0, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "FIXTURE\tDESCRIPTION")
+	for _, fixture := range fixtures.Fixtures {
+		fmt.Fprintf(w, "%s\t%s\n", fixture.Name, fixture.Description)
+	}
+	return w.Flush()
+}
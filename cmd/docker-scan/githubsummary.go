@@ -0,0 +1,71 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// githubStepSummaryEnv is the file of the job summary of the current GitHub Actions step
+const githubStepSummaryEnv = "GITHUB_STEP_SUMMARY"
+
+func validateGitHubSummary(flags options) error {
+	if flags.baseline != "" && !flags.githubSummary {
+		return fmt.Errorf("--baseline flag requires --github-summary")
+	}
+	return nil
+}
+
+// writeGitHubSummary appends the markdown summary of the scan to the job summary of the GitHub Actions step with
+// --github-summary. It is skipped with a warning outside of GitHub Actions, so that the same command runs locally.
+func writeGitHubSummary(dockerCli command.Cli, flags options, results scanResults) error {
+	if !flags.githubSummary || results.report == nil {
+		return nil
+	}
+	path := os.Getenv(githubStepSummaryEnv)
+	if path == "" {
+		fmt.Fprintf(dockerCli.Err(), "Warning: --github-summary is ignored outside of GitHub Actions, %s is not set\n", githubStepSummaryEnv)
+		return nil
+	}
+	summary := report.MarkdownSummary{Image: results.ref, Findings: results.findings()}
+	if flags.baseline != "" {
+		document, err := ioutil.ReadFile(flags.baseline)
+		if err != nil {
+			return fmt.Errorf("cannot read the baseline report: %s", err)
+		}
+		baseline, err := report.Parse(document)
+		if err != nil {
+			return fmt.Errorf("invalid baseline report %s, expected a JSON report of docker scan: %s", flags.baseline, err)
+		}
+		summary.Compared = true
+		summary.Introduced, summary.Fixed = report.Diff(baseline, report.Report{Vulnerabilities: summary.Findings})
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write the GitHub job summary: %s", err)
+	}
+	report.WriteMarkdown(f, summary)
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("cannot write the GitHub job summary: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/archive"
+	"github.com/docker/scan-cli-plugin/internal/history"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type historyOptions struct {
+	since      string
+	archive    string
+	jsonFormat bool
+}
+
+func newHistoryCmd(dockerCli command.Cli) *cobra.Command {
+	var flags historyOptions
+	cmd := &cobra.Command{
+		Use:   "history [OPTIONS] IMAGE",
+		Short: "Show the vulnerabilities of the past scans of an image recorded with --history, to follow their trend",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHistory(dockerCli, flags, args[0], time.Now())
+		},
+	}
+	cmd.Flags().StringVar(&flags.since, "since", "", "Only show the scans made since a date like 2021-03-01, or for a duration like 72h")
+	cmd.Flags().StringVar(&flags.archive, "archive", "", "Show the scans whose reports were archived in a directory with --json-file DIR/REPORT.json.zst")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the scans in JSON format")
+	return cmd
+}
+
+func runHistory(dockerCli command.Cli, flags historyOptions, image string, now time.Time) error {
+	since, err := parseSince(flags.since, now)
+	if err != nil {
+		return err
+	}
+	entries, err := historyEntries(flags)
+	if err != nil {
+		return err
+	}
+	entries = history.Select(entries, image, since)
+	if flags.jsonFormat {
+		if entries == nil {
+			entries = []history.Entry{}
+		}
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(dockerCli.Out(), "No scan of %s recorded, scan it with --history to record its results\n", image)
+		return nil
+	}
+	return writeHistory(dockerCli.Out(), entries)
+}
+
+// historyEntries returns the scans of the history, or the scans indexed in the archive directory given with --archive
+func historyEntries(flags historyOptions) ([]history.Entry, error) {
+	if flags.archive == "" {
+		return history.Read(history.DefaultPath())
+	}
+	if info, err := os.Stat(flags.archive); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("invalid --archive %q, expected a directory of reports written with --json-file", flags.archive)
+	}
+	return archive.ReadIndex(flags.archive)
+}
+
+// parseSince returns the time of --since, given as a date, a timestamp or a duration before now
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	if duration, err := time.ParseDuration(since); err == nil {
+		return now.Add(-duration), nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", since, time.Local); err == nil {
+		return date, nil
+	}
+	if date, err := time.Parse(time.RFC3339, since); err == nil {
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, expected a date like 2021-03-01 or a duration like 72h", since)
+}
+
+// writeHistory prints a line per scan with its counts per severity, and the change of the total since the previous scan
+func writeHistory(out io.Writer, entries []history.Entry) error {
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	header := []string{"SCANNED", "IMAGE", "DIGEST"}
+	for i := len(report.Severities) - 1; i >= 0; i-- {
+		header = append(header, strings.ToUpper(report.Severities[i]))
+	}
+	fmt.Fprintln(w, strings.Join(append(header, "TOTAL", "CHANGE"), "\t"))
+	for i, entry := range entries {
+		row := []string{entry.Time.Local().Format("2006-01-02 15:04"), entry.Image, shortDigest(entry.Digest)}
+		for i := len(report.Severities) - 1; i >= 0; i-- {
+			row = append(row, fmt.Sprint(entry.Counts[report.Severities[i]]))
+		}
+		change := ""
+		if i > 0 {
+			change = fmt.Sprintf("%+d", entry.Total-entries[i-1].Total)
+		}
+		fmt.Fprintln(w, strings.Join(append(row, fmt.Sprint(entry.Total), change), "\t"))
+	}
+	return w.Flush()
+}
+
+func shortDigest(digest string) string {
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
+
+// recordHistory appends the counts of the scan to the history with --history, and saves its JSON report. The scan
+// doesn't fail when the history can't be written.
+func recordHistory(ctx context.Context, dockerCli command.Cli, flags options, scanProvider provider.Provider, document []byte, results scanResults) {
+	if !flags.history || results.report == nil || config.ReadOnly() {
+		return
+	}
+	counts := report.NewMatrixColumn(results.ref, results.findings()).Counts
+	appendHistory(dockerCli, historyEntry(ctx, dockerCli, scanProvider, results.ref, counts), document)
+}
+
+// appendHistory saves the JSON report of the scan, checked against the new advisories by docker scan alerts, and
+// appends the scan to the history
+func appendHistory(dockerCli command.Cli, entry history.Entry, document []byte) {
+	path, err := history.SaveReport(history.DefaultReportsDir(), entry.Image, document)
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Warning: failed to save the report of the scan in the history: %s\n", err)
+	} else {
+		entry.Report = path
+	}
+	if err := history.Append(history.DefaultPath(), entry); err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Warning: failed to record the scan in the history: %s\n", err)
+	}
+}
+
+// historyEntry returns the normalized result of the scan of an image, identified by its ID when it's a local image
+func historyEntry(ctx context.Context, dockerCli command.Cli, scanProvider provider.Provider, image string, counts map[string]int) history.Entry {
+	entry := history.Entry{Image: image, Time: time.Now().UTC(), Counts: counts}
+	for _, count := range entry.Counts {
+		entry.Total += count
+	}
+	if inspect, _, err := dockerCli.Client().ImageInspectWithRaw(ctx, image); err == nil {
+		entry.Digest = inspect.ID
+	}
+	if version, err := scanProvider.Version(); err == nil {
+		entry.Provider = version
+	}
+	return entry
+}
@@ -0,0 +1,77 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/docker/cli/cli/config/configfile"
+)
+
+// hooksCommandName is the command the Docker CLI runs, as "docker-scan scan docker-cli-plugin-hooks DATA", after
+// the commands listed in the hooks of the scan section of the plugins configuration
+const hooksCommandName = "docker-cli-plugin-hooks"
+
+// hookData is sent by the Docker CLI to the hooks command, once the hooked command completed
+type hookData struct {
+	RootCmd      string
+	Flags        map[string]string
+	CommandError string
+}
+
+// hookResponse is the message the Docker CLI prints after the hooked command, its template
+// can refer to the arguments of the command with {{arg . 0}}
+type hookResponse struct {
+	Type     int
+	Template string
+}
+
+// isHooksInvocation returns true if the plugin is run by the Docker CLI for its hooks. The hooks command is handled
+// apart from the scan commands so it doesn't show up in their usage.
+func isHooksInvocation(args []string) bool {
+	return len(args) == 4 && args[1] == pluginName && args[2] == hooksCommandName
+}
+
+func runHooks(out io.Writer, configFile *configfile.ConfigFile, data string) error {
+	var hook hookData
+	if err := json.Unmarshal([]byte(data), &hook); err != nil {
+		return fmt.Errorf("invalid hook data: %s", err)
+	}
+	if hook.CommandError != "" || !requireScanBeforePush(configFile) {
+		return nil
+	}
+	template := hookMessage(hook.RootCmd)
+	if template == "" {
+		return nil
+	}
+	return json.NewEncoder(out).Encode(hookResponse{Template: template})
+}
+
+// hookMessage returns the message printed after a build or a push when scan.require_before_push is enabled.
+// The Docker CLI runs the hooks once the command completed, they can't refuse a push.
+func hookMessage(rootCmd string) string {
+	switch rootCmd {
+	case "push", "image push":
+		return fmt.Sprintf("{{arg . 0}} was pushed without checking its scan, use 'docker scan push {{arg . 0}}' to push only images which passed a scan (%s.%s)",
+			pluginName, requireBeforePushOption)
+	case "build", "image build", "builder build", "buildx build":
+		return "Scan the image with 'docker scan IMAGE' before pushing it with 'docker scan push IMAGE'"
+	}
+	return ""
+}
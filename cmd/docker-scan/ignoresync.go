@@ -0,0 +1,85 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+func validateSyncIgnores(flags options) error {
+	if flags.syncIgnores && !usesSnyk(flags.provider) {
+		return fmt.Errorf("--sync-ignores flag requires the Snyk provider")
+	}
+	return nil
+}
+
+// localIgnores returns the vulnerabilities ignored locally, excluded with --exclude-cve or suppressed by the VEX
+// statements, to be ignored in the Snyk projects of the image with --sync-ignores
+func localIgnores(flags options, vulns []report.Vulnerability, vexResults []vexResult) []provider.Ignore {
+	var ignores []provider.Ignore
+	seen := map[string]bool{}
+	add := func(ignore provider.Ignore) {
+		if !seen[ignore.ID] {
+			seen[ignore.ID] = true
+			ignores = append(ignores, ignore)
+		}
+	}
+	for _, vuln := range vulns {
+		for _, id := range append(vuln.CVEs(), vuln.ID) {
+			if contains(flags.excludedCVEs, id) {
+				add(provider.Ignore{ID: vuln.ID, Reason: "Excluded with docker scan --exclude-cve " + id, ReasonType: provider.IgnoreWontFix})
+				break
+			}
+		}
+	}
+	for _, result := range vexResults {
+		if !result.Suppressed {
+			continue
+		}
+		reason := "VEX status " + result.Status
+		if result.Justification != "" {
+			reason += ": " + result.Justification
+		}
+		add(provider.Ignore{ID: result.ID, Reason: reason, ReasonType: provider.IgnoreNotVulnerable})
+	}
+	return ignores
+}
+
+// syncIgnores ignores the vulnerabilities ignored locally in the Snyk projects monitoring the image with
+// --sync-ignores. A failed synchronization doesn't fail the scan, a warning is printed instead.
+func syncIgnores(ctx context.Context, dockerCli command.Cli, flags options, results scanResults) {
+	if !flags.syncIgnores || len(results.ignores) == 0 {
+		return
+	}
+	authOpts := append([]provider.Ops{provider.WithContext(ctx), provider.WithRetryPolicy(provider.DefaultRetryPolicy)}, orgOptions(flags)...)
+	opts, err := authOptions(dockerCli, flags.profile, authOpts...)
+	if err == nil {
+		var sync provider.IgnoreSync
+		if sync, err = provider.SyncIgnores(opts, results.ref, results.ignores); err == nil {
+			fmt.Fprintf(dockerCli.Err(), "%d ignored vulnerabilities synced to the Snyk projects %s\n", len(sync.Ignored),
+				strings.Join(sync.Projects, ", "))
+			return
+		}
+	}
+	fmt.Fprintf(dockerCli.Err(), "Warning: failed to sync the ignored vulnerabilities to Snyk: %s\n", err)
+}
@@ -0,0 +1,111 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"github.com/docker/scan-cli-plugin/internal/misconfig"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+func validateInput(flags options) error {
+	if flags.input == "" {
+		return nil
+	}
+	if flags.watch {
+		return fmt.Errorf("--watch flag cannot be used with --input flag")
+	}
+	if flags.budget != 0 {
+		return fmt.Errorf("--budget flag cannot be used with --input flag")
+	}
+	return nil
+}
+
+func checkScanArgs(cmd *cobra.Command, flags options, args []string) error {
+	switch {
+	case flags.input != "" && len(args) != 0:
+		if err := cmd.Usage(); err != nil {
+			return err
+		}
+		return fmt.Errorf(`"docker scan --input" accepts no argument`)
+	case flags.input == "" && len(args) != 1:
+		if err := cmd.Usage(); err != nil {
+			return err
+		}
+		return fmt.Errorf(`"docker scan" requires exactly 1 argument`)
+	}
+	return nil
+}
+
+// scanReference returns the reference of the image given to the provider, and a function
+// removing the temporary files created for it
+func scanReference(ctx context.Context, dockerCli command.Cli, flags options, args []string) (string, func(), error) {
+	if flags.input == "" && flags.platform != "" {
+		ref, err := platformReference(ctx, dockerCli, args[0], flags.platform)
+		return ref, func() {}, err
+	}
+	if flags.input == "" {
+		return args[0], func() {}, nil
+	}
+	input, err := image.OpenInput(flags.input)
+	if err != nil {
+		return "", nil, err
+	}
+	return input.ProviderReference()
+}
+
+// imageName is the name of the scanned image in the reports
+func imageName(flags options, ref string) string {
+	if flags.input != "" {
+		return flags.input
+	}
+	return ref
+}
+
+// extractImage extracts the layers of the image archive given with --input, or of the image exported from the engine
+func extractImage(ctx context.Context, dockerCli command.Cli, flags options, ref string) (*image.ExtractedImage, error) {
+	if flags.input == "" {
+		return image.Extract(ctx, dockerCli.Client(), ref)
+	}
+	input, err := image.OpenInput(flags.input)
+	if err != nil {
+		return nil, err
+	}
+	return input.Extract()
+}
+
+// checkImageConfig reports the issues of the configuration of the image archive given with --input,
+// or of the image inspected from the engine
+func checkImageConfig(ctx context.Context, dockerCli command.Cli, flags options, ref string) ([]report.Vulnerability, error) {
+	if flags.input == "" {
+		return misconfig.CheckImage(ctx, dockerCli.Client(), ref)
+	}
+	input, err := image.OpenInput(flags.input)
+	if err != nil {
+		return nil, err
+	}
+	config, err := input.Config()
+	if err != nil {
+		return nil, err
+	}
+	return misconfig.CheckConfig(config), nil
+}
@@ -0,0 +1,114 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/browser"
+	"github.com/docker/scan-cli-plugin/internal/ignore"
+)
+
+const (
+	alternateScreen = "\x1b[?1049h\x1b[?25l"
+	mainScreen      = "\x1b[?25h\x1b[?1049l"
+	clearScreen     = "\x1b[H\x1b[2J"
+)
+
+func validateInteractive(flags options) error {
+	if !flags.interactive {
+		return nil
+	}
+	if flags.jsonFormat || flags.quiet || flags.summary || flags.format != "" {
+		return fmt.Errorf("--interactive flag can't be used with --json, --quiet, --summary or --format")
+	}
+	if flags.watch || flags.allPlatforms {
+		return fmt.Errorf("--interactive flag can't be used with --watch or --all-platforms")
+	}
+	return nil
+}
+
+// checkTerminal fails the interactive scans before scanning when the plugin doesn't run in a terminal
+func checkTerminal(dockerCli command.Cli, flags options) error {
+	if flags.interactive && (!dockerCli.In().IsTerminal() || !dockerCli.Out().IsTerminal()) {
+		return fmt.Errorf("--interactive flag requires a terminal")
+	}
+	return nil
+}
+
+// applyIgnoreFile excludes the vulnerabilities listed in the ignore file given with --ignore-file
+func applyIgnoreFile(flags *options) error {
+	if flags.ignoreFile == "" {
+		return nil
+	}
+	ids, err := ignore.Load(flags.ignoreFile)
+	if err != nil {
+		return fmt.Errorf("cannot read the ignore file: %s", err)
+	}
+	flags.excludedCVEs = append(flags.excludedCVEs, ids...)
+	return nil
+}
+
+// browseFindings opens the terminal UI browsing the findings, and adds the findings marked by the user to the ignore
+// file
+func browseFindings(dockerCli command.Cli, flags options, results scanResults) error {
+	b := browser.New(results.ref, results.findings())
+	if err := dockerCli.In().SetRawTerminal(); err != nil {
+		return fmt.Errorf("cannot open the interactive browser: %s", err)
+	}
+	fmt.Fprint(dockerCli.Out(), alternateScreen)
+	err := runBrowser(dockerCli, b)
+	fmt.Fprint(dockerCli.Out(), mainScreen)
+	dockerCli.In().RestoreTerminal() //nolint: errcheck
+	if err != nil {
+		return err
+	}
+	marked := b.Marked()
+	if len(marked) == 0 {
+		return nil
+	}
+	path := flags.ignoreFile
+	if path == "" {
+		path = ignore.DefaultFile
+	}
+	if err := ignore.Append(path, marked, time.Now()); err != nil {
+		return fmt.Errorf("cannot write the ignore file: %s", err)
+	}
+	fmt.Fprintf(dockerCli.Out(), "%d vulnerabilities added to the ignore file %s, use --ignore-file %s to exclude them\n",
+		len(marked), path, path)
+	return nil
+}
+
+// runBrowser draws the browser and handles the keys until the user quits
+func runBrowser(dockerCli command.Cli, b *browser.Browser) error {
+	reader := bufio.NewReader(dockerCli.In())
+	for {
+		height, width := dockerCli.Out().GetTtySize()
+		fmt.Fprint(dockerCli.Out(), clearScreen+strings.Join(b.Render(int(width), int(height)), "\r\n"))
+		key, err := browser.ReadKey(reader)
+		if err != nil {
+			return fmt.Errorf("cannot read the terminal input: %s", err)
+		}
+		if !b.HandleKey(key) {
+			return nil
+		}
+	}
+}
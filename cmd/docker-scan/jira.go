@@ -0,0 +1,78 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/jira"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+const defaultJiraIssueType = "Bug"
+
+func validateJiraSeverity(flags options) error {
+	if flags.createJira && report.SeverityLevel(flags.jiraSeverity) < 0 {
+		return fmt.Errorf("--jira-severity takes only %s values", strings.Join(report.Severities, ", "))
+	}
+	return nil
+}
+
+// createJiraIssues opens or updates the Jira issues of the findings of the given severity or higher
+func createJiraIssues(ctx context.Context, dockerCli command.Cli, flags options, results scanResults) error {
+	if !flags.createJira || results.report == nil {
+		return nil
+	}
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	if conf.Jira == nil || conf.Jira.URL == "" || conf.Jira.Project == "" {
+		return fmt.Errorf("--create-jira requires the Jira url and project to be set in the jira section of the docker scan configuration file")
+	}
+	token := os.Getenv("JIRA_API_TOKEN")
+	if token == "" {
+		return fmt.Errorf("--create-jira requires the JIRA_API_TOKEN environment variable")
+	}
+	httpClient, err := proxy.NewHTTPClient(caCertPath(flags, conf))
+	if err != nil {
+		return err
+	}
+	image, err := normalizeTag(results.ref)
+	if err != nil {
+		return err
+	}
+	issueType := conf.Jira.IssueType
+	if issueType == "" {
+		issueType = defaultJiraIssueType
+	}
+	client := &jira.Client{URL: conf.Jira.URL, Username: conf.Jira.Username, Token: token, HTTPClient: httpClient}
+	findings := report.Filter(report.Report{Vulnerabilities: results.findings()}, report.AtLeast(flags.jiraSeverity)).Vulnerabilities
+	synced, err := jira.Sync(ctx, client, conf.Jira.Project, issueType, image, findings)
+	fmt.Fprintf(dockerCli.Err(), "Jira issues created: %d %s, updated: %d %s\n",
+		len(synced.Created), strings.Join(synced.Created, " "), len(synced.Updated), strings.Join(synced.Updated, " "))
+	if err != nil {
+		return fmt.Errorf("failed to create Jira issues: %s", err)
+	}
+	return nil
+}
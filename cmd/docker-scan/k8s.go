@@ -0,0 +1,228 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/k8s"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type k8sOptions struct {
+	files      []string
+	helmChart  string
+	helmValues []string
+	severity   string
+	jsonFormat bool
+}
+
+// workloadResult aggregates the scans of the images of a workload
+type workloadResult struct {
+	k8s.Workload
+	Counts map[string]int `json:"counts"`
+	// Errors are the scans of the images which failed
+	Errors []string `json:"errors,omitempty"`
+}
+
+func newK8sCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags k8sOptions
+	cmd := &cobra.Command{
+		Use:   "k8s [OPTIONS]",
+		Short: "Scan the images of the workloads of Kubernetes manifests or of a Helm chart",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exitCodeError(runK8s(ctx, dockerCli, flags), options{exitCodeOnVuln: defaultExitCodeOnVuln, exitCodeOnError: defaultExitCodeOnError})
+		},
+	}
+	cmd.Flags().StringArrayVarP(&flags.files, "file", "f", nil, "Manifest file, or directory of manifest files, to scan, - for the standard input")
+	cmd.Flags().StringVar(&flags.helmChart, "helm-chart", "", "Helm chart to render with helm template and scan")
+	cmd.Flags().StringArrayVar(&flags.helmValues, "values", nil, "Values file used to render the Helm chart")
+	cmd.Flags().StringVar(&flags.severity, "severity", "", "Only fail on vulnerabilities of provided level or higher (low|medium|high|critical)")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the results per workload in JSON format")
+	return cmd
+}
+
+func runK8s(ctx context.Context, dockerCli command.Cli, flags k8sOptions) error {
+	if len(flags.files) == 0 && flags.helmChart == "" {
+		return fmt.Errorf("k8s requires manifests with --file or a chart with --helm-chart")
+	}
+	if flags.severity != "" && report.SeverityLevel(flags.severity) < 0 {
+		return fmt.Errorf("--severity takes only %s values", strings.Join(report.Severities, ", "))
+	}
+	workloads, err := loadWorkloads(ctx, dockerCli, flags)
+	if err != nil {
+		return err
+	}
+	if len(workloads) == 0 {
+		return fmt.Errorf("no container image found in the manifests")
+	}
+	images := k8s.Images(workloads)
+	columns, err := scanMatrix(ctx, dockerCli, matrixOptions{}, images)
+	if err != nil {
+		return err
+	}
+	results := aggregateWorkloads(workloads, images, columns)
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			return err
+		}
+	} else if err := writeWorkloads(dockerCli.Out(), results); err != nil {
+		return err
+	}
+	return workloadsError(results, flags.severity)
+}
+
+// loadWorkloads parses the workloads of the manifest files and of the rendered chart
+func loadWorkloads(ctx context.Context, dockerCli command.Cli, flags k8sOptions) ([]k8s.Workload, error) {
+	var workloads []k8s.Workload
+	for _, file := range flags.files {
+		fileWorkloads, err := fileWorkloads(dockerCli, file)
+		if err != nil {
+			return nil, err
+		}
+		workloads = append(workloads, fileWorkloads...)
+	}
+	if flags.helmChart != "" {
+		rendered, err := k8s.RenderChart(ctx, flags.helmChart, flags.helmValues)
+		if err != nil {
+			return nil, err
+		}
+		chartWorkloads, err := k8s.ParseManifests(bytes.NewReader(rendered))
+		if err != nil {
+			return nil, err
+		}
+		workloads = append(workloads, chartWorkloads...)
+	}
+	return workloads, nil
+}
+
+// fileWorkloads parses the workloads of the standard input, a manifest file or the YAML files of a directory
+func fileWorkloads(dockerCli command.Cli, path string) ([]k8s.Workload, error) {
+	if path == "-" {
+		return k8s.ParseManifests(dockerCli.In())
+	}
+	var workloads []k8s.Workload
+	err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || (file != path && !isYAMLFile(file)) {
+			return nil
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint: errcheck
+		parsed, err := k8s.ParseManifests(f)
+		if err != nil {
+			return fmt.Errorf("%s: %s", file, err)
+		}
+		workloads = append(workloads, parsed...)
+		return nil
+	})
+	return workloads, err
+}
+
+func isYAMLFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// aggregateWorkloads sums the findings of the images of each workload, the images being scanned once
+func aggregateWorkloads(workloads []k8s.Workload, images []string, columns []report.MatrixColumn) []workloadResult {
+	byImage := map[string]report.MatrixColumn{}
+	for i, image := range images {
+		byImage[image] = columns[i]
+	}
+	results := make([]workloadResult, 0, len(workloads))
+	for _, workload := range workloads {
+		result := workloadResult{Workload: workload, Counts: map[string]int{}}
+		for _, image := range workload.Images {
+			column := byImage[image]
+			if column.Error != "" {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", image, column.Error))
+			}
+			for severity, count := range column.Counts {
+				result.Counts[severity] += count
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func writeWorkloads(out io.Writer, results []workloadResult) error {
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	header := []string{"WORKLOAD", "IMAGES"}
+	for i := len(report.Severities) - 1; i >= 0; i-- {
+		header = append(header, strings.ToUpper(report.Severities[i]))
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, result := range results {
+		row := []string{result.String(), fmt.Sprint(len(result.Images))}
+		for i := len(report.Severities) - 1; i >= 0; i-- {
+			row = append(row, fmt.Sprint(result.Counts[report.Severities[i]]))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, result := range results {
+		for _, scanErr := range result.Errors {
+			fmt.Fprintf(out, "%s: failed to scan %s\n", result.String(), scanErr)
+		}
+	}
+	return nil
+}
+
+// workloadsError fails on the images which couldn't be scanned, then on the vulnerabilities of the severity or higher
+func workloadsError(results []workloadResult, severity string) error {
+	failed := 0
+	vulnerable := false
+	for _, result := range results {
+		failed += len(result.Errors)
+		for level, count := range result.Counts {
+			if count > 0 && report.SeverityLevel(level) >= report.SeverityLevel(severity) {
+				vulnerable = true
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to scan the images of %d workload container(s)", failed)
+	}
+	if vulnerable {
+		return provider.NewVulnerabilitiesFoundError()
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/dockerfile"
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+const groupByLayer = "layer"
+
+func validateGroupBy(flags options) error {
+	if flags.groupBy != "" && flags.groupBy != groupByLayer {
+		return fmt.Errorf("--group-by takes only %q value", groupByLayer)
+	}
+	return nil
+}
+
+func validateLayerHeatmap(flags options) error {
+	if flags.layerHeatmap && (flags.quiet || flags.summary || flags.format != "") {
+		return fmt.Errorf("--layer-heatmap flag can't be used with --quiet, --summary or --format")
+	}
+	return nil
+}
+
+// attributeLayers groups the vulnerabilities by layer with --group-by layer, and correlates them with the size of the
+// layers with --layer-heatmap
+func attributeLayers(ctx context.Context, dockerCli command.Cli, flags options, ref string, results *scanResults) {
+	if flags.groupBy == groupByLayer {
+		results.layers = layerGroups(ctx, dockerCli, flags, ref, *results.report)
+	}
+	if flags.layerHeatmap {
+		results.heatmap = layerHeatmap(ctx, dockerCli, flags, ref, *results.report)
+	}
+}
+
+// layerHeatmap correlates the size of the layers with their vulnerabilities, using the image history and the layer
+// digests when the image is available on the engine
+func layerHeatmap(ctx context.Context, dockerCli command.Cli, flags options, ref string, scanReport report.Report) []report.LayerHeat {
+	layers, err := image.History(ctx, dockerCli.Client(), ref, baseImage(flags, scanReport))
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Warning: the image is not available on the engine, the layer sizes are unknown: %s\n", err)
+		layers = nil
+	}
+	digests, _ := image.LayerDigests(ctx, dockerCli.Client(), ref)
+	return report.LayerHeatmap(scanReport, layers, digests)
+}
+
+// layerGroups attributes the vulnerabilities of the scan result to the layers of the image,
+// using the image history when the image is available on the engine
+func layerGroups(ctx context.Context, dockerCli command.Cli, flags options, ref string, scanReport report.Report) []report.LayerGroup {
+	layers, err := image.History(ctx, dockerCli.Client(), ref, baseImage(flags, scanReport))
+	if err != nil {
+		layers = nil
+	}
+	return report.GroupByLayer(scanReport, layers)
+}
+
+// keepFromImage extends keepVulnerability to filter out the vulnerabilities of the base image with --exclude-base,
+// whatever the provider. The vulnerabilities which can't be attributed to a layer are kept with a warning.
+func keepFromImage(ctx context.Context, dockerCli command.Cli, flags options, ref string, scanReport report.Report) func(report.Vulnerability) bool {
+	keep := keepVulnerability(flags)
+	if !flags.excludeBase {
+		return keep
+	}
+	baseLayers := baseLayers(ctx, dockerCli, flags, ref, scanReport)
+	unattributed := 0
+	for _, vuln := range scanReport.Vulnerabilities {
+		if _, attributed := report.FromBaseImage(vuln, baseLayers); !attributed {
+			unattributed++
+		}
+	}
+	if unattributed > 0 {
+		fmt.Fprintf(dockerCli.Err(), "Warning: %d vulnerabilities could not be attributed to a layer and are kept with --exclude-base\n", unattributed)
+	}
+	return func(vuln report.Vulnerability) bool {
+		fromBase, _ := report.FromBaseImage(vuln, baseLayers)
+		return !fromBase && keep(vuln)
+	}
+}
+
+// baseLayers returns the diff IDs of the base image layers, when the provider reports the layers of the vulnerabilities
+func baseLayers(ctx context.Context, dockerCli command.Cli, flags options, ref string, scanReport report.Report) map[string]bool {
+	layers := map[string]bool{}
+	withLayer := false
+	for _, vuln := range scanReport.Vulnerabilities {
+		withLayer = withLayer || vuln.Layer != ""
+	}
+	if !withLayer {
+		return layers
+	}
+	diffIDs, err := image.BaseLayers(ctx, dockerCli.Client(), ref, baseImage(flags, scanReport))
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Warning: could not find the layers of the base image: %s\n", err)
+	}
+	for _, diffID := range diffIDs {
+		layers[diffID] = true
+	}
+	return layers
+}
+
+// baseImage returns the base image of the Dockerfile given with --file, or the one detected by the provider
+func baseImage(flags options, scanReport report.Report) string {
+	if flags.dockerFilePath != "" {
+		if stages, err := parseDockerfile(flags); err == nil {
+			if fromDockerfile := dockerfile.BaseImage(stages); fromDockerfile != "" && fromDockerfile != "scratch" {
+				return fromDockerfile
+			}
+		}
+	}
+	return scanReport.BaseImage
+}
@@ -17,36 +17,58 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"os/signal"
 	"runtime"
 	"syscall"
+	"time"
 
 	"github.com/docker/cli/cli-plugins/manager"
 	"github.com/docker/cli/cli-plugins/plugin"
 	"github.com/docker/cli/cli/command"
+	cliConfig "github.com/docker/cli/cli/config"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/scan-cli-plugin/config"
 	"github.com/docker/scan-cli-plugin/internal"
+	"github.com/docker/scan-cli-plugin/internal/authentication"
+	"github.com/docker/scan-cli-plugin/internal/budget"
 	"github.com/docker/scan-cli-plugin/internal/optin"
 	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
 	"github.com/spf13/cobra"
 )
 
 func main() {
+	if isHooksInvocation(os.Args) {
+		if err := runHooks(os.Stdout, cliConfig.LoadDefaultConfigFile(os.Stderr), os.Args[3]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 	ctx, closeFunc := newSigContext()
 	defer closeFunc()
 	plugin.Run(func(dockerCli command.Cli) *cobra.Command {
 		cmd := newScanCmd(ctx, dockerCli)
 		originalPreRun := cmd.PersistentPreRunE
 		cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+			selected, err := selectContext(cmd)
+			if err != nil {
+				return err
+			}
 			if err := plugin.PersistentPreRunE(cmd, args); err != nil {
 				return err
 			}
+			if selected {
+				if err := exportDockerHost(dockerCli); err != nil {
+					return err
+				}
+			}
+			applyTheme(dockerCli)
 			if originalPreRun != nil {
 				return originalPreRun(cmd, args)
 			}
@@ -61,47 +83,260 @@ func main() {
 }
 
 type options struct {
-	login          bool
-	token          string
-	dependencyTree bool
-	dockerFilePath string
-	excludeBase    bool
-	jsonFormat     bool
-	showVersion    bool
-	forceOptIn     bool
-	forceOptOut    bool
-	severity       string
-	groupIssues    bool
+	login            bool
+	token            string
+	dependencyTree   bool
+	dockerFilePath   string
+	excludeBase      bool
+	jsonFormat       bool
+	showVersion      bool
+	forceOptIn       bool
+	forceOptOut      bool
+	severity         string
+	groupIssues      bool
+	maxImageAge      int
+	exitCodeOnVuln   int
+	exitCodeOnError  int
+	groupBy          string
+	layerHeatmap     bool
+	showPaths        bool
+	yaraRules        []string
+	binaries         bool
+	binarySignatures string
+	scopes           []string
+	caCert           string
+	budget           int
+	budgetOrder      string
+	noCache          bool
+	exports          []string
+	watch            bool
+	createJira       bool
+	jiraSeverity     string
+	quiet            bool
+	summary          bool
+	email            bool
+	input            string
+	format           string
+	policy           string
+	licenses         bool
+	deniedLicenses   []string
+	verifyLayers     bool
+	simulateSquash   bool
+	// budgets are the numbers of vulnerabilities allowed per severity with --max-critical, --max-high, --max-medium
+	// and --max-low
+	budgets map[string]int
+	// waiver is the signed waiver letting the image pass the gate despite its violations, verified with waiverKey
+	waiver    string
+	waiverKey string
+	// runtimeChecks is the ID of the container whose runtime configuration is checked
+	runtimeChecks   string
+	excludedCVEs    []string
+	nonRuntimePaths []string
+	onlyFixable     bool
+	vex             []string
+	platform        string
+	buildArgs       []string
+	enrich          []string
+	sortBy          string
+	syncIgnores     bool
+	ignoreFile      string
+	interactive     bool
+	metricsFile     string
+	org             string
+	projectName     string
+	githubSummary   bool
+	baseline        string
+	allPlatforms    bool
+	failOn          string
+	jsonFile        string
+	debug           bool
+	timeout         time.Duration
+	provider        string
+	sbomInput       string
+	// profile is the name of the account the scans run under, instead of the default one
+	profile string
+	// retries is the number of times the DockerScanID retrieval and the scan are tried again after a transient failure
+	retries    int
+	retryDelay time.Duration
+	// isolated runs the Snyk provider in a container instead of on the host
+	isolated bool
+	// softFail prints the findings but always exits with 0, the exit code of the scan being written in the JSON results
+	softFail bool
+	// checkEOL reads the base OS release of the image to check its end of life
+	checkEOL bool
+	// pushResults posts the results to the collector of pushResultsURL, or of the configuration if empty
+	pushResults    bool
+	pushResultsURL string
+	// notifications are the notifications of the configuration, unless disabled with noNotify
+	notifications []config.NotificationConfig
+	noNotify      bool
+	// trackAge records when each vulnerability was first seen, to report its age
+	trackAge bool
+	// history records the counts of the scan in the history shown by docker scan history
+	history bool
 }
 
 func newScanCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
 	var flags options
 	cmd := &cobra.Command{
-		Short:       "Docker Scan",
-		Long:        `A tool to scan your images`,
+		Short: "Docker Scan",
+		Long: `A tool to scan your images
+
+To scan an image, run 'docker scan [OPTIONS] IMAGE'`,
 		Use:         "scan [OPTIONS] IMAGE",
 		Annotations: map[string]string{},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			enableDebug(dockerCli, flags)
+			if err := validateExitCodes(flags); err != nil {
+				return err
+			}
+			// checked before the scan, as its failures are reported as warnings with --soft-fail
+			if err := validateSoftFail(flags); err != nil {
+				return err
+			}
+			flags.budgets = severityBudgets(cmd)
+			if err := applyConfigDefaults(cmd, &flags); err != nil {
+				return exitCodeError(err, flags)
+			}
+			// the timeout may come from the configuration defaults
+			ctx, cancel := withTimeout(ctx, flags)
+			defer cancel()
 			if flags.showVersion {
-				return runVersion(ctx, dockerCli, flags)
+				return exitCodeError(runVersion(ctx, dockerCli, flags), flags)
 			}
 			if flags.login {
-				return runAuthentication(ctx, dockerCli, flags, args)
+				return exitCodeError(runAuthentication(ctx, dockerCli, flags, args), flags)
+			}
+			if flags.sbomInput != "" {
+				return softFail(dockerCli, flags, exitCodeError(runSBOMScan(ctx, cmd, dockerCli, flags, args), flags))
+			}
+			if flags.watch {
+				return exitCodeError(runWatch(ctx, cmd, dockerCli, flags, args), flags)
 			}
-			return runScan(ctx, cmd, dockerCli, flags, args)
+			if flags.allPlatforms {
+				return softFail(dockerCli, flags, exitCodeError(runAllPlatformsScan(ctx, cmd, dockerCli, flags, args), flags))
+			}
+			if flags.budget != 0 {
+				return softFail(dockerCli, flags, exitCodeError(runBudgetScan(ctx, cmd, dockerCli, flags, args), flags))
+			}
+			return softFail(dockerCli, flags, exitCodeError(runScan(ctx, cmd, dockerCli, flags, args), flags))
 		},
 	}
+	cmd.PersistentFlags().String("context", "", "Scan with the engine of this docker context, without switching the current context")
+	cmd.AddCommand(
+		newAuthCmd(ctx, dockerCli),
+		newRecommendCmd(ctx, dockerCli),
+		newFixCmd(ctx, dockerCli),
+		newUpdateProviderCmd(ctx, dockerCli),
+		newPushCmd(ctx, dockerCli),
+		newCacheCmd(),
+		newMatrixCmd(ctx, dockerCli),
+		newRepoCmd(ctx, dockerCli),
+		newExportRootfsCmd(ctx, dockerCli),
+		newExplainCmd(ctx, dockerCli),
+		newReportFPCmd(ctx, dockerCli),
+		newRefreshCmd(ctx, dockerCli),
+		newDiffCmd(ctx, dockerCli),
+		newCVECmd(ctx, dockerCli),
+		newContainerCmd(ctx, dockerCli),
+		newEngineCmd(ctx, dockerCli),
+		newQuotaCmd(dockerCli),
+		newK8sCmd(ctx, dockerCli),
+		newScheduleCmd(ctx, dockerCli),
+		newHistoryCmd(dockerCli),
+		newVEXCmd(ctx, dockerCli),
+		newWaiverCmd(ctx, dockerCli),
+		newTestdataCmd(ctx, dockerCli),
+		newAttestCmd(ctx, dockerCli),
+		newVerifyAttestationCmd(ctx, dockerCli),
+		newWebhookCmd(dockerCli),
+		newConfigCmd(dockerCli),
+		newOrgsCmd(dockerCli),
+		newMonitorCmd(ctx, dockerCli),
+		newAlertsCmd(ctx, dockerCli),
+		newSupportBundleCmd(ctx, dockerCli),
+		newDoctorCmd(ctx, dockerCli),
+		newVersionCmd(ctx, dockerCli),
+		newServeCmd(ctx, dockerCli),
+	)
 	cmd.Flags().BoolVar(&flags.login, "login", false, "Authenticate to the scan provider using an optional token (with --token), or web base token if empty")
 	cmd.Flags().StringVar(&flags.token, "token", "", "Authentication token to login to the third party scanning provider")
+	cmd.Flags().BoolVar(&flags.pushResults, "push-results", false, "Post the scan results to the collector of the docker scan configuration, for a central view of the scans")
+	cmd.Flags().StringVar(&flags.pushResultsURL, "push-results-url", "", "Collector the results are posted to with --push-results, instead of the configured one")
+	cmd.Flags().BoolVar(&flags.trackAge, "track-age", false, "Track since when each vulnerability is found in the image repository, and report its age")
+	cmd.Flags().BoolVar(&flags.history, "history", false, "Record the scan in the history of the image shown by docker scan history")
+	cmd.Flags().BoolVar(&flags.noNotify, "no-notify", false, "Don't send the notifications of the docker scan configuration")
+	cmd.Flags().StringVar(&flags.org, "org", "", "Run the scan in the given Snyk organization, by ID, name or slug, instead of the default one of the account")
+	cmd.Flags().StringVar(&flags.projectName, "project-name", "", "Name the Snyk project of the image instead of naming it after the image")
+	cmd.Flags().StringVar(&flags.profile, "profile", "", "Scan under the account of a profile created with docker scan auth login --profile")
 	cmd.Flags().BoolVar(&flags.dependencyTree, "dependency-tree", false, "Show dependency tree with scan results")
-	cmd.Flags().BoolVar(&flags.excludeBase, "exclude-base", false, "Exclude base image from vulnerability scanning (requires --file)")
+	cmd.Flags().BoolVar(&flags.excludeBase, "exclude-base", false, "Exclude base image from vulnerability scanning (requires --file with Snyk)")
 	cmd.Flags().StringVarP(&flags.dockerFilePath, "file", "f", "", "Dockerfile associated with image, provides more detailed results")
+	cmd.Flags().StringArrayVar(&flags.buildArgs, "build-arg", nil, "Build arg the image was built with, resolving the ARG instructions of the Dockerfile (requires --file)")
 	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output results in JSON format")
+	cmd.Flags().StringVar(&flags.platform, "platform", "", "Scan the image of the given platform of a multi-platform image, like linux/arm64")
+	cmd.Flags().BoolVar(&flags.allPlatforms, "all-platforms", false, "Scan each platform of a multi-platform image, and report the vulnerabilities differing between them")
+	cmd.Flags().BoolVar(&flags.githubSummary, "github-summary", false, "Also write a markdown summary of the results to the job summary of the GitHub Actions step")
+	cmd.Flags().StringVar(&flags.baseline, "baseline", "", "Compare the results with a JSON report of docker scan in the GitHub job summary (requires --github-summary)")
+	cmd.Flags().StringVar(&flags.jsonFile, "json-file", "", "Also write the results in JSON format to the given file")
 	cmd.Flags().BoolVar(&flags.showVersion, "version", false, "Display version of the scan plugin")
 	cmd.Flags().BoolVar(&flags.forceOptIn, "accept-license", false, "Accept using a third party scanning provider")
 	cmd.Flags().BoolVar(&flags.forceOptOut, "reject-license", false, "Reject using a third party scanning provider")
 	cmd.Flags().StringVar(&flags.severity, "severity", "", "Only report vulnerabilities of provided level or higher (low|medium|high)")
+	cmd.Flags().StringSliceVar(&flags.excludedCVEs, "exclude-cve", nil, "Don't report the vulnerabilities with the given CVE or vulnerability IDs")
+	cmd.Flags().StringVar(&flags.ignoreFile, "ignore-file", "", "Don't report the vulnerabilities listed in the given ignore file, one ID per line")
+	cmd.Flags().BoolVar(&flags.interactive, "interactive", false, "Browse the findings in a terminal UI, and mark the ones to add to the ignore file")
+	cmd.Flags().BoolVar(&flags.syncIgnores, "sync-ignores", false, "Ignore the vulnerabilities excluded with --exclude-cve or suppressed by --vex in the Snyk projects monitoring the image too")
+	cmd.Flags().StringArrayVar(&flags.vex, "vex", nil, "Suppress the findings marked not_affected or fixed in the given OpenVEX or CSAF documents, and annotate the others")
+	cmd.Flags().BoolVar(&flags.onlyFixable, "only-fixable", false, "Only report the vulnerabilities with an available fix, and only fail on them")
+	cmd.Flags().StringVar(&flags.failOn, "fail-on", failOnAll, "Vulnerabilities changing the exit code, all or only the ones with an available fix (all|upgradable)")
+	cmd.Flags().StringSliceVar(&flags.nonRuntimePaths, "non-runtime-path", nil, "Report the vulnerabilities found in files matching the given globs, like build-only or documentation files, as informational")
 	cmd.Flags().BoolVar(&flags.groupIssues, "group-issues", false, "Aggregate duplicated vulnerabilities and group them to a single one (requires --json)")
+	cmd.Flags().BoolVar(&flags.checkEOL, "check-eol", false, "Warn when the base OS of the image reached its end of life, read from a container created from the image and never started")
+	cmd.Flags().IntVar(&flags.maxImageAge, "max-image-age", 0, "Warn when the image or its base image was built more than the given number of days ago")
+	cmd.Flags().StringSliceVar(&flags.enrich, "enrich", nil, "Enrich the vulnerabilities with the likelihood they are exploited: their EPSS score (epss), and whether CISA knows them to be exploited (kev)")
+	cmd.Flags().StringVar(&flags.sortBy, "sort-by", "", "Sort the vulnerabilities from the most to the least likely to be exploited (epss)")
+	cmd.Flags().StringVar(&flags.groupBy, "group-by", "", "Group vulnerabilities by the image layer which introduced them (layer)")
+	cmd.Flags().BoolVar(&flags.showPaths, "show-paths", false, "Print the dependency paths from the direct dependencies to the vulnerable packages of the applications")
+	cmd.Flags().BoolVar(&flags.layerHeatmap, "layer-heatmap", false, "Report the size and the vulnerabilities of each image layer, and the layers to refactor first")
+	cmd.Flags().StringSliceVar(&flags.yaraRules, "yara-rules", nil, "Scan the image layers for malware with the given YARA rules files (requires yara)")
+	cmd.Flags().BoolVar(&flags.binaries, "binaries", false, "Identify the standalone binaries of the image, not managed by the OS package manager")
+	cmd.Flags().StringVar(&flags.binarySignatures, "binary-signatures", "", "JSON file of additional signatures used to identify binaries (requires --binaries)")
+	cmd.Flags().BoolVar(&flags.licenses, "licenses", false, "Report the licenses of the OS and application packages of the image")
+	cmd.Flags().StringSliceVar(&flags.deniedLicenses, "deny-license", nil, "Report the packages with the given licenses as license issues, overrides the licenses configuration (requires --licenses)")
+	cmd.Flags().BoolVar(&flags.verifyLayers, "verify-layers", false, "Check the image layers against the build history recorded in the image configuration, to detect substituted layers")
+	cmd.Flags().BoolVar(&flags.simulateSquash, "simulate-squash", false, "Report the files, and the findings of the layer analyzers located in them, which would disappear if the image were squashed")
+	cmd.Flags().StringSliceVar(&flags.scopes, "scope", nil, "Only run the analyzers of the given scopes (os|app|config|secrets|licenses)")
+	cmd.Flags().StringVar(&flags.caCert, "ca-cert", "", "PEM file of additional CA certificates to trust for all outbound calls, overrides the caCert configuration")
+	cmd.Flags().IntVar(&flags.budget, "budget", 0, "Scan several images, only the given number of most important ones, and report the deferred images")
+	cmd.Flags().StringVar(&flags.budgetOrder, "budget-order", budget.RecentOrder, "Order used to pick the images scanned within the budget (recent|given|policy)")
+	cmd.Flags().BoolVar(&flags.noCache, "no-cache", false, "Scan the image again instead of using the cached results of a previous scan")
+	cmd.Flags().StringSliceVar(&flags.exports, "export", nil, "Export the results to a file, as FORMAT=PATH (backstage|servicenow)")
+	cmd.Flags().BoolVar(&flags.watch, "watch", false, "Scan the image again each time it is rebuilt or retagged, and print the changes")
+	cmd.Flags().BoolVar(&flags.createJira, "create-jira", false, "Open or update Jira issues for the findings, in the project of the docker scan configuration")
+	cmd.Flags().StringVar(&flags.jiraSeverity, "jira-severity", "high", "Only open Jira issues for findings of provided level or higher (low|medium|high|critical)")
+	cmd.Flags().StringVar(&flags.policy, "policy", "", "Evaluate the results against the rules of a policy file, the exit code follows the policy evaluation")
+	cmd.Flags().StringVar(&flags.waiver, "waiver", "", "Signed waiver letting the image pass the gate despite its violations, recorded in the audit log")
+	cmd.Flags().StringVar(&flags.waiverKey, "waiver-key", "", "Public key of the security team verifying the signature of the --waiver")
+	for _, severity := range report.Severities {
+		cmd.Flags().Int(budgetFlag(severity), 0, fmt.Sprintf("Fail only when more %s vulnerabilities than the given number are found", severity))
+	}
+	cmd.Flags().BoolVarP(&flags.quiet, "quiet", "q", false, "Only print the number of findings per severity")
+	cmd.Flags().BoolVar(&flags.summary, "summary", false, "Only print a table with a line per CVE")
+	cmd.Flags().StringVar(&flags.format, "format", "", "Print the report as a standalone document instead of text, or stream the scan events as newline-delimited JSON (html|pdf|junit|ndjson)")
+	cmd.Flags().BoolVar(&flags.email, "email", false, "Send the HTML report by email, with the SMTP server of the docker scan configuration")
+	cmd.Flags().StringVar(&flags.input, "input", "", "Scan an image archive created by docker save, or an OCI image layout directory or archive, instead of an image of the engine")
+	cmd.Flags().StringVar(&flags.sbomInput, "sbom-input", "", "Scan a CycloneDX or SPDX JSON SBOM instead of an image, against the current vulnerabilities")
+	cmd.Flags().DurationVar(&flags.timeout, "timeout", 0, "Stop the scan and the provider if it doesn't complete within the given duration, like 10m")
+	cmd.Flags().IntVar(&flags.retries, "retries", provider.DefaultRetryPolicy.Retries, "Number of times the scan is tried again after a transient network failure, 0 to fail at once")
+	cmd.Flags().DurationVar(&flags.retryDelay, "retry-delay", provider.DefaultRetryPolicy.InitialDelay, "Wait before the first retry, doubled at each retry")
+	cmd.Flags().StringVar(&flags.provider, "provider", "", "Scan provider, overrides the provider of the configuration defaults (binary|image|trivy|grype|hub)")
+	cmd.Flags().BoolVar(&flags.isolated, "isolated", false, "Run the Snyk provider in a container instead of on the host, with only the variables it needs in its environment")
+	cmd.Flags().StringVar(&flags.metricsFile, "metrics-file", "", "Append a JSON line summarizing the scan to the given file, overrides the metrics-file setting of the configuration")
+	cmd.Flags().BoolVar(&flags.debug, "debug", false, "Print debug logs: provider command lines with the secrets redacted, timings and HTTP calls")
+	cmd.Flags().IntVar(&flags.exitCodeOnVuln, "exit-code-on-vuln", defaultExitCodeOnVuln, "Exit code returned when vulnerabilities are found, 0 to succeed anyway")
+	cmd.Flags().IntVar(&flags.exitCodeOnError, "exit-code-on-error", defaultExitCodeOnError, "Exit code returned when the scan fails")
+	cmd.Flags().BoolVar(&flags.softFail, "soft-fail", false, "Print the findings but always exit with 0, the exit code the scan would have returned is written in the JSON results")
 
 	return cmd
 }
@@ -114,42 +349,171 @@ func configureProvider(ctx context.Context, dockerCli command.Cli, flags options
 
 	opts := []provider.Ops{
 		provider.WithContext(ctx),
-		provider.WithPath(conf.Path),
+		provider.WithPath(providerPath(conf)),
+		providerTokenStore(dockerCli),
+		provider.WithCACert(caCertPath(flags, conf)),
 	}
+	opts = append(opts, environmentOptions(conf)...)
 	opts = append(opts, options...)
-	if flags.jsonFormat {
+	if flags.retries < 0 {
+		return nil, fmt.Errorf("--retries flag must be positive or zero")
+	}
+	opts = append(opts, provider.WithRetryPolicy(provider.RetryPolicy{
+		Retries:      flags.retries,
+		InitialDelay: flags.retryDelay,
+		MaxDelay:     provider.DefaultRetryPolicy.MaxDelay,
+	}))
+	// the credentials of the profile replace the default ones
+	profileOpts, err := profileOptions(dockerCli, conf, flags.profile)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, profileOpts...)
+	oidcOpts, err := oidcOptions(ctx, conf, flags.profile, caCertPath(flags, conf))
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, oidcOpts...)
+	flagsOpts, err := scanFlagsOptions(flags)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, flagsOpts...)
+	defaultProvider, err := provider.NewProvider(opts...)
+	if err != nil {
+		return nil, err
+	}
+	switch selectedProvider(flags, conf) {
+	case trivyProvider:
+		return provider.NewTrivyProvider(defaultProvider)
+	case grypeProvider:
+		return provider.NewGrypeProvider(defaultProvider)
+	case hubProvider:
+		return provider.NewHubProvider(defaultProvider)
+	}
+	if useProviderImage(selectedProvider(flags, conf), defaultProvider) {
+		return provider.NewDockerSnykProvider(dockerCli, defaultProvider)
+	}
+	if !provider.IsBinaryAvailable(defaultProvider) && offerProviderDownload(ctx, dockerCli, caCertPath(flags, conf)) {
+		if defaultProvider, err = provider.NewProvider(append(opts, provider.WithPath(provider.DownloadedBinaryPath()))...); err != nil {
+			return nil, err
+		}
+	}
+	return provider.NewSnykProvider(defaultProvider)
+}
+
+// useProviderImage returns true if Snyk runs in a container, as selected with --provider or in the defaults of the
+// docker scan configuration, or on Linux when the Snyk binary is not installed
+func useProviderImage(name string, providerOpts provider.Options) bool {
+	switch name {
+	case imageProvider:
+		return true
+	case binaryProvider:
+		return false
+	default:
+		return runtime.GOOS == "linux" && !provider.UseExternalBinary(providerOpts)
+	}
+}
+
+// validatePluginFlags checks the flags of the features implemented by the plugin on top of the provider
+func validatePluginFlags(flags options) error {
+	for _, validate := range pluginFlagsValidators {
+		if err := validate(flags); err != nil {
+			return err
+		}
+	}
+	_, err := exportTargets(flags)
+	return err
+}
+
+// pluginFlagsValidators check the flags of the scans, in order
+var pluginFlagsValidators = []func(options) error{
+	validateProvider,
+	validateIsolated,
+	validateGroupBy,
+	validateLayerHeatmap,
+	validateScopes,
+	validateFailOn,
+	validateOutputMode,
+	validateShowPaths,
+	validateJiraSeverity,
+	validateInput,
+	validateSBOMInput,
+	validatePolicy,
+	validateBudgets,
+	validateWaiver,
+	validatePushResults,
+	validatePlatform,
+	validateBuildArgs,
+	validateEnrich,
+	validateGitHubSummary,
+	validateOrg,
+	validateInteractive,
+	validateSyncIgnores,
+}
+
+// scanFlagsOptions converts the scan flags to provider options
+func scanFlagsOptions(flags options) ([]provider.Ops, error) {
+	var opts []provider.Ops
+	if err := validatePluginFlags(flags); err != nil {
+		return nil, err
+	}
+	opts = append(opts, scopeOptions(flags)...)
+	opts = append(opts, orgOptions(flags)...)
+	if flags.jsonFormat || needsReport(flags) {
 		opts = append(opts, provider.WithJSON())
+	}
+	if flags.jsonFormat {
 		if flags.groupIssues {
 			opts = append(opts, provider.WithGroupIssues())
 		}
 	} else if flags.groupIssues {
 		return nil, fmt.Errorf("--json flag is mandatory to use --group-issues flag")
 	}
-	if flags.dockerFilePath != "" {
-		opts = append(opts, provider.WithDockerFile(flags.dockerFilePath))
-		if flags.excludeBase {
-			opts = append(opts, provider.WithoutBaseImageVulnerabilities())
-		}
-	} else if flags.excludeBase {
-		return nil, fmt.Errorf("--file flag is mandatory to use --exclude-base flag")
+	dockerFileOpts, err := dockerFileOptions(flags)
+	if err != nil {
+		return nil, err
 	}
+	opts = append(opts, dockerFileOpts...)
 	if flags.dependencyTree {
 		opts = append(opts, provider.WithDependencyTree())
 	}
+	if flags.platform != "" {
+		opts = append(opts, provider.WithPlatform(flags.platform))
+	}
 	if flags.severity != "" {
 		if flags.severity != "low" && flags.severity != "medium" && flags.severity != "high" {
 			return nil, fmt.Errorf("--severity takes only 'low', 'medium' or 'high' values")
 		}
 		opts = append(opts, provider.WithSeverity(flags.severity))
 	}
-	defaultProvider, err := provider.NewProvider(opts...)
-	if err != nil {
-		return nil, err
+	return opts, nil
+}
+
+func dockerFileOptions(flags options) ([]provider.Ops, error) {
+	if flags.dockerFilePath == "" {
+		if flags.excludeBase && usesSnyk(flags.provider) {
+			// the other providers report the layer of the vulnerabilities, used by the plugin to exclude the base image
+			return nil, fmt.Errorf("--file flag is mandatory to use --exclude-base flag")
+		}
+		return nil, nil
 	}
-	if runtime.GOOS == "linux" && !provider.UseExternalBinary(defaultProvider) {
-		return provider.NewDockerSnykProvider(dockerCli, defaultProvider)
+	opts := []provider.Ops{provider.WithDockerFile(flags.dockerFilePath)}
+	if flags.excludeBase {
+		opts = append(opts, provider.WithoutBaseImageVulnerabilities())
 	}
-	return provider.NewSnykProvider(defaultProvider)
+	return opts, nil
+}
+
+func providerTokenStore(dockerCli command.Cli) provider.Ops {
+	return provider.WithTokenStore(authentication.NewProviderTokenStore(
+		dockerCli.ConfigFile().GetCredentialsStore(authentication.ProviderServerAddress)))
+}
+
+func hubAuthConfig(dockerCli command.Cli) provider.Ops {
+	return provider.WithAuthConfig(func(hub *registry.IndexInfo) types.AuthConfig {
+		return resolveAuthConfig(dockerCli, hub)
+	})
 }
 
 func checkConsent(flags options, dockerCli command.Streams) (config.Config, error) {
@@ -157,7 +521,8 @@ func checkConsent(flags options, dockerCli command.Streams) (config.Config, erro
 	if err != nil {
 		return config.Config{}, err
 	}
-	if flags.showVersion {
+	// the consent is only required to use Snyk
+	if flags.showVersion || !usesSnyk(selectedProvider(flags, conf)) {
 		return conf, nil
 	}
 
@@ -173,7 +538,8 @@ func checkConsent(flags options, dockerCli command.Streams) (config.Config, erro
 			conf.Optin = false
 		}
 
-		if err := config.SaveConfigFile(conf); err != nil {
+		// a read-only configuration keeps the consent for this run only, like --accept-license on each run
+		if err := config.SaveConfigFile(conf); err != nil && !config.IsReadOnly(err) {
 			return config.Config{}, err
 		}
 		if !conf.Optin {
@@ -193,7 +559,7 @@ func runVersion(ctx context.Context, dockerCli command.Cli, flags options) error
 	if err != nil {
 		return err
 	}
-	fmt.Println(version)
+	fmt.Fprintln(dockerCli.Out(), version)
 	return nil
 }
 
@@ -205,32 +571,98 @@ func runAuthentication(ctx context.Context, dockerCli command.Cli, flags options
 	if err != nil {
 		return err
 	}
-	return scanProvider.Authenticate(flags.token)
+	if err := scanProvider.Authenticate(flags.token); err != nil {
+		return err
+	}
+	warnPlaintextToken(dockerCli, flags)
+	return nil
+}
+
+// warnPlaintextToken warns that the Snyk token is saved in plain text when no credentials store is configured
+func warnPlaintextToken(dockerCli command.Cli, flags options) {
+	conf, err := config.ReadConfigFile()
+	if err != nil || !usesSnyk(selectedProvider(flags, conf)) {
+		return
+	}
+	address := authentication.ProviderServerAddress
+	if flags.profile != "" {
+		address = authentication.ProfileServerAddress(flags.profile)
+	}
+	if authentication.PlaintextStore(dockerCli.ConfigFile(), address) {
+		fmt.Fprintf(dockerCli.Err(), "Warning: no credentials store is configured, the Snyk token is saved in plain text in %s\n", dockerCli.ConfigFile().Filename)
+	}
 }
 
 func runScan(ctx context.Context, cmd *cobra.Command, dockerCli command.Cli, flags options, args []string) error {
-	scanProvider, err := configureProvider(ctx, dockerCli, flags, provider.WithAuthConfig(func(hub *registry.IndexInfo) types.AuthConfig {
-		return command.ResolveAuthConfig(context.Background(), dockerCli, hub)
-	}))
-	if len(args) != 1 {
-		if err := cmd.Usage(); err != nil {
-			return err
-		}
-		return fmt.Errorf(`"docker scan" requires exactly 1 argument`)
+	if err := checkTerminal(dockerCli, flags); err != nil {
+		return err
+	}
+	start := time.Now()
+	results, err := scanImage(ctx, cmd, dockerCli, flags, args)
+	recordMetrics(dockerCli, flags, args, start, results, err)
+	return err
+}
+
+// scanImage scans the image and prints the results
+func scanImage(ctx context.Context, cmd *cobra.Command, dockerCli command.Cli, flags options, args []string) (scanResults, error) {
+	providerOut := bytes.NewBuffer(nil)
+	scanCache := newScanCache(flags, dockerCli.Out())
+	if flags.jsonFormat || needsReport(flags) {
+		scanCache = newScanCache(flags, providerOut)
+	}
+	providerOps := []provider.Ops{hubAuthConfig(dockerCli), provider.WithStreams(scanCache.writer(), dockerCli.Err())}
+	scanProvider, err := configureProvider(ctx, dockerCli, flags, providerOps...)
+	if err := checkScanArgs(cmd, flags, args); err != nil {
+		return scanResults{}, err
 	}
 	if err != nil {
-		return err
+		return scanResults{}, err
+	}
+	ref, cleanup, err := scanReference(ctx, dockerCli, flags, args)
+	if err != nil {
+		return scanResults{}, err
 	}
-	err = scanProvider.Scan(args[0])
-	if _, ok := err.(*exec.ExitError); ok {
-		os.Exit(1)
+	defer cleanup()
+	if denied, err := deniedByPolicy(flags, ref); err != nil || denied != nil {
+		return deniedResults(dockerCli, flags, ref, denied, err)
 	}
-	return err
+	analyzers, err := newLayerAnalyzers(flags)
+	if err != nil {
+		return scanResults{}, err
+	}
+	emitEvents(dockerCli, flags, scanStartedEvent(flags, ref))
+	if runsProvider(flags) {
+		stopProgress := startProgress(dockerCli, flags, "Scanning "+imageName(flags, ref))
+		err = scanCache.scan(ctx, dockerCli, flags, scanProvider, ref)
+		stopProgress()
+	} else {
+		err = writeEmptyProviderOutput(providerOut, ref)
+	}
+	if ctx.Err() != nil {
+		return scanResults{}, interruptedScanError(ctx, dockerCli, flags, providerOut.Bytes())
+	}
+	results, analyzeErr := analyzeImage(ctx, dockerCli, flags, ref, providerOut.Bytes(), analyzers)
+	results.quotaExceeded = provider.IsQuotaExceededError(err)
+	scanErr := results.gateError(err, analyzeErr, flags.failOn)
+	results.softFail = softFailOf(flags, scanErr)
+	if writeErr := writeResults(dockerCli, flags, providerOut.Bytes(), results); writeErr != nil {
+		return results, writeErr
+	}
+	if publishErr := publishResults(ctx, dockerCli, flags, results); publishErr != nil {
+		return results, publishErr
+	}
+	recordHistory(ctx, dockerCli, flags, scanProvider, providerOut.Bytes(), results)
+	if analyzeErr != nil && !results.quotaExceeded {
+		return results, analyzeErr
+	}
+	scanErr = applyWaiver(ctx, dockerCli, flags, ref, scanErr)
+	recordScanResult(ctx, dockerCli, flags, ref, scanErr)
+	return results, scanErr
 }
 
 func newSigContext() (context.Context, func()) {
 	ctx, cancel := context.WithCancel(context.Background())
-	s := make(chan os.Signal)
+	s := make(chan os.Signal, 1)
 	signal.Notify(s, syscall.SIGTERM, syscall.SIGINT)
 	go func() {
 		<-s
@@ -0,0 +1,110 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type matrixOptions struct {
+	dockerFilePath string
+	jsonFormat     bool
+}
+
+func newMatrixCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags matrixOptions
+	cmd := &cobra.Command{
+		Use:   "matrix [OPTIONS] IMAGE IMAGE...",
+		Short: "Compare the number of vulnerabilities per severity of several images, like the tags of an image",
+		Args:  cli.RequiresMinArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMatrix(ctx, dockerCli, flags, args)
+		},
+	}
+	cmd.Flags().StringVarP(&flags.dockerFilePath, "file", "f", "", "Dockerfile associated with the images, provides more detailed results")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the matrix in JSON format")
+	return cmd
+}
+
+func runMatrix(ctx context.Context, dockerCli command.Cli, flags matrixOptions, images []string) error {
+	columns, err := scanMatrix(ctx, dockerCli, flags, images)
+	if err != nil {
+		return err
+	}
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(columns)
+	}
+	return report.WriteMatrix(dockerCli.Out(), columns)
+}
+
+// scanMatrix scans each image and counts its findings per severity
+func scanMatrix(ctx context.Context, dockerCli command.Cli, flags matrixOptions, images []string) ([]report.MatrixColumn, error) {
+	providerOut := bytes.NewBuffer(nil)
+	scanProvider, err := configureProvider(ctx, dockerCli, options{
+		dockerFilePath: flags.dockerFilePath,
+		jsonFormat:     true,
+	}, hubAuthConfig(dockerCli), provider.WithStreams(providerOut, dockerCli.Err()))
+	if err != nil {
+		return nil, err
+	}
+	var columns []report.MatrixColumn
+	for _, image := range images {
+		providerOut.Reset()
+		columns = append(columns, matrixColumn(scanProvider, providerOut, image))
+	}
+	return columns, nil
+}
+
+// scanImageReport scans an image with the provider JSON output, failing when the provider can't scan it
+func scanImageReport(ctx context.Context, dockerCli command.Cli, dockerFilePath, image string) (report.Report, error) {
+	providerOut := bytes.NewBuffer(nil)
+	scanProvider, err := configureProvider(ctx, dockerCli, options{
+		dockerFilePath: dockerFilePath,
+		jsonFormat:     true,
+	}, hubAuthConfig(dockerCli), provider.WithStreams(providerOut, dockerCli.Err()))
+	if err != nil {
+		return report.Report{}, err
+	}
+	// vulnerabilities are expected, provider failures are reported in the JSON output
+	if err := scanProvider.Scan(image); err != nil && !provider.IsVulnerabilitiesFoundError(err) && !provider.IsProviderFailedError(err) {
+		return report.Report{}, err
+	}
+	return report.Parse(providerOut.Bytes())
+}
+
+// matrixColumn scans an image, a failed scan is reported in the matrix instead of stopping the comparison
+func matrixColumn(scanProvider provider.Provider, providerOut *bytes.Buffer, image string) report.MatrixColumn {
+	// vulnerabilities are expected, provider failures are reported in the JSON output
+	if err := scanProvider.Scan(image); err != nil && !provider.IsVulnerabilitiesFoundError(err) && !provider.IsProviderFailedError(err) {
+		return report.MatrixColumn{Image: image, Error: err.Error()}
+	}
+	scanReport, err := report.Parse(providerOut.Bytes())
+	if err != nil {
+		return report.MatrixColumn{Image: image, Error: err.Error()}
+	}
+	return report.NewMatrixColumn(image, scanReport.Vulnerabilities)
+}
@@ -0,0 +1,67 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/dockerfile"
+	"github.com/docker/scan-cli-plugin/internal/image"
+)
+
+// imageMetadata inspects the scanned image on the engine, the metadata are
+// best effort and nil is returned if the image is not available locally
+func imageMetadata(ctx context.Context, dockerCli command.Cli, flags options, ref string) *image.Metadata {
+	now := time.Now()
+	metadata, err := image.Inspect(ctx, dockerCli.Client(), ref, now)
+	if err != nil {
+		return nil
+	}
+	if flags.checkEOL {
+		if metadata, err = image.WithOSRelease(ctx, dockerCli.Client(), metadata, ref, now); err != nil {
+			return nil
+		}
+	}
+	if flags.dockerFilePath != "" {
+		if stages, err := parseDockerfile(flags); err == nil {
+			if baseImage := dockerfile.BaseImage(stages); baseImage != "" && baseImage != "scratch" {
+				metadata = image.WithBaseImage(ctx, dockerCli.Client(), metadata, baseImage, now)
+			}
+		}
+	}
+	return &metadata
+}
+
+func printMetadataWarnings(out io.Writer, metadata image.Metadata, maxImageAge int) {
+	// the releases missing from the end of life dates are only reported in the JSON output
+	if metadata.EOLStatus == image.EOLReached {
+		fmt.Fprintf(out, "\nWarning: the base OS of the image (%s) reached its end of life on %s\n", metadata.OS.PrettyName, metadata.EOLDate)
+	}
+	if maxImageAge <= 0 {
+		return
+	}
+	if metadata.AgeDays > maxImageAge {
+		fmt.Fprintf(out, "\nWarning: the image was built %d days ago, consider rebuilding it\n", metadata.AgeDays)
+	}
+	if metadata.BaseImageAgeDays > maxImageAge {
+		fmt.Fprintf(out, "\nWarning: the base image %s was built %d days ago, consider using a more recent tag\n", metadata.BaseImage, metadata.BaseImageAgeDays)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/metrics"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// recordMetrics appends the summary of the scan to the metrics file, set with --metrics-file or in the configuration.
+// The scan doesn't fail when the file can't be written.
+func recordMetrics(dockerCli command.Cli, flags options, args []string, start time.Time, results scanResults, scanErr error) {
+	if flags.metricsFile == "" {
+		return
+	}
+	record := metrics.Record{
+		Time:            start.UTC(),
+		Image:           results.ref,
+		Provider:        flags.provider,
+		DurationSeconds: time.Since(start).Seconds(),
+		Status:          metrics.StatusPassed,
+		Counts:          map[string]int{},
+	}
+	if usesSnyk(flags.provider) {
+		record.Provider = "snyk"
+	}
+	if record.Image == "" && len(args) > 0 {
+		record.Image = args[0]
+	}
+	if results.report != nil {
+		record.Counts = report.NewMatrixColumn(results.ref, results.findings()).Counts
+	}
+	for _, count := range record.Counts {
+		record.Total += count
+	}
+	switch {
+	case provider.IsVulnerabilitiesFoundError(scanErr):
+		record.Status = metrics.StatusVulnerable
+	case scanErr != nil:
+		record.Status = metrics.StatusError
+		record.Error = scanErr.Error()
+	}
+	if err := metrics.Append(flags.metricsFile, record); err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Warning: failed to record the scan in the metrics file: %s\n", err)
+	}
+}
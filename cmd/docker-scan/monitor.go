@@ -0,0 +1,132 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+// defaultDockerfile is the Dockerfile of the working directory, monitored with the image when --file is not set
+const defaultDockerfile = "Dockerfile"
+
+type monitorOptions struct {
+	dockerFilePath string
+	excludeBase    bool
+	platform       string
+	org            string
+	projectName    string
+	profile        string
+	jsonFormat     bool
+}
+
+func newMonitorCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags monitorOptions
+	cmd := &cobra.Command{
+		Use:   "monitor [OPTIONS] IMAGE",
+		Short: "Register an image in Snyk for continuous monitoring, to be alerted of the vulnerabilities disclosed after it is shipped",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMonitor(ctx, cmd, dockerCli, flags, args[0])
+		},
+	}
+	cmd.Flags().StringVarP(&flags.dockerFilePath, "file", "f", "", "Dockerfile of the image, the Dockerfile of the working directory by default")
+	cmd.Flags().BoolVar(&flags.excludeBase, "exclude-base", false, "Don't monitor the vulnerabilities of the base image")
+	cmd.Flags().StringVar(&flags.platform, "platform", "", "Monitor the image of the given platform of a multi-platform image")
+	cmd.Flags().StringVar(&flags.org, "org", "", "Monitor the image in the given Snyk organization")
+	cmd.Flags().StringVar(&flags.projectName, "project-name", "", "Name the Snyk project of the image instead of naming it after the image")
+	cmd.Flags().StringVar(&flags.profile, "profile", "", "Monitor the image with the Snyk account of a profile")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the monitored projects in JSON format")
+	return cmd
+}
+
+func runMonitor(ctx context.Context, cmd *cobra.Command, dockerCli command.Cli, flags monitorOptions, image string) error {
+	scanFlags := options{
+		provider:       binaryProvider,
+		dockerFilePath: flags.dockerFilePath,
+		excludeBase:    flags.excludeBase,
+		platform:       flags.platform,
+		org:            flags.org,
+		projectName:    flags.projectName,
+		profile:        flags.profile,
+		jsonFormat:     flags.jsonFormat,
+	}
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	if conf.Defaults != nil {
+		applySnykDefaults(cmd.Flags().Changed, &scanFlags, *conf.Defaults)
+	}
+	if _, err := os.Stat(defaultDockerfile); scanFlags.dockerFilePath == "" && err == nil {
+		scanFlags.dockerFilePath = defaultDockerfile
+	}
+	scanProvider, err := configureProvider(ctx, dockerCli, scanFlags, hubAuthConfig(dockerCli), provider.WithStreams(dockerCli.Out(), dockerCli.Err()))
+	if err != nil {
+		return err
+	}
+	monitor, ok := scanProvider.(provider.Monitor)
+	if !ok {
+		return fmt.Errorf("monitoring an image requires the Snyk binary")
+	}
+	metadata := monitorMetadata(scanFlags.dockerFilePath)
+	fmt.Fprintf(dockerCli.Err(), "Monitoring %s with %s\n", image, metadata)
+	return monitor.Monitor(image, metadata)
+}
+
+// monitorMetadata captures the origin of the image: the git remote of the repository of the Dockerfile, or of the
+// working directory, and the path of the Dockerfile in the repository
+func monitorMetadata(dockerfile string) provider.MonitorMetadata {
+	dir := "."
+	if dockerfile != "" {
+		dir = filepath.Dir(dockerfile)
+	}
+	metadata := provider.MonitorMetadata{GitRemote: git(dir, "config", "--get", "remote.origin.url")}
+	if dockerfile == "" {
+		return metadata
+	}
+	metadata.Dockerfile = filepath.ToSlash(dockerfile)
+	root := git(dir, "rev-parse", "--show-toplevel")
+	absolute, err := filepath.Abs(dockerfile)
+	if root == "" || err != nil {
+		return metadata
+	}
+	if relative, err := filepath.Rel(root, absolute); err == nil && !strings.HasPrefix(relative, "..") {
+		metadata.Dockerfile = filepath.ToSlash(relative)
+	}
+	return metadata
+}
+
+// git returns the output of a git command run in the directory, empty when git is missing or the command fails
+func git(dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
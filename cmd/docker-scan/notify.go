@@ -0,0 +1,130 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/email"
+	"github.com/docker/scan-cli-plugin/internal/notify"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/docker/scan-cli-plugin/webhook"
+)
+
+func validateNotifications(notifications []config.NotificationConfig) error {
+	for i, notification := range notifications {
+		if err := validateNotification(notification); err != nil {
+			return fmt.Errorf("invalid notification %d of the docker scan configuration: %s", i+1, err)
+		}
+	}
+	return nil
+}
+
+func validateNotification(notification config.NotificationConfig) error {
+	switch notification.Type {
+	case notify.SlackType, notify.WebhookType:
+		if notification.URL == "" {
+			return fmt.Errorf("%s notifications require an url", notification.Type)
+		}
+	case notify.EmailType:
+		if len(notification.To) == 0 {
+			return fmt.Errorf("email notifications require recipients")
+		}
+	default:
+		return fmt.Errorf("unknown type %q, expected one of %s", notification.Type, strings.Join(notify.Types, ", "))
+	}
+	if notification.Severity != "" && report.SeverityLevel(notification.Severity) < 0 {
+		return fmt.Errorf("unknown severity %q, expected one of %s", notification.Severity, strings.Join(report.Severities, ", "))
+	}
+	return nil
+}
+
+// sendNotifications sends the notifications whose threshold is reached by the findings. A notification which
+// can't be sent doesn't fail the scan, a warning is printed instead.
+func sendNotifications(ctx context.Context, dockerCli command.Cli, flags options, results scanResults) {
+	if len(flags.notifications) == 0 || results.report == nil {
+		return
+	}
+	findings := results.findings()
+	for _, notification := range flags.notifications {
+		event, ok := notify.NewEvent(imageName(flags, results.ref), notification.Severity, findings, notification.Link)
+		if !ok {
+			continue
+		}
+		if err := sendNotification(ctx, flags, notification, event, findings); err != nil {
+			fmt.Fprintf(dockerCli.Err(), "Warning: failed to send the %s notification: %s\n", notification.Type, err)
+		}
+	}
+}
+
+func sendNotification(ctx context.Context, flags options, notification config.NotificationConfig, event notify.Event, findings []report.Vulnerability) error {
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	if notification.Type == notify.EmailType {
+		return sendEmailNotification(flags, conf, notification, event, findings)
+	}
+	httpClient, err := proxy.NewHTTPClient(caCertPath(flags, conf))
+	if err != nil {
+		return err
+	}
+	if notification.Type == notify.SlackType {
+		return notify.Slack(ctx, httpClient, notification.URL, event)
+	}
+	return notify.Webhook(ctx, httpClient, notification.URL, event, []byte(os.Getenv(webhook.SecretEnvVar)))
+}
+
+// sendEmailNotification sends the summary of the event with the HTML report attached
+func sendEmailNotification(flags options, conf config.Config, notification config.NotificationConfig, event notify.Event, findings []report.Vulnerability) error {
+	if conf.SMTP == nil || conf.SMTP.Host == "" || conf.SMTP.From == "" {
+		return fmt.Errorf("email notifications require the host and from fields to be set in the smtp section of the docker scan configuration file")
+	}
+	server, err := smtpServer(flags, conf)
+	if err != nil {
+		return err
+	}
+	document := bytes.NewBuffer(nil)
+	if err := report.WriteHTML(document, event.Image, time.Now(), findings); err != nil {
+		return err
+	}
+	body := fmt.Sprintf("<p>%s</p>", html.EscapeString(event.Summary()))
+	if event.Link != "" {
+		link := html.EscapeString(event.Link)
+		body += fmt.Sprintf(`<p><a href="%s">%s</a></p>`, link, link)
+	}
+	return email.Send(server, email.Message{
+		From:    conf.SMTP.From,
+		To:      notification.To,
+		Subject: event.Summary(),
+		HTML:    []byte(body),
+		Attachments: []email.Attachment{{
+			Name:        "scan-report.html",
+			ContentType: "text/html",
+			Content:     document.Bytes(),
+		}},
+	})
+}
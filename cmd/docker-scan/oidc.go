@@ -0,0 +1,39 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/oidc"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
+)
+
+// oidcOptions replaces the stored provider token with the one exchanged for the OIDC token of the CI job, when the
+// oidc section of the configuration is set, the job provides an OIDC token and no profile is selected
+func oidcOptions(ctx context.Context, conf config.Config, profile, caCert string) ([]provider.Ops, error) {
+	if conf.OIDC == nil || conf.OIDC.URL == "" || profile != "" || !oidc.Available() {
+		return nil, nil
+	}
+	httpClient, err := proxy.NewHTTPClient(caCert)
+	if err != nil {
+		return nil, err
+	}
+	return []provider.Ops{provider.WithTokenStore(oidc.NewTokenStore(ctx, httpClient, conf.OIDC.URL, conf.OIDC.Audience))}, nil
+}
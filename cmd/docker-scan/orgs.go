@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+// maxProjectName is the longest Snyk project name
+const maxProjectName = 255
+
+type orgsOptions struct {
+	profile    string
+	jsonFormat bool
+}
+
+// orgResult is an organization of the JSON output of docker scan orgs
+type orgResult struct {
+	provider.Org
+	Selected bool `json:"selected"`
+}
+
+func newOrgsCmd(dockerCli command.Cli) *cobra.Command {
+	var flags orgsOptions
+	cmd := &cobra.Command{
+		Use:   "orgs [OPTIONS]",
+		Short: "List the Snyk organizations available to the Snyk token, to select one with --org",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runOrgs(dockerCli, flags)
+		},
+	}
+	cmd.Flags().StringVar(&flags.profile, "profile", "", "List the organizations of a profile")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "List the organizations in JSON format")
+	return cmd
+}
+
+func runOrgs(dockerCli command.Cli, flags orgsOptions) error {
+	opts, err := authOptions(dockerCli, flags.profile)
+	if err != nil {
+		return err
+	}
+	orgs, err := provider.ListOrgs(opts)
+	if err != nil {
+		return err
+	}
+	selected := provider.SelectedOrg(opts)
+	results := []orgResult{}
+	for _, org := range orgs {
+		results = append(results, orgResult{Org: org, Selected: selected != "" && org.Matches(selected)})
+	}
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+	return writeOrgs(dockerCli.Out(), results)
+}
+
+// writeOrgs prints a table of the organizations, the one the scans run in being marked with a star
+func writeOrgs(out io.Writer, orgs []orgResult) error {
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSLUG\tID")
+	for _, org := range orgs {
+		name := org.Name
+		if org.Selected {
+			name += " *"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, org.Slug, org.ID)
+	}
+	return w.Flush()
+}
+
+// orgOptions returns the provider options selecting the Snyk organization and project name of the scans, they replace
+// the organization of the profile
+func orgOptions(flags options) []provider.Ops {
+	var opts []provider.Ops
+	if flags.org != "" {
+		opts = append(opts, provider.WithOrg(flags.org))
+	}
+	if flags.projectName != "" {
+		opts = append(opts, provider.WithProjectName(flags.projectName))
+	}
+	return opts
+}
+
+func validateOrg(flags options) error {
+	if (flags.org != "" || flags.projectName != "") && !usesSnyk(flags.provider) {
+		return fmt.Errorf("--org and --project-name flags require the Snyk provider")
+	}
+	if flags.org != "" && (strings.HasPrefix(flags.org, "-") || strings.TrimSpace(flags.org) != flags.org || hasControl(flags.org)) {
+		return fmt.Errorf("invalid organization %q, expected the ID, name or slug of a Snyk organization listed by docker scan orgs", flags.org)
+	}
+	if flags.projectName != "" && (strings.TrimSpace(flags.projectName) == "" || hasControl(flags.projectName) || len(flags.projectName) > maxProjectName) {
+		return fmt.Errorf("invalid project name %q, expected up to %d printable characters", flags.projectName, maxProjectName)
+	}
+	return nil
+}
+
+func hasControl(value string) bool {
+	return strings.IndexFunc(value, unicode.IsControl) >= 0
+}
@@ -0,0 +1,201 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/internal/debug"
+	"github.com/docker/scan-cli-plugin/internal/oci"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+// platformScan is the result of the scan of a platform of a multi-platform image with --all-platforms
+type platformScan struct {
+	Platform        string                 `json:"platform"`
+	Digest          string                 `json:"digest"`
+	Counts          map[string]int         `json:"counts,omitempty"`
+	Vulnerabilities []report.Vulnerability `json:"vulnerabilities,omitempty"`
+	Error           string                 `json:"error,omitempty"`
+}
+
+func validatePlatform(flags options) error {
+	if flags.platform != "" && flags.allPlatforms {
+		return fmt.Errorf("--platform flag cannot be used with --all-platforms flag")
+	}
+	if (flags.platform != "" || flags.allPlatforms) && (flags.input != "" || flags.sbomInput != "") {
+		return fmt.Errorf("--platform and --all-platforms flags select the images of a registry, they cannot be used with --input or --sbom")
+	}
+	if flags.platform != "" {
+		return oci.ValidatePlatform(flags.platform)
+	}
+	return nil
+}
+
+// platformReference returns the reference of the image of the platform, pinned by its digest in the registry, instead
+// of the image of the default platform of the engine. The images with a single platform, or which are not in a
+// registry, are scanned as is, the platform being given to the provider.
+func platformReference(ctx context.Context, dockerCli command.Cli, ref, platform string) (string, error) {
+	named, platforms, err := imagePlatforms(ctx, dockerCli, ref)
+	if err != nil {
+		debug.Log("cannot get the platforms of the image", "image", ref, "error", err)
+		return ref, nil
+	}
+	if platforms == nil {
+		return ref, nil
+	}
+	for _, candidate := range platforms {
+		if candidate.Matches(platform) {
+			return named.Name() + "@" + candidate.Digest, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no image for the platform %s, its platforms are %s", ref, platform, platformNames(platforms))
+}
+
+// imagePlatforms returns the platforms of a multi-platform image from its registry, nil for a single platform image
+func imagePlatforms(ctx context.Context, dockerCli command.Cli, ref string) (reference.Named, []oci.Platform, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	repository, err := registryRepository(ctx, dockerCli, named)
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest := reference.TagNameOnly(named).String()
+	if canonical, ok := named.(reference.Canonical); ok {
+		manifest = canonical.Digest().String()
+	} else if tagged, ok := reference.TagNameOnly(named).(reference.Tagged); ok {
+		manifest = tagged.Tag()
+	}
+	platforms, err := repository.Platforms(ctx, manifest)
+	return named, platforms, err
+}
+
+func platformNames(platforms []oci.Platform) string {
+	var names []string
+	for _, platform := range platforms {
+		names = append(names, platform.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// runAllPlatformsScan scans each platform of a multi-platform image, and reports the vulnerabilities which differ
+// between the platforms
+func runAllPlatformsScan(ctx context.Context, cmd *cobra.Command, dockerCli command.Cli, flags options, args []string) error {
+	if err := checkScanArgs(cmd, flags, args); err != nil {
+		return err
+	}
+	named, platforms, err := imagePlatforms(ctx, dockerCli, args[0])
+	if err != nil {
+		return fmt.Errorf("cannot get the platforms of %s: %s", args[0], err)
+	}
+	if platforms == nil {
+		return fmt.Errorf("%s is not a multi-platform image, scan it without --all-platforms", args[0])
+	}
+	scanFlags := flags
+	scanFlags.jsonFormat = true
+	var scans []platformScan
+	for _, platform := range platforms {
+		scanFlags.platform = platform.String()
+		scans = append(scans, scanPlatform(ctx, dockerCli, scanFlags, named.Name()+"@"+platform.Digest, platform))
+	}
+	differences := platformDifferences(scans)
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(struct {
+			Image       string                      `json:"image"`
+			Platforms   []platformScan              `json:"platforms"`
+			Differences []report.PlatformDifference `json:"differences"`
+		}{Image: args[0], Platforms: scans, Differences: differences}); err != nil {
+			return err
+		}
+	} else {
+		var columns []report.MatrixColumn
+		for _, scan := range scans {
+			column := report.NewMatrixColumn(scan.Platform, scan.Vulnerabilities)
+			column.Error = scan.Error
+			columns = append(columns, column)
+		}
+		if err := report.WriteMatrix(dockerCli.Out(), columns); err != nil {
+			return err
+		}
+		report.WritePlatformDifferences(dockerCli.Out(), differences)
+	}
+	return platformsScanError(scans, flags.failOn)
+}
+
+// scanPlatform scans the image of a platform, a failed scan is reported instead of stopping the other ones
+func scanPlatform(ctx context.Context, dockerCli command.Cli, flags options, ref string, platform oci.Platform) platformScan {
+	scan := platformScan{Platform: platform.String(), Digest: platform.Digest}
+	providerOut := bytes.NewBuffer(nil)
+	scanProvider, err := configureProvider(ctx, dockerCli, flags, hubAuthConfig(dockerCli), provider.WithStreams(providerOut, dockerCli.Err()))
+	if err == nil {
+		// vulnerabilities are expected, provider failures are reported in the JSON output
+		if err = scanProvider.Scan(ref); provider.IsVulnerabilitiesFoundError(err) || provider.IsProviderFailedError(err) {
+			err = nil
+		}
+	}
+	var scanReport report.Report
+	if err == nil {
+		scanReport, err = report.Parse(providerOut.Bytes())
+	}
+	if err != nil {
+		scan.Error = err.Error()
+		fmt.Fprintf(dockerCli.Err(), "Warning: failed to scan %s: %s\n", scan.Platform, err)
+		return scan
+	}
+	scan.Vulnerabilities = report.Filter(scanReport, keepVulnerability(flags)).Vulnerabilities
+	scan.Counts = report.NewMatrixColumn(scan.Platform, scan.Vulnerabilities).Counts
+	return scan
+}
+
+func platformDifferences(scans []platformScan) []report.PlatformDifference {
+	var platforms []string
+	var findings [][]report.Vulnerability
+	for _, scan := range scans {
+		if scan.Error == "" {
+			platforms = append(platforms, scan.Platform)
+			findings = append(findings, scan.Vulnerabilities)
+		}
+	}
+	return report.PlatformDifferences(platforms, findings)
+}
+
+// platformsScanError fails when a platform couldn't be scanned, or when vulnerabilities are found on a platform
+func platformsScanError(scans []platformScan, failOn string) error {
+	found := false
+	for _, scan := range scans {
+		if scan.Error != "" {
+			return fmt.Errorf("failed to scan the %s platform: %s", scan.Platform, scan.Error)
+		}
+		found = found || failingVulnerabilities(scan.Vulnerabilities, failOn) > 0
+	}
+	if found {
+		return provider.NewVulnerabilitiesFoundError()
+	}
+	return nil
+}
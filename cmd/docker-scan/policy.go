@@ -0,0 +1,156 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/policy"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+func validatePolicy(flags options) error {
+	if flags.policy == "" {
+		return nil
+	}
+	_, err := policy.Load(flags.policy)
+	return err
+}
+
+func validateBudgets(flags options) error {
+	for _, severity := range report.Severities {
+		if max, ok := flags.budgets[severity]; ok && max < 0 {
+			return fmt.Errorf("--%s takes only a number of 0 or more", budgetFlag(severity))
+		}
+	}
+	return nil
+}
+
+func budgetFlag(severity string) string {
+	return "max-" + severity
+}
+
+// severityBudgets returns the numbers of vulnerabilities allowed per severity, for the budget flags set on the command
+// line, as 0 is a budget too
+func severityBudgets(cmd *cobra.Command) map[string]int {
+	budgets := map[string]int{}
+	for _, severity := range report.Severities {
+		if !cmd.Flags().Changed(budgetFlag(severity)) {
+			continue
+		}
+		if max, err := cmd.Flags().GetInt(budgetFlag(severity)); err == nil {
+			budgets[severity] = max
+		}
+	}
+	return budgets
+}
+
+// evaluatesPolicy returns true if the results are evaluated against a policy file or vulnerability budgets, the exit
+// code following the evaluation
+func evaluatesPolicy(flags options) bool {
+	return flags.policy != "" || len(flags.budgets) > 0
+}
+
+// loadPolicy returns the policy given with --policy, extended with a max-vulnerabilities rule per budget flag
+func loadPolicy(flags options) (policy.Policy, error) {
+	var scanPolicy policy.Policy
+	if flags.policy != "" {
+		loaded, err := policy.Load(flags.policy)
+		if err != nil {
+			return policy.Policy{}, err
+		}
+		scanPolicy = loaded
+	}
+	for i := len(report.Severities) - 1; i >= 0; i-- {
+		severity := report.Severities[i]
+		if max, ok := flags.budgets[severity]; ok {
+			max := max
+			scanPolicy.Rules = append(scanPolicy.Rules, policy.Rule{
+				Name:     fmt.Sprintf("--%s %d", budgetFlag(severity), max),
+				Type:     policy.MaxVulnerabilities,
+				Severity: severity,
+				Max:      &max,
+			})
+		}
+	}
+	return scanPolicy, nil
+}
+
+// evaluatePolicy evaluates the policy given with --policy and the vulnerability budgets against the scan results
+func evaluatePolicy(flags options, results *scanResults) error {
+	if !evaluatesPolicy(flags) || results.report == nil {
+		return nil
+	}
+	scanPolicy, err := loadPolicy(flags)
+	if err != nil {
+		return err
+	}
+	results.policy = policy.Evaluate(scanPolicy, policy.Input{
+		Image:     policyImage(flags, results.ref),
+		BaseImage: baseImage(flags, *results.report),
+		Findings:  results.findings(),
+		Packages:  results.licensedPackages,
+	})
+	return nil
+}
+
+// deniedByPolicy evaluates the image rules of the policy before the scan, returning their results only if the image
+// is denied
+func deniedByPolicy(flags options, ref string) ([]policy.Result, error) {
+	if flags.policy == "" {
+		return nil, nil
+	}
+	scanPolicy, err := policy.Load(flags.policy)
+	if err != nil || !scanPolicy.HasImageRules() {
+		return nil, err
+	}
+	results := policy.EvaluateImage(scanPolicy, policyImage(flags, ref))
+	if policy.Passed(results) {
+		return nil, nil
+	}
+	return results, nil
+}
+
+// deniedResults reports the image denied by the policy as a policy failure, without scanning it
+func deniedResults(dockerCli command.Cli, flags options, ref string, denied []policy.Result, err error) (scanResults, error) {
+	if err != nil {
+		return scanResults{}, err
+	}
+	policy.WriteResults(policyOutput(dockerCli, flags), denied)
+	return scanResults{ref: ref, policy: denied}, provider.NewVulnerabilitiesFoundError()
+}
+
+// policyImage returns the reference the image rules are evaluated on, unknown for the image archives
+func policyImage(flags options, ref string) string {
+	if flags.input != "" {
+		return ""
+	}
+	return ref
+}
+
+// policyOutput returns the stream of the policy evaluation, which is printed after the findings
+// unless they are printed as a report document
+func policyOutput(dockerCli command.Cli, flags options) io.Writer {
+	if flags.format != "" || flags.jsonFormat {
+		return dockerCli.Err()
+	}
+	return dockerCli.Out()
+}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/docker/cli/cli/command"
+	configtypes "github.com/docker/cli/cli/config/types"
+	helperCredentials "github.com/docker/docker-credential-helpers/credentials"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/authentication"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+)
+
+var profileName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+func validateProfileName(name string) error {
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q, only letters, digits, '.', '_' and '-' are allowed", name)
+	}
+	return nil
+}
+
+// profileOptions returns the provider options running the scans under the account of a profile: its provider token,
+// its Docker Hub account and its Snyk organization
+func profileOptions(dockerCli command.Cli, conf config.Config, name string) ([]provider.Ops, error) {
+	if name == "" {
+		return nil, nil
+	}
+	profile, ok := conf.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q, create it with docker scan auth login --profile %s", name, name)
+	}
+	opts := []provider.Ops{profileTokenStore(dockerCli, name)}
+	if profile.HubUsername != "" {
+		opts = append(opts, provider.WithAuthConfig(func(*registry.IndexInfo) types.AuthConfig {
+			return profileHubAuthConfig(dockerCli, name)
+		}))
+	}
+	if profile.Org != "" {
+		opts = append(opts, provider.WithOrg(profile.Org))
+	}
+	return opts, nil
+}
+
+func profileTokenStore(dockerCli command.Cli, name string) provider.Ops {
+	return provider.WithTokenStore(authentication.NewProfileTokenStore(
+		dockerCli.ConfigFile().GetCredentialsStore(authentication.ProfileServerAddress(name)), name))
+}
+
+// profileHubAuthConfig returns the Docker Hub credentials of a profile, empty if they can't be read
+func profileHubAuthConfig(dockerCli command.Cli, name string) types.AuthConfig {
+	address := authentication.HubProfileServerAddress(name)
+	auth, err := dockerCli.ConfigFile().GetCredentialsStore(address).Get(address)
+	if err != nil {
+		return types.AuthConfig{}
+	}
+	return types.AuthConfig(auth)
+}
+
+// storeProfileHubAuthConfig saves the Docker Hub credentials of a profile in the credentials store
+func storeProfileHubAuthConfig(dockerCli command.Cli, name, username, password string) error {
+	address := authentication.HubProfileServerAddress(name)
+	return dockerCli.ConfigFile().GetCredentialsStore(address).Store(configtypes.AuthConfig{
+		ServerAddress: address,
+		Username:      username,
+		Password:      password,
+	})
+}
+
+// eraseProfileHubAuthConfig removes the Docker Hub credentials of a profile from the credentials store
+func eraseProfileHubAuthConfig(dockerCli command.Cli, name string) error {
+	address := authentication.HubProfileServerAddress(name)
+	if err := dockerCli.ConfigFile().GetCredentialsStore(address).Erase(address); err != nil && !helperCredentials.IsErrCredentialsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/pushgate"
+	"github.com/spf13/cobra"
+)
+
+const (
+	pluginName              = "scan"
+	requireBeforePushOption = "require_before_push"
+	// maxPassingScanAge is how long a passing scan allows to push an image without scanning it again
+	maxPassingScanAge = 24 * time.Hour
+)
+
+func newPushCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "push IMAGE",
+		Short: "Push an image, after a passing scan when scan.require_before_push is enabled",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPush(ctx, cmd, dockerCli, args[0])
+		},
+	}
+}
+
+func runPush(ctx context.Context, cmd *cobra.Command, dockerCli command.Cli, ref string) error {
+	var flags options
+	if err := applyConfigDefaults(cmd, &flags); err != nil {
+		return err
+	}
+	if requireScanBeforePush(dockerCli.ConfigFile()) && !hasPassingScan(ctx, dockerCli, flags, ref) {
+		fmt.Fprintf(dockerCli.Err(), "No recent passing scan of %s, scanning it before pushing\n", ref)
+		scanCtx, cancel := withTimeout(ctx, flags)
+		defer cancel()
+		if err := runScan(scanCtx, cmd, dockerCli, flags, []string{ref}); err != nil {
+			if provider.IsVulnerabilitiesFoundError(err) {
+				return fmt.Errorf("push of %s refused, fix the findings above or disable %s.%s", ref, pluginName, requireBeforePushOption)
+			}
+			return fmt.Errorf("push of %s refused, the scan failed: %s", ref, err)
+		}
+	}
+	push := exec.CommandContext(ctx, "docker", "--context", dockerCli.CurrentContext(), "push", ref)
+	push.Stdin = dockerCli.In()
+	push.Stdout = dockerCli.Out()
+	push.Stderr = dockerCli.Err()
+	return push.Run()
+}
+
+// requireScanBeforePush returns true when scan.require_before_push is set in the Docker CLI configuration plugins section
+func requireScanBeforePush(configFile *configfile.ConfigFile) bool {
+	value, ok := configFile.PluginConfig(pluginName, requireBeforePushOption)
+	return ok && value == "true"
+}
+
+// hasPassingScan returns true if the image recently passed a scan filtering its findings like the push one
+func hasPassingScan(ctx context.Context, dockerCli command.Cli, flags options, ref string) bool {
+	inspect, _, err := dockerCli.Client().ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return false
+	}
+	result, ok, err := pushgate.NewStore().Get(inspect.ID)
+	return err == nil && ok && result.AllowsPush(time.Now(), maxPassingScanAge, scanFilters(flags))
+}
+
+// gatesPush returns true if the result of the scan can allow to push the image: scans of an image archive, running
+// only some of the analyzers, excluding the base image or the vulnerabilities without fix, or evaluated against a policy
+// or vulnerability budgets are never recorded
+func gatesPush(flags options) bool {
+	return flags.input == "" && len(flags.scopes) == 0 && !flags.excludeBase && !evaluatesPolicy(flags) &&
+		!flags.onlyFixable && len(flags.vex) == 0 && flags.failOn != failOnUpgradable
+}
+
+// scanFilters describes the options filtering the findings which can be set in the configuration defaults,
+// a push requires a passing scan with the same filters as the defaults
+func scanFilters(flags options) string {
+	var filters []string
+	if flags.severity != "" {
+		filters = append(filters, "severity="+flags.severity)
+	}
+	if len(flags.excludedCVEs) > 0 {
+		excluded := append([]string(nil), flags.excludedCVEs...)
+		sort.Strings(excluded)
+		filters = append(filters, "exclude-cve="+strings.Join(excluded, ","))
+	}
+	return strings.Join(filters, " ")
+}
+
+// recordScanResult saves the result of the scan of an image, to allow pushing it when scan.require_before_push is enabled
+func recordScanResult(ctx context.Context, dockerCli command.Cli, flags options, ref string, scanErr error) {
+	if !requireScanBeforePush(dockerCli.ConfigFile()) || !gatesPush(flags) || config.ReadOnly() {
+		return
+	}
+	inspect, _, err := dockerCli.Client().ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return
+	}
+	result := pushgate.Result{
+		ImageID:   inspect.ID,
+		Ref:       ref,
+		ScannedAt: time.Now(),
+		Passed:    scanErr == nil,
+		Filters:   scanFilters(flags),
+	}
+	if err := pushgate.NewStore().Save(result); err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Failed to record the scan result: %s\n", err)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal"
+	"github.com/docker/scan-cli-plugin/internal/collector"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// pushResults posts the normalized results to the collector with --push-results. A collector which can't be reached
+// doesn't fail the scan, a warning is printed instead.
+func pushResults(ctx context.Context, dockerCli command.Cli, flags options, results scanResults) {
+	if !flags.pushResults || results.report == nil {
+		return
+	}
+	endpoint, err := postResults(ctx, dockerCli, flags, results)
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Warning: failed to push the scan results: %s\n", err)
+		return
+	}
+	fmt.Fprintf(dockerCli.Err(), "Scan results pushed to %s\n", endpoint)
+}
+
+func validatePushResults(flags options) error {
+	if flags.pushResultsURL == "" {
+		return nil
+	}
+	return collector.ValidateEndpoint(flags.pushResultsURL)
+}
+
+func postResults(ctx context.Context, dockerCli command.Cli, flags options, results scanResults) (string, error) {
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return "", err
+	}
+	endpoint := flags.pushResultsURL
+	if endpoint == "" && conf.Results != nil {
+		endpoint = conf.Results.URL
+	}
+	if endpoint == "" {
+		return "", fmt.Errorf("no collector, set the url of the results section of the docker scan configuration or use --push-results-url")
+	}
+	httpClient, err := proxy.NewHTTPClient(caCertPath(flags, conf))
+	if err != nil {
+		return "", err
+	}
+	token, err := resultsToken(ctx, dockerCli, flags)
+	if err != nil {
+		return "", err
+	}
+	return endpoint, collector.Push(ctx, httpClient, endpoint, token, collectorResult(ctx, dockerCli, results))
+}
+
+// resultsToken returns the DockerScanID authenticating the results, empty when no Docker Hub account is logged in
+func resultsToken(ctx context.Context, dockerCli command.Cli, flags options) (string, error) {
+	opts, err := authOptions(dockerCli, flags.profile, provider.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	token, err := provider.DockerScanID(opts)
+	if errors.Is(err, provider.ErrNotAuthenticated) {
+		return "", nil
+	}
+	return token, err
+}
+
+func collectorResult(ctx context.Context, dockerCli command.Cli, results scanResults) collector.Result {
+	findings := results.findings()
+	result := collector.Result{
+		Image:         results.ref,
+		ScannedAt:     time.Now().UTC(),
+		CI:            os.Getenv("CI") != "",
+		PluginVersion: internal.Version,
+		Severities:    report.CountBySeverity(findings),
+		Findings:      findings,
+	}
+	if inspect, _, err := dockerCli.Client().ImageInspectWithRaw(ctx, results.ref); err == nil {
+		result.ImageID = inspect.ID
+	}
+	if host, err := os.Hostname(); err == nil {
+		result.Host = host
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+type quotaOptions struct {
+	profile    string
+	jsonFormat bool
+}
+
+// quotaResult is the JSON output of docker scan quota
+type quotaResult struct {
+	provider.AuthStatus
+	// Usage is set when the scans run with the DockerScanID, whose number is limited each month
+	Usage     *usage.Usage `json:"usage,omitempty"`
+	Remaining *int         `json:"remaining,omitempty"`
+	ResetsOn  string       `json:"resetsOn,omitempty"`
+}
+
+func newQuotaCmd(dockerCli command.Cli) *cobra.Command {
+	var flags quotaOptions
+	cmd := &cobra.Command{
+		Use:   "quota [OPTIONS]",
+		Short: "Display the scans left this month to the Docker Hub users",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runQuota(dockerCli, flags)
+		},
+	}
+	cmd.Flags().StringVar(&flags.profile, "profile", "", "Display the scans left to a profile")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Display the quota in JSON format")
+	return cmd
+}
+
+func runQuota(dockerCli command.Cli, flags quotaOptions) error {
+	opts, err := authOptions(dockerCli, flags.profile)
+	if err != nil {
+		return err
+	}
+	status, err := provider.GetAuthStatus(opts)
+	if err != nil {
+		return err
+	}
+	if !status.IsAuthenticated() {
+		return fmt.Errorf(`Not authenticated, please login to Docker Hub using the Docker Login command
+or authenticate to the scan provider using docker scan --login`)
+	}
+	result := quotaResult{AuthStatus: status}
+	if status.Source == provider.DockerScanIDSource {
+		scans, err := usage.Get(usage.DefaultPath(), status.Username, time.Now())
+		if err != nil {
+			return err
+		}
+		remaining := scans.Remaining()
+		result.Usage = &scans
+		result.Remaining = &remaining
+		result.ResetsOn = scans.ResetsOn().Format("2006-01-02")
+	}
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	writeQuota(dockerCli.Out(), result)
+	return nil
+}
+
+func writeQuota(out io.Writer, result quotaResult) {
+	fmt.Fprintf(out, "Authenticated with: %s\n", result.Source)
+	if result.Usage == nil {
+		fmt.Fprintln(out, "The scans count against the test limit of your Snyk organization, see https://app.snyk.io")
+		return
+	}
+	fmt.Fprintf(out, "Docker Hub user:    %s\n", result.Username)
+	fmt.Fprintf(out, "Scans this month:   %d of %d\n", result.Usage.Scans, result.Usage.Limit)
+	fmt.Fprintf(out, "Remaining:          %d\n", *result.Remaining)
+	fmt.Fprintf(out, "Resets on:          %s\n", result.ResetsOn)
+	if result.Usage.Exceeded {
+		fmt.Fprintf(out, "Quota exceeded:     %s\n", result.Usage.Message)
+	}
+	fmt.Fprintln(out, "Only the scans made on this machine are counted.")
+}
@@ -0,0 +1,144 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/hub"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type recommendOptions struct {
+	dockerFilePath string
+	jsonFormat     bool
+	tags           int
+}
+
+func newRecommendCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags recommendOptions
+	cmd := &cobra.Command{
+		Use:   "recommend [OPTIONS] IMAGE",
+		Short: "Display the base image upgrades, or the base image tag, recommended to reduce vulnerabilities",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.tags != 0 {
+				return runTagRecommend(ctx, dockerCli, flags, args[0])
+			}
+			return runRecommend(ctx, dockerCli, flags, args[0])
+		},
+	}
+	cmd.Flags().StringVarP(&flags.dockerFilePath, "file", "f", "", "Dockerfile associated with image, used to detect the base image")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output recommendations in JSON format")
+	cmd.Flags().IntVar(&flags.tags, "tags", 0, "Scan the given number of most recent tags of a Docker Hub repository, and recommend the one with the fewest critical and high vulnerabilities")
+	return cmd
+}
+
+func runRecommend(ctx context.Context, dockerCli command.Cli, flags recommendOptions, image string) error {
+	providerOut := bytes.NewBuffer(nil)
+	scanProvider, err := configureProvider(ctx, dockerCli, options{
+		dockerFilePath: flags.dockerFilePath,
+		jsonFormat:     true,
+	}, hubAuthConfig(dockerCli), provider.WithStreams(providerOut, dockerCli.Err()))
+	if err != nil {
+		return err
+	}
+	// vulnerabilities are expected, only provider failures matter
+	if err := scanProvider.Scan(image); err != nil && !provider.IsVulnerabilitiesFoundError(err) && !provider.IsProviderFailedError(err) {
+		return err
+	}
+	scanReport, err := report.Parse(providerOut.Bytes())
+	if err != nil {
+		return err
+	}
+	recommendations := report.Recommendations(scanReport)
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(recommendations)
+	}
+	return report.WriteRecommendations(dockerCli.Out(), recommendations)
+}
+
+// runTagRecommend scans the latest tags of a Docker Hub repository and recommends the one with the fewest
+// critical and high vulnerabilities
+func runTagRecommend(ctx context.Context, dockerCli command.Cli, flags recommendOptions, repository string) error {
+	if flags.tags < 0 {
+		return fmt.Errorf("--tags must be a positive number of tags")
+	}
+	named, err := reference.ParseNormalizedNamed(repository)
+	if err != nil {
+		return err
+	}
+	if !reference.IsNameOnly(named) {
+		return fmt.Errorf("--tags expects a repository without tag or digest, like alpine")
+	}
+	if reference.Domain(named) != "docker.io" {
+		return fmt.Errorf("--tags only supports Docker Hub repositories")
+	}
+	tags, err := latestTags(reference.Path(named), flags.tags)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("no tags found for %s", repository)
+	}
+	var images []string
+	for _, tag := range tags {
+		images = append(images, reference.FamiliarName(named)+":"+tag)
+	}
+	columns, err := scanMatrix(ctx, dockerCli, matrixOptions{dockerFilePath: flags.dockerFilePath}, images)
+	if err != nil {
+		return err
+	}
+	if flags.jsonFormat {
+		result := struct {
+			Tags        []report.MatrixColumn `json:"tags"`
+			Recommended string                `json:"recommended,omitempty"`
+		}{Tags: columns}
+		if best, ok := report.RecommendTag(columns); ok {
+			result.Recommended = best.Image
+		}
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	return report.WriteTagRecommendation(dockerCli.Out(), columns)
+}
+
+func latestTags(repository string, count int) ([]string, error) {
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := proxy.NewHTTPClient(caCertPath(options{}, conf))
+	if err != nil {
+		return nil, err
+	}
+	client := hub.Client{Domain: hub.GetInstance().APIHubBaseURL, HTTPClient: httpClient, CacheDir: hub.DefaultCacheDir()}
+	return client.LatestTags(repository, count)
+}
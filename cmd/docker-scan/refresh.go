@@ -0,0 +1,135 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/archive"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type refreshOptions struct {
+	provider   string
+	severity   string
+	jsonFormat bool
+}
+
+// refreshResult lists the changes of the vulnerabilities of a report against the current vulnerabilities
+type refreshResult struct {
+	Introduced []report.Vulnerability `json:"introduced"`
+	Fixed      []report.Vulnerability `json:"fixed"`
+}
+
+func newRefreshCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags refreshOptions
+	cmd := &cobra.Command{
+		Use:   "refresh [OPTIONS] FILE",
+		Short: "Scan the packages of a JSON report or of an SBOM again, and show the vulnerabilities which newly affect them",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRefresh(ctx, dockerCli, flags, args[0])
+		},
+	}
+	cmd.Flags().StringVar(&flags.provider, "provider", "", "Scan provider able to scan an SBOM, overrides the provider of the configuration defaults (binary|trivy|grype)")
+	cmd.Flags().StringVar(&flags.severity, "severity", "", "Only report vulnerabilities of provided level or higher (low|medium|high)")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the changes in JSON format")
+	return cmd
+}
+
+func runRefresh(ctx context.Context, dockerCli command.Cli, flags refreshOptions, path string) error {
+	sbomPath, previous, cleanup, err := refreshInput(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	providerOut := bytes.NewBuffer(nil)
+	scanProvider, err := configureProvider(ctx, dockerCli, options{provider: flags.provider, severity: flags.severity, jsonFormat: true},
+		hubAuthConfig(dockerCli), provider.WithStreams(providerOut, dockerCli.Err()))
+	if err != nil {
+		return err
+	}
+	scanner, ok := scanProvider.(provider.SBOMScanner)
+	if !ok {
+		return fmt.Errorf("refreshing a report requires the Snyk binary, Trivy or Grype, use --provider binary, trivy or grype")
+	}
+	if err := scanner.ScanSBOM(sbomPath); err != nil && !provider.IsVulnerabilitiesFoundError(err) {
+		return err
+	}
+	current, err := report.Parse(providerOut.Bytes())
+	if err != nil {
+		return err
+	}
+	introduced, fixed := report.Diff(previous, current)
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(refreshResult{Introduced: nonNil(introduced), Fixed: nonNil(fixed)})
+	}
+	report.WriteDiff(dockerCli.Out(), introduced, fixed)
+	return nil
+}
+
+// refreshInput returns the SBOM to scan and the previous report. A JSON report of docker scan is converted to
+// a temporary SBOM, removed by the returned function. An SBOM has no previous report, all its vulnerabilities are new.
+func refreshInput(path string) (string, report.Report, func(), error) {
+	if _, err := provider.SBOMFormat(path); err == nil {
+		return path, report.Report{}, func() {}, nil
+	}
+	document, err := archive.ReadFile(path)
+	if err != nil {
+		return "", report.Report{}, nil, err
+	}
+	previous, err := report.Parse(document)
+	if err != nil {
+		return "", report.Report{}, nil, fmt.Errorf("%s is neither a JSON report of docker scan nor a CycloneDX or SPDX SBOM: %s", path, err)
+	}
+	sbom, err := report.SBOM(document)
+	if err != nil {
+		return "", report.Report{}, nil, err
+	}
+	file, err := ioutil.TempFile("", "docker-scan-refresh-*.cdx.json")
+	if err != nil {
+		return "", report.Report{}, nil, err
+	}
+	cleanup := func() { os.Remove(file.Name()) } //nolint: errcheck
+	_, err = file.Write(sbom)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", report.Report{}, nil, err
+	}
+	return file.Name(), previous, cleanup, nil
+}
+
+func nonNil(vulns []report.Vulnerability) []report.Vulnerability {
+	if vulns == nil {
+		return []report.Vulnerability{}
+	}
+	return vulns
+}
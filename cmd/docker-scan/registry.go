@@ -0,0 +1,84 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	registrytypes "github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/registry"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/oci"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
+)
+
+// registryRepository returns the registry repository of the image, authenticated with the credentials of docker login
+func registryRepository(ctx context.Context, dockerCli command.Cli, named reference.Named) (*oci.Repository, error) {
+	repoInfo, err := registry.ParseRepositoryInfo(named)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := proxy.NewHTTPClient(conf.CACert)
+	if err != nil {
+		return nil, err
+	}
+	auth := resolveAuthConfig(dockerCli, repoInfo.Index)
+	return oci.NewRepository(httpClient, named, auth.Username, auth.Password), nil
+}
+
+// resolveAuthConfig returns the credentials of the registry from the configuration of the docker CLI, honoring
+// DOCKER_CONFIG and --config. Unlike command.ResolveAuthConfig, it does not ask the daemon for the address of Docker Hub:
+// the round trip is slow over SSH contexts, fails without a daemon and every daemon answers the default address anyway.
+func resolveAuthConfig(dockerCli command.Cli, index *registrytypes.IndexInfo) types.AuthConfig {
+	server := index.Name
+	if index.Official {
+		server = registry.IndexServer
+	}
+	auth, _ := dockerCli.ConfigFile().GetAuthConfig(server)
+	return types.AuthConfig(auth)
+}
+
+// registryDigest returns the digest of the image in its registry, the one of the local image when it was pushed or
+// pulled, otherwise the one of the tag in the registry
+func registryDigest(ctx context.Context, dockerCli command.Cli, repository *oci.Repository, named reference.Named) (string, error) {
+	if canonical, ok := named.(reference.Canonical); ok {
+		return canonical.Digest().String(), nil
+	}
+	named = reference.TagNameOnly(named)
+	if inspect, _, err := dockerCli.Client().ImageInspectWithRaw(ctx, reference.FamiliarString(named)); err == nil {
+		for _, repoDigest := range inspect.RepoDigests {
+			if canonical, err := reference.ParseNormalizedNamed(repoDigest); err == nil && canonical.Name() == named.Name() {
+				if withDigest, ok := canonical.(reference.Canonical); ok {
+					return withDigest.Digest().String(), nil
+				}
+			}
+		}
+	}
+	digest, err := repository.Digest(ctx, named.(reference.Tagged).Tag())
+	if err != nil {
+		return "", fmt.Errorf("cannot get the digest of %s in its registry, push the image first: %s", reference.FamiliarString(named), err)
+	}
+	return digest, nil
+}
@@ -0,0 +1,202 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type repoOptions struct {
+	registry    string
+	allTags     bool
+	filter      string
+	concurrency int
+	severity    string
+	jsonFormat  bool
+}
+
+// repoTag is the result of the scan of a tag in the JSON output of docker scan repo
+type repoTag struct {
+	Tag    string         `json:"tag"`
+	Counts map[string]int `json:"counts,omitempty"`
+	Error  string         `json:"error,omitempty"`
+	Safe   bool           `json:"safe"`
+}
+
+func newRepoCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags repoOptions
+	cmd := &cobra.Command{
+		Use:   "repo [OPTIONS] REPOSITORY",
+		Short: "Scan the tags of a registry repository and compare their number of vulnerabilities per severity",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateRepo(flags); err != nil {
+				return err
+			}
+			return runRepo(ctx, dockerCli, flags, args[0])
+		},
+	}
+	cmd.Flags().StringVar(&flags.registry, "registry", "", "Registry of the repository, like ghcr.io, Docker Hub by default")
+	cmd.Flags().BoolVar(&flags.allTags, "all-tags", false, "Scan every tag of the repository")
+	cmd.Flags().StringVar(&flags.filter, "filter", "", "Scan the tags matching this regular expression")
+	cmd.Flags().IntVar(&flags.concurrency, "concurrency", 2, "Maximum number of tags scanned at the same time")
+	cmd.Flags().StringVar(&flags.severity, "severity", "high", "Lowest severity making a tag unsafe to keep, low, medium, high or critical")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the results per tag in JSON format")
+	return cmd
+}
+
+func validateRepo(flags repoOptions) error {
+	if flags.allTags == (flags.filter != "") {
+		return fmt.Errorf("either --all-tags or --filter is required to select the tags to scan")
+	}
+	if _, err := regexp.Compile(flags.filter); err != nil {
+		return fmt.Errorf("invalid --filter regular expression: %s", err)
+	}
+	if flags.concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	if report.SeverityLevel(flags.severity) < 0 {
+		return fmt.Errorf("invalid --severity value %q, expected low, medium, high or critical", flags.severity)
+	}
+	return nil
+}
+
+func runRepo(ctx context.Context, dockerCli command.Cli, flags repoOptions, repository string) error {
+	if flags.registry != "" {
+		repository = flags.registry + "/" + repository
+	}
+	named, err := reference.ParseNormalizedNamed(repository)
+	if err != nil {
+		return err
+	}
+	if !reference.IsNameOnly(named) {
+		return fmt.Errorf("expected a repository without tag or digest, like myorg/app")
+	}
+	tags, err := repositoryTags(ctx, dockerCli, named, flags.filter)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("no tags to scan in %s", reference.FamiliarName(named))
+	}
+	var images []string
+	for _, tag := range tags {
+		images = append(images, reference.FamiliarName(named)+":"+tag)
+	}
+	fmt.Fprintf(dockerCli.Err(), "Scanning %d tags of %s, %d at a time\n", len(tags), reference.FamiliarName(named), flags.concurrency)
+	columns, err := scanConcurrently(ctx, dockerCli, images, flags.concurrency)
+	if err != nil {
+		return err
+	}
+	for i := range columns {
+		columns[i].Image = tags[i]
+	}
+	if flags.jsonFormat {
+		result := struct {
+			Repository string    `json:"repository"`
+			Tags       []repoTag `json:"tags"`
+		}{Repository: reference.FamiliarName(named), Tags: []repoTag{}}
+		for _, column := range columns {
+			result.Tags = append(result.Tags, repoTag{
+				Tag:    column.Image,
+				Counts: column.Counts,
+				Error:  column.Error,
+				Safe:   report.SafeTag(column, flags.severity),
+			})
+		}
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	return report.WriteTagMatrix(dockerCli.Out(), columns, flags.severity)
+}
+
+// repositoryTags lists the tags of the repository with the registry API, authenticated with the credentials of
+// docker login, keeping the ones matching the filter
+func repositoryTags(ctx context.Context, dockerCli command.Cli, named reference.Named, filter string) ([]string, error) {
+	repository, err := registryRepository(ctx, dockerCli, named)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := repository.Tags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the tags of %s: %s", reference.FamiliarName(named), err)
+	}
+	matcher := regexp.MustCompile(filter)
+	var matching []string
+	for _, tag := range tags {
+		if matcher.MatchString(tag) {
+			matching = append(matching, tag)
+		}
+	}
+	return matching, nil
+}
+
+// scanConcurrently scans the images with up to the given number of providers at the same time, the columns being in
+// the order of the images. A failed scan is reported in its column.
+func scanConcurrently(ctx context.Context, dockerCli command.Cli, images []string, concurrency int) ([]report.MatrixColumn, error) {
+	if concurrency > len(images) {
+		concurrency = len(images)
+	}
+	scanProviders := make([]provider.Provider, concurrency)
+	providerOuts := make([]*bytes.Buffer, concurrency)
+	for i := range scanProviders {
+		providerOuts[i] = bytes.NewBuffer(nil)
+		scanProvider, err := configureProvider(ctx, dockerCli, options{jsonFormat: true}, hubAuthConfig(dockerCli),
+			provider.WithStreams(providerOuts[i], dockerCli.Err()))
+		if err != nil {
+			return nil, err
+		}
+		scanProviders[i] = scanProvider
+	}
+	columns := make([]report.MatrixColumn, len(images))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := range scanProviders {
+		wg.Add(1)
+		go func(scanProvider provider.Provider, providerOut *bytes.Buffer) {
+			defer wg.Done()
+			for index := range indexes {
+				providerOut.Reset()
+				columns[index] = matrixColumn(scanProvider, providerOut, images[index])
+			}
+		}(scanProviders[i], providerOuts[i])
+	}
+feed:
+	for i := range images {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return columns, ctx.Err()
+}
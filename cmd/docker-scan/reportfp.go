@@ -0,0 +1,122 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	cliConfig "github.com/docker/cli/cli/config"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/dispute"
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type reportFPOptions struct {
+	dockerFilePath string
+	reason         string
+	local          bool
+}
+
+func newReportFPCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags reportFPOptions
+	cmd := &cobra.Command{
+		Use:   "report-fp [OPTIONS] IMAGE FINDING-ID",
+		Short: "Report a finding as a false positive, with the package and layer evidence found in the image",
+		Args:  cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReportFP(ctx, dockerCli, flags, args[0], args[1])
+		},
+	}
+	cmd.Flags().StringVarP(&flags.dockerFilePath, "file", "f", "", "Dockerfile associated with the image, attributes the vulnerabilities to its instructions")
+	cmd.Flags().StringVar(&flags.reason, "reason", "", "Why the finding is a false positive")
+	cmd.Flags().BoolVar(&flags.local, "local", false, "Only save the dispute record locally, even if a false positive endpoint is configured")
+	return cmd
+}
+
+func runReportFP(ctx context.Context, dockerCli command.Cli, flags reportFPOptions, ref string, id string) error {
+	results, err := explainScan(ctx, dockerCli, explainOptions{dockerFilePath: flags.dockerFilePath}, ref)
+	if err != nil {
+		return err
+	}
+	explanations := explainResults(results, id)
+	if len(explanations) == 0 {
+		return fmt.Errorf("no finding matches %s in %s", id, ref)
+	}
+	record := disputeRecord(ctx, dockerCli, ref, id, flags.reason, explanations)
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	if flags.local || conf.FalsePositives == nil || conf.FalsePositives.URL == "" {
+		path, err := dispute.Save(filepath.Join(cliConfig.Dir(), "scan", "disputes"), record)
+		if err != nil {
+			return fmt.Errorf("failed to save the false positive report: %s", err)
+		}
+		fmt.Fprintf(dockerCli.Out(), "False positive report of %s saved to %s\n", id, path)
+		return nil
+	}
+	httpClient, err := proxy.NewHTTPClient(conf.CACert)
+	if err != nil {
+		return err
+	}
+	if err := dispute.Submit(ctx, httpClient, conf.FalsePositives.URL, os.Getenv("FALSE_POSITIVE_API_TOKEN"), record); err != nil {
+		return fmt.Errorf("failed to submit the false positive report: %s", err)
+	}
+	fmt.Fprintf(dockerCli.Out(), "False positive report of %s submitted to %s\n", id, conf.FalsePositives.URL)
+	return nil
+}
+
+// disputeRecord packages the explanations of a finding as the evidence of a false positive report.
+// The layer digests are best effort, the image may not be available on the engine.
+func disputeRecord(ctx context.Context, dockerCli command.Cli, ref, id, reason string, explanations []report.Explanation) dispute.Record {
+	record := dispute.Record{
+		FindingID: id,
+		Title:     explanations[0].Finding.Title,
+		Image:     ref,
+		Reason:    reason,
+		Created:   time.Now(),
+	}
+	if inspect, _, err := dockerCli.Client().ImageInspectWithRaw(ctx, ref); err == nil {
+		record.ImageID = inspect.ID
+	}
+	digests, _ := image.LayerDigests(ctx, dockerCli.Client(), ref)
+	for _, explanation := range explanations {
+		evidence := dispute.Evidence{
+			Analyzer:       explanation.Analyzer,
+			Package:        explanation.Finding.PackageName,
+			Version:        explanation.Finding.Version,
+			PackageManager: explanation.Finding.PackageManager,
+			Path:           explanation.Finding.Path,
+			Layer:          explanation.Layer,
+			CreatedBy:      explanation.CreatedBy,
+		}
+		if explanation.Layer > 0 && explanation.Layer <= len(digests) {
+			evidence.LayerDigest = digests[explanation.Layer-1]
+		}
+		record.Evidence = append(record.Evidence, evidence)
+	}
+	return record
+}
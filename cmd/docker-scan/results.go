@@ -0,0 +1,496 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/archive"
+	"github.com/docker/scan-cli-plugin/internal/history"
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"github.com/docker/scan-cli-plugin/internal/misconfig"
+	"github.com/docker/scan-cli-plugin/internal/policy"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// Document formats of the report
+const (
+	htmlFormat  = "html"
+	pdfFormat   = "pdf"
+	junitFormat = "junit"
+	// ndjsonFormat streams the events of the scan as newline-delimited JSON instead of a document
+	ndjsonFormat = "ndjson"
+)
+
+var outputFormats = []string{htmlFormat, pdfFormat, junitFormat, ndjsonFormat}
+
+// Vulnerabilities changing the exit code, selected with --fail-on
+const (
+	failOnAll        = "all"
+	failOnUpgradable = "upgradable"
+)
+
+// scanResults gathers the analyses made by the plugin on top of the provider results
+type scanResults struct {
+	ref               string
+	report            *report.Report
+	metadata          *image.Metadata
+	layers            []report.LayerGroup
+	heatmap           []report.LayerHeat
+	malware           []report.Vulnerability
+	binaries          []report.Binary
+	misconfigurations []report.Vulnerability
+	secrets           []report.Vulnerability
+	packages          []report.PackageLicense
+	// licensedPackages are the packages scanned for their licenses, even when they are not reported
+	licensedPackages  []report.PackageLicense
+	licenseIssues     []report.Vulnerability
+	integrityWarnings []string
+	squash            *report.SquashSimulation
+	policy            []policy.Result
+	// vex lists the VEX statements applied to the findings with --vex
+	vex []vexResult
+	// ignores are the vulnerabilities ignored locally, synced to the Snyk projects of the image with --sync-ignores
+	ignores []provider.Ignore
+	// keep selects the vulnerabilities of the provider output reported by the plugin
+	keep func(report.Vulnerability) bool
+	// quotaExceeded is set when the provider refused the scan because the test limit of the account is reached
+	quotaExceeded bool
+	// softFail holds the exit code the scan would have returned without --soft-fail
+	softFail *softFailResult
+}
+
+// needsReport returns true if the provider output must be parsed by the plugin
+// instead of being printed as is
+func needsReport(flags options) bool {
+	return len(flags.scopes) > 0 || flags.trackAge || evaluatesPolicy(flags) || len(flags.nonRuntimePaths) > 0 ||
+		flags.failOn == failOnUpgradable || filtersVulnerabilities(flags) || (flags.excludeBase && !usesSnyk(flags.provider)) ||
+		publishesResults(flags) || enrichesVulnerabilities(flags) || rewritesOutput(flags)
+}
+
+// rewritesOutput returns true if the plugin prints the results in another output mode than the provider one
+func rewritesOutput(flags options) bool {
+	return flags.groupBy != "" || flags.layerHeatmap || flags.showPaths || flags.watch || flags.quiet || flags.summary || flags.format != "" ||
+		flags.interactive
+}
+
+// enrichesVulnerabilities returns true if the plugin adds information to the vulnerabilities of the provider output
+func enrichesVulnerabilities(flags options) bool {
+	return len(flags.buildArgs) > 0 || len(flags.enrich) > 0 || flags.sortBy != ""
+}
+
+// publishesResults returns true if the results are sent to files or external systems
+func publishesResults(flags options) bool {
+	return len(flags.exports) > 0 || flags.jsonFile != "" || flags.createJira || flags.email || flags.pushResults ||
+		len(flags.notifications) > 0 || flags.history || flags.syncIgnores || flags.metricsFile != "" ||
+		flags.githubSummary
+}
+
+// filtersVulnerabilities returns true if the plugin removes vulnerabilities from the provider output, Snyk excluding
+// itself the base image vulnerabilities
+func filtersVulnerabilities(flags options) bool {
+	return len(flags.scopes) > 0 || len(flags.excludedCVEs) > 0 || flags.onlyFixable || len(flags.vex) > 0
+}
+
+// publishResults sends the results to the external systems configured
+func publishResults(ctx context.Context, dockerCli command.Cli, flags options, results scanResults) error {
+	if err := createJiraIssues(ctx, dockerCli, flags, results); err != nil {
+		return err
+	}
+	if err := writeGitHubSummary(dockerCli, flags, results); err != nil {
+		return err
+	}
+	pushResults(ctx, dockerCli, flags, results)
+	syncIgnores(ctx, dockerCli, flags, results)
+	sendNotifications(ctx, dockerCli, flags, results)
+	return sendEmailReport(dockerCli, flags, results)
+}
+
+// validateFailOn checks the --fail-on flag, empty for the commands without the flag, which fail on all the vulnerabilities
+func validateFailOn(flags options) error {
+	if flags.failOn != "" && flags.failOn != failOnAll && flags.failOn != failOnUpgradable {
+		return fmt.Errorf("--fail-on takes only %s or %s values", failOnAll, failOnUpgradable)
+	}
+	return nil
+}
+
+func validateShowPaths(flags options) error {
+	if flags.showPaths && (flags.quiet || flags.summary || flags.format != "") {
+		return fmt.Errorf("--show-paths flag can't be used with --quiet, --summary or --format")
+	}
+	return nil
+}
+
+func validateOutputMode(flags options) error {
+	switch {
+	case flags.quiet && flags.summary:
+		return fmt.Errorf("--quiet and --summary flags can't be used together")
+	case (flags.quiet || flags.summary) && flags.jsonFormat:
+		return fmt.Errorf("--json flag can't be used with --quiet or --summary")
+	case (flags.quiet || flags.summary) && flags.groupBy != "":
+		return fmt.Errorf("--group-by flag can't be used with --quiet or --summary")
+	case flags.format != "" && !contains(outputFormats, flags.format):
+		return fmt.Errorf("--format takes only %s values", strings.Join(outputFormats, ", "))
+	case flags.format != "" && (flags.jsonFormat || flags.quiet || flags.summary):
+		return fmt.Errorf("--format flag can't be used with --json, --quiet or --summary")
+	}
+	return nil
+}
+
+// writeDocument writes the report of the findings as an HTML, PDF or JUnit XML document
+func writeDocument(out io.Writer, format string, results scanResults) error {
+	switch format {
+	case pdfFormat:
+		return report.WritePDF(out, results.ref, time.Now(), results.findings())
+	case junitFormat:
+		return report.WriteJUnit(out, results.ref, time.Now(), results.findings())
+	}
+	return report.WriteHTML(out, results.ref, time.Now(), results.findings())
+}
+
+func analyzeImage(ctx context.Context, dockerCli command.Cli, flags options, ref string, providerOutput []byte, analyzers layerAnalyzers) (scanResults, error) {
+	results := scanResults{
+		ref:      imageName(flags, ref),
+		metadata: imageMetadata(ctx, dockerCli, flags, ref),
+	}
+	if needsReport(flags) {
+		scanReport, err := report.Parse(providerOutput)
+		if err != nil && !flags.jsonFormat {
+			return results, err
+		}
+		// on failure the provider JSON output reporting the error is printed as is
+		if err == nil {
+			locateInDockerfile(flags, &scanReport)
+			results.keep = keepFromImage(ctx, dockerCli, flags, ref, scanReport)
+			if err := applyVEX(ctx, dockerCli, flags, ref, &scanReport, &results); err != nil {
+				return results, err
+			}
+			if flags.syncIgnores {
+				results.ignores = localIgnores(flags, scanReport.Vulnerabilities, results.vex)
+			}
+			scanReport = report.Downgrade(report.Filter(scanReport, results.keep), flags.nonRuntimePaths)
+			if err := enrichFindings(ctx, flags, &scanReport); err != nil {
+				return results, err
+			}
+			results.report = &scanReport
+			emitEvents(dockerCli, flags, report.FindingEvents(results.ref, scanReport.Vulnerabilities)...)
+		}
+	}
+	if results.report != nil {
+		attributeLayers(ctx, dockerCli, flags, ref, &results)
+	}
+	if err := checkConfigurations(ctx, dockerCli, flags, ref, &results); err != nil {
+		return results, err
+	}
+	emitEvents(dockerCli, flags, report.FindingEvents(results.ref, results.misconfigurations)...)
+	if err := analyzeLayers(ctx, dockerCli, flags, analyzers, ref, &results); err != nil {
+		return results, err
+	}
+	if err := trackFindingAge(flags, &results); err != nil {
+		return results, err
+	}
+	err := evaluatePolicy(flags, &results)
+	return results, err
+}
+
+// checkConfigurations reports the configuration issues of the image with --scope config, and of the container
+// running it with runtime checks
+func checkConfigurations(ctx context.Context, dockerCli command.Cli, flags options, ref string, results *scanResults) error {
+	if hasScope(flags, report.ScopeConfig) {
+		misconfigurations, err := checkConfiguration(ctx, dockerCli, flags, ref)
+		if err != nil {
+			return err
+		}
+		results.misconfigurations = misconfigurations
+	}
+	if flags.runtimeChecks != "" {
+		runtimeIssues, err := misconfig.CheckContainer(ctx, dockerCli.Client(), flags.runtimeChecks)
+		if err != nil {
+			return err
+		}
+		results.misconfigurations = append(nonNil(results.misconfigurations), runtimeIssues...)
+	}
+	return nil
+}
+
+func checkConfiguration(ctx context.Context, dockerCli command.Cli, flags options, ref string) ([]report.Vulnerability, error) {
+	misconfigurations, err := checkImageConfig(ctx, dockerCli, flags, ref)
+	if err != nil {
+		return nil, err
+	}
+	if flags.dockerFilePath != "" {
+		stages, err := parseDockerfile(flags)
+		if err != nil {
+			return nil, err
+		}
+		misconfigurations = append(misconfigurations, misconfig.CheckDockerfile(stages)...)
+	}
+	return misconfigurations, nil
+}
+
+// scanError returns the error of the scan, taking into account the findings of the plugin analyzers
+// and the vulnerabilities filtered out by the plugin. When a policy is evaluated, the scan fails only
+// if the policy fails. With the upgradable fail-on mode, only the fixable vulnerabilities fail the scan.
+func (r scanResults) scanError(providerErr error, failOn string) error {
+	if providerErr != nil && !provider.IsVulnerabilitiesFoundError(providerErr) {
+		return providerErr
+	}
+	if r.policy != nil {
+		if !policy.Passed(r.policy) {
+			return provider.NewVulnerabilitiesFoundError()
+		}
+		return nil
+	}
+	findings := len(r.malware) + len(r.misconfigurations) + len(r.secrets) + len(r.licenseIssues)
+	if r.report != nil {
+		findings += failingVulnerabilities(r.report.Vulnerabilities, failOn)
+	} else if providerErr != nil {
+		return providerErr
+	}
+	if findings > 0 {
+		return provider.NewVulnerabilitiesFoundError()
+	}
+	return nil
+}
+
+// gateError returns the error of the scan, the analysis error if the provider output can't be analyzed, except when the
+// provider refused the scan as the quota error is reported instead
+func (r scanResults) gateError(providerErr, analyzeErr error, failOn string) error {
+	if analyzeErr != nil && !r.quotaExceeded {
+		return analyzeErr
+	}
+	return r.scanError(providerErr, failOn)
+}
+
+// failingVulnerabilities counts the vulnerabilities failing the scan
+func failingVulnerabilities(vulns []report.Vulnerability, failOn string) int {
+	count := 0
+	for _, vuln := range vulns {
+		// the informational findings are outside of the runtime attack surface and don't fail the scan
+		if report.IsInformational(vuln) || (failOn == failOnUpgradable && !vuln.Fixable()) {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+func writeResults(dockerCli command.Cli, flags options, providerOutput []byte, results scanResults) error {
+	if err := writeExports(flags, results); err != nil {
+		return err
+	}
+	if flags.jsonFile != "" {
+		if err := archive.WriteReport(flags.jsonFile, jsonResults(flags, providerOutput, results), archiveEntry(results)); err != nil {
+			return fmt.Errorf("cannot write the JSON results: %s", err)
+		}
+	}
+	if flags.jsonFormat {
+		_, err := dockerCli.Out().Write(jsonResults(flags, providerOutput, results))
+		return err
+	}
+	if flags.interactive {
+		if err := browseFindings(dockerCli, flags, results); err != nil {
+			return err
+		}
+	} else if err := writeFindings(dockerCli.Out(), flags, results); err != nil {
+		return err
+	}
+	if results.policy != nil {
+		policy.WriteResults(policyOutput(dockerCli, flags), results.policy)
+	}
+	return nil
+}
+
+// archiveEntry returns the counts of the scan indexed with its report archived with --json-file report.json.zst
+func archiveEntry(results scanResults) history.Entry {
+	entry := history.Entry{Image: results.ref, Time: time.Now().UTC(), Counts: map[string]int{}}
+	if results.report != nil {
+		entry.Counts = report.NewMatrixColumn(results.ref, results.findings()).Counts
+	}
+	for _, count := range entry.Counts {
+		entry.Total += count
+	}
+	return entry
+}
+
+// writeFindings prints the findings in the output mode selected by the flags
+func writeFindings(out io.Writer, flags options, results scanResults) error {
+	if flags.quiet {
+		report.WriteCounts(out, results.findings())
+		return nil
+	}
+	if flags.summary {
+		return report.WriteSummary(out, results.findings())
+	}
+	if streamsEvents(flags) {
+		return writeSummaryEvent(out, results)
+	}
+	if flags.format != "" {
+		return writeDocument(out, flags.format, results)
+	}
+	if err := writeProviderFindings(out, flags, results); err != nil {
+		return err
+	}
+	if results.misconfigurations != nil {
+		report.WriteFindings(out, "Configuration issues", results.misconfigurations)
+	}
+	if results.secrets != nil {
+		report.WriteFindings(out, "Secrets", results.secrets)
+	}
+	if len(results.licenseIssues) > 0 {
+		report.WriteFindings(out, "License issues", results.licenseIssues)
+	}
+	if len(results.malware) > 0 {
+		report.WriteMalware(out, results.malware)
+	}
+	if err := writeInventory(out, results); err != nil {
+		return err
+	}
+	if results.squash != nil {
+		report.WriteSquashSimulation(out, *results.squash)
+	}
+	printWarnings(out, flags, results)
+	return nil
+}
+
+// writeProviderFindings prints the vulnerabilities of the provider, grouped by layer with --group-by layer, followed by
+// the layer heatmap and the dependency paths when requested
+func writeProviderFindings(out io.Writer, flags options, results scanResults) error {
+	if results.report == nil {
+		return nil
+	}
+	switch {
+	case results.layers != nil:
+		report.WriteLayerGroups(out, *results.report, results.layers)
+	case runsProvider(flags):
+		report.WriteText(out, *results.report)
+	}
+	if results.heatmap != nil {
+		if err := report.WriteLayerHeatmap(out, results.heatmap); err != nil {
+			return err
+		}
+	}
+	if flags.showPaths {
+		report.WriteDependencyPaths(out, report.DependencyPaths(results.report.Vulnerabilities))
+	}
+	return nil
+}
+
+// printWarnings prints the warnings about the image itself, which don't change the exit code
+func printWarnings(out io.Writer, flags options, results scanResults) {
+	if results.metadata != nil {
+		printMetadataWarnings(out, *results.metadata, flags.maxImageAge)
+	}
+	for _, warning := range results.integrityWarnings {
+		fmt.Fprintf(out, "\nWarning: %s, possible layer substitution\n", warning)
+	}
+}
+
+// writeInventory prints the standalone binaries and the packages found in the image
+func writeInventory(out io.Writer, results scanResults) error {
+	if results.binaries != nil {
+		if err := report.WriteBinaries(out, results.binaries); err != nil {
+			return err
+		}
+	}
+	if results.packages != nil {
+		return report.WriteLicenses(out, results.packages)
+	}
+	return nil
+}
+
+// jsonResults adds the plugin analyses to the provider JSON output, without the vulnerabilities filtered out by the plugin
+func jsonResults(flags options, providerOutput []byte, results scanResults) []byte {
+	output := rewriteProviderOutput(flags, providerOutput, results)
+	reportURL := ""
+	var dependencyPaths []report.DependencyTree
+	if results.report != nil {
+		reportURL = results.report.URL
+		if flags.showPaths {
+			dependencyPaths = report.DependencyPaths(results.report.Vulnerabilities)
+		}
+	}
+	fields := []struct {
+		key   string
+		value interface{}
+		set   bool
+	}{
+		{key: "layers", value: results.layers, set: results.layers != nil},
+		{key: "layerHeatmap", value: results.heatmap, set: results.heatmap != nil},
+		{key: "dependencyPaths", value: dependencyPaths, set: dependencyPaths != nil},
+		{key: "misconfigurations", value: results.misconfigurations, set: results.misconfigurations != nil},
+		{key: "secrets", value: results.secrets, set: results.secrets != nil},
+		{key: "packages", value: results.packages, set: results.packages != nil},
+		{key: "licenseIssues", value: results.licenseIssues, set: results.licenseIssues != nil},
+		{key: "malware", value: results.malware, set: results.malware != nil},
+		{key: "binaries", value: results.binaries, set: results.binaries != nil},
+		{key: "integrityWarnings", value: results.integrityWarnings, set: results.integrityWarnings != nil},
+		{key: "squashSimulation", value: results.squash, set: results.squash != nil},
+		{key: "imageMetadata", value: results.metadata, set: results.metadata != nil},
+		{key: "policy", value: results.policy, set: results.policy != nil},
+		{key: "vex", value: results.vex, set: results.vex != nil},
+		{key: "quotaExceeded", value: true, set: results.quotaExceeded},
+		{key: "softFail", value: results.softFail, set: results.softFail != nil},
+		{key: "reportUrl", value: reportURL, set: reportURL != ""},
+	}
+	for _, field := range fields {
+		if !field.set {
+			continue
+		}
+		if withField, err := report.AppendField(output, field.key, field.value); err == nil {
+			output = withField
+		}
+	}
+	return output
+}
+
+// rewriteProviderOutput applies the filters and the annotations of the plugin to the provider JSON output
+func rewriteProviderOutput(flags options, providerOutput []byte, results scanResults) []byte {
+	output := providerOutput
+	if results.report != nil && (filtersVulnerabilities(flags) || flags.excludeBase) {
+		if filtered, err := report.FilterDocument(output, results.keep); err == nil {
+			output = filtered
+		}
+	}
+	if results.report != nil && len(flags.nonRuntimePaths) > 0 {
+		if downgraded, err := report.DowngradeDocument(output, flags.nonRuntimePaths); err == nil {
+			output = downgraded
+		}
+	}
+	if locate := dockerfileLocator(flags); locate != nil {
+		if located, err := report.LocateDocument(output, locate); err == nil {
+			output = located
+		}
+	}
+	if results.report != nil && enriched(results.report.Vulnerabilities) {
+		if withExploits, err := report.ExploitDocument(output, results.report.Vulnerabilities, flags.sortBy == sortByEPSS); err == nil {
+			output = withExploits
+		}
+	}
+	if results.report != nil {
+		if linked, err := report.LinkDocument(output); err == nil {
+			output = linked
+		}
+	}
+	return output
+}
@@ -0,0 +1,44 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+)
+
+// validateIsolated rejects --isolated with the providers running on the host
+func validateIsolated(flags options) error {
+	if flags.isolated && flags.provider != "" && flags.provider != imageProvider {
+		return fmt.Errorf("--isolated runs the Snyk provider in a container, it can't be used with --provider %s", flags.provider)
+	}
+	return nil
+}
+
+// environmentOptions selects the environment variables passed to the provider processes with the environment section
+// of the docker scan configuration
+func environmentOptions(conf config.Config) []provider.Ops {
+	if conf.Environment == nil {
+		return nil
+	}
+	return []provider.Ops{provider.WithEnvironment(provider.EnvironmentFilter{
+		Allow: conf.Environment.Allow,
+		Deny:  conf.Environment.Deny,
+	})}
+}
@@ -0,0 +1,66 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+func validateSBOMInput(flags options) error {
+	if flags.sbomInput == "" {
+		return nil
+	}
+	switch {
+	case flags.input != "":
+		return fmt.Errorf("--sbom-input flag cannot be used with --input flag")
+	case flags.watch:
+		return fmt.Errorf("--watch flag cannot be used with --sbom-input flag")
+	case flags.budget != 0:
+		return fmt.Errorf("--budget flag cannot be used with --sbom-input flag")
+	case flags.dockerFilePath != "":
+		return fmt.Errorf("--file flag cannot be used with --sbom-input flag")
+	}
+	return nil
+}
+
+// runSBOMScan matches the packages of the SBOM given with --sbom-input against the vulnerabilities,
+// the analyzers of the image don't run
+func runSBOMScan(ctx context.Context, cmd *cobra.Command, dockerCli command.Cli, flags options, args []string) error {
+	if len(args) != 0 {
+		if err := cmd.Usage(); err != nil {
+			return err
+		}
+		return fmt.Errorf(`"docker scan --sbom-input" accepts no argument`)
+	}
+	if _, err := provider.SBOMFormat(flags.sbomInput); err != nil {
+		return err
+	}
+	scanProvider, err := configureProvider(ctx, dockerCli, flags, hubAuthConfig(dockerCli), provider.WithStreams(dockerCli.Out(), dockerCli.Err()))
+	if err != nil {
+		return err
+	}
+	scanner, ok := scanProvider.(provider.SBOMScanner)
+	if !ok {
+		return fmt.Errorf("scanning an SBOM requires the Snyk binary, Trivy or Grype, use --provider binary, trivy or grype")
+	}
+	return scanner.ScanSBOM(flags.sbomInput)
+}
@@ -0,0 +1,245 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/filelock"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/docker/scan-cli-plugin/internal/schedule"
+	"github.com/spf13/cobra"
+)
+
+func newScheduleCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Manage the images scanned periodically by the schedule runner",
+		Args:  cli.NoArgs,
+	}
+	cmd.AddCommand(
+		newScheduleAddCmd(dockerCli),
+		newScheduleListCmd(dockerCli),
+		newScheduleRemoveCmd(dockerCli),
+		newScheduleRunCmd(ctx, dockerCli),
+	)
+	return cmd
+}
+
+func newScheduleAddCmd(dockerCli command.Cli) *cobra.Command {
+	var cron string
+	cmd := &cobra.Command{
+		Use:   "add [OPTIONS] IMAGE",
+		Short: "Scan an image periodically, following a cron expression",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleAdd(dockerCli, args[0], cron)
+		},
+	}
+	cmd.Flags().StringVar(&cron, "cron", "", `When to scan the image, as a cron expression like "0 6 * * *" in local time`)
+	return cmd
+}
+
+func newScheduleListCmd(dockerCli command.Cli) *cobra.Command {
+	var jsonFormat bool
+	cmd := &cobra.Command{
+		Use:     "ls [OPTIONS]",
+		Aliases: []string{"list"},
+		Short:   "List the scheduled scans and the results of their last run",
+		Args:    cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleList(dockerCli, jsonFormat)
+		},
+	}
+	cmd.Flags().BoolVar(&jsonFormat, "json", false, "Output the schedules and their runs in JSON format")
+	return cmd
+}
+
+func newScheduleRemoveCmd(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm ID",
+		Aliases: []string{"remove"},
+		Short:   "Remove a scheduled scan and the results of its runs",
+		Args:    cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if config.ReadOnly() {
+				return config.ErrReadOnly
+			}
+			if err := schedule.Remove(schedule.DefaultPath(), args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintln(dockerCli.Out(), args[0])
+			return nil
+		},
+	}
+}
+
+func newScheduleRunCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "run [OPTIONS]",
+		Short: "Run the due scheduled scans and store their results",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exitCodeError(runSchedules(ctx, dockerCli, all, time.Now()), options{
+				exitCodeOnVuln:  defaultExitCodeOnVuln,
+				exitCodeOnError: defaultExitCodeOnError,
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "Run all the scheduled scans, due or not")
+	return cmd
+}
+
+func runScheduleAdd(dockerCli command.Cli, image, cron string) error {
+	if cron == "" {
+		return fmt.Errorf("schedule add requires a cron expression with --cron")
+	}
+	if config.ReadOnly() {
+		return config.ErrReadOnly
+	}
+	added, err := schedule.Add(schedule.DefaultPath(), image, cron, time.Now())
+	if err != nil {
+		return err
+	}
+	next, _ := added.Next()
+	fmt.Fprintf(dockerCli.Out(), "Scheduled %s with ID %s, next scan at %s\n", image, added.ID, formatRunTime(next))
+	return nil
+}
+
+func runScheduleList(dockerCli command.Cli, jsonFormat bool) error {
+	schedules, err := schedule.Load(schedule.DefaultPath())
+	if err != nil {
+		return err
+	}
+	if jsonFormat {
+		if schedules == nil {
+			schedules = []schedule.Schedule{}
+		}
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(schedules)
+	}
+	return writeSchedules(dockerCli.Out(), schedules)
+}
+
+func writeSchedules(out io.Writer, schedules []schedule.Schedule) error {
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "ID\tIMAGE\tCRON\tNEXT SCAN\tLAST SCAN\tLAST RESULT")
+	for _, scheduled := range schedules {
+		next, _ := scheduled.Next()
+		last, result := "never", ""
+		if len(scheduled.Runs) > 0 {
+			run := scheduled.Runs[len(scheduled.Runs)-1]
+			last, result = formatRunTime(run.Time), formatRunResult(run)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", scheduled.ID, scheduled.Image, scheduled.Cron, formatRunTime(next), last, result)
+	}
+	return w.Flush()
+}
+
+// runSchedules scans the due images, an image failing to scan doesn't stop the scans of the others
+func runSchedules(ctx context.Context, dockerCli command.Cli, all bool, now time.Time) error {
+	if config.ReadOnly() {
+		return config.ErrReadOnly
+	}
+	path := schedule.DefaultPath()
+	schedules, err := schedule.Load(path)
+	if err != nil {
+		return err
+	}
+	var due []schedule.Schedule
+	for _, scheduled := range schedules {
+		if all || scheduled.Due(now) {
+			due = append(due, scheduled)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+	providerOut := bytes.NewBuffer(nil)
+	scanProvider, err := configureProvider(ctx, dockerCli, options{jsonFormat: true},
+		hubAuthConfig(dockerCli), provider.WithStreams(providerOut, dockerCli.Err()))
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, scheduled := range due {
+		providerOut.Reset()
+		run := runSchedule(scanProvider, providerOut, path, scheduled)
+		if run.Error != "" {
+			failed++
+		} else {
+			appendHistory(dockerCli, historyEntry(ctx, dockerCli, scanProvider, scheduled.Image, run.Counts), providerOut.Bytes())
+		}
+		if err := schedule.Record(path, scheduled.ID, run); err != nil {
+			return err
+		}
+		fmt.Fprintf(dockerCli.Out(), "%s %s: %s\n", scheduled.ID, scheduled.Image, formatRunResult(run))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of the %d scheduled scans failed", failed, len(due))
+	}
+	return nil
+}
+
+// runSchedule scans the image of a schedule, storing the JSON results of the provider next to the schedules
+func runSchedule(scanProvider provider.Provider, providerOut *bytes.Buffer, path string, scheduled schedule.Schedule) schedule.Run {
+	run := schedule.Run{Time: time.Now().UTC()}
+	column := matrixColumn(scanProvider, providerOut, scheduled.Image)
+	if column.Error != "" {
+		run.Error = column.Error
+		return run
+	}
+	run.Counts = column.Counts
+	results := schedule.ResultsPath(path, scheduled.ID, run.Time)
+	if err := filelock.WriteFile(results, providerOut.Bytes(), 0644); err != nil {
+		run.Error = fmt.Sprintf("failed to store the results: %s", err)
+		return run
+	}
+	run.Results = results
+	return run
+}
+
+func formatRunTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func formatRunResult(run schedule.Run) string {
+	if run.Error != "" {
+		return "failed: " + run.Error
+	}
+	var parts []string
+	for i := len(report.Severities) - 1; i >= 0; i-- {
+		parts = append(parts, fmt.Sprintf("%d %s", run.Counts[report.Severities[i]], report.Severities[i]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,101 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+func validateScopes(flags options) error {
+	for _, scope := range flags.scopes {
+		if !contains(report.Scopes, scope) {
+			return fmt.Errorf("--scope takes only %s values", strings.Join(report.Scopes, ", "))
+		}
+	}
+	return nil
+}
+
+// hasScope returns true if the scope has been selected with --scope
+func hasScope(flags options, scope string) bool {
+	return contains(flags.scopes, scope)
+}
+
+// runsProvider returns true if the scan provider is needed for the selected scopes,
+// it always runs when no scope is selected
+func runsProvider(flags options) bool {
+	return len(flags.scopes) == 0 ||
+		hasScope(flags, report.ScopeOS) || hasScope(flags, report.ScopeApp) || hasScope(flags, report.ScopeLicenses)
+}
+
+// scopeOptions selects the provider analyzers matching the scopes
+func scopeOptions(flags options) []provider.Ops {
+	switch {
+	case hasScope(flags, report.ScopeApp):
+		return []provider.Ops{provider.WithAppVulns()}
+	case hasScope(flags, report.ScopeOS):
+		return []provider.Ops{provider.WithoutAppVulns()}
+	}
+	return nil
+}
+
+// inScope returns true if the vulnerability belongs to a selected scope
+func inScope(flags options) func(report.Vulnerability) bool {
+	return func(vuln report.Vulnerability) bool {
+		return len(flags.scopes) == 0 || hasScope(flags, vuln.Scope())
+	}
+}
+
+// keepVulnerability returns true if the vulnerability belongs to a selected scope, is not excluded with --exclude-cve
+// and has a fix with --only-fixable
+func keepVulnerability(flags options) func(report.Vulnerability) bool {
+	scoped := inScope(flags)
+	return func(vuln report.Vulnerability) bool {
+		if !scoped(vuln) || (flags.onlyFixable && !vuln.Fixable()) {
+			return false
+		}
+		for _, id := range vuln.CVEs() {
+			if contains(flags.excludedCVEs, id) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// writeEmptyProviderOutput stands for the provider output when the provider doesn't run
+func writeEmptyProviderOutput(out io.Writer, ref string) error {
+	return json.NewEncoder(out).Encode(map[string]interface{}{
+		"ok":              true,
+		"path":            ref,
+		"vulnerabilities": []interface{}{},
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
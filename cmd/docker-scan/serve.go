@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	cliConfig "github.com/docker/cli/cli/config"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/server"
+	"github.com/spf13/cobra"
+)
+
+func newServeCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var listen string
+	cmd := &cobra.Command{
+		Use:   "serve [OPTIONS]",
+		Short: "Serve a local REST API running scans, for Docker Desktop and IDE extensions",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(ctx, dockerCli, listen)
+		},
+	}
+	cmd.Flags().StringVar(&listen, "listen", "", "Address to listen on, as unix://PATH or HOST:PORT (default unix socket in the docker scan configuration directory)")
+	return cmd
+}
+
+func runServe(ctx context.Context, dockerCli command.Cli, address string) error {
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	// the server can't ask for the consent on each scan request
+	if usesSnyk(selectedProvider(options{}, conf)) && !conf.Optin {
+		return fmt.Errorf("accept using the third party scanning provider first, with docker scan --accept-license")
+	}
+	listener, err := serveListener(address)
+	if err != nil {
+		return err
+	}
+	// the unix socket is only accessible to the user, any local process and web page can reach a TCP address
+	var token string
+	if listener.Addr().Network() == "tcp" {
+		if token, err = server.NewToken(); err != nil {
+			return err
+		}
+	}
+	httpServer := &http.Server{Handler: server.New(ctx, serveScanner(dockerCli), token).Handler()}
+	go func() {
+		<-ctx.Done()
+		httpServer.Close() //nolint: errcheck
+	}()
+	fmt.Fprintf(dockerCli.Err(), "Serving the docker scan API on %s\n", listener.Addr())
+	if token != "" {
+		fmt.Fprintf(dockerCli.Err(), "Authenticate the requests with the header: Authorization: Bearer %s\n", token)
+	}
+	if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// serveListener listens on a unix socket or on a TCP address, only on the loopback interface by default
+func serveListener(address string) (net.Listener, error) {
+	if address == "" {
+		address = "unix://" + filepath.Join(cliConfig.Dir(), "scan", "scan.sock")
+	}
+	if !strings.HasPrefix(address, "unix://") {
+		if host, _, err := net.SplitHostPort(address); err == nil && host == "" {
+			address = "127.0.0.1" + address
+		}
+		return net.Listen("tcp", address)
+	}
+	path := strings.TrimPrefix(address, "unix://")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	// remove the socket left by a previous server
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return listener, os.Chmod(path, 0600)
+}
+
+// serveScanner runs the provider of the configuration, reporting the results as JSON
+func serveScanner(dockerCli command.Cli) server.Scanner {
+	return func(ctx context.Context, request server.Request, out *server.Output) error {
+		flags := options{jsonFormat: true, severity: request.Severity, dockerFilePath: request.Dockerfile}
+		scanProvider, err := configureProvider(ctx, dockerCli, flags, hubAuthConfig(dockerCli), provider.WithStreams(out, ioutil.Discard))
+		if err != nil {
+			return err
+		}
+		if err := scanProvider.Scan(request.Image); err != nil && !provider.IsVulnerabilitiesFoundError(err) {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,64 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+)
+
+// softFailResult is added to the JSON results with --soft-fail
+type softFailResult struct {
+	// ExitCode is the exit code the scan would have returned without --soft-fail
+	ExitCode int `json:"exitCode"`
+}
+
+func validateSoftFail(flags options) error {
+	if flags.softFail && flags.waiver != "" {
+		return cli.StatusError{
+			Status:     "--soft-fail never fails the scan, it can't be used with --waiver",
+			StatusCode: invalidFlagsExitCode,
+		}
+	}
+	return nil
+}
+
+// softFailOf returns the exit code of the scan error with --soft-fail, nil otherwise
+func softFailOf(flags options, scanErr error) *softFailResult {
+	if !flags.softFail {
+		return nil
+	}
+	if statusErr, ok := exitCodeError(scanErr, flags).(cli.StatusError); ok {
+		return &softFailResult{ExitCode: statusErr.StatusCode}
+	}
+	return &softFailResult{}
+}
+
+// softFail reports the failure of the scan as a warning with --soft-fail, so that the scan always succeeds
+func softFail(dockerCli command.Cli, flags options, err error) error {
+	statusErr, ok := err.(cli.StatusError)
+	if !flags.softFail || !ok {
+		return err
+	}
+	if statusErr.Status != "" {
+		fmt.Fprintln(dockerCli.Err(), statusErr.Status)
+	}
+	fmt.Fprintf(dockerCli.Err(), "Warning: --soft-fail is set, the scan would have exited with code %d\n", statusErr.StatusCode)
+	return nil
+}
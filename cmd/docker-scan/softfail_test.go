@@ -0,0 +1,102 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"gotest.tools/v3/assert"
+)
+
+// fakeStreamsCli is a docker CLI writing its error stream to a buffer
+type fakeStreamsCli struct {
+	command.Cli
+	err *bytes.Buffer
+}
+
+func (c fakeStreamsCli) Err() io.Writer {
+	return c.err
+}
+
+func TestSoftFail(t *testing.T) {
+	testCases := []struct {
+		name             string
+		scanErr          error
+		expectedExitCode int
+	}{
+		{name: "vulnerabilities found", scanErr: provider.NewVulnerabilitiesFoundError(), expectedExitCode: 10},
+		{name: "provider failure", scanErr: provider.NewProviderFailedError(1), expectedExitCode: 20},
+		{name: "scan failure", scanErr: errors.New("cannot pull the image"), expectedExitCode: 20},
+		{name: "classified failure", scanErr: fmt.Errorf("no grype: %w", provider.ErrBinaryNotFound), expectedExitCode: binaryNotFoundExitCode},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			flags := options{exitCodeOnVuln: 10, exitCodeOnError: 20}
+
+			// without --soft-fail, the scan fails with the exit code
+			statusErr, ok := exitCodeError(testCase.scanErr, flags).(cli.StatusError)
+			assert.Assert(t, ok)
+			assert.Equal(t, statusErr.StatusCode, testCase.expectedExitCode)
+
+			// with --soft-fail, the scan succeeds and warns about the exit code
+			flags.softFail = true
+			stderr := bytes.NewBuffer(nil)
+			err := softFail(fakeStreamsCli{err: stderr}, flags, exitCodeError(testCase.scanErr, flags))
+			assert.NilError(t, err)
+			assert.Assert(t, bytes.Contains(stderr.Bytes(),
+				[]byte(fmt.Sprintf("Warning: --soft-fail is set, the scan would have exited with code %d", testCase.expectedExitCode))), stderr.String())
+
+			// the JSON results hold the exit code of the scan without --soft-fail
+			output := jsonResults(flags, []byte(`{"ok":false,"vulnerabilities":[]}`), scanResults{softFail: softFailOf(flags, testCase.scanErr)})
+			var results struct {
+				SoftFail *softFailResult `json:"softFail"`
+			}
+			assert.NilError(t, json.Unmarshal(output, &results))
+			assert.Assert(t, results.SoftFail != nil)
+			assert.Equal(t, results.SoftFail.ExitCode, testCase.expectedExitCode)
+		})
+	}
+}
+
+func TestSoftFailWithoutFindings(t *testing.T) {
+	flags := options{exitCodeOnVuln: 1, exitCodeOnError: 2, softFail: true}
+	stderr := bytes.NewBuffer(nil)
+	assert.NilError(t, softFail(fakeStreamsCli{err: stderr}, flags, exitCodeError(nil, flags)))
+	assert.Equal(t, stderr.String(), "")
+	assert.DeepEqual(t, softFailOf(flags, nil), &softFailResult{ExitCode: 0})
+}
+
+func TestSoftFailNotSet(t *testing.T) {
+	flags := options{exitCodeOnVuln: 1, exitCodeOnError: 2}
+	err := softFail(fakeStreamsCli{err: bytes.NewBuffer(nil)}, flags, exitCodeError(provider.NewVulnerabilitiesFoundError(), flags))
+	assert.DeepEqual(t, err, cli.StatusError{StatusCode: 1})
+	assert.Assert(t, softFailOf(flags, provider.NewVulnerabilitiesFoundError()) == nil)
+}
+
+func TestValidateSoftFail(t *testing.T) {
+	assert.NilError(t, validateSoftFail(options{softFail: true}))
+	err := validateSoftFail(options{softFail: true, waiver: "waiver.json"})
+	assert.Equal(t, err.(cli.StatusError).StatusCode, invalidFlagsExitCode)
+}
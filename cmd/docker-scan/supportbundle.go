@@ -0,0 +1,168 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal"
+	"github.com/docker/scan-cli-plugin/internal/debug"
+	"github.com/docker/scan-cli-plugin/internal/invocation"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+type supportBundleOptions struct {
+	output string
+}
+
+func newSupportBundleCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags supportBundleOptions
+	cmd := &cobra.Command{
+		Use:   "support-bundle [OPTIONS]",
+		Short: "Create a zip with the configuration, the versions and the recent provider runs to attach to a bug report",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSupportBundle(ctx, dockerCli, flags)
+		},
+	}
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Path of the zip file, docker-scan-support-DATE.zip by default")
+	return cmd
+}
+
+func runSupportBundle(ctx context.Context, dockerCli command.Cli, flags supportBundleOptions) error {
+	output := flags.output
+	if output == "" {
+		output = fmt.Sprintf("docker-scan-support-%s.zip", time.Now().Format("20060102-150405"))
+	}
+	files, err := supportBundleFiles(ctx, dockerCli)
+	if err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(nil)
+	archive := zip.NewWriter(buf)
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(file.content); err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(output, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Out(), "Support bundle written to %s, attach it to your bug report\n", output)
+	return nil
+}
+
+type bundleFile struct {
+	name    string
+	content []byte
+}
+
+// supportBundleFiles returns the files of the support bundle: the configuration with its secrets redacted, the versions,
+// the invocation log of the provider and its last failed invocation
+func supportBundleFiles(ctx context.Context, dockerCli command.Cli) ([]bundleFile, error) {
+	conf, err := redactedConfig()
+	if err != nil {
+		return nil, err
+	}
+	files := []bundleFile{
+		{name: "config.json", content: conf},
+		{name: "version.txt", content: bundleVersion(ctx, dockerCli)},
+	}
+	log, err := ioutil.ReadFile(invocation.DefaultPath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	files = append(files, bundleFile{name: "invocations.jsonl", content: log})
+	invocations, err := invocation.Read(invocation.DefaultPath())
+	if err != nil {
+		return nil, err
+	}
+	if failed, ok := invocation.LastFailed(invocations); ok {
+		content, err := json.MarshalIndent(failed, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, bundleFile{name: "last-failure.json", content: content})
+	}
+	return files, nil
+}
+
+// redactedConfig returns the docker scan configuration in JSON format, without its secrets
+func redactedConfig() ([]byte, error) {
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	buf, err := json.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	if err := json.Unmarshal(buf, &document); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(debug.RedactDocument(document), "", "  ")
+}
+
+// bundleVersion describes the versions of the plugin, of the provider and of the platform, the failures to get them
+// are part of the description
+func bundleVersion(ctx context.Context, dockerCli command.Cli) []byte {
+	out := bytes.NewBuffer(nil)
+	fmt.Fprintf(out, "Version:    %s\n", internal.Version)
+	fmt.Fprintf(out, "Git commit: %s\n", internal.GitCommit)
+	fmt.Fprintf(out, "Go version: %s\n", runtime.Version())
+	fmt.Fprintf(out, "OS/Arch:    %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	if server, err := dockerCli.Client().ServerVersion(ctx); err != nil {
+		fmt.Fprintf(out, "Engine:     %s\n", err)
+	} else {
+		fmt.Fprintf(out, "Engine:     %s (%s/%s)\n", server.Version, server.Os, server.Arch)
+	}
+	flags := options{showVersion: true, exitCodeOnVuln: defaultExitCodeOnVuln, exitCodeOnError: defaultExitCodeOnError}
+	scanProvider, err := configureProvider(ctx, dockerCli, flags, provider.WithStreams(ioutil.Discard, ioutil.Discard))
+	if err == nil {
+		var version string
+		if version, err = scanProvider.Version(); err == nil {
+			fmt.Fprintf(out, "Provider:   %s\n", version)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(out, "Provider:   %s\n", err)
+	}
+	if err := writeBinaryResolution(out, flags); err != nil {
+		fmt.Fprintf(out, "Provider binary: %s\n", err)
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,45 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/cli/cli/command"
+)
+
+// withTimeout returns the context of the command, canceled after the timeout given with --timeout
+func withTimeout(ctx context.Context, flags options) (context.Context, func()) {
+	if flags.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, flags.timeout)
+}
+
+// interruptedScanError prints the partial output of the provider, when it was captured instead of printed
+// as it came, and returns the reason of the interruption
+func interruptedScanError(ctx context.Context, dockerCli command.Cli, flags options, providerOutput []byte) error {
+	if (flags.jsonFormat || needsReport(flags)) && len(providerOutput) > 0 {
+		fmt.Fprintln(dockerCli.Err(), "Partial results of the interrupted scan:")
+		_, _ = dockerCli.Out().Write(providerOutput)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("the scan did not complete within %s", flags.timeout)
+	}
+	return fmt.Errorf("the scan was canceled")
+}
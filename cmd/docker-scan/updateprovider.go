@@ -0,0 +1,106 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/optin"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
+	"github.com/spf13/cobra"
+)
+
+func newUpdateProviderCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var version, checksum, caCert string
+	cmd := &cobra.Command{
+		Use:   "update-provider [OPTIONS]",
+		Short: "Download the Snyk binary used to scan images",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpdateProvider(ctx, dockerCli, version, checksum, caCert)
+		},
+	}
+	cmd.Flags().StringVar(&version, "version", provider.SnykVersion, "Version of Snyk to download")
+	cmd.Flags().StringVar(&checksum, "checksum", "", "SHA256 checksum of the Snyk release artifact, required with another version than the pinned one")
+	cmd.Flags().StringVar(&caCert, "ca-cert", "", "PEM file of additional CA certificates to trust, overrides the caCert configuration")
+	return cmd
+}
+
+func runUpdateProvider(ctx context.Context, dockerCli command.Cli, version, checksum, caCert string) error {
+	if checksum == "" {
+		if version != provider.SnykVersion {
+			return fmt.Errorf("--checksum is required to download Snyk %s, only Snyk %s checksum is pinned in the plugin", version, provider.SnykVersion)
+		}
+		checksum = provider.SnykChecksum
+	}
+	if caCert == "" {
+		// the configuration file is only needed for the CA certificate, don't fail without it
+		if conf, err := config.ReadConfigFile(); err == nil {
+			caCert = conf.CACert
+		}
+	}
+	client, err := proxy.NewHTTPClient(caCert)
+	if err != nil {
+		return err
+	}
+	path := provider.DownloadedBinaryPath()
+	fmt.Fprintf(dockerCli.Err(), "Downloading Snyk %s...\n", version)
+	if err := provider.Download(ctx, client, version, checksum, path); err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Out(), "Snyk %s installed in %s\n", version, path)
+	return nil
+}
+
+// providerPath returns the Snyk binary chosen among the candidate locations, empty if none is found
+func providerPath(conf config.Config) string {
+	return provider.ResolveBinary(conf.Path).Path
+}
+
+// caCertPath returns the CA certificate given on the command line, otherwise the one from the configuration
+func caCertPath(flags options, conf config.Config) string {
+	if flags.caCert != "" {
+		return flags.caCert
+	}
+	return conf.CACert
+}
+
+// offerProviderDownload asks to download Snyk when its binary can't be found, only in an interactive terminal
+func offerProviderDownload(ctx context.Context, dockerCli command.Cli, caCert string) bool {
+	if !dockerCli.In().IsTerminal() {
+		return false
+	}
+	question := fmt.Sprintf("Could not find Snyk binary, do you want to download Snyk %s to %s?", provider.SnykVersion, provider.DownloadedBinaryPath())
+	if !optin.Ask(dockerCli.In(), dockerCli.Err(), question) {
+		return false
+	}
+	client, err := proxy.NewHTTPClient(caCert)
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Failed to download Snyk: %s\n", err)
+		return false
+	}
+	if err := provider.Download(ctx, client, provider.SnykVersion, provider.SnykChecksum, provider.DownloadedBinaryPath()); err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Failed to download Snyk: %s\n", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+type versionOptions struct {
+	verbose    bool
+	jsonFormat bool
+}
+
+// versionComponents are the versions of the plugin, of the scan provider and of the Docker CLI and engine
+type versionComponents struct {
+	Plugin   pluginVersion      `json:"plugin"`
+	Provider provider.Component `json:"provider"`
+	Docker   dockerVersion      `json:"docker"`
+}
+
+type pluginVersion struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit"`
+}
+
+type dockerVersion struct {
+	// ClientAPIVersion is the API version the Docker CLI uses with the engine
+	ClientAPIVersion    string `json:"clientApiVersion"`
+	EngineVersion       string `json:"engineVersion,omitempty"`
+	EngineAPIVersion    string `json:"engineApiVersion,omitempty"`
+	EngineMinAPIVersion string `json:"engineMinApiVersion,omitempty"`
+	// Error tells why the versions of the engine are missing
+	Error string `json:"error,omitempty"`
+}
+
+func newVersionCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags versionOptions
+	cmd := &cobra.Command{
+		Use:   "version [OPTIONS]",
+		Short: "Display the versions of the scan plugin, of the scan provider and of the Docker CLI and engine",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scanFlags := options{
+				showVersion:     true,
+				exitCodeOnVuln:  defaultExitCodeOnVuln,
+				exitCodeOnError: defaultExitCodeOnError,
+			}
+			if flags.verbose && flags.jsonFormat {
+				return exitCodeError(fmt.Errorf("--verbose can't be used with --json"), scanFlags)
+			}
+			if flags.verbose {
+				if err := writeBinaryResolution(dockerCli.Out(), scanFlags); err != nil {
+					return exitCodeError(err, scanFlags)
+				}
+			}
+			return exitCodeError(runVersionMatrix(ctx, dockerCli, flags, scanFlags), scanFlags)
+		},
+	}
+	cmd.Flags().BoolVar(&flags.verbose, "verbose", false, "Display the locations probed for the Snyk binary and the one chosen")
+	cmd.Flags().BoolVar(&flags.jsonFormat, "json", false, "Output the versions in JSON format")
+	return cmd
+}
+
+func runVersionMatrix(ctx context.Context, dockerCli command.Cli, flags versionOptions, scanFlags options) error {
+	scanProvider, err := configureProvider(ctx, dockerCli, scanFlags)
+	if err != nil {
+		return err
+	}
+	component, err := provider.Describe(scanProvider)
+	if err != nil {
+		return err
+	}
+	components := versionComponents{
+		Plugin:   pluginVersion{Version: internal.Version, GitCommit: internal.GitCommit},
+		Provider: component,
+		Docker:   dockerVersions(ctx, dockerCli),
+	}
+	if flags.jsonFormat {
+		encoder := json.NewEncoder(dockerCli.Out())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(components)
+	}
+	return writeVersionMatrix(dockerCli.Out(), components)
+}
+
+// dockerVersions returns the API version of the Docker CLI and the versions of the engine, the failure to reach the
+// engine being part of the versions
+func dockerVersions(ctx context.Context, dockerCli command.Cli) dockerVersion {
+	versions := dockerVersion{ClientAPIVersion: dockerCli.Client().ClientVersion()}
+	server, err := dockerCli.Client().ServerVersion(ctx)
+	if err != nil {
+		versions.Error = err.Error()
+		return versions
+	}
+	versions.EngineVersion = server.Version
+	versions.EngineAPIVersion = server.APIVersion
+	versions.EngineMinAPIVersion = server.MinAPIVersion
+	return versions
+}
+
+func writeVersionMatrix(out io.Writer, components versionComponents) error {
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tVERSION")
+	fmt.Fprintf(w, "docker scan\t%s (%s)\n", components.Plugin.Version, components.Plugin.GitCommit)
+	fmt.Fprintf(w, "%s\t%s\n", components.Provider.Name, components.Provider.Version)
+	fmt.Fprintf(w, "  minimal version\t%s\n", orDash(components.Provider.MinimalVersion))
+	databaseUpdatedAt := ""
+	if components.Provider.DatabaseUpdatedAt != nil {
+		databaseUpdatedAt = components.Provider.DatabaseUpdatedAt.Format(time.RFC3339)
+	}
+	fmt.Fprintf(w, "  vulnerability DB\t%s\n", orDash(databaseUpdatedAt))
+	fmt.Fprintf(w, "Docker CLI API\t%s\n", components.Docker.ClientAPIVersion)
+	if components.Docker.Error != "" {
+		fmt.Fprintf(w, "Docker Engine\t%s\n", components.Docker.Error)
+	} else {
+		fmt.Fprintf(w, "Docker Engine\t%s\n", components.Docker.EngineVersion)
+		fmt.Fprintf(w, "Docker Engine API\t%s (minimal %s)\n", components.Docker.EngineAPIVersion, components.Docker.EngineMinAPIVersion)
+	}
+	return w.Flush()
+}
+
+// writeBinaryResolution prints the candidate locations of the Snyk binary, when Snyk is the provider
+func writeBinaryResolution(out io.Writer, flags options) error {
+	conf, err := config.ReadConfigFile()
+	if err != nil {
+		return err
+	}
+	if !usesSnyk(selectedProvider(flags, conf)) {
+		return nil
+	}
+	resolution := provider.ResolveBinary(conf.Path)
+	if resolution.Path == "" {
+		fmt.Fprintln(out, "Provider binary: not found")
+	} else {
+		fmt.Fprintf(out, "Provider binary: %s (%s)\n", resolution.Path, resolution.Source)
+	}
+	for _, candidate := range resolution.Candidates {
+		status := "✗"
+		if candidate.Found {
+			status = "✓"
+		}
+		fmt.Fprintf(out, "  %s %-15s %s\n", status, candidate.Source, candidate.Path)
+	}
+	return nil
+}
+
+// orDash returns the value, or a dash when it is empty
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
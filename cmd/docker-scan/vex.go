@@ -0,0 +1,176 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/docker/scan-cli-plugin/internal/vex"
+	"github.com/spf13/cobra"
+)
+
+// vexResult is a VEX statement applied to a finding, reported in the JSON output
+type vexResult struct {
+	ID            string `json:"id"`
+	PackageName   string `json:"packageName"`
+	Status        string `json:"status"`
+	Justification string `json:"justification,omitempty"`
+	Suppressed    bool   `json:"suppressed"`
+}
+
+// applyVEX removes the findings the VEX documents of --vex mark not_affected or fixed, and annotates the findings
+// with another status
+func applyVEX(ctx context.Context, dockerCli command.Cli, flags options, ref string, scanReport *report.Report, results *scanResults) error {
+	if len(flags.vex) == 0 {
+		return nil
+	}
+	document, err := vex.Load(flags.vex)
+	if err != nil {
+		return err
+	}
+	target := vexTarget(ctx, dockerCli, flags, ref)
+	keep := results.keep
+	results.vex = []vexResult{}
+	annotated := []report.Vulnerability{}
+	for _, vuln := range scanReport.Vulnerabilities {
+		statement, ok := document.Lookup(target, vuln)
+		if !ok {
+			annotated = append(annotated, vuln)
+			continue
+		}
+		if keep(vuln) {
+			results.vex = append(results.vex, vexResult{
+				ID:            vuln.ID,
+				PackageName:   vuln.PackageName,
+				Status:        statement.Status,
+				Justification: statement.Justification,
+				Suppressed:    statement.Suppresses(),
+			})
+		}
+		if !statement.Suppresses() {
+			vuln.VEXStatus = statement.Status
+			vuln.VEXJustification = statement.Justification
+			annotated = append(annotated, vuln)
+		}
+	}
+	scanReport.Vulnerabilities = annotated
+	results.keep = func(vuln report.Vulnerability) bool {
+		statement, ok := document.Lookup(target, vuln)
+		return !(ok && statement.Suppresses()) && keep(vuln)
+	}
+	if suppressed := countSuppressed(results.vex); suppressed > 0 {
+		fmt.Fprintf(dockerCli.Err(), "%d vulnerabilities suppressed by the VEX statements\n", suppressed)
+	}
+	return nil
+}
+
+func countSuppressed(results []vexResult) int {
+	count := 0
+	for _, result := range results {
+		if result.Suppressed {
+			count++
+		}
+	}
+	return count
+}
+
+// vexTarget describes the scanned image to the VEX products, with its repository digests when it's known by the engine
+func vexTarget(ctx context.Context, dockerCli command.Cli, flags options, ref string) vex.Target {
+	target := vex.Target{Image: imageName(flags, ref)}
+	if flags.input != "" {
+		return target
+	}
+	inspect, _, err := dockerCli.Client().ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return target
+	}
+	for _, repoDigest := range inspect.RepoDigests {
+		if i := strings.Index(repoDigest, "@"); i >= 0 {
+			target.Digests = append(target.Digests, repoDigest[i+1:])
+		}
+	}
+	return target
+}
+
+type vexGenerateOptions struct {
+	author         string
+	output         string
+	dockerFilePath string
+}
+
+func newVEXCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vex",
+		Short: "Manage the VEX documents telling which vulnerabilities affect an image",
+		Args:  cli.NoArgs,
+	}
+	cmd.AddCommand(newVEXGenerateCmd(ctx, dockerCli))
+	return cmd
+}
+
+func newVEXGenerateCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags vexGenerateOptions
+	cmd := &cobra.Command{
+		Use:   "generate [OPTIONS] IMAGE",
+		Short: "Generate an OpenVEX document with the vulnerabilities of an image under investigation, to be completed with their status",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVEXGenerate(ctx, dockerCli, flags, args[0])
+		},
+	}
+	cmd.Flags().StringVar(&flags.author, "author", "", "Author of the VEX statements, like the email of the image maintainers")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Write the document to the given file instead of the standard output")
+	cmd.Flags().StringVarP(&flags.dockerFilePath, "file", "f", "", "Dockerfile associated with image, provides more detailed results")
+	return cmd
+}
+
+func runVEXGenerate(ctx context.Context, dockerCli command.Cli, flags vexGenerateOptions, image string) error {
+	if flags.author == "" {
+		return fmt.Errorf("the author of the VEX statements is required, set it with --author")
+	}
+	scanReport, err := scanImageReport(ctx, dockerCli, flags.dockerFilePath, image)
+	if err != nil {
+		return err
+	}
+	// the statements apply to the pushed image when it has a digest, to all the images of the tag otherwise
+	digest := ""
+	if target := vexTarget(ctx, dockerCli, options{}, image); len(target.Digests) > 0 {
+		digest = target.Digests[0]
+	}
+	product, err := vex.ImagePURL(image, digest)
+	if err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(vex.Generate(flags.author, product, scanReport.Vulnerabilities, time.Now()), "", "  ")
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+	if flags.output == "" {
+		_, err := dockerCli.Out().Write(buf)
+		return err
+	}
+	return ioutil.WriteFile(flags.output, buf, 0644)
+}
@@ -0,0 +1,218 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/internal/attest"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/waiver"
+	"github.com/spf13/cobra"
+)
+
+type waiverIssueOptions struct {
+	key     string
+	reason  string
+	issuer  string
+	expires time.Duration
+	output  string
+}
+
+func validateWaiver(flags options) error {
+	if flags.waiver == "" {
+		return nil
+	}
+	if flags.waiverKey == "" {
+		return fmt.Errorf("--waiver-key is mandatory to verify the signature of the --waiver")
+	}
+	if _, err := attest.LoadPublicKey(flags.waiverKey); err != nil {
+		return err
+	}
+	_, err := waiver.Load(flags.waiver)
+	return err
+}
+
+// applyWaiver lets the image pass the gate when its violations are waived by the waiver given with --waiver, signed
+// with the key of the security team. Each waiver presented is recorded in the audit log, accepted or not.
+func applyWaiver(ctx context.Context, dockerCli command.Cli, flags options, ref string, scanErr error) error {
+	if flags.waiver == "" || !provider.IsVulnerabilitiesFoundError(scanErr) {
+		return scanErr
+	}
+	record := waiver.Record{Time: time.Now(), Image: imageName(flags, ref), Digests: imageDigests(ctx, dockerCli, flags, ref), File: flags.waiver}
+	accepted, err := verifyWaiver(flags, record.Digests, record.Time)
+	record.Digest, record.Issuer, record.Reason, record.Expires = accepted.Digest, accepted.Issuer, accepted.Reason, accepted.Expires
+	record.Accepted = err == nil
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if auditErr := waiver.Audit(waiver.AuditPath(), record); auditErr != nil {
+		// an unaudited waiver must not be applied
+		fmt.Fprintf(dockerCli.Err(), "Warning: the waiver is ignored, it can't be recorded in the audit log: %s\n", auditErr)
+		return scanErr
+	}
+	if err != nil {
+		fmt.Fprintf(dockerCli.Err(), "Warning: the waiver %s is rejected: %s\n", flags.waiver, err)
+		return scanErr
+	}
+	fmt.Fprintf(dockerCli.Err(), "The violations of %s are waived until %s by %s: %s\n", record.Image,
+		accepted.Expires.Format(time.RFC3339), waiverIssuer(accepted), accepted.Reason)
+	return nil
+}
+
+func verifyWaiver(flags options, digests []string, now time.Time) (waiver.Waiver, error) {
+	key, err := attest.LoadPublicKey(flags.waiverKey)
+	if err != nil {
+		return waiver.Waiver{}, err
+	}
+	envelope, err := waiver.Load(flags.waiver)
+	if err != nil {
+		return waiver.Waiver{}, err
+	}
+	return waiver.Verify(envelope, key, digests, now)
+}
+
+func waiverIssuer(accepted waiver.Waiver) string {
+	if accepted.Issuer == "" {
+		return "the security team"
+	}
+	return accepted.Issuer
+}
+
+// imageDigests returns the digests a waiver can be issued for: the digest of the reference, the image ID and the
+// repository digests of the image known by the engine
+func imageDigests(ctx context.Context, dockerCli command.Cli, flags options, ref string) []string {
+	var digests []string
+	if named, err := reference.ParseNormalizedNamed(ref); err == nil {
+		if canonical, ok := named.(reference.Canonical); ok {
+			digests = append(digests, canonical.Digest().String())
+		}
+	}
+	if flags.input != "" {
+		return digests
+	}
+	inspect, _, err := dockerCli.Client().ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return digests
+	}
+	digests = append(digests, inspect.ID)
+	for _, repoDigest := range inspect.RepoDigests {
+		if i := strings.Index(repoDigest, "@"); i >= 0 {
+			digests = append(digests, repoDigest[i+1:])
+		}
+	}
+	return digests
+}
+
+func newWaiverCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "waiver",
+		Short: "Manage the signed waivers letting an image pass the gate despite its violations",
+		Args:  cli.NoArgs,
+	}
+	cmd.AddCommand(newWaiverIssueCmd(ctx, dockerCli))
+	return cmd
+}
+
+func newWaiverIssueCmd(ctx context.Context, dockerCli command.Cli) *cobra.Command {
+	var flags waiverIssueOptions
+	cmd := &cobra.Command{
+		Use:   "issue [OPTIONS] IMAGE|DIGEST",
+		Short: "Sign a waiver letting the image with this digest pass the gate despite its violations",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWaiverIssue(ctx, dockerCli, flags, args[0])
+		},
+	}
+	cmd.Flags().StringVar(&flags.key, "key", "", "Private key of the security team signing the waiver, like the cosign.key generated by cosign generate-key-pair")
+	cmd.Flags().StringVar(&flags.reason, "reason", "", "Why the violations of the image are accepted")
+	cmd.Flags().StringVar(&flags.issuer, "issuer", "", "Who issues the waiver, recorded in the audit log")
+	cmd.Flags().DurationVar(&flags.expires, "expires", 30*24*time.Hour, "Validity period of the waiver")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Write the waiver to this file instead of the standard output")
+	return cmd
+}
+
+func runWaiverIssue(ctx context.Context, dockerCli command.Cli, flags waiverIssueOptions, image string) error {
+	if flags.key == "" {
+		return fmt.Errorf("the private key signing the waiver is required, set it with --key")
+	}
+	if flags.reason == "" {
+		return fmt.Errorf("the reason of the waiver is required, set it with --reason")
+	}
+	if flags.expires <= 0 {
+		return fmt.Errorf("--expires must be a positive duration")
+	}
+	key, err := attest.LoadPrivateKey(flags.key, func() ([]byte, error) {
+		return keyPassword(dockerCli)
+	})
+	if err != nil {
+		return err
+	}
+	digest, err := waivedDigest(ctx, dockerCli, image)
+	if err != nil {
+		return err
+	}
+	issued := time.Now().UTC().Truncate(time.Second)
+	envelope, err := waiver.Issue(key, waiver.Waiver{
+		Digest:   digest,
+		Reason:   flags.reason,
+		Issuer:   flags.issuer,
+		IssuedAt: issued,
+		Expires:  issued.Add(flags.expires),
+	})
+	if err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+	if flags.output == "" {
+		_, err = dockerCli.Out().Write(buf)
+		return err
+	}
+	if err := ioutil.WriteFile(flags.output, buf, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Out(), "Waiver for %s written to %s, valid until %s\n", digest, flags.output, issued.Add(flags.expires).Format(time.RFC3339))
+	return nil
+}
+
+// waivedDigest returns the digest given as is, or the digest of the image in its registry
+func waivedDigest(ctx context.Context, dockerCli command.Cli, image string) (string, error) {
+	if strings.HasPrefix(image, "sha256:") {
+		return image, nil
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	repository, err := registryRepository(ctx, dockerCli, named)
+	if err != nil {
+		return "", err
+	}
+	return registryDigest(ctx, dockerCli, repository, named)
+}
@@ -0,0 +1,97 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/scan-cli-plugin/internal/provider"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/spf13/cobra"
+)
+
+// runWatch scans the image again each time its tag is rebuilt or retagged, and prints the changes since the previous scan
+func runWatch(ctx context.Context, cmd *cobra.Command, dockerCli command.Cli, flags options, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf(`"docker scan --watch" requires exactly 1 argument`)
+	}
+	if flags.jsonFormat {
+		return fmt.Errorf("--json flag can't be used with --watch")
+	}
+	ref, err := normalizeTag(args[0])
+	if err != nil {
+		return err
+	}
+	// subscribe before the first scan, to not miss a rebuild happening during it
+	messages, errs := dockerCli.Client().Events(ctx, types.EventsOptions{
+		Filters: filters.NewArgs(filters.Arg("type", events.ImageEventType), filters.Arg("event", "tag")),
+	})
+	var previous *report.Report
+	for {
+		results, err := scanImage(ctx, cmd, dockerCli, flags, args)
+		if err != nil && !provider.IsVulnerabilitiesFoundError(err) {
+			fmt.Fprintf(dockerCli.Err(), "Scan failed: %s\n", err)
+		}
+		if results.report != nil {
+			if previous != nil {
+				introduced, fixed := report.Diff(*previous, *results.report)
+				report.WriteDiff(dockerCli.Out(), introduced, fixed)
+			}
+			previous = results.report
+		}
+		fmt.Fprintf(dockerCli.Err(), "\nWatching %s, rebuild or retag it to scan it again (Ctrl-C to stop)\n", args[0])
+		if err := waitForTag(ctx, messages, errs, ref); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// waitForTag returns when the image is tagged with the reference, or when the context is canceled
+func waitForTag(ctx context.Context, messages <-chan events.Message, errs <-chan error, ref string) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to watch the engine events: %s", err)
+		case message := <-messages:
+			if name, err := normalizeTag(message.Actor.Attributes["name"]); err == nil && name == ref {
+				return nil
+			}
+		}
+	}
+}
+
+func normalizeTag(ref string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", err
+	}
+	return reference.TagNameOnly(named).String(), nil
+}
@@ -0,0 +1,90 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/scan-cli-plugin/webhook"
+	"github.com/spf13/cobra"
+)
+
+type webhookVerifyOptions struct {
+	signature string
+	timestamp string
+	maxAge    time.Duration
+}
+
+func newWebhookCmd(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "Help the receivers of the webhook notifications",
+		Args:  cli.NoArgs,
+	}
+	cmd.AddCommand(newWebhookVerifyCmd(dockerCli))
+	return cmd
+}
+
+func newWebhookVerifyCmd(dockerCli command.Cli) *cobra.Command {
+	var flags webhookVerifyOptions
+	cmd := &cobra.Command{
+		Use:   "verify [OPTIONS] [FILE]",
+		Short: "Verify the signature of a webhook delivery, read from the file or the standard input, with the secret of " + webhook.SecretEnvVar,
+		Args:  cli.RequiresMaxArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "-"
+			if len(args) > 0 {
+				path = args[0]
+			}
+			return runWebhookVerify(dockerCli, flags, path)
+		},
+	}
+	cmd.Flags().StringVar(&flags.signature, "signature", "", "Value of the "+webhook.SignatureHeader+" header of the delivery")
+	cmd.Flags().StringVar(&flags.timestamp, "timestamp", "", "Value of the "+webhook.TimestampHeader+" header of the delivery")
+	cmd.Flags().DurationVar(&flags.maxAge, "max-age", webhook.DefaultTolerance, "Maximum age of the delivery, 0 to accept any age")
+	return cmd
+}
+
+func runWebhookVerify(dockerCli command.Cli, flags webhookVerifyOptions, path string) error {
+	secret := os.Getenv(webhook.SecretEnvVar)
+	if secret == "" {
+		return fmt.Errorf("the secret shared with the webhook endpoint must be set in %s", webhook.SecretEnvVar)
+	}
+	if flags.signature == "" || flags.timestamp == "" {
+		return fmt.Errorf("--signature and --timestamp flags are required")
+	}
+	var body []byte
+	var err error
+	if path == "-" {
+		body, err = ioutil.ReadAll(dockerCli.In())
+	} else {
+		body, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	if err := webhook.Verify([]byte(secret), body, flags.signature, flags.timestamp, flags.maxAge, time.Now()); err != nil {
+		return fmt.Errorf("invalid webhook delivery: %s", err)
+	}
+	fmt.Fprintln(dockerCli.Out(), "The signature of the delivery is valid")
+	return nil
+}
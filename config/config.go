@@ -18,11 +18,15 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"syscall"
 
+	"github.com/docker/scan-cli-plugin/internal/filelock"
 	"github.com/pkg/errors"
 
 	cliConfig "github.com/docker/cli/cli/config"
@@ -30,47 +34,238 @@ import (
 
 // Config points to scan provider's binary
 type Config struct {
-	Path  string `json:"path"`
-	Optin bool   `json:"optin"`
+	Path   string `json:"path"`
+	Optin  bool   `json:"optin"`
+	CACert string `json:"caCert,omitempty"`
+	// Theme colors the severities of the terminal output: default, colorblind or none
+	Theme    string          `json:"theme,omitempty"`
+	Jira     *JiraConfig     `json:"jira,omitempty"`
+	SMTP     *SMTPConfig     `json:"smtp,omitempty"`
+	Licenses *LicensesConfig `json:"licenses,omitempty"`
+	Defaults *DefaultsConfig `json:"defaults,omitempty"`
+	Budget   *BudgetConfig   `json:"budget,omitempty"`
+	// FalsePositives configures where docker scan report-fp submits the false positive reports
+	FalsePositives *FalsePositivesConfig `json:"falsePositives,omitempty"`
+	// Results configures where docker scan --push-results posts the scan results
+	Results *ResultsConfig `json:"results,omitempty"`
+	// Notifications are sent when a scan finds issues of their severity or higher
+	Notifications []NotificationConfig `json:"notifications,omitempty"`
+	// OIDC configures the exchange of the OIDC token of the CI jobs for the provider token
+	OIDC *OIDCConfig `json:"oidc,omitempty"`
+	// Profiles are the named accounts selected with --profile, their credentials are kept in the credentials store
+	Profiles map[string]ProfileConfig `json:"profiles,omitempty"`
+	// MetricsFile is the JSON lines file each scan appends its summary to, no summary is written when empty
+	MetricsFile string `json:"metricsFile,omitempty"`
+	// Environment selects the environment variables passed to the provider processes
+	Environment *EnvironmentConfig `json:"environment,omitempty"`
+}
+
+// EnvironmentConfig selects the environment variables passed to the provider processes, by name or by prefix
+// followed by *, like SNYK_*
+type EnvironmentConfig struct {
+	// Allow lists the variables passed with the ones the providers need to run, like PATH or HOME, all the
+	// variables are passed when empty
+	Allow []string `json:"allow,omitempty"`
+	// Deny lists the variables never passed, even when they are allowed
+	Deny []string `json:"deny,omitempty"`
+}
+
+// ProfileConfig holds the settings of a named account
+type ProfileConfig struct {
+	// Org is the Snyk organization the scans of the profile run in
+	Org string `json:"org,omitempty"`
+	// HubUsername is the Docker Hub account of the profile, used instead of the docker login one
+	HubUsername string `json:"hubUsername,omitempty"`
+}
+
+// NotificationConfig is a notification sent when a scan finds issues of its severity or higher
+type NotificationConfig struct {
+	// Type is slack, webhook or email
+	Type string `json:"type"`
+	// URL is the Slack incoming webhook or the HTTP endpoint
+	URL string `json:"url,omitempty"`
+	// To are the recipients of the email notifications, sent with the SMTP server of the smtp section
+	To []string `json:"to,omitempty"`
+	// Severity is the threshold of the notification, all the issues are notified when empty
+	Severity string `json:"severity,omitempty"`
+	// Link points to the results of the scan, {image} being replaced by the name of the image
+	Link string `json:"link,omitempty"`
+}
+
+// OIDCConfig points to the endpoint exchanging the OIDC token of a CI job for a provider token
+type OIDCConfig struct {
+	URL string `json:"url"`
+	// Audience is the audience of the OIDC token requested to GitHub Actions
+	Audience string `json:"audience,omitempty"`
+}
+
+// ResultsConfig points to the collector of the scan results, like the Docker Hub scan history or a self-hosted one
+type ResultsConfig struct {
+	URL string `json:"url"`
+}
+
+// FalsePositivesConfig points to the endpoint receiving the false positive reports
+type FalsePositivesConfig struct {
+	URL string `json:"url"`
+}
+
+// BudgetConfig configures the images scanned first by docker scan --budget-order policy
+type BudgetConfig struct {
+	// Priorities lists image name patterns, from the most important images to the least important ones
+	Priorities []string `json:"priorities"`
+}
+
+// DefaultsConfig holds the default values of docker scan flags, used when the flags are not set on the command line
+type DefaultsConfig struct {
+	// Provider selects the scan provider: binary for the Snyk binary, image for the Snyk image, trivy or grype
+	// for the Trivy or Grype binaries, hub for the Docker Hub scans, empty to use the Snyk image on Linux when
+	// the Snyk binary is not installed
+	Provider string `json:"provider,omitempty"`
+	// Severity is the default of --severity
+	Severity string `json:"severity,omitempty"`
+	// Format is the default output format, json or one of the --format values
+	Format string `json:"format,omitempty"`
+	// ExcludeCVEs is the default of --exclude-cve
+	ExcludeCVEs []string `json:"excludeCVEs,omitempty"`
+	// NonRuntimePaths is the default of --non-runtime-path
+	NonRuntimePaths []string `json:"nonRuntimePaths,omitempty"`
+	// JSONFile is the default of --json-file
+	JSONFile string `json:"jsonFile,omitempty"`
+	// Timeout is the default of --timeout, as a duration like 10m
+	Timeout string `json:"timeout,omitempty"`
+	// Retries is the default of --retries, nil to keep the built-in default
+	Retries *int `json:"retries,omitempty"`
+	// RetryDelay is the default of --retry-delay, as a duration like 5s
+	RetryDelay string `json:"retryDelay,omitempty"`
+	// History is the default of --history
+	History bool `json:"history,omitempty"`
+	// Org is the default of --org
+	Org string `json:"org,omitempty"`
+	// ProjectName is the default of --project-name
+	ProjectName string `json:"projectName,omitempty"`
+	// GitHubSummary is the default of --github-summary
+	GitHubSummary bool `json:"githubSummary,omitempty"`
+}
+
+// JiraConfig points to the Jira project where issues are created for the findings
+type JiraConfig struct {
+	URL       string `json:"url"`
+	Project   string `json:"project"`
+	IssueType string `json:"issueType,omitempty"`
+	Username  string `json:"username"`
+}
+
+// LicensesConfig configures the license scanning of docker scan --licenses
+type LicensesConfig struct {
+	// Deny lists the licenses reported as license issues
+	Deny []string `json:"deny"`
+}
+
+// SMTPConfig points to the SMTP server used to send the scan reports by email
+type SMTPConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port,omitempty"`
+	Username string   `json:"username,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+const (
+	// FileEnv is the environment variable selecting the docker scan configuration file, which is never created
+	// nor removed by the plugin
+	FileEnv = "DOCKER_SCAN_CONFIG"
+	// ReadOnlyEnv is the environment variable disabling all the writes of the plugin to the configuration directory,
+	// for the read-only home directories of hardened CI containers
+	ReadOnlyEnv = "DOCKER_SCAN_READ_ONLY"
+)
+
+// ErrReadOnly is returned when saving the configuration in read-only mode
+var ErrReadOnly = fmt.Errorf("the docker scan configuration is read-only, %s is set", ReadOnlyEnv)
+
+// ReadOnly returns true if the writes to the configuration directory are disabled
+func ReadOnly() bool {
+	readOnly, _ := strconv.ParseBool(os.Getenv(ReadOnlyEnv))
+	return readOnly
+}
+
+// IsReadOnly returns true if the error is a write refused because of the read-only mode, of a read-only file system
+// or of the permissions of the file
+func IsReadOnly(err error) bool {
+	return errors.Is(err, ErrReadOnly) || errors.Is(err, syscall.EROFS) || os.IsPermission(errors.Cause(err))
+}
+
+// Path returns the docker scan configuration file, ${DOCKER_CONFIG}/scan/config.json unless DOCKER_SCAN_CONFIG is set
+func Path() string {
+	if path := os.Getenv(FileEnv); path != "" {
+		return path
+	}
+	return filepath.Join(cliConfig.Dir(), "scan", "config.json")
 }
 
 // ReadConfigFile tries to read docker-scan configuration file that
-// should be at ${DOCKER_CONFIG}/scan/config.json
+// should be at ${DOCKER_CONFIG}/scan/config.json, or at the path of DOCKER_SCAN_CONFIG
 func ReadConfigFile() (Config, error) {
 	var conf Config
-	path := filepath.Join(cliConfig.Dir(), "scan", "config.json")
-	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
-		_, err := os.Stat(path)
-		if err != nil && os.IsNotExist(err) {
-			err := SaveConfigFile(Config{})
-			if err != nil {
-				return conf, errors.Wrapf(err, "failed to create initial scan configuration file %q", path)
-			}
+	path := Path()
+	// the file selected with DOCKER_SCAN_CONFIG is managed by the user
+	managed := os.Getenv(FileEnv) == ""
+	// Docker Desktop creates the configuration file on Windows and macOS
+	creates := runtime.GOOS != "windows" && runtime.GOOS != "darwin"
+	if creates && managed {
+		if err := createConfigFile(path); err != nil {
+			return conf, err
 		}
 	}
 	buf, err := ioutil.ReadFile(path)
+	// a read-only configuration directory without configuration runs with the default configuration
+	if err != nil && os.IsNotExist(err) && managed && (creates || ReadOnly()) {
+		return conf, nil
+	}
 	if err != nil {
-		_ = os.Remove(path)
+		if managed && !ReadOnly() {
+			_ = os.Remove(path)
+		}
 		return conf, errors.Wrap(err, "failed to read docker scan configuration file. Please restart Docker Desktop")
 	}
 	if err := json.Unmarshal(buf, &conf); err != nil {
-		_ = os.Remove(path)
+		if managed && !ReadOnly() {
+			_ = os.Remove(path)
+		}
 		return conf, errors.Wrapf(err, "invalid docker scan configuration file %s. Please restart Docker Desktop", path)
 	}
 	return conf, nil
 }
 
+// createConfigFile creates an empty configuration file if there is none, unless the configuration is read-only
+func createConfigFile(path string) error {
+	if _, err := os.Stat(path); err == nil || !os.IsNotExist(err) {
+		return nil
+	}
+	if err := SaveConfigFile(Config{}); err != nil && !IsReadOnly(err) {
+		return errors.Wrapf(err, "failed to create initial scan configuration file %q", path)
+	}
+	return nil
+}
+
 // SaveConfigFile tries to save docker-scan configuration file that
-// should be at ${DOCKER_CONFIG}/scan/config.json
+// should be at ${DOCKER_CONFIG}/scan/config.json, or at the path of DOCKER_SCAN_CONFIG
 func SaveConfigFile(conf Config) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
 	out, err := json.Marshal(conf)
 	if err != nil {
 		return err
 	}
-	if err = os.MkdirAll(filepath.Join(cliConfig.Dir(), "scan"), 0744); err != nil {
+	path := Path()
+	if err = os.MkdirAll(filepath.Dir(path), 0744); err != nil {
 		return errors.Wrap(err, "failed to create docker scan configuration directory")
 	}
-
-	path := filepath.Join(cliConfig.Dir(), "scan", "config.json")
-	return errors.Wrap(ioutil.WriteFile(path, out, os.FileMode(0644)), "failed to write docker scan configuration file")
+	// concurrent invocations of the plugin read the previous or the new configuration, never a partial one
+	unlock, err := filelock.Lock(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to lock docker scan configuration file")
+	}
+	defer unlock()
+	return errors.Wrap(filelock.WriteFile(path, out, os.FileMode(0644)), "failed to write docker scan configuration file")
 }
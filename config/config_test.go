@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	cliConfig "github.com/docker/cli/cli/config"
 	dockerConfigFile "github.com/docker/cli/cli/config/configfile"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/env"
@@ -59,5 +60,60 @@ func TestSaveConfigFile(t *testing.T) {
 
 	result, err := ReadConfigFile()
 	assert.NilError(t, err)
-	assert.Equal(t, result, expected)
+	assert.DeepEqual(t, result, expected)
+}
+
+func TestReadConfigFileDefaults(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "config")
+	assert.NilError(t, err)
+	defer os.RemoveAll(configDir) //nolint:errcheck
+	// the Docker CLI configuration directory is read from DOCKER_CONFIG once at startup
+	defer cliConfig.SetDir(cliConfig.Dir())
+	cliConfig.SetDir(configDir)
+
+	assert.NilError(t, os.MkdirAll(filepath.Join(configDir, "scan"), 0744))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(configDir, "scan", "config.json"),
+		[]byte(`{"path":"/usr/bin/snyk","defaults":{"provider":"image","severity":"high","format":"json","excludeCVEs":["CVE-2021-3711"],"nonRuntimePaths":["/usr/share/doc/**"],"jsonFile":"results.json"},"budget":{"priorities":["myorg/*"]},"profiles":{"prod":{"org":"acme-prod","hubUsername":"acmebot"}}}`), 0644))
+
+	result, err := ReadConfigFile()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, Config{
+		Path: "/usr/bin/snyk",
+		Defaults: &DefaultsConfig{
+			Provider:        "image",
+			Severity:        "high",
+			Format:          "json",
+			ExcludeCVEs:     []string{"CVE-2021-3711"},
+			NonRuntimePaths: []string{"/usr/share/doc/**"},
+			JSONFile:        "results.json",
+		},
+		Budget:   &BudgetConfig{Priorities: []string{"myorg/*"}},
+		Profiles: map[string]ProfileConfig{"prod": {Org: "acme-prod", HubUsername: "acmebot"}},
+	})
+}
+
+func TestReadOnlyConfig(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "config")
+	assert.NilError(t, err)
+	defer os.RemoveAll(configDir) //nolint:errcheck
+	defer cliConfig.SetDir(cliConfig.Dir())
+	cliConfig.SetDir(configDir)
+	defer env.Patch(t, ReadOnlyEnv, "true")()
+
+	// no configuration file is created
+	result, err := ReadConfigFile()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, Config{})
+	_, err = os.Stat(filepath.Join(configDir, "scan"))
+	assert.Assert(t, os.IsNotExist(err))
+	err = SaveConfigFile(Config{Optin: true})
+	assert.Assert(t, IsReadOnly(err))
+
+	// the configuration is read from DOCKER_SCAN_CONFIG
+	path := filepath.Join(configDir, "ci-scan-config.json")
+	assert.NilError(t, ioutil.WriteFile(path, []byte(`{"optin":true,"path":"/usr/bin/snyk"}`), 0444))
+	defer env.Patch(t, FileEnv, path)()
+	result, err = ReadConfigFile()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, Config{Optin: true, Path: "/usr/bin/snyk"})
 }
@@ -66,7 +66,7 @@ func TestAuthenticationFlagFailsWithImage(t *testing.T) {
 
 	cmd.Command = dockerCli.Command("scan", "--accept-license", "--login", "--token", token, "example:image")
 	icmd.RunCmd(cmd).Assert(t, icmd.Expected{
-		ExitCode: 1,
+		ExitCode: 2,
 		Err:      "--login flag expects no argument",
 	})
 }
@@ -78,7 +78,7 @@ func TestAuthenticationChecksToken(t *testing.T) {
 
 	cmd.Command = dockerCli.Command("scan", "--accept-license", "--login", "--token", "invalid-token")
 	icmd.RunCmd(cmd).Assert(t, icmd.Expected{
-		ExitCode: 1,
+		ExitCode: 5,
 		Err:      `invalid authentication token "invalid-token"`,
 	})
 }
@@ -121,7 +121,7 @@ func TestAuthWithContainerSnykFlagFailsWithImage(t *testing.T) {
 
 	cmd.Command = dockerCli.Command("scan", "--accept-license", "--login", "--token", token, "example:image")
 	icmd.RunCmd(cmd).Assert(t, icmd.Expected{
-		ExitCode: 1,
+		ExitCode: 2,
 		Err:      "--login flag expects no argument",
 	})
 }
@@ -136,7 +136,7 @@ func TestAuthWithContainerSnykChecksToken(t *testing.T) {
 
 	cmd.Command = dockerCli.Command("scan", "--accept-license", "--login", "--token", "invalid-token")
 	icmd.RunCmd(cmd).Assert(t, icmd.Expected{
-		ExitCode: 1,
+		ExitCode: 5,
 		Err:      `invalid authentication token "invalid-token"`,
 	})
 }
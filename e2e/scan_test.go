@@ -59,7 +59,7 @@ func TestScanFailsNoAuthentication(t *testing.T) {
 
 	cmd.Command = dockerCli.Command("scan", "--accept-license", "example:image")
 	icmd.RunCmd(cmd).Assert(t, icmd.Expected{
-		ExitCode: 1,
+		ExitCode: 5,
 		Err: `You need to be logged in to Docker Hub to use scan feature.
 please login to Docker Hub using the Docker Login command`,
 	})
@@ -79,7 +79,7 @@ func TestScanFailsWithCleanMessage(t *testing.T) {
 
 	cmd.Command = dockerCli.Command("scan", "--accept-license", "example:image")
 	icmd.RunCmd(cmd).Assert(t, icmd.Expected{
-		ExitCode: 1,
+		ExitCode: 5,
 		Err: `You need to be logged in to Docker Hub to use scan feature.
 please login to Docker Hub using the Docker Login command`,
 	})
@@ -139,7 +139,7 @@ func TestScanWithSnyk(t *testing.T) {
 		/*{
 			name:     "invalid-docker-archive",
 			image:    InvalidImage,
-			exitCode: 1,
+			exitCode: 2,
 			contains: "(HTTP code 500) server error - empty export - not implemented",
 		},*/
 		{
@@ -151,7 +151,7 @@ func TestScanWithSnyk(t *testing.T) {
 		{
 			name:     "invalid-image-name",
 			image:    "scratch",
-			exitCode: 1,
+			exitCode: 2,
 			contains: "manifest unknown",
 		},
 	}
@@ -191,7 +191,7 @@ func TestScanJsonOutput(t *testing.T) {
 		{
 			name:     "invalid-docker-archive",
 			image:    InvalidImage,
-			exitCode: 1,
+			exitCode: 2,
 			isEmpty:  true,
 		},
 		{
@@ -247,7 +247,7 @@ func TestScanWithExcludeBaseImageVulns(t *testing.T) {
 
 	cmd.Command = dockerCli.Command("scan", "--accept-license", "--exclude-base", ImageBaseImageVulnerabilities)
 	icmd.RunCmd(cmd).Assert(t, icmd.Expected{
-		ExitCode: 1,
+		ExitCode: 2,
 		Err:      "--file flag is mandatory to use --exclude-base flag"})
 }
 
@@ -302,7 +302,7 @@ func TestScanWithSeverityBadValue(t *testing.T) {
 
 	cmd.Command = dockerCli.Command("scan", "--accept-license", "--severity=unsupportedValue", ImageWithVulnerabilities)
 	icmd.RunCmd(cmd).Assert(t, icmd.Expected{
-		ExitCode: 1,
+		ExitCode: 2,
 		Err:      "--severity takes only 'low', 'medium' or 'high' values"})
 }
 
@@ -340,7 +340,7 @@ func TestScanWithGroupIssues(t *testing.T) {
 
 	cmd.Command = dockerCli.Command("scan", "--accept-license", "--group-issues", ImageBaseImageVulnerabilities)
 	icmd.RunCmd(cmd).Assert(t, icmd.Expected{
-		ExitCode: 1,
+		ExitCode: 2,
 		Err:      "--json flag is mandatory to use --group-issues flag"})
 }
 
@@ -370,7 +370,7 @@ func TestScanWithContainerizedSnyk(t *testing.T) {
 		{
 			name:     "invalid-docker-archive",
 			image:    InvalidImage,
-			exitCode: 1,
+			exitCode: 2,
 			contains: "(HTTP code 500) server error - empty export - not implemented",
 		},
 		{
@@ -382,7 +382,7 @@ func TestScanWithContainerizedSnyk(t *testing.T) {
 		{
 			name:     "invalid-image-name",
 			image:    "scratch",
-			exitCode: 1,
+			exitCode: 2,
 			contains: "manifest unknown",
 		},
 	}
@@ -495,3 +495,155 @@ func createScanConfigFileOptinAndPath(t *testing.T, configDir string, optin bool
 	err = ioutil.WriteFile(filepath.Join(configDir, "scan", "config.json"), buf, 0644)
 	assert.NilError(t, err)
 }
+
+func TestScanExitCodeOverride(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Can't run on this ci platform (windows containers or no engine installed)")
+	}
+	_, cleanFunction := createSnykConfFile(t, os.Getenv("E2E_TEST_AUTH_TOKEN"))
+	defer cleanFunction()
+
+	cmd, configDir, cleanup := dockerCli.createTestCmd()
+	defer cleanup()
+	createScanConfigFile(t, configDir)
+
+	testCases := []struct {
+		name     string
+		flags    []string
+		image    string
+		exitCode int
+	}{
+		{
+			name:     "vulnerabilities-ignored",
+			flags:    []string{"--exit-code-on-vuln=0"},
+			image:    ImageWithVulnerabilities,
+			exitCode: 0,
+		},
+		{
+			name:     "vulnerabilities-custom-code",
+			flags:    []string{"--exit-code-on-vuln=42"},
+			image:    ImageWithVulnerabilities,
+			exitCode: 42,
+		},
+		{
+			name:     "error-custom-code",
+			flags:    []string{"--exit-code-on-error=3"},
+			image:    "scratch",
+			exitCode: 3,
+		},
+		{
+			name:     "invalid-exit-code",
+			flags:    []string{"--exit-code-on-error=0"},
+			image:    ImageWithVulnerabilities,
+			exitCode: 125,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			args := append([]string{"scan", "--accept-license"}, testCase.flags...)
+			cmd.Command = dockerCli.Command(append(args, testCase.image)...)
+			icmd.RunCmd(cmd).Assert(t, icmd.Expected{ExitCode: testCase.exitCode})
+		})
+	}
+}
+
+func TestScanGroupByLayer(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Can't run on this ci platform (windows containers or no engine installed)")
+	}
+	_, cleanFunction := createSnykConfFile(t, os.Getenv("E2E_TEST_AUTH_TOKEN"))
+	defer cleanFunction()
+
+	cmd, configDir, cleanup := dockerCli.createTestCmd()
+	defer cleanup()
+	createScanConfigFile(t, configDir)
+
+	cmd.Command = dockerCli.Command("scan", "--accept-license", "--group-by", "layer", "--file", "./testdata/Dockerfile", ImageBaseImageVulnerabilities)
+	output := icmd.RunCmd(cmd).Assert(t, icmd.Expected{ExitCode: 1}).Combined()
+	assert.Assert(t, strings.Contains(output, "Base image alpine:3.10.0"), output)
+	assert.Assert(t, strings.Contains(output, "from the base image"), output)
+
+	cmd.Command = dockerCli.Command("scan", "--accept-license", "--group-by", "package", ImageBaseImageVulnerabilities)
+	icmd.RunCmd(cmd).Assert(t, icmd.Expected{
+		ExitCode: 2,
+		Err:      `--group-by takes only "layer" value`,
+	})
+}
+
+func TestScanRecommend(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Can't run on this ci platform (windows containers or no engine installed)")
+	}
+	_, cleanFunction := createSnykConfFile(t, os.Getenv("E2E_TEST_AUTH_TOKEN"))
+	defer cleanFunction()
+
+	cmd, configDir, cleanup := dockerCli.createTestCmd()
+	defer cleanup()
+	createScanConfigFile(t, configDir)
+
+	cmd.Command = dockerCli.Command("scan", "recommend", "--json", "--file", "./testdata/Dockerfile", ImageBaseImageVulnerabilities)
+	output := icmd.RunCmd(cmd).Assert(t, icmd.Success).Stdout()
+	var recommendations struct {
+		BaseImage       string        `json:"baseImage"`
+		Recommendations []interface{} `json:"recommendations"`
+	}
+	assert.NilError(t, json.Unmarshal([]byte(output), &recommendations), output)
+	assert.Equal(t, recommendations.BaseImage, "alpine:3.10.0")
+}
+
+func TestScanScopes(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Can't run on this ci platform (windows containers or no engine installed)")
+	}
+	_, cleanFunction := createSnykConfFile(t, os.Getenv("E2E_TEST_AUTH_TOKEN"))
+	defer cleanFunction()
+
+	cmd, configDir, cleanup := dockerCli.createTestCmd()
+	defer cleanup()
+	createScanConfigFile(t, configDir)
+
+	cmd.Command = dockerCli.Command("pull", ImageWithVulnerabilities)
+	icmd.RunCmd(cmd).Assert(t, icmd.Success)
+
+	// only the configuration is checked, the image runs as root
+	cmd.Command = dockerCli.Command("scan", "--accept-license", "--scope", "config", ImageWithVulnerabilities)
+	output := icmd.RunCmd(cmd).Assert(t, icmd.Expected{ExitCode: 1}).Combined()
+	assert.Assert(t, strings.Contains(output, "DOCKER-CONFIG-ROOT-USER"), output)
+	assert.Assert(t, !strings.Contains(output, "vulnerability found"), output)
+
+	cmd.Command = dockerCli.Command("scan", "--accept-license", "--scope", "unknown", ImageWithVulnerabilities)
+	icmd.RunCmd(cmd).Assert(t, icmd.Expected{
+		ExitCode: 2,
+		Err:      "--scope takes only os, app, config, secrets, licenses values",
+	})
+}
+
+func TestScanQuietAndSummary(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Can't run on this ci platform (windows containers or no engine installed)")
+	}
+	_, cleanFunction := createSnykConfFile(t, os.Getenv("E2E_TEST_AUTH_TOKEN"))
+	defer cleanFunction()
+
+	cmd, configDir, cleanup := dockerCli.createTestCmd()
+	defer cleanup()
+	createScanConfigFile(t, configDir)
+
+	cmd.Command = dockerCli.Command("pull", ImageWithVulnerabilities)
+	icmd.RunCmd(cmd).Assert(t, icmd.Success)
+
+	cmd.Command = dockerCli.Command("scan", "--accept-license", "--scope", "config", "--quiet", ImageWithVulnerabilities)
+	output := icmd.RunCmd(cmd).Assert(t, icmd.Expected{ExitCode: 1}).Stdout()
+	assert.Assert(t, strings.Contains(output, " findings (critical: "), output)
+	assert.Assert(t, !strings.Contains(output, "DOCKER-CONFIG-ROOT-USER"), output)
+
+	cmd.Command = dockerCli.Command("scan", "--accept-license", "--scope", "config", "--summary", ImageWithVulnerabilities)
+	output = icmd.RunCmd(cmd).Assert(t, icmd.Expected{ExitCode: 1}).Stdout()
+	assert.Assert(t, strings.Contains(output, "DOCKER-CONFIG-ROOT-USER"), output)
+
+	cmd.Command = dockerCli.Command("scan", "--accept-license", "--quiet", "--summary", ImageWithVulnerabilities)
+	icmd.RunCmd(cmd).Assert(t, icmd.Expected{
+		ExitCode: 2,
+		Err:      "--quiet and --summary flags can't be used together",
+	})
+}
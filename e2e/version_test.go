@@ -112,7 +112,7 @@ Provider:   %s
 		})
 	} else {
 		output := res.Assert(t, icmd.Expected{
-			ExitCode: 1,
+			ExitCode: 2,
 		}).Combined()
 		expected := "failed to read docker scan configuration file. Please restart Docker Desktop"
 		assert.Assert(t, is.Contains(output, expected))
@@ -0,0 +1,125 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/filelock"
+	"github.com/docker/scan-cli-plugin/internal/history"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// Extension is the extension of the reports compressed with zstd, like report.json.zst
+	Extension = ".zst"
+	// IndexFile lists the reports archived in a directory, one JSON line per report
+	IndexFile = "index.jsonl"
+
+	// maxReportSize bounds the memory used to decompress a report
+	maxReportSize = 1 << 30
+)
+
+// zstdMagic starts the zstd frames, the compressed reports are recognized by their content whatever their name
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// IsArchive returns true if the report is written compressed to the path
+func IsArchive(path string) bool {
+	return strings.HasSuffix(path, Extension)
+}
+
+// WriteReport writes a JSON report to the path. When the path ends with .zst, the report is compressed with zstd and
+// recorded in the index of its directory, with the counts of the entry and the name of the report file. A report
+// written again to the same path replaces its entry in the index.
+func WriteReport(path string, document []byte, entry history.Entry) error {
+	if !IsArchive(path) {
+		return filelock.WriteFile(path, document, 0644)
+	}
+	compressed, err := Compress(document)
+	if err != nil {
+		return err
+	}
+	// the index is locked while the report is written, so that the report and its entry stay consistent when
+	// concurrent scans archive to the same path
+	index := filepath.Join(filepath.Dir(path), IndexFile)
+	unlock, err := filelock.Lock(index)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := filelock.WriteFile(path, compressed, 0644); err != nil {
+		return err
+	}
+	entry.Report = filepath.Base(path)
+	return updateIndex(index, entry)
+}
+
+// Compress compresses a report with zstd
+func Compress(document []byte) ([]byte, error) {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	defer encoder.Close() //nolint: errcheck
+	return encoder.EncodeAll(document, nil), nil
+}
+
+// ReadFile reads a report, decompressed if it was compressed with zstd
+func ReadFile(path string) ([]byte, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil || !bytes.HasPrefix(buf, zstdMagic) {
+		return buf, err
+	}
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxReportSize))
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+	return decoder.DecodeAll(buf, nil)
+}
+
+// ReadIndex returns the reports archived in the directory, from the oldest to the most recent one
+func ReadIndex(dir string) ([]history.Entry, error) {
+	return history.Read(filepath.Join(dir, IndexFile))
+}
+
+// updateIndex adds an entry at the end of the index, dropping the previous entry of the same report. The index must be
+// locked by the caller.
+func updateIndex(path string, entry history.Entry) error {
+	entries, err := history.Read(path)
+	if err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(nil)
+	encoder := json.NewEncoder(buf)
+	for _, e := range entries {
+		if e.Report == entry.Report {
+			continue
+		}
+		if err := encoder.Encode(e); err != nil {
+			return err
+		}
+	}
+	if err := encoder.Encode(entry); err != nil {
+		return err
+	}
+	return filelock.WriteFile(path, buf.Bytes(), 0644)
+}
@@ -0,0 +1,89 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package archive
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/history"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestWriteReport(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	document := []byte(`{"path":"alpine:3.13","vulnerabilities":[` + string(bytes.Repeat([]byte(`{"id":"SNYK-1"},`), 1000)) + `{"id":"SNYK-2"}]}`)
+	scanned := time.Date(2021, time.March, 15, 9, 0, 0, 0, time.UTC)
+
+	assert.NilError(t, WriteReport(dir.Join("alpine.json.zst"), document,
+		history.Entry{Image: "alpine:3.13", Time: scanned, Counts: map[string]int{"high": 2}, Total: 2}))
+	compressed, err := ioutil.ReadFile(dir.Join("alpine.json.zst"))
+	assert.NilError(t, err)
+	assert.Assert(t, bytes.HasPrefix(compressed, zstdMagic))
+	assert.Assert(t, len(compressed) < len(document)/10, "%d bytes compressed to %d", len(document), len(compressed))
+	read, err := ReadFile(dir.Join("alpine.json.zst"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, read, document)
+
+	entries, err := ReadIndex(dir.Path())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, entries, []history.Entry{
+		{Image: "alpine:3.13", Time: scanned, Counts: map[string]int{"high": 2}, Total: 2, Report: "alpine.json.zst"},
+	})
+
+	assert.NilError(t, WriteReport(dir.Join("alpine.json"), document, history.Entry{Image: "alpine:3.13"}))
+	read, err = ReadFile(dir.Join("alpine.json"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, read, document)
+	entries, err = ReadIndex(dir.Path())
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 1)
+}
+
+func TestWriteReportReplacesIndexEntry(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	document := []byte(`{"path":"alpine:3.13","vulnerabilities":[]}`)
+	first := time.Date(2021, time.March, 15, 9, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	assert.NilError(t, WriteReport(dir.Join("alpine.json.zst"), document, history.Entry{Image: "alpine:3.13", Time: first, Total: 2}))
+	assert.NilError(t, WriteReport(dir.Join("nginx.json.zst"), document, history.Entry{Image: "nginx", Time: first}))
+	assert.NilError(t, WriteReport(dir.Join("alpine.json.zst"), document, history.Entry{Image: "alpine:3.13", Time: second, Total: 1}))
+
+	entries, err := ReadIndex(dir.Path())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, entries, []history.Entry{
+		{Image: "nginx", Time: first, Report: "nginx.json.zst"},
+		{Image: "alpine:3.13", Time: second, Total: 1, Report: "alpine.json.zst"},
+	})
+}
+
+func TestReadFileRecognizesCompressedReports(t *testing.T) {
+	compressed, err := Compress([]byte(`{"ok":true}`))
+	assert.NilError(t, err)
+	dir := fs.NewDir(t, t.Name(), fs.WithFile("report.json", "", fs.WithBytes(compressed)))
+	defer dir.Remove()
+
+	read, err := ReadFile(dir.Join("report.json"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(read), `{"ok":true}`)
+}
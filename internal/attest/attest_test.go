@@ -0,0 +1,157 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package attest
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/internal/collector"
+	"github.com/docker/scan-cli-plugin/internal/oci"
+	"gotest.tools/v3/assert"
+)
+
+const imageDigest = "sha256:6d1ef012b5674ad8a127ecfa9b5e6f5178d171b90ee462846974177fd9bdd39f"
+
+func password(value string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return []byte(value), nil
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	key, err := LoadPrivateKey("testdata/cosign.key", password("password"))
+	assert.NilError(t, err)
+	public, err := LoadPublicKey("testdata/cosign.pub")
+	assert.NilError(t, err)
+	assert.Assert(t, key.PublicKey.Equal(public))
+
+	_, err = LoadPrivateKey("testdata/cosign.key", password("wrong"))
+	assert.ErrorContains(t, err, "wrong password")
+	_, err = LoadPublicKey("testdata/cosign.key")
+	assert.ErrorContains(t, err, "no PUBLIC KEY PEM block found")
+}
+
+func TestSignAndVerify(t *testing.T) {
+	key, err := LoadPrivateKey("testdata/cosign.key", password("password"))
+	assert.NilError(t, err)
+	scannedAt := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	statement, err := NewStatement("docker.io/library/app", imageDigest, collector.Result{
+		Image:         "app:1.0",
+		ScannedAt:     scannedAt,
+		PluginVersion: "v0.9.0",
+		Severities:    map[string]int{"high": 1},
+	}, scannedAt.Add(-time.Minute))
+	assert.NilError(t, err)
+	payload, err := json.Marshal(statement)
+	assert.NilError(t, err)
+	envelope, err := Sign(key, payload)
+	assert.NilError(t, err)
+
+	public, err := LoadPublicKey("testdata/cosign.pub")
+	assert.NilError(t, err)
+	verified := Verify([]Envelope{envelope}, public, imageDigest)
+	assert.Equal(t, len(verified), 1)
+	assert.Equal(t, verified[0].Subject[0].Digest["sha256"], strings.TrimPrefix(imageDigest, "sha256:"))
+	assert.Equal(t, verified[0].Predicate.Scanner.Result.Severities["high"], 1)
+
+	other, err := LoadPublicKey("testdata/other.pub")
+	assert.NilError(t, err)
+	assert.Equal(t, len(Verify([]Envelope{envelope}, other, imageDigest)), 0)
+	assert.Equal(t, len(Verify([]Envelope{envelope}, public, "sha256:0000")), 0)
+
+	tampered := envelope
+	tampered.Payload = envelope.Payload[:len(envelope.Payload)-4] + "AAAA"
+	assert.Equal(t, len(Verify([]Envelope{tampered}, public, imageDigest)), 0)
+}
+
+// fakeRegistry stores the blobs and manifests pushed to the app repository
+type fakeRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := ioutil.ReadAll(r.Body)
+	path := strings.TrimPrefix(r.URL.Path, "/v2/app")
+	switch {
+	case r.Method == http.MethodPost && path == "/blobs/uploads/":
+		w.Header().Set("Location", "/v2/app/blobs/uploads/1")
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut && path == "/blobs/uploads/1":
+		f.blobs[r.URL.Query().Get("digest")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "/manifests/"):
+		f.manifests[strings.TrimPrefix(path, "/manifests/")] = body
+		w.WriteHeader(http.StatusCreated)
+	default:
+		content, ok := f.blobs[strings.TrimPrefix(path, "/blobs/")]
+		if strings.HasPrefix(path, "/manifests/") {
+			content, ok = f.manifests[strings.TrimPrefix(path, "/manifests/")]
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(content) //nolint:errcheck
+	}
+}
+
+func TestAttachAndFetch(t *testing.T) {
+	registry := &fakeRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(server.URL, "http://") + "/app:1.0")
+	assert.NilError(t, err)
+	ctx := context.Background()
+	repository := oci.NewRepository(http.DefaultClient, named, "", "")
+
+	envelopes, err := Attestations(ctx, repository, imageDigest)
+	assert.NilError(t, err)
+	assert.Equal(t, len(envelopes), 0)
+
+	first := Envelope{PayloadType: PayloadType, Payload: "Zmlyc3Q=", Signatures: []Signature{{Sig: "c2ln"}}}
+	second := Envelope{PayloadType: PayloadType, Payload: "c2Vjb25k", Signatures: []Signature{{Sig: "c2ln"}}}
+	assert.NilError(t, Attach(ctx, repository, imageDigest, first))
+	assert.NilError(t, Attach(ctx, repository, imageDigest, second))
+
+	var m oci.Manifest
+	assert.NilError(t, json.Unmarshal(registry.manifests[AttestationTag(imageDigest)], &m))
+	assert.Equal(t, m.MediaType, oci.ManifestMediaType)
+	assert.Equal(t, len(m.Layers), 2)
+	assert.Equal(t, m.Layers[0].MediaType, EnvelopeMediaType)
+	assert.Equal(t, m.Layers[0].Annotations[predicateTypeAnnotation], PredicateType)
+
+	envelopes, err = Attestations(ctx, repository, imageDigest)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, envelopes, []Envelope{first, second})
+}
+
+func TestAttestationTag(t *testing.T) {
+	assert.Equal(t, AttestationTag(imageDigest), "sha256-6d1ef012b5674ad8a127ecfa9b5e6f5178d171b90ee462846974177fd9bdd39f.att")
+}
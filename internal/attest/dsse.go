@@ -0,0 +1,85 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package attest
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// PayloadType is the type of the in-toto statements signed in the DSSE envelopes
+const PayloadType = "application/vnd.in-toto+json"
+
+// Envelope is a DSSE envelope, holding a signed payload
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a signature of the payload of an envelope
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// Sign wraps the in-toto statement in an envelope signed with the key
+func Sign(key *ecdsa.PrivateKey, payload []byte) (Envelope, error) {
+	return SignPayload(key, PayloadType, payload)
+}
+
+// SignPayload wraps the payload of the type in an envelope signed with the key
+func SignPayload(key *ecdsa.PrivateKey, payloadType string, payload []byte) (Envelope, error) {
+	hash := sha256.Sum256(pae(payloadType, payload))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{
+		PayloadType: payloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []Signature{{Sig: base64.StdEncoding.EncodeToString(sig)}},
+	}, nil
+}
+
+// Verify returns the payload of the envelope if one of its signatures is made with the key
+func (e Envelope) Verify(key *ecdsa.PublicKey) ([]byte, error) {
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %s", err)
+	}
+	hash := sha256.Sum256(pae(e.PayloadType, payload))
+	for _, signature := range e.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(signature.Sig)
+		if err != nil {
+			continue
+		}
+		if ecdsa.VerifyASN1(key, hash[:], sig) {
+			return payload, nil
+		}
+	}
+	return nil, fmt.Errorf("no signature matches the key")
+}
+
+// pae is the pre-authentication encoding of the payload, which is signed instead of the payload itself
+func pae(payloadType string, payload []byte) []byte {
+	header := fmt.Sprintf("DSSEv1 %d %s %d ", len(payloadType), payloadType, len(payload))
+	return append([]byte(header), payload...)
+}
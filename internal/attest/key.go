@@ -0,0 +1,151 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package attest
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// PEM types of the private keys generated by cosign generate-key-pair
+const (
+	cosignPrivateKeyType   = "ENCRYPTED COSIGN PRIVATE KEY"
+	sigstorePrivateKeyType = "ENCRYPTED SIGSTORE PRIVATE KEY"
+)
+
+// encryptedKey is the PEM content of a cosign private key, encrypted with a key derived from its password
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// LoadPrivateKey reads an ECDSA private key, generated by cosign or in PKCS8 or SEC1 PEM format. The password is
+// only asked for the encrypted cosign keys.
+func LoadPrivateKey(path string, password func() ([]byte, error)) (*ecdsa.PrivateKey, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the private key: %s", err)
+	}
+	block, _ := pem.Decode(buf)
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key %s: no PEM block found", path)
+	}
+	key, err := parsePrivateKey(block, password)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key %s: %s", path, err)
+	}
+	return key, nil
+}
+
+func parsePrivateKey(block *pem.Block, password func() ([]byte, error)) (*ecdsa.PrivateKey, error) {
+	switch block.Type {
+	case cosignPrivateKeyType, sigstorePrivateKeyType:
+		pass, err := password()
+		if err != nil {
+			return nil, err
+		}
+		der, err := decryptKey(block.Bytes, pass)
+		if err != nil {
+			return nil, err
+		}
+		return parsePKCS8(der)
+	case "PRIVATE KEY":
+		return parsePKCS8(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("unsupported PEM type %q", block.Type)
+}
+
+func parsePKCS8(der []byte) (*ecdsa.PrivateKey, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("only ECDSA keys are supported")
+	}
+	return ecdsaKey, nil
+}
+
+func decryptKey(content, password []byte) ([]byte, error) {
+	var encrypted encryptedKey
+	if err := json.Unmarshal(content, &encrypted); err != nil {
+		return nil, err
+	}
+	if encrypted.KDF.Name != "scrypt" || encrypted.Cipher.Name != "nacl/secretbox" {
+		return nil, fmt.Errorf("unsupported encryption %s with %s", encrypted.Cipher.Name, encrypted.KDF.Name)
+	}
+	if len(encrypted.Cipher.Nonce) != 24 {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+	params := encrypted.KDF.Params
+	derived, err := scrypt.Key(password, encrypted.KDF.Salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	var secret [32]byte
+	var nonce [24]byte
+	copy(secret[:], derived)
+	copy(nonce[:], encrypted.Cipher.Nonce)
+	der, ok := secretbox.Open(nil, encrypted.Ciphertext, &nonce, &secret)
+	if !ok {
+		return nil, fmt.Errorf("wrong password")
+	}
+	return der, nil
+}
+
+// LoadPublicKey reads an ECDSA public key in PEM format, like the cosign.pub key generated by cosign
+func LoadPublicKey(path string) (*ecdsa.PublicKey, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the public key: %s", err)
+	}
+	block, _ := pem.Decode(buf)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("invalid public key %s: no PUBLIC KEY PEM block found", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %s: %s", path, err)
+	}
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid public key %s: only ECDSA keys are supported", path)
+	}
+	return ecdsaKey, nil
+}
@@ -0,0 +1,111 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package attest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/oci"
+)
+
+const (
+	configMediaType = "application/vnd.oci.image.config.v1+json"
+	// EnvelopeMediaType is the media type of the layers holding the DSSE envelopes of the attestations
+	EnvelopeMediaType = "application/vnd.dsse.envelope.v1+json"
+	// signatureAnnotation marks the layers holding signatures, the signature being in the envelope for attestations
+	signatureAnnotation     = "dev.cosignproject.cosign/signature"
+	predicateTypeAnnotation = "predicateType"
+)
+
+// imageConfig is the config of the attestations image, listing the envelopes as its layers
+type imageConfig struct {
+	Architecture string   `json:"architecture"`
+	OS           string   `json:"os"`
+	Config       struct{} `json:"config"`
+	RootFS       struct {
+		Type    string   `json:"type"`
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+// AttestationTag returns the tag of the attestations of the image with the digest, like cosign
+func AttestationTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".att"
+}
+
+// Attach adds the envelope to the attestations of the image with the digest, stored like cosign does in an image
+// tagged after the digest of the attested image
+func Attach(ctx context.Context, repository *oci.Repository, digest string, envelope Envelope) error {
+	tag := AttestationTag(digest)
+	attestations, err := repository.Manifest(ctx, tag)
+	if err != nil {
+		return err
+	}
+	if attestations == nil {
+		attestations = &oci.Manifest{SchemaVersion: 2, MediaType: oci.ManifestMediaType}
+	}
+	buf, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	layer, err := repository.PushBlob(ctx, EnvelopeMediaType, buf)
+	if err != nil {
+		return err
+	}
+	layer.Annotations = map[string]string{signatureAnnotation: "", predicateTypeAnnotation: PredicateType}
+	attestations.Layers = append(attestations.Layers, layer)
+	config := imageConfig{}
+	config.RootFS.Type = "layers"
+	for _, layer := range attestations.Layers {
+		config.RootFS.DiffIDs = append(config.RootFS.DiffIDs, layer.Digest)
+	}
+	buf, err = json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if attestations.Config, err = repository.PushBlob(ctx, configMediaType, buf); err != nil {
+		return err
+	}
+	return repository.PutManifest(ctx, tag, *attestations)
+}
+
+// Attestations returns the envelopes attached to the image with the digest
+func Attestations(ctx context.Context, repository *oci.Repository, digest string) ([]Envelope, error) {
+	attestations, err := repository.Manifest(ctx, AttestationTag(digest))
+	if err != nil || attestations == nil {
+		return nil, err
+	}
+	var envelopes []Envelope
+	for _, layer := range attestations.Layers {
+		if layer.MediaType != EnvelopeMediaType {
+			continue
+		}
+		buf, err := repository.Blob(ctx, layer.Digest)
+		if err != nil {
+			return nil, err
+		}
+		var envelope Envelope
+		if err := json.Unmarshal(buf, &envelope); err != nil {
+			return nil, fmt.Errorf("invalid attestation %s: %s", layer.Digest, err)
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	return envelopes, nil
+}
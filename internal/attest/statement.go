@@ -0,0 +1,132 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package attest
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/collector"
+)
+
+const (
+	// StatementType is the type of the in-toto statements
+	StatementType = "https://in-toto.io/Statement/v0.1"
+	// PredicateType is the type of the vulnerability scan predicates, as defined by cosign
+	PredicateType = "https://cosign.sigstore.dev/attestation/vuln/v1"
+	// ScannerURI identifies the plugin as the scanner of the predicates
+	ScannerURI = "https://github.com/docker/scan-cli-plugin"
+)
+
+// Statement is an in-toto statement attesting the scan results of an image
+type Statement struct {
+	Type          string    `json:"_type"`
+	PredicateType string    `json:"predicateType"`
+	Subject       []Subject `json:"subject"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// Subject is the image the statement is about, identified by its repository and its manifest digest
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Predicate holds the scan results of the image, in the cosign vulnerability attestation format
+type Predicate struct {
+	Scanner  Scanner  `json:"scanner"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// Scanner describes the scanner and its normalized results
+type Scanner struct {
+	URI     string           `json:"uri"`
+	Version string           `json:"version"`
+	Result  collector.Result `json:"result"`
+}
+
+// Metadata holds when the scan ran
+type Metadata struct {
+	ScanStartedOn  time.Time `json:"scanStartedOn"`
+	ScanFinishedOn time.Time `json:"scanFinishedOn"`
+}
+
+// NewStatement returns the statement attesting the result of the scan of the image with the repository and digest
+func NewStatement(repository, digest string, result collector.Result, started time.Time) (Statement, error) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return Statement{}, fmt.Errorf("invalid image digest %q", digest)
+	}
+	return Statement{
+		Type:          StatementType,
+		PredicateType: PredicateType,
+		Subject:       []Subject{{Name: repository, Digest: map[string]string{parts[0]: parts[1]}}},
+		Predicate: Predicate{
+			Scanner: Scanner{
+				URI:     ScannerURI,
+				Version: result.PluginVersion,
+				Result:  result,
+			},
+			Metadata: Metadata{
+				ScanStartedOn:  started.UTC(),
+				ScanFinishedOn: result.ScannedAt.UTC(),
+			},
+		},
+	}, nil
+}
+
+// Matches returns true if the statement is a scan result of the image with the digest
+func (s Statement) Matches(digest string) bool {
+	if s.Type != StatementType || s.PredicateType != PredicateType {
+		return false
+	}
+	parts := strings.SplitN(digest, ":", 2)
+	for _, subject := range s.Subject {
+		if len(parts) == 2 && subject.Digest[parts[0]] == parts[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify returns the statements about the image with the digest in the envelopes signed with the key, the most
+// recent scan first
+func Verify(envelopes []Envelope, key *ecdsa.PublicKey, digest string) []Statement {
+	var statements []Statement
+	for _, envelope := range envelopes {
+		if envelope.PayloadType != PayloadType {
+			continue
+		}
+		payload, err := envelope.Verify(key)
+		if err != nil {
+			continue
+		}
+		var statement Statement
+		if err := json.Unmarshal(payload, &statement); err != nil || !statement.Matches(digest) {
+			continue
+		}
+		statements = append(statements, statement)
+	}
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statements[i].Predicate.Metadata.ScanFinishedOn.After(statements[j].Predicate.Metadata.ScanFinishedOn)
+	})
+	return statements
+}
@@ -22,12 +22,15 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"time"
 
 	cliConfig "github.com/docker/cli/cli/config"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/filelock"
 	"github.com/docker/scan-cli-plugin/internal/hub"
 	"gopkg.in/square/go-jose.v2"
 	"gopkg.in/square/go-jose.v2/jwt"
@@ -46,10 +49,10 @@ type Authenticator struct {
 }
 
 //NewAuthenticator returns an Authenticator
-// configured to run against Docker Hub prod or staging
-func NewAuthenticator(jwks jose.JSONWebKeySet, apiHubBaseURL string) *Authenticator {
+// configured to run against Docker Hub prod or staging with the given HTTP client
+func NewAuthenticator(jwks jose.JSONWebKeySet, apiHubBaseURL string, client *http.Client) *Authenticator {
 	return &Authenticator{
-		hub:        hub.Client{Domain: apiHubBaseURL},
+		hub:        hub.Client{Domain: apiHubBaseURL, HTTPClient: client, CacheDir: hub.DefaultCacheDir()},
 		tokensPath: filepath.Join(cliConfig.Dir(), "scan", "tokens.json"),
 		jwks:       jwks,
 	}
@@ -71,13 +74,48 @@ func (a *Authenticator) GetToken(hubAuthConfig types.AuthConfig) (string, error)
 	if err != nil {
 		return "", err
 	}
-	// Persist token on local storage
-	if err := a.updateLocalToken(hubAuthConfig, token); err != nil {
+	// Persist token on local storage, a read-only storage negotiates the token on each run
+	if config.ReadOnly() {
+		return token, nil
+	}
+	if err := a.updateLocalToken(hubAuthConfig, token); err != nil && !config.IsReadOnly(err) {
 		return "", err
 	}
 	return token, nil
 }
 
+//RemoveLocalToken deletes the DockerScanID stored locally for the given Docker Hub user
+func (a *Authenticator) RemoveLocalToken(hubAuthConfig types.AuthConfig) error {
+	if _, err := os.Stat(a.tokensPath); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	unlock, err := filelock.Lock(a.tokensPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	buf, err := ioutil.ReadFile(a.tokensPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	tokens := map[string]string{}
+	if err := json.Unmarshal(buf, &tokens); err != nil {
+		// the tokens of the other users are kept, the file is left for the user to fix
+		return fmt.Errorf("invalid tokens file %s: %s", a.tokensPath, err)
+	}
+	if _, ok := tokens[hubAuthConfig.Username]; !ok {
+		return nil
+	}
+	delete(tokens, hubAuthConfig.Username)
+	if buf, err = json.Marshal(tokens); err != nil {
+		return err
+	}
+	return filelock.WriteFile(a.tokensPath, buf, 0644)
+}
+
 func (a *Authenticator) getLocalToken(hubAuthConfig types.AuthConfig) string {
 	buf, err := ioutil.ReadFile(a.tokensPath)
 	if errors.Is(err, os.ErrNotExist) {
@@ -141,6 +179,11 @@ func (a *Authenticator) negotiateScanIDToken(hubAuthConfig types.AuthConfig) (st
 }
 
 func (a *Authenticator) updateLocalToken(hubAuthConfig types.AuthConfig, token string) error {
+	unlock, err := filelock.Lock(a.tokensPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	stats, err := os.Stat(a.tokensPath)
 	mode := os.FileMode(0644)
 	if err != nil {
@@ -162,5 +205,5 @@ func (a *Authenticator) updateLocalToken(hubAuthConfig types.AuthConfig, token s
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(a.tokensPath, buf, mode)
+	return filelock.WriteFile(a.tokensPath, buf, mode)
 }
@@ -62,7 +62,7 @@ func TestHubAuthenticateNegociatesToken(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	authenticator := NewAuthenticator(jose.JSONWebKeySet{}, ts.URL)
+	authenticator := NewAuthenticator(jose.JSONWebKeySet{}, ts.URL, http.DefaultClient)
 	token, err := authenticator.negotiateScanIDToken(authConfig)
 	assert.NilError(t, err)
 	assert.Equal(t, token, "XXXX.YYYY.ZZZZ")
@@ -108,7 +108,7 @@ func TestHubAuthenticateChecksTokenValidity(t *testing.T) {
 			}
 			defer dir.Remove()
 
-			authenticator := NewAuthenticator(jose.JSONWebKeySet{}, "")
+			authenticator := NewAuthenticator(jose.JSONWebKeySet{}, "", http.DefaultClient)
 			authenticator.tokensPath = dir.Join("tokens.json")
 
 			authConfig := types.AuthConfig{Username: "hubUser2"}
@@ -151,7 +151,7 @@ func TestUpdateLocalToken(t *testing.T) {
 			}
 			defer dir.Remove()
 
-			authenticator := NewAuthenticator(jose.JSONWebKeySet{}, "")
+			authenticator := NewAuthenticator(jose.JSONWebKeySet{}, "", http.DefaultClient)
 			authenticator.tokensPath = dir.Join("tokens.json")
 
 			authConfig := types.AuthConfig{Username: "hubUser1"}
@@ -165,6 +165,34 @@ func TestUpdateLocalToken(t *testing.T) {
 	}
 }
 
+func TestRemoveLocalToken(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithFile("tokens.json", `{"hubUser1":"XXXX.YYYY.ZZZZ","hubUser2":"AAAA.BBBB.CCCC"}`))
+	defer dir.Remove()
+
+	authenticator := NewAuthenticator(jose.JSONWebKeySet{}, "", http.DefaultClient)
+	authenticator.tokensPath = dir.Join("tokens.json")
+
+	err := authenticator.RemoveLocalToken(types.AuthConfig{Username: "hubUser1"})
+	assert.NilError(t, err)
+	actual, err := ioutil.ReadFile(dir.Join("tokens.json"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(actual), `{"hubUser2":"AAAA.BBBB.CCCC"}`)
+}
+
+func TestRemoveLocalTokenKeepsInvalidFile(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithFile("tokens.json", `{"hubUser1":"XXXX.YYYY.ZZZZ",`))
+	defer dir.Remove()
+
+	authenticator := NewAuthenticator(jose.JSONWebKeySet{}, "", http.DefaultClient)
+	authenticator.tokensPath = dir.Join("tokens.json")
+
+	err := authenticator.RemoveLocalToken(types.AuthConfig{Username: "hubUser1"})
+	assert.ErrorContains(t, err, "invalid tokens file")
+	actual, err := ioutil.ReadFile(dir.Join("tokens.json"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(actual), `{"hubUser1":"XXXX.YYYY.ZZZZ",`)
+}
+
 func TestCheckTokenValidity(t *testing.T) {
 	// Generate JWKS file containing the public key
 	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
@@ -239,7 +267,7 @@ func TestCheckTokenValidity(t *testing.T) {
 	}
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			authenticator := NewAuthenticator(jwks, "")
+			authenticator := NewAuthenticator(jwks, "", http.DefaultClient)
 			err := authenticator.checkTokenValidity(testCase.generateToken())
 			if testCase.expectedError == "" {
 				assert.NilError(t, err)
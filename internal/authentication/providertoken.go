@@ -0,0 +1,149 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package authentication
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/cli/config/credentials"
+	"github.com/docker/cli/cli/config/types"
+	helperCredentials "github.com/docker/docker-credential-helpers/credentials"
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	// ProviderServerAddress is the key used to store the provider token in the Docker credentials store
+	ProviderServerAddress = "https://snyk.io"
+	// hubProfilesAddress is the prefix of the keys used to store the Docker Hub credentials of the profiles
+	hubProfilesAddress = "https://hub.docker.com/scan-profiles/"
+)
+
+// ProviderTokenStore stores the scan provider token using the Docker credential helpers
+// instead of the provider plaintext configuration file
+type ProviderTokenStore struct {
+	store           credentials.Store
+	serverAddress   string
+	configstorePath string
+}
+
+// NewProviderTokenStore returns a ProviderTokenStore backed by the given Docker credentials store
+func NewProviderTokenStore(store credentials.Store) *ProviderTokenStore {
+	return &ProviderTokenStore{
+		store:           store,
+		serverAddress:   ProviderServerAddress,
+		configstorePath: SnykConfigstorePath(),
+	}
+}
+
+// NewProfileTokenStore returns a ProviderTokenStore storing the provider token of a named profile
+func NewProfileTokenStore(store credentials.Store, profile string) *ProviderTokenStore {
+	return &ProviderTokenStore{
+		store:           store,
+		serverAddress:   ProfileServerAddress(profile),
+		configstorePath: SnykConfigstorePath(),
+	}
+}
+
+// ProfileServerAddress returns the key used to store the provider token of a profile in the Docker credentials store
+func ProfileServerAddress(profile string) string {
+	return ProviderServerAddress + "/scan-profiles/" + profile
+}
+
+// HubProfileServerAddress returns the key used to store the Docker Hub credentials of a profile in the Docker
+// credentials store
+func HubProfileServerAddress(profile string) string {
+	return hubProfilesAddress + profile
+}
+
+// SnykConfigstorePath returns the path of the plaintext configuration file where Snyk stores its token
+func SnykConfigstorePath() string {
+	home, err := homedir.Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "configstore", "snyk.json")
+}
+
+// PlaintextStore returns true if the credentials of the address are saved in plain text in the Docker CLI
+// configuration file, as no credential helper is configured for it
+func PlaintextStore(configFile *configfile.ConfigFile, address string) bool {
+	return configFile.CredentialsStore == "" && configFile.CredentialHelpers[address] == ""
+}
+
+// Get returns the provider token stored in the credentials store
+func (p *ProviderTokenStore) Get() (string, error) {
+	auth, err := p.store.Get(p.serverAddress)
+	if err != nil {
+		return "", err
+	}
+	return auth.IdentityToken, nil
+}
+
+// Store saves the provider token in the credentials store
+func (p *ProviderTokenStore) Store(token string) error {
+	return p.store.Store(types.AuthConfig{
+		ServerAddress: p.serverAddress,
+		IdentityToken: token,
+	})
+}
+
+// Erase removes the provider token from the credentials store, the login of the Snyk CLI is kept
+func (p *ProviderTokenStore) Erase() error {
+	if err := p.store.Erase(p.serverAddress); err != nil && !helperCredentials.IsErrCredentialsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Migrate copies the token written by the provider in its plaintext configuration file to the credentials store.
+// The file is left as is, as it holds the login of the Snyk CLI too.
+func (p *ProviderTokenStore) Migrate() error {
+	if config.ReadOnly() {
+		return nil
+	}
+	token, err := p.ConfigstoreToken()
+	if err != nil || token == "" {
+		return err
+	}
+	return p.Store(token)
+}
+
+// ConfigstoreToken returns the token saved by the provider in its plaintext configuration file, empty if there is none
+func (p *ProviderTokenStore) ConfigstoreToken() (string, error) {
+	if p.configstorePath == "" {
+		return "", nil
+	}
+	buf, err := ioutil.ReadFile(p.configstorePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	content := map[string]interface{}{}
+	if err := json.Unmarshal(buf, &content); err != nil {
+		return "", err
+	}
+	token, _ := content["api"].(string)
+	return token, nil
+}
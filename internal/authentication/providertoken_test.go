@@ -0,0 +1,113 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package authentication
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/cli/config/credentials"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestProviderTokenStoreMigratesConfigstore(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("snyk.json", `{"api":"provider-token","org":"my-org"}`))
+	defer dir.Remove()
+
+	// Reading the token doesn't migrate it
+	store := newTestProviderTokenStore(dir)
+	token, err := store.Get()
+	assert.NilError(t, err)
+	assert.Equal(t, token, "")
+
+	assert.NilError(t, store.Migrate())
+	token, err = store.Get()
+	assert.NilError(t, err)
+	assert.Equal(t, token, "provider-token")
+
+	// The login of the Snyk CLI is kept
+	buf, err := ioutil.ReadFile(dir.Join("snyk.json"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), `{"api":"provider-token","org":"my-org"}`)
+
+	// Erasing the token doesn't log the Snyk CLI out either
+	assert.NilError(t, store.Erase())
+	token, err = store.ConfigstoreToken()
+	assert.NilError(t, err)
+	assert.Equal(t, token, "provider-token")
+}
+
+func TestPlaintextStore(t *testing.T) {
+	configFile := configfile.New("config.json")
+	assert.Assert(t, PlaintextStore(configFile, ProviderServerAddress))
+	configFile.CredentialHelpers = map[string]string{ProviderServerAddress: "pass"}
+	assert.Assert(t, !PlaintextStore(configFile, ProviderServerAddress))
+	configFile.CredentialHelpers = nil
+	configFile.CredentialsStore = "desktop"
+	assert.Assert(t, !PlaintextStore(configFile, ProviderServerAddress))
+}
+
+func TestProviderTokenStoreErase(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+
+	store := newTestProviderTokenStore(dir)
+	assert.NilError(t, store.Store("provider-token"))
+	token, err := store.Get()
+	assert.NilError(t, err)
+	assert.Equal(t, token, "provider-token")
+
+	assert.NilError(t, store.Erase())
+	token, err = store.Get()
+	assert.NilError(t, err)
+	assert.Equal(t, token, "")
+}
+
+func TestProfileTokenStore(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+
+	store := newTestProviderTokenStore(dir)
+	profileStore := &ProviderTokenStore{
+		store:           store.store,
+		serverAddress:   ProfileServerAddress("prod"),
+		configstorePath: dir.Join("snyk.json"),
+	}
+	assert.NilError(t, store.Store("default-token"))
+	assert.NilError(t, profileStore.Store("prod-token"))
+
+	token, err := profileStore.Get()
+	assert.NilError(t, err)
+	assert.Equal(t, token, "prod-token")
+	assert.NilError(t, profileStore.Erase())
+	token, err = store.Get()
+	assert.NilError(t, err)
+	assert.Equal(t, token, "default-token")
+}
+
+func newTestProviderTokenStore(dir *fs.Dir) *ProviderTokenStore {
+	configFile := configfile.New(filepath.Join(dir.Path(), "config.json"))
+	return &ProviderTokenStore{
+		store:           credentials.NewFileStore(configFile),
+		serverAddress:   ProviderServerAddress,
+		configstorePath: dir.Join("snyk.json"),
+	}
+}
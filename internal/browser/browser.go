@@ -0,0 +1,314 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package browser implements the terminal UI browsing the findings of a scan with docker scan --interactive
+package browser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/docker/scan-cli-plugin/internal/theme"
+)
+
+const help = "↑/↓ move  enter details  space mark  s severity  / filter  esc clear  q quit"
+
+// Browser is the state of the terminal UI: the findings kept by the filters, the selected one, the expanded and the
+// marked ones
+type Browser struct {
+	title    string
+	findings []report.Vulnerability
+	// visible are the indexes of the findings kept by the severity and the text filters
+	visible []int
+	cursor  int
+	offset  int
+	page    int
+	// minSeverity is the level of the lowest severity shown, -1 showing all the findings
+	minSeverity int
+	query       string
+	editing     bool
+	expanded    map[int]bool
+	marked      map[int]bool
+}
+
+// New returns a browser of the findings, titled with the scanned image
+func New(title string, findings []report.Vulnerability) *Browser {
+	b := &Browser{
+		title:       title,
+		findings:    findings,
+		page:        10,
+		minSeverity: -1,
+		expanded:    map[int]bool{},
+		marked:      map[int]bool{},
+	}
+	b.filter()
+	return b
+}
+
+// Marked returns the findings marked to be added to the ignore file, in the order of the findings
+func (b *Browser) Marked() []report.Vulnerability {
+	var marked []report.Vulnerability
+	for i, finding := range b.findings {
+		if b.marked[i] {
+			marked = append(marked, finding)
+		}
+	}
+	return marked
+}
+
+// HandleKey updates the state with a key pressed by the user, it returns false when the user quits
+func (b *Browser) HandleKey(key Key) bool {
+	if key == CtrlC {
+		return false
+	}
+	if b.editing {
+		b.editQuery(key)
+		return true
+	}
+	switch key {
+	case 'q', 'Q':
+		return false
+	case Up, 'k':
+		b.move(-1)
+	case Down, 'j':
+		b.move(1)
+	case PageUp:
+		b.move(-b.page)
+	case PageDown:
+		b.move(b.page)
+	case Home, 'g':
+		b.move(-len(b.visible))
+	case End, 'G':
+		b.move(len(b.visible))
+	case Enter:
+		b.toggle(b.expanded)
+	case ' ', 'i':
+		b.toggle(b.marked)
+	case 's':
+		b.minSeverity++
+		if b.minSeverity >= len(report.Severities) {
+			b.minSeverity = -1
+		}
+		b.filter()
+	case '/':
+		b.editing = true
+	case Escape:
+		b.query = ""
+		b.filter()
+	}
+	return true
+}
+
+// editQuery updates the text filter while the user types it
+func (b *Browser) editQuery(key Key) {
+	switch key {
+	case Enter:
+		b.editing = false
+	case Escape:
+		b.editing = false
+		b.query = ""
+	case Backspace:
+		if query := []rune(b.query); len(query) > 0 {
+			b.query = string(query[:len(query)-1])
+		}
+	default:
+		if key >= ' ' {
+			b.query += string(rune(key))
+		}
+	}
+	b.filter()
+}
+
+func (b *Browser) move(delta int) {
+	b.cursor += delta
+	if b.cursor >= len(b.visible) {
+		b.cursor = len(b.visible) - 1
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+}
+
+// toggle flips the selected finding in the set
+func (b *Browser) toggle(set map[int]bool) {
+	if len(b.visible) == 0 {
+		return
+	}
+	index := b.visible[b.cursor]
+	set[index] = !set[index]
+}
+
+// filter keeps the findings of the minimum severity matching the query, by ID, package or layer
+func (b *Browser) filter() {
+	query := strings.ToLower(b.query)
+	b.visible = b.visible[:0]
+	for i, finding := range b.findings {
+		if b.minSeverity >= 0 && report.SeverityLevel(finding.Severity) < b.minSeverity {
+			continue
+		}
+		if query != "" && !matches(finding, query) {
+			continue
+		}
+		b.visible = append(b.visible, i)
+	}
+	b.move(0)
+}
+
+func matches(finding report.Vulnerability, query string) bool {
+	for _, field := range []string{finding.ID, finding.PackageName, layer(finding)} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// Render returns the lines of the screen, a header, the findings scrolled to the selected one and a help footer
+func (b *Browser) Render(width, height int) []string {
+	b.page = height - 2
+	if b.page < 1 {
+		b.page = 1
+	}
+	var body []string
+	cursorLine := 0
+	for i, index := range b.visible {
+		if i == b.cursor {
+			cursorLine = len(body)
+		}
+		body = append(body, b.row(index, i == b.cursor, width))
+		if b.expanded[index] {
+			for _, line := range details(b.findings[index]) {
+				body = append(body, truncate("      "+line, width))
+			}
+		}
+	}
+	if len(body) == 0 {
+		body = append(body, "No findings match the filters")
+	}
+	if cursorLine < b.offset {
+		b.offset = cursorLine
+	}
+	if cursorLine >= b.offset+b.page {
+		b.offset = cursorLine - b.page + 1
+	}
+	if b.offset > len(body)-1 {
+		b.offset = len(body) - 1
+	}
+	end := b.offset + b.page
+	if end > len(body) {
+		end = len(body)
+	}
+	lines := []string{truncate(b.header(), width)}
+	lines = append(lines, body[b.offset:end]...)
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	return append(lines, truncate(b.footer(), width))
+}
+
+func (b *Browser) header() string {
+	header := fmt.Sprintf("%s: %d of %d findings", b.title, len(b.visible), len(b.findings))
+	if b.minSeverity >= 0 {
+		header += " | severity >= " + report.Severities[b.minSeverity]
+	}
+	if b.query != "" || b.editing {
+		header += " | filter: " + b.query
+	}
+	if marked := len(b.Marked()); marked > 0 {
+		header += fmt.Sprintf(" | %d marked", marked)
+	}
+	return header
+}
+
+func (b *Browser) footer() string {
+	if b.editing {
+		return "/" + b.query + "█  enter apply  esc clear"
+	}
+	return help
+}
+
+// row returns the line of a finding, colored with its severity or in reverse video when selected
+func (b *Browser) row(index int, selected bool, width int) string {
+	finding := b.findings[index]
+	cursor, mark := " ", " "
+	if selected {
+		cursor = ">"
+	}
+	if b.marked[index] {
+		mark = "x"
+	}
+	line := truncate(fmt.Sprintf("%s [%s] %-8s %s %s@%s %s", cursor, mark, strings.ToLower(finding.Severity), finding.ID,
+		finding.PackageName, finding.Version, layer(finding)), width)
+	if selected {
+		return "\x1b[7m" + line + "\x1b[0m"
+	}
+	return theme.Current().Severity(finding.Severity, line)
+}
+
+// details returns the lines describing an expanded finding
+func details(finding report.Vulnerability) []string {
+	lines := []string{finding.Title}
+	if url := report.AdvisoryURL(finding.ID); url != "" {
+		lines = append(lines, "Advisory: "+url)
+	}
+	if len(finding.FixedIn) > 0 {
+		lines = append(lines, "Fixed in: "+strings.Join(finding.FixedIn, ", "))
+	} else {
+		lines = append(lines, "Fixed in: no fix available")
+	}
+	if len(finding.From) > 0 {
+		lines = append(lines, "From: "+strings.Join(finding.From, " > "))
+	}
+	if finding.Layer != "" {
+		lines = append(lines, "Layer: "+finding.Layer)
+	}
+	if finding.DockerfileLine > 0 {
+		lines = append(lines, fmt.Sprintf("Dockerfile: line %d, stage %s: %s", finding.DockerfileLine,
+			finding.DockerfileStage, finding.DockerfileInstruction))
+	}
+	if finding.Exploit != nil {
+		lines = append(lines, "Exploit: "+finding.Exploit.Describe())
+	}
+	if finding.VEXStatus != "" {
+		lines = append(lines, fmt.Sprintf("VEX: %s %s", finding.VEXStatus, finding.VEXJustification))
+	}
+	return lines
+}
+
+// layer returns the Dockerfile instruction or the base image which introduced the finding, or its layer digest
+func layer(finding report.Vulnerability) string {
+	switch {
+	case finding.DockerfileInstruction != "":
+		return finding.DockerfileInstruction
+	case finding.DockerBaseImage != "":
+		return "base image " + finding.DockerBaseImage
+	}
+	digest := strings.TrimPrefix(finding.Layer, "sha256:")
+	if len(digest) > 12 {
+		digest = digest[:12]
+	}
+	return digest
+}
+
+// truncate cuts the line to the width of the terminal
+func truncate(line string, width int) string {
+	runes := []rune(line)
+	if width <= 0 || len(runes) <= width {
+		return line
+	}
+	return string(runes[:width])
+}
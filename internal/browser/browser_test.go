@@ -0,0 +1,111 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package browser
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+)
+
+var findings = []report.Vulnerability{
+	{ID: "SNYK-DEBIAN10-CURL-1", Title: "Use After Free", Severity: "high", PackageName: "curl", Version: "7.64.0",
+		DockerBaseImage: "debian:buster", FixedIn: []string{"7.64.0-4+deb10u2"}},
+	{ID: "SNYK-DEBIAN10-ZLIB-2", Title: "Out-of-bounds Write", Severity: "critical", PackageName: "zlib",
+		Version: "1.2.11", DockerfileInstruction: "RUN apt-get install zlib1g"},
+	{ID: "SNYK-DEBIAN10-TAR-3", Title: "CVE-2005-2541", Severity: "low", PackageName: "tar", Version: "1.30",
+		Layer: "sha256:4b1f2e6c9a7d3e5f8b0a1c2d3e4f5a6b7c8d9e0f"},
+}
+
+func TestReadKey(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("\x1b[A\x1b[B\x1b[5~\x1b[6~\x1bOH\x1b[4~\r\x7f\x03qé\x1b"))
+	var keys []Key
+	for {
+		key, err := ReadKey(reader)
+		if err != nil {
+			break
+		}
+		keys = append(keys, key)
+	}
+	assert.DeepEqual(t, keys, []Key{Up, Down, PageUp, PageDown, Home, End, Enter, Backspace, CtrlC, 'q', 'é', Escape})
+}
+
+func TestFilter(t *testing.T) {
+	b := New("alpine", findings)
+	assert.Equal(t, len(b.visible), 3)
+
+	b.HandleKey('s')
+	b.HandleKey('s')
+	assert.DeepEqual(t, b.visible, []int{0, 1})
+	assert.Assert(t, strings.Contains(b.header(), "2 of 3 findings | severity >= medium"))
+
+	for _, key := range []Key{'s', 's', 's', '/', 'z', 'l', 'x', Backspace, Enter} {
+		assert.Assert(t, b.HandleKey(key))
+	}
+	assert.Equal(t, b.query, "zl")
+	assert.DeepEqual(t, b.visible, []int{1})
+
+	b.HandleKey(Escape)
+	assert.Equal(t, len(b.visible), 3)
+
+	b.HandleKey('/')
+	for _, key := range "buster" {
+		b.HandleKey(Key(key))
+	}
+	assert.DeepEqual(t, b.visible, []int{0})
+	assert.Assert(t, !b.HandleKey(CtrlC))
+}
+
+func TestMark(t *testing.T) {
+	b := New("alpine", findings)
+	b.HandleKey(' ')
+	b.HandleKey(End)
+	b.HandleKey('i')
+	b.HandleKey(Up)
+	b.HandleKey('i')
+	b.HandleKey('i')
+	marked := b.Marked()
+	assert.Equal(t, len(marked), 2)
+	assert.Equal(t, marked[0].ID, "SNYK-DEBIAN10-CURL-1")
+	assert.Equal(t, marked[1].ID, "SNYK-DEBIAN10-TAR-3")
+	assert.Assert(t, !b.HandleKey('q'))
+}
+
+func TestRender(t *testing.T) {
+	b := New("alpine", findings)
+	b.HandleKey(' ')
+	b.HandleKey(Enter)
+	lines := b.Render(60, 8)
+	assert.DeepEqual(t, lines, []string{
+		"alpine: 3 of 3 findings | 1 marked",
+		"\x1b[7m> [x] high     SNYK-DEBIAN10-CURL-1 curl@7.64.0 base image d\x1b[0m",
+		"      Use After Free",
+		"      Advisory: https://snyk.io/vuln/SNYK-DEBIAN10-CURL-1",
+		"      Fixed in: 7.64.0-4+deb10u2",
+		"  [ ] critical SNYK-DEBIAN10-ZLIB-2 zlib@1.2.11 RUN apt-get ",
+		"  [ ] low      SNYK-DEBIAN10-TAR-3 tar@1.30 4b1f2e6c9a7d",
+		"↑/↓ move  enter details  space mark  s severity  / filter  e",
+	})
+
+	b.HandleKey(End)
+	lines = b.Render(60, 4)
+	assert.Equal(t, len(lines), 4)
+	assert.Equal(t, lines[2], "\x1b[7m> [ ] low      SNYK-DEBIAN10-TAR-3 tar@1.30 4b1f2e6c9a7d\x1b[0m")
+}
@@ -0,0 +1,108 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package browser
+
+import (
+	"bufio"
+)
+
+// Key is a key pressed in the terminal, the printable characters being their rune
+type Key rune
+
+// The keys without a printable character
+const (
+	Unknown Key = -iota
+	Up
+	Down
+	PageUp
+	PageDown
+	Home
+	End
+	Enter
+	Escape
+	Backspace
+	CtrlC
+)
+
+// escapeSequences maps the final characters of the escape sequences sent by the terminals to their key
+var escapeSequences = map[string]Key{
+	"A":  Up,
+	"B":  Down,
+	"5~": PageUp,
+	"6~": PageDown,
+	"H":  Home,
+	"1~": Home,
+	"7~": Home,
+	"F":  End,
+	"4~": End,
+	"8~": End,
+}
+
+// ReadKey reads the next key from a terminal in raw mode
+func ReadKey(reader *bufio.Reader) (Key, error) {
+	b, err := reader.ReadByte()
+	if err != nil {
+		return Unknown, err
+	}
+	switch b {
+	case 0x1b:
+		return readEscapeSequence(reader)
+	case '\r', '\n':
+		return Enter, nil
+	case 0x7f, 0x08:
+		return Backspace, nil
+	case 0x03:
+		return CtrlC, nil
+	}
+	if b < 0x80 {
+		return Key(b), nil
+	}
+	if err := reader.UnreadByte(); err != nil {
+		return Unknown, err
+	}
+	r, _, err := reader.ReadRune()
+	return Key(r), err
+}
+
+// readEscapeSequence reads the rest of an escape sequence, a lone escape being the Escape key
+func readEscapeSequence(reader *bufio.Reader) (Key, error) {
+	if reader.Buffered() == 0 {
+		return Escape, nil
+	}
+	b, err := reader.ReadByte()
+	if err != nil {
+		return Unknown, err
+	}
+	if b != '[' && b != 'O' {
+		return Escape, nil
+	}
+	var sequence []byte
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return Unknown, err
+		}
+		sequence = append(sequence, b)
+		if b < '0' || b > '9' {
+			break
+		}
+	}
+	if key, ok := escapeSequences[string(sequence)]; ok {
+		return key, nil
+	}
+	return Unknown, nil
+}
@@ -0,0 +1,90 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package budget
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/docker/distribution/reference"
+)
+
+const (
+	// RecentOrder scans the most recently built images first
+	RecentOrder = "recent"
+	// GivenOrder scans the images in the order they are given
+	GivenOrder = "given"
+	// PolicyOrder scans the images by the priorities of the budget configuration, the most recent first
+	// for the same priority
+	PolicyOrder = "policy"
+)
+
+// Candidate is an image of a bulk scan
+type Candidate struct {
+	Ref     string
+	Created time.Time
+	// Priority is the position of the first priority pattern matching the image, lower is more important
+	Priority int
+}
+
+// ValidateOrder checks the order used to rank the images
+func ValidateOrder(order string) error {
+	if order != RecentOrder && order != GivenOrder && order != PolicyOrder {
+		return fmt.Errorf("--budget-order takes only '%s', '%s' or '%s' values", RecentOrder, GivenOrder, PolicyOrder)
+	}
+	return nil
+}
+
+// Priority returns the position of the first pattern matching the image reference, or the number of patterns when none
+// matches. Patterns are matched against the familiar name of the image, with and without its tag, like "myorg/*" or
+// "myapp:prod-*".
+func Priority(ref string, patterns []string) int {
+	names := []string{ref}
+	if named, err := reference.ParseNormalizedNamed(ref); err == nil {
+		names = []string{reference.FamiliarString(named), reference.FamiliarName(named)}
+	}
+	for i, pattern := range patterns {
+		for _, name := range names {
+			if matched, _ := path.Match(pattern, name); matched {
+				return i
+			}
+		}
+	}
+	return len(patterns)
+}
+
+// Plan ranks the candidates with the given order, and splits them between the ones to scan
+// within the budget and the deferred ones
+func Plan(candidates []Candidate, budget int, order string) ([]Candidate, []Candidate) {
+	ranked := make([]Candidate, len(candidates))
+	copy(ranked, candidates)
+	if order == RecentOrder || order == PolicyOrder {
+		// images which could not be inspected have a zero creation date and are scanned last
+		sort.SliceStable(ranked, func(i, j int) bool {
+			if order == PolicyOrder && ranked[i].Priority != ranked[j].Priority {
+				return ranked[i].Priority < ranked[j].Priority
+			}
+			return ranked[i].Created.After(ranked[j].Created)
+		})
+	}
+	if budget >= len(ranked) {
+		return ranked, nil
+	}
+	return ranked[:budget], ranked[budget:]
+}
@@ -0,0 +1,92 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := Candidate{Ref: "old", Created: now.AddDate(-1, 0, 0)}
+	recent := Candidate{Ref: "recent", Created: now}
+	unknown := Candidate{Ref: "unknown"}
+	middle := Candidate{Ref: "middle", Created: now.AddDate(0, -1, 0)}
+	candidates := []Candidate{old, unknown, recent, middle}
+
+	testCases := []struct {
+		name             string
+		budget           int
+		order            string
+		expectedScan     []Candidate
+		expectedDeferred []Candidate
+	}{
+		{
+			name:             "most recent first",
+			budget:           2,
+			order:            RecentOrder,
+			expectedScan:     []Candidate{recent, middle},
+			expectedDeferred: []Candidate{old, unknown},
+		},
+		{
+			name:             "given order",
+			budget:           3,
+			order:            GivenOrder,
+			expectedScan:     []Candidate{old, unknown, recent},
+			expectedDeferred: []Candidate{middle},
+		},
+		{
+			name:         "budget larger than the images",
+			budget:       10,
+			order:        RecentOrder,
+			expectedScan: []Candidate{recent, middle, old, unknown},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			scan, deferred := Plan(candidates, testCase.budget, testCase.order)
+			assert.DeepEqual(t, scan, testCase.expectedScan)
+			assert.DeepEqual(t, deferred, testCase.expectedDeferred)
+		})
+	}
+	// the candidates are left untouched
+	assert.DeepEqual(t, candidates, []Candidate{old, unknown, recent, middle})
+}
+
+func TestValidateOrder(t *testing.T) {
+	assert.NilError(t, ValidateOrder(RecentOrder))
+	assert.NilError(t, ValidateOrder(GivenOrder))
+	assert.NilError(t, ValidateOrder(PolicyOrder))
+	assert.ErrorContains(t, ValidateOrder("size"), "--budget-order takes only 'recent', 'given' or 'policy' values")
+}
+
+func TestPolicyOrder(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	patterns := []string{"myorg/prod-*", "myapp:release-*"}
+	var candidates []Candidate
+	for i, ref := range []string{"docker.io/library/myapp:latest", "myorg/prod-api", "myapp:release-1", "myorg/prod-web:1.0"} {
+		candidates = append(candidates, Candidate{Ref: ref, Created: now.AddDate(0, 0, i), Priority: Priority(ref, patterns)})
+	}
+	assert.DeepEqual(t, []int{candidates[0].Priority, candidates[1].Priority, candidates[2].Priority, candidates[3].Priority}, []int{2, 0, 1, 0})
+
+	scan, deferred := Plan(candidates, 3, PolicyOrder)
+	assert.DeepEqual(t, scan, []Candidate{candidates[3], candidates[1], candidates[2]})
+	assert.DeepEqual(t, deferred, []Candidate{candidates[0]})
+}
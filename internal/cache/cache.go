@@ -0,0 +1,120 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	cliConfig "github.com/docker/cli/cli/config"
+	"github.com/docker/scan-cli-plugin/internal/filelock"
+)
+
+// Entry is the cached result of a scan
+type Entry struct {
+	// Image is the reference of the scanned image
+	Image                string    `json:"image,omitempty"`
+	Output               []byte    `json:"output"`
+	VulnerabilitiesFound bool      `json:"vulnerabilitiesFound"`
+	CreatedAt            time.Time `json:"createdAt"`
+}
+
+// Cache stores the scan results on disk, one file per key
+type Cache struct {
+	dir string
+}
+
+// New returns the cache stored in ${DOCKER_CONFIG}/scan/cache
+func New() *Cache {
+	return &Cache{dir: filepath.Join(cliConfig.Dir(), "scan", "cache")}
+}
+
+// Key returns the cache key identifying the given parts, like the image digest and the provider version
+func Key(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part)) //nolint:errcheck
+		hash.Write([]byte{0})    //nolint:errcheck
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get returns the entry stored with the key, if it is not older than maxAge
+func (c *Cache) Get(key string, now time.Time, maxAge time.Duration) (Entry, bool) {
+	buf, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return Entry{}, false
+	}
+	var entry Entry
+	if err := json.Unmarshal(buf, &entry); err != nil {
+		return Entry{}, false
+	}
+	if now.Sub(entry.CreatedAt) > maxAge {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// Put stores the entry with the key
+func (c *Cache) Put(key string, entry Entry) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return filelock.WriteFile(c.path(key), buf, 0644)
+}
+
+// Entries returns all the cached entries, whatever their age
+func (c *Cache) Entries() ([]Entry, error) {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, file := range files {
+		buf, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var entry Entry
+		if err := json.Unmarshal(buf, &entry); err != nil {
+			// skip the entries written by another version
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Purge removes all the cached entries
+func (c *Cache) Purge() error {
+	err := os.RemoveAll(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
@@ -0,0 +1,64 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestCache(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	cache := &Cache{dir: dir.Join("cache")}
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	key := Key("sha256:image", "Snyk (1.563.0)")
+
+	_, ok := cache.Get(key, now, time.Hour)
+	assert.Assert(t, !ok)
+
+	entry := Entry{Image: "myimage:latest", Output: []byte(`{"ok":false}`), VulnerabilitiesFound: true, CreatedAt: now.Add(-30 * time.Minute)}
+	assert.NilError(t, cache.Put(key, entry))
+
+	cached, ok := cache.Get(key, now, time.Hour)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, cached, entry)
+
+	// Outdated entries are ignored
+	_, ok = cache.Get(key, now, 10*time.Minute)
+	assert.Assert(t, !ok)
+
+	entries, err := cache.Entries()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, entries, []Entry{entry})
+
+	assert.NilError(t, cache.Purge())
+	_, ok = cache.Get(key, now, time.Hour)
+	assert.Assert(t, !ok)
+	// Purging an empty cache succeeds
+	assert.NilError(t, cache.Purge())
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key("sha256:image", "Snyk (1.563.0)"), Key("sha256:image", "Snyk (1.563.0)"))
+	assert.Assert(t, Key("sha256:image", "Snyk (1.563.0)") != Key("sha256:image", "Snyk (1.564.0)"))
+	// Parts are delimited
+	assert.Assert(t, Key("ab", "c") != Key("a", "bc"))
+}
@@ -0,0 +1,100 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// Result is the normalized result of a scan posted to a collector, to get a central view of the scans made on the
+// developer machines and in CI
+type Result struct {
+	Image     string    `json:"image"`
+	ImageID   string    `json:"imageId,omitempty"`
+	ScannedAt time.Time `json:"scannedAt"`
+	// Host is the name of the machine the scan ran on
+	Host string `json:"host,omitempty"`
+	// CI is true when the scan ran in a CI pipeline
+	CI            bool   `json:"ci"`
+	PluginVersion string `json:"pluginVersion"`
+	// Severities counts the findings by severity
+	Severities map[string]int         `json:"severities"`
+	Findings   []report.Vulnerability `json:"findings"`
+}
+
+// ValidateEndpoint checks the collector endpoint is reached over HTTPS, unless it runs on the machine, as the token
+// authenticating the results is sent to it
+func ValidateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid results endpoint %q: %s", endpoint, err)
+	}
+	switch {
+	case u.Scheme == "https" && u.Host != "":
+		return nil
+	case u.Scheme == "http" && isLoopback(u.Hostname()):
+		return nil
+	}
+	return fmt.Errorf("the results endpoint %q must be an https URL, http is only allowed on the loopback interface", endpoint)
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Push posts the result to the collector endpoint, authenticated with the token when it is not empty
+func Push(ctx context.Context, client *http.Client, endpoint, token string, result Result) error {
+	if err := ValidateEndpoint(endpoint); err != nil {
+		return err
+	}
+	buf, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("results endpoint returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
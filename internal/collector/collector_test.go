@@ -0,0 +1,79 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+)
+
+var result = Result{
+	Image:         "myimage:1.0",
+	ImageID:       "sha256:0123",
+	ScannedAt:     time.Date(2021, time.March, 12, 10, 0, 0, 0, time.UTC),
+	Host:          "ci-runner-1",
+	CI:            true,
+	PluginVersion: "v0.9.0",
+	Severities:    map[string]int{"high": 1},
+	Findings:      []report.Vulnerability{{ID: "SNYK-DEBIAN10-OPENSSL-1075326", Severity: "high", PackageName: "openssl"}},
+}
+
+func TestPush(t *testing.T) {
+	var received Result
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodPost)
+		assert.Equal(t, r.Header.Get("Authorization"), "Bearer dockerscanid")
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	assert.NilError(t, Push(context.Background(), server.Client(), server.URL, "dockerscanid", result))
+	assert.DeepEqual(t, received, result)
+}
+
+func TestPushError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("Authorization"), "")
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := Push(context.Background(), server.Client(), server.URL, "", result)
+	assert.Error(t, err, "results endpoint returned 401 Unauthorized: invalid token")
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	assert.NilError(t, ValidateEndpoint("https://scans.example.com/results"))
+	assert.NilError(t, ValidateEndpoint("http://localhost:8080/results"))
+	assert.NilError(t, ValidateEndpoint("http://127.0.0.1:8080/results"))
+	assert.NilError(t, ValidateEndpoint("http://[::1]:8080/results"))
+	assert.ErrorContains(t, ValidateEndpoint("http://scans.example.com/results"), "must be an https URL")
+	assert.ErrorContains(t, ValidateEndpoint("scans.example.com/results"), "must be an https URL")
+}
+
+func TestPushRefusesPlainHTTP(t *testing.T) {
+	err := Push(context.Background(), http.DefaultClient, "http://scans.example.com/results", "token", Result{})
+	assert.ErrorContains(t, err, "must be an https URL")
+}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dispute
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Evidence describes where a disputed finding was found in the image
+type Evidence struct {
+	Analyzer       string `json:"analyzer"`
+	Package        string `json:"package,omitempty"`
+	Version        string `json:"version,omitempty"`
+	PackageManager string `json:"packageManager,omitempty"`
+	Path           string `json:"path,omitempty"`
+	// Layer is the position of the layer in the image history starting at 1, or 0 if the layer is unknown
+	Layer       int    `json:"layer,omitempty"`
+	LayerDigest string `json:"layerDigest,omitempty"`
+	CreatedBy   string `json:"createdBy,omitempty"`
+}
+
+// Record is a false positive report of a finding, with the evidence collected from the image
+type Record struct {
+	FindingID string     `json:"findingId"`
+	Title     string     `json:"title,omitempty"`
+	Image     string     `json:"image"`
+	ImageID   string     `json:"imageId,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	Created   time.Time  `json:"created"`
+	Evidence  []Evidence `json:"evidence"`
+}
+
+// Save writes the record as a JSON file in dir and returns its path
+func Save(dir string, record Record) (string, error) {
+	if err := os.MkdirAll(dir, 0744); err != nil {
+		return "", err
+	}
+	buf, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%s.json", unsafeFileChars.ReplaceAllString(record.FindingID, "_"), record.Created.UTC().Format("20060102T150405Z"))
+	path := filepath.Join(dir, name)
+	return path, ioutil.WriteFile(path, buf, 0644)
+}
+
+// Submit posts the record to the false positive endpoint, authenticated with the token when it is not empty
+func Submit(ctx context.Context, client *http.Client, endpoint, token string, record Record) error {
+	buf, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("false positive endpoint returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dispute
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+var record = Record{
+	FindingID: "SNYK-DEBIAN10-OPENSSL-1075326",
+	Image:     "myimage:latest",
+	Reason:    "openssl is not used",
+	Created:   time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	Evidence: []Evidence{{
+		Analyzer:    "Snyk OS packages scan (deb)",
+		Package:     "openssl",
+		Version:     "1.1.1d-0+deb10u3",
+		Layer:       1,
+		LayerDigest: "sha256:abc",
+	}},
+}
+
+func TestSave(t *testing.T) {
+	dir := fs.NewDir(t, "disputes")
+	defer dir.Remove()
+
+	path, err := Save(filepath.Join(dir.Path(), "disputes"), record)
+	assert.NilError(t, err)
+	assert.Equal(t, filepath.Base(path), "SNYK-DEBIAN10-OPENSSL-1075326-20210601T120000Z.json")
+	buf, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	var saved Record
+	assert.NilError(t, json.Unmarshal(buf, &saved))
+	assert.DeepEqual(t, saved, record)
+}
+
+func TestSubmit(t *testing.T) {
+	var received Record
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("Authorization"), "Bearer secret")
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	assert.NilError(t, Submit(context.Background(), server.Client(), server.URL, "secret", record))
+	assert.DeepEqual(t, received, record)
+}
+
+func TestSubmitError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown finding", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := Submit(context.Background(), server.Client(), server.URL, "", record)
+	assert.ErrorContains(t, err, "404 Not Found: unknown finding")
+}
@@ -0,0 +1,282 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerfile
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Stage is a build stage of a Dockerfile
+type Stage struct {
+	Name string
+	// BaseImage is the image of the FROM instruction, with the build args resolved
+	BaseImage    string
+	Line         int
+	Instructions []Instruction
+}
+
+// Instruction is an instruction of a build stage, following the FROM instruction
+type Instruction struct {
+	Line int
+	// Text is the instruction as written, its continuation lines joined
+	Text string
+	// Expanded is the instruction with the build args of the stage resolved
+	Expanded string
+	// From is the stage or the image the files of a COPY --from instruction are copied from
+	From string
+}
+
+// Location is the instruction of the Dockerfile which introduced a finding
+type Location struct {
+	// Stage is the name, or the index when it has no name, of the stage which built the files of the finding: the
+	// stage of the instruction, or the stage a COPY --from instruction copied them from
+	Stage       string
+	Line        int
+	Instruction string
+}
+
+// ParseFile reads the build stages of a Dockerfile, the build args overriding the default values of its ARG
+// instructions
+func ParseFile(path string, buildArgs map[string]string) ([]Stage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	//nolint: errcheck
+	defer f.Close()
+	return Parse(f, buildArgs)
+}
+
+// Parse reads the build stages from the content of a Dockerfile
+func Parse(reader io.Reader, buildArgs map[string]string) ([]Stage, error) {
+	var stages []Stage
+	globalArgs := map[string]string{}
+	var stageArgs map[string]string
+	err := readInstructions(reader, func(line int, text string) {
+		fields := strings.Fields(text)
+		switch strings.ToUpper(fields[0]) {
+		case "FROM":
+			if stage, ok := parseFrom(fields[1:], globalArgs); ok {
+				stage.Line = line
+				stages = append(stages, stage)
+				stageArgs = map[string]string{}
+			}
+		case "ARG":
+			if stages == nil {
+				declareArgs(fields[1:], globalArgs, nil, buildArgs)
+			} else {
+				declareArgs(fields[1:], stageArgs, globalArgs, buildArgs)
+			}
+		default:
+			if stages == nil {
+				return
+			}
+			instruction := Instruction{Line: line, Text: text, Expanded: expand(text, stageArgs)}
+			if strings.EqualFold(fields[0], "COPY") {
+				instruction.From = copyFrom(strings.Fields(instruction.Expanded)[1:])
+			}
+			stages[len(stages)-1].Instructions = append(stages[len(stages)-1].Instructions, instruction)
+		}
+	})
+	return stages, err
+}
+
+// readInstructions calls the handler with each instruction and the line it starts at, its continuation lines joined
+// and its whitespaces collapsed
+func readInstructions(reader io.Reader, handle func(line int, text string)) error {
+	scanner := bufio.NewScanner(reader)
+	var (
+		line    = 0
+		start   = 0
+		pending []string
+	)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		// the comments and empty lines are ignored, even between continuation lines
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if pending == nil {
+			start = line
+		}
+		if strings.HasSuffix(text, "\\") {
+			pending = append(pending, strings.TrimSuffix(text, "\\"))
+			continue
+		}
+		if fields := strings.Fields(strings.Join(append(pending, text), " ")); len(fields) > 0 {
+			handle(start, strings.Join(fields, " "))
+		}
+		pending = nil
+	}
+	if fields := strings.Fields(strings.Join(pending, " ")); len(fields) > 0 {
+		handle(start, strings.Join(fields, " "))
+	}
+	return scanner.Err()
+}
+
+func parseFrom(fields []string, globalArgs map[string]string) (Stage, bool) {
+	// skip the flags like --platform
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return Stage{}, false
+	}
+	stage := Stage{BaseImage: expand(fields[0], globalArgs)}
+	if len(fields) >= 3 && strings.EqualFold(fields[1], "AS") {
+		stage.Name = fields[2]
+	}
+	return stage, true
+}
+
+// declareArgs sets the value of the declared args: the build arg, else the default value of the declaration, else the
+// value of the global arg of the same name
+func declareArgs(declarations []string, args map[string]string, globalArgs map[string]string, buildArgs map[string]string) {
+	for _, declaration := range declarations {
+		name, value := declaration, ""
+		hasDefault := false
+		if index := strings.Index(declaration, "="); index >= 0 {
+			name, value, hasDefault = declaration[:index], expand(strings.Trim(declaration[index+1:], `"'`), args), true
+		}
+		if buildArg, ok := buildArgs[name]; ok {
+			args[name] = buildArg
+		} else if hasDefault {
+			args[name] = value
+		} else if global, ok := globalArgs[name]; ok {
+			args[name] = global
+		} else if _, ok := args[name]; !ok {
+			args[name] = ""
+		}
+	}
+}
+
+// expand replaces the references to the args, like $NAME, ${NAME} or ${NAME:-default}. The references to unknown
+// variables, like the environment variables of a RUN instruction, are kept.
+func expand(text string, args map[string]string) string {
+	return os.Expand(text, func(reference string) string {
+		if len(reference) == 1 && strings.Contains("*#$@!?-0123456789", reference) {
+			// special shell parameters, like $$
+			return "$" + reference
+		}
+		name, modifier, word := reference, "", ""
+		if index := strings.Index(reference, ":"); index > 0 && len(reference) > index+1 {
+			name, modifier, word = reference[:index], reference[index+1:index+2], reference[index+2:]
+		}
+		value, ok := args[name]
+		switch {
+		case modifier == "-" && value == "":
+			return word
+		case modifier == "+" && value != "":
+			return word
+		case modifier == "+":
+			return ""
+		case !ok:
+			if reference == name {
+				return "${" + name + "}"
+			}
+			return "${" + reference + "}"
+		}
+		return value
+	})
+}
+
+func copyFrom(flags []string) string {
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, "--") {
+			break
+		}
+		if strings.HasPrefix(flag, "--from=") {
+			return strings.TrimPrefix(flag, "--from=")
+		}
+	}
+	return ""
+}
+
+// BaseImage returns the base image of the final stage, following the references to previous stages
+func BaseImage(stages []Stage) string {
+	if len(stages) == 0 {
+		return ""
+	}
+	baseImage := stages[len(stages)-1].BaseImage
+	for i := len(stages) - 2; i >= 0; i-- {
+		if stages[i].Name != "" && strings.EqualFold(stages[i].Name, baseImage) {
+			baseImage = stages[i].BaseImage
+		}
+	}
+	return baseImage
+}
+
+// Locate finds the instruction reported by the scan provider, as written or with its build args resolved, starting
+// from the final stage. The files copied by a COPY --from instruction are located in the stage they are copied from.
+func Locate(stages []Stage, instruction string) (Location, bool) {
+	target := normalize(instruction)
+	if target == "" {
+		return Location{}, false
+	}
+	for i := len(stages) - 1; i >= 0; i-- {
+		for j := len(stages[i].Instructions) - 1; j >= 0; j-- {
+			candidate := stages[i].Instructions[j]
+			if normalize(candidate.Text) != target && normalize(candidate.Expanded) != target {
+				continue
+			}
+			location := Location{Stage: stageName(stages, i), Line: candidate.Line, Instruction: candidate.Text}
+			if source := findStage(stages[:i], candidate.From); source >= 0 {
+				location.Stage = stageName(stages, source)
+			}
+			return location, true
+		}
+	}
+	return Location{}, false
+}
+
+// findStage returns the index of the stage of the name or the index, or -1
+func findStage(stages []Stage, reference string) int {
+	if reference == "" {
+		return -1
+	}
+	if index, err := strconv.Atoi(reference); err == nil && index >= 0 && index < len(stages) {
+		return index
+	}
+	for i := len(stages) - 1; i >= 0; i-- {
+		if strings.EqualFold(stages[i].Name, reference) {
+			return i
+		}
+	}
+	return -1
+}
+
+func stageName(stages []Stage, index int) string {
+	if stages[index].Name != "" {
+		return stages[index].Name
+	}
+	return strconv.Itoa(index)
+}
+
+// normalize collapses the whitespaces of an instruction and upper cases its keyword
+func normalize(instruction string) string {
+	fields := strings.Fields(instruction)
+	if len(fields) == 0 {
+		return ""
+	}
+	fields[0] = strings.ToUpper(fields[0])
+	return strings.Join(fields, " ")
+}
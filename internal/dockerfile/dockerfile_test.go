@@ -0,0 +1,110 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerfile
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParse(t *testing.T) {
+	stages, err := Parse(strings.NewReader(`FROM --platform=linux/amd64 golang:1.15 AS builder
+RUN go build
+
+from alpine:3.12 as base
+FROM base
+COPY --from=builder /app /app
+`), nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, stages, []Stage{
+		{Name: "builder", BaseImage: "golang:1.15", Line: 1, Instructions: []Instruction{
+			{Line: 2, Text: "RUN go build", Expanded: "RUN go build"},
+		}},
+		{Name: "base", BaseImage: "alpine:3.12", Line: 4},
+		{BaseImage: "base", Line: 5, Instructions: []Instruction{
+			{Line: 6, Text: "COPY --from=builder /app /app", Expanded: "COPY --from=builder /app /app", From: "builder"},
+		}},
+	})
+	assert.Equal(t, BaseImage(stages), "alpine:3.12")
+}
+
+func TestParseBuildArgs(t *testing.T) {
+	dockerfile := `# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.15
+ARG BASE=alpine:3.12
+FROM golang:${GO_VERSION} AS builder
+ARG TARGET=app
+RUN go build -o /${TARGET} \
+    # the comments are ignored
+    ./cmd/${TARGET}
+
+FROM $BASE
+ARG BASE
+ARG CURL_VERSION
+RUN apk add curl=${CURL_VERSION:-7.79.1-r0} && echo $$ $HOME
+COPY --from=0 /app /app
+`
+	stages, err := Parse(strings.NewReader(dockerfile), map[string]string{"GO_VERSION": "1.17", "TARGET": "server"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, stages, []Stage{
+		{Name: "builder", BaseImage: "golang:1.17", Line: 4, Instructions: []Instruction{
+			{Line: 6, Text: "RUN go build -o /${TARGET} ./cmd/${TARGET}", Expanded: "RUN go build -o /server ./cmd/server"},
+		}},
+		{BaseImage: "alpine:3.12", Line: 10, Instructions: []Instruction{
+			{Line: 13, Text: "RUN apk add curl=${CURL_VERSION:-7.79.1-r0} && echo $$ $HOME", Expanded: "RUN apk add curl=7.79.1-r0 && echo $$ ${HOME}"},
+			{Line: 14, Text: "COPY --from=0 /app /app", Expanded: "COPY --from=0 /app /app", From: "0"},
+		}},
+	})
+}
+
+func TestLocate(t *testing.T) {
+	stages, err := Parse(strings.NewReader(`ARG BASE=alpine:3.12
+FROM golang:1.17 AS builder
+ARG VERSION=1.2.0
+RUN go install example.com/tool@v${VERSION}
+
+FROM ${BASE}
+RUN apk add curl
+COPY --from=builder /go/bin/tool /usr/local/bin/tool
+`), nil)
+	assert.NilError(t, err)
+
+	location, ok := Locate(stages, "RUN  apk add curl")
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, location, Location{Stage: "1", Line: 7, Instruction: "RUN apk add curl"})
+
+	// the files copied from a previous stage are located in this stage
+	location, ok = Locate(stages, "COPY --from=builder /go/bin/tool /usr/local/bin/tool")
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, location, Location{Stage: "builder", Line: 8, Instruction: "COPY --from=builder /go/bin/tool /usr/local/bin/tool"})
+
+	// the instructions are matched with their build args resolved too
+	location, ok = Locate(stages, "RUN go install example.com/tool@v1.2.0")
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, location, Location{Stage: "builder", Line: 4, Instruction: "RUN go install example.com/tool@v${VERSION}"})
+
+	_, ok = Locate(stages, "RUN apk add wget")
+	assert.Assert(t, !ok)
+	_, ok = Locate(stages, "")
+	assert.Assert(t, !ok)
+}
+
+func TestBaseImageWithoutStages(t *testing.T) {
+	assert.Equal(t, BaseImage(nil), "")
+}
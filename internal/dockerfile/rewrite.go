@@ -0,0 +1,150 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Change is a FROM instruction rewritten to another base image
+type Change struct {
+	Line   int
+	Before string
+	After  string
+}
+
+// RewriteBaseImages replaces the images of the FROM instructions starting at the given lines. The images using build
+// args, or written on a continuation line, are left untouched and missing from the changes.
+func RewriteBaseImages(content []byte, images map[int]string) ([]byte, []Change) {
+	lines := strings.Split(string(content), "\n")
+	var changes []Change
+	for i, line := range lines {
+		image, ok := images[i+1]
+		if !ok {
+			continue
+		}
+		start, end := fromImageSpan(line)
+		if start < 0 || strings.Contains(line[start:end], "$") || line[start:end] == image {
+			continue
+		}
+		changes = append(changes, Change{Line: i + 1, Before: line[start:end], After: image})
+		lines[i] = line[:start] + image + line[end:]
+	}
+	return []byte(strings.Join(lines, "\n")), changes
+}
+
+// fromImageSpan returns the position of the image in the line of a FROM instruction, or -1
+func fromImageSpan(line string) (int, int) {
+	keyword := true
+	for _, span := range fieldSpans(line) {
+		field := line[span[0]:span[1]]
+		switch {
+		case keyword && !strings.EqualFold(field, "FROM"):
+			return -1, -1
+		case keyword:
+			keyword = false
+		case field == "\\":
+			return -1, -1
+		case !strings.HasPrefix(field, "--"):
+			return span[0], span[1]
+		}
+	}
+	return -1, -1
+}
+
+// fieldSpans returns the start and end positions of the fields of the line separated by whitespaces
+func fieldSpans(line string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range line {
+		space := r == ' ' || r == '\t' || r == '\r'
+		switch {
+		case space && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		case !space && start < 0:
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(line)})
+	}
+	return spans
+}
+
+// Patch returns the unified diff of a file whose lines were rewritten in place, with 3 lines of context
+func Patch(name string, before, after []byte) string {
+	oldLines := splitLines(before)
+	newLines := splitLines(after)
+	if len(oldLines) != len(newLines) {
+		return ""
+	}
+	const context = 3
+	var b strings.Builder
+	for i := 0; i < len(oldLines); i++ {
+		if oldLines[i] == newLines[i] {
+			continue
+		}
+		// the hunk lasts until 2 × context unchanged lines follow a change
+		start, end := i-context, i
+		if start < 0 {
+			start = 0
+		}
+		for j := i; j < len(oldLines) && j <= end+2*context; j++ {
+			if oldLines[j] != newLines[j] {
+				end = j
+			}
+		}
+		last := end + context
+		if last >= len(oldLines) {
+			last = len(oldLines) - 1
+		}
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", name, name)
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", start+1, last-start+1, start+1, last-start+1)
+		writeHunk(&b, oldLines[start:last+1], newLines[start:last+1])
+		i = last
+	}
+	return b.String()
+}
+
+// writeHunk writes the unchanged lines as context, and each run of rewritten lines as removals followed by additions
+func writeHunk(b *strings.Builder, oldLines, newLines []string) {
+	for i := 0; i < len(oldLines); i++ {
+		if oldLines[i] == newLines[i] {
+			fmt.Fprintf(b, " %s\n", oldLines[i])
+			continue
+		}
+		end := i
+		for end < len(oldLines) && oldLines[end] != newLines[end] {
+			end++
+		}
+		for _, line := range oldLines[i:end] {
+			fmt.Fprintf(b, "-%s\n", line)
+		}
+		for _, line := range newLines[i:end] {
+			fmt.Fprintf(b, "+%s\n", line)
+		}
+		i = end - 1
+	}
+}
+
+func splitLines(content []byte) []string {
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
@@ -0,0 +1,70 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerfile
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestRewriteBaseImages(t *testing.T) {
+	content := []byte(`ARG VERSION=3.12
+FROM --platform=linux/amd64 golang:1.15 AS builder
+RUN go build
+
+FROM alpine:${VERSION}
+from	node:14  as  app
+COPY --from=builder /app /app
+`)
+	rewritten, changes := RewriteBaseImages(content, map[int]string{
+		2: "golang:1.15@sha256:0123",
+		5: "alpine:3.13",
+		6: "node:14.17-alpine",
+	})
+	assert.DeepEqual(t, changes, []Change{
+		{Line: 2, Before: "golang:1.15", After: "golang:1.15@sha256:0123"},
+		{Line: 6, Before: "node:14", After: "node:14.17-alpine"},
+	})
+	assert.Equal(t, string(rewritten), `ARG VERSION=3.12
+FROM --platform=linux/amd64 golang:1.15@sha256:0123 AS builder
+RUN go build
+
+FROM alpine:${VERSION}
+from	node:14.17-alpine  as  app
+COPY --from=builder /app /app
+`)
+}
+
+func TestPatch(t *testing.T) {
+	before := []byte("FROM golang:1.15 AS builder\nRUN go build\n\nFROM alpine:3.12\nCOPY --from=builder /app /app\nUSER app\nEXPOSE 80\nENTRYPOINT [\"/app\"]\n")
+	after := []byte("FROM golang:1.16 AS builder\nRUN go build\n\nFROM alpine:3.13\nCOPY --from=builder /app /app\nUSER app\nEXPOSE 80\nENTRYPOINT [\"/app\"]\n")
+	assert.Equal(t, Patch("Dockerfile", before, after), `--- a/Dockerfile
++++ b/Dockerfile
+@@ -1,7 +1,7 @@
+-FROM golang:1.15 AS builder
++FROM golang:1.16 AS builder
+ RUN go build
+ 
+-FROM alpine:3.12
++FROM alpine:3.13
+ COPY --from=builder /app /app
+ USER app
+ EXPOSE 80
+`)
+	assert.Equal(t, Patch("Dockerfile", before, before), "")
+}
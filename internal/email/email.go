@@ -0,0 +1,160 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Server is the SMTP server used to send the messages
+type Server struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// RootCAs are the certificates trusted for STARTTLS, the system ones when nil
+	RootCAs *x509.CertPool
+}
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// Message is an HTML email
+type Message struct {
+	From        string
+	To          []string
+	Subject     string
+	HTML        []byte
+	Attachments []Attachment
+}
+
+// Bytes returns the MIME encoded message, with the HTML body and the attachments as parts of a multipart/mixed message
+func (m Message) Bytes() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	writer := multipart.NewWriter(buf)
+	fmt.Fprintf(buf, "From: %s\r\n", m.From)
+	fmt.Fprintf(buf, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+
+	if err := writePart(writer, textproto.MIMEHeader{"Content-Type": {"text/html; charset=utf-8"}}, m.HTML); err != nil {
+		return nil, err
+	}
+	for _, attachment := range m.Attachments {
+		header := textproto.MIMEHeader{
+			"Content-Type":        {attachment.ContentType},
+			"Content-Disposition": {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+		}
+		if err := writePart(writer, header, attachment.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writePart writes a base64 encoded part, wrapped at 76 characters per line
+func writePart(writer *multipart.Writer, header textproto.MIMEHeader, content []byte) error {
+	header.Set("Content-Transfer-Encoding", "base64")
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(part, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = fmt.Fprintf(part, "%s\r\n", encoded)
+	return err
+}
+
+// Send delivers the message with the SMTP server, using STARTTLS when the server supports it
+func Send(server Server, message Message) error {
+	buf, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := send(server, message.From, message.To, buf); err != nil {
+		return fmt.Errorf("failed to send the report by email: %s", err)
+	}
+	return nil
+}
+
+// send works like smtp.SendMail, trusting the root CAs of the server for STARTTLS
+func send(server Server, from string, to []string, msg []byte) error {
+	client, err := smtp.Dial(net.JoinHostPort(server.Host, strconv.Itoa(server.Port)))
+	if err != nil {
+		return err
+	}
+	//nolint: errcheck
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		config := &tls.Config{
+			ServerName: server.Host,
+			RootCAs:    server.RootCAs,
+			MinVersion: tls.VersionTLS12,
+		}
+		if err := client.StartTLS(config); err != nil {
+			return err
+		}
+	}
+	if server.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", server.Username, server.Password, server.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(msg); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
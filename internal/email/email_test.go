@@ -0,0 +1,151 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestMessageBytes(t *testing.T) {
+	message := Message{
+		From:    "scan@example.com",
+		To:      []string{"security@example.com", "devs@example.com"},
+		Subject: "Scan report of myimage: 2 findings",
+		HTML:    []byte("<html><body>report</body></html>"),
+		Attachments: []Attachment{
+			{Name: "report.pdf", ContentType: "application/pdf", Content: []byte("%PDF-1.4")},
+		},
+	}
+	buf, err := message.Bytes()
+	assert.NilError(t, err)
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(buf))
+	assert.NilError(t, err)
+	assert.Equal(t, parsed.Header.Get("To"), "security@example.com, devs@example.com")
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	assert.NilError(t, err)
+	assert.Equal(t, subject, "Scan report of myimage: 2 findings")
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	assert.NilError(t, err)
+	assert.Equal(t, mediaType, "multipart/mixed")
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+
+	part, err := reader.NextRawPart()
+	assert.NilError(t, err)
+	assert.Equal(t, part.Header.Get("Content-Type"), "text/html; charset=utf-8")
+	assert.Equal(t, part.Header.Get("Content-Transfer-Encoding"), "base64")
+
+	part, err = reader.NextPart()
+	assert.NilError(t, err)
+	assert.Equal(t, part.FileName(), "report.pdf")
+	content, err := ioutil.ReadAll(part)
+	assert.NilError(t, err)
+	assert.Assert(t, len(content) > 0)
+}
+
+func TestSendTrustsRootCAs(t *testing.T) {
+	// the certificate of the test TLS server is only trusted when given as root CA
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(tlsServer.Certificate())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer listener.Close() //nolint: errcheck
+	received := make(chan string, 2)
+	go serveSMTP(listener, tlsServer.TLS.Certificates, received)
+
+	port, err := strconv.Atoi(strings.Split(listener.Addr().String(), ":")[1])
+	assert.NilError(t, err)
+	server := Server{Host: "127.0.0.1", Port: port}
+	message := Message{From: "scan@example.com", To: []string{"team@example.com"}, Subject: "report", HTML: []byte("<p>report</p>")}
+
+	err = Send(server, message)
+	assert.ErrorContains(t, err, "failed to send the report by email")
+
+	server.RootCAs = roots
+	assert.NilError(t, Send(server, message))
+	assert.Equal(t, <-received, "team@example.com")
+}
+
+// serveSMTP accepts SMTP sessions upgraded with STARTTLS and sends the recipients of the delivered messages
+func serveSMTP(listener net.Listener, certificates []tls.Certificate, received chan<- string) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close() //nolint: errcheck
+			text := textproto.NewConn(conn)
+			_ = text.PrintfLine("220 localhost ESMTP")
+			var recipient string
+			for {
+				line, err := text.ReadLine()
+				if err != nil {
+					return
+				}
+				command := strings.ToUpper(strings.Fields(line + " ")[0])
+				switch command {
+				case "EHLO":
+					_ = text.PrintfLine("250-localhost")
+					_ = text.PrintfLine("250 STARTTLS")
+				case "STARTTLS":
+					_ = text.PrintfLine("220 ready")
+					tlsConn := tls.Server(conn, &tls.Config{Certificates: certificates})
+					if err := tlsConn.Handshake(); err != nil {
+						return
+					}
+					conn = tlsConn
+					text = textproto.NewConn(tlsConn)
+				case "RCPT":
+					recipient = strings.Trim(strings.SplitN(line, ":", 2)[1], "<> ")
+					_ = text.PrintfLine("250 OK")
+				case "DATA":
+					_ = text.PrintfLine("354 go ahead")
+					if _, err := ioutil.ReadAll(text.DotReader()); err != nil {
+						return
+					}
+					received <- recipient
+					_ = text.PrintfLine("250 OK")
+				case "QUIT":
+					_ = text.PrintfLine("221 bye")
+					return
+				default:
+					_ = text.PrintfLine("250 OK")
+				}
+			}
+		}()
+	}
+}
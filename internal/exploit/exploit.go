@@ -0,0 +1,216 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package exploit enriches the vulnerabilities with the likelihood they are exploited: their EPSS score and whether
+// they are known to be exploited in the wild
+package exploit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	cliConfig "github.com/docker/cli/cli/config"
+	"github.com/docker/scan-cli-plugin/internal/filelock"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+const (
+	// DefaultEPSSURL is the API of the Exploit Prediction Scoring System of FIRST
+	DefaultEPSSURL = "https://api.first.org/data/v1/epss"
+	// DefaultKEVURL is the catalog of the Known Exploited Vulnerabilities of CISA
+	DefaultKEVURL = "https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json"
+
+	// epssBatch is the number of CVEs requested at once to the EPSS API
+	epssBatch = 100
+	// kevMaxAge is how long the KEV catalog is cached, CISA updating it a few times a week
+	kevMaxAge = 24 * time.Hour
+)
+
+// Enricher adds exploit information to the vulnerabilities
+type Enricher interface {
+	Enrich(ctx context.Context, exploits map[string]*report.Exploit) error
+}
+
+// Enrich sets the exploit information of the vulnerabilities with each enricher, the information of a vulnerability
+// with several CVEs being the one of its most exploitable CVE
+func Enrich(ctx context.Context, vulns []report.Vulnerability, enrichers ...Enricher) error {
+	exploits := map[string]*report.Exploit{}
+	for _, vuln := range vulns {
+		for _, id := range vuln.CVEs() {
+			if strings.HasPrefix(id, "CVE-") {
+				exploits[id] = &report.Exploit{}
+			}
+		}
+	}
+	if len(exploits) == 0 {
+		return nil
+	}
+	for _, enricher := range enrichers {
+		if err := enricher.Enrich(ctx, exploits); err != nil {
+			return err
+		}
+	}
+	for i, vuln := range vulns {
+		var merged *report.Exploit
+		for _, id := range vuln.CVEs() {
+			exploit, ok := exploits[id]
+			if !ok {
+				continue
+			}
+			if merged == nil {
+				merged = &report.Exploit{}
+			}
+			if exploit.EPSS > merged.EPSS {
+				merged.EPSS, merged.Percentile = exploit.EPSS, exploit.Percentile
+			}
+			merged.KnownExploited = merged.KnownExploited || exploit.KnownExploited
+		}
+		vulns[i].Exploit = merged
+	}
+	return nil
+}
+
+// EPSS sets the EPSS score of the CVEs, the probability they are exploited in the next 30 days
+type EPSS struct {
+	Client *http.Client
+	URL    string
+}
+
+// Enrich implements Enricher
+func (e EPSS) Enrich(ctx context.Context, exploits map[string]*report.Exploit) error {
+	var ids []string
+	for id := range exploits {
+		ids = append(ids, id)
+	}
+	for start := 0; start < len(ids); start += epssBatch {
+		end := start + epssBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := e.enrichBatch(ctx, ids[start:end], exploits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e EPSS) enrichBatch(ctx context.Context, ids []string, exploits map[string]*report.Exploit) error {
+	body, err := get(ctx, e.Client, e.URL+"?cve="+url.QueryEscape(strings.Join(ids, ",")))
+	if err != nil {
+		return fmt.Errorf("cannot get the EPSS scores: %s", err)
+	}
+	var document struct {
+		Data []struct {
+			CVE        string `json:"cve"`
+			EPSS       string `json:"epss"`
+			Percentile string `json:"percentile"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return fmt.Errorf("invalid EPSS API response: %s", err)
+	}
+	for _, score := range document.Data {
+		exploit, ok := exploits[score.CVE]
+		if !ok {
+			continue
+		}
+		exploit.EPSS, _ = strconv.ParseFloat(score.EPSS, 64)
+		exploit.Percentile, _ = strconv.ParseFloat(score.Percentile, 64)
+	}
+	return nil
+}
+
+// KEV flags the CVEs of the catalog of the vulnerabilities known to be exploited in the wild, cached in CacheDir
+type KEV struct {
+	Client   *http.Client
+	URL      string
+	CacheDir string
+}
+
+// DefaultCacheDir returns the directory of the docker scan configuration where the KEV catalog is cached
+func DefaultCacheDir() string {
+	return filepath.Join(cliConfig.Dir(), "scan", "exploit-cache")
+}
+
+// Enrich implements Enricher
+func (k KEV) Enrich(ctx context.Context, exploits map[string]*report.Exploit) error {
+	body, err := k.catalog(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get the catalog of the known exploited vulnerabilities: %s", err)
+	}
+	var document struct {
+		Vulnerabilities []struct {
+			CVEID string `json:"cveID"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return fmt.Errorf("invalid catalog of the known exploited vulnerabilities: %s", err)
+	}
+	for _, vuln := range document.Vulnerabilities {
+		if exploit, ok := exploits[vuln.CVEID]; ok {
+			exploit.KnownExploited = true
+		}
+	}
+	return nil
+}
+
+// catalog returns the cached catalog while it is recent enough, or downloads it again
+func (k KEV) catalog(ctx context.Context) ([]byte, error) {
+	path := ""
+	if k.CacheDir != "" {
+		path = filepath.Join(k.CacheDir, "kev.json")
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < kevMaxAge {
+			if body, err := ioutil.ReadFile(path); err == nil {
+				return body, nil
+			}
+		}
+	}
+	body, err := get(ctx, k.Client, k.URL)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		// the catalog is downloaded again on the next scan when it can't be cached, it is written atomically as the
+		// concurrent scans read it
+		_ = filelock.WriteFile(path, body, 0644)
+	}
+	return body, nil
+}
+
+func get(ctx context.Context, client *http.Client, endpoint string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", endpoint, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
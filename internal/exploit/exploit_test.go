@@ -0,0 +1,85 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package exploit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestEnrich(t *testing.T) {
+	var requested []string
+	epssServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = strings.Split(r.URL.Query().Get("cve"), ",")
+		fmt.Fprint(w, `{"status":"OK","data":[
+  {"cve":"CVE-2021-44228","epss":"0.975560000","percentile":"0.999950000"},
+  {"cve":"CVE-2021-45046","epss":"0.973330000","percentile":"0.998900000"},
+  {"cve":"CVE-2019-14697","epss":"0.012340000","percentile":"0.850000000"}
+]}`)
+	}))
+	defer epssServer.Close()
+	kevRequests := 0
+	kevServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		kevRequests++
+		fmt.Fprint(w, `{"vulnerabilities":[{"cveID":"CVE-2021-44228","dateAdded":"2021-12-10"}]}`)
+	}))
+	defer kevServer.Close()
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+
+	vulns := []report.Vulnerability{
+		{ID: "SNYK-JAVA-ORGAPACHELOGGINGLOG4J-2314720", Identifiers: map[string][]string{"CVE": {"CVE-2021-44228", "CVE-2021-45046"}}},
+		{ID: "CVE-2019-14697"},
+		{ID: "SNYK-ALPINE310-CURL-1"},
+	}
+	enrichers := []Enricher{
+		EPSS{Client: epssServer.Client(), URL: epssServer.URL},
+		KEV{Client: kevServer.Client(), URL: kevServer.URL, CacheDir: dir.Path()},
+	}
+	assert.NilError(t, Enrich(context.Background(), vulns, enrichers...))
+	assert.Equal(t, len(requested), 3)
+	assert.DeepEqual(t, vulns[0].Exploit, &report.Exploit{EPSS: 0.97556, Percentile: 0.99995, KnownExploited: true})
+	assert.DeepEqual(t, vulns[1].Exploit, &report.Exploit{EPSS: 0.01234, Percentile: 0.85})
+	assert.Assert(t, vulns[2].Exploit == nil)
+
+	// the catalog is cached
+	assert.NilError(t, Enrich(context.Background(), vulns, enrichers[1]))
+	assert.Equal(t, kevRequests, 1)
+	_, err := os.Stat(filepath.Join(dir.Path(), "kev.json"))
+	assert.NilError(t, err)
+}
+
+func TestEnrichFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	vulns := []report.Vulnerability{{ID: "CVE-2019-14697"}}
+	err := Enrich(context.Background(), vulns, EPSS{Client: server.Client(), URL: server.URL})
+	assert.ErrorContains(t, err, "cannot get the EPSS scores: "+server.URL)
+	assert.ErrorContains(t, err, "returned 503 Service Unavailable")
+}
@@ -0,0 +1,60 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+const backstageAnnotationPrefix = "docker.com/scan-"
+
+type backstageEntity struct {
+	Metadata struct {
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+}
+
+// WriteBackstage writes the summary of the scan as the annotations of a Backstage entity, to be merged in its catalog-info.yaml
+func WriteBackstage(out io.Writer, scan Scan) error {
+	counts := map[string]int{}
+	for _, finding := range scan.Findings {
+		counts[strings.ToLower(finding.Severity)]++
+	}
+	status := "passed"
+	if len(scan.Findings) > 0 {
+		status = "failed"
+	}
+	var entity backstageEntity
+	entity.Metadata.Annotations = map[string]string{
+		backstageAnnotationPrefix + "image":    scan.Image,
+		backstageAnnotationPrefix + "date":     scan.ScannedAt.UTC().Format(time.RFC3339),
+		backstageAnnotationPrefix + "status":   status,
+		backstageAnnotationPrefix + "findings": strconv.Itoa(len(scan.Findings)),
+	}
+	for _, severity := range report.Severities {
+		entity.Metadata.Annotations[backstageAnnotationPrefix+severity] = strconv.Itoa(counts[severity])
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entity)
+}
@@ -0,0 +1,55 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+const (
+	// BackstageFormat exports the results as Backstage entity annotations
+	BackstageFormat = "backstage"
+	// ServiceNowFormat exports the results in the ServiceNow Vulnerability Response import format
+	ServiceNowFormat = "servicenow"
+)
+
+// Formats lists the supported export formats
+var Formats = []string{BackstageFormat, ServiceNowFormat}
+
+// Scan holds the results of the scan of an image to be exported
+type Scan struct {
+	Image     string
+	ScannedAt time.Time
+	Findings  []report.Vulnerability
+}
+
+// Write exports the scan in the given format
+func Write(out io.Writer, format string, scan Scan) error {
+	switch format {
+	case BackstageFormat:
+		return WriteBackstage(out, scan)
+	case ServiceNowFormat:
+		return WriteServiceNow(out, scan)
+	default:
+		return fmt.Errorf("unsupported export format %q, supported formats are %s", format, strings.Join(Formats, ", "))
+	}
+}
@@ -0,0 +1,83 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+)
+
+var testScan = Scan{
+	Image:     "myimage:1.0",
+	ScannedAt: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	Findings: []report.Vulnerability{
+		{
+			ID:          "SNYK-DEBIAN10-OPENSSL-1075326",
+			Title:       "NULL Pointer Dereference",
+			Severity:    "high",
+			PackageName: "openssl",
+			Version:     "1.1.1d-0+deb10u3",
+			FixedIn:     []string{"1.1.1d-0+deb10u4"},
+			Identifiers: map[string][]string{"CVE": {"CVE-2020-1971"}},
+		},
+		{
+			ID:       "DS001",
+			Type:     report.SecretType,
+			Title:    "AWS access key",
+			Severity: "critical",
+			Path:     "/app/.env",
+		},
+	},
+}
+
+func TestWriteBackstage(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, Write(out, BackstageFormat, testScan))
+	assert.Equal(t, out.String(), `{
+  "metadata": {
+    "annotations": {
+      "docker.com/scan-critical": "1",
+      "docker.com/scan-date": "2021-06-01T12:00:00Z",
+      "docker.com/scan-findings": "2",
+      "docker.com/scan-high": "1",
+      "docker.com/scan-image": "myimage:1.0",
+      "docker.com/scan-low": "0",
+      "docker.com/scan-medium": "0",
+      "docker.com/scan-status": "failed"
+    }
+  }
+}
+`)
+}
+
+func TestWriteServiceNow(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, Write(out, ServiceNowFormat, testScan))
+	assert.Equal(t, out.String(), `Vulnerability ID,Configuration Item,Source,Severity,Summary,Package,Installed Version,Fixed In,Path,First Found
+CVE-2020-1971,myimage:1.0,Docker Scan,High,NULL Pointer Dereference,openssl,1.1.1d-0+deb10u3,1.1.1d-0+deb10u4,,2021-06-01T12:00:00Z
+DS001,myimage:1.0,Docker Scan,Critical,AWS access key,,,,/app/.env,2021-06-01T12:00:00Z
+`)
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	err := Write(bytes.NewBuffer(nil), "jira", testScan)
+	assert.ErrorContains(t, err, `unsupported export format "jira"`)
+}
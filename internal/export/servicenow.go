@@ -0,0 +1,66 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+)
+
+var serviceNowHeader = []string{
+	"Vulnerability ID", "Configuration Item", "Source", "Severity", "Summary",
+	"Package", "Installed Version", "Fixed In", "Path", "First Found",
+}
+
+// WriteServiceNow writes the findings as a CSV file to be imported with the ServiceNow Vulnerability Response
+// generic integration, one row per CVE and finding
+func WriteServiceNow(out io.Writer, scan Scan) error {
+	writer := csv.NewWriter(out)
+	if err := writer.Write(serviceNowHeader); err != nil {
+		return err
+	}
+	for _, finding := range scan.Findings {
+		for _, id := range finding.CVEs() {
+			row := []string{
+				id,
+				scan.Image,
+				"Docker Scan",
+				capitalize(finding.Severity),
+				finding.Title,
+				finding.PackageName,
+				finding.Version,
+				strings.Join(finding.FixedIn, " "),
+				finding.Path,
+				scan.ScannedAt.UTC().Format(time.RFC3339),
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func capitalize(severity string) string {
+	if severity == "" {
+		return ""
+	}
+	return strings.ToUpper(severity[:1]) + strings.ToLower(severity[1:])
+}
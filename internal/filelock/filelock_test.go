@@ -0,0 +1,67 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package filelock
+
+import (
+	"io/ioutil"
+	"strconv"
+	"sync"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestUpdateConcurrently(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	path := dir.Join("scan", "counter")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				err := Update(path, 0644, func(current []byte) ([]byte, error) {
+					count, _ := strconv.Atoi(string(current))
+					return []byte(strconv.Itoa(count + 1)), nil
+				})
+				assert.Check(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	buf, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), "100")
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithFile("results.json", "previous"))
+	defer dir.Remove()
+
+	assert.NilError(t, WriteFile(dir.Join("results.json"), []byte("{}"), 0600))
+	buf, err := ioutil.ReadFile(dir.Join("results.json"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), "{}")
+	// no temporary file is left
+	files, err := ioutil.ReadDir(dir.Path())
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 1)
+}
@@ -0,0 +1,116 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package findingage
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cliConfig "github.com/docker/cli/cli/config"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/internal/filelock"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// DefaultPath returns the file of the docker scan configuration where the first scans finding each issue are recorded
+func DefaultPath() string {
+	return filepath.Join(cliConfig.Dir(), "scan", "findings.json")
+}
+
+// Track sets when each finding was first seen in the repository of the image, recording the new findings as seen now.
+// The findings which are not found anymore are forgotten, so a finding coming back is tracked again from now.
+func Track(path, image string, findings []report.Vulnerability, now time.Time) ([]report.Vulnerability, error) {
+	unlock, err := filelock.Lock(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	tracked, images, err := track(path, image, findings, now)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := json.Marshal(images)
+	if err != nil {
+		return nil, err
+	}
+	return tracked, filelock.WriteFile(path, buf, 0644)
+}
+
+// Lookup sets when each finding was first seen like Track, without recording the new findings, for the read-only
+// configurations
+func Lookup(path, image string, findings []report.Vulnerability, now time.Time) ([]report.Vulnerability, error) {
+	tracked, _, err := track(path, image, findings, now)
+	return tracked, err
+}
+
+func track(path, image string, findings []report.Vulnerability, now time.Time) ([]report.Vulnerability, map[string]map[string]time.Time, error) {
+	images, err := read(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	previous := images[repository(image)]
+	current := map[string]time.Time{}
+	tracked := make([]report.Vulnerability, len(findings))
+	for i, finding := range findings {
+		key := findingKey(finding)
+		firstSeen, ok := current[key]
+		if !ok {
+			if firstSeen, ok = previous[key]; !ok {
+				firstSeen = now.UTC()
+			}
+			current[key] = firstSeen
+		}
+		finding.FirstSeen = &firstSeen
+		tracked[i] = finding
+	}
+	images[repository(image)] = current
+	return tracked, images, nil
+}
+
+// repository returns the repository of the image, as its findings are tracked across its tags
+func repository(image string) string {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return image
+	}
+	return named.Name()
+}
+
+// findingKey identifies a finding across the scans, a package upgrade which doesn't fix the finding keeping its age
+func findingKey(finding report.Vulnerability) string {
+	return strings.Join([]string{finding.Type, finding.ID, finding.PackageName, finding.Path}, "|")
+}
+
+func read(path string) (map[string]map[string]time.Time, error) {
+	images := map[string]map[string]time.Time{}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return images, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, &images); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
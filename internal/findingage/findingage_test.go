@@ -0,0 +1,61 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package findingage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestTrack(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	path := dir.Join("scan", "findings.json")
+	monday := time.Date(2021, time.March, 1, 9, 0, 0, 0, time.UTC)
+	openssl := report.Vulnerability{ID: "SNYK-DEBIAN10-OPENSSL-1075326", Severity: "critical", PackageName: "openssl", Version: "1.1.1d"}
+	curl := report.Vulnerability{ID: "SNYK-DEBIAN10-CURL-1585138", Severity: "low", PackageName: "curl", Version: "7.64.0"}
+
+	tracked, err := Track(path, "myapp:1.0", []report.Vulnerability{openssl, curl}, monday)
+	assert.NilError(t, err)
+	assert.Equal(t, *tracked[0].FirstSeen, monday)
+	assert.Equal(t, tracked[0].OpenDays(monday), 0)
+
+	// a new tag keeps the age of the findings of the repository, even with an upgraded package
+	nextWeek := monday.AddDate(0, 0, 8)
+	openssl.Version = "1.1.1k"
+	tracked, err = Track(path, "docker.io/library/myapp:1.1", []report.Vulnerability{openssl}, nextWeek)
+	assert.NilError(t, err)
+	assert.Equal(t, *tracked[0].FirstSeen, monday)
+	assert.Equal(t, tracked[0].OpenDays(nextWeek), 8)
+
+	// a lookup doesn't record the new findings
+	tracked, err = Lookup(path, "myapp:1.2", []report.Vulnerability{openssl, curl}, nextWeek.Add(time.Hour))
+	assert.NilError(t, err)
+	assert.Equal(t, *tracked[0].FirstSeen, monday)
+	assert.Equal(t, *tracked[1].FirstSeen, nextWeek.Add(time.Hour))
+
+	// the fixed curl finding is tracked again from now when it comes back
+	tracked, err = Track(path, "myapp:1.2", []report.Vulnerability{openssl, curl}, nextWeek)
+	assert.NilError(t, err)
+	assert.Equal(t, *tracked[0].FirstSeen, monday)
+	assert.Equal(t, *tracked[1].FirstSeen, nextWeek)
+	assert.Equal(t, curl.OpenDays(nextWeek), 0)
+}
@@ -0,0 +1,136 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package fingerprint
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// maxContentSize is the maximum size of the binaries matched against the signature patterns
+const maxContentSize = 64 * 1024 * 1024
+
+var magics = []struct {
+	format string
+	magic  []byte
+}{
+	{format: "elf", magic: []byte{0x7f, 'E', 'L', 'F'}},
+	{format: "pe", magic: []byte{'M', 'Z'}},
+	{format: "macho", magic: []byte{0xcf, 0xfa, 0xed, 0xfe}},
+	{format: "macho", magic: []byte{0xce, 0xfa, 0xed, 0xfe}},
+}
+
+// Scan walks the files of the extracted image and identifies the binaries not owned by an OS package.
+// A file overwritten by a more recent layer is only reported once.
+func Scan(extracted *image.ExtractedImage, db *Database) ([]report.Binary, error) {
+	packaged := packagedFiles(extracted)
+	seen := map[string]bool{}
+	binaries := []report.Binary{}
+	for index := len(extracted.LayerIDs) - 1; index >= 0; index-- {
+		root := extracted.LayerDir(index)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			relPath, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			imagePath := "/" + filepath.ToSlash(relPath)
+			if seen[imagePath] {
+				return nil
+			}
+			seen[imagePath] = true
+			if packaged[imagePath] {
+				return nil
+			}
+			binary, ok, err := identify(path, info.Size(), db)
+			if err != nil || !ok {
+				return err
+			}
+			binary.Path = imagePath
+			binary.Layer = extracted.LayerIDs[index]
+			binaries = append(binaries, binary)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(binaries, func(i, j int) bool {
+		return binaries[i].Path < binaries[j].Path
+	})
+	return binaries, nil
+}
+
+func identify(path string, size int64, db *Database) (report.Binary, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return report.Binary{}, false, err
+	}
+	//nolint: errcheck
+	defer f.Close()
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(f, header); err != nil {
+		// files smaller than the magic numbers are not binaries
+		return report.Binary{}, false, nil
+	}
+	format := binaryFormat(header)
+	if format == "" {
+		return report.Binary{}, false, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return report.Binary{}, false, err
+	}
+
+	hash := sha256.New()
+	var content []byte
+	if size <= maxContentSize {
+		if content, err = ioutil.ReadAll(io.TeeReader(f, hash)); err != nil {
+			return report.Binary{}, false, err
+		}
+	} else if _, err := io.Copy(hash, f); err != nil {
+		return report.Binary{}, false, err
+	}
+	binary := report.Binary{
+		Format: format,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	}
+	binary.Project, binary.Version = db.Identify(binary.SHA256, content)
+	return binary, true, nil
+}
+
+func binaryFormat(header []byte) string {
+	for _, magic := range magics {
+		if bytes.HasPrefix(header, magic.magic) {
+			return magic.format
+		}
+	}
+	return ""
+}
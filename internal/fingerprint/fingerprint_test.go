@@ -0,0 +1,79 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package fingerprint
+
+import (
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+const elfHeader = "\x7fELF\x02\x01\x01"
+
+func TestScan(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("0",
+			fs.WithDir("bin", fs.WithFile("busybox", elfHeader+"BusyBox v1.31.1 (2020-01-01)")),
+			fs.WithDir("lib", fs.WithDir("apk", fs.WithDir("db",
+				fs.WithFile("installed", "P:busybox\nF:bin\nR:busybox\n")))),
+			fs.WithDir("usr", fs.WithDir("local", fs.WithDir("bin",
+				fs.WithFile("curl", elfHeader+"curl 7.60.0 (x86_64-pc-linux-gnu)"))))),
+		fs.WithDir("1",
+			fs.WithDir("usr", fs.WithDir("local", fs.WithDir("bin",
+				fs.WithFile("curl", elfHeader+"curl 7.79.1 (x86_64-pc-linux-gnu)"),
+				fs.WithFile("tool", elfHeader+"no signature"),
+				fs.WithFile("script.sh", "#!/bin/sh\necho curl 7.79.1\n"))))))
+	defer dir.Remove()
+
+	db, err := NewDatabase(DefaultSignatures)
+	assert.NilError(t, err)
+	binaries, err := Scan(&image.ExtractedImage{Dir: dir.Path(), LayerIDs: []string{"sha256:base", "sha256:app"}}, db)
+	assert.NilError(t, err)
+	assert.Equal(t, len(binaries), 2)
+	assert.DeepEqual(t, binaries[0], report.Binary{
+		Path:    "/usr/local/bin/curl",
+		Layer:   "sha256:app",
+		Format:  "elf",
+		SHA256:  binaries[0].SHA256,
+		Project: "curl",
+		Version: "7.79.1",
+	})
+	assert.Equal(t, binaries[1].Path, "/usr/local/bin/tool")
+	assert.Equal(t, binaries[1].Project, "")
+}
+
+func TestDatabaseIdentifyByHash(t *testing.T) {
+	db, err := NewDatabase(append([]Signature{{Project: "internal-tool", SHA256: "ABCDEF", Version: "2.0"}}, DefaultSignatures...))
+	assert.NilError(t, err)
+	project, version := db.Identify("abcdef", []byte("BusyBox v1.31.1"))
+	assert.Equal(t, project, "internal-tool")
+	assert.Equal(t, version, "2.0")
+
+	project, version = db.Identify("012345", []byte("BusyBox v1.31.1"))
+	assert.Equal(t, project, "busybox")
+	assert.Equal(t, version, "1.31.1")
+}
+
+func TestNewDatabaseChecksSignatures(t *testing.T) {
+	_, err := NewDatabase([]Signature{{Project: "tool"}})
+	assert.ErrorContains(t, err, "a pattern or a sha256 is required")
+	_, err = NewDatabase([]Signature{{Project: "tool", Pattern: "("}})
+	assert.ErrorContains(t, err, "invalid binary signature for tool")
+}
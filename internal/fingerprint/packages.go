@@ -0,0 +1,92 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package fingerprint
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+)
+
+const (
+	apkDatabase = "lib/apk/db/installed"
+	dpkgInfoDir = "var/lib/dpkg/info"
+)
+
+// packagedFiles lists the files installed by the apk and dpkg package managers,
+// reading their databases from the most recent layer which contains them
+func packagedFiles(extracted *image.ExtractedImage) map[string]bool {
+	files := map[string]bool{}
+	apkFound := false
+	dpkgLists := map[string]bool{}
+	for index := len(extracted.LayerIDs) - 1; index >= 0; index-- {
+		root := extracted.LayerDir(index)
+		if !apkFound {
+			apkFound = readAPKDatabase(filepath.Join(root, filepath.FromSlash(apkDatabase)), files)
+		}
+		lists, _ := filepath.Glob(filepath.Join(root, filepath.FromSlash(dpkgInfoDir), "*.list"))
+		for _, list := range lists {
+			if dpkgLists[filepath.Base(list)] {
+				continue
+			}
+			dpkgLists[filepath.Base(list)] = true
+			readDPKGList(list, files)
+		}
+	}
+	return files
+}
+
+// readAPKDatabase reads the F: (directory) and R: (file) entries of the apk database
+func readAPKDatabase(databasePath string, files map[string]bool) bool {
+	f, err := os.Open(databasePath)
+	if err != nil {
+		return false
+	}
+	//nolint: errcheck
+	defer f.Close()
+	dir := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "F:"):
+			dir = strings.TrimPrefix(line, "F:")
+		case strings.HasPrefix(line, "R:"):
+			files[path.Join("/", dir, strings.TrimPrefix(line, "R:"))] = true
+		}
+	}
+	return true
+}
+
+func readDPKGList(listPath string, files map[string]bool) {
+	f, err := os.Open(listPath)
+	if err != nil {
+		return
+	}
+	//nolint: errcheck
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			files[path.Clean(line)] = true
+		}
+	}
+}
@@ -0,0 +1,108 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package fingerprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// Signature identifies the upstream project of a binary, either by a pattern matched against
+// the binary content and capturing the version, or by the SHA256 of a known binary
+type Signature struct {
+	Project string `json:"project"`
+	Pattern string `json:"pattern,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// DefaultSignatures detects the tools commonly copied as static binaries in images
+var DefaultSignatures = []Signature{
+	{Project: "busybox", Pattern: `BusyBox v(\d+\.\d+(?:\.\d+)?)`},
+	{Project: "curl", Pattern: `\bcurl[ /](\d+\.\d+\.\d+)`},
+	{Project: "openssl", Pattern: `OpenSSL (\d+\.\d+\.\d+[a-z]?)`},
+	{Project: "nginx", Pattern: `nginx/(\d+\.\d+\.\d+)`},
+	{Project: "bash", Pattern: `GNU bash, version (\d+\.\d+\.\d+)`},
+	{Project: "sqlite", Pattern: `SQLite version (\d+\.\d+\.\d+)`},
+	{Project: "go", Pattern: `(?s)Go buildinf:.{14,40}?go(1\.\d+(?:\.\d+)?)`},
+}
+
+// Database matches binaries against signatures
+type Database struct {
+	patterns []compiledSignature
+	hashes   map[string]Signature
+}
+
+type compiledSignature struct {
+	project string
+	pattern *regexp.Regexp
+}
+
+// NewDatabase compiles the signatures, the first matching signature wins
+func NewDatabase(signatures []Signature) (*Database, error) {
+	db := &Database{hashes: map[string]Signature{}}
+	for _, signature := range signatures {
+		switch {
+		case signature.Project == "":
+			return nil, fmt.Errorf("invalid binary signature: missing project")
+		case signature.SHA256 != "":
+			db.hashes[strings.ToLower(signature.SHA256)] = signature
+		case signature.Pattern != "":
+			pattern, err := regexp.Compile(signature.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid binary signature for %s: %s", signature.Project, err)
+			}
+			db.patterns = append(db.patterns, compiledSignature{project: signature.Project, pattern: pattern})
+		default:
+			return nil, fmt.Errorf("invalid binary signature for %s: a pattern or a sha256 is required", signature.Project)
+		}
+	}
+	return db, nil
+}
+
+// LoadSignatures reads signatures from a JSON file, they take precedence over the default ones
+func LoadSignatures(path string) ([]Signature, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var signatures []Signature
+	if err := json.Unmarshal(buf, &signatures); err != nil {
+		return nil, fmt.Errorf("invalid binary signatures file %s: %s", path, err)
+	}
+	return append(signatures, DefaultSignatures...), nil
+}
+
+// Identify returns the project and version of a binary from its hash or its content
+func (db *Database) Identify(sha256 string, content []byte) (string, string) {
+	if signature, ok := db.hashes[sha256]; ok {
+		return signature.Project, signature.Version
+	}
+	for _, signature := range db.patterns {
+		if match := signature.pattern.FindSubmatch(content); match != nil {
+			version := ""
+			if len(match) > 1 {
+				version = string(match[1])
+			}
+			return signature.project, version
+		}
+	}
+	return "", ""
+}
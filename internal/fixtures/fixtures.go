@@ -0,0 +1,327 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package fixtures
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultRepository is the repository prefix of the fixture images
+const DefaultRepository = "docker-scan-testdata"
+
+// Version is the tag of the fixture images, increased when their content changes
+const Version = "1"
+
+// created is the creation date of the fixture images and of their files, so their IDs never change
+var created = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Fixture is a small image with a known set of pinned packages, for the e2e tests and the demos
+type Fixture struct {
+	Name        string
+	Description string
+	Layers      []Layer
+	Cmd         []string
+}
+
+// Layer is a layer of a fixture image, with the instruction it pretends to be created by
+type Layer struct {
+	CreatedBy string
+	// Files maps the paths of the files, without leading slash, to their content
+	Files map[string]string
+}
+
+// Image is a fixture image written to an archive
+type Image struct {
+	Fixture Fixture
+	// Tag is the repository and tag of the image
+	Tag string
+	// ID is the digest of the image configuration, the same on every machine
+	ID string
+}
+
+const alpineOSRelease = `NAME="Alpine Linux"
+ID=alpine
+VERSION_ID=3.10.0
+PRETTY_NAME="Alpine Linux v3.10"
+HOME_URL="https://alpinelinux.org/"
+BUG_REPORT_URL="https://bugs.alpinelinux.org/"
+`
+
+// alpinePackages are packages of Alpine 3.10.0 with known vulnerabilities, like CVE-2019-14697 in musl or
+// CVE-2019-1547 in OpenSSL
+var alpinePackages = [][3]string{
+	{"musl", "1.1.22-r2", "musl"},
+	{"busybox", "1.30.1-r2", "busybox"},
+	{"libcrypto1.1", "1.1.1b-r1", "openssl"},
+	{"libssl1.1", "1.1.1b-r1", "openssl"},
+	{"zlib", "1.2.11-r1", "zlib"},
+	{"apk-tools", "2.10.4-r1", "apk-tools"},
+}
+
+const nodePackageJSON = `{
+  "name": "docker-scan-testdata",
+  "version": "1.0.0",
+  "private": true,
+  "dependencies": {
+    "lodash": "4.17.15",
+    "minimist": "1.2.0"
+  }
+}
+`
+
+// nodePackageLock pins lodash 4.17.15, vulnerable to CVE-2020-8203, and minimist 1.2.0, vulnerable to CVE-2020-7598
+const nodePackageLock = `{
+  "name": "docker-scan-testdata",
+  "version": "1.0.0",
+  "lockfileVersion": 1,
+  "requires": true,
+  "dependencies": {
+    "lodash": {
+      "version": "4.17.15",
+      "resolved": "https://registry.npmjs.org/lodash/-/lodash-4.17.15.tgz",
+      "integrity": "sha512-8xOcRHvCjnocdS5cpwXQXVzmmh5e5+saE2QGoeQmbKmRS6J3VQppPOIt0MnmE+4xlZoumy0GPG0D0MVIQbNA1A=="
+    },
+    "minimist": {
+      "version": "1.2.0",
+      "resolved": "https://registry.npmjs.org/minimist/-/minimist-1.2.0.tgz",
+      "integrity": "sha1-o1AIsg9BOD7sH7kU9M1d95omQoQ="
+    }
+  }
+}
+`
+
+var alpineLayer = Layer{
+	CreatedBy: "ADD alpine-minirootfs-3.10.0.tar.gz /",
+	Files: map[string]string{
+		"etc/os-release":       alpineOSRelease,
+		"etc/alpine-release":   "3.10.0\n",
+		"lib/apk/db/installed": apkInstalled(alpinePackages),
+	},
+}
+
+// Fixtures lists the fixture images
+var Fixtures = []Fixture{
+	{
+		Name:        "alpine-vulnerable",
+		Description: "Alpine 3.10.0 packages with known vulnerabilities",
+		Layers:      []Layer{alpineLayer},
+		Cmd:         []string{"/bin/sh"},
+	},
+	{
+		Name:        "node-vulnerable",
+		Description: "Alpine 3.10.0 packages and a Node.js application with vulnerable lodash and minimist dependencies",
+		Layers: []Layer{alpineLayer, {
+			CreatedBy: "COPY package.json package-lock.json /app/",
+			Files: map[string]string{
+				"app/package.json":      nodePackageJSON,
+				"app/package-lock.json": nodePackageLock,
+			},
+		}},
+		Cmd: []string{"node", "/app/index.js"},
+	},
+	{
+		Name:        "no-vulnerabilities",
+		Description: "A single file without any package",
+		Layers: []Layer{{
+			CreatedBy: "COPY hello.txt /",
+			Files:     map[string]string{"hello.txt": "Hello from docker scan\n"},
+		}},
+		Cmd: []string{"/hello"},
+	},
+}
+
+// apkInstalled returns the database of the installed packages of apk, the package manager of Alpine
+func apkInstalled(packages [][3]string) string {
+	var b strings.Builder
+	for _, p := range packages {
+		fmt.Fprintf(&b, "P:%s\nV:%s\nA:x86_64\no:%s\n\n", p[0], p[1], p[2])
+	}
+	return b.String()
+}
+
+// Find returns the fixtures with the names, all of them without names
+func Find(names []string) ([]Fixture, error) {
+	if len(names) == 0 {
+		return Fixtures, nil
+	}
+	var found []Fixture
+	for _, name := range names {
+		fixture, ok := find(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown fixture %q", name)
+		}
+		found = append(found, fixture)
+	}
+	return found, nil
+}
+
+func find(name string) (Fixture, bool) {
+	for _, fixture := range Fixtures {
+		if fixture.Name == name {
+			return fixture, true
+		}
+	}
+	return Fixture{}, false
+}
+
+// Tag returns the repository and tag of the fixture image under the repository prefix
+func (f Fixture) Tag(repository string) string {
+	return repository + "/" + f.Name + ":" + Version
+}
+
+type imageConfig struct {
+	Architecture string          `json:"architecture"`
+	OS           string          `json:"os"`
+	Created      time.Time       `json:"created"`
+	Config       containerConfig `json:"config"`
+	RootFS       rootFS          `json:"rootfs"`
+	History      []history       `json:"history"`
+}
+
+type containerConfig struct {
+	Env []string `json:"Env"`
+	Cmd []string `json:"Cmd"`
+}
+
+type rootFS struct {
+	Type    string   `json:"type"`
+	DiffIDs []string `json:"diff_ids"`
+}
+
+type history struct {
+	Created   time.Time `json:"created"`
+	CreatedBy string    `json:"created_by"`
+}
+
+type manifestEntry struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// WriteArchive writes the fixture images as an archive loadable with docker load, tagged under the repository
+// prefix. The layers shared by several fixtures are written once.
+func WriteArchive(out io.Writer, fixtures []Fixture, repository string) ([]Image, error) {
+	archive := tar.NewWriter(out)
+	written := map[string]bool{}
+	var manifest []manifestEntry
+	var images []Image
+	for _, fixture := range fixtures {
+		config := imageConfig{
+			Architecture: "amd64",
+			OS:           "linux",
+			Created:      created,
+			Config:       containerConfig{Env: []string{"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"}, Cmd: fixture.Cmd},
+			RootFS:       rootFS{Type: "layers"},
+		}
+		entry := manifestEntry{RepoTags: []string{fixture.Tag(repository)}}
+		for _, layer := range fixture.Layers {
+			content, err := layerArchive(layer)
+			if err != nil {
+				return nil, err
+			}
+			diffID := digest(content)
+			path := strings.TrimPrefix(diffID, "sha256:") + "/layer.tar"
+			if !written[path] {
+				if err := writeFile(archive, path, content); err != nil {
+					return nil, err
+				}
+				written[path] = true
+			}
+			config.RootFS.DiffIDs = append(config.RootFS.DiffIDs, diffID)
+			config.History = append(config.History, history{Created: created, CreatedBy: layer.CreatedBy})
+			entry.Layers = append(entry.Layers, path)
+		}
+		content, err := json.Marshal(config)
+		if err != nil {
+			return nil, err
+		}
+		id := digest(content)
+		entry.Config = strings.TrimPrefix(id, "sha256:") + ".json"
+		if err := writeFile(archive, entry.Config, content); err != nil {
+			return nil, err
+		}
+		manifest = append(manifest, entry)
+		images = append(images, Image{Fixture: fixture, Tag: fixture.Tag(repository), ID: id})
+	}
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(archive, "manifest.json", content); err != nil {
+		return nil, err
+	}
+	return images, archive.Close()
+}
+
+// layerArchive returns the tar archive of the files of the layer, sorted and dated so its digest never changes
+func layerArchive(layer Layer) ([]byte, error) {
+	var paths []string
+	dirs := map[string]bool{}
+	for path := range layer.Files {
+		paths = append(paths, path)
+		for dir := path; strings.Contains(dir, "/"); {
+			dir = dir[:strings.LastIndex(dir, "/")]
+			if !dirs[dir] {
+				dirs[dir] = true
+				paths = append(paths, dir+"/")
+			}
+		}
+	}
+	sort.Strings(paths)
+	buf := bytes.NewBuffer(nil)
+	archive := tar.NewWriter(buf)
+	for _, path := range paths {
+		header := &tar.Header{Name: path, ModTime: created, Format: tar.FormatUSTAR}
+		if strings.HasSuffix(path, "/") {
+			header.Typeflag, header.Mode = tar.TypeDir, 0755
+		} else {
+			header.Typeflag, header.Mode, header.Size = tar.TypeReg, 0644, int64(len(layer.Files[path]))
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(archive, layer.Files[path]); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeFile(archive *tar.Writer, path string, content []byte) error {
+	header := &tar.Header{Name: path, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content)), ModTime: created, Format: tar.FormatUSTAR}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := archive.Write(content)
+	return err
+}
+
+func digest(content []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+}
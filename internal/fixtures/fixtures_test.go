@@ -0,0 +1,91 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package fixtures
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteArchive(t *testing.T) {
+	first := bytes.NewBuffer(nil)
+	images, err := WriteArchive(first, Fixtures, DefaultRepository)
+	assert.NilError(t, err)
+	second := bytes.NewBuffer(nil)
+	again, err := WriteArchive(second, Fixtures, DefaultRepository)
+	assert.NilError(t, err)
+	// the images are the same on every machine
+	assert.DeepEqual(t, images, again)
+	assert.Assert(t, bytes.Equal(first.Bytes(), second.Bytes()))
+	assert.Equal(t, images[0].Tag, "docker-scan-testdata/alpine-vulnerable:1")
+
+	files := map[string][]byte{}
+	reader := tar.NewReader(first)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+		content, err := ioutil.ReadAll(reader)
+		assert.NilError(t, err)
+		_, duplicate := files[header.Name]
+		assert.Assert(t, !duplicate, header.Name)
+		files[header.Name] = content
+	}
+	var manifest []manifestEntry
+	assert.NilError(t, json.Unmarshal(files["manifest.json"], &manifest))
+	assert.Equal(t, len(manifest), 3)
+	assert.DeepEqual(t, manifest[1].RepoTags, []string{"docker-scan-testdata/node-vulnerable:1"})
+	// the Alpine layer is shared by the Alpine and Node.js fixtures
+	assert.Equal(t, manifest[0].Layers[0], manifest[1].Layers[0])
+	assert.Equal(t, manifest[1].Config, strings.TrimPrefix(images[1].ID, "sha256:")+".json")
+
+	layer := tar.NewReader(bytes.NewReader(files[manifest[0].Layers[0]]))
+	var paths []string
+	for {
+		header, err := layer.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+		paths = append(paths, header.Name)
+	}
+	assert.DeepEqual(t, paths, []string{"etc/", "etc/alpine-release", "etc/os-release", "lib/", "lib/apk/", "lib/apk/db/", "lib/apk/db/installed"})
+}
+
+func TestFind(t *testing.T) {
+	found, err := Find(nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(found), len(Fixtures))
+	found, err = Find([]string{"no-vulnerabilities"})
+	assert.NilError(t, err)
+	assert.Equal(t, found[0].Name, "no-vulnerabilities")
+	_, err = Find([]string{"unknown"})
+	assert.ErrorContains(t, err, `unknown fixture "unknown"`)
+}
+
+func TestApkInstalled(t *testing.T) {
+	assert.Equal(t, apkInstalled([][3]string{{"libssl1.1", "1.1.1b-r1", "openssl"}}), "P:libssl1.1\nV:1.1.1b-r1\nA:x86_64\no:openssl\n\n")
+}
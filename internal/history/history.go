@@ -0,0 +1,160 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	cliConfig "github.com/docker/cli/cli/config"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/internal/filelock"
+)
+
+// maxEntries is the number of scans kept in the history, the oldest ones are removed
+const maxEntries = 10000
+
+// Entry is the normalized result of a scan, one JSON line of the history
+type Entry struct {
+	Image string `json:"image"`
+	// Digest is the ID of the scanned image, the digest of its configuration
+	Digest string    `json:"digest,omitempty"`
+	Time   time.Time `json:"time"`
+	// Provider is the name and the version of the provider
+	Provider string         `json:"provider,omitempty"`
+	Counts   map[string]int `json:"counts"`
+	Total    int            `json:"total"`
+	// Report is the JSON report of the last scan of the image, checked against the new advisories by docker scan alerts
+	Report string `json:"report,omitempty"`
+}
+
+// DefaultPath returns the history of the scans of the docker scan configuration
+func DefaultPath() string {
+	return filepath.Join(cliConfig.Dir(), "scan", "history.jsonl")
+}
+
+// DefaultReportsDir returns the directory of the JSON report of the last scan of each image of the history
+func DefaultReportsDir() string {
+	return filepath.Join(cliConfig.Dir(), "scan", "reports")
+}
+
+// SaveReport saves the JSON report of the last scan of an image in the directory, replacing the report of its previous
+// scan, and returns its path
+func SaveReport(dir, image string, document []byte) (string, error) {
+	sum := sha256.Sum256([]byte(image))
+	path := filepath.Join(dir, hex.EncodeToString(sum[:8])+".json")
+	return path, filelock.WriteFile(path, document, 0644)
+}
+
+// Latest returns the most recent scan of each image, in the order of their first scan
+func Latest(entries []Entry) []Entry {
+	indexes := map[string]int{}
+	var latest []Entry
+	for _, entry := range entries {
+		if index, ok := indexes[entry.Image]; ok {
+			latest[index] = entry
+			continue
+		}
+		indexes[entry.Image] = len(latest)
+		latest = append(latest, entry)
+	}
+	return latest
+}
+
+// Append adds a scan to the history, keeping the most recent ones
+func Append(path string, entry Entry) error {
+	unlock, err := filelock.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	entries, err := Read(path)
+	if err != nil {
+		return err
+	}
+	entries = append(entries, entry)
+	if len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+	buf := bytes.NewBuffer(nil)
+	encoder := json.NewEncoder(buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return filelock.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// Read returns the scans of the history, from the oldest to the most recent one. The invalid lines are skipped.
+func Read(path string) ([]Entry, error) {
+	buf, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// Select returns the scans of the image made since the given time, all the tags of the repository when the image has
+// no tag nor digest
+func Select(entries []Entry, image string, since time.Time) []Entry {
+	named, err := reference.ParseNormalizedNamed(image)
+	var selected []Entry
+	for _, entry := range entries {
+		if entry.Time.Before(since) {
+			continue
+		}
+		if matches(named, err, image, entry.Image) {
+			selected = append(selected, entry)
+		}
+	}
+	return selected
+}
+
+func matches(named reference.Named, parseErr error, image, scanned string) bool {
+	if parseErr != nil {
+		return image == scanned
+	}
+	scannedNamed, err := reference.ParseNormalizedNamed(scanned)
+	if err != nil {
+		return false
+	}
+	if reference.IsNameOnly(named) {
+		return named.Name() == scannedNamed.Name()
+	}
+	return named.String() == reference.TagNameOnly(scannedNamed).String()
+}
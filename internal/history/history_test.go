@@ -0,0 +1,98 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestAppendRead(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	path := dir.Join("scan", "history.jsonl")
+
+	entries, err := Read(path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 0)
+
+	monday := time.Date(2021, time.March, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		assert.NilError(t, Append(path, Entry{
+			Image:    "alpine:3.13",
+			Digest:   fmt.Sprintf("sha256:%d", i),
+			Time:     monday.Add(time.Duration(i) * 24 * time.Hour),
+			Provider: "Snyk (1.563.0)",
+			Counts:   map[string]int{"high": 3 - i},
+			Total:    3 - i,
+		}))
+	}
+	entries, err = Read(path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 3)
+	assert.Equal(t, entries[2].Digest, "sha256:2")
+	assert.Equal(t, entries[2].Counts["high"], 1)
+}
+
+func TestSelect(t *testing.T) {
+	monday := time.Date(2021, time.March, 1, 9, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Image: "alpine:3.13", Time: monday},
+		{Image: "docker.io/library/alpine:3.13", Time: monday.Add(24 * time.Hour)},
+		{Image: "alpine:3.12", Time: monday.Add(48 * time.Hour)},
+		{Image: "alpine", Time: monday.Add(72 * time.Hour)},
+		{Image: "nginx:1.19", Time: monday.Add(96 * time.Hour)},
+	}
+	assert.Equal(t, len(Select(entries, "alpine:3.13", time.Time{})), 2)
+	assert.Equal(t, len(Select(entries, "alpine", time.Time{})), 4)
+	assert.Equal(t, len(Select(entries, "alpine:latest", time.Time{})), 1)
+	assert.Equal(t, len(Select(entries, "alpine", monday.Add(48*time.Hour))), 2)
+	assert.Equal(t, len(Select(entries, "nginx", time.Time{})), 1)
+}
+
+func TestLatestAndSaveReport(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+
+	first, err := SaveReport(dir.Path(), "alpine:3.13", []byte(`{"vulnerabilities":[]}`))
+	assert.NilError(t, err)
+	second, err := SaveReport(dir.Path(), "alpine:3.13", []byte(`{"vulnerabilities":[{"id":"CVE-1"}]}`))
+	assert.NilError(t, err)
+	assert.Equal(t, first, second)
+	other, err := SaveReport(dir.Path(), "alpine:3.12", []byte(`{}`))
+	assert.NilError(t, err)
+	assert.Assert(t, other != first)
+	assert.Assert(t, fs.Equal(dir.Path(), fs.Expected(t,
+		fs.WithFile(filepath.Base(first), `{"vulnerabilities":[{"id":"CVE-1"}]}`),
+		fs.WithFile(filepath.Base(other), `{}`))))
+
+	monday := time.Date(2021, time.March, 1, 9, 0, 0, 0, time.UTC)
+	latest := Latest([]Entry{
+		{Image: "alpine:3.13", Time: monday, Total: 3},
+		{Image: "alpine:3.12", Time: monday.Add(24 * time.Hour)},
+		{Image: "alpine:3.13", Time: monday.Add(48 * time.Hour), Total: 1, Report: first},
+	})
+	assert.DeepEqual(t, latest, []Entry{
+		{Image: "alpine:3.13", Time: monday.Add(48 * time.Hour), Total: 1, Report: first},
+		{Image: "alpine:3.12", Time: monday.Add(24 * time.Hour)},
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 
 	"github.com/docker/docker/api/types"
 )
@@ -31,11 +32,16 @@ const (
 	LoginURL = "/v2/users/login"
 	// ScanTokenURL path to the Hub provider token generation URL
 	ScanTokenURL = "/api/scan/v1/provider/token"
+	// VulnerabilitiesURL path to the Hub API returning the results of the server side scan of a tag
+	VulnerabilitiesURL = "/api/scan/v1/repositories/%s/tags/%s/vulnerabilities"
 )
 
 //Client sends authenticates on Hub and sends requests to the API
 type Client struct {
-	Domain string
+	Domain     string
+	HTTPClient *http.Client
+	// CacheDir stores the responses with an ETag or a Last-Modified date, revalidated by the next requests
+	CacheDir string
 }
 
 //Login logs into Hub and returns the auth token
@@ -47,12 +53,12 @@ func (h *Client) Login(hubAuthConfig types.AuthConfig) (string, error) {
 	body := bytes.NewBuffer(data)
 
 	// Login on the Docker Hub
-	req, err := http.NewRequest("POST", h.Domain+LoginURL, ioutil.NopCloser(body))
+	req, err := http.NewRequest("POST", h.Domain+LoginURL, body)
 	if err != nil {
 		return "", err
 	}
 	req.Header["Content-Type"] = []string{"application/json"}
-	buf, err := doRequest(req)
+	buf, err := h.doRequest(req)
 	if err != nil {
 		return "", err
 	}
@@ -73,22 +79,44 @@ func (h *Client) GetScanID(hubToken string) (string, error) {
 		return "", err
 	}
 	req.Header["Authorization"] = []string{fmt.Sprintf("Bearer %s", hubToken)}
-	token, err := doRequest(req)
+	token, err := h.doRequest(req)
 	if err != nil {
 		return "", err
 	}
 	return string(token), nil
 }
 
-func doRequest(req *http.Request) ([]byte, error) {
+//GetVulnerabilities returns the results of the server side scan of a tag pushed to Hub, in the Snyk JSON format
+func (h *Client) GetVulnerabilities(hubToken, repository, tag string) ([]byte, error) {
+	req, err := http.NewRequest("GET", h.Domain+fmt.Sprintf(VulnerabilitiesURL, repository, url.PathEscape(tag)), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header["Authorization"] = []string{fmt.Sprintf("Bearer %s", hubToken)}
+	buf, err := h.doRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the vulnerabilities of %s:%s: %w", repository, tag, err)
+	}
+	return buf, nil
+}
+
+func (h *Client) doRequest(req *http.Request) ([]byte, error) {
 	req.Header["Accept"] = []string{"application/json"}
-	resp, err := http.DefaultClient.Do(req)
+	cachePath := h.cachePath(req)
+	var cached *cachedResponse
+	if cachePath != "" {
+		cached = readCache(req, cachePath)
+	}
+	resp, err := h.send(req)
 	if err != nil {
 		return nil, err
 	}
 	if resp.Body != nil {
 		defer resp.Body.Close() //nolint:errcheck
 	}
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return cached.Body, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("bad status code %q", resp.Status)
 	}
@@ -96,5 +124,15 @@ func doRequest(req *http.Request) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if cachePath != "" {
+		writeCache(cachePath, resp, buf)
+	}
 	return buf, nil
 }
+
+func httpClient(client *http.Client) *http.Client {
+	if client == nil {
+		return http.DefaultClient
+	}
+	return client
+}
@@ -48,10 +48,10 @@ func GetInstance() *Instance {
 	}
 }
 
-//FetchJwks fetches a jwks.json file and parses it
-func (i *Instance) FetchJwks() (jose.JSONWebKeySet, error) {
+//FetchJwks fetches a jwks.json file with the given HTTP client and parses it
+func (i *Instance) FetchJwks(client *http.Client) (jose.JSONWebKeySet, error) {
 	// fetch jwks.json file from URL
-	resp, err := http.Get(i.JwksURL)
+	resp, err := httpClient(client).Get(i.JwksURL)
 	if err != nil {
 		return jose.JSONWebKeySet{}, fmt.Errorf("failed to fetch JWKS: %s", err)
 	}
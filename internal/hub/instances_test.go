@@ -17,6 +17,7 @@
 package hub
 
 import (
+	"net/http"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -24,7 +25,7 @@ import (
 
 func TestInstance_FetchJwks(t *testing.T) {
 	instance := GetInstance()
-	got, err := instance.FetchJwks()
+	got, err := instance.FetchJwks(http.DefaultClient)
 	assert.NilError(t, err)
 	assert.Assert(t, len(got.Keys) >= 1)
 }
@@ -0,0 +1,159 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	cliConfig "github.com/docker/cli/cli/config"
+	"github.com/docker/scan-cli-plugin/internal/filelock"
+)
+
+const (
+	// maxRetries is the number of times a rate limited request is sent again
+	maxRetries = 3
+	// maxRetryAfter is the longest wait accepted before sending a rate limited request again
+	maxRetryAfter = time.Minute
+	// defaultRetryAfter is the wait when the rate limited response doesn't tell how long to wait
+	defaultRetryAfter = 5 * time.Second
+)
+
+// ErrRateLimited is returned when Docker Hub still rate limits a request after the retries
+var ErrRateLimited = errors.New("rate limited by Docker Hub")
+
+var (
+	now   = time.Now
+	sleep = func(req *http.Request, d time.Duration) error {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-req.Context().Done():
+			return req.Context().Err()
+		}
+	}
+)
+
+// DefaultCacheDir returns the directory of the docker scan configuration where the Hub responses are cached
+func DefaultCacheDir() string {
+	return filepath.Join(cliConfig.Dir(), "scan", "hub-cache")
+}
+
+// cachedResponse is a response stored with its validators, to be revalidated with a conditional request
+type cachedResponse struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Body         []byte `json:"body"`
+}
+
+// send sends the request, waiting and sending it again while Hub rate limits it
+func (h *Client) send(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := httpClient(h.HTTPClient).Do(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+		wait := retryAfter(resp.Header.Get("Retry-After"))
+		resp.Body.Close() //nolint:errcheck
+		if attempt == maxRetries || wait > maxRetryAfter {
+			return nil, fmt.Errorf("%w, retry after %s", ErrRateLimited, wait)
+		}
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("%w, retry after %s", ErrRateLimited, wait)
+			}
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		if err := sleep(req, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryAfter parses the Retry-After header, either a number of seconds or an HTTP date
+func retryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now()); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+	return defaultRetryAfter
+}
+
+// cachePath returns where the response to the request is cached, empty if the request is not cached.
+// Only the anonymous GET requests are cached, the responses to the authenticated ones may hold credentials.
+func (h *Client) cachePath(req *http.Request) string {
+	if h.CacheDir == "" || req.Method != http.MethodGet || req.Header.Get("Authorization") != "" {
+		return ""
+	}
+	return filepath.Join(h.CacheDir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(req.URL.String()))))
+}
+
+// readCache returns the cached response and adds its validators to the request
+func readCache(req *http.Request, path string) *cachedResponse {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(buf, &cached); err != nil {
+		return nil
+	}
+	if cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+	return &cached
+}
+
+// writeCache stores the response when it can be revalidated, errors are ignored as the cache is an optimization
+func writeCache(path string, resp *http.Response, body []byte) {
+	cached := cachedResponse{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Body:         body,
+	}
+	if cached.ETag == "" && cached.LastModified == "" {
+		return
+	}
+	buf, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	_ = filelock.WriteFile(path, buf, 0600)
+}
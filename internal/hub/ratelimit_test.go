@@ -0,0 +1,125 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestLatestTagsRevalidatesCachedResponse(t *testing.T) {
+	dir := fs.NewDir(t, "hub-cache")
+	defer dir.Remove()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"results":[{"name":"3.14"}]}`)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	client := Client{Domain: server.URL, CacheDir: dir.Path()}
+	for i := 0; i < 2; i++ {
+		tags, err := client.LatestTags("library/alpine", 1)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, tags, []string{"3.14"})
+	}
+	assert.Equal(t, requests, 2)
+}
+
+func TestAuthenticatedResponsesAreNotCached(t *testing.T) {
+	dir := fs.NewDir(t, "hub-cache")
+	defer dir.Remove()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("If-None-Match"), "")
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("token")) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	client := Client{Domain: server.URL, CacheDir: dir.Path()}
+	for i := 0; i < 2; i++ {
+		_, err := client.GetScanID("hub-token")
+		assert.NilError(t, err)
+	}
+	assert.Assert(t, fs.Equal(dir.Path(), fs.Expected(t)))
+}
+
+func TestRetryRateLimitedRequest(t *testing.T) {
+	var waits []time.Duration
+	defer restoreSleep(func(req *http.Request, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	})()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"token":"hub-token"}`)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	client := Client{Domain: server.URL}
+	token, err := client.Login(types.AuthConfig{Username: "user", Password: "password"})
+	assert.NilError(t, err)
+	assert.Equal(t, token, "hub-token")
+	assert.DeepEqual(t, waits, []time.Duration{2 * time.Second, 2 * time.Second})
+}
+
+func TestRateLimitedTooLong(t *testing.T) {
+	defer restoreSleep(func(req *http.Request, d time.Duration) error {
+		t.Fatal("should not wait")
+		return nil
+	})()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := (&Client{Domain: server.URL}).LatestTags("library/alpine", 1)
+	assert.ErrorContains(t, err, "rate limited by Docker Hub, retry after 1h0m0s")
+}
+
+func TestRetryAfter(t *testing.T) {
+	defer func(original func() time.Time) { now = original }(now)
+	now = func() time.Time { return time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC) }
+	assert.Equal(t, retryAfter("10"), 10*time.Second)
+	assert.Equal(t, retryAfter("Tue, 01 Jun 2021 12:00:30 GMT"), 30*time.Second)
+	assert.Equal(t, retryAfter("Tue, 01 Jun 2021 11:00:00 GMT"), time.Duration(0))
+	assert.Equal(t, retryAfter(""), defaultRetryAfter)
+}
+
+func restoreSleep(fake func(*http.Request, time.Duration) error) func() {
+	original := sleep
+	sleep = fake
+	return func() { sleep = original }
+}
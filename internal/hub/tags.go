@@ -0,0 +1,55 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// TagsURL path to the Hub API listing the tags of a repository
+const TagsURL = "/v2/repositories/%s/tags"
+
+//LatestTags returns the most recently updated tags of a Docker Hub repository, like library/alpine
+func (h *Client) LatestTags(repository string, count int) ([]string, error) {
+	query := url.Values{}
+	query.Set("page_size", fmt.Sprint(count))
+	query.Set("ordering", "last_updated")
+	req, err := http.NewRequest("GET", h.Domain+fmt.Sprintf(TagsURL, repository)+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := h.doRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the tags of %s: %s", repository, err)
+	}
+	var page struct {
+		Results []struct {
+			Name string `json:"name"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(buf, &page); err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, result := range page.Results {
+		tags = append(tags, result.Name)
+	}
+	return tags, nil
+}
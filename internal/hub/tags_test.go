@@ -0,0 +1,47 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLatestTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/repositories/library/alpine/tags" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, r.URL.Query().Get("page_size"), "2")
+		assert.Equal(t, r.URL.Query().Get("ordering"), "last_updated")
+		_, err := w.Write([]byte(`{"count": 42, "results": [{"name": "3.14.2"}, {"name": "edge"}]}`))
+		assert.NilError(t, err)
+	}))
+	defer server.Close()
+
+	client := Client{Domain: server.URL}
+	tags, err := client.LatestTags("library/alpine", 2)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, tags, []string{"3.14.2", "edge"})
+
+	_, err = client.LatestTags("library/unknown", 2)
+	assert.ErrorContains(t, err, "cannot list the tags of library/unknown")
+}
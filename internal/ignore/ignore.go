@@ -0,0 +1,76 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package ignore reads and writes the ignore files, listing the IDs of the vulnerabilities excluded from the results
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// DefaultFile is the ignore file the vulnerabilities marked in the interactive browser are added to by default
+const DefaultFile = ".dockerscanignore"
+
+// Load returns the IDs of an ignore file: a vulnerability or CVE ID per line, the empty lines and the comments
+// starting with # being skipped
+func Load(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	//nolint: errcheck
+	defer f.Close()
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = line[:index]
+		}
+		if id := strings.TrimSpace(line); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read the ignore file %s: %s", path, err)
+	}
+	return ids, nil
+}
+
+// Append adds the vulnerabilities to the ignore file, created if it doesn't exist, with a comment telling the
+// package they were found in and when they were ignored
+func Append(path string, vulns []report.Vulnerability, now time.Time) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(f)
+	for _, vuln := range vulns {
+		fmt.Fprintf(writer, "%s # %s in %s@%s, ignored on %s\n", vuln.ID, vuln.Title, vuln.PackageName, vuln.Version,
+			now.Format("2006-01-02"))
+	}
+	if err := writer.Flush(); err != nil {
+		f.Close() //nolint: errcheck
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,48 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ignore
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithFile(DefaultFile, "# accepted risks\nCVE-2021-3711\n\n"))
+	defer dir.Remove()
+	path := dir.Join(DefaultFile)
+
+	now := time.Date(2021, time.March, 15, 9, 0, 0, 0, time.UTC)
+	assert.NilError(t, Append(path, []report.Vulnerability{
+		{ID: "SNYK-DEBIAN10-CURL-1", Title: "Use After Free", PackageName: "curl", Version: "7.64.0"},
+	}, now))
+	buf, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), "# accepted risks\nCVE-2021-3711\n\nSNYK-DEBIAN10-CURL-1 # Use After Free in curl@7.64.0, ignored on 2021-03-15\n")
+
+	ids, err := Load(path)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ids, []string{"CVE-2021-3711", "SNYK-DEBIAN10-CURL-1"})
+
+	_, err = Load(dir.Join("missing"))
+	assert.ErrorContains(t, err, "no such file or directory")
+}
@@ -0,0 +1,156 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+const (
+	ociImageIndexMediaType = "application/vnd.oci.image.index.v1+json"
+	dockerManifestListType = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// archiveManifest points to the configuration and the layers of an image in an archive
+type archiveManifest struct {
+	Config string
+	Layers []string
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+// fileReader reads a file of an image archive, from its path relative to the root of the archive
+type fileReader func(name string) ([]byte, error)
+
+func dirReader(dir string) fileReader {
+	return func(name string) ([]byte, error) {
+		return ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(path.Clean("/"+name))))
+	}
+}
+
+// tarReader reads a file from a tar archive, going through the archive for each file
+func tarReader(archive string) fileReader {
+	return func(name string) ([]byte, error) {
+		f, err := os.Open(archive)
+		if err != nil {
+			return nil, err
+		}
+		//nolint: errcheck
+		defer f.Close()
+		tr := tar.NewReader(f)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return nil, os.ErrNotExist
+			}
+			if err != nil {
+				return nil, err
+			}
+			if path.Clean("/"+header.Name) == path.Clean("/"+name) {
+				return ioutil.ReadAll(tr)
+			}
+		}
+	}
+}
+
+// readArchiveManifest reads the manifest.json written by docker save, or the index.json of an OCI image layout
+func readArchiveManifest(read fileReader) (archiveManifest, error) {
+	buf, err := read("manifest.json")
+	if err == nil {
+		var manifests []archiveManifest
+		if err := json.Unmarshal(buf, &manifests); err != nil {
+			return archiveManifest{}, fmt.Errorf("invalid image archive: %s", err)
+		}
+		if len(manifests) != 1 {
+			return archiveManifest{}, fmt.Errorf("invalid image archive: expected a single image, got %d", len(manifests))
+		}
+		return manifests[0], nil
+	}
+	return readOCIManifest(read)
+}
+
+func readOCIManifest(read fileReader) (archiveManifest, error) {
+	buf, err := read("index.json")
+	if err != nil {
+		return archiveManifest{}, fmt.Errorf("invalid image archive: no manifest.json or index.json found")
+	}
+	var index struct {
+		Manifests []ociDescriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(buf, &index); err != nil {
+		return archiveManifest{}, fmt.Errorf("invalid image archive: %s", err)
+	}
+	if len(index.Manifests) != 1 {
+		return archiveManifest{}, fmt.Errorf("invalid image archive: expected a single image, got %d", len(index.Manifests))
+	}
+	if mediaType := index.Manifests[0].MediaType; mediaType == ociImageIndexMediaType || mediaType == dockerManifestListType {
+		return archiveManifest{}, fmt.Errorf("invalid image archive: multi-platform images are not supported")
+	}
+	buf, err = read(blobPath(index.Manifests[0].Digest))
+	if err != nil {
+		return archiveManifest{}, fmt.Errorf("invalid image archive: %s", err)
+	}
+	var manifest struct {
+		Config ociDescriptor   `json:"config"`
+		Layers []ociDescriptor `json:"layers"`
+	}
+	if err := json.Unmarshal(buf, &manifest); err != nil {
+		return archiveManifest{}, fmt.Errorf("invalid image archive: %s", err)
+	}
+	result := archiveManifest{Config: blobPath(manifest.Config.Digest)}
+	for _, layer := range manifest.Layers {
+		result.Layers = append(result.Layers, blobPath(layer.Digest))
+	}
+	return result, nil
+}
+
+// blobPath returns the path of a blob in an OCI image layout, from its digest
+func blobPath(digest string) string {
+	return "blobs/" + strings.Replace(digest, ":", "/", 1)
+}
+
+// readArchiveConfig reads the container configuration of the image of an archive
+func readArchiveConfig(read fileReader) (container.Config, error) {
+	manifest, err := readArchiveManifest(read)
+	if err != nil {
+		return container.Config{}, err
+	}
+	buf, err := read(manifest.Config)
+	if err != nil {
+		return container.Config{}, fmt.Errorf("invalid image archive: %s", err)
+	}
+	var config struct {
+		Config container.Config `json:"config"`
+	}
+	if err := json.Unmarshal(buf, &config); err != nil {
+		return container.Config{}, fmt.Errorf("invalid image archive: %s", err)
+	}
+	return config.Config, nil
+}
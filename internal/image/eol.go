@@ -0,0 +1,112 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"strings"
+	"time"
+)
+
+// endOfLifeDates lists the end of life dates of the base OS releases, by distribution and version
+var endOfLifeDates = map[string]map[string]string{
+	"alpine": {
+		"3.7":  "2019-11-01",
+		"3.8":  "2020-05-01",
+		"3.9":  "2020-11-01",
+		"3.10": "2021-05-01",
+		"3.11": "2021-11-01",
+		"3.12": "2022-05-01",
+		"3.13": "2022-11-01",
+		"3.14": "2023-05-01",
+		"3.15": "2023-11-01",
+		"3.16": "2024-05-23",
+		"3.17": "2024-11-22",
+		"3.18": "2025-05-09",
+		"3.19": "2025-11-01",
+		"3.20": "2026-04-01",
+		"3.21": "2026-11-01",
+		"3.22": "2027-05-01",
+		"3.23": "2027-11-01",
+	},
+	"debian": {
+		"7":  "2018-05-31",
+		"8":  "2020-06-30",
+		"9":  "2022-06-30",
+		"10": "2024-06-30",
+		"11": "2026-08-31",
+		"12": "2028-06-30",
+		"13": "2030-06-30",
+	},
+	"ubuntu": {
+		"14.04": "2019-04-25",
+		"16.04": "2021-04-30",
+		"18.04": "2023-05-31",
+		"20.04": "2025-05-31",
+		"22.04": "2027-06-01",
+		"24.04": "2029-05-31",
+	},
+	"centos": {
+		"6": "2020-11-30",
+		"7": "2024-06-30",
+		"8": "2021-12-31",
+	},
+}
+
+// EOLStatus tells whether an OS release reached its end of life
+type EOLStatus string
+
+const (
+	// EOLSupported is the status of the releases still supported
+	EOLSupported = EOLStatus("supported")
+	// EOLReached is the status of the releases which reached their end of life
+	EOLReached = EOLStatus("eol")
+	// EOLUnknown is the status of the releases missing from the end of life dates, like releases
+	// more recent than the plugin, which cannot be told supported
+	EOLUnknown = EOLStatus("unknown")
+)
+
+// EndOfLife returns the end of life date of an OS release and its status, the status is empty
+// when the image has no identified OS release
+func EndOfLife(osRelease OSRelease, now time.Time) (string, EOLStatus) {
+	if osRelease.ID == "" {
+		return "", ""
+	}
+	date, ok := endOfLifeDates[osRelease.ID][releaseVersion(osRelease)]
+	if !ok {
+		return "", EOLUnknown
+	}
+	eol, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", EOLUnknown
+	}
+	if now.After(eol) {
+		return date, EOLReached
+	}
+	return date, EOLSupported
+}
+
+// releaseVersion trims the patch version of the distributions versioned by minor releases
+func releaseVersion(osRelease OSRelease) string {
+	if osRelease.ID != "alpine" {
+		return osRelease.VersionID
+	}
+	parts := strings.Split(osRelease.VersionID, ".")
+	if len(parts) < 2 {
+		return osRelease.VersionID
+	}
+	return parts[0] + "." + parts[1]
+}
@@ -0,0 +1,134 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+
+	"github.com/docker/docker/client"
+)
+
+// BaseNameLabel is the label naming the base image of an image, set by the builders following the OCI annotations
+const BaseNameLabel = "org.opencontainers.image.base.name"
+
+// Layer is an entry of the image history
+type Layer struct {
+	ID        string `json:"id,omitempty"`
+	CreatedBy string `json:"createdBy"`
+	Size      int64  `json:"size"`
+	Base      bool   `json:"base"`
+}
+
+// History returns the layers of an image available on the engine, from the oldest to the most recent.
+// The layers coming from the base image are flagged when the base image is available on the engine too.
+func History(ctx context.Context, cli client.APIClient, ref string, baseImage string) ([]Layer, error) {
+	history, err := cli.ImageHistory(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	baseLayers := 0
+	if baseImage != "" {
+		if baseHistory, err := cli.ImageHistory(ctx, baseImage); err == nil && len(baseHistory) <= len(history) {
+			baseLayers = len(baseHistory)
+		}
+	}
+	layers := make([]Layer, 0, len(history))
+	// the engine returns the most recent layer first
+	for i := len(history) - 1; i >= 0; i-- {
+		entry := history[i]
+		id := entry.ID
+		if id == "<missing>" {
+			id = ""
+		}
+		layers = append(layers, Layer{
+			ID:        id,
+			CreatedBy: entry.CreatedBy,
+			Size:      entry.Size,
+			Base:      len(layers) < baseLayers,
+		})
+	}
+	return layers, nil
+}
+
+// BaseLayers returns the diff IDs of the layers an image shares with its base image, both being available on the
+// engine. The base image defaults to the one named by the BaseNameLabel label of the image. No layer is returned if the
+// base image is unknown or if the image is not built on top of it.
+func BaseLayers(ctx context.Context, cli client.APIClient, ref string, baseImage string) ([]string, error) {
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if baseImage == "" && inspect.Config != nil {
+		baseImage = inspect.Config.Labels[BaseNameLabel]
+	}
+	if baseImage == "" {
+		return nil, nil
+	}
+	base, _, err := cli.ImageInspectWithRaw(ctx, baseImage)
+	if err != nil {
+		return nil, err
+	}
+	return sharedLayers(inspect.RootFS.Layers, base.RootFS.Layers), nil
+}
+
+// sharedLayers returns the base layers if they are the first layers of the image
+func sharedLayers(layers []string, baseLayers []string) []string {
+	if len(baseLayers) > len(layers) {
+		return nil
+	}
+	for i, diffID := range baseLayers {
+		if layers[i] != diffID {
+			return nil
+		}
+	}
+	return baseLayers
+}
+
+// LayerDigests returns the digest of the filesystem layer created by each entry of the image history, from the oldest
+// to the most recent, empty for the entries which don't create a layer like ENV or LABEL
+func LayerDigests(ctx context.Context, cli client.APIClient, ref string) ([]string, error) {
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	history, err := History(ctx, cli, ref, "")
+	if err != nil {
+		return nil, err
+	}
+	return matchLayerDigests(history, inspect.RootFS.Layers), nil
+}
+
+// matchLayerDigests attributes the layer digests to the history entries of a non empty size. The engine doesn't
+// report which entries create a layer, so no digest is attributed when the numbers don't match.
+func matchLayerDigests(history []Layer, digests []string) []string {
+	result := make([]string, len(history))
+	index := 0
+	for i, layer := range history {
+		if layer.Size == 0 {
+			continue
+		}
+		if index >= len(digests) {
+			return make([]string, len(history))
+		}
+		result[i] = digests[index]
+		index++
+	}
+	if index != len(digests) {
+		return make([]string, len(history))
+	}
+	return result
+}
@@ -0,0 +1,172 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+const osReleasePath = "/etc/os-release"
+
+// Metadata describes the scanned image and its base OS, to be evaluated alongside vulnerabilities
+type Metadata struct {
+	Created          time.Time `json:"created"`
+	AgeDays          int       `json:"ageDays"`
+	OS               OSRelease `json:"os"`
+	EOL              bool      `json:"eol"`
+	EOLDate          string    `json:"eolDate,omitempty"`
+	EOLStatus        EOLStatus `json:"eolStatus,omitempty"`
+	BaseImage        string    `json:"baseImage,omitempty"`
+	BaseImageAgeDays int       `json:"baseImageAgeDays,omitempty"`
+}
+
+// OSRelease holds the identification of the image operating system
+type OSRelease struct {
+	ID         string `json:"id,omitempty"`
+	VersionID  string `json:"versionId,omitempty"`
+	PrettyName string `json:"prettyName,omitempty"`
+}
+
+// Inspect gathers the metadata of an image available on the engine
+func Inspect(ctx context.Context, cli client.APIClient, ref string, now time.Time) (Metadata, error) {
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return Metadata{}, err
+	}
+	created, err := time.Parse(time.RFC3339Nano, inspect.Created)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("invalid image creation date %q: %s", inspect.Created, err)
+	}
+	return Metadata{
+		Created: created,
+		AgeDays: ageInDays(created, now),
+	}, nil
+}
+
+// WithOSRelease adds the base OS release of an image available on the engine to the metadata, with its end of life
+// status. The os-release file is read from a container created for that purpose, never started.
+func WithOSRelease(ctx context.Context, cli client.APIClient, metadata Metadata, ref string, now time.Time) (Metadata, error) {
+	osRelease, err := readOSRelease(ctx, cli, ref)
+	if err != nil && !client.IsErrNotFound(err) {
+		return metadata, err
+	}
+	metadata.OS = osRelease
+	metadata.EOLDate, metadata.EOLStatus = EndOfLife(osRelease, now)
+	metadata.EOL = metadata.EOLStatus == EOLReached
+	return metadata, nil
+}
+
+// WithBaseImage adds the age of the base image to the metadata, if the base image is available on the engine
+func WithBaseImage(ctx context.Context, cli client.APIClient, metadata Metadata, baseImage string, now time.Time) Metadata {
+	metadata.BaseImage = baseImage
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, baseImage)
+	if err != nil {
+		return metadata
+	}
+	if created, err := time.Parse(time.RFC3339Nano, inspect.Created); err == nil {
+		metadata.BaseImageAgeDays = ageInDays(created, now)
+	}
+	return metadata
+}
+
+func ageInDays(created, now time.Time) int {
+	return int(now.Sub(created).Hours() / 24)
+}
+
+// readOSRelease reads the os-release file of an image, by copying it from a created but never started container
+func readOSRelease(ctx context.Context, cli client.APIClient, ref string) (OSRelease, error) {
+	// the entrypoint is never run, it only allows to create containers from images without any command
+	result, err := cli.ContainerCreate(ctx, &container.Config{Image: ref, Entrypoint: []string{"/"}}, &container.HostConfig{}, nil, "")
+	if err != nil {
+		return OSRelease{}, fmt.Errorf("cannot create container: %s", err)
+	}
+	// the container is removed even when the scan is interrupted or times out
+	//nolint: errcheck
+	defer cli.ContainerRemove(context.Background(), result.ID, types.ContainerRemoveOptions{})
+
+	filePath := osReleasePath
+	// os-release is usually a symbolic link to /usr/lib/os-release, follow it once
+	for i := 0; i < 2; i++ {
+		content, link, err := copyFile(ctx, cli, result.ID, filePath)
+		if err != nil {
+			return OSRelease{}, err
+		}
+		if link == "" {
+			return ParseOSRelease(content), nil
+		}
+		if !path.IsAbs(link) {
+			link = path.Join(path.Dir(filePath), link)
+		}
+		filePath = link
+	}
+	return OSRelease{}, fmt.Errorf("too many symbolic links resolving %s", osReleasePath)
+}
+
+func copyFile(ctx context.Context, cli client.APIClient, containerID, filePath string) (io.Reader, string, error) {
+	reader, _, err := cli.CopyFromContainer(ctx, containerID, filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	//nolint: errcheck
+	defer reader.Close()
+	tr := tar.NewReader(reader)
+	header, err := tr.Next()
+	if err != nil {
+		return nil, "", err
+	}
+	if header.Typeflag == tar.TypeSymlink {
+		return nil, header.Linkname, nil
+	}
+	buf := new(strings.Builder)
+	if _, err := io.Copy(buf, tr); err != nil {
+		return nil, "", err
+	}
+	return strings.NewReader(buf.String()), "", nil
+}
+
+// ParseOSRelease parses the content of an os-release file
+func ParseOSRelease(reader io.Reader) OSRelease {
+	var osRelease OSRelease
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(scanner.Text()), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.Trim(parts[1], `"'`)
+		switch parts[0] {
+		case "ID":
+			osRelease.ID = value
+		case "VERSION_ID":
+			osRelease.VersionID = value
+		case "PRETTY_NAME":
+			osRelease.PrettyName = value
+		}
+	}
+	return osRelease
+}
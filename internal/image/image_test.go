@@ -0,0 +1,93 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseOSRelease(t *testing.T) {
+	osRelease := ParseOSRelease(strings.NewReader(`NAME="Alpine Linux"
+ID=alpine
+VERSION_ID=3.10.0
+PRETTY_NAME="Alpine Linux v3.10"
+HOME_URL="https://alpinelinux.org/"
+`))
+	assert.DeepEqual(t, osRelease, OSRelease{ID: "alpine", VersionID: "3.10.0", PrettyName: "Alpine Linux v3.10"})
+}
+
+func TestEndOfLife(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name         string
+		osRelease    OSRelease
+		expectedDate string
+		expectedEOL  EOLStatus
+	}{
+		{
+			name:         "alpine patch release",
+			osRelease:    OSRelease{ID: "alpine", VersionID: "3.10.0"},
+			expectedDate: "2021-05-01",
+			expectedEOL:  EOLReached,
+		},
+		{
+			name:         "supported debian",
+			osRelease:    OSRelease{ID: "debian", VersionID: "11"},
+			expectedDate: "2026-08-31",
+			expectedEOL:  EOLSupported,
+		},
+		{
+			name:        "unknown distribution",
+			osRelease:   OSRelease{ID: "unknown", VersionID: "1"},
+			expectedEOL: EOLUnknown,
+		},
+		{
+			name:        "release more recent than the end of life dates",
+			osRelease:   OSRelease{ID: "alpine", VersionID: "3.99.0"},
+			expectedEOL: EOLUnknown,
+		},
+		{
+			name: "no OS release",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			date, eol := EndOfLife(testCase.osRelease, now)
+			assert.Equal(t, date, testCase.expectedDate)
+			assert.Equal(t, eol, testCase.expectedEOL)
+		})
+	}
+}
+
+func TestMatchLayerDigests(t *testing.T) {
+	history := []Layer{{Size: 5}, {Size: 0}, {Size: 3}}
+	assert.DeepEqual(t, matchLayerDigests(history, []string{"sha256:a", "sha256:b"}), []string{"sha256:a", "", "sha256:b"})
+	// an empty layer can't be told apart from an entry without layer
+	assert.DeepEqual(t, matchLayerDigests(history, []string{"sha256:a", "sha256:b", "sha256:c"}), []string{"", "", ""})
+	assert.DeepEqual(t, matchLayerDigests(history, []string{"sha256:a"}), []string{"", "", ""})
+}
+
+func TestSharedLayers(t *testing.T) {
+	layers := []string{"sha256:base1", "sha256:base2", "sha256:app"}
+	assert.DeepEqual(t, sharedLayers(layers, []string{"sha256:base1", "sha256:base2"}), []string{"sha256:base1", "sha256:base2"})
+	assert.Assert(t, sharedLayers(layers, []string{"sha256:other"}) == nil)
+	assert.Assert(t, sharedLayers([]string{"sha256:base1"}, []string{"sha256:base1", "sha256:base2"}) == nil)
+}
@@ -0,0 +1,174 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Kinds of image inputs
+const (
+	// DockerArchive is an archive created by docker save
+	DockerArchive = "docker-archive"
+	// OCIArchive is a tar archive of an OCI image layout
+	OCIArchive = "oci-archive"
+	// OCILayout is an OCI image layout directory, as produced by BuildKit or oras
+	OCILayout = "oci-layout"
+)
+
+// Input is an image stored in a file or a directory instead of the engine
+type Input struct {
+	Path string
+	Kind string
+}
+
+// OpenInput detects the kind of image stored at the given path
+func OpenInput(inputPath string) (Input, error) {
+	abs, err := filepath.Abs(inputPath)
+	if err != nil {
+		return Input{}, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return Input{}, err
+	}
+	if info.IsDir() {
+		if _, err := os.Stat(filepath.Join(abs, "oci-layout")); err != nil {
+			return Input{}, fmt.Errorf("%s is not an OCI image layout directory", inputPath)
+		}
+		return Input{Path: abs, Kind: OCILayout}, nil
+	}
+	read := tarReader(abs)
+	if _, err := read("manifest.json"); err == nil {
+		return Input{Path: abs, Kind: DockerArchive}, nil
+	}
+	if _, err := read("index.json"); err == nil {
+		return Input{Path: abs, Kind: OCIArchive}, nil
+	}
+	return Input{}, fmt.Errorf("%s is not an image archive created by docker save or an OCI image archive", inputPath)
+}
+
+// ProviderReference returns the reference of the image for the provider. An OCI image layout directory
+// is archived to a temporary file, removed by the returned function.
+func (i Input) ProviderReference() (string, func(), error) {
+	if i.Kind != OCILayout {
+		return i.Kind + ":" + i.Path, func() {}, nil
+	}
+	f, err := ioutil.TempFile("", "docker-scan-oci-*.tar")
+	if err != nil {
+		return "", nil, err
+	}
+	remove := func() {
+		os.Remove(f.Name()) //nolint: errcheck
+	}
+	if err := archiveDir(i.Path, f); err != nil {
+		f.Close() //nolint: errcheck
+		remove()
+		return "", nil, err
+	}
+	if err := f.Close(); err != nil {
+		remove()
+		return "", nil, err
+	}
+	return OCIArchive + ":" + f.Name(), remove, nil
+}
+
+// Config returns the container configuration of the image
+func (i Input) Config() (container.Config, error) {
+	return readArchiveConfig(i.reader())
+}
+
+// Extract extracts the layers of the image to a temporary directory, which must be removed by the caller
+func (i Input) Extract() (*ExtractedImage, error) {
+	dir, err := ioutil.TempDir("", "docker-scan-layers")
+	if err != nil {
+		return nil, err
+	}
+	extracted, err := i.extractLayers(dir)
+	if err != nil {
+		os.RemoveAll(dir) //nolint: errcheck
+		return nil, fmt.Errorf("cannot extract the image layers: %s", err)
+	}
+	return extracted, nil
+}
+
+func (i Input) extractLayers(dir string) (*ExtractedImage, error) {
+	if i.Kind == OCILayout {
+		return extractArchiveLayers(i.Path, dir)
+	}
+	archiveDir := filepath.Join(dir, "archive")
+	f, err := os.Open(i.Path)
+	if err != nil {
+		return nil, err
+	}
+	//nolint: errcheck
+	defer f.Close()
+	if _, err := extractFiles(f, archiveDir, false); err != nil {
+		return nil, err
+	}
+	//nolint: errcheck
+	defer os.RemoveAll(archiveDir)
+	return extractArchiveLayers(archiveDir, dir)
+}
+
+func (i Input) reader() fileReader {
+	if i.Kind == OCILayout {
+		return dirReader(i.Path)
+	}
+	return tarReader(i.Path)
+}
+
+// archiveDir writes the regular files of a directory to a tar archive
+func archiveDir(dir string, out io.Writer) error {
+	tw := tar.NewWriter(out)
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		//nolint: errcheck
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
@@ -0,0 +1,176 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+const (
+	testImageConfig = `{"config":{"User":"app","Env":["PATH=/usr/bin"]}}`
+	testOCIIndex    = `{"schemaVersion":2,"manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:manifest"}]}`
+	testOCIManifest = `{"config":{"digest":"sha256:config"},"layers":[{"digest":"sha256:layer1"}]}`
+)
+
+func TestDockerArchiveInput(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("image.tar", testTar(t, map[string]string{
+			"manifest.json":      `[{"Config":"config.json","Layers":["a2c0a9e3/layer.tar"]}]`,
+			"config.json":        testImageConfig,
+			"a2c0a9e3/layer.tar": testTar(t, map[string]string{"etc/os-release": "ID=alpine"}),
+		})))
+	defer dir.Remove()
+
+	input, err := OpenInput(dir.Join("image.tar"))
+	assert.NilError(t, err)
+	assert.Equal(t, input.Kind, DockerArchive)
+
+	ref, remove, err := input.ProviderReference()
+	assert.NilError(t, err)
+	defer remove()
+	assert.Equal(t, ref, "docker-archive:"+dir.Join("image.tar"))
+
+	assertInputContent(t, input, "a2c0a9e3")
+}
+
+func TestDockerArchiveRepeatedLayer(t *testing.T) {
+	layer := testTar(t, map[string]string{"etc/os-release": "ID=alpine"})
+	buf := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buf)
+	// docker save writes the repeated layers as links to the first occurrence, the link comes first here
+	// to check the links are resolved once the whole archive is extracted
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "b7e1d2f4/layer.tar", Typeflag: tar.TypeSymlink, Linkname: "../a2c0a9e3/layer.tar"}))
+	for _, file := range []struct{ name, content string }{
+		{"manifest.json", `[{"Config":"config.json","Layers":["a2c0a9e3/layer.tar","b7e1d2f4/layer.tar"]}]`},
+		{"config.json", testImageConfig},
+		{"a2c0a9e3/layer.tar", layer},
+	} {
+		assert.NilError(t, tw.WriteHeader(&tar.Header{Name: file.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(file.content))}))
+		_, err := tw.Write([]byte(file.content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tw.Close())
+	dir := fs.NewDir(t, t.Name(), fs.WithFile("image.tar", buf.String()))
+	defer dir.Remove()
+
+	input, err := OpenInput(dir.Join("image.tar"))
+	assert.NilError(t, err)
+	extracted, err := input.Extract()
+	assert.NilError(t, err)
+	defer extracted.Remove() //nolint: errcheck
+	assert.DeepEqual(t, extracted.LayerIDs, []string{"a2c0a9e3", "b7e1d2f4"})
+	content, err := ioutil.ReadFile(filepath.Join(extracted.LayerDir(1), "etc", "os-release"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "ID=alpine")
+}
+
+func TestOCILayoutInput(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("oci-layout", `{"imageLayoutVersion":"1.0.0"}`),
+		fs.WithFile("index.json", testOCIIndex),
+		fs.WithDir("blobs", fs.WithDir("sha256",
+			fs.WithFile("manifest", testOCIManifest),
+			fs.WithFile("config", testImageConfig),
+			fs.WithFile("layer1", testTar(t, map[string]string{"etc/os-release": "ID=alpine"})))))
+	defer dir.Remove()
+
+	input, err := OpenInput(dir.Path())
+	assert.NilError(t, err)
+	assert.Equal(t, input.Kind, OCILayout)
+	assertInputContent(t, input, "sha256:layer1")
+
+	// The layout directory is archived for the provider
+	ref, remove, err := input.ProviderReference()
+	assert.NilError(t, err)
+	assert.Assert(t, strings.HasPrefix(ref, "oci-archive:"))
+	archive, err := OpenInput(strings.TrimPrefix(ref, "oci-archive:"))
+	assert.NilError(t, err)
+	assert.Equal(t, archive.Kind, OCIArchive)
+	assertInputContent(t, archive, "sha256:layer1")
+
+	remove()
+	_, err = os.Stat(archive.Path)
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestOpenInvalidInput(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("image.tar", testTar(t, map[string]string{"README": "not an image"})),
+		fs.WithDir("layout"))
+	defer dir.Remove()
+
+	_, err := OpenInput(dir.Join("image.tar"))
+	assert.ErrorContains(t, err, "is not an image archive")
+	_, err = OpenInput(dir.Join("layout"))
+	assert.ErrorContains(t, err, "is not an OCI image layout directory")
+	_, err = OpenInput(dir.Join("missing.tar"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestMultiPlatformInput(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("oci-layout", `{"imageLayoutVersion":"1.0.0"}`),
+		fs.WithFile("index.json", `{"manifests":[{"mediaType":"application/vnd.oci.image.index.v1+json","digest":"sha256:index"}]}`))
+	defer dir.Remove()
+
+	input, err := OpenInput(dir.Path())
+	assert.NilError(t, err)
+	_, err = input.Config()
+	assert.ErrorContains(t, err, "multi-platform images are not supported")
+}
+
+func assertInputContent(t *testing.T, input Input, layerID string) {
+	t.Helper()
+	config, err := input.Config()
+	assert.NilError(t, err)
+	assert.Equal(t, config.User, "app")
+
+	extracted, err := input.Extract()
+	assert.NilError(t, err)
+	defer extracted.Remove() //nolint: errcheck
+	assert.DeepEqual(t, extracted.LayerIDs, []string{layerID})
+	buf, err := ioutil.ReadFile(filepath.Join(extracted.LayerDir(0), "etc", "os-release"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), "ID=alpine")
+}
+
+func testTar(t *testing.T, files map[string]string) string {
+	t.Helper()
+	buf := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buf)
+	for name, content := range files {
+		assert.NilError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+			Size:     int64(len(content)),
+		}))
+		_, err := tw.Write([]byte(content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tw.Close())
+	return buf.String()
+}
@@ -0,0 +1,90 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// layersConfig is the part of an image configuration describing how its layers were built
+type layersConfig struct {
+	History []historyEntry `json:"history"`
+	RootFS  struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+type historyEntry struct {
+	CreatedBy  string `json:"created_by"`
+	EmptyLayer bool   `json:"empty_layer"`
+}
+
+// readLayersConfig reads the history and the diff IDs from the configuration of the image of an archive
+func readLayersConfig(read fileReader, manifest archiveManifest) (layersConfig, error) {
+	buf, err := read(manifest.Config)
+	if err != nil {
+		return layersConfig{}, fmt.Errorf("invalid image archive: %s", err)
+	}
+	var config layersConfig
+	if err := json.Unmarshal(buf, &config); err != nil {
+		return layersConfig{}, fmt.Errorf("invalid image archive: %s", err)
+	}
+	return config, nil
+}
+
+// checkIntegrity compares the diff IDs computed from the extracted layers with the ones recorded in the
+// image configuration, and the layers with the history of the commands which created them.
+// A mismatch means a layer doesn't come from the build step the image claims, for instance after a
+// build cache or registry layer substitution.
+func checkIntegrity(config layersConfig, diffIDs []string) []string {
+	if len(config.RootFS.DiffIDs) == 0 {
+		// nothing was recorded to check the layers against
+		return nil
+	}
+	var warnings []string
+	createdBy := layersCreatedBy(config.History)
+	if len(config.History) > 0 && len(createdBy) != len(config.RootFS.DiffIDs) {
+		warnings = append(warnings, fmt.Sprintf("the image history records %d layers but its configuration lists %d", len(createdBy), len(config.RootFS.DiffIDs)))
+	}
+	if len(diffIDs) != len(config.RootFS.DiffIDs) {
+		warnings = append(warnings, fmt.Sprintf("the image manifest has %d layers but its configuration lists %d", len(diffIDs), len(config.RootFS.DiffIDs)))
+		return warnings
+	}
+	for index, diffID := range diffIDs {
+		if diffID == config.RootFS.DiffIDs[index] {
+			continue
+		}
+		step := "an unknown build step"
+		if index < len(createdBy) {
+			step = fmt.Sprintf("%q", createdBy[index])
+		}
+		warnings = append(warnings, fmt.Sprintf("layer %d (%s) does not match the diff ID %s recorded for %s", index, diffID, config.RootFS.DiffIDs[index], step))
+	}
+	return warnings
+}
+
+// layersCreatedBy returns the commands of the history entries which created a layer
+func layersCreatedBy(history []historyEntry) []string {
+	var createdBy []string
+	for _, entry := range history {
+		if !entry.EmptyLayer {
+			createdBy = append(createdBy, entry.CreatedBy)
+		}
+	}
+	return createdBy
+}
@@ -0,0 +1,99 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestExtractLayerArchiveDiffID(t *testing.T) {
+	layer := testTar(t, map[string]string{"etc/os-release": "ID=alpine"})
+	expected := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(layer)))
+
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	diffID, _, err := extractLayerArchive(bytes.NewBufferString(layer), dir.Join("plain"))
+	assert.NilError(t, err)
+	assert.Equal(t, diffID, expected)
+
+	compressed := bytes.NewBuffer(nil)
+	gw := gzip.NewWriter(compressed)
+	_, err = gw.Write([]byte(layer))
+	assert.NilError(t, err)
+	assert.NilError(t, gw.Close())
+	diffID, _, err = extractLayerArchive(compressed, dir.Join("gzip"))
+	assert.NilError(t, err)
+	assert.Equal(t, diffID, expected)
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	history := []historyEntry{
+		{CreatedBy: "/bin/sh -c #(nop) ADD file:b2c1 in / "},
+		{CreatedBy: "/bin/sh -c #(nop)  CMD [\"/bin/sh\"]", EmptyLayer: true},
+		{CreatedBy: "RUN /bin/sh -c apk add curl"},
+	}
+	testCases := []struct {
+		name     string
+		config   layersConfig
+		diffIDs  []string
+		expected []string
+	}{
+		{
+			name:    "consistent",
+			config:  newLayersConfig(history, "sha256:a", "sha256:b"),
+			diffIDs: []string{"sha256:a", "sha256:b"},
+		},
+		{
+			name:    "no recorded diff IDs",
+			config:  layersConfig{},
+			diffIDs: []string{"sha256:a"},
+		},
+		{
+			name:     "substituted layer",
+			config:   newLayersConfig(history, "sha256:a", "sha256:b"),
+			diffIDs:  []string{"sha256:a", "sha256:c"},
+			expected: []string{`layer 1 (sha256:c) does not match the diff ID sha256:b recorded for "RUN /bin/sh -c apk add curl"`},
+		},
+		{
+			name:    "history and layers mismatch",
+			config:  newLayersConfig(history, "sha256:a", "sha256:b", "sha256:c"),
+			diffIDs: []string{"sha256:a", "sha256:b"},
+			expected: []string{
+				"the image history records 2 layers but its configuration lists 3",
+				"the image manifest has 2 layers but its configuration lists 3",
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.DeepEqual(t, checkIntegrity(testCase.config, testCase.diffIDs), testCase.expected)
+		})
+	}
+}
+
+func newLayersConfig(history []historyEntry, diffIDs ...string) layersConfig {
+	config := layersConfig{History: history}
+	config.RootFS.DiffIDs = diffIDs
+	return config
+}
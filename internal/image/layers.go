@@ -0,0 +1,265 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/client"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	// opaqueWhiteout marks a directory whose content in the previous layers is deleted
+	opaqueWhiteout = whiteoutPrefix + whiteoutPrefix + ".opq"
+)
+
+// ExtractLayers exports an image from the engine and extracts the files of each layer to a sub directory of dir,
+// named after the position of the layer starting at 0.
+func ExtractLayers(ctx context.Context, cli client.APIClient, ref string, dir string) (*ExtractedImage, error) {
+	saveDir := filepath.Join(dir, "save")
+	if err := saveImage(ctx, cli, ref, saveDir); err != nil {
+		return nil, err
+	}
+	//nolint: errcheck
+	defer os.RemoveAll(saveDir)
+	return extractArchiveLayers(saveDir, dir)
+}
+
+// extractArchiveLayers extracts the layers of an image archive, already extracted to archiveDir,
+// and checks them against the history and the diff IDs recorded in the image configuration
+func extractArchiveLayers(archiveDir string, dir string) (*ExtractedImage, error) {
+	read := dirReader(archiveDir)
+	manifest, err := readArchiveManifest(read)
+	if err != nil {
+		return nil, err
+	}
+	extracted := &ExtractedImage{Dir: dir}
+	var diffIDs []string
+	for index, layer := range manifest.Layers {
+		diffID, deleted, err := extractLayer(filepath.Join(archiveDir, filepath.FromSlash(path.Clean("/"+layer))), filepath.Join(dir, strconv.Itoa(index)))
+		if err != nil {
+			return nil, err
+		}
+		extracted.LayerIDs = append(extracted.LayerIDs, layerID(layer))
+		extracted.Deleted = append(extracted.Deleted, deleted)
+		diffIDs = append(diffIDs, diffID)
+	}
+	config, err := readLayersConfig(read, manifest)
+	if err != nil {
+		return nil, err
+	}
+	extracted.IntegrityWarnings = checkIntegrity(config, diffIDs)
+	return extracted, nil
+}
+
+func saveImage(ctx context.Context, cli client.APIClient, ref string, dir string) error {
+	reader, err := cli.ImageSave(ctx, []string{ref})
+	if err != nil {
+		return err
+	}
+	//nolint: errcheck
+	defer reader.Close()
+	_, err = extractFiles(reader, dir, false)
+	return err
+}
+
+// extractLayer extracts a layer archive to dir and returns its diff ID and the paths deleted by its whiteout files
+func extractLayer(layerPath string, dir string) (string, []string, error) {
+	f, err := os.Open(layerPath)
+	if err != nil {
+		return "", nil, err
+	}
+	//nolint: errcheck
+	defer f.Close()
+	return extractLayerArchive(f, dir)
+}
+
+// ExtractArchive extracts the regular files of a layer archive, compressed or not, to dir.
+// Links, devices and whiteout files are skipped.
+func ExtractArchive(reader io.Reader, dir string) error {
+	_, _, err := extractLayerArchive(reader, dir)
+	return err
+}
+
+// extractLayerArchive extracts a layer archive like ExtractArchive and returns its diff ID, the digest of the
+// uncompressed archive, and the paths deleted by its whiteout files
+func extractLayerArchive(reader io.Reader, dir string) (string, []string, error) {
+	buffered := bufio.NewReader(reader)
+	var uncompressed io.Reader = buffered
+	magic, err := buffered.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return "", nil, err
+		}
+		//nolint: errcheck
+		defer gzipReader.Close()
+		uncompressed = gzipReader
+	}
+	hash := sha256.New()
+	tee := io.TeeReader(uncompressed, hash)
+	deleted, err := extractFiles(tee, dir, true)
+	if err != nil {
+		return "", nil, err
+	}
+	// the tar reader stops at the end-of-archive marker, the padding must be hashed too
+	if _, err := io.Copy(ioutil.Discard, tee); err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), deleted, nil
+}
+
+// extractFiles extracts the regular files of an archive to dir. The links of layer archives are skipped, as well as
+// their whiteout files, while the symbolic links of image archives are resolved inside the archive and extracted as
+// copies of their targets, like the layer.tar files that docker save links to the first occurrence of a repeated layer.
+// The paths deleted by the whiteout files of a layer are returned, a directory for an opaque whiteout.
+func extractFiles(reader io.Reader, dir string, layer bool) ([]string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	var links []*tar.Header
+	var deleted []string
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return deleted, resolveLinks(dir, links)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeSymlink && !layer {
+			links = append(links, header)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		// cleaning the path as an absolute one prevents any path traversal out of dir
+		name := path.Clean("/" + header.Name)
+		if layer && strings.HasPrefix(path.Base(name), whiteoutPrefix) {
+			deleted = append(deleted, whiteoutTarget(name))
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return nil, err
+		}
+		if err := writeFile(target, tr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// whiteoutTarget returns the path deleted by a whiteout file
+func whiteoutTarget(name string) string {
+	if path.Base(name) == opaqueWhiteout {
+		return path.Dir(name)
+	}
+	return path.Join(path.Dir(name), strings.TrimPrefix(path.Base(name), whiteoutPrefix))
+}
+
+// resolveLinks copies the regular files targeted by symbolic links of an archive extracted to dir,
+// links to missing files or out of the archive are skipped
+func resolveLinks(dir string, links []*tar.Header) error {
+	for _, link := range links {
+		name := path.Clean("/" + link.Name)
+		target := link.Linkname
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(name), target)
+		}
+		source := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+target)))
+		if info, err := os.Lstat(source); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if err := copyLayerFile(source, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(target string, reader io.Reader) error {
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, reader); err != nil {
+		f.Close() //nolint: errcheck
+		return err
+	}
+	return f.Close()
+}
+
+// layerID returns the ID of a layer from its path in the image archive, either
+// <id>/layer.tar for the legacy format or blobs/<algorithm>/<digest> for the OCI format
+func layerID(layerPath string) string {
+	parts := strings.Split(path.Clean(layerPath), "/")
+	if len(parts) == 3 && parts[0] == "blobs" {
+		return parts[1] + ":" + parts[2]
+	}
+	return parts[0]
+}
+
+// ExtractedImage is an image whose layers have been extracted to a temporary directory
+type ExtractedImage struct {
+	Dir string
+	// LayerIDs are the IDs of the layers, from the oldest to the most recent
+	LayerIDs []string
+	// Deleted lists for each layer the paths deleted from the previous layers by its whiteout files
+	Deleted [][]string
+	// IntegrityWarnings report the layers which don't match the image history or configuration,
+	// a possible sign of a layer substitution
+	IntegrityWarnings []string
+}
+
+// Extract exports an image from the engine and extracts its layers to a temporary directory,
+// which must be removed by the caller
+func Extract(ctx context.Context, cli client.APIClient, ref string) (*ExtractedImage, error) {
+	dir, err := ioutil.TempDir("", "docker-scan-layers")
+	if err != nil {
+		return nil, err
+	}
+	extracted, err := ExtractLayers(ctx, cli, ref, dir)
+	if err != nil {
+		os.RemoveAll(dir) //nolint: errcheck
+		return nil, fmt.Errorf("cannot extract the image layers: %s", err)
+	}
+	return extracted, nil
+}
+
+// LayerDir returns the directory where the files of a layer are extracted
+func (e *ExtractedImage) LayerDir(index int) string {
+	return filepath.Join(e.Dir, strconv.Itoa(index))
+}
+
+// Remove deletes the extracted files
+func (e *ExtractedImage) Remove() error {
+	return os.RemoveAll(e.Dir)
+}
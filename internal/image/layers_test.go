@@ -0,0 +1,74 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestExtractArchive(t *testing.T) {
+	archive := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(archive)
+	for _, file := range []struct {
+		name     string
+		typeflag byte
+		content  string
+	}{
+		{name: "usr/bin/tool", typeflag: tar.TypeReg, content: "binary"},
+		{name: "../../escape", typeflag: tar.TypeReg, content: "outside"},
+		{name: "etc/.wh.passwd", typeflag: tar.TypeReg},
+		{name: "etc/link", typeflag: tar.TypeSymlink},
+	} {
+		assert.NilError(t, tw.WriteHeader(&tar.Header{
+			Name:     file.name,
+			Typeflag: file.typeflag,
+			Linkname: "/etc/shadow",
+			Mode:     0755,
+			Size:     int64(len(file.content)),
+		}))
+		_, err := tw.Write([]byte(file.content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tw.Close())
+
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	assert.NilError(t, ExtractArchive(archive, dir.Path()))
+
+	buf, err := ioutil.ReadFile(dir.Join("usr", "bin", "tool"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), "binary")
+	buf, err = ioutil.ReadFile(dir.Join("escape"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), "outside")
+	_, err = os.Lstat(dir.Join("etc", ".wh.passwd"))
+	assert.Assert(t, os.IsNotExist(err))
+	_, err = os.Lstat(dir.Join("etc", "link"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestLayerID(t *testing.T) {
+	assert.Equal(t, layerID("a2c0a9e3/layer.tar"), "a2c0a9e3")
+	assert.Equal(t, layerID("blobs/sha256/a2c0a9e3"), "sha256:a2c0a9e3")
+}
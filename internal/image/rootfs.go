@@ -0,0 +1,82 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// MergeLayers copies to dir the files of the extracted layers as the analyzers see them: each file comes from the
+// most recent layer containing it. As whiteouts are skipped at extraction, the files deleted by a layer are kept.
+// dir is created if needed and must be empty.
+func (e *ExtractedImage) MergeLayers(dir string) error {
+	if err := checkEmptyDir(dir); err != nil {
+		return err
+	}
+	for index := len(e.LayerIDs) - 1; index >= 0; index-- {
+		root := e.LayerDir(index)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return err
+			}
+			relative, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			target := filepath.Join(dir, relative)
+			if _, err := os.Lstat(target); err == nil {
+				// shadowed by a more recent layer
+				return nil
+			}
+			return copyLayerFile(path, target)
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkEmptyDir(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(dir, 0755)
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("output directory %q is not empty", dir)
+	}
+	return nil
+}
+
+func copyLayerFile(source string, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	//nolint: errcheck
+	defer f.Close()
+	return writeFile(target, f)
+}
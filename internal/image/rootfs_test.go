@@ -0,0 +1,52 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestMergeLayers(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("0",
+			fs.WithDir("etc", fs.WithFile("os-release", "ID=alpine"), fs.WithFile("passwd", "root:x:0:0"))),
+		fs.WithDir("1",
+			fs.WithDir("etc", fs.WithFile("passwd", "root:x:0:0\napp:x:1000:1000")),
+			fs.WithDir("app", fs.WithFile("main.js", "console.log()"))),
+	)
+	defer dir.Remove()
+	extracted := &ExtractedImage{Dir: dir.Path(), LayerIDs: []string{"base", "app"}}
+
+	output := dir.Join("rootfs")
+	assert.NilError(t, extracted.MergeLayers(output))
+	for path, expected := range map[string]string{
+		"etc/os-release": "ID=alpine",
+		"etc/passwd":     "root:x:0:0\napp:x:1000:1000",
+		"app/main.js":    "console.log()",
+	} {
+		buf, err := ioutil.ReadFile(filepath.Join(output, filepath.FromSlash(path)))
+		assert.NilError(t, err)
+		assert.Equal(t, string(buf), expected)
+	}
+
+	assert.ErrorContains(t, extracted.MergeLayers(output), "is not empty")
+}
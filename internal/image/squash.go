@@ -0,0 +1,80 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HiddenFile is a file of a layer which is not part of the image filesystem, as a more recent layer replaces or
+// deletes it. It is still shipped with the image, and would disappear if the image were squashed.
+type HiddenFile struct {
+	Layer string `json:"layer"`
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+}
+
+// HiddenFiles lists the files of the layers replaced or deleted by a more recent layer
+func (e *ExtractedImage) HiddenFiles() ([]HiddenFile, error) {
+	hidden := []HiddenFile{}
+	for index, layerID := range e.LayerIDs {
+		root := e.LayerDir(index)
+		err := filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return err
+			}
+			relative, err := filepath.Rel(root, file)
+			if err != nil {
+				return err
+			}
+			name := "/" + filepath.ToSlash(relative)
+			if e.hidden(index, name) {
+				hidden = append(hidden, HiddenFile{Layer: layerID, Path: name, Size: info.Size()})
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return hidden, nil
+}
+
+// hidden returns true if a layer more recent than the given one replaces or deletes the file
+func (e *ExtractedImage) hidden(index int, name string) bool {
+	for next := index + 1; next < len(e.LayerIDs); next++ {
+		if _, err := os.Lstat(filepath.Join(e.LayerDir(next), filepath.FromSlash(name))); err == nil {
+			return true
+		}
+		if next < len(e.Deleted) && deletes(e.Deleted[next], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// deletes returns true if one of the deleted paths is the file or one of its parent directories
+func deletes(deleted []string, name string) bool {
+	for _, deletedPath := range deleted {
+		if name == deletedPath || strings.HasPrefix(name, strings.TrimSuffix(deletedPath, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
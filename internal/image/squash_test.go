@@ -0,0 +1,50 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestHiddenFiles(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	extracted := &ExtractedImage{Dir: dir.Path(), LayerIDs: []string{"sha256:base", "sha256:build", "sha256:app"}}
+	layers := []map[string]string{
+		{"etc/os-release": "ID=alpine", "etc/passwd": "root", "src/Makefile": "all:"},
+		{"root/.ssh/id_rsa": "key", "src/build/tool": "binary", "etc/passwd": "root,app"},
+		{"src/.wh..wh..opq": "", "root/.ssh/.wh.id_rsa": "", "app/server": "binary"},
+	}
+	for index, files := range layers {
+		_, deleted, err := extractLayerArchive(bytes.NewBufferString(testTar(t, files)), extracted.LayerDir(index))
+		assert.NilError(t, err)
+		extracted.Deleted = append(extracted.Deleted, deleted)
+	}
+
+	hidden, err := extracted.HiddenFiles()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, hidden, []HiddenFile{
+		{Layer: "sha256:base", Path: "/etc/passwd", Size: 4},
+		{Layer: "sha256:base", Path: "/src/Makefile", Size: 4},
+		{Layer: "sha256:build", Path: "/root/.ssh/id_rsa", Size: 3},
+		{Layer: "sha256:build", Path: "/src/build/tool", Size: 6},
+	})
+}
@@ -0,0 +1,112 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package invocation
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	cliConfig "github.com/docker/cli/cli/config"
+	"github.com/docker/scan-cli-plugin/internal/filelock"
+)
+
+// maxInvocations is the number of invocations kept in the log, the oldest ones are removed
+const maxInvocations = 100
+
+// Invocation describes a run of the scan provider, one JSON line of the invocation log
+type Invocation struct {
+	Time time.Time `json:"time"`
+	// Command is the command line of the provider, with its secrets redacted
+	Command  []string `json:"command"`
+	Duration string   `json:"duration"`
+	ExitCode int      `json:"exitCode"`
+	Error    string   `json:"error,omitempty"`
+	// Stderr is the end of the error output of the provider
+	Stderr string `json:"stderr,omitempty"`
+}
+
+// Failed returns true if the provider failed, exiting with 1 only tells vulnerabilities were found
+func (i Invocation) Failed() bool {
+	return i.Error != "" || i.ExitCode > 1 || i.ExitCode < 0
+}
+
+// DefaultPath returns the invocation log of the docker scan configuration
+func DefaultPath() string {
+	return filepath.Join(cliConfig.Dir(), "scan", "invocations.jsonl")
+}
+
+// Append adds an invocation to the log, keeping the most recent ones
+func Append(path string, invocation Invocation) error {
+	unlock, err := filelock.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	invocations, err := Read(path)
+	if err != nil {
+		return err
+	}
+	invocations = append(invocations, invocation)
+	if len(invocations) > maxInvocations {
+		invocations = invocations[len(invocations)-maxInvocations:]
+	}
+	buf := bytes.NewBuffer(nil)
+	encoder := json.NewEncoder(buf)
+	for _, invocation := range invocations {
+		if err := encoder.Encode(invocation); err != nil {
+			return err
+		}
+	}
+	return filelock.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// Read returns the invocations of the log, from the oldest to the most recent one. The invalid lines are skipped.
+func Read(path string) ([]Invocation, error) {
+	buf, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var invocations []Invocation
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var invocation Invocation
+		if err := json.Unmarshal(scanner.Bytes(), &invocation); err == nil {
+			invocations = append(invocations, invocation)
+		}
+	}
+	return invocations, nil
+}
+
+// LastFailed returns the most recent failed invocation
+func LastFailed(invocations []Invocation) (Invocation, bool) {
+	for i := len(invocations) - 1; i >= 0; i-- {
+		if invocations[i].Failed() {
+			return invocations[i], true
+		}
+	}
+	return Invocation{}, false
+}
@@ -0,0 +1,69 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package invocation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestAppend(t *testing.T) {
+	dir := fs.NewDir(t, "invocations")
+	defer dir.Remove()
+	path := dir.Join("scan", "invocations.jsonl")
+
+	invocations, err := Read(path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(invocations), 0)
+
+	start := time.Date(2021, time.March, 12, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < maxInvocations+5; i++ {
+		invocation := Invocation{Time: start.Add(time.Duration(i) * time.Minute), Command: []string{"snyk", "container", "test", fmt.Sprintf("image%d", i)}}
+		if i == 50 {
+			invocation.ExitCode = 2
+			invocation.Stderr = "Error: invalid image reference"
+		}
+		assert.NilError(t, Append(path, invocation))
+	}
+	invocations, err = Read(path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(invocations), maxInvocations)
+	assert.Equal(t, invocations[0].Command[3], "image5")
+	assert.Equal(t, invocations[maxInvocations-1].Command[3], fmt.Sprintf("image%d", maxInvocations+4))
+
+	failed, ok := LastFailed(invocations)
+	assert.Assert(t, ok)
+	assert.Equal(t, failed.Command[3], "image50")
+	assert.Equal(t, failed.Stderr, "Error: invalid image reference")
+}
+
+func TestReadSkipsInvalidLines(t *testing.T) {
+	dir := fs.NewDir(t, "invocations", fs.WithFile("invocations.jsonl", `{"command": ["snyk", "--version"], "exitCode": 0}
+not json
+{"command": ["snyk", "container", "test", "alpine"], "exitCode": 1}
+`))
+	defer dir.Remove()
+	invocations, err := Read(dir.Join("invocations.jsonl"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(invocations), 2)
+	_, ok := LastFailed(invocations)
+	assert.Assert(t, !ok)
+}
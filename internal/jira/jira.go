@@ -0,0 +1,134 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client calls the Jira REST API
+type Client struct {
+	URL        string
+	Username   string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// Issue holds the fields of an issue to be created
+type Issue struct {
+	Project     string
+	IssueType   string
+	Summary     string
+	Description string
+	Labels      []string
+}
+
+// FindIssue returns the key of an issue of the project with the given label
+func (c *Client) FindIssue(ctx context.Context, project, label string) (string, bool, error) {
+	query := url.Values{}
+	query.Set("jql", fmt.Sprintf("project = %q AND labels = %q", project, label))
+	query.Set("fields", "key")
+	query.Set("maxResults", "1")
+	var result struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/2/search?"+query.Encode(), nil, &result); err != nil {
+		return "", false, err
+	}
+	if len(result.Issues) == 0 {
+		return "", false, nil
+	}
+	return result.Issues[0].Key, true, nil
+}
+
+// CreateIssue creates the issue and returns its key
+func (c *Client) CreateIssue(ctx context.Context, issue Issue) (string, error) {
+	request := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": issue.Project},
+			"issuetype":   map[string]string{"name": issue.IssueType},
+			"summary":     issue.Summary,
+			"description": issue.Description,
+			"labels":      issue.Labels,
+		},
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/issue", request, &created); err != nil {
+		return "", err
+	}
+	return created.Key, nil
+}
+
+// UpdateDescription replaces the description of an issue
+func (c *Client) UpdateDescription(ctx context.Context, key, description string) error {
+	request := map[string]interface{}{
+		"fields": map[string]string{"description": description},
+	}
+	return c.do(ctx, http.MethodPut, "/rest/api/2/issue/"+url.PathEscape(key), request, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, request, response interface{}) error {
+	var body io.Reader
+	if request != nil {
+		buf, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.URL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.Username, c.Token)
+	req.Header.Set("Accept", "application/json")
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("jira request %s %s failed with status %q: %s", method, path, resp.Status, strings.TrimSpace(string(buf)))
+	}
+	if response == nil {
+		return nil
+	}
+	return json.Unmarshal(buf, response)
+}
@@ -0,0 +1,128 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package jira
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// Label is set on all the issues created by docker scan
+const Label = "docker-scan"
+
+// SyncResult lists the keys of the created and updated issues
+type SyncResult struct {
+	Created []string
+	Updated []string
+}
+
+type findingGroup struct {
+	cve      string
+	findings []report.Vulnerability
+}
+
+// Sync opens an issue per CVE found in the image, or updates the issue opened by a previous scan
+// of the same image, found with a label identifying the CVE and the image
+func Sync(ctx context.Context, client *Client, project, issueType, image string, findings []report.Vulnerability) (SyncResult, error) {
+	var result SyncResult
+	for _, group := range groupByCVE(findings) {
+		label := DedupLabel(group.cve, image)
+		description := describe(image, group.findings)
+		key, found, err := client.FindIssue(ctx, project, label)
+		if err != nil {
+			return result, err
+		}
+		if found {
+			if err := client.UpdateDescription(ctx, key, description); err != nil {
+				return result, err
+			}
+			result.Updated = append(result.Updated, key)
+			continue
+		}
+		key, err = client.CreateIssue(ctx, Issue{
+			Project:     project,
+			IssueType:   issueType,
+			Summary:     fmt.Sprintf("%s: %s in %s", group.cve, group.findings[0].Title, image),
+			Description: description,
+			Labels:      []string{Label, label},
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Created = append(result.Created, key)
+	}
+	return result, nil
+}
+
+// DedupLabel returns the label identifying the issue of a CVE found in an image
+func DedupLabel(cve, image string) string {
+	hash := sha256.Sum256([]byte(cve + "\x00" + image))
+	return Label + "-" + hex.EncodeToString(hash[:])[:16]
+}
+
+func groupByCVE(findings []report.Vulnerability) []findingGroup {
+	groups := map[string]*findingGroup{}
+	var cves []string
+	for _, finding := range findings {
+		for _, cve := range finding.CVEs() {
+			group, ok := groups[cve]
+			if !ok {
+				group = &findingGroup{cve: cve}
+				groups[cve] = group
+				cves = append(cves, cve)
+			}
+			group.findings = append(group.findings, finding)
+		}
+	}
+	sort.Strings(cves)
+	var sorted []findingGroup
+	for _, cve := range cves {
+		sorted = append(sorted, *groups[cve])
+	}
+	return sorted
+}
+
+func describe(image string, findings []report.Vulnerability) string {
+	lines := []string{
+		fmt.Sprintf("%s was found by docker scan in the image %s.", findings[0].Title, image),
+		"",
+		fmt.Sprintf("Severity: %s", findings[0].Severity),
+	}
+	seen := map[string]bool{}
+	for _, finding := range findings {
+		location := finding.Path
+		if finding.PackageName != "" {
+			location = finding.PackageName + "@" + finding.Version
+		}
+		if seen[location] {
+			continue
+		}
+		seen[location] = true
+		line := fmt.Sprintf("* %s (%s)", location, finding.ID)
+		if len(finding.FixedIn) > 0 {
+			line += ", fixed in " + strings.Join(finding.FixedIn, ", ")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
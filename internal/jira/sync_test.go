@@ -0,0 +1,119 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+)
+
+// fakeJira stores the issues created, indexed by their dedup label
+type fakeJira struct {
+	issues       map[string]map[string]interface{}
+	descriptions map[string]string
+}
+
+func (f *fakeJira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, token, ok := r.BasicAuth(); !ok || user != "user" || token != "token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/search":
+		var issues []map[string]string
+		for label := range f.issues {
+			if strings.Contains(r.URL.Query().Get("jql"), fmt.Sprintf("labels = %q", label)) {
+				issues = append(issues, map[string]string{"key": f.issues[label]["key"].(string)})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"issues": issues})
+	case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+		var request struct {
+			Fields map[string]interface{} `json:"fields"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		key := fmt.Sprintf("SEC-%d", len(f.issues)+1)
+		request.Fields["key"] = key
+		labels := request.Fields["labels"].([]interface{})
+		f.issues[labels[1].(string)] = request.Fields
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"key":%q}`, key)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/rest/api/2/issue/"):
+		var request struct {
+			Fields map[string]string `json:"fields"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		f.descriptions[strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/")] = request.Fields["description"]
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestSync(t *testing.T) {
+	fake := &fakeJira{issues: map[string]map[string]interface{}{}, descriptions: map[string]string{}}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+	client := &Client{URL: ts.URL, Username: "user", Token: "token"}
+
+	findings := []report.Vulnerability{
+		{ID: "SNYK-1", Title: "Overflow", Severity: "high", PackageName: "openssl", Version: "1.1.1d",
+			FixedIn: []string{"1.1.1g"}, Identifiers: map[string][]string{"CVE": {"CVE-2020-1971"}}},
+		{ID: "SNYK-1", Title: "Overflow", Severity: "high", PackageName: "openssl", Version: "1.1.1d",
+			FixedIn: []string{"1.1.1g"}, Identifiers: map[string][]string{"CVE": {"CVE-2020-1971"}}},
+		{ID: "SNYK-2", Title: "Use after free", Severity: "critical", PackageName: "curl", Version: "7.64.0"},
+	}
+	result, err := Sync(context.Background(), client, "SEC", "Bug", "myimage:latest", findings)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, SyncResult{Created: []string{"SEC-1", "SEC-2"}})
+
+	issue := fake.issues[DedupLabel("CVE-2020-1971", "myimage:latest")]
+	assert.Equal(t, issue["summary"], "CVE-2020-1971: Overflow in myimage:latest")
+	assert.Equal(t, issue["description"], `Overflow was found by docker scan in the image myimage:latest.
+
+Severity: high
+* openssl@1.1.1d (SNYK-1), fixed in 1.1.1g`)
+	assert.DeepEqual(t, issue["issuetype"], map[string]interface{}{"name": "Bug"})
+
+	// Scanning again updates the existing issues instead of creating new ones
+	result, err = Sync(context.Background(), client, "SEC", "Bug", "myimage:latest", findings)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, SyncResult{Updated: []string{"SEC-1", "SEC-2"}})
+	assert.Equal(t, len(fake.descriptions), 2)
+
+	// The same CVE found in another image gets its own issue
+	result, err = Sync(context.Background(), client, "SEC", "Bug", "otherimage:latest", findings[:1])
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, SyncResult{Created: []string{"SEC-3"}})
+}
+
+func TestClientError(t *testing.T) {
+	ts := httptest.NewServer(&fakeJira{})
+	defer ts.Close()
+	client := &Client{URL: ts.URL, Username: "user", Token: "invalid"}
+
+	_, _, err := client.FindIssue(context.Background(), "SEC", "label")
+	assert.ErrorContains(t, err, `failed with status "401 Unauthorized"`)
+}
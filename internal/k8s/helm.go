@@ -0,0 +1,46 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// RenderChart renders the manifests of a Helm chart with helm template, using the values files if any
+func RenderChart(ctx context.Context, chart string, valuesFiles []string) ([]byte, error) {
+	helm, err := exec.LookPath("helm")
+	if err != nil {
+		return nil, fmt.Errorf("the helm binary is required to render the chart %s: %s", chart, err)
+	}
+	args := []string{"template", "docker-scan", chart}
+	for _, values := range valuesFiles {
+		args = append(args, "--values", values)
+	}
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd := exec.CommandContext(ctx, helm, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to render the chart %s: %s", chart, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
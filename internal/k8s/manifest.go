@@ -0,0 +1,158 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package k8s
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// containerFields are the fields of the pod specs listing containers
+var containerFields = []string{"initContainers", "containers", "ephemeralContainers"}
+
+// Workload is a Kubernetes object running containers, like a Deployment, a CronJob or a Pod
+type Workload struct {
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace,omitempty"`
+	Images    []string `json:"images"`
+}
+
+// String returns the kind and name of the workload, prefixed by its namespace if any
+func (w Workload) String() string {
+	if w.Namespace != "" {
+		return fmt.Sprintf("%s/%s/%s", w.Namespace, w.Kind, w.Name)
+	}
+	return fmt.Sprintf("%s/%s", w.Kind, w.Name)
+}
+
+// ParseManifests returns the workloads of a stream of YAML documents, the objects without containers being ignored
+func ParseManifests(r io.Reader) ([]Workload, error) {
+	decoder := yaml.NewDecoder(r)
+	var workloads []Workload
+	for {
+		var document map[interface{}]interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			return workloads, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest: %s", err)
+		}
+		workloads = append(workloads, documentWorkloads(document)...)
+	}
+}
+
+// documentWorkloads returns the workload of the document, or of the items of a List
+func documentWorkloads(document map[interface{}]interface{}) []Workload {
+	if document["kind"] == "List" {
+		var workloads []Workload
+		items, _ := document["items"].([]interface{})
+		for _, item := range items {
+			if object, ok := item.(map[interface{}]interface{}); ok {
+				workloads = append(workloads, documentWorkloads(object)...)
+			}
+		}
+		return workloads
+	}
+	images := containerImages(document, map[string]bool{})
+	if len(images) == 0 {
+		return nil
+	}
+	workload := Workload{Kind: fmt.Sprint(document["kind"]), Images: images}
+	if metadata, ok := document["metadata"].(map[interface{}]interface{}); ok {
+		workload.Name, _ = metadata["name"].(string)
+		workload.Namespace, _ = metadata["namespace"].(string)
+	}
+	return []Workload{workload}
+}
+
+// containerImages walks the object to find the images of the containers of its pod specs, whatever their nesting
+// in the object, in order of appearance
+func containerImages(value interface{}, seen map[string]bool) []string {
+	var images []string
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		for _, field := range containerFields {
+			containers, _ := value[field].([]interface{})
+			for _, container := range containers {
+				image := imageOf(container)
+				if image != "" && !seen[image] {
+					seen[image] = true
+					images = append(images, image)
+				}
+			}
+		}
+		for _, key := range sortedKeys(value) {
+			if !isContainerField(key) {
+				images = append(images, containerImages(value[key], seen)...)
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			images = append(images, containerImages(item, seen)...)
+		}
+	}
+	return images
+}
+
+func imageOf(container interface{}) string {
+	fields, ok := container.(map[interface{}]interface{})
+	if !ok {
+		return ""
+	}
+	image, _ := fields["image"].(string)
+	return image
+}
+
+func isContainerField(key interface{}) bool {
+	for _, field := range containerFields {
+		if key == field {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(value map[interface{}]interface{}) []interface{} {
+	keys := make([]interface{}, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
+
+// Images returns the images of the workloads, each one once in order of appearance
+func Images(workloads []Workload) []string {
+	seen := map[string]bool{}
+	var images []string
+	for _, workload := range workloads {
+		for _, image := range workload.Images {
+			if !seen[image] {
+				seen[image] = true
+				images = append(images, image)
+			}
+		}
+	}
+	return images
+}
@@ -0,0 +1,85 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+const manifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: registry.example.com/shop/migrate:1.2
+      containers:
+        - name: web
+          image: registry.example.com/shop/web:1.2
+        - name: proxy
+          image: nginx:1.21
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: backup
+              image: nginx:1.21
+---
+apiVersion: v1
+kind: List
+items:
+  - kind: Pod
+    metadata:
+      name: debug
+    spec:
+      containers:
+        - image: alpine:3.15
+`
+
+func TestParseManifests(t *testing.T) {
+	workloads, err := ParseManifests(strings.NewReader(manifests))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, workloads, []Workload{
+		{Kind: "Deployment", Name: "web", Namespace: "shop", Images: []string{"registry.example.com/shop/migrate:1.2", "registry.example.com/shop/web:1.2", "nginx:1.21"}},
+		{Kind: "CronJob", Name: "backup", Images: []string{"nginx:1.21"}},
+		{Kind: "Pod", Name: "debug", Images: []string{"alpine:3.15"}},
+	})
+	assert.Equal(t, workloads[0].String(), "shop/Deployment/web")
+	assert.DeepEqual(t, Images(workloads), []string{"registry.example.com/shop/migrate:1.2", "registry.example.com/shop/web:1.2", "nginx:1.21", "alpine:3.15"})
+
+	_, err = ParseManifests(strings.NewReader("kind: [Deployment"))
+	assert.ErrorContains(t, err, "invalid manifest")
+}
@@ -0,0 +1,156 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package licenses
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// Package types
+const (
+	APK    = "apk"
+	DPKG   = "deb"
+	NPM    = "npm"
+	Python = "python"
+)
+
+const (
+	apkDatabase    = "/lib/apk/db/installed"
+	dpkgStatus     = "/var/lib/dpkg/status"
+	dpkgCopyrights = "/usr/share/doc/"
+)
+
+// file is a file of the image filesystem, extracted from the most recent layer containing it
+type file struct {
+	hostPath string
+	layer    string
+}
+
+// Scan lists the OS packages installed by apk or dpkg and the npm and Python packages of the image,
+// with the licenses they declare
+func Scan(extracted *image.ExtractedImage) ([]report.PackageLicense, error) {
+	files, err := metadataFiles(extracted)
+	if err != nil {
+		return nil, err
+	}
+	packages := []report.PackageLicense{}
+	for imagePath, f := range files {
+		var found []report.PackageLicense
+		switch {
+		case imagePath == apkDatabase:
+			found = readAPKDatabase(f.hostPath)
+		case imagePath == dpkgStatus:
+			found = readDPKGStatus(f.hostPath, files)
+		case isNPMManifest(imagePath):
+			found = readNPMManifest(f.hostPath)
+		case isPythonMetadata(imagePath):
+			found = readPythonMetadata(f.hostPath)
+		}
+		for _, pkg := range found {
+			pkg.Path = imagePath
+			pkg.Layer = f.layer
+			packages = append(packages, pkg)
+		}
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Type != packages[j].Type {
+			return packages[i].Type < packages[j].Type
+		}
+		if packages[i].Name != packages[j].Name {
+			return packages[i].Name < packages[j].Name
+		}
+		return packages[i].Path < packages[j].Path
+	})
+	return packages, nil
+}
+
+// metadataFiles finds the package metadata files of the image, a file of a layer hides the same file of the older layers
+func metadataFiles(extracted *image.ExtractedImage) (map[string]file, error) {
+	files := map[string]file{}
+	for index := len(extracted.LayerIDs) - 1; index >= 0; index-- {
+		root := extracted.LayerDir(index)
+		err := filepath.Walk(root, func(hostPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			relPath, err := filepath.Rel(root, hostPath)
+			if err != nil {
+				return err
+			}
+			imagePath := "/" + filepath.ToSlash(relPath)
+			if _, ok := files[imagePath]; !ok && isMetadataFile(imagePath) {
+				files[imagePath] = file{hostPath: hostPath, layer: extracted.LayerIDs[index]}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func isMetadataFile(imagePath string) bool {
+	return imagePath == apkDatabase || imagePath == dpkgStatus || isDPKGCopyright(imagePath) ||
+		isNPMManifest(imagePath) || isPythonMetadata(imagePath)
+}
+
+func isDPKGCopyright(imagePath string) bool {
+	return strings.HasPrefix(imagePath, dpkgCopyrights) && path.Base(imagePath) == "copyright"
+}
+
+// isNPMManifest matches the package.json of the npm packages installed in a node_modules directory
+func isNPMManifest(imagePath string) bool {
+	if path.Base(imagePath) != "package.json" {
+		return false
+	}
+	dir := path.Dir(imagePath)
+	parent := path.Dir(dir)
+	if strings.HasPrefix(path.Base(parent), "@") {
+		parent = path.Dir(parent)
+	}
+	return path.Base(parent) == "node_modules"
+}
+
+func isPythonMetadata(imagePath string) bool {
+	dir := path.Base(path.Dir(imagePath))
+	return (path.Base(imagePath) == "METADATA" && strings.HasSuffix(dir, ".dist-info")) ||
+		(path.Base(imagePath) == "PKG-INFO" && strings.HasSuffix(dir, ".egg-info"))
+}
+
+func addLicense(licenses []string, license string) []string {
+	license = strings.TrimSpace(license)
+	if license == "" || strings.EqualFold(license, "UNKNOWN") {
+		return licenses
+	}
+	for _, l := range licenses {
+		if l == license {
+			return licenses
+		}
+	}
+	return append(licenses, license)
+}
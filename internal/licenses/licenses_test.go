@@ -0,0 +1,97 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package licenses
+
+import (
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+const (
+	testAPKDatabase = `P:musl
+V:1.1.24-r9
+L:MIT
+
+P:busybox
+V:1.31.1-r19
+L:GPL-2.0-only
+`
+	testDPKGStatus = `Package: bash
+Status: install ok installed
+Version: 5.0-4
+
+Package: removed
+Status: deinstall ok config-files
+Version: 1.0
+`
+	testDPKGCopyright = `Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+
+Files: *
+License: GPL-3+
+
+Files: lib/readline/*
+License: GPL-2+ or Artistic
+`
+	testPythonMetadata = `Metadata-Version: 2.1
+Name: requests
+Version: 2.25.1
+License: Apache 2.0
+Classifier: License :: OSI Approved
+Classifier: License :: OSI Approved :: Apache Software License
+
+Name: not a field of the metadata
+`
+)
+
+func TestScan(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithDir("0",
+			fs.WithDir("lib", fs.WithDir("apk", fs.WithDir("db", fs.WithFile("installed", "P:outdated\nV:0.1\nL:MIT\n"))))),
+		fs.WithDir("1",
+			fs.WithDir("lib", fs.WithDir("apk", fs.WithDir("db", fs.WithFile("installed", testAPKDatabase)))),
+			fs.WithDir("var", fs.WithDir("lib", fs.WithDir("dpkg", fs.WithFile("status", testDPKGStatus)))),
+			fs.WithDir("usr", fs.WithDir("share", fs.WithDir("doc", fs.WithDir("bash", fs.WithFile("copyright", testDPKGCopyright))))),
+			fs.WithDir("app", fs.WithDir("node_modules",
+				fs.WithDir("express", fs.WithFile("package.json", `{"name":"express","version":"4.17.1","license":"MIT"}`),
+					fs.WithDir("lib", fs.WithFile("package.json", `{"name":"not-a-package"}`))),
+				fs.WithDir("@types", fs.WithDir("node", fs.WithFile("package.json", `{"name":"@types/node","version":"14.0.0","license":{"type":"MIT"}}`))))),
+			fs.WithDir("usr", fs.WithDir("lib", fs.WithDir("python3", fs.WithDir("site-packages",
+				fs.WithDir("requests-2.25.1.dist-info", fs.WithFile("METADATA", testPythonMetadata))))))))
+	defer dir.Remove()
+
+	packages, err := Scan(&image.ExtractedImage{Dir: dir.Path(), LayerIDs: []string{"sha256:base", "sha256:app"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, packages, []report.PackageLicense{
+		{Name: "busybox", Version: "1.31.1-r19", Type: APK, Licenses: []string{"GPL-2.0-only"}, Path: "/lib/apk/db/installed", Layer: "sha256:app"},
+		{Name: "musl", Version: "1.1.24-r9", Type: APK, Licenses: []string{"MIT"}, Path: "/lib/apk/db/installed", Layer: "sha256:app"},
+		{Name: "bash", Version: "5.0-4", Type: DPKG, Licenses: []string{"GPL-3+", "GPL-2+", "Artistic"}, Path: "/var/lib/dpkg/status", Layer: "sha256:app"},
+		{Name: "@types/node", Version: "14.0.0", Type: NPM, Licenses: []string{"MIT"}, Path: "/app/node_modules/@types/node/package.json", Layer: "sha256:app"},
+		{Name: "express", Version: "4.17.1", Type: NPM, Licenses: []string{"MIT"}, Path: "/app/node_modules/express/package.json", Layer: "sha256:app"},
+		{Name: "requests", Version: "2.25.1", Type: Python, Licenses: []string{"Apache Software License"},
+			Path: "/usr/lib/python3/site-packages/requests-2.25.1.dist-info/METADATA", Layer: "sha256:app"},
+	})
+
+	issues := report.DeniedLicenses(packages, []string{"GPL"})
+	assert.Equal(t, len(issues), 3)
+	assert.Equal(t, issues[0].ID, "license:apk:busybox:GPL-2.0-only")
+	assert.Equal(t, issues[0].Type, report.LicenseType)
+	assert.Equal(t, issues[0].License, "GPL-2.0-only")
+}
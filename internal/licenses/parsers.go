@@ -0,0 +1,175 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package licenses
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// readFields reads the "Key: value" fields of the stanzas of a file separated by empty lines,
+// like the apk database, the dpkg status file and the Python package metadata
+func readFields(filePath string, separator string, stanza func(fields map[string][]string)) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	//nolint: errcheck
+	defer f.Close()
+	fields := map[string][]string{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(fields) > 0 {
+				stanza(fields)
+			}
+			fields = map[string][]string{}
+			continue
+		}
+		if index := strings.Index(line, separator); index > 0 && !strings.HasPrefix(line, " ") {
+			key := line[:index]
+			fields[key] = append(fields[key], strings.TrimSpace(line[index+len(separator):]))
+		}
+	}
+	if len(fields) > 0 {
+		stanza(fields)
+	}
+}
+
+func first(fields map[string][]string, key string) string {
+	if values := fields[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// readAPKDatabase reads the P: (name), V: (version) and L: (license) fields of the apk database
+func readAPKDatabase(databasePath string) []report.PackageLicense {
+	var packages []report.PackageLicense
+	readFields(databasePath, ":", func(fields map[string][]string) {
+		if name := first(fields, "P"); name != "" {
+			var licenses []string
+			for _, license := range strings.Fields(first(fields, "L")) {
+				if license != "AND" && license != "OR" {
+					licenses = addLicense(licenses, license)
+				}
+			}
+			packages = append(packages, report.PackageLicense{Name: name, Version: first(fields, "V"), Type: APK, Licenses: licenses})
+		}
+	})
+	return packages
+}
+
+// readDPKGStatus reads the installed packages of the dpkg status file, and their licenses from the
+// License fields of their machine-readable copyright file
+func readDPKGStatus(statusPath string, files map[string]file) []report.PackageLicense {
+	var packages []report.PackageLicense
+	readFields(statusPath, ":", func(fields map[string][]string) {
+		name := first(fields, "Package")
+		if name == "" || !strings.HasSuffix(first(fields, "Status"), " installed") {
+			return
+		}
+		pkg := report.PackageLicense{Name: name, Version: first(fields, "Version"), Type: DPKG}
+		if copyright, ok := files[dpkgCopyrights+name+"/copyright"]; ok {
+			pkg.Licenses = readDPKGCopyright(copyright.hostPath)
+		}
+		packages = append(packages, pkg)
+	})
+	return packages
+}
+
+func readDPKGCopyright(copyrightPath string) []string {
+	var licenses []string
+	readFields(copyrightPath, ":", func(fields map[string][]string) {
+		for _, license := range fields["License"] {
+			// a license expression like "GPL-2+ or Artistic" lists several licenses
+			for _, name := range strings.FieldsFunc(license, func(r rune) bool { return r == ' ' || r == ',' }) {
+				if name != "or" && name != "and" && name != "with" {
+					licenses = addLicense(licenses, name)
+				}
+			}
+		}
+	})
+	return licenses
+}
+
+// readNPMManifest reads the license of a package.json, declared as an SPDX expression or with the
+// deprecated object and list forms
+func readNPMManifest(manifestPath string) []report.PackageLicense {
+	buf, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil
+	}
+	var manifest struct {
+		Name     string          `json:"name"`
+		Version  string          `json:"version"`
+		License  json.RawMessage `json:"license"`
+		Licenses []struct {
+			Type string `json:"type"`
+		} `json:"licenses"`
+	}
+	if err := json.Unmarshal(buf, &manifest); err != nil || manifest.Name == "" {
+		return nil
+	}
+	var licenses []string
+	var license struct {
+		Type string `json:"type"`
+	}
+	var expression string
+	switch {
+	case json.Unmarshal(manifest.License, &expression) == nil:
+		licenses = addLicense(licenses, expression)
+	case json.Unmarshal(manifest.License, &license) == nil:
+		licenses = addLicense(licenses, license.Type)
+	}
+	for _, l := range manifest.Licenses {
+		licenses = addLicense(licenses, l.Type)
+	}
+	return []report.PackageLicense{{Name: manifest.Name, Version: manifest.Version, Type: NPM, Licenses: licenses}}
+}
+
+// readPythonMetadata reads the License field and the license classifiers of a Python package metadata,
+// its body after the first empty line is ignored
+func readPythonMetadata(metadataPath string) []report.PackageLicense {
+	var pkg *report.PackageLicense
+	readFields(metadataPath, ": ", func(fields map[string][]string) {
+		if pkg != nil || first(fields, "Name") == "" {
+			return
+		}
+		pkg = &report.PackageLicense{Name: first(fields, "Name"), Version: first(fields, "Version"), Type: Python}
+		for _, classifier := range fields["Classifier"] {
+			if strings.HasPrefix(classifier, "License :: ") && classifier != "License :: OSI Approved" {
+				parts := strings.Split(classifier, " :: ")
+				pkg.Licenses = addLicense(pkg.Licenses, parts[len(parts)-1])
+			}
+		}
+		if len(pkg.Licenses) == 0 {
+			pkg.Licenses = addLicense(pkg.Licenses, first(fields, "License"))
+		}
+	})
+	if pkg == nil {
+		return nil
+	}
+	return []report.PackageLicense{*pkg}
+}
@@ -0,0 +1,69 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package metrics appends a summary of each scan to a local JSON lines file, for the dashboards tailing it. The file
+// is only written when the user configures it, and nothing is sent anywhere.
+package metrics
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// The statuses of the scans
+const (
+	// StatusPassed is a scan without findings failing it
+	StatusPassed = "passed"
+	// StatusVulnerable is a scan failed by its findings
+	StatusVulnerable = "vulnerable"
+	// StatusError is a scan which couldn't complete
+	StatusError = "error"
+)
+
+// Record is the summary of a scan, one JSON line of the metrics file
+type Record struct {
+	Time  time.Time `json:"time"`
+	Image string    `json:"image"`
+	// Provider is the provider which scanned the image: snyk, trivy, grype or hub
+	Provider string `json:"provider"`
+	// DurationSeconds is the time the scan took, analysis and output included
+	DurationSeconds float64 `json:"durationSeconds"`
+	Status          string  `json:"status"`
+	// Counts are the numbers of findings per severity, empty when the scan failed before its results were analyzed
+	Counts map[string]int `json:"counts"`
+	Total  int            `json:"total"`
+	// Error is the error of the scans with the error status
+	Error string `json:"error,omitempty"`
+}
+
+// Append adds the record of a scan at the end of the metrics file, created if it doesn't exist. The record is written
+// in a single append, so that the concurrent scans don't interleave their lines.
+func Append(path string, record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,54 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package metrics
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestAppend(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	path := dir.Join("metrics.jsonl")
+
+	assert.NilError(t, Append(path, Record{
+		Time:            time.Date(2021, time.March, 15, 9, 0, 0, 0, time.UTC),
+		Image:           "alpine:3.13",
+		Provider:        "snyk",
+		DurationSeconds: 12.5,
+		Status:          StatusVulnerable,
+		Counts:          map[string]int{"high": 1, "low": 2},
+		Total:           3,
+	}))
+	assert.NilError(t, Append(path, Record{
+		Time:     time.Date(2021, time.March, 15, 9, 1, 0, 0, time.UTC),
+		Image:    "missing",
+		Provider: "trivy",
+		Status:   StatusError,
+		Error:    "image not found",
+	}))
+	buf, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), `{"time":"2021-03-15T09:00:00Z","image":"alpine:3.13","provider":"snyk","durationSeconds":12.5,"status":"vulnerable","counts":{"high":1,"low":2},"total":3}
+{"time":"2021-03-15T09:01:00Z","image":"missing","provider":"trivy","durationSeconds":0,"status":"error","counts":null,"total":0,"error":"image not found"}
+`)
+}
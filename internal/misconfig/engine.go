@@ -0,0 +1,139 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package misconfig
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// localLoggingDrivers are the logging drivers keeping the logs on the host only
+var localLoggingDrivers = []string{"json-file", "local", "none"}
+
+// CheckEngine reports the issues of the configuration of the Docker engine, following the daemon configuration
+// rules of the CIS Docker Benchmark which can be checked through the engine API
+func CheckEngine(ctx context.Context, cli client.APIClient) ([]report.Vulnerability, error) {
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return CheckEngineInfo(info), nil
+}
+
+// CheckEngineInfo reports the issues of the engine configuration: exposed API, registries, isolation and logging
+func CheckEngineInfo(info types.Info) []report.Vulnerability {
+	issues := []report.Vulnerability{}
+	for _, warning := range info.Warnings {
+		if strings.Contains(warning, "without encryption") {
+			issues = append(issues, issue("DOCKER-ENGINE-UNENCRYPTED-API", "critical",
+				"The engine API is reachable over TCP without TLS, anyone reaching it controls the host"))
+			break
+		}
+	}
+	issues = append(issues, checkRegistries(info)...)
+	if info.Driver == "aufs" {
+		issues = append(issues, issue("DOCKER-ENGINE-AUFS-STORAGE", "medium", "The engine uses the deprecated aufs storage driver"))
+	}
+	if info.OSType == "linux" {
+		issues = append(issues, checkIsolation(info.SecurityOptions)...)
+	}
+	if len(info.Plugins.Authorization) == 0 {
+		issues = append(issues, issue("DOCKER-ENGINE-NO-AUTHORIZATION", "low",
+			"No authorization plugin controls the access to the engine API"))
+	}
+	if contains(localLoggingDrivers, info.LoggingDriver) {
+		issues = append(issues, issue("DOCKER-ENGINE-LOCAL-LOGGING", "low",
+			fmt.Sprintf("The %s logging driver keeps the container logs on the host only, configure centralized logging", info.LoggingDriver)))
+	}
+	if !info.LiveRestoreEnabled {
+		issues = append(issues, issue("DOCKER-ENGINE-NO-LIVE-RESTORE", "low",
+			"Live restore is disabled, the containers stop when the engine stops"))
+	}
+	if info.ExperimentalBuild {
+		issues = append(issues, issue("DOCKER-ENGINE-EXPERIMENTAL", "low", "The experimental features of the engine are enabled"))
+	}
+	if info.Debug {
+		issues = append(issues, issue("DOCKER-ENGINE-DEBUG", "low", "The engine runs in debug mode"))
+	}
+	return issues
+}
+
+// checkRegistries reports the registries the engine reaches without verifying their certificate, except the local ones
+func checkRegistries(info types.Info) []report.Vulnerability {
+	if info.RegistryConfig == nil {
+		return nil
+	}
+	var insecure []string
+	for _, cidr := range info.RegistryConfig.InsecureRegistryCIDRs {
+		if cidr != nil && !cidr.IP.IsLoopback() {
+			insecure = append(insecure, cidr.String())
+		}
+	}
+	for name, index := range info.RegistryConfig.IndexConfigs {
+		if index != nil && !index.Secure {
+			insecure = append(insecure, name)
+		}
+	}
+	sort.Strings(insecure)
+	var issues []report.Vulnerability
+	for _, registry := range insecure {
+		issues = append(issues, issue("DOCKER-ENGINE-INSECURE-REGISTRY", "high",
+			fmt.Sprintf("The registry %s is used without TLS or certificate verification", registry)))
+	}
+	return issues
+}
+
+// checkIsolation reports the missing kernel isolation features of a Linux engine
+func checkIsolation(securityOptions []string) []report.Vulnerability {
+	var issues []report.Vulnerability
+	seccomp, userns, rootless := "", false, false
+	for _, option := range securityOptions {
+		fields := map[string]string{}
+		for _, field := range strings.Split(option, ",") {
+			parts := strings.SplitN(field, "=", 2)
+			if len(parts) == 2 {
+				fields[parts[0]] = parts[1]
+			}
+		}
+		switch fields["name"] {
+		case "seccomp":
+			seccomp = fields["profile"]
+		case "userns":
+			userns = true
+		case "rootless":
+			rootless = true
+		}
+	}
+	switch seccomp {
+	case "":
+		issues = append(issues, issue("DOCKER-ENGINE-NO-SECCOMP", "medium", "The engine doesn't support seccomp profiles"))
+	case "unconfined":
+		issues = append(issues, issue("DOCKER-ENGINE-SECCOMP-UNCONFINED", "high",
+			"The default seccomp profile of the engine is unconfined"))
+	}
+	if !userns && !rootless {
+		issues = append(issues, issue("DOCKER-ENGINE-NO-USERNS", "low",
+			"User namespace remapping is disabled, root in a container is root on the host"))
+	}
+	return issues
+}
@@ -0,0 +1,76 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package misconfig
+
+import (
+	"net"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/registry"
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckEngineInfo(t *testing.T) {
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	_, private, _ := net.ParseCIDR("10.0.0.0/8")
+	issues := CheckEngineInfo(types.Info{
+		OSType:        "linux",
+		Driver:        "aufs",
+		LoggingDriver: "json-file",
+		RegistryConfig: &registry.ServiceConfig{
+			InsecureRegistryCIDRs: []*registry.NetIPNet{(*registry.NetIPNet)(loopback), (*registry.NetIPNet)(private)},
+			IndexConfigs: map[string]*registry.IndexInfo{
+				"docker.io":           {Name: "docker.io", Secure: true},
+				"registry.local:5000": {Name: "registry.local:5000"},
+			},
+		},
+		SecurityOptions:   []string{"name=apparmor", "name=seccomp,profile=unconfined"},
+		ExperimentalBuild: true,
+		Warnings:          []string{"WARNING: API is accessible on http://0.0.0.0:2375 without encryption."},
+	})
+	var ids []string
+	for _, issue := range issues {
+		ids = append(ids, issue.ID)
+	}
+	assert.DeepEqual(t, ids, []string{
+		"DOCKER-ENGINE-UNENCRYPTED-API",
+		"DOCKER-ENGINE-INSECURE-REGISTRY",
+		"DOCKER-ENGINE-INSECURE-REGISTRY",
+		"DOCKER-ENGINE-AUFS-STORAGE",
+		"DOCKER-ENGINE-SECCOMP-UNCONFINED",
+		"DOCKER-ENGINE-NO-USERNS",
+		"DOCKER-ENGINE-NO-AUTHORIZATION",
+		"DOCKER-ENGINE-LOCAL-LOGGING",
+		"DOCKER-ENGINE-NO-LIVE-RESTORE",
+		"DOCKER-ENGINE-EXPERIMENTAL",
+	})
+	assert.Equal(t, issues[1].Title, "The registry 10.0.0.0/8 is used without TLS or certificate verification")
+	assert.Equal(t, issues[2].Title, "The registry registry.local:5000 is used without TLS or certificate verification")
+}
+
+func TestCheckEngineInfoHardened(t *testing.T) {
+	issues := CheckEngineInfo(types.Info{
+		OSType:             "linux",
+		Driver:             "overlay2",
+		LoggingDriver:      "syslog",
+		SecurityOptions:    []string{"name=seccomp,profile=default", "name=rootless"},
+		Plugins:            types.PluginsInfo{Authorization: []string{"opa-docker-authz"}},
+		LiveRestoreEnabled: true,
+	})
+	assert.Equal(t, len(issues), 0)
+}
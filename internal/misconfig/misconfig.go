@@ -0,0 +1,108 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package misconfig
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/scan-cli-plugin/internal/dockerfile"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+var sensitiveEnv = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|private_?key|credentials)`)
+
+// CheckImage reports the configuration issues of an image available on the engine
+func CheckImage(ctx context.Context, cli client.APIClient, ref string) ([]report.Vulnerability, error) {
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if inspect.Config == nil {
+		return []report.Vulnerability{}, nil
+	}
+	return CheckConfig(*inspect.Config), nil
+}
+
+// CheckConfig reports the issues of an image configuration
+func CheckConfig(config container.Config) []report.Vulnerability {
+	issues := []report.Vulnerability{}
+	user := strings.SplitN(config.User, ":", 2)[0]
+	if user == "" || user == "root" || user == "0" {
+		issues = append(issues, issue("DOCKER-CONFIG-ROOT-USER", "medium", "The image runs as root, set a non root USER"))
+	}
+	var ports []string
+	for port := range config.ExposedPorts {
+		ports = append(ports, string(port))
+	}
+	sort.Strings(ports)
+	for _, port := range ports {
+		if port == "22/tcp" {
+			issues = append(issues, issue("DOCKER-CONFIG-SSH-PORT", "low", "The image exposes the SSH port 22"))
+		}
+	}
+	for _, env := range config.Env {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) == 2 && parts[1] != "" && sensitiveEnv.MatchString(parts[0]) {
+			issues = append(issues, issue("DOCKER-CONFIG-SENSITIVE-ENV", "high",
+				fmt.Sprintf("The environment variable %s may contain a secret, pass it at runtime instead", parts[0])))
+		}
+	}
+	return issues
+}
+
+// CheckDockerfile reports the issues of the build stages of a Dockerfile
+func CheckDockerfile(stages []dockerfile.Stage) []report.Vulnerability {
+	issues := []report.Vulnerability{}
+	names := map[string]bool{}
+	for _, stage := range stages {
+		if !names[stage.BaseImage] && stage.BaseImage != "scratch" && !isPinned(stage.BaseImage) {
+			issues = append(issues, issue("DOCKER-CONFIG-UNPINNED-BASE-IMAGE", "low",
+				fmt.Sprintf("The base image %s (line %d) is not pinned to a tag or a digest", stage.BaseImage, stage.Line)))
+		}
+		if stage.Name != "" {
+			names[stage.Name] = true
+		}
+	}
+	return issues
+}
+
+func isPinned(image string) bool {
+	if strings.Contains(image, "@") {
+		return true
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	tag := ""
+	if index := strings.Index(name, ":"); index >= 0 {
+		tag = name[index+1:]
+	}
+	return tag != "" && tag != "latest"
+}
+
+func issue(id, severity, title string) report.Vulnerability {
+	return report.Vulnerability{
+		ID:       id,
+		Type:     report.ConfigType,
+		Title:    title,
+		Severity: severity,
+	}
+}
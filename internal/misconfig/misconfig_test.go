@@ -0,0 +1,57 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package misconfig
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/scan-cli-plugin/internal/dockerfile"
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckConfig(t *testing.T) {
+	issues := CheckConfig(container.Config{
+		ExposedPorts: nat.PortSet{"22/tcp": {}, "80/tcp": {}},
+		Env:          []string{"PATH=/usr/bin", "DB_PASSWORD=secret", "API_TOKEN="},
+	})
+	var ids []string
+	for _, issue := range issues {
+		ids = append(ids, issue.ID)
+	}
+	assert.DeepEqual(t, ids, []string{"DOCKER-CONFIG-ROOT-USER", "DOCKER-CONFIG-SSH-PORT", "DOCKER-CONFIG-SENSITIVE-ENV"})
+	assert.Equal(t, issues[2].Title, "The environment variable DB_PASSWORD may contain a secret, pass it at runtime instead")
+
+	assert.Equal(t, len(CheckConfig(container.Config{User: "app:app"})), 0)
+	assert.Equal(t, len(CheckConfig(container.Config{User: "0:0"})), 1)
+}
+
+func TestCheckDockerfile(t *testing.T) {
+	issues := CheckDockerfile([]dockerfile.Stage{
+		{Name: "build", BaseImage: "golang", Line: 1},
+		{BaseImage: "build", Line: 5},
+		{BaseImage: "localhost:5000/alpine:latest", Line: 6},
+		{BaseImage: "localhost:5000/alpine", Line: 7},
+		{BaseImage: "alpine:3.12", Line: 8},
+		{BaseImage: "alpine@sha256:abcd", Line: 9},
+		{BaseImage: "scratch", Line: 10},
+	})
+	assert.Equal(t, len(issues), 3)
+	assert.Equal(t, issues[0].Title, "The base image golang (line 1) is not pinned to a tag or a digest")
+	assert.Equal(t, issues[2].Title, "The base image localhost:5000/alpine (line 7) is not pinned to a tag or a digest")
+}
@@ -0,0 +1,194 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package misconfig
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// dangerousCapabilities are the kernel capabilities giving a container control over the host
+var dangerousCapabilities = map[string]bool{
+	"ALL":             true,
+	"SYS_ADMIN":       true,
+	"SYS_MODULE":      true,
+	"SYS_PTRACE":      true,
+	"SYS_RAWIO":       true,
+	"SYS_TIME":        true,
+	"NET_ADMIN":       true,
+	"DAC_READ_SEARCH": true,
+	"BPF":             true,
+}
+
+// sensitiveHostPaths are the host directories a container must not mount
+var sensitiveHostPaths = []string{"/", "/boot", "/dev", "/etc", "/lib", "/proc", "/sys", "/usr"}
+
+// dockerSockets are the paths of the Docker engine socket, giving full control over the host
+var dockerSockets = []string{"/var/run/docker.sock", "/run/docker.sock"}
+
+// CheckContainer reports the issues of the runtime configuration of a container, following the container runtime
+// rules of the CIS Docker Benchmark
+func CheckContainer(ctx context.Context, cli client.APIClient, id string) ([]report.Vulnerability, error) {
+	inspect, err := cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return CheckRuntimeConfig(inspect), nil
+}
+
+// CheckRuntimeConfig reports the issues of a container: privileges, capabilities, mounts and published ports
+func CheckRuntimeConfig(inspect types.ContainerJSON) []report.Vulnerability {
+	issues := []report.Vulnerability{}
+	if inspect.ContainerJSONBase != nil && inspect.HostConfig != nil {
+		issues = append(issues, checkPrivileges(*inspect.HostConfig)...)
+		issues = append(issues, checkNamespaces(*inspect.HostConfig)...)
+	}
+	if inspect.Config != nil {
+		user := strings.SplitN(inspect.Config.User, ":", 2)[0]
+		if user == "" || user == "root" || user == "0" {
+			issues = append(issues, issue("DOCKER-RUNTIME-ROOT-USER", "medium", "The container runs as root, run it with a non root --user"))
+		}
+	}
+	issues = append(issues, checkMounts(inspect.Mounts)...)
+	if inspect.NetworkSettings != nil {
+		issues = append(issues, checkPorts(inspect.NetworkSettings.Ports)...)
+	}
+	return issues
+}
+
+func checkPrivileges(hostConfig container.HostConfig) []report.Vulnerability {
+	var issues []report.Vulnerability
+	if hostConfig.Privileged {
+		issues = append(issues, issue("DOCKER-RUNTIME-PRIVILEGED", "critical",
+			"The container runs in privileged mode, with all the capabilities and access to the host devices"))
+	}
+	for _, capability := range hostConfig.CapAdd {
+		name := strings.TrimPrefix(strings.ToUpper(capability), "CAP_")
+		if dangerousCapabilities[name] {
+			issues = append(issues, issue("DOCKER-RUNTIME-CAPABILITY", "high",
+				fmt.Sprintf("The container is granted the %s capability", name)))
+		}
+	}
+	for _, option := range hostConfig.SecurityOpt {
+		switch strings.Replace(option, ":", "=", 1) {
+		case "seccomp=unconfined":
+			issues = append(issues, issue("DOCKER-RUNTIME-SECCOMP-UNCONFINED", "medium", "The container runs without seccomp profile"))
+		case "apparmor=unconfined":
+			issues = append(issues, issue("DOCKER-RUNTIME-APPARMOR-UNCONFINED", "medium", "The container runs without AppArmor profile"))
+		}
+	}
+	if !contains(hostConfig.SecurityOpt, "no-new-privileges") && !contains(hostConfig.SecurityOpt, "no-new-privileges:true") &&
+		!contains(hostConfig.SecurityOpt, "no-new-privileges=true") {
+		issues = append(issues, issue("DOCKER-RUNTIME-NEW-PRIVILEGES", "low",
+			"The container processes can gain new privileges, set --security-opt no-new-privileges"))
+	}
+	if !hostConfig.ReadonlyRootfs {
+		issues = append(issues, issue("DOCKER-RUNTIME-WRITABLE-ROOTFS", "low", "The root filesystem of the container is writable, set --read-only"))
+	}
+	if hostConfig.Memory == 0 {
+		issues = append(issues, issue("DOCKER-RUNTIME-NO-MEMORY-LIMIT", "low", "The memory of the container is not limited, set --memory"))
+	}
+	return issues
+}
+
+func checkNamespaces(hostConfig container.HostConfig) []report.Vulnerability {
+	var issues []report.Vulnerability
+	if hostConfig.NetworkMode.IsHost() {
+		issues = append(issues, issue("DOCKER-RUNTIME-HOST-NETWORK", "medium", "The container shares the network namespace of the host"))
+	}
+	if hostConfig.PidMode.IsHost() {
+		issues = append(issues, issue("DOCKER-RUNTIME-HOST-PID", "medium", "The container shares the process namespace of the host"))
+	}
+	if hostConfig.IpcMode.IsHost() {
+		issues = append(issues, issue("DOCKER-RUNTIME-HOST-IPC", "medium", "The container shares the IPC namespace of the host"))
+	}
+	return issues
+}
+
+func checkMounts(mounts []types.MountPoint) []report.Vulnerability {
+	var issues []report.Vulnerability
+	for _, mount := range mounts {
+		if mount.Type != "bind" {
+			continue
+		}
+		source := path.Clean(mount.Source)
+		switch {
+		case contains(dockerSockets, source):
+			finding := issue("DOCKER-RUNTIME-DOCKER-SOCKET", "critical", "The Docker socket is mounted in the container, giving it full control over the host")
+			finding.Path = source
+			issues = append(issues, finding)
+		case contains(sensitiveHostPaths, source):
+			mode := "read-only"
+			if mount.RW {
+				mode = "writable"
+			}
+			finding := issue("DOCKER-RUNTIME-SENSITIVE-MOUNT", "high",
+				fmt.Sprintf("The sensitive host directory %s is mounted %s in the container at %s", source, mode, mount.Destination))
+			finding.Path = source
+			issues = append(issues, finding)
+		}
+	}
+	return issues
+}
+
+// checkPorts reports the published ports, once per container port whatever the IPv4 and IPv6 bindings
+func checkPorts(ports nat.PortMap) []report.Vulnerability {
+	var issues []report.Vulnerability
+	var exposed []string
+	for port := range ports {
+		exposed = append(exposed, string(port))
+	}
+	sort.Strings(exposed)
+	for _, port := range exposed {
+		privilegedPort := 0
+		allInterfaces := false
+		for _, binding := range ports[nat.Port(port)] {
+			if hostPort, err := strconv.Atoi(binding.HostPort); err == nil && hostPort > 0 && hostPort < 1024 {
+				privilegedPort = hostPort
+			}
+			allInterfaces = allInterfaces || binding.HostIP == "" || binding.HostIP == "0.0.0.0" || binding.HostIP == "::"
+		}
+		if privilegedPort > 0 {
+			issues = append(issues, issue("DOCKER-RUNTIME-PRIVILEGED-PORT", "low",
+				fmt.Sprintf("The container port %s is published on the privileged host port %d", port, privilegedPort)))
+		}
+		if allInterfaces {
+			issues = append(issues, issue("DOCKER-RUNTIME-PORT-ALL-INTERFACES", "low",
+				fmt.Sprintf("The container port %s is published on all the host interfaces", port)))
+		}
+	}
+	return issues
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package misconfig
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckRuntimeConfig(t *testing.T) {
+	issues := CheckRuntimeConfig(types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{HostConfig: &container.HostConfig{
+			Privileged:  true,
+			CapAdd:      []string{"NET_BIND_SERVICE", "CAP_SYS_ADMIN"},
+			SecurityOpt: []string{"seccomp=unconfined"},
+			NetworkMode: "host",
+		}},
+		Config: &container.Config{User: "root"},
+		Mounts: []types.MountPoint{
+			{Type: "bind", Source: "/var/run/docker.sock", Destination: "/var/run/docker.sock", RW: true},
+			{Type: "bind", Source: "/etc/", Destination: "/host/etc"},
+			{Type: "bind", Source: "/home/app/data", Destination: "/data", RW: true},
+			{Type: "volume", Name: "data", Source: "/var/lib/docker/volumes/data/_data", Destination: "/var/lib/data", RW: true},
+		},
+		NetworkSettings: &types.NetworkSettings{NetworkSettingsBase: types.NetworkSettingsBase{Ports: nat.PortMap{
+			"80/tcp":   {{HostIP: "0.0.0.0", HostPort: "80"}, {HostIP: "::", HostPort: "80"}},
+			"8080/tcp": {{HostIP: "127.0.0.1", HostPort: "8080"}},
+			"9000/tcp": nil,
+		}}},
+	})
+	var ids []string
+	for _, issue := range issues {
+		ids = append(ids, issue.ID)
+	}
+	assert.DeepEqual(t, ids, []string{
+		"DOCKER-RUNTIME-PRIVILEGED",
+		"DOCKER-RUNTIME-CAPABILITY",
+		"DOCKER-RUNTIME-SECCOMP-UNCONFINED",
+		"DOCKER-RUNTIME-NEW-PRIVILEGES",
+		"DOCKER-RUNTIME-WRITABLE-ROOTFS",
+		"DOCKER-RUNTIME-NO-MEMORY-LIMIT",
+		"DOCKER-RUNTIME-HOST-NETWORK",
+		"DOCKER-RUNTIME-ROOT-USER",
+		"DOCKER-RUNTIME-DOCKER-SOCKET",
+		"DOCKER-RUNTIME-SENSITIVE-MOUNT",
+		"DOCKER-RUNTIME-PRIVILEGED-PORT",
+		"DOCKER-RUNTIME-PORT-ALL-INTERFACES",
+	})
+	assert.Equal(t, issues[1].Title, "The container is granted the SYS_ADMIN capability")
+	assert.Equal(t, issues[9].Title, "The sensitive host directory /etc is mounted read-only in the container at /host/etc")
+	assert.Equal(t, issues[9].Path, "/etc")
+	assert.Equal(t, issues[10].Title, "The container port 80/tcp is published on the privileged host port 80")
+}
+
+func TestCheckRuntimeConfigHardened(t *testing.T) {
+	issues := CheckRuntimeConfig(types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{HostConfig: &container.HostConfig{
+			CapAdd:         []string{"NET_BIND_SERVICE"},
+			SecurityOpt:    []string{"no-new-privileges:true"},
+			ReadonlyRootfs: true,
+			Resources:      container.Resources{Memory: 512 * 1024 * 1024},
+		}},
+		Config: &container.Config{User: "app"},
+	})
+	assert.Equal(t, len(issues), 0)
+}
@@ -0,0 +1,128 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/docker/scan-cli-plugin/webhook"
+)
+
+// Notification types
+const (
+	// SlackType posts a message to a Slack incoming webhook
+	SlackType = "slack"
+	// WebhookType posts the event as JSON to an HTTP endpoint
+	WebhookType = "webhook"
+	// EmailType sends the HTML report with the SMTP server of the docker scan configuration
+	EmailType = "email"
+)
+
+// Types are the supported notification types
+var Types = []string{SlackType, WebhookType, EmailType}
+
+// Event is a scan which found findings of the notification threshold or higher
+type Event struct {
+	Image string `json:"image"`
+	// Threshold is the lowest severity of the findings notified
+	Threshold string `json:"threshold"`
+	// Total counts the findings of the threshold or higher
+	Total int `json:"total"`
+	// Counts counts all the findings by severity
+	Counts map[string]int `json:"counts"`
+	// Link points to the results of the scan, like the CI job or the scan history
+	Link string `json:"link,omitempty"`
+}
+
+// NewEvent returns the event of the scan findings, and false if none reaches the threshold
+func NewEvent(image, threshold string, findings []report.Vulnerability, link string) (Event, bool) {
+	if threshold == "" {
+		threshold = report.Severities[0]
+	}
+	atLeast := report.AtLeast(threshold)
+	event := Event{
+		Image:     image,
+		Threshold: threshold,
+		Counts:    report.CountBySeverity(findings),
+		Link:      strings.ReplaceAll(link, "{image}", image),
+	}
+	for _, finding := range findings {
+		if atLeast(finding) {
+			event.Total++
+		}
+	}
+	return event, event.Total > 0
+}
+
+// Summary describes the event in a sentence
+func (e Event) Summary() string {
+	var counts []string
+	for i := len(report.Severities) - 1; i >= 0; i-- {
+		if count := e.Counts[report.Severities[i]]; count > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", count, report.Severities[i]))
+		}
+	}
+	return fmt.Sprintf("docker scan found %d issues of %s severity or higher in %s (%s)", e.Total, e.Threshold, e.Image,
+		strings.Join(counts, ", "))
+}
+
+// Slack posts the summary of the event, followed by its link, to a Slack incoming webhook
+func Slack(ctx context.Context, client *http.Client, url string, event Event) error {
+	text := event.Summary()
+	if event.Link != "" {
+		text += "\n" + event.Link
+	}
+	return post(ctx, client, url, map[string]string{"text": text}, nil)
+}
+
+// Webhook posts the event as JSON to the endpoint, signed with the secret unless it is empty
+func Webhook(ctx context.Context, client *http.Client, url string, event Event, secret []byte) error {
+	return post(ctx, client, url, event, secret)
+}
+
+func post(ctx context.Context, client *http.Client, url string, payload interface{}, secret []byte) error {
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(secret) > 0 {
+		webhook.SignRequest(req, secret, buf, time.Now())
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("notification endpoint returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/docker/scan-cli-plugin/webhook"
+	"gotest.tools/v3/assert"
+)
+
+var findings = []report.Vulnerability{
+	{ID: "SNYK-1", Severity: "critical"},
+	{ID: "SNYK-2", Severity: "high"},
+	{ID: "SNYK-3", Severity: "low"},
+}
+
+func TestNewEvent(t *testing.T) {
+	event, ok := NewEvent("myapp:1.0", "high", findings, "https://ci.example.com/scans?image={image}")
+	assert.Assert(t, ok)
+	assert.Equal(t, event.Total, 2)
+	assert.Equal(t, event.Link, "https://ci.example.com/scans?image=myapp:1.0")
+	assert.Equal(t, event.Summary(), "docker scan found 2 issues of high severity or higher in myapp:1.0 (1 critical, 1 high, 1 low)")
+
+	_, ok = NewEvent("myapp:1.0", "critical", findings[1:], "")
+	assert.Assert(t, !ok)
+	event, ok = NewEvent("myapp:1.0", "", findings[2:], "")
+	assert.Assert(t, ok)
+	assert.Equal(t, event.Threshold, "low")
+}
+
+func TestSlack(t *testing.T) {
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("Content-Type"), "application/json")
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer server.Close()
+
+	event, _ := NewEvent("myapp:1.0", "critical", findings, "https://ci.example.com/job/42")
+	assert.NilError(t, Slack(context.Background(), server.Client(), server.URL, event))
+	assert.Equal(t, payload["text"], "docker scan found 1 issues of critical severity or higher in myapp:1.0 (1 critical, 1 high, 1 low)\nhttps://ci.example.com/job/42")
+}
+
+func TestWebhook(t *testing.T) {
+	var received Event
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(webhook.SignatureHeader)
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	event, _ := NewEvent("myapp:1.0", "high", findings, "")
+	assert.NilError(t, Webhook(context.Background(), server.Client(), server.URL, event, nil))
+	assert.DeepEqual(t, received, event)
+	assert.Equal(t, signature, "")
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid token", http.StatusForbidden)
+	}))
+	defer failing.Close()
+	err := Webhook(context.Background(), failing.Client(), failing.URL, event, nil)
+	assert.Error(t, err, "notification endpoint returned 403 Forbidden: invalid token")
+}
+
+func TestSignedWebhook(t *testing.T) {
+	secret := []byte("s3cr3t")
+	var verifyErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, verifyErr = webhook.VerifyRequest(r, secret)
+	}))
+	defer server.Close()
+
+	event, _ := NewEvent("myapp:1.0", "high", findings, "")
+	assert.NilError(t, Webhook(context.Background(), server.Client(), server.URL, event, secret))
+	assert.NilError(t, verifyErr)
+
+	assert.NilError(t, Webhook(context.Background(), server.Client(), server.URL, event, []byte("other")))
+	assert.Error(t, verifyErr, "the signature doesn't match the content of the delivery")
+}
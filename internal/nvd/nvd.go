@@ -0,0 +1,226 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package nvd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DefaultURL is the CVE API of the National Vulnerability Database
+const DefaultURL = "https://services.nvd.nist.gov/rest/json/cves/2.0"
+
+var cveID = regexp.MustCompile(`^CVE-\d{4}-\d{4,}$`)
+
+// CVSS is the CVSS score of a CVE
+type CVSS struct {
+	Version  string  `json:"version"`
+	Vector   string  `json:"vector"`
+	Score    float64 `json:"score"`
+	Severity string  `json:"severity,omitempty"`
+}
+
+// Affected is a product affected by a CVE, with the range of its vulnerable versions
+type Affected struct {
+	Vendor   string `json:"vendor"`
+	Product  string `json:"product"`
+	Versions string `json:"versions"`
+}
+
+// CVE holds the details of a CVE published by the NVD
+type CVE struct {
+	ID          string     `json:"id"`
+	Published   string     `json:"published,omitempty"`
+	Description string     `json:"description"`
+	CVSS        *CVSS      `json:"cvss,omitempty"`
+	Affected    []Affected `json:"affected,omitempty"`
+	References  []string   `json:"references,omitempty"`
+}
+
+// NormalizeID returns the upper case CVE ID, or an error if id is not a CVE ID
+func NormalizeID(id string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(id))
+	if !cveID.MatchString(normalized) {
+		return "", fmt.Errorf("%q is not a CVE ID like CVE-2021-3711", id)
+	}
+	return normalized, nil
+}
+
+// Lookup gets the details of the CVE from the NVD API at endpoint, the API key raising the rate limit of the API
+func Lookup(ctx context.Context, client *http.Client, endpoint, apiKey, id string) (CVE, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?cveId="+url.QueryEscape(id), nil)
+	if err != nil {
+		return CVE{}, err
+	}
+	if apiKey != "" {
+		req.Header.Set("apiKey", apiKey)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return CVE{}, err
+	}
+	defer resp.Body.Close() //nolint: errcheck
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return CVE{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return CVE{}, fmt.Errorf("NVD API returned %s", resp.Status)
+	}
+	var document nvdResponse
+	if err := json.Unmarshal(body, &document); err != nil {
+		return CVE{}, fmt.Errorf("invalid NVD API response: %s", err)
+	}
+	if len(document.Vulnerabilities) == 0 {
+		return CVE{}, fmt.Errorf("%s is not published in the NVD", id)
+	}
+	return document.Vulnerabilities[0].CVE.convert(), nil
+}
+
+type nvdResponse struct {
+	Vulnerabilities []struct {
+		CVE nvdCVE `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+type nvdCVE struct {
+	ID           string `json:"id"`
+	Published    string `json:"published"`
+	Descriptions []struct {
+		Lang  string `json:"lang"`
+		Value string `json:"value"`
+	} `json:"descriptions"`
+	Metrics struct {
+		CVSSMetricV31 []nvdMetric `json:"cvssMetricV31"`
+		CVSSMetricV30 []nvdMetric `json:"cvssMetricV30"`
+		CVSSMetricV2  []nvdMetric `json:"cvssMetricV2"`
+	} `json:"metrics"`
+	Configurations []struct {
+		Nodes []struct {
+			CPEMatch []nvdCPEMatch `json:"cpeMatch"`
+		} `json:"nodes"`
+	} `json:"configurations"`
+	References []struct {
+		URL string `json:"url"`
+	} `json:"references"`
+}
+
+type nvdMetric struct {
+	Type     string `json:"type"`
+	CVSSData struct {
+		Version      string  `json:"version"`
+		VectorString string  `json:"vectorString"`
+		BaseScore    float64 `json:"baseScore"`
+		BaseSeverity string  `json:"baseSeverity"`
+	} `json:"cvssData"`
+	// BaseSeverity is set on the metric itself for CVSS v2
+	BaseSeverity string `json:"baseSeverity"`
+}
+
+type nvdCPEMatch struct {
+	Vulnerable            bool   `json:"vulnerable"`
+	Criteria              string `json:"criteria"`
+	VersionStartIncluding string `json:"versionStartIncluding"`
+	VersionStartExcluding string `json:"versionStartExcluding"`
+	VersionEndIncluding   string `json:"versionEndIncluding"`
+	VersionEndExcluding   string `json:"versionEndExcluding"`
+}
+
+func (c nvdCVE) convert() CVE {
+	cve := CVE{ID: c.ID, Published: c.Published, CVSS: c.cvss()}
+	for _, description := range c.Descriptions {
+		if description.Lang == "en" {
+			cve.Description = description.Value
+			break
+		}
+	}
+	seen := map[Affected]bool{}
+	for _, configuration := range c.Configurations {
+		for _, node := range configuration.Nodes {
+			for _, match := range node.CPEMatch {
+				affected, ok := match.affected()
+				if ok && !seen[affected] {
+					seen[affected] = true
+					cve.Affected = append(cve.Affected, affected)
+				}
+			}
+		}
+	}
+	for _, reference := range c.References {
+		cve.References = append(cve.References, reference.URL)
+	}
+	return cve
+}
+
+// cvss returns the most recent CVSS score, preferring the primary score of the NVD to the ones of other sources
+func (c nvdCVE) cvss() *CVSS {
+	for _, metrics := range [][]nvdMetric{c.Metrics.CVSSMetricV31, c.Metrics.CVSSMetricV30, c.Metrics.CVSSMetricV2} {
+		if len(metrics) == 0 {
+			continue
+		}
+		metric := metrics[0]
+		for _, candidate := range metrics {
+			if candidate.Type == "Primary" {
+				metric = candidate
+				break
+			}
+		}
+		severity := metric.CVSSData.BaseSeverity
+		if severity == "" {
+			severity = metric.BaseSeverity
+		}
+		return &CVSS{
+			Version:  metric.CVSSData.Version,
+			Vector:   metric.CVSSData.VectorString,
+			Score:    metric.CVSSData.BaseScore,
+			Severity: strings.ToLower(severity),
+		}
+	}
+	return nil
+}
+
+// affected returns the product and the vulnerable versions of a CPE match, like cpe:2.3:a:openssl:openssl:*:...
+func (m nvdCPEMatch) affected() (Affected, bool) {
+	parts := strings.Split(m.Criteria, ":")
+	if !m.Vulnerable || len(parts) < 6 {
+		return Affected{}, false
+	}
+	var versions []string
+	if parts[5] != "*" && parts[5] != "-" {
+		versions = append(versions, "= "+parts[5])
+	}
+	for _, bound := range []struct{ operator, version string }{
+		{">=", m.VersionStartIncluding},
+		{">", m.VersionStartExcluding},
+		{"<=", m.VersionEndIncluding},
+		{"<", m.VersionEndExcluding},
+	} {
+		if bound.version != "" {
+			versions = append(versions, bound.operator+" "+bound.version)
+		}
+	}
+	if len(versions) == 0 {
+		versions = []string{"all versions"}
+	}
+	return Affected{Vendor: parts[3], Product: parts[4], Versions: strings.Join(versions, ", ")}, true
+}
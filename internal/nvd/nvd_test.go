@@ -0,0 +1,75 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package nvd
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestNormalizeID(t *testing.T) {
+	id, err := NormalizeID(" cve-2021-3711")
+	assert.NilError(t, err)
+	assert.Equal(t, id, "CVE-2021-3711")
+	_, err = NormalizeID("SNYK-DEBIAN10-OPENSSL-1569403")
+	assert.ErrorContains(t, err, "is not a CVE ID")
+}
+
+func TestLookup(t *testing.T) {
+	document, err := ioutil.ReadFile("testdata/CVE-2021-3711.json")
+	assert.NilError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Query().Get("cveId"), "CVE-2021-3711")
+		assert.Equal(t, r.Header.Get("apiKey"), "key")
+		w.Write(document) //nolint: errcheck
+	}))
+	defer server.Close()
+
+	cve, err := Lookup(context.Background(), server.Client(), server.URL, "key", "CVE-2021-3711")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, cve, CVE{
+		ID:          "CVE-2021-3711",
+		Published:   "2021-08-24T15:15:09.133",
+		Description: "In order to decrypt SM2 encrypted data an application is expected to call the API function EVP_PKEY_decrypt().",
+		CVSS: &CVSS{
+			Version:  "3.1",
+			Vector:   "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+			Score:    9.8,
+			Severity: "critical",
+		},
+		Affected: []Affected{
+			{Vendor: "openssl", Product: "openssl", Versions: ">= 1.1.1, < 1.1.1l"},
+			{Vendor: "debian", Product: "debian_linux", Versions: "= 10.0"},
+		},
+		References: []string{"https://www.openssl.org/news/secadv/20210824.txt"},
+	})
+}
+
+func TestLookupNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"totalResults": 0, "vulnerabilities": []}`)) //nolint: errcheck
+	}))
+	defer server.Close()
+
+	_, err := Lookup(context.Background(), server.Client(), server.URL, "", "CVE-2099-0001")
+	assert.Error(t, err, "CVE-2099-0001 is not published in the NVD")
+}
@@ -0,0 +1,350 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+)
+
+// Media types of the manifests
+const (
+	IndexMediaType        = "application/vnd.oci.image.index.v1+json"
+	ManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+	ManifestMediaType     = "application/vnd.oci.image.manifest.v1+json"
+	DockerManifestType    = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// manifestMediaTypes are the media types of the manifests of the images, the multi-platform ones first
+var manifestMediaTypes = []string{IndexMediaType, ManifestListMediaType, ManifestMediaType, DockerManifestType}
+
+// Descriptor references a blob or a manifest of a repository
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is an image manifest, listing the config and the layers of an image
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+}
+
+// Repository reads and writes the manifests and blobs of a registry repository, with the OCI distribution API
+type Repository struct {
+	client   *http.Client
+	baseURL  string
+	path     string
+	username string
+	password string
+	// authorization is the Authorization header of the requests, obtained from the challenge of the registry
+	authorization string
+}
+
+// NewRepository returns the repository of the image, authenticated with the credentials when they are not empty.
+// The registries on the local host are accessed over HTTP.
+func NewRepository(client *http.Client, named reference.Named, username, password string) *Repository {
+	host := reference.Domain(named)
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	scheme := "https"
+	if isLocalHost(host) {
+		scheme = "http"
+	}
+	return &Repository{
+		client:   client,
+		baseURL:  fmt.Sprintf("%s://%s/v2/%s", scheme, host, reference.Path(named)),
+		path:     reference.Path(named),
+		username: username,
+		password: password,
+	}
+}
+
+func isLocalHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Digest returns the digest of the manifest with the tag or digest
+func (r *Repository) Digest(ctx context.Context, ref string) (string, error) {
+	resp, err := r.do(ctx, http.MethodHead, r.baseURL+"/manifests/"+ref, strings.Join(manifestMediaTypes, ", "), nil)
+	if err != nil {
+		return "", err
+	}
+	if err := closeExpecting(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("the registry didn't return the digest of %s", ref)
+	}
+	return digest, nil
+}
+
+// Manifest returns the image manifest with the tag or digest, nil when it doesn't exist
+func (r *Repository) Manifest(ctx context.Context, ref string) (*Manifest, error) {
+	buf, err := r.manifest(ctx, ref, ManifestMediaType)
+	if err != nil || buf == nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %s", ref, err)
+	}
+	return &m, nil
+}
+
+// manifest returns the content of the manifest with the tag or digest, nil when it doesn't exist
+func (r *Repository) manifest(ctx context.Context, ref, accept string) ([]byte, error) {
+	resp, err := r.do(ctx, http.MethodGet, r.baseURL+"/manifests/"+ref, accept, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, resp.Body.Close()
+	}
+	return readExpecting(resp, http.StatusOK)
+}
+
+var nextLink = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// Tags lists the tags of the repository, following the pages of the registry
+func (r *Repository) Tags(ctx context.Context) ([]string, error) {
+	var tags []string
+	target := r.baseURL + "/tags/list?n=1000"
+	for target != "" {
+		resp, err := r.do(ctx, http.MethodGet, target, "application/json", nil)
+		if err != nil {
+			return nil, err
+		}
+		buf, err := readExpecting(resp, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.Unmarshal(buf, &page); err != nil {
+			return nil, fmt.Errorf("invalid tag list: %s", err)
+		}
+		tags = append(tags, page.Tags...)
+		target = ""
+		if match := nextLink.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+			next, err := resp.Request.URL.Parse(match[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid tag list link: %s", err)
+			}
+			target = next.String()
+		}
+	}
+	return tags, nil
+}
+
+// PutManifest pushes the image manifest with the tag
+func (r *Repository) PutManifest(ctx context.Context, tag string, m Manifest) error {
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	resp, err := r.do(ctx, http.MethodPut, r.baseURL+"/manifests/"+tag, m.MediaType, buf)
+	if err != nil {
+		return err
+	}
+	return closeExpecting(resp, http.StatusCreated)
+}
+
+// Blob returns the content of the blob with the digest
+func (r *Repository) Blob(ctx context.Context, digest string) ([]byte, error) {
+	resp, err := r.do(ctx, http.MethodGet, r.baseURL+"/blobs/"+digest, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return readExpecting(resp, http.StatusOK)
+}
+
+// PushBlob uploads the content, unless the registry already has it
+func (r *Repository) PushBlob(ctx context.Context, mediaType string, content []byte) (Descriptor, error) {
+	blob := Descriptor{
+		MediaType: mediaType,
+		Digest:    fmt.Sprintf("sha256:%x", sha256.Sum256(content)),
+		Size:      int64(len(content)),
+	}
+	resp, err := r.do(ctx, http.MethodHead, r.baseURL+"/blobs/"+blob.Digest, "", nil)
+	if err != nil {
+		return blob, err
+	}
+	if err := resp.Body.Close(); err != nil || resp.StatusCode == http.StatusOK {
+		return blob, err
+	}
+	resp, err = r.do(ctx, http.MethodPost, r.baseURL+"/blobs/uploads/", "", nil)
+	if err != nil {
+		return blob, err
+	}
+	location, err := resp.Location()
+	if closeErr := closeExpecting(resp, http.StatusAccepted); closeErr != nil {
+		return blob, closeErr
+	}
+	if err != nil {
+		return blob, fmt.Errorf("invalid blob upload location: %s", err)
+	}
+	query := location.Query()
+	query.Set("digest", blob.Digest)
+	location.RawQuery = query.Encode()
+	resp, err = r.do(ctx, http.MethodPut, location.String(), "application/octet-stream", content)
+	if err != nil {
+		return blob, err
+	}
+	return blob, closeExpecting(resp, http.StatusCreated)
+}
+
+// do sends the request, authenticating with the challenge of the registry when it is refused
+func (r *Repository) do(ctx context.Context, method, target, contentType string, body []byte) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if method == http.MethodGet || method == http.MethodHead {
+			req.Header.Set("Accept", contentType)
+		} else if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if r.authorization != "" {
+			req.Header.Set("Authorization", r.authorization)
+		}
+		return r.client.Do(req)
+	}
+	resp, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close() //nolint:errcheck
+	if err := r.authenticate(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return send()
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authenticate sets the authorization asked by the challenge of the registry, a token for the Bearer scheme
+func (r *Repository) authenticate(ctx context.Context, challenge string) error {
+	params := map[string]string{}
+	for _, match := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	scheme := strings.ToLower(strings.SplitN(challenge, " ", 2)[0])
+	switch {
+	case scheme == "basic" && r.username != "":
+		req, _ := http.NewRequest(http.MethodGet, r.baseURL, nil)
+		req.SetBasicAuth(r.username, r.password)
+		r.authorization = req.Header.Get("Authorization")
+		return nil
+	case scheme == "bearer" && params["realm"] != "":
+		token, err := r.token(ctx, params)
+		if err != nil {
+			return err
+		}
+		r.authorization = "Bearer " + token
+		return nil
+	}
+	return fmt.Errorf("authentication to the registry failed, log in with docker login")
+}
+
+func (r *Repository) token(ctx context.Context, params map[string]string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil {
+		return "", fmt.Errorf("invalid registry authentication realm: %s", err)
+	}
+	query := realm.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + r.path + ":pull,push"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	buf, err := readExpecting(resp, http.StatusOK)
+	if err != nil {
+		return "", fmt.Errorf("authentication to the registry failed: %s", err)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(buf, &token); err != nil {
+		return "", fmt.Errorf("invalid registry token: %s", err)
+	}
+	if token.Token == "" {
+		return token.AccessToken, nil
+	}
+	return token.Token, nil
+}
+
+func readExpecting(resp *http.Response, status int) ([]byte, error) {
+	defer resp.Body.Close() //nolint:errcheck
+	buf, err := ioutil.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != status {
+		return nil, fmt.Errorf("registry returned %s on %s", resp.Status, resp.Request.URL.Path)
+	}
+	return buf, nil
+}
+
+func closeExpecting(resp *http.Response, status int) error {
+	_, err := readExpecting(resp, status)
+	return err
+}
@@ -0,0 +1,163 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/distribution/reference"
+	"gotest.tools/v3/assert"
+)
+
+const imageDigest = "sha256:6d1ef012b5674ad8a127ecfa9b5e6f5178d171b90ee462846974177fd9bdd39f"
+
+const imageIndex = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {"digest": "sha256:amd64", "platform": {"os": "linux", "architecture": "amd64"}},
+    {"digest": "sha256:armv7", "platform": {"os": "linux", "architecture": "arm", "variant": "v7"}},
+    {"digest": "sha256:arm64", "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+    {"digest": "sha256:attestation", "platform": {"os": "unknown", "architecture": "unknown"}}
+  ]
+}`
+
+// newFakeRegistry serves the app repository, with a token given to the user
+func newFakeRegistry(t *testing.T) (*httptest.Server, map[string][]byte) {
+	blobs := map[string][]byte{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Equal(t, r.URL.Query().Get("scope"), "repository:app:pull,push")
+			fmt.Fprint(w, `{"token":"registry-token"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer registry-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake",scope="repository:app:pull,push"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		switch path := strings.TrimPrefix(r.URL.Path, "/v2/app"); {
+		case r.Method == http.MethodHead && path == "/manifests/1.0":
+			w.Header().Set("Docker-Content-Digest", imageDigest)
+		case path == "/tags/list" && r.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/app/tags/list?last=1.1&n=2>; rel="next"`)
+			fmt.Fprint(w, `{"name": "app", "tags": ["1.0", "1.1"]}`)
+		case path == "/tags/list":
+			assert.Equal(t, r.URL.Query().Get("last"), "1.1")
+			fmt.Fprint(w, `{"name": "app", "tags": ["latest"]}`)
+		case path == "/manifests/multi":
+			fmt.Fprint(w, imageIndex)
+		case path == "/manifests/single":
+			fmt.Fprintf(w, `{"schemaVersion": 2, "mediaType": %q, "layers": []}`, DockerManifestType)
+		case r.Method == http.MethodPost && path == "/blobs/uploads/":
+			w.Header().Set("Location", "/v2/app/blobs/uploads/1?state=x")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPut && path == "/blobs/uploads/1":
+			assert.Equal(t, r.URL.Query().Get("state"), "x")
+			blobs[r.URL.Query().Get("digest")] = body
+			w.WriteHeader(http.StatusCreated)
+		case strings.HasPrefix(path, "/blobs/"):
+			blob, ok := blobs[strings.TrimPrefix(path, "/blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(blob) //nolint:errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, blobs
+}
+
+func newTestRepository(t *testing.T, server *httptest.Server, username, password string) *Repository {
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(server.URL, "http://") + "/app")
+	assert.NilError(t, err)
+	return NewRepository(http.DefaultClient, named, username, password)
+}
+
+func TestRepository(t *testing.T) {
+	server, blobs := newFakeRegistry(t)
+	ctx := context.Background()
+	repository := newTestRepository(t, server, "user", "secret")
+
+	digest, err := repository.Digest(ctx, "1.0")
+	assert.NilError(t, err)
+	assert.Equal(t, digest, imageDigest)
+
+	blob, err := repository.PushBlob(ctx, "application/json", []byte("{}"))
+	assert.NilError(t, err)
+	assert.Equal(t, blob.Digest, "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a")
+	assert.Equal(t, string(blobs[blob.Digest]), "{}")
+	content, err := repository.Blob(ctx, blob.Digest)
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "{}")
+
+	tags, err := repository.Tags(ctx)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, tags, []string{"1.0", "1.1", "latest"})
+
+	m, err := repository.Manifest(ctx, "missing")
+	assert.NilError(t, err)
+	assert.Assert(t, m == nil)
+
+	_, err = newTestRepository(t, server, "", "").Digest(ctx, "1.0")
+	assert.ErrorContains(t, err, "authentication to the registry failed")
+}
+
+func TestPlatforms(t *testing.T) {
+	server, _ := newFakeRegistry(t)
+	ctx := context.Background()
+	repository := newTestRepository(t, server, "user", "secret")
+
+	platforms, err := repository.Platforms(ctx, "multi")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, platforms, []Platform{
+		{OS: "linux", Architecture: "amd64", Digest: "sha256:amd64"},
+		{OS: "linux", Architecture: "arm", Variant: "v7", Digest: "sha256:armv7"},
+		{OS: "linux", Architecture: "arm64", Variant: "v8", Digest: "sha256:arm64"},
+	})
+	assert.Equal(t, platforms[1].String(), "linux/arm/v7")
+	assert.Assert(t, platforms[2].Matches("linux/arm64"))
+	assert.Assert(t, platforms[2].Matches("linux/arm64/v8"))
+	assert.Assert(t, !platforms[1].Matches("linux/arm/v6"))
+	assert.Assert(t, !platforms[0].Matches("windows/amd64"))
+
+	platforms, err = repository.Platforms(ctx, "single")
+	assert.NilError(t, err)
+	assert.Assert(t, platforms == nil)
+}
+
+func TestValidatePlatform(t *testing.T) {
+	assert.NilError(t, ValidatePlatform("linux/arm64"))
+	assert.NilError(t, ValidatePlatform("linux/arm/v7"))
+	assert.ErrorContains(t, ValidatePlatform("arm64"), "expected os/arch[/variant]")
+	assert.ErrorContains(t, ValidatePlatform("linux//v7"), "expected os/arch[/variant]")
+}
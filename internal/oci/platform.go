@@ -0,0 +1,109 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Platform is the platform of an image of a multi-platform image, with the digest of its manifest
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+	Digest       string `json:"digest"`
+}
+
+// String formats the platform like the --platform flag of docker, os/arch[/variant]
+func (p Platform) String() string {
+	platform := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		platform += "/" + p.Variant
+	}
+	return platform
+}
+
+// Matches returns true if the platform is the one given as os/arch[/variant], any variant matching a platform without
+func (p Platform) Matches(platform string) bool {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != p.OS || parts[1] != p.Architecture {
+		return false
+	}
+	return len(parts) == 2 || parts[2] == p.Variant
+}
+
+// ValidatePlatform checks the platform is given as os/arch[/variant]
+func ValidatePlatform(platform string) error {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("invalid platform %q, expected os/arch[/variant] like linux/arm64", platform)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return fmt.Errorf("invalid platform %q, expected os/arch[/variant] like linux/arm64", platform)
+		}
+	}
+	return nil
+}
+
+type index struct {
+	MediaType string `json:"mediaType"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform *struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+			Variant      string `json:"variant"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+// Platforms returns the platforms of the multi-platform image with the tag or digest, nil when the image has a single
+// platform
+func (r *Repository) Platforms(ctx context.Context, ref string) ([]Platform, error) {
+	buf, err := r.manifest(ctx, ref, strings.Join(manifestMediaTypes, ", "))
+	if err != nil {
+		return nil, err
+	}
+	if buf == nil {
+		return nil, fmt.Errorf("manifest %s not found", ref)
+	}
+	var list index
+	if err := json.Unmarshal(buf, &list); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %s", ref, err)
+	}
+	if list.MediaType != IndexMediaType && list.MediaType != ManifestListMediaType && list.Manifests == nil {
+		return nil, nil
+	}
+	var platforms []Platform
+	for _, manifest := range list.Manifests {
+		// the attestation manifests of the images built by BuildKit have an unknown platform
+		if manifest.Platform == nil || manifest.Platform.OS == "unknown" {
+			continue
+		}
+		platforms = append(platforms, Platform{
+			OS:           manifest.Platform.OS,
+			Architecture: manifest.Platform.Architecture,
+			Variant:      manifest.Platform.Variant,
+			Digest:       manifest.Digest,
+		})
+	}
+	return platforms, nil
+}
@@ -0,0 +1,170 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+const (
+	// TokenEnv is the environment variable holding the OIDC token of the CI job, like a GitLab id_tokens variable
+	TokenEnv = "DOCKER_SCAN_OIDC_TOKEN"
+	// githubRequestURLEnv and githubRequestTokenEnv are set by GitHub Actions when the job has the id-token: write
+	// permission
+	githubRequestURLEnv   = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	githubRequestTokenEnv = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+)
+
+// ErrReadOnly is returned when storing or erasing the exchanged credentials, which only live for the CI job
+var ErrReadOnly = errors.New("the credentials exchanged for the OIDC token of the CI job can't be stored or erased")
+
+// Available returns true if the CI job provides an OIDC token
+func Available() bool {
+	return os.Getenv(TokenEnv) != "" || (os.Getenv(githubRequestURLEnv) != "" && os.Getenv(githubRequestTokenEnv) != "")
+}
+
+// IDToken returns the OIDC token of the CI job for the audience, either from DOCKER_SCAN_OIDC_TOKEN or requested to
+// GitHub Actions
+func IDToken(ctx context.Context, client *http.Client, audience string) (string, error) {
+	if token := os.Getenv(TokenEnv); token != "" {
+		return token, nil
+	}
+	requestURL := os.Getenv(githubRequestURLEnv)
+	if requestURL == "" || os.Getenv(githubRequestTokenEnv) == "" {
+		return "", fmt.Errorf("no OIDC token, set %s or grant the id-token: write permission to the GitHub Actions job", TokenEnv)
+	}
+	if audience != "" {
+		parsed, err := url.Parse(requestURL)
+		if err != nil {
+			return "", err
+		}
+		query := parsed.Query()
+		query.Set("audience", audience)
+		parsed.RawQuery = query.Encode()
+		requestURL = parsed.String()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+os.Getenv(githubRequestTokenEnv))
+	var response struct {
+		Value string `json:"value"`
+	}
+	if err := do(client, req, &response); err != nil {
+		return "", fmt.Errorf("failed to get the OIDC token of the GitHub Actions job: %s", err)
+	}
+	return response.Value, nil
+}
+
+// Exchange posts the OIDC token to the exchange endpoint and returns the provider token it is exchanged for. The
+// protocol is specific to docker scan and documented in the README: the endpoint is a broker run by the user, which
+// receives {"idToken": "..."} and answers {"token": "..."} with a 2xx status once the OIDC token is verified.
+func Exchange(ctx context.Context, client *http.Client, endpoint, idToken string) (string, error) {
+	buf, err := json.Marshal(map[string]string{"idToken": idToken})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(buf))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var response struct {
+		Token string `json:"token"`
+	}
+	if err := do(client, req, &response); err != nil {
+		return "", fmt.Errorf("failed to exchange the OIDC token: %s", err)
+	}
+	if response.Token == "" {
+		return "", fmt.Errorf("failed to exchange the OIDC token: no token in the response of %s", endpoint)
+	}
+	return response.Token, nil
+}
+
+func do(client *http.Client, req *http.Request, response interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint: errcheck
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, bytes.TrimSpace(body))
+	}
+	return json.Unmarshal(body, response)
+}
+
+// TokenStore provides the provider token exchanged for the OIDC token of the CI job, the exchange happening once
+type TokenStore struct {
+	ctx      context.Context
+	client   *http.Client
+	endpoint string
+	audience string
+
+	once  sync.Once
+	token string
+	err   error
+}
+
+// NewTokenStore returns a token store exchanging the OIDC token for the audience with the endpoint
+func NewTokenStore(ctx context.Context, client *http.Client, endpoint, audience string) *TokenStore {
+	return &TokenStore{ctx: ctx, client: client, endpoint: endpoint, audience: audience}
+}
+
+// Get returns the exchanged provider token
+func (s *TokenStore) Get() (string, error) {
+	s.once.Do(func() {
+		var idToken string
+		if idToken, s.err = IDToken(s.ctx, s.client, s.audience); s.err == nil {
+			s.token, s.err = Exchange(s.ctx, s.client, s.endpoint, idToken)
+		}
+	})
+	return s.token, s.err
+}
+
+// Store fails, the exchanged token is not persisted
+func (s *TokenStore) Store(string) error {
+	return ErrReadOnly
+}
+
+// Erase fails, the exchanged token is not persisted
+func (s *TokenStore) Erase() error {
+	return ErrReadOnly
+}
+
+// Migrate does nothing, there is no plaintext token to migrate
+func (s *TokenStore) Migrate() error {
+	return nil
+}
+
+// Source describes where the token comes from in the authentication status
+func (s *TokenStore) Source() string {
+	return "Snyk token exchanged for the OIDC token of the CI job"
+}
@@ -0,0 +1,84 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/env"
+)
+
+func TestTokenStoreGitHubActions(t *testing.T) {
+	exchanges := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("Authorization"), "Bearer request-token")
+		assert.Equal(t, r.URL.Query().Get("audience"), "snyk")
+		assert.Equal(t, r.URL.Query().Get("api-version"), "2.0")
+		w.Write([]byte(`{"value": "github-id-token"}`)) //nolint: errcheck
+	})
+	mux.HandleFunc("/exchange", func(w http.ResponseWriter, r *http.Request) {
+		exchanges++
+		var request map[string]string
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, request["idToken"], "github-id-token")
+		w.Write([]byte(`{"token": "snyk-token"}`)) //nolint: errcheck
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	defer env.Patch(t, TokenEnv, "")()
+	defer env.Patch(t, githubRequestURLEnv, server.URL+"/token?api-version=2.0")()
+	defer env.Patch(t, githubRequestTokenEnv, "request-token")()
+	assert.Assert(t, Available())
+
+	store := NewTokenStore(context.Background(), server.Client(), server.URL+"/exchange", "snyk")
+	for i := 0; i < 2; i++ {
+		token, err := store.Get()
+		assert.NilError(t, err)
+		assert.Equal(t, token, "snyk-token")
+	}
+	assert.Equal(t, exchanges, 1)
+	assert.Equal(t, store.Store("token"), ErrReadOnly)
+}
+
+func TestExchangeGitLab(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "untrusted issuer", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	defer env.Patch(t, TokenEnv, "gitlab-id-token")()
+
+	idToken, err := IDToken(context.Background(), server.Client(), "snyk")
+	assert.NilError(t, err)
+	assert.Equal(t, idToken, "gitlab-id-token")
+	_, err = Exchange(context.Background(), server.Client(), server.URL, idToken)
+	assert.ErrorContains(t, err, "failed to exchange the OIDC token: ")
+	assert.ErrorContains(t, err, "401 Unauthorized: untrusted issuer")
+}
+
+func TestIDTokenUnavailable(t *testing.T) {
+	defer env.Patch(t, TokenEnv, "")()
+	defer env.Patch(t, githubRequestURLEnv, "")()
+	assert.Assert(t, !Available())
+	_, err := IDToken(context.Background(), http.DefaultClient, "")
+	assert.ErrorContains(t, err, "no OIDC token")
+}
@@ -25,7 +25,12 @@ import (
 
 // AskForConsent prompts a consent question to inform about Snyk usage on behalf
 func AskForConsent(stdin io.Reader, stdout io.Writer) bool {
-	fmt.Fprintln(stdout, "Docker Scan relies upon access to Snyk, a third party provider, do you consent to proceed using Snyk? (y/N)")
+	return Ask(stdin, stdout, "Docker Scan relies upon access to Snyk, a third party provider, do you consent to proceed using Snyk?")
+}
+
+// Ask prompts a yes/no question, anything but yes is a no
+func Ask(stdin io.Reader, stdout io.Writer, question string) bool {
+	fmt.Fprintf(stdout, "%s (y/N)\n", question)
 	reader := bufio.NewReader(stdin)
 	input, _ := reader.ReadString('\n')
 	input = strings.ToLower(strings.TrimSpace(input))
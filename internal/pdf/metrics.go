@@ -0,0 +1,63 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pdf
+
+// helveticaWidths are the widths of the printable ASCII characters of the Helvetica font,
+// in thousandths of the font size, from its Adobe font metrics
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0 to 9
+	278, 278, 584, 584, 584, 556, 1015, // : to @
+	667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // A to M
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N to Z
+	278, 278, 278, 469, 556, 333, // [ to `
+	556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // a to m
+	556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // n to z
+	334, 260, 334, 584, // { to ~
+}
+
+// boldRatio approximates the width of Helvetica-Bold from the width of Helvetica
+const boldRatio = 1.08
+
+// TextWidth returns the width of a text written with the given style
+func TextWidth(text string, style Style) float64 {
+	width := 0
+	for _, r := range text {
+		if r >= ' ' && r <= '~' {
+			width += helveticaWidths[r-' ']
+		} else {
+			width += 556
+		}
+	}
+	result := float64(width) * style.Size / 1000
+	if style.Bold {
+		result *= boldRatio
+	}
+	return result
+}
+
+// Truncate shortens a text to fit in the given width, ending it with an ellipsis when it is shortened
+func Truncate(text string, style Style, width float64) string {
+	if TextWidth(text, style) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && TextWidth(string(runes)+"...", style) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
@@ -0,0 +1,167 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page sizes in points
+const (
+	A4Width  = 595.0
+	A4Height = 842.0
+)
+
+// Color is an RGB color, with components between 0 and 1
+type Color struct {
+	R, G, B float64
+}
+
+// Black is the default text color
+var Black = Color{}
+
+// Style is the style of a text
+type Style struct {
+	Size  float64
+	Bold  bool
+	Color Color
+}
+
+// Document is a PDF document made of text and lines. It only uses the standard Helvetica fonts,
+// which PDF readers provide, so no font needs to be embedded.
+type Document struct {
+	width, height float64
+	pages         []*bytes.Buffer
+}
+
+// New returns an empty document with pages of the given size
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// AddPage starts a new page, on which the following texts and lines are drawn
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, bytes.NewBuffer(nil))
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text draws a text on the current page, from the baseline at the given position. The origin is the
+// bottom left corner of the page.
+func (d *Document) Text(x, y float64, style Style, text string) {
+	font := "F1"
+	if style.Bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page(), "%s rg BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		color(style.Color), font, number(style.Size), number(x), number(y), escape(text))
+}
+
+// Line draws a thin line on the current page
+func (d *Document) Line(x1, y1, x2, y2 float64, c Color) {
+	fmt.Fprintf(d.page(), "%s RG 0.5 w %s %s m %s %s l S\n",
+		color(c), number(x1), number(y1), number(x2), number(y2))
+}
+
+// Write writes the document
+func (d *Document) Write(out io.Writer) error {
+	d.page()
+	w := &writer{out: out}
+	w.printf("%%PDF-1.4\n")
+	pageCount := len(d.pages)
+	kids := make([]string, pageCount)
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObject+2*i)
+	}
+	w.object("<< /Type /Catalog /Pages 2 0 R >>")
+	w.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount))
+	w.object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	w.object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, content := range d.pages {
+		w.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			number(d.width), number(d.height), firstPageObject+2*i+1))
+		w.object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+	xref := w.written
+	w.printf("xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		w.printf("%010d 00000 n \n", offset)
+	}
+	w.printf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, xref)
+	return w.err
+}
+
+// firstPageObject is the number of the object of the first page, after the catalog, the page tree and the fonts
+const firstPageObject = 5
+
+// writer writes the objects of a document, recording their offsets for the cross-reference table
+type writer struct {
+	out     io.Writer
+	written int
+	offsets []int
+	err     error
+}
+
+func (w *writer) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.out, format, args...)
+	w.written += n
+	w.err = err
+}
+
+func (w *writer) object(content string) {
+	w.offsets = append(w.offsets, w.written)
+	w.printf("%d 0 obj\n%s\nendobj\n", len(w.offsets), content)
+}
+
+func color(c Color) string {
+	return fmt.Sprintf("%s %s %s", number(c.R), number(c.G), number(c.B))
+}
+
+func number(value float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+}
+
+// escape encodes a text as a PDF string in the WinAnsi encoding of the fonts, replacing the
+// characters it can't represent
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWrite(t *testing.T) {
+	doc := New(A4Width, A4Height)
+	doc.Text(40, 800, Style{Size: 16, Bold: true}, "Scan report (alpine)")
+	doc.AddPage()
+	doc.Line(40, 780, 555, 780, Color{R: 0.5, G: 0.5, B: 0.5})
+	doc.Text(40, 760, Style{Size: 10, Color: Color{R: 1}}, `café \ ✓`)
+
+	buf := bytes.NewBuffer(nil)
+	assert.NilError(t, doc.Write(buf))
+	content := buf.String()
+	assert.Assert(t, strings.HasPrefix(content, "%PDF-1.4\n"))
+	assert.Assert(t, strings.HasSuffix(content, "%%EOF\n"))
+	assert.Assert(t, strings.Contains(content, "/Kids [5 0 R 7 0 R] /Count 2"))
+	assert.Assert(t, strings.Contains(content, "0 0 0 rg BT /F2 16 Tf 40 800 Td (Scan report \\(alpine\\)) Tj ET"))
+	assert.Assert(t, strings.Contains(content, "1 0 0 rg BT /F1 10 Tf 40 760 Td (caf\\351 \\\\ ?) Tj ET"))
+
+	// the cross-reference table points to the objects
+	xref := strings.Index(content, "xref\n")
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(content)
+	assert.Equal(t, startxref[1], strconv.Itoa(xref))
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(content, -1)
+	assert.Equal(t, len(offsets), 8)
+	for i, offset := range offsets {
+		position, err := strconv.Atoi(offset[1])
+		assert.NilError(t, err)
+		assert.Assert(t, strings.HasPrefix(content[position:], fmt.Sprintf("%d 0 obj\n", i+1)))
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	style := Style{Size: 10}
+	assert.Equal(t, TextWidth("ab", style), 11.12)
+	assert.Equal(t, Truncate("short", style, 100), "short")
+	truncated := Truncate("a rather long vulnerability title", style, 60)
+	assert.Equal(t, truncated, "a rather lon...")
+	assert.Assert(t, TextWidth(truncated, style) <= 60)
+}
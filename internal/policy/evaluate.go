@@ -0,0 +1,353 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// Input holds the scan results a policy is evaluated against
+type Input struct {
+	// Image is the reference of the scanned image
+	Image     string
+	BaseImage string
+	Findings  []report.Vulnerability
+	// Packages are the packages of the image with their licenses, found by the license scanner
+	Packages []report.PackageLicense
+	// Now is the time the age of the findings is computed at, the current time when zero
+	Now time.Time
+}
+
+// Result is the evaluation of a rule
+type Result struct {
+	Rule       Rule     `json:"rule"`
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Passed returns true if the rule has no violations
+func (r Result) Passed() bool {
+	return len(r.Violations) == 0
+}
+
+// Evaluate evaluates each rule of the policy against the scan results
+func Evaluate(policy Policy, input Input) []Result {
+	var results []Result
+	for _, rule := range policy.Rules {
+		var violations []string
+		switch rule.Type {
+		case NoVulnerabilities:
+			violations = vulnerabilityViolations(rule, input.Findings)
+		case ApprovedBaseImages:
+			violations = baseImageViolations(rule, input.BaseImage)
+		case DeniedLicenses:
+			violations = licenseViolations(rule, input)
+		case NoMisconfigurations:
+			violations = misconfigurationViolations(rule, input.Findings)
+		case MaxFindingAge:
+			violations = ageViolations(rule, input)
+		case NoKnownExploited, MaxEPSS:
+			violations = exploitViolations(rule, input.Findings)
+		case MaxVulnerabilities:
+			violations = countViolations(rule, input.Findings)
+		default:
+			violations = imageViolations(rule, input.Image)
+		}
+		results = append(results, Result{Rule: rule, Violations: violations})
+	}
+	return results
+}
+
+// EvaluateImage evaluates the rules of the policy on the reference of the image, before it is scanned
+func EvaluateImage(policy Policy, image string) []Result {
+	var results []Result
+	for _, rule := range policy.Rules {
+		if rule.isImageRule() {
+			results = append(results, Result{Rule: rule, Violations: imageViolations(rule, image)})
+		}
+	}
+	return results
+}
+
+// Passed returns true if all the rules passed
+func Passed(results []Result) bool {
+	for _, result := range results {
+		if !result.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+func vulnerabilityViolations(rule Rule, findings []report.Vulnerability) []string {
+	severity := rule.Severity
+	if severity == "" {
+		severity = report.Severities[0]
+	}
+	atLeast := report.AtLeast(severity)
+	seen := map[string]bool{}
+	var violations []string
+	for _, finding := range findings {
+		if finding.Type != "" && finding.Type != report.VulnerabilityType || !atLeast(finding) {
+			continue
+		}
+		if rule.Fixable && len(finding.FixedIn) == 0 {
+			continue
+		}
+		for _, id := range finding.CVEs() {
+			violation := fmt.Sprintf("%s (%s) in %s@%s", id, strings.ToLower(finding.Severity), finding.PackageName, finding.Version)
+			if !seen[violation] {
+				seen[violation] = true
+				violations = append(violations, violation)
+			}
+		}
+	}
+	return violations
+}
+
+// countViolations reports the number of vulnerabilities of the rule severity when it exceeds the max of the rule,
+// a vulnerability found in several paths of the image being counted once
+func countViolations(rule Rule, findings []report.Vulnerability) []string {
+	seen := map[string]bool{}
+	for _, finding := range findings {
+		if finding.Type != "" && finding.Type != report.VulnerabilityType {
+			continue
+		}
+		if rule.Severity != "" && !strings.EqualFold(finding.Severity, rule.Severity) {
+			continue
+		}
+		if rule.Fixable && len(finding.FixedIn) == 0 {
+			continue
+		}
+		seen[finding.ID+" "+finding.PackageName+"@"+finding.Version] = true
+	}
+	if len(seen) <= *rule.Max {
+		return nil
+	}
+	kind := "vulnerabilities"
+	if rule.Severity != "" {
+		kind = strings.ToLower(rule.Severity) + " " + kind
+	}
+	return []string{fmt.Sprintf("%d %s found, %d allowed", len(seen), kind, *rule.Max)}
+}
+
+// ageViolations reports the vulnerabilities open for more than the days of the rule, the ones whose age is not tracked
+// being ignored
+func ageViolations(rule Rule, input Input) []string {
+	now := input.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	severity := rule.Severity
+	if severity == "" {
+		severity = report.Severities[0]
+	}
+	atLeast := report.AtLeast(severity)
+	var violations []string
+	for _, finding := range input.Findings {
+		if finding.Type != "" && finding.Type != report.VulnerabilityType || !atLeast(finding) {
+			continue
+		}
+		if days := finding.OpenDays(now); days > rule.MaxDays {
+			violations = append(violations, fmt.Sprintf("%s (%s) in %s@%s open for %d days, more than %d", finding.ID,
+				strings.ToLower(finding.Severity), finding.PackageName, finding.Version, days, rule.MaxDays))
+		}
+	}
+	return violations
+}
+
+// exploitViolations reports the vulnerabilities known to be exploited, or whose EPSS score is above the one of the
+// rule, the vulnerabilities which are not enriched being ignored
+func exploitViolations(rule Rule, findings []report.Vulnerability) []string {
+	severity := rule.Severity
+	if severity == "" {
+		severity = report.Severities[0]
+	}
+	atLeast := report.AtLeast(severity)
+	var violations []string
+	for _, finding := range findings {
+		if finding.Exploit == nil || !atLeast(finding) {
+			continue
+		}
+		switch {
+		case rule.Type == NoKnownExploited && finding.Exploit.KnownExploited:
+			violations = append(violations, fmt.Sprintf("%s (%s) in %s@%s is known to be exploited", finding.ID,
+				strings.ToLower(finding.Severity), finding.PackageName, finding.Version))
+		case rule.Type == MaxEPSS && finding.Exploit.EPSS > rule.EPSS:
+			violations = append(violations, fmt.Sprintf("%s (%s) in %s@%s has an EPSS score of %.3f, more than %.3f", finding.ID,
+				strings.ToLower(finding.Severity), finding.PackageName, finding.Version, finding.Exploit.EPSS, rule.EPSS))
+		}
+	}
+	return violations
+}
+
+func misconfigurationViolations(rule Rule, findings []report.Vulnerability) []string {
+	severity := rule.Severity
+	if severity == "" {
+		severity = report.Severities[0]
+	}
+	atLeast := report.AtLeast(severity)
+	var violations []string
+	for _, finding := range findings {
+		if finding.Type == report.ConfigType && atLeast(finding) {
+			violations = append(violations, fmt.Sprintf("%s (%s): %s", finding.ID, strings.ToLower(finding.Severity), finding.Title))
+		}
+	}
+	return violations
+}
+
+func baseImageViolations(rule Rule, baseImage string) []string {
+	if baseImage == "" {
+		return []string{"the base image of the image is unknown, use --file to provide its Dockerfile"}
+	}
+	named, err := reference.ParseNormalizedNamed(baseImage)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid base image %q: %s", baseImage, err)}
+	}
+	for _, image := range rule.Images {
+		if approved, err := reference.ParseNormalizedNamed(image); err == nil && matchesImage(named, approved) {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("the base image %s is not approved", baseImage)}
+}
+
+func imageViolations(rule Rule, image string) []string {
+	if image == "" {
+		return []string{"the reference of the image is unknown"}
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid image %q: %s", image, err)}
+	}
+	switch rule.Type {
+	case AllowedRegistries:
+		if !matchesRegistries(named, rule.Registries) {
+			return []string{fmt.Sprintf("the image %s is not from an allowed registry", image)}
+		}
+	case DeniedRegistries:
+		if matchesRegistries(named, rule.Registries) {
+			return []string{fmt.Sprintf("the image %s is from a denied registry", image)}
+		}
+	case DeniedImages:
+		for _, denied := range rule.Images {
+			if deniedNamed, err := reference.ParseNormalizedNamed(denied); err == nil && matchesImage(named, deniedNamed) {
+				return []string{fmt.Sprintf("the image %s is denied", image)}
+			}
+		}
+	case NoLatestTag:
+		if isLatest(named) {
+			return []string{fmt.Sprintf("the image %s uses the latest tag, use a version tag or a digest", image)}
+		}
+	}
+	return nil
+}
+
+// matchesRegistries returns true if the repository of the image is in one of the registries, a registry being a
+// host optionally followed by a repository path prefix
+func matchesRegistries(image reference.Named, registries []string) bool {
+	for _, registry := range registries {
+		registry = strings.TrimSuffix(strings.ToLower(registry), "/")
+		if registry == "index.docker.io" || strings.HasPrefix(registry, "index.docker.io/") {
+			registry = "docker.io" + strings.TrimPrefix(registry, "index.docker.io")
+		}
+		if image.Name() == registry || strings.HasPrefix(image.Name(), registry+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isLatest returns true if the image isn't pinned by digest, and has the latest tag or no tag at all
+func isLatest(image reference.Named) bool {
+	if _, ok := image.(reference.Digested); ok {
+		return false
+	}
+	tagged, ok := image.(reference.Tagged)
+	return !ok || tagged.Tag() == "latest"
+}
+
+// matchesImage returns true if the image has the repository of the approved image,
+// and the same tag or digest if the approved image has one
+func matchesImage(image, approved reference.Named) bool {
+	if image.Name() != approved.Name() {
+		return false
+	}
+	if tagged, ok := approved.(reference.Tagged); ok {
+		imageTagged, ok := image.(reference.Tagged)
+		return ok && imageTagged.Tag() == tagged.Tag()
+	}
+	if digested, ok := approved.(reference.Digested); ok {
+		imageDigested, ok := image.(reference.Digested)
+		return ok && imageDigested.Digest() == digested.Digest()
+	}
+	return true
+}
+
+// licenseViolations reports the packages found by the license scanner, and the license issues of the provider,
+// licensed under a denied license
+func licenseViolations(rule Rule, input Input) []string {
+	seen := map[string]bool{}
+	var violations []string
+	addViolation := func(name, version, license string) {
+		violation := fmt.Sprintf("%s@%s is licensed under %s", name, version, license)
+		if !seen[violation] {
+			seen[violation] = true
+			violations = append(violations, violation)
+		}
+	}
+	for _, pkg := range input.Packages {
+		for _, license := range pkg.Licenses {
+			if report.MatchesLicense(license, rule.Licenses) {
+				addViolation(pkg.Name, pkg.Version, license)
+			}
+		}
+	}
+	for _, finding := range input.Findings {
+		if finding.Type != report.LicenseType {
+			continue
+		}
+		license := finding.License
+		if license == "" {
+			license = finding.Title
+		}
+		if report.MatchesLicense(license, rule.Licenses) {
+			addViolation(finding.PackageName, finding.Version, license)
+		}
+	}
+	return violations
+}
+
+// WriteResults prints the result of each rule and its violations
+func WriteResults(out io.Writer, results []Result) {
+	fmt.Fprintln(out, "\nPolicy evaluation:")
+	for _, result := range results {
+		status := "PASS"
+		if !result.Passed() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(out, "  %s  %s\n", status, result.Rule.Title())
+		for _, violation := range result.Violations {
+			fmt.Fprintf(out, "          %s\n", violation)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gopkg.in/yaml.v2"
+)
+
+// Rule types
+const (
+	// NoVulnerabilities fails when vulnerabilities of the rule severity or higher are found,
+	// only the ones with a fix available if the rule is fixable
+	NoVulnerabilities = "no-vulnerabilities"
+	// ApprovedBaseImages fails when the base image is not one of the rule images
+	ApprovedBaseImages = "approved-base-images"
+	// DeniedLicenses fails when packages have one of the rule licenses
+	DeniedLicenses = "denied-licenses"
+	// NoMisconfigurations fails when configuration issues of the rule severity or higher are found
+	NoMisconfigurations = "no-misconfigurations"
+	// AllowedRegistries fails when the image is not from one of the rule registries
+	AllowedRegistries = "allowed-registries"
+	// DeniedRegistries fails when the image is from one of the rule registries
+	DeniedRegistries = "denied-registries"
+	// DeniedImages fails when the image is one of the rule images
+	DeniedImages = "denied-images"
+	// NoLatestTag fails when the image is tagged latest, or not tagged at all, and not pinned by digest
+	NoLatestTag = "no-latest-tag"
+	// MaxFindingAge fails when vulnerabilities of the rule severity or higher are open for more than the rule days
+	MaxFindingAge = "max-finding-age"
+	// NoKnownExploited fails when vulnerabilities of the rule severity or higher are in the CISA catalog of Known
+	// Exploited Vulnerabilities
+	NoKnownExploited = "no-known-exploited"
+	// MaxEPSS fails when vulnerabilities of the rule severity or higher have an EPSS score above the rule one
+	MaxEPSS = "max-epss"
+	// MaxVulnerabilities fails when more vulnerabilities of the rule severity, of any severity if the rule has none,
+	// than the rule max are found, only the ones with a fix available if the rule is fixable
+	MaxVulnerabilities = "max-vulnerabilities"
+)
+
+var ruleTypes = []string{NoVulnerabilities, ApprovedBaseImages, DeniedLicenses, NoMisconfigurations, AllowedRegistries,
+	DeniedRegistries, DeniedImages, NoLatestTag, MaxFindingAge, NoKnownExploited, MaxEPSS, MaxVulnerabilities}
+
+// Policy is a set of rules the scan results are evaluated against
+type Policy struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule is a rule of a policy, its fields depend on its type
+type Rule struct {
+	Name     string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Type     string   `json:"type" yaml:"type"`
+	Severity string   `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Fixable  bool     `json:"fixable,omitempty" yaml:"fixable,omitempty"`
+	Images   []string `json:"images,omitempty" yaml:"images,omitempty"`
+	Licenses []string `json:"licenses,omitempty" yaml:"licenses,omitempty"`
+	// Registries are registry hosts, optionally followed by a repository path prefix like ghcr.io/acme
+	Registries []string `json:"registries,omitempty" yaml:"registries,omitempty"`
+	// MaxDays is the number of days a finding can stay open
+	MaxDays int `json:"maxDays,omitempty" yaml:"maxDays,omitempty"`
+	// EPSS is the highest EPSS score allowed, from 0 to 1
+	EPSS float64 `json:"epss,omitempty" yaml:"epss,omitempty"`
+	// Max is the number of vulnerabilities allowed
+	Max *int `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// Load reads a policy file, either in YAML or in JSON when its extension is .json
+func Load(path string) (Policy, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Policy{}, err
+	}
+	unmarshal := yaml.Unmarshal
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		unmarshal = json.Unmarshal
+	}
+	var policy Policy
+	if err := unmarshal(buf, &policy); err != nil {
+		return Policy{}, fmt.Errorf("invalid policy file %s: %s", path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return Policy{}, fmt.Errorf("invalid policy file %s: %s", path, err)
+	}
+	return policy, nil
+}
+
+// RequiresLicenses returns true if the policy has rules on the licenses of the packages, which requires to scan them
+func (p Policy) RequiresLicenses() bool {
+	for _, rule := range p.Rules {
+		if rule.Type == DeniedLicenses {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresAge returns true if the policy has rules on the age of the findings, which requires to track them
+func (p Policy) RequiresAge() bool {
+	for _, rule := range p.Rules {
+		if rule.Type == MaxFindingAge {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresEPSS returns true if the policy has rules on the EPSS scores, which requires to enrich the findings with them
+func (p Policy) RequiresEPSS() bool {
+	return p.hasRule(MaxEPSS)
+}
+
+// RequiresKEV returns true if the policy has rules on the known exploited vulnerabilities, which requires to enrich
+// the findings with the CISA catalog
+func (p Policy) RequiresKEV() bool {
+	return p.hasRule(NoKnownExploited)
+}
+
+func (p Policy) hasRule(ruleType string) bool {
+	for _, rule := range p.Rules {
+		if rule.Type == ruleType {
+			return true
+		}
+	}
+	return false
+}
+
+// HasImageRules returns true if the policy has rules on the reference of the scanned image, which are evaluated
+// before the scan
+func (p Policy) HasImageRules() bool {
+	for _, rule := range p.Rules {
+		if rule.isImageRule() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) isImageRule() bool {
+	switch r.Type {
+	case AllowedRegistries, DeniedRegistries, DeniedImages, NoLatestTag:
+		return true
+	}
+	return false
+}
+
+// Validate checks the rules of the policy
+func (p Policy) Validate() error {
+	if len(p.Rules) == 0 {
+		return fmt.Errorf("the policy has no rules")
+	}
+	for i, rule := range p.Rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("rule %d: %s", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (r Rule) validate() error {
+	switch r.Type {
+	case NoVulnerabilities, NoMisconfigurations, NoKnownExploited:
+		return validateSeverity(r.Severity)
+	case ApprovedBaseImages, DeniedImages:
+		return r.validateImages()
+	case DeniedLicenses:
+		if len(r.Licenses) == 0 {
+			return fmt.Errorf("%s rule requires licenses", DeniedLicenses)
+		}
+	case AllowedRegistries, DeniedRegistries:
+		if len(r.Registries) == 0 {
+			return fmt.Errorf("%s rule requires registries", r.Type)
+		}
+	case MaxFindingAge:
+		if r.MaxDays <= 0 {
+			return fmt.Errorf("%s rule requires a positive maxDays", MaxFindingAge)
+		}
+		return validateSeverity(r.Severity)
+	case MaxEPSS:
+		if r.EPSS <= 0 || r.EPSS > 1 {
+			return fmt.Errorf("%s rule requires an epss score between 0 and 1", MaxEPSS)
+		}
+		return validateSeverity(r.Severity)
+	case MaxVulnerabilities:
+		if r.Max == nil || *r.Max < 0 {
+			return fmt.Errorf("%s rule requires a max of 0 or more", MaxVulnerabilities)
+		}
+		return validateSeverity(r.Severity)
+	case NoLatestTag:
+	default:
+		return fmt.Errorf("unknown rule type %q, expected one of %s", r.Type, strings.Join(ruleTypes, ", "))
+	}
+	return nil
+}
+
+func validateSeverity(severity string) error {
+	if severity != "" && report.SeverityLevel(severity) < 0 {
+		return fmt.Errorf("unknown severity %q, expected one of %s", severity, strings.Join(report.Severities, ", "))
+	}
+	return nil
+}
+
+func (r Rule) validateImages() error {
+	if len(r.Images) == 0 {
+		return fmt.Errorf("%s rule requires images", r.Type)
+	}
+	for _, image := range r.Images {
+		if _, err := reference.ParseNormalizedNamed(image); err != nil {
+			return fmt.Errorf("invalid image %q: %s", image, err)
+		}
+	}
+	return nil
+}
+
+// Title returns the name of the rule, or its type when it has no name
+func (r Rule) Title() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Type
+}
@@ -0,0 +1,212 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package policy
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+const testPolicy = `rules:
+  - name: No fixable critical vulnerabilities
+    type: no-vulnerabilities
+    severity: critical
+    fixable: true
+  - name: Approved base images
+    type: approved-base-images
+    images: [alpine, "debian:bullseye-slim"]
+  - name: No GPL licenses
+    type: denied-licenses
+    licenses: [GPL]
+`
+
+func TestLoad(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("policy.yaml", testPolicy),
+		fs.WithFile("policy.json", `{"rules": [{"type": "no-vulnerabilities"}]}`),
+		fs.WithFile("invalid.yaml", "rules:\n  - type: no-vulnerabilities\n    severity: urgent\n"))
+	defer dir.Remove()
+
+	policy, err := Load(dir.Join("policy.yaml"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policy.Rules[0], Rule{Name: "No fixable critical vulnerabilities", Type: NoVulnerabilities, Severity: "critical", Fixable: true})
+	assert.DeepEqual(t, policy.Rules[1].Images, []string{"alpine", "debian:bullseye-slim"})
+
+	policy, err = Load(dir.Join("policy.json"))
+	assert.NilError(t, err)
+	assert.Equal(t, policy.Rules[0].Title(), NoVulnerabilities)
+
+	_, err = Load(dir.Join("invalid.yaml"))
+	assert.ErrorContains(t, err, `rule 1: unknown severity "urgent"`)
+}
+
+func TestEvaluate(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithFile("policy.yaml", testPolicy))
+	defer dir.Remove()
+	policy, err := Load(dir.Join("policy.yaml"))
+	assert.NilError(t, err)
+
+	findings := []report.Vulnerability{
+		{ID: "SNYK-1", Severity: "critical", PackageName: "openssl", Version: "1.1.1d", FixedIn: []string{"1.1.1g"},
+			Identifiers: map[string][]string{"CVE": {"CVE-2020-1971"}}},
+		{ID: "SNYK-2", Severity: "critical", PackageName: "zlib", Version: "1.2.11"},
+		{ID: "SNYK-3", Severity: "high", PackageName: "curl", Version: "7.64", FixedIn: []string{"7.70"}},
+		{ID: "snyk:lic:deb:readline:GPL-3.0", Type: report.LicenseType, PackageName: "readline", Version: "8.0", License: "GPL-3.0"},
+		{ID: "snyk:lic:deb:zlib:LGPL-2.1", Type: report.LicenseType, PackageName: "libz", Version: "1.0", License: "LGPL-2.1"},
+	}
+	packages := []report.PackageLicense{
+		{Name: "bash", Version: "5.0", Licenses: []string{"GPL-3.0+"}},
+		{Name: "readline", Version: "8.0", Licenses: []string{"GPL-3.0"}},
+		{Name: "musl", Version: "1.2", Licenses: []string{"MIT"}},
+	}
+	assert.Assert(t, policy.RequiresLicenses())
+	results := Evaluate(policy, Input{BaseImage: "debian:buster", Findings: findings, Packages: packages})
+	assert.Equal(t, len(results), 3)
+	assert.DeepEqual(t, results[0].Violations, []string{"CVE-2020-1971 (critical) in openssl@1.1.1d"})
+	assert.DeepEqual(t, results[1].Violations, []string{"the base image debian:buster is not approved"})
+	assert.DeepEqual(t, results[2].Violations, []string{"bash@5.0 is licensed under GPL-3.0+", "readline@8.0 is licensed under GPL-3.0"})
+	assert.Assert(t, !Passed(results))
+
+	results = Evaluate(policy, Input{BaseImage: "docker.io/library/alpine:3.12", Findings: findings[1:3]})
+	assert.Assert(t, Passed(results))
+
+	out := bytes.NewBuffer(nil)
+	WriteResults(out, Evaluate(policy, Input{}))
+	assert.Equal(t, out.String(), `
+Policy evaluation:
+  PASS  No fixable critical vulnerabilities
+  FAIL  Approved base images
+          the base image of the image is unknown, use --file to provide its Dockerfile
+  PASS  No GPL licenses
+`)
+}
+
+func TestEvaluateMisconfigurations(t *testing.T) {
+	policy := Policy{Rules: []Rule{{Type: NoMisconfigurations, Severity: "high"}}}
+	assert.NilError(t, policy.Validate())
+	findings := []report.Vulnerability{
+		{ID: "DOCKER-ENGINE-INSECURE-REGISTRY", Type: report.ConfigType, Severity: "high", Title: "The registry 10.0.0.0/8 is used without TLS or certificate verification"},
+		{ID: "DOCKER-ENGINE-NO-LIVE-RESTORE", Type: report.ConfigType, Severity: "low", Title: "Live restore is disabled"},
+		{ID: "SNYK-1", Severity: "critical", PackageName: "openssl", Version: "1.1.1d"},
+	}
+	results := Evaluate(policy, Input{Findings: findings})
+	assert.DeepEqual(t, results[0].Violations, []string{
+		"DOCKER-ENGINE-INSECURE-REGISTRY (high): The registry 10.0.0.0/8 is used without TLS or certificate verification",
+	})
+	assert.Assert(t, Passed(Evaluate(policy, Input{Findings: findings[1:]})))
+}
+
+func TestEvaluateImage(t *testing.T) {
+	policy := Policy{Rules: []Rule{
+		{Type: NoVulnerabilities},
+		{Type: AllowedRegistries, Registries: []string{"registry.example.com", "index.docker.io/acme"}},
+		{Type: DeniedImages, Images: []string{"registry.example.com/legacy"}},
+		{Type: NoLatestTag},
+	}}
+	assert.NilError(t, policy.Validate())
+	assert.Assert(t, policy.HasImageRules())
+
+	assert.Assert(t, Passed(EvaluateImage(policy, "registry.example.com/app:1.2")))
+	assert.Assert(t, Passed(EvaluateImage(policy, "acme/app:1.2")))
+	assert.Assert(t, Passed(EvaluateImage(policy, "registry.example.com/app@sha256:"+digest)))
+
+	results := EvaluateImage(policy, "alpine")
+	assert.Equal(t, len(results), 3)
+	assert.DeepEqual(t, results[0].Violations, []string{"the image alpine is not from an allowed registry"})
+	assert.DeepEqual(t, results[2].Violations, []string{"the image alpine uses the latest tag, use a version tag or a digest"})
+
+	results = EvaluateImage(policy, "registry.example.com/legacy:2.0")
+	assert.DeepEqual(t, results[1].Violations, []string{"the image registry.example.com/legacy:2.0 is denied"})
+	assert.Assert(t, !Passed(Evaluate(policy, Input{Image: "registry.example.com/app:latest"})))
+
+	denied := Policy{Rules: []Rule{{Type: DeniedRegistries, Registries: []string{"docker.io"}}}}
+	assert.Assert(t, !Passed(EvaluateImage(denied, "alpine:3.15")))
+	assert.Assert(t, Passed(EvaluateImage(denied, "ghcr.io/acme/app:1.0")))
+	assert.ErrorContains(t, Policy{Rules: []Rule{{Type: DeniedRegistries}}}.Validate(), "denied-registries rule requires registries")
+}
+
+const digest = "4ff3ca91275773af45cb4b0834e12b7eb47d1c18f770a0b151381cd227f4c253"
+
+func TestEvaluateFindingAge(t *testing.T) {
+	policy := Policy{Rules: []Rule{{Type: MaxFindingAge, Severity: "critical", MaxDays: 7}}}
+	assert.NilError(t, policy.Validate())
+	assert.Assert(t, policy.RequiresAge())
+	now := time.Date(2021, time.March, 15, 9, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, 0, -9)
+	recent := now.AddDate(0, 0, -2)
+	findings := []report.Vulnerability{
+		{ID: "SNYK-1", Severity: "critical", PackageName: "openssl", Version: "1.1.1d", FirstSeen: &old},
+		{ID: "SNYK-2", Severity: "critical", PackageName: "curl", Version: "7.64.0", FirstSeen: &recent},
+		{ID: "SNYK-3", Severity: "high", PackageName: "zlib", Version: "1.2.11", FirstSeen: &old},
+		{ID: "SNYK-4", Severity: "critical", PackageName: "glibc", Version: "2.28"},
+	}
+	results := Evaluate(policy, Input{Findings: findings, Now: now})
+	assert.DeepEqual(t, results[0].Violations, []string{"SNYK-1 (critical) in openssl@1.1.1d open for 9 days, more than 7"})
+	assert.Assert(t, Passed(Evaluate(policy, Input{Findings: findings[1:], Now: now})))
+	assert.ErrorContains(t, Policy{Rules: []Rule{{Type: MaxFindingAge}}}.Validate(), "max-finding-age rule requires a positive maxDays")
+}
+
+func TestEvaluateExploits(t *testing.T) {
+	policy := Policy{Rules: []Rule{
+		{Type: NoKnownExploited},
+		{Type: MaxEPSS, Severity: "high", EPSS: 0.5},
+	}}
+	assert.NilError(t, policy.Validate())
+	assert.Assert(t, policy.RequiresKEV())
+	assert.Assert(t, policy.RequiresEPSS())
+	findings := []report.Vulnerability{
+		{ID: "SNYK-1", Severity: "low", PackageName: "log4j", Version: "2.14.1", Exploit: &report.Exploit{EPSS: 0.975, KnownExploited: true}},
+		{ID: "SNYK-2", Severity: "critical", PackageName: "curl", Version: "7.64.0", Exploit: &report.Exploit{EPSS: 0.62}},
+		{ID: "SNYK-3", Severity: "high", PackageName: "zlib", Version: "1.2.11", Exploit: &report.Exploit{EPSS: 0.01}},
+		{ID: "SNYK-4", Severity: "critical", PackageName: "glibc", Version: "2.28"},
+	}
+	results := Evaluate(policy, Input{Findings: findings})
+	assert.DeepEqual(t, results[0].Violations, []string{"SNYK-1 (low) in log4j@2.14.1 is known to be exploited"})
+	assert.DeepEqual(t, results[1].Violations, []string{"SNYK-2 (critical) in curl@7.64.0 has an EPSS score of 0.620, more than 0.500"})
+	assert.Assert(t, Passed(Evaluate(policy, Input{Findings: findings[2:]})))
+	assert.ErrorContains(t, Policy{Rules: []Rule{{Type: MaxEPSS, EPSS: 2}}}.Validate(), "max-epss rule requires an epss score between 0 and 1")
+}
+
+func TestEvaluateMaxVulnerabilities(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("policy.yaml", "rules:\n  - type: max-vulnerabilities\n    severity: critical\n    max: 0\n"+
+			"  - type: max-vulnerabilities\n    severity: high\n    max: 1\n  - type: max-vulnerabilities\n    fixable: true\n    max: 2\n"))
+	defer dir.Remove()
+	policy, err := Load(dir.Join("policy.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, *policy.Rules[0].Max, 0)
+
+	findings := []report.Vulnerability{
+		{ID: "SNYK-1", Severity: "high", PackageName: "openssl", Version: "1.1.1d", FixedIn: []string{"1.1.1g"}},
+		{ID: "SNYK-1", Severity: "high", PackageName: "openssl", Version: "1.1.1d", FixedIn: []string{"1.1.1g"}, Path: "/usr/lib"},
+		{ID: "SNYK-2", Severity: "High", PackageName: "curl", Version: "7.64.0"},
+		{ID: "SNYK-3", Severity: "medium", PackageName: "zlib", Version: "1.2.11", FixedIn: []string{"1.2.12"}},
+		{ID: "CFG-1", Severity: "critical", Type: report.ConfigType},
+	}
+	results := Evaluate(policy, Input{Findings: findings})
+	assert.Assert(t, results[0].Passed())
+	assert.DeepEqual(t, results[1].Violations, []string{"2 high vulnerabilities found, 1 allowed"})
+	assert.Assert(t, results[2].Passed())
+	assert.Assert(t, Passed(Evaluate(policy, Input{Findings: findings[2:]})))
+
+	assert.ErrorContains(t, Policy{Rules: []Rule{{Type: MaxVulnerabilities}}}.Validate(), "max-vulnerabilities rule requires a max of 0 or more")
+}
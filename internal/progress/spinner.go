@@ -0,0 +1,73 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const spinnerInterval = 100 * time.Millisecond
+
+var frames = []string{"|", "/", "-", "\\"}
+
+// Spinner shows an activity indicator on a terminal while a long operation runs
+type Spinner struct {
+	out     io.Writer
+	message string
+	done    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// StartSpinner prints the message with a spinner on out until Stop is called
+func StartSpinner(out io.Writer, message string) *Spinner {
+	s := &Spinner{
+		out:     out,
+		message: message,
+		done:    make(chan struct{}),
+	}
+	s.start()
+	return s
+}
+
+func (s *Spinner) start() {
+	s.stopped.Add(1)
+	go func() {
+		defer s.stopped.Done()
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			fmt.Fprintf(s.out, "\r%s %s", frames[frame%len(frames)], s.message)
+			select {
+			case <-s.done:
+				// erase the line so that the next output starts on a clean line
+				fmt.Fprintf(s.out, "\r%s\r", strings.Repeat(" ", len(s.message)+2))
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop erases the spinner, and waits for it to be erased before returning
+func (s *Spinner) Stop() {
+	close(s.done)
+	s.stopped.Wait()
+}
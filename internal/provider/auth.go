@@ -0,0 +1,159 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/docker/scan-cli-plugin/internal/authentication"
+	"github.com/docker/scan-cli-plugin/internal/debug"
+	"github.com/docker/scan-cli-plugin/internal/hub"
+	"gopkg.in/square/go-jose.v2"
+)
+
+const (
+	// EnvTokenSource is the source used when the SNYK_TOKEN environment variable is set
+	EnvTokenSource = "SNYK_TOKEN environment variable"
+	// StoreTokenSource is the source used when a provider token is stored in the Docker credentials store
+	StoreTokenSource = "Snyk token from the Docker credentials store"
+	// DockerScanIDSource is the source used when scans run under the Docker Hub identity
+	DockerScanIDSource = "Docker Hub DockerScanID"
+)
+
+// dockerScanIDEnv is the environment variable passing the DockerScanID to Snyk
+const dockerScanIDEnv = "SNYK_DOCKER_TOKEN="
+
+// AuthStatus describes the identity a scan runs under
+type AuthStatus struct {
+	Source   string `json:"source,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// IsAuthenticated returns true if a scan can run with the current credentials
+func (a AuthStatus) IsAuthenticated() bool {
+	return a.Source != ""
+}
+
+// GetAuthStatus returns the credentials which will be used to run a scan
+func GetAuthStatus(opts Options) (AuthStatus, error) {
+	if os.Getenv("SNYK_TOKEN") != "" {
+		return AuthStatus{Source: EnvTokenSource}, nil
+	}
+	token, err := opts.tokenStore.Get()
+	if err != nil {
+		return AuthStatus{}, err
+	}
+	if token != "" {
+		return AuthStatus{Source: tokenStoreSource(opts.tokenStore)}, nil
+	}
+	if opts.auth.Username == "" {
+		return AuthStatus{}, nil
+	}
+	return AuthStatus{Source: DockerScanIDSource, Username: opts.auth.Username}, nil
+}
+
+// Identity returns a digest of the credentials and the Snyk organization a scan runs under, to tell apart
+// the results of different accounts without storing their credentials
+func Identity(opts Options) (string, error) {
+	credentials := os.Getenv("SNYK_TOKEN")
+	if credentials == "" {
+		token, err := opts.tokenStore.Get()
+		if err != nil {
+			return "", err
+		}
+		credentials = token
+	}
+	if credentials == "" && opts.auth.Username != "" {
+		credentials = "hub:" + opts.auth.Username
+	}
+	org := SelectedOrg(opts)
+	hash := sha256.Sum256([]byte(credentials + "\x00" + org))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Logout removes the provider token and the DockerScanID stored locally
+func Logout(opts Options) error {
+	if err := opts.tokenStore.Erase(); err != nil {
+		return err
+	}
+	if opts.auth.Username == "" {
+		return nil
+	}
+	authenticator := authentication.NewAuthenticator(jose.JSONWebKeySet{}, hub.GetInstance().APIHubBaseURL, opts.httpClient)
+	return authenticator.RemoveLocalToken(opts.auth)
+}
+
+// scanTokenEnv returns the environment variable holding the credentials used by the provider to scan
+func scanTokenEnv(opts Options) (string, error) {
+	if token := os.Getenv("SNYK_TOKEN"); token != "" {
+		debug.Log("using provider token", "source", EnvTokenSource)
+		return "SNYK_TOKEN=" + token, nil
+	}
+	token, err := opts.tokenStore.Get()
+	if token != "" && err == nil {
+		debug.Log("using provider token", "source", tokenStoreSource(opts.tokenStore))
+		return "SNYK_TOKEN=" + token, nil
+	}
+	// the tokens which don't come from the credentials store, like the exchanged ones, are expected to be there
+	if err != nil && tokenStoreSource(opts.tokenStore) != StoreTokenSource {
+		return "", err
+	}
+	debug.Log("using provider token", "source", DockerScanIDSource, "user", opts.auth.Username)
+	token, err = getToken(opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to get DockerScanID: %w", err)
+	}
+	return dockerScanIDEnv + token, nil
+}
+
+// tokenStoreSource returns the source of the tokens of the store, the Docker credentials store unless the store
+// tells otherwise
+func tokenStoreSource(store TokenStore) string {
+	if sourced, ok := store.(interface{ Source() string }); ok {
+		return sourced.Source()
+	}
+	return StoreTokenSource
+}
+
+// DockerScanID returns the DockerScanID of the Docker Hub account, a new one is negotiated with Docker Hub when the
+// stored one expired
+func DockerScanID(opts Options) (string, error) {
+	return getToken(opts)
+}
+
+func getToken(opts Options) (string, error) {
+	if opts.auth.Username == "" {
+		return "", classify(ErrNotAuthenticated, nil, `You need to be logged in to Docker Hub to use scan feature.
+please login to Docker Hub using the Docker Login command`)
+	}
+	h := hub.GetInstance()
+	var token string
+	// Docker Hub may be unreachable for a moment, the DockerScanID is requested again after a transient failure
+	err := retry(opts, func() error {
+		jwks, err := h.FetchJwks(opts.httpClient)
+		if err != nil {
+			return err
+		}
+		authenticator := authentication.NewAuthenticator(jwks, h.APIHubBaseURL, opts.httpClient)
+		token, err = authenticator.GetToken(opts.auth)
+		return err
+	})
+	return token, err
+}
@@ -0,0 +1,142 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/registry"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/env"
+)
+
+func TestGetAuthStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		envToken string
+		token    string
+		username string
+		expected AuthStatus
+	}{
+		{
+			name:     "not authenticated",
+			expected: AuthStatus{},
+		},
+		{
+			name:     "environment variable first",
+			envToken: snykToken,
+			token:    snykToken,
+			username: "hubUser",
+			expected: AuthStatus{Source: EnvTokenSource},
+		},
+		{
+			name:     "provider token before Docker Hub",
+			token:    snykToken,
+			username: "hubUser",
+			expected: AuthStatus{Source: StoreTokenSource},
+		},
+		{
+			name:     "Docker Hub",
+			username: "hubUser",
+			expected: AuthStatus{Source: DockerScanIDSource, Username: "hubUser"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			defer env.Patch(t, "SNYK_TOKEN", testCase.envToken)()
+			opts, err := NewProvider(
+				WithTokenStore(&memoryTokenStore{token: testCase.token}),
+				WithAuthConfig(func(*registry.IndexInfo) types.AuthConfig {
+					return types.AuthConfig{Username: testCase.username}
+				}))
+			assert.NilError(t, err)
+
+			status, err := GetAuthStatus(opts)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, status, testCase.expected)
+			assert.Equal(t, status.IsAuthenticated(), testCase.expected.Source != "")
+		})
+	}
+}
+
+func TestLogoutErasesProviderToken(t *testing.T) {
+	store := &memoryTokenStore{token: snykToken}
+	opts, err := NewProvider(WithTokenStore(store))
+	assert.NilError(t, err)
+
+	assert.NilError(t, Logout(opts))
+	assert.Equal(t, store.token, "")
+}
+
+func TestIdentity(t *testing.T) {
+	identity := func(envToken, token, username, org string) string {
+		defer env.Patch(t, "SNYK_TOKEN", envToken)()
+		defer env.Patch(t, "SNYK_CFG_ORG", org)()
+		opts, err := NewProvider(
+			WithTokenStore(&memoryTokenStore{token: token}),
+			WithAuthConfig(func(*registry.IndexInfo) types.AuthConfig {
+				return types.AuthConfig{Username: username}
+			}))
+		assert.NilError(t, err)
+		id, err := Identity(opts)
+		assert.NilError(t, err)
+		assert.Assert(t, !strings.Contains(id, snykToken))
+		return id
+	}
+	assert.Equal(t, identity("", snykToken, "user", ""), identity(snykToken, "", "", ""))
+	assert.Assert(t, identity("", snykToken, "", "") != identity("", "other-token", "", ""))
+	assert.Assert(t, identity("", snykToken, "", "") != identity("", snykToken, "", "my-org"))
+	assert.Assert(t, identity("", "", "user", "") != identity("", "", "other-user", ""))
+
+	// the organization of a profile is used like the one of the environment
+	opts, err := NewProvider(WithTokenStore(&memoryTokenStore{token: snykToken}), WithOrg("my-org"))
+	assert.NilError(t, err)
+	id, err := Identity(opts)
+	assert.NilError(t, err)
+	assert.Equal(t, id, identity("", snykToken, "", "my-org"))
+}
+
+type exchangedTokenStore struct {
+	memoryTokenStore
+	err error
+}
+
+func (s *exchangedTokenStore) Get() (string, error) {
+	return s.token, s.err
+}
+
+func (s *exchangedTokenStore) Source() string {
+	return "exchanged token"
+}
+
+func TestExchangedTokenSource(t *testing.T) {
+	defer env.Patch(t, "SNYK_TOKEN", "")()
+	opts, err := NewProvider(WithTokenStore(&exchangedTokenStore{memoryTokenStore: memoryTokenStore{token: snykToken}}))
+	assert.NilError(t, err)
+	status, err := GetAuthStatus(opts)
+	assert.NilError(t, err)
+	assert.Equal(t, status.Source, "exchanged token")
+
+	// a failed exchange doesn't fall back to the DockerScanID
+	opts, err = NewProvider(WithTokenStore(&exchangedTokenStore{err: errors.New("untrusted issuer")}))
+	assert.NilError(t, err)
+	_, err = scanTokenEnv(opts)
+	assert.Error(t, err, "untrusted issuer")
+}
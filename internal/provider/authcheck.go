@@ -0,0 +1,168 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// defaultSnykAPI is the Snyk API used unless the SNYK_API environment variable points to another one, like Snyk does
+const defaultSnykAPI = "https://snyk.io/api"
+
+// SnykAPI returns the Snyk API called by the plugin and by Snyk
+func SnykAPI() string {
+	if api := os.Getenv("SNYK_API"); api != "" {
+		return api
+	}
+	return defaultSnykAPI
+}
+
+// AuthCheck is the result of an authenticated call made with the credentials of the scans
+type AuthCheck struct {
+	AuthStatus
+	// Valid is true when the credentials were accepted
+	Valid bool `json:"valid"`
+	// User is the Snyk account of the provider token
+	User string `json:"user,omitempty"`
+	// Org is the Snyk organization the scans run in, when one is selected
+	Org string `json:"org,omitempty"`
+	// Error tells why the credentials were refused
+	Error string `json:"error,omitempty"`
+}
+
+// snykUser is the part of the response of the Snyk user endpoint telling the account and its organizations
+type snykUser struct {
+	Username string `json:"username"`
+	Orgs     []Org  `json:"orgs"`
+}
+
+// Org is a Snyk organization the account is a member of
+type Org struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// ListOrgs returns the Snyk organizations available to the Snyk token of the scans
+func ListOrgs(opts Options) ([]Org, error) {
+	token, err := apiToken(opts)
+	if err != nil {
+		return nil, err
+	}
+	var user snykUser
+	if err := retry(opts, func() error {
+		return getSnykUser(opts, token, &user)
+	}); err != nil {
+		return nil, err
+	}
+	return user.Orgs, nil
+}
+
+// SelectedOrg returns the organization the scans run in, selected with WithOrg or SNYK_CFG_ORG, empty for the default
+// organization of the account
+func SelectedOrg(opts Options) string {
+	if opts.org != "" {
+		return opts.org
+	}
+	return os.Getenv("SNYK_CFG_ORG")
+}
+
+// Matches returns true if the organization is the given ID, name or slug
+func (o Org) Matches(org string) bool {
+	return org == o.ID || org == o.Name || org == o.Slug
+}
+
+// CheckAuth makes a cheap authenticated call with the credentials the scans run with: the Snyk API tells the account
+// of a provider token and whether it can access the selected organization, otherwise the DockerScanID of the Docker
+// Hub account is retrieved. The returned error is nil only if the credentials are valid.
+func CheckAuth(opts Options) (AuthCheck, error) {
+	status, err := GetAuthStatus(opts)
+	if err != nil {
+		return AuthCheck{}, err
+	}
+	check := AuthCheck{AuthStatus: status, Org: SelectedOrg(opts)}
+	switch status.Source {
+	case "":
+		err = classify(ErrNotAuthenticated, nil, "no credentials, log in to Docker Hub or authenticate to Snyk")
+	case DockerScanIDSource:
+		_, err = getToken(opts)
+	default:
+		err = checkSnykToken(opts, &check)
+	}
+	if err != nil {
+		check.Error = err.Error()
+		return check, err
+	}
+	check.Valid = true
+	return check, nil
+}
+
+// checkSnykToken gets the Snyk account of the provider token, and checks it can access the organization of the check
+func checkSnykToken(opts Options, check *AuthCheck) error {
+	token := os.Getenv("SNYK_TOKEN")
+	if token == "" {
+		var err error
+		if token, err = opts.tokenStore.Get(); err != nil {
+			return err
+		}
+	}
+	var user snykUser
+	if err := retry(opts, func() error {
+		return getSnykUser(opts, token, &user)
+	}); err != nil {
+		return err
+	}
+	check.User = user.Username
+	if check.Org == "" {
+		return nil
+	}
+	for _, org := range user.Orgs {
+		if org.Matches(check.Org) {
+			return nil
+		}
+	}
+	return classify(ErrNotAuthenticated, nil, "the Snyk account %s is not a member of the organization %s", user.Username, check.Org)
+}
+
+func getSnykUser(opts Options, token string, user *snykUser) error {
+	req, err := http.NewRequestWithContext(opts.context, http.MethodGet, strings.TrimSuffix(SnykAPI(), "/")+"/v1/user/me", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "token "+token)
+	resp, err := opts.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return classify(ErrNotAuthenticated, nil, "the Snyk token was refused: %s", resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("failed to check the Snyk token: %s", resp.Status)
+	}
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, user)
+}
@@ -0,0 +1,99 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/env"
+)
+
+func TestCheckAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/api/v1/user/me")
+		if r.Header.Get("Authorization") != "token "+snykToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"username": "jane", "orgs": [{"id": "1234", "name": "Acme Prod", "slug": "acme-prod"}]}`)
+	}))
+	defer server.Close()
+	defer env.Patch(t, "SNYK_API", server.URL+"/api/")()
+	defer env.Patch(t, "SNYK_TOKEN", "")()
+	defer env.Patch(t, "SNYK_CFG_ORG", "")()
+
+	opts, err := NewProvider(WithTokenStore(&memoryTokenStore{token: snykToken}), WithOrg("acme-prod"))
+	assert.NilError(t, err)
+	check, err := CheckAuth(opts)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, check, AuthCheck{AuthStatus: AuthStatus{Source: StoreTokenSource}, Valid: true, User: "jane", Org: "acme-prod"})
+
+	opts, err = NewProvider(WithTokenStore(&memoryTokenStore{token: snykToken}), WithOrg("acme-staging"))
+	assert.NilError(t, err)
+	check, err = CheckAuth(opts)
+	assert.Assert(t, errors.Is(err, ErrNotAuthenticated))
+	assert.Equal(t, check.Error, "the Snyk account jane is not a member of the organization acme-staging")
+	assert.Assert(t, !check.Valid)
+
+	opts, err = NewProvider(WithTokenStore(&memoryTokenStore{token: "revoked"}))
+	assert.NilError(t, err)
+	check, err = CheckAuth(opts)
+	assert.Assert(t, errors.Is(err, ErrNotAuthenticated))
+	assert.Equal(t, check.Error, "the Snyk token was refused: 401 Unauthorized")
+
+	opts, err = NewProvider()
+	assert.NilError(t, err)
+	check, err = CheckAuth(opts)
+	assert.Assert(t, errors.Is(err, ErrNotAuthenticated))
+	assert.Assert(t, !check.IsAuthenticated())
+}
+
+func TestListOrgs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/api/v1/user/me")
+		fmt.Fprint(w, `{"username": "jane", "orgs": [{"id": "1234", "name": "Acme Prod", "slug": "acme-prod"}, {"id": "5678", "name": "Acme Staging", "slug": "acme-staging"}]}`)
+	}))
+	defer server.Close()
+	defer env.Patch(t, "SNYK_API", server.URL+"/api/")()
+	defer env.Patch(t, "SNYK_TOKEN", "")()
+	defer env.Patch(t, "SNYK_CFG_ORG", "acme-staging")()
+
+	opts, err := NewProvider(WithTokenStore(&memoryTokenStore{token: snykToken}))
+	assert.NilError(t, err)
+	orgs, err := ListOrgs(opts)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, orgs, []Org{
+		{ID: "1234", Name: "Acme Prod", Slug: "acme-prod"},
+		{ID: "5678", Name: "Acme Staging", Slug: "acme-staging"},
+	})
+	assert.Equal(t, SelectedOrg(opts), "acme-staging")
+	assert.Assert(t, orgs[1].Matches("5678"))
+
+	// the organization of the flag replaces the one of the profile
+	opts, err = NewProvider(WithOrg("acme-prod"), WithOrg("1234"), WithProjectName("web"))
+	assert.NilError(t, err)
+	assert.Equal(t, SelectedOrg(opts), "1234")
+	assert.DeepEqual(t, opts.flags, []string{"container", "test", "--org=1234", "--project-name=web"})
+
+	_, err = ListOrgs(Options{tokenStore: emptyTokenStore{}})
+	assert.Assert(t, errors.Is(err, ErrNotAuthenticated))
+}
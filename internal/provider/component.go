@@ -0,0 +1,127 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+// databaseTimeLayouts are the formats of the update times of the Trivy and Grype vulnerability databases
+var databaseTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	time.RFC3339Nano,
+}
+
+// Component describes the scan provider for docker scan version
+type Component struct {
+	// Name is the name of the provider, like Snyk or Trivy
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// MinimalVersion is the constraint on the version of the binaries installed by the user, empty when any version
+	// is supported
+	MinimalVersion string `json:"minimalVersion,omitempty"`
+	// DatabaseUpdatedAt is the update time of the local vulnerability database, nil when the provider queries its
+	// hosted database or the time is unknown
+	DatabaseUpdatedAt *time.Time `json:"databaseUpdatedAt,omitempty"`
+}
+
+// Describe returns the name and the version of the provider, with its minimal version and the update time of its
+// vulnerability database
+func Describe(p Provider) (Component, error) {
+	version, err := p.Version()
+	if err != nil {
+		return Component{}, err
+	}
+	component := parseComponent(version)
+	switch p := p.(type) {
+	case *snykProvider:
+		component.MinimalVersion = minimalSnykVersion
+	case *trivyProvider:
+		component.DatabaseUpdatedAt = p.databaseUpdatedAt()
+	case *grypeProvider:
+		component.DatabaseUpdatedAt = p.databaseUpdatedAt()
+	}
+	return component, nil
+}
+
+// parseComponent splits the provider version, like Snyk (1.827.0), in its name and its version
+func parseComponent(version string) Component {
+	start := strings.LastIndex(version, " (")
+	if start < 0 || !strings.HasSuffix(version, ")") {
+		return Component{Name: version}
+	}
+	return Component{Name: version[:start], Version: version[start+2 : len(version)-1]}
+}
+
+func (t *trivyProvider) databaseUpdatedAt() *time.Time {
+	cmd := t.newCommand("--version")
+	buff := bytes.NewBuffer(nil)
+	cmd.Stdout = buff
+	defer logCommand(cmd.Args)()
+	if err := runCommand(t.context, cmd); err != nil {
+		return nil
+	}
+	return trivyDatabaseUpdatedAt(buff.String())
+}
+
+// trivyDatabaseUpdatedAt returns the update time of the vulnerability database of the trivy --version output, the
+// Java database being ignored
+func trivyDatabaseUpdatedAt(output string) *time.Time {
+	inDatabase := false
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case !strings.HasPrefix(line, " "):
+			inDatabase = strings.HasPrefix(line, "Vulnerability DB:")
+		case inDatabase && strings.HasPrefix(strings.TrimSpace(line), "UpdatedAt:"):
+			return parseDatabaseTime(strings.TrimPrefix(strings.TrimSpace(line), "UpdatedAt:"))
+		}
+	}
+	return nil
+}
+
+func (g *grypeProvider) databaseUpdatedAt() *time.Time {
+	cmd := g.newCommand("db", "status")
+	buff := bytes.NewBuffer(nil)
+	cmd.Stdout = buff
+	defer logCommand(cmd.Args)()
+	if err := runCommand(g.context, cmd); err != nil {
+		return nil
+	}
+	return grypeDatabaseUpdatedAt(buff.String())
+}
+
+// grypeDatabaseUpdatedAt returns the build time of the database of the grype db status output
+func grypeDatabaseUpdatedAt(output string) *time.Time {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "Built:") {
+			return parseDatabaseTime(strings.TrimPrefix(line, "Built:"))
+		}
+	}
+	return nil
+}
+
+func parseDatabaseTime(value string) *time.Time {
+	for _, layout := range databaseTimeLayouts {
+		if updatedAt, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			updatedAt = updatedAt.UTC()
+			return &updatedAt
+		}
+	}
+	return nil
+}
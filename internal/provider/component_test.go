@@ -0,0 +1,56 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseComponent(t *testing.T) {
+	assert.DeepEqual(t, parseComponent("Snyk (1.827.0)"), Component{Name: "Snyk", Version: "1.827.0"})
+	assert.DeepEqual(t, parseComponent("Docker Hub (hub.docker.com)"), Component{Name: "Docker Hub", Version: "hub.docker.com"})
+	assert.DeepEqual(t, parseComponent("custom"), Component{Name: "custom"})
+}
+
+func TestTrivyDatabaseUpdatedAt(t *testing.T) {
+	output := `Version: 0.45.1
+Vulnerability DB:
+  Version: 2
+  UpdatedAt: 2023-09-20 12:10:44.785316 +0000 UTC
+  NextUpdate: 2023-09-21 00:10:44.785316 +0000 UTC
+Java DB:
+  Version: 1
+  UpdatedAt: 2023-09-18 01:02:03.123 +0000 UTC
+`
+	updatedAt := trivyDatabaseUpdatedAt(output)
+	assert.Assert(t, updatedAt != nil)
+	assert.Equal(t, updatedAt.Format(time.RFC3339), "2023-09-20T12:10:44Z")
+	assert.Assert(t, trivyDatabaseUpdatedAt("Version: 0.45.1\n") == nil)
+}
+
+func TestGrypeDatabaseUpdatedAt(t *testing.T) {
+	updatedAt := grypeDatabaseUpdatedAt("Location:  /root/.cache/grype/db/5\nBuilt:     2023-09-20 01:31:08 +0000 UTC\nSchema:    5\n")
+	assert.Assert(t, updatedAt != nil)
+	assert.Equal(t, updatedAt.Format(time.RFC3339), "2023-09-20T01:31:08Z")
+
+	updatedAt = grypeDatabaseUpdatedAt("Path:      /root/.cache/grype/db/6\nBuilt:     2025-02-11T04:06:41Z\n")
+	assert.Assert(t, updatedAt != nil)
+	assert.Equal(t, updatedAt.Format(time.RFC3339), "2025-02-11T04:06:41Z")
+}
@@ -18,12 +18,14 @@ package provider
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/pkg/jsonmessage"
 
@@ -34,6 +36,8 @@ import (
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/stdcopy"
 
+	"github.com/docker/scan-cli-plugin/internal/debug"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
 	"github.com/google/uuid"
 	"github.com/mitchellh/go-homedir"
 )
@@ -44,6 +48,8 @@ var (
 	image       = fmt.Sprintf("snyk/snyk@%s", ImageDigest)
 )
 
+const containerCACertPath = "/etc/docker-scan/ca.pem"
+
 type dockerSnykProvider struct {
 	cli command.Cli
 	Options
@@ -56,12 +62,16 @@ type removeContainerFunc func() error
 
 func (d *dockerSnykProvider) removeContainer(containerID string) removeContainerFunc {
 	return func() error {
-		return d.cli.Client().ContainerRemove(d.context, containerID, types.ContainerRemoveOptions{})
+		// the container is also removed, and stopped if still running, when the scan is canceled
+		return d.cli.Client().ContainerRemove(context.Background(), containerID, types.ContainerRemoveOptions{Force: true})
 	}
 }
 
 // NewDockerSnykProvider returns a containerized Snyk implementation of scan provider
 func NewDockerSnykProvider(cli command.Cli, defaultProvider Options) (Provider, error) {
+	if !SupportsContainerizedProvider(runtime.GOARCH) {
+		return nil, classify(ErrBinaryNotFound, nil, "could not find Snyk binary, there is no Snyk image for linux/%s, please install Snyk using npm (npm install -g snyk)", runtime.GOARCH)
+	}
 	provider := dockerSnykProvider{
 		cli:     cli,
 		Options: defaultProvider,
@@ -71,11 +81,13 @@ func NewDockerSnykProvider(cli command.Cli, defaultProvider Options) (Provider,
 	if err != nil {
 		responseBody, err := cli.Client().ImagePull(provider.context, image, options)
 		if err != nil {
-			return nil, err
+			return nil, classify(ErrImagePullFailed, err, "failed to pull %s: %s", image, err)
 		}
 		//nolint: errcheck
 		defer responseBody.Close()
-		return &provider, jsonmessage.DisplayJSONMessagesStream(responseBody, bytes.NewBuffer(nil), cli.Out().FD(), false, nil)
+		if err := jsonmessage.DisplayJSONMessagesStream(responseBody, bytes.NewBuffer(nil), cli.Out().FD(), false, nil); err != nil {
+			return &provider, classify(ErrImagePullFailed, err, "failed to pull %s: %s", image, err)
+		}
 	}
 	return &provider, nil
 }
@@ -107,7 +119,7 @@ func (d *dockerSnykProvider) Authenticate(token string) error {
 		return err
 	}
 
-	streamFunc, err := d.startContainer(containerID)
+	streamFunc, _, err := d.startContainer(containerID)
 	if err != nil {
 		return err
 	}
@@ -116,7 +128,10 @@ func (d *dockerSnykProvider) Authenticate(token string) error {
 		return err
 	}
 	streamFunc()
-	return d.copySnykConfigToHost(containerName, home)
+	if err := d.copySnykConfigToHost(containerName, home); err != nil {
+		return err
+	}
+	return d.tokenStore.Migrate()
 }
 
 func (d *dockerSnykProvider) checkContainerState(containerID string) error {
@@ -130,7 +145,7 @@ func (d *dockerSnykProvider) checkContainerState(containerID string) error {
 		switch s.StatusCode {
 		case 0:
 		default:
-			return containerizedError{}
+			return containerizedError{statusCode: s.StatusCode}
 		}
 	}
 	return nil
@@ -146,10 +161,12 @@ func (d *dockerSnykProvider) createContainer(token string, containerName string)
 		"SNYK_UTM_SOURCE=Docker",
 		"SNYK_UTM_CAMPAIGN=Docker-Desktop-2020",
 	}
+	envVars = append(envVars, providerLocale...)
 	bindings := dockerBindings{
-		"/var/run/docker.sock:/var/run/docker.sock",
+		dockerSocketBinding(d.cli.DockerEndpoint().Host),
 		"TMP:/root/.config/configstore",
 	}
+	envVars, bindings = d.networkConfig(envVars, bindings)
 
 	config, hostConfig := containerConfigs(envVars, bindings, strslice.StrSlice{"snyk", "auth", token})
 
@@ -178,22 +195,29 @@ func (d *dockerSnykProvider) copySnykConfigToContainer(containerID string, home
 
 }
 
-func (d *dockerSnykProvider) startContainer(containerID string) (func(), error) {
+// startContainer starts the container streaming its output, and returns the function to stop the streaming with the
+// spool of its error output
+func (d *dockerSnykProvider) startContainer(containerID string) (func(), *stderrSpool, error) {
 	resp, err := d.cli.Client().ContainerAttach(d.context, containerID, types.ContainerAttachOptions{
 		Stream: true,
 		Stdout: true,
 		Stderr: true,
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	// a large error output is spooled to a temporary file
+	spool := newStderrSpool(d.err)
 	go func() {
 		for {
-			_, err = stdcopy.StdCopy(d.out, d.err, resp.Reader)
+			_, err = stdcopy.StdCopy(d.out, spool, resp.Reader)
 		}
 	}()
 
-	return resp.Close, d.cli.Client().ContainerStart(d.context, containerID, types.ContainerStartOptions{})
+	return func() {
+		resp.Close()
+		spool.Close() //nolint:errcheck
+	}, spool, d.cli.Client().ContainerStart(d.context, containerID, types.ContainerStartOptions{})
 }
 
 func (d *dockerSnykProvider) copySnykConfigToHost(containerID string, home string) error {
@@ -211,33 +235,53 @@ func (d *dockerSnykProvider) copySnykConfigToHost(containerID string, home strin
 	return archive.Untar(reader, configstoreFolder, &archive.TarOptions{NoLchown: true})
 }
 
-func (d *dockerSnykProvider) Scan(image string) error {
-	var token string
-	snykAuthToken, err := getSnykAuthenticationToken()
-	if snykAuthToken == "" || err != nil {
-		var err error
-		token, err = getToken(d.Options)
-		if err != nil {
-			return fmt.Errorf("failed to get DockerScanID: %s", err)
-		}
-		token = fmt.Sprintf("SNYK_DOCKER_TOKEN=%s", token)
-	} else {
-		token = fmt.Sprintf("SNYK_TOKEN=%s", snykAuthToken)
+func (d *dockerSnykProvider) Scan(target string) error {
+	token, err := scanTokenEnv(d.Options)
+	if err != nil {
+		return err
 	}
+	err = retryScan(d.Options, func(out, errOut io.Writer) error {
+		return d.scanContainer(token, target, out, errOut)
+	})
+	recordUsage(d.Options, token, err)
+	return err
+}
+
+// scanContainer runs a scan in a new provider container, writing its output to out and errOut
+func (d *dockerSnykProvider) scanContainer(token, target string, out, errOut io.Writer) error {
 	// check snyk token
-	containerID, removeContainer, err := d.newCommand([]string{token}, append(d.flags, image)...)
+	containerID, removeContainer, err := d.newCommand([]string{token}, append(d.flags, target)...)
 	if err != nil {
 		return err
 	}
 	//nolint: errcheck
 	defer removeContainer()
-	streamFunc, err := d.startContainer(containerID)
+	defer debug.Time("running provider container", "container", containerID)()
+	// the output is checked for a quota message, restore the streams for the next commands
+	quota := newQuotaDetector()
+	savedOut, savedErr := d.out, d.err
+	defer func() {
+		d.out, d.err = savedOut, savedErr
+	}()
+	d.out, d.err = quota.wrap(out), quota.wrap(errOut)
+	start := time.Now()
+	streamFunc, spool, err := d.startContainer(containerID)
 	if err != nil {
 		return err
 	}
 	defer streamFunc()
 
-	return d.checkContainerState(containerID)
+	err = d.checkContainerState(containerID)
+	args := append([]string{"docker", "run", image}, append(d.flags, target)...)
+	if containerErr, ok := err.(containerizedError); ok {
+		recordInvocation(args, start, int(containerErr.statusCode), nil, spool.Tail())
+		return quotaResult(int(containerErr.statusCode), quota)
+	}
+	recordInvocation(args, start, 0, err, spool.Tail())
+	if err != nil && d.context.Err() != nil {
+		return d.context.Err()
+	}
+	return err
 }
 
 func (d *dockerSnykProvider) Version() (string, error) {
@@ -249,9 +293,14 @@ func (d *dockerSnykProvider) Version() (string, error) {
 	defer removeContainer()
 	buff := bytes.NewBuffer(nil)
 	buffErr := bytes.NewBuffer(nil)
+	// the version is read from the container output, restore the streams for the next commands
+	out, errOut := d.out, d.err
+	defer func() {
+		d.out, d.err = out, errOut
+	}()
 	d.out = buff
 	d.err = buffErr
-	streamFunc, err := d.startContainer(containerID)
+	streamFunc, _, err := d.startContainer(containerID)
 	if err != nil {
 		return "", err
 	}
@@ -266,7 +315,7 @@ func (d *dockerSnykProvider) Version() (string, error) {
 
 func (d *dockerSnykProvider) newCommand(envVars []string, arg ...string) (string, removeContainerFunc, error) {
 	bindings := dockerBindings{
-		"/var/run/docker.sock:/var/run/docker.sock",
+		dockerSocketBinding(d.cli.DockerEndpoint().Host),
 	}
 	for index, argument := range arg {
 		if strings.HasPrefix(argument, "--file") {
@@ -279,14 +328,22 @@ func (d *dockerSnykProvider) newCommand(envVars []string, arg ...string) (string
 			bindings = append(bindings, fmt.Sprintf(`%s:/app/Dockerfile`, filePath))
 			arg[index] = "--file=/app/Dockerfile"
 		}
+		if binding, containerArg, ok := archiveBinding(argument); ok {
+			bindings = append(bindings, binding)
+			arg[index] = containerArg
+		}
 	}
 	defaultEnvs := []string{"NO_UPDATE_NOTIFIER=true", "SNYK_CFG_DISABLESUGGESTIONS=true",
 		"SNYK_INTEGRATION_NAME=DOCKER_DESKTOP"}
 	envVars = append(envVars, defaultEnvs...)
+	envVars = append(envVars, providerLocale...)
+	envVars, bindings = d.networkConfig(envVars, bindings)
 
 	args := strslice.StrSlice{"snyk"}
 	args = append(args, arg...)
 	config, hostConfig := containerConfigs(envVars, bindings, args)
+	debug.Log("creating provider container", "image", config.Image, "command", strings.Join(debug.Redact(args), " "),
+		"env", strings.Join(debug.Redact(envVars), " "), "binds", strings.Join(bindings, " "))
 
 	result, err := d.cli.Client().ContainerCreate(d.context, &config, &hostConfig, nil, "")
 	if err != nil {
@@ -296,6 +353,39 @@ func (d *dockerSnykProvider) newCommand(envVars []string, arg ...string) (string
 	return result.ID, removeContainer, nil
 }
 
+// dockerSocketBinding mounts the socket of the daemon the docker CLI talks to, the one of the current context or of
+// DOCKER_HOST, so the provider reaches rootless daemons too. The container runs next to the daemon, so remote daemons,
+// over SSH or TCP, are reached through their default socket.
+func dockerSocketBinding(host string) string {
+	const containerSocket = "/var/run/docker.sock"
+	if strings.HasPrefix(host, "unix://") {
+		return strings.TrimPrefix(host, "unix://") + ":" + containerSocket
+	}
+	return containerSocket + ":" + containerSocket
+}
+
+// archiveBinding mounts an image archive given to the provider as docker-archive:PATH or oci-archive:PATH
+func archiveBinding(argument string) (string, string, bool) {
+	for _, prefix := range []string{"docker-archive:", "oci-archive:"} {
+		if strings.HasPrefix(argument, prefix) {
+			hostPath := strings.TrimPrefix(argument, prefix)
+			containerPath := "/input/" + filepath.Base(hostPath)
+			return fmt.Sprintf("%s:%s:ro", hostPath, containerPath), prefix + containerPath, true
+		}
+	}
+	return "", "", false
+}
+
+// networkConfig forwards the proxy settings and the custom CA certificate to the container
+func (d *dockerSnykProvider) networkConfig(envVars dockerEnvs, bindings dockerBindings) (dockerEnvs, dockerBindings) {
+	envVars = append(envVars, proxy.Environment()...)
+	if d.caCert != "" {
+		envVars = append(envVars, "NODE_EXTRA_CA_CERTS="+containerCACertPath)
+		bindings = append(bindings, fmt.Sprintf("%s:%s:ro", d.caCert, containerCACertPath))
+	}
+	return envVars, bindings
+}
+
 func containerConfigs(envVars dockerEnvs, bindings dockerBindings, entrypoint strslice.StrSlice) (container.Config, container.HostConfig) {
 	config := container.Config{
 		Image:        image,
@@ -310,23 +400,3 @@ func containerConfigs(envVars dockerEnvs, bindings dockerBindings, entrypoint st
 	}
 	return config, hostConfig
 }
-
-func getSnykAuthenticationToken() (string, error) {
-	home, err := homedir.Dir()
-	if err != nil {
-		return "", err
-	}
-	snykConfFilePath := filepath.Join(home, ".config", "configstore", "snyk.json")
-	buff, err := ioutil.ReadFile(snykConfFilePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return "", nil
-		}
-		return "", err
-	}
-	var config snykConfig
-	if err := json.Unmarshal(buff, &config); err != nil {
-		return "", err
-	}
-	return config.API, nil
-}
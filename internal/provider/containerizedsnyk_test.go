@@ -0,0 +1,41 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDockerSocketBinding(t *testing.T) {
+	testCases := []struct {
+		host     string
+		expected string
+	}{
+		{host: "unix:///var/run/docker.sock", expected: "/var/run/docker.sock:/var/run/docker.sock"},
+		{host: "unix:///run/user/1000/docker.sock", expected: "/run/user/1000/docker.sock:/var/run/docker.sock"},
+		{host: "ssh://user@remote", expected: "/var/run/docker.sock:/var/run/docker.sock"},
+		{host: "tcp://192.168.1.10:2376", expected: "/var/run/docker.sock:/var/run/docker.sock"},
+		{host: "npipe:////./pipe/docker_engine", expected: "/var/run/docker.sock:/var/run/docker.sock"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.host, func(t *testing.T) {
+			assert.Equal(t, dockerSocketBinding(testCase.host), testCase.expected)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	cliConfig "github.com/docker/cli/cli/config"
+)
+
+var (
+	// SnykVersion is the version of the Snyk release downloaded by the plugin, set at build time
+	SnykVersion = "1.563.0"
+	// SnykChecksum is the SHA256 of the SnykVersion release artifact for the platform of the plugin, set at build time
+	SnykChecksum = ""
+	// snykReleaseURL is the URL of a Snyk release artifact, from its version and name
+	snykReleaseURL = "https://github.com/snyk/snyk/releases/download/v%s/%s"
+)
+
+// DownloadedBinaryPath returns the path where the plugin installs the Snyk binary it downloads
+func DownloadedBinaryPath() string {
+	name := "snyk"
+	if runtime.GOOS == "windows" {
+		name = "snyk.exe"
+	}
+	return filepath.Join(cliConfig.Dir(), "scan", "provider", name)
+}
+
+// IsBinaryAvailable returns true if the provider binary exists
+func IsBinaryAvailable(providerOpts Options) bool {
+	if providerOpts.path == "" {
+		return false
+	}
+	info, err := os.Stat(providerOpts.path)
+	return err == nil && !info.IsDir()
+}
+
+// ReleaseURL returns the URL of a Snyk release artifact
+func ReleaseURL(version, artifact string) string {
+	return fmt.Sprintf(snykReleaseURL, version, artifact)
+}
+
+// Download fetches the Snyk release artifact for the current platform, verifies it against the expected
+// SHA256 checksum and installs it at the given path. The checksum is never read from the release itself,
+// which would not detect a tampered release.
+func Download(ctx context.Context, client *http.Client, version, checksum, path string) error {
+	artifact, err := SnykArtifact(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+	if checksum == "" {
+		return fmt.Errorf("no checksum to verify Snyk %s (%s) against", version, artifact)
+	}
+	checksum = strings.ToLower(checksum)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// download next to the destination, so the binary is replaced atomically once verified
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".snyk-download")
+	if err != nil {
+		return err
+	}
+	//nolint: errcheck
+	defer os.Remove(tmp.Name())
+
+	body, err := get(ctx, client, ReleaseURL(version, artifact))
+	if err != nil {
+		tmp.Close() //nolint: errcheck
+		return err
+	}
+	//nolint: errcheck
+	defer body.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), body); err != nil {
+		tmp.Close() //nolint: errcheck
+		return fmt.Errorf("failed to download Snyk %s: %s", version, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != checksum {
+		return fmt.Errorf("invalid checksum for Snyk %s (%s): expected %s, got %s", version, artifact, checksum, actual)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func get(ctx context.Context, client *http.Client, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close() //nolint: errcheck
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
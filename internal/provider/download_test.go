@@ -0,0 +1,84 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestDownload(t *testing.T) {
+	artifact, err := SnykArtifact(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skip("no Snyk release for this platform")
+	}
+	content := "snyk binary"
+	sum := sha256.Sum256([]byte(content))
+	checksum := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.0.0/" + artifact:
+			fmt.Fprint(w, content)
+		case "/v2.0.0/" + artifact:
+			fmt.Fprint(w, "tampered binary")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer func(url string) { snykReleaseURL = url }(snykReleaseURL)
+	snykReleaseURL = server.URL + "/v%s/%s"
+
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	path := dir.Join("provider", "snyk")
+
+	assert.NilError(t, Download(context.Background(), server.Client(), "1.0.0", checksum, path))
+	buf, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), content)
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		assert.NilError(t, err)
+		assert.Equal(t, info.Mode().Perm(), os.FileMode(0755))
+	}
+
+	err = Download(context.Background(), server.Client(), "2.0.0", checksum, path)
+	assert.ErrorContains(t, err, "invalid checksum for Snyk 2.0.0")
+	// the installed binary is kept
+	buf, err = ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), content)
+
+	err = Download(context.Background(), server.Client(), "1.0.0", "", path)
+	assert.ErrorContains(t, err, "no checksum to verify Snyk 1.0.0")
+
+	err = Download(context.Background(), server.Client(), "3.0.0", checksum, path)
+	assert.ErrorContains(t, err, "404 Not Found")
+}
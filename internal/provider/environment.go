@@ -0,0 +1,75 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"runtime"
+	"strings"
+)
+
+// essentialVariables are passed to the providers even when they are not allowed, as they can't run without them, unless
+// they are denied
+var essentialVariables = []string{
+	"PATH", "HOME", "TMPDIR", "DOCKER_HOST", "DOCKER_CERT_PATH", "DOCKER_TLS_VERIFY", "DOCKER_CONFIG",
+	// Windows
+	"SYSTEMROOT", "USERPROFILE", "APPDATA", "LOCALAPPDATA", "TEMP", "TMP", "PATHEXT", "COMSPEC",
+}
+
+// EnvironmentFilter selects the environment variables of the plugin passed to the provider processes. A pattern is a
+// variable name, or a prefix followed by *, like SNYK_*.
+type EnvironmentFilter struct {
+	// Allow lists the variables passed to the providers with the essential ones, all the variables are passed when empty
+	Allow []string
+	// Deny lists the variables never passed to the providers, even when they are allowed or essential
+	Deny []string
+}
+
+// passes returns true if the variable of the name is passed to the providers
+func (f EnvironmentFilter) passes(name string) bool {
+	if matchesAny(f.Deny, name) {
+		return false
+	}
+	return len(f.Allow) == 0 || matchesAny(f.Allow, name) || matchesAny(essentialVariables, name)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchesVariable(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesVariable matches the name with the pattern, ignoring the case on Windows like its environment does
+func matchesVariable(pattern, name string) bool {
+	if runtime.GOOS == "windows" {
+		pattern, name = strings.ToUpper(pattern), strings.ToUpper(name)
+	}
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(name, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == name
+}
+
+// WithEnvironment selects the environment variables of the plugin passed to the provider processes
+func WithEnvironment(filter EnvironmentFilter) Ops {
+	return func(provider *Options) error {
+		provider.environment = filter
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestEnvironmentFilter(t *testing.T) {
+	t.Setenv("SNYK_TEST_TOKEN", "allowed")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("SNYK_TEST_DENIED", "denied")
+
+	env := providerEnvironment(EnvironmentFilter{
+		Allow: []string{"SNYK_*"},
+		Deny:  []string{"SNYK_TEST_DENIED", "PATH"},
+	})
+	assert.Assert(t, containsString(env, "SNYK_TEST_TOKEN=allowed"))
+	assert.Assert(t, !containsString(env, "AWS_SECRET_ACCESS_KEY=secret"))
+	assert.Assert(t, !containsString(env, "SNYK_TEST_DENIED=denied"))
+	assert.DeepEqual(t, env[len(env)-2:], providerLocale)
+}
+
+func TestEnvironmentFilterPasses(t *testing.T) {
+	testCases := []struct {
+		filter   EnvironmentFilter
+		name     string
+		expected bool
+	}{
+		{filter: EnvironmentFilter{}, name: "AWS_SECRET_ACCESS_KEY", expected: true},
+		{filter: EnvironmentFilter{Deny: []string{"AWS_*"}}, name: "AWS_SECRET_ACCESS_KEY", expected: false},
+		{filter: EnvironmentFilter{Allow: []string{"SNYK_TOKEN"}}, name: "SNYK_TOKEN", expected: true},
+		{filter: EnvironmentFilter{Allow: []string{"SNYK_TOKEN"}}, name: "SNYK_TOKEN_FILE", expected: false},
+		{filter: EnvironmentFilter{Allow: []string{"SNYK_TOKEN"}}, name: "HOME", expected: true},
+		{filter: EnvironmentFilter{Allow: []string{"SNYK_TOKEN"}, Deny: []string{"HOME"}}, name: "HOME", expected: false},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.filter.passes(testCase.name), testCase.expected, testCase.name)
+	}
+}
@@ -16,7 +16,69 @@
 
 package provider
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/docker/scan-cli-plugin/internal/hub"
+)
+
+var (
+	// ErrBinaryNotFound classifies the failures to find the binary of the scan provider
+	ErrBinaryNotFound = errors.New("scan provider binary not found")
+	// ErrNotAuthenticated classifies the failures to authenticate to Docker Hub or to the scan provider
+	ErrNotAuthenticated = errors.New("not authenticated")
+	// ErrRateLimited classifies the requests refused by Docker Hub because of its rate limit
+	ErrRateLimited = hub.ErrRateLimited
+	// ErrImagePullFailed classifies the failures to pull the image of the scan provider
+	ErrImagePullFailed = errors.New("failed to pull the scan provider image")
+	// ErrQuotaExceeded classifies the scans refused because the test limit of the account is reached
+	ErrQuotaExceeded = errors.New("quota exceeded")
+)
+
+// classifiedError keeps the message of an error while classifying it as one of the Err values, so that it can be
+// checked with errors.Is
+type classifiedError struct {
+	class   error
+	message string
+	cause   error
+}
+
+func (c *classifiedError) Error() string {
+	return c.message
+}
+
+func (c *classifiedError) Is(target error) bool {
+	return target == c.class
+}
+
+func (c *classifiedError) Unwrap() error {
+	return c.cause
+}
+
+// classify returns an error with the given message, classified as class and caused by cause if not nil
+func classify(class error, cause error, format string, args ...interface{}) error {
+	return &classifiedError{class: class, message: fmt.Sprintf(format, args...), cause: cause}
+}
+
+// Remediation returns what the user can do to solve a classified error, empty if the error is not classified
+func Remediation(err error) string {
+	switch {
+	case errors.Is(err, ErrBinaryNotFound):
+		return "Install the scan provider, or set the path of its binary in the path field of ~/.docker/scan/config.json. " +
+			"docker scan version --verbose lists where the binary is looked up."
+	case errors.Is(err, ErrNotAuthenticated):
+		return "Log in to Docker Hub with docker login, or authenticate to Snyk with docker scan --login."
+	case errors.Is(err, ErrRateLimited):
+		return "Wait before scanning again, the requests of the users logged in with docker login are less limited."
+	case errors.Is(err, ErrImagePullFailed):
+		return "Check the connection to Docker Hub, or install the Snyk binary and scan with --provider binary."
+	case errors.Is(err, ErrQuotaExceeded):
+		return "docker scan quota tells when the monthly scans are counted again, authenticate with a Snyk account " +
+			"using docker scan --login to get more scans."
+	}
+	return ""
+}
 
 type authenticationError struct {
 }
@@ -25,6 +87,10 @@ func (a authenticationError) Error() string {
 	return "authentication error"
 }
 
+func (a authenticationError) Is(target error) bool {
+	return target == ErrNotAuthenticated
+}
+
 // IsAuthenticationError check if the error type is an authentication error
 func IsAuthenticationError(err error) bool {
 	_, ok := err.(*authenticationError)
@@ -39,6 +105,10 @@ func (i invalidTokenError) Error() string {
 	return fmt.Sprintf("invalid authentication token %q", i.token)
 }
 
+func (i invalidTokenError) Is(target error) bool {
+	return target == ErrNotAuthenticated
+}
+
 // IsInvalidTokenError check if the error type is an invalid token error
 func IsInvalidTokenError(err error) bool {
 	_, ok := err.(*invalidTokenError)
@@ -46,8 +116,78 @@ func IsInvalidTokenError(err error) bool {
 }
 
 type containerizedError struct {
+	statusCode int64
 }
 
 func (c containerizedError) Error() string {
 	return ""
 }
+
+type vulnerabilitiesFoundError struct {
+}
+
+func (v vulnerabilitiesFoundError) Error() string {
+	return "vulnerabilities found"
+}
+
+// NewVulnerabilitiesFoundError returns the error reported when the scan succeeded but found vulnerabilities
+func NewVulnerabilitiesFoundError() error {
+	return &vulnerabilitiesFoundError{}
+}
+
+// IsVulnerabilitiesFoundError check if the scan succeeded but reported vulnerabilities
+func IsVulnerabilitiesFoundError(err error) bool {
+	_, ok := err.(*vulnerabilitiesFoundError)
+	return ok
+}
+
+type providerFailedError struct {
+	exitCode int
+}
+
+func (p providerFailedError) Error() string {
+	return fmt.Sprintf("scan provider failed with exit code %d", p.exitCode)
+}
+
+// NewProviderFailedError returns the error reported when the scan provider failed with the exit code, after reporting
+// the failure itself
+func NewProviderFailedError(exitCode int) error {
+	return &providerFailedError{exitCode: exitCode}
+}
+
+// IsProviderFailedError check if the scan provider failed, in which case it has already reported the failure
+func IsProviderFailedError(err error) bool {
+	_, ok := err.(*providerFailedError)
+	return ok
+}
+
+type quotaExceededError struct {
+	message string
+}
+
+func (q quotaExceededError) Error() string {
+	return fmt.Sprintf("the quota of the scan provider is exceeded: %s", q.message)
+}
+
+func (q quotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// IsQuotaExceededError check if the scan provider failed because the test limit of the account is reached
+func IsQuotaExceededError(err error) bool {
+	_, ok := err.(*quotaExceededError)
+	return ok
+}
+
+// scanResult maps the exit code of the scan provider to the plugin errors,
+// the provider exits with 1 when vulnerabilities are found and any other non zero code on failure
+func scanResult(exitCode int) error {
+	switch exitCode {
+	case 0:
+		return nil
+	case 1:
+		return &vulnerabilitiesFoundError{}
+	default:
+		return &providerFailedError{exitCode: exitCode}
+	}
+}
@@ -18,8 +18,12 @@ package provider
 
 import (
 	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
 	"testing"
 
+	"github.com/docker/scan-cli-plugin/internal/hub"
 	"gotest.tools/v3/assert"
 )
 
@@ -32,3 +36,29 @@ func TestIsInvalidTokenError(t *testing.T) {
 	assert.Assert(t, IsInvalidTokenError(&invalidTokenError{}))
 	assert.Assert(t, !IsInvalidTokenError(errors.New("")))
 }
+
+func TestScanResult(t *testing.T) {
+	assert.NilError(t, scanResult(0))
+	assert.Assert(t, IsVulnerabilitiesFoundError(scanResult(1)))
+	assert.Assert(t, !IsProviderFailedError(scanResult(1)))
+	assert.Assert(t, IsProviderFailedError(scanResult(2)))
+	assert.Assert(t, IsProviderFailedError(scanResult(-1)))
+	assert.Assert(t, !IsVulnerabilitiesFoundError(scanResult(2)))
+}
+
+func TestClassifiedErrors(t *testing.T) {
+	err := checkCommandErr(&exec.Error{Name: "snyk", Err: exec.ErrNotFound})
+	assert.Error(t, err, "could not find Snyk binary")
+	assert.Assert(t, errors.Is(err, ErrBinaryNotFound))
+	assert.Assert(t, errors.Is(err, exec.ErrNotFound))
+	assert.Assert(t, !errors.Is(err, ErrNotAuthenticated))
+
+	assert.Assert(t, errors.Is(&invalidTokenError{}, ErrNotAuthenticated))
+	assert.Assert(t, errors.Is(&authenticationError{}, ErrNotAuthenticated))
+
+	err = fmt.Errorf("failed to get DockerScanID: %w", fmt.Errorf("%w, retry after 1m0s", hub.ErrRateLimited))
+	assert.Assert(t, errors.Is(err, ErrRateLimited))
+	assert.Assert(t, strings.HasPrefix(Remediation(err), "Wait before scanning again"))
+
+	assert.Equal(t, Remediation(errors.New("unknown")), "")
+}
@@ -0,0 +1,306 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/debug"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// grypePackageManagers maps the Grype artifact types to the package managers reported by Snyk
+var grypePackageManagers = map[string]string{
+	"apk":          "apk",
+	"deb":          "deb",
+	"rpm":          "rpm",
+	"npm":          "npm",
+	"python":       "pip",
+	"java-archive": "maven",
+	"go-module":    "gomodules",
+	"gem":          "rubygems",
+	"dotnet":       "nuget",
+	"rust-crate":   "cargo",
+	"php-composer": "composer",
+}
+
+type grypeProvider struct {
+	Options
+}
+
+// NewGrypeProvider returns an Anchore Grype implementation of scan provider, wrapping the grype binary found in
+// the PATH: the Grype library isn't linked, its dependencies require a more recent Go toolchain and the binary manages
+// its own vulnerability database. Grype reports all the vulnerabilities as JSON, they are filtered according to the
+// scan flags and printed like the Snyk output.
+func NewGrypeProvider(defaultProvider Options) (Provider, error) {
+	path, err := exec.LookPath("grype")
+	if err != nil {
+		return nil, classify(ErrBinaryNotFound, nil, "could not find Grype binary, please install Grype: https://github.com/anchore/grype")
+	}
+	defaultProvider.path = path
+	return &grypeProvider{Options: defaultProvider}, nil
+}
+
+// Authenticate does nothing, Grype doesn't require any account
+func (g *grypeProvider) Authenticate(token string) error {
+	return nil
+}
+
+func (g *grypeProvider) Scan(image string) error {
+	return g.scan(image)
+}
+
+// ScanSBOM matches the packages of a CycloneDX or SPDX SBOM against the vulnerabilities
+func (g *grypeProvider) ScanSBOM(path string) error {
+	return g.scan("sbom:" + path)
+}
+
+// scan runs Grype on the target, an image or an SBOM
+func (g *grypeProvider) scan(target string) error {
+	filter := grypeFilterFromFlags(g.flags)
+	out := bytes.NewBuffer(nil)
+	args := []string{target, "--output", "json", "--quiet"}
+	if filter.platform != "" {
+		args = append(args, "--platform", filter.platform)
+	}
+	cmd := g.newCommand(args...)
+	cmd.Stdout = out
+	cmd.Stderr = g.err
+	done := logCommand(cmd.Args)
+	err := runCommand(g.context, cmd)
+	done()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return &providerFailedError{exitCode: exitErr.ExitCode()}
+	}
+	if err != nil {
+		if _, ok := err.(*exec.Error); ok {
+			return classify(ErrBinaryNotFound, err, "could not find Grype binary")
+		}
+		return err
+	}
+	converted, found, err := convertGrypeReport(out.Bytes(), filter)
+	if err != nil {
+		return err
+	}
+	if filter.jsonOutput {
+		_, err = g.out.Write(converted)
+	} else {
+		err = writeConvertedText(g.out, converted)
+	}
+	if err != nil {
+		return err
+	}
+	if found {
+		return scanResult(1)
+	}
+	return nil
+}
+
+func (g *grypeProvider) Version() (string, error) {
+	cmd := g.newCommand("version")
+	buff := bytes.NewBuffer(nil)
+	cmd.Stdout = buff
+	defer logCommand(cmd.Args)()
+	if err := runCommand(g.context, cmd); err != nil {
+		return "", fmt.Errorf("failed to get grype version: %s", err)
+	}
+	for _, line := range strings.Split(buff.String(), "\n") {
+		if strings.HasPrefix(line, "Version:") {
+			return fmt.Sprintf("Grype (%s)", strings.TrimSpace(strings.TrimPrefix(line, "Version:"))), nil
+		}
+	}
+	return fmt.Sprintf("Grype (%s)", strings.TrimSpace(buff.String())), nil
+}
+
+func (g *grypeProvider) newCommand(arg ...string) *exec.Cmd {
+	cmd := exec.Command(g.path, arg...)
+	cmd.Env = providerEnvironment(g.environment, "GRYPE_CHECK_FOR_APP_UPDATE=false")
+	if g.caCert != "" {
+		cmd.Env = append(cmd.Env, "SSL_CERT_FILE="+g.caCert)
+	}
+	return cmd
+}
+
+// grypeFilter holds the scan flags applied to the Grype results
+type grypeFilter struct {
+	jsonOutput bool
+	severity   string
+	osOnly     bool
+	platform   string
+}
+
+func grypeFilterFromFlags(flags []string) grypeFilter {
+	var filter grypeFilter
+	for _, flag := range flags {
+		name, value := flag, ""
+		if parts := strings.SplitN(flag, "=", 2); len(parts) == 2 {
+			name, value = parts[0], parts[1]
+		}
+		switch name {
+		case "container", "test", "--app-vulns":
+		case "--json":
+			filter.jsonOutput = true
+		case "--severity-threshold":
+			filter.severity = value
+		case "--exclude-app-vulns":
+			filter.osOnly = true
+		case "--platform":
+			filter.platform = value
+		default:
+			debug.Log("ignoring flag without Grype equivalent", "flag", name)
+		}
+	}
+	return filter
+}
+
+type grypeReport struct {
+	Matches []grypeMatch `json:"matches"`
+	Source  struct {
+		Target json.RawMessage `json:"target"`
+	} `json:"source"`
+}
+
+type grypeMatch struct {
+	Vulnerability struct {
+		ID          string `json:"id"`
+		Severity    string `json:"severity"`
+		Description string `json:"description"`
+		Fix         struct {
+			Versions []string `json:"versions"`
+		} `json:"fix"`
+	} `json:"vulnerability"`
+	RelatedVulnerabilities []struct {
+		ID string `json:"id"`
+	} `json:"relatedVulnerabilities"`
+	Artifact struct {
+		Name      string `json:"name"`
+		Version   string `json:"version"`
+		Type      string `json:"type"`
+		Locations []struct {
+			Path    string `json:"path"`
+			LayerID string `json:"layerID"`
+		} `json:"locations"`
+	} `json:"artifact"`
+}
+
+// target returns the name of the scanned image
+func (r grypeReport) target() string {
+	var target struct {
+		UserInput string `json:"userInput"`
+	}
+	if err := json.Unmarshal(r.Source.Target, &target); err == nil && target.UserInput != "" {
+		return target.UserInput
+	}
+	var name string
+	_ = json.Unmarshal(r.Source.Target, &name)
+	return name
+}
+
+// convertGrypeReport converts the Grype JSON report to the Snyk JSON output, a result per package manager,
+// and returns whether vulnerabilities were found
+func convertGrypeReport(document []byte, filter grypeFilter) ([]byte, bool, error) {
+	var grype grypeReport
+	if err := json.Unmarshal(document, &grype); err != nil {
+		return nil, false, fmt.Errorf("invalid grype output: %s", err)
+	}
+	target := grype.target()
+	byManager := map[string]*snykResult{}
+	var managers []string
+	for _, match := range grype.Matches {
+		vuln := convertGrypeMatch(target, match)
+		if report.SeverityLevel(vuln.Severity) < 0 {
+			// negligible and unknown severities rank below low, they aren't reported
+			debug.Log("ignoring Grype match below low severity", "id", vuln.ID, "severity", vuln.Severity)
+			continue
+		}
+		if !filter.matches(vuln) {
+			continue
+		}
+		result, ok := byManager[vuln.PackageManager]
+		if !ok {
+			result = &snykResult{Path: target, PackageManager: vuln.PackageManager, Vulnerabilities: []snykVulnerability{}}
+			byManager[vuln.PackageManager] = result
+			managers = append(managers, vuln.PackageManager)
+		}
+		result.Vulnerabilities = append(result.Vulnerabilities, vuln)
+	}
+	sort.Strings(managers)
+	results := []snykResult{}
+	for _, manager := range managers {
+		results = append(results, *byManager[manager])
+	}
+	if len(results) == 0 {
+		results = append(results, snykResult{OK: true, Path: target, Vulnerabilities: []snykVulnerability{}})
+	}
+	out, err := marshalSnykResults(results)
+	return out, len(managers) > 0, err
+}
+
+func convertGrypeMatch(target string, match grypeMatch) snykVulnerability {
+	id, name, version := match.Vulnerability.ID, match.Artifact.Name, match.Artifact.Version
+	vuln := snykVulnerability{
+		ID:             id,
+		Title:          match.Vulnerability.Description,
+		Severity:       strings.ToLower(match.Vulnerability.Severity),
+		PackageName:    name,
+		Version:        version,
+		From:           []string{target, name + "@" + version},
+		FixedIn:        match.Vulnerability.Fix.Versions,
+		PackageManager: grypePackageManagers[match.Artifact.Type],
+		Identifiers:    map[string][]string{},
+	}
+	if vuln.PackageManager == "" {
+		vuln.PackageManager = match.Artifact.Type
+	}
+	if vuln.Title == "" {
+		vuln.Title = id
+	}
+	if strings.HasPrefix(id, "CVE-") {
+		vuln.Identifiers["CVE"] = []string{id}
+	}
+	for _, related := range match.RelatedVulnerabilities {
+		if strings.HasPrefix(related.ID, "CVE-") && !containsString(vuln.Identifiers["CVE"], related.ID) {
+			vuln.Identifiers["CVE"] = append(vuln.Identifiers["CVE"], related.ID)
+		}
+	}
+	if len(match.Artifact.Locations) > 0 {
+		vuln.Path = match.Artifact.Locations[0].Path
+		vuln.Layer = match.Artifact.Locations[0].LayerID
+	}
+	return vuln
+}
+
+func (f grypeFilter) matches(vuln snykVulnerability) bool {
+	if f.severity != "" && report.SeverityLevel(vuln.Severity) < report.SeverityLevel(f.severity) {
+		return false
+	}
+	return !f.osOnly || report.Vulnerability{PackageManager: vuln.PackageManager}.Scope() == report.ScopeOS
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
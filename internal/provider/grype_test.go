@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+)
+
+var (
+	_ Provider = &grypeProvider{}
+)
+
+func TestConvertGrypeReport(t *testing.T) {
+	document, err := ioutil.ReadFile("testdata/grype/report.json")
+	assert.NilError(t, err)
+	converted, found, err := convertGrypeReport(document, grypeFilter{})
+	assert.NilError(t, err)
+	assert.Assert(t, found)
+
+	// the converted output is read like the Snyk output
+	scanReport, err := report.Parse(converted)
+	assert.NilError(t, err)
+	assert.Equal(t, scanReport.Path, "myapp:latest")
+	// the negligible CVE-2019-14855 isn't reported
+	assert.Equal(t, len(scanReport.Vulnerabilities), 2)
+	assert.DeepEqual(t, scanReport.Vulnerabilities[0], report.Vulnerability{
+		ID:             "CVE-2021-3711",
+		Title:          "In order to decrypt SM2 encrypted data an application is expected to call the API function EVP_PKEY_decrypt().",
+		Severity:       "critical",
+		PackageName:    "libssl1.1",
+		Version:        "1.1.1k-r0",
+		From:           []string{"myapp:latest", "libssl1.1@1.1.1k-r0"},
+		FixedIn:        []string{"1.1.1l-r0"},
+		PackageManager: "apk",
+		Path:           "/lib/apk/db/installed",
+		Layer:          "sha256:50644c29ef5a27c9a40c393a73ece2479de78325cae7d762ef3cdc19bf42dd0a",
+		Identifiers:    map[string][]string{"CVE": {"CVE-2021-3711"}},
+	})
+	assert.DeepEqual(t, scanReport.Vulnerabilities[1].CVEs(), []string{"CVE-2021-23337"})
+}
+
+func TestConvertGrypeReportFilters(t *testing.T) {
+	document, err := ioutil.ReadFile("testdata/grype/report.json")
+	assert.NilError(t, err)
+
+	converted, found, err := convertGrypeReport(document, grypeFilterFromFlags([]string{"container", "test", "--severity-threshold=high"}))
+	assert.NilError(t, err)
+	assert.Assert(t, found)
+	scanReport, err := report.Parse(converted)
+	assert.NilError(t, err)
+	assert.Equal(t, len(scanReport.Vulnerabilities), 2)
+
+	converted, found, err = convertGrypeReport(document, grypeFilterFromFlags([]string{"--exclude-app-vulns", "--severity-threshold=medium"}))
+	assert.NilError(t, err)
+	assert.Assert(t, found)
+	scanReport, err = report.Parse(converted)
+	assert.NilError(t, err)
+	assert.Equal(t, len(scanReport.Vulnerabilities), 1)
+	assert.Equal(t, scanReport.Vulnerabilities[0].ID, "CVE-2021-3711")
+
+	_, found, err = convertGrypeReport([]byte(`{"matches":[],"source":{"target":"alpine"}}`), grypeFilter{})
+	assert.NilError(t, err)
+	assert.Assert(t, !found)
+}
+
+func TestWriteConvertedText(t *testing.T) {
+	document, err := ioutil.ReadFile("testdata/grype/report.json")
+	assert.NilError(t, err)
+	converted, _, err := convertGrypeReport(document, grypeFilter{})
+	assert.NilError(t, err)
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, writeConvertedText(out, converted))
+	assert.Assert(t, strings.Contains(out.String(), "✗ Critical severity vulnerability found in libssl1.1"))
+	assert.Assert(t, strings.Contains(out.String(), "Introduced through: lodash@4.17.15"))
+	assert.Assert(t, strings.Contains(out.String(), "found 2 issues"))
+}
@@ -0,0 +1,128 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/internal/debug"
+	"github.com/docker/scan-cli-plugin/internal/hub"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+type hubProvider struct {
+	Options
+	client hub.Client
+}
+
+// NewHubProvider returns a provider getting the results of the server side scans of Docker Hub,
+// for the images already pushed to Hub. No scanner runs locally.
+func NewHubProvider(defaultProvider Options) (Provider, error) {
+	return &hubProvider{
+		Options: defaultProvider,
+		client: hub.Client{
+			Domain:     hub.GetInstance().APIHubBaseURL,
+			HTTPClient: defaultProvider.httpClient,
+		},
+	}, nil
+}
+
+// Authenticate does nothing, the Docker Hub credentials of docker login are used
+func (h *hubProvider) Authenticate(token string) error {
+	return fmt.Errorf("the hub provider uses your Docker Hub credentials, please login using the docker login command")
+}
+
+func (h *hubProvider) Scan(image string) error {
+	repository, tag, err := hubRepository(image)
+	if err != nil {
+		return err
+	}
+	if h.auth.Username == "" {
+		return fmt.Errorf("you need to be logged in to Docker Hub to get the results of its scans, please login using the docker login command")
+	}
+	if ignored := hubIgnoredFlags(h.flags); len(ignored) > 0 {
+		fmt.Fprintf(h.err, "Warning: the Docker Hub scan results don't take %s into account\n", strings.Join(ignored, ", "))
+	}
+	hubToken, err := h.client.Login(h.auth)
+	if err != nil {
+		return fmt.Errorf("failed to login to Docker Hub: %s", err)
+	}
+	defer debug.Time("getting Hub scan results", "repository", repository, "tag", tag)()
+	results, err := h.client.GetVulnerabilities(hubToken, repository, tag)
+	if err != nil {
+		return err
+	}
+	scanReport, err := report.Parse(results)
+	if err != nil {
+		return err
+	}
+	if jsonOutput(h.flags) {
+		_, err = h.out.Write(results)
+	} else {
+		err = writeConvertedText(h.out, results)
+	}
+	if err != nil {
+		return err
+	}
+	if len(scanReport.Vulnerabilities) > 0 {
+		return scanResult(1)
+	}
+	return nil
+}
+
+func (h *hubProvider) Version() (string, error) {
+	return fmt.Sprintf("Docker Hub (%s)", h.client.Domain), nil
+}
+
+// hubRepository returns the Hub repository and the tag of an image reference, which must be a Hub image
+func hubRepository(image string) (string, string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", "", err
+	}
+	if reference.Domain(named) != "docker.io" {
+		return "", "", fmt.Errorf("the hub provider only scans the images pushed to Docker Hub, not %s", image)
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return "", "", fmt.Errorf("the hub provider requires a tag, not a digest: %s", image)
+	}
+	named = reference.TagNameOnly(named)
+	return reference.Path(named), named.(reference.Tagged).Tag(), nil
+}
+
+// jsonOutput returns true if the Snyk flags request the JSON output
+func jsonOutput(flags []string) bool {
+	for _, flag := range flags {
+		if flag == "--json" {
+			return true
+		}
+	}
+	return false
+}
+
+// hubIgnoredFlags lists the flags of the local scans which don't apply to the server side scans
+func hubIgnoredFlags(flags []string) []string {
+	var ignored []string
+	for _, flag := range flags {
+		if flag != "container" && flag != "test" && flag != "--json" {
+			ignored = append(ignored, strings.SplitN(flag, "=", 2)[0])
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,75 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/scan-cli-plugin/internal/hub"
+	"gotest.tools/v3/assert"
+)
+
+var (
+	_ Provider = &hubProvider{}
+)
+
+func TestHubRepository(t *testing.T) {
+	repository, tag, err := hubRepository("myorg/app:1.0")
+	assert.NilError(t, err)
+	assert.Equal(t, repository, "myorg/app")
+	assert.Equal(t, tag, "1.0")
+
+	repository, tag, err = hubRepository("alpine")
+	assert.NilError(t, err)
+	assert.Equal(t, repository, "library/alpine")
+	assert.Equal(t, tag, "latest")
+
+	_, _, err = hubRepository("ghcr.io/myorg/app:1.0")
+	assert.ErrorContains(t, err, "only scans the images pushed to Docker Hub")
+}
+
+func TestHubProviderScan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case hub.LoginURL:
+			w.Write([]byte(`{"token":"hub-token"}`)) //nolint:errcheck
+		case "/api/scan/v1/repositories/myorg/app/tags/1.0/vulnerabilities":
+			assert.Equal(t, r.Header.Get("Authorization"), "Bearer hub-token")
+			w.Write([]byte(`{"ok":false,"path":"myorg/app:1.0","packageManager":"apk","vulnerabilities":[` + //nolint:errcheck
+				`{"id":"SNYK-ALPINE312-OPENSSL-1569447","title":"Buffer Overflow","severity":"critical","packageName":"openssl","version":"1.1.1k-r0"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	out, errOut := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	opts, err := NewProvider(WithStreams(out, errOut), WithJSON(), WithSeverity("high"))
+	assert.NilError(t, err)
+	opts.auth = types.AuthConfig{Username: "user", Password: "password"}
+	provider := &hubProvider{Options: opts, client: hub.Client{Domain: server.URL}}
+
+	err = provider.Scan("myorg/app:1.0")
+	assert.Assert(t, IsVulnerabilitiesFoundError(err))
+	assert.Assert(t, strings.Contains(out.String(), "SNYK-ALPINE312-OPENSSL-1569447"))
+	assert.Equal(t, errOut.String(), "Warning: the Docker Hub scan results don't take --severity-threshold into account\n")
+}
@@ -0,0 +1,186 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Snyk ignore reason types
+const (
+	// IgnoreNotVulnerable ignores an issue which doesn't affect the project
+	IgnoreNotVulnerable = "not-vulnerable"
+	// IgnoreWontFix ignores an issue accepted as a risk
+	IgnoreWontFix = "wont-fix"
+)
+
+// Ignore is a finding ignored locally, to be ignored in the Snyk projects of the image too
+type Ignore struct {
+	ID         string `json:"id"`
+	Reason     string `json:"reason"`
+	ReasonType string `json:"reasonType"`
+}
+
+// IgnoreSync is the result of the synchronization of the ignores with the Snyk projects of an image
+type IgnoreSync struct {
+	Org      string   `json:"org"`
+	Projects []string `json:"projects"`
+	Ignored  []string `json:"ignored"`
+}
+
+// SyncIgnores ignores the findings in the policy of the Snyk projects monitoring the image, in the organization of the
+// scans, so that the Snyk web dashboards are consistent with the local results. It requires a Snyk token.
+func SyncIgnores(opts Options, image string, ignores []Ignore) (IgnoreSync, error) {
+	token, err := apiToken(opts)
+	if err != nil {
+		return IgnoreSync{}, err
+	}
+	orgID, err := snykOrgID(opts, token)
+	if err != nil {
+		return IgnoreSync{}, err
+	}
+	sync := IgnoreSync{Org: orgID}
+	projects, err := imageProjects(opts, token, orgID, image)
+	if err != nil {
+		return sync, err
+	}
+	if len(projects) == 0 {
+		return sync, fmt.Errorf("no Snyk project of the organization monitors %s", image)
+	}
+	for _, project := range projects {
+		sync.Projects = append(sync.Projects, project.Name)
+		for _, ignore := range ignores {
+			if err := ignoreIssue(opts, token, orgID, project.ID, ignore); err != nil {
+				return sync, fmt.Errorf("cannot ignore %s in the Snyk project %s: %s", ignore.ID, project.Name, err)
+			}
+		}
+	}
+	for _, ignore := range ignores {
+		sync.Ignored = append(sync.Ignored, ignore.ID)
+	}
+	return sync, nil
+}
+
+// apiToken returns the Snyk token of the scans, the DockerScanID can't call the Snyk API
+func apiToken(opts Options) (string, error) {
+	if token := os.Getenv("SNYK_TOKEN"); token != "" {
+		return token, nil
+	}
+	token, err := opts.tokenStore.Get()
+	if err != nil || token == "" {
+		return "", classify(ErrNotAuthenticated, err, "a Snyk token is required, authenticate with docker scan --login")
+	}
+	return token, nil
+}
+
+// snykOrgID returns the ID of the organization of the scans, or of the only organization of the account
+func snykOrgID(opts Options, token string) (string, error) {
+	org := SelectedOrg(opts)
+	var user snykUser
+	if err := retry(opts, func() error {
+		return getSnykUser(opts, token, &user)
+	}); err != nil {
+		return "", err
+	}
+	if org == "" && len(user.Orgs) == 1 {
+		return user.Orgs[0].ID, nil
+	}
+	if org == "" {
+		return "", fmt.Errorf("the Snyk account %s is a member of several organizations, select one with --org or SNYK_CFG_ORG", user.Username)
+	}
+	for _, candidate := range user.Orgs {
+		if candidate.Matches(org) {
+			return candidate.ID, nil
+		}
+	}
+	return "", classify(ErrNotAuthenticated, nil, "the Snyk account %s is not a member of the organization %s", user.Username, org)
+}
+
+type snykProject struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// imageProjects returns the projects of the image, one per target of the image like its OS packages or an application
+// manifest, named after the image
+func imageProjects(opts Options, token, orgID, image string) ([]snykProject, error) {
+	var response struct {
+		Projects []snykProject `json:"projects"`
+	}
+	filter := map[string]interface{}{"filters": map[string]string{"name": image}}
+	if err := snykAPI(opts, token, http.MethodPost, "/v1/org/"+url.PathEscape(orgID)+"/projects", filter, &response); err != nil {
+		return nil, err
+	}
+	var projects []snykProject
+	for _, project := range response.Projects {
+		if project.Name == image || strings.HasPrefix(project.Name, image+":") {
+			projects = append(projects, project)
+		}
+	}
+	return projects, nil
+}
+
+func ignoreIssue(opts Options, token, orgID, projectID string, ignore Ignore) error {
+	path := fmt.Sprintf("/v1/org/%s/project/%s/ignore/%s", url.PathEscape(orgID), url.PathEscape(projectID), url.PathEscape(ignore.ID))
+	return snykAPI(opts, token, http.MethodPost, path, map[string]interface{}{
+		"ignorePath":         "*",
+		"reason":             ignore.Reason,
+		"reasonType":         ignore.ReasonType,
+		"disregardIfFixable": false,
+	}, nil)
+}
+
+// snykAPI sends the request to the Snyk API, and decodes the response in result unless it is nil
+func snykAPI(opts Options, token, method, path string, body interface{}, result interface{}) error {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return retry(opts, func() error {
+		req, err := http.NewRequestWithContext(opts.context, method, strings.TrimSuffix(SnykAPI(), "/")+path, bytes.NewReader(buf))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "token "+token)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := opts.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close() //nolint:errcheck
+		content, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			return classify(ErrNotAuthenticated, nil, "the Snyk token was refused: %s", resp.Status)
+		case resp.StatusCode < 200 || resp.StatusCode >= 300:
+			return fmt.Errorf("Snyk API returned %s: %s", resp.Status, bytes.TrimSpace(content))
+		case result == nil:
+			return nil
+		}
+		return json.Unmarshal(content, result)
+	})
+}
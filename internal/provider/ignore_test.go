@@ -0,0 +1,88 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/env"
+)
+
+func TestSyncIgnores(t *testing.T) {
+	var ignored []string
+	var reasons []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token "+snykToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/user/me":
+			fmt.Fprint(w, `{"username": "jane", "orgs": [{"id": "1234", "name": "Acme Prod", "slug": "acme-prod"}, {"id": "5678", "slug": "acme-staging"}]}`)
+		case "/api/v1/org/1234/projects":
+			var filter map[string]map[string]string
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&filter))
+			if filter["filters"]["name"] != "myapp:1.0" {
+				fmt.Fprint(w, `{"projects": []}`)
+				return
+			}
+			fmt.Fprint(w, `{"projects": [
+  {"id": "p1", "name": "myapp:1.0"},
+  {"id": "p2", "name": "myapp:1.0:/app/package.json"},
+  {"id": "p3", "name": "myapp:1.0-debug"}
+]}`)
+		case "/api/v1/org/1234/project/p1/ignore/SNYK-DEBIAN10-CURL-1", "/api/v1/org/1234/project/p2/ignore/SNYK-DEBIAN10-CURL-1":
+			var reason map[string]interface{}
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&reason))
+			ignored = append(ignored, r.URL.Path)
+			reasons = append(reasons, reason)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer env.Patch(t, "SNYK_API", server.URL+"/api/")()
+	defer env.Patch(t, "SNYK_TOKEN", "")()
+	defer env.Patch(t, "SNYK_CFG_ORG", "")()
+
+	opts, err := NewProvider(WithTokenStore(&memoryTokenStore{token: snykToken}), WithOrg("acme-prod"))
+	assert.NilError(t, err)
+	sync, err := SyncIgnores(opts, "myapp:1.0", []Ignore{{ID: "SNYK-DEBIAN10-CURL-1", Reason: "not exploitable", ReasonType: IgnoreNotVulnerable}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sync, IgnoreSync{
+		Org:      "1234",
+		Projects: []string{"myapp:1.0", "myapp:1.0:/app/package.json"},
+		Ignored:  []string{"SNYK-DEBIAN10-CURL-1"},
+	})
+	assert.Equal(t, len(ignored), 2)
+	assert.DeepEqual(t, reasons[0], map[string]interface{}{
+		"ignorePath": "*", "reason": "not exploitable", "reasonType": "not-vulnerable", "disregardIfFixable": false,
+	})
+
+	_, err = SyncIgnores(opts, "other:1.0", []Ignore{{ID: "SNYK-DEBIAN10-CURL-1"}})
+	assert.Error(t, err, "no Snyk project of the organization monitors other:1.0")
+
+	opts, err = NewProvider(WithTokenStore(&memoryTokenStore{token: snykToken}))
+	assert.NilError(t, err)
+	_, err = SyncIgnores(opts, "myapp:1.0", nil)
+	assert.Error(t, err, "the Snyk account jane is a member of several organizations, select one with --org or SNYK_CFG_ORG")
+}
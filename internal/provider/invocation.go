@@ -0,0 +1,50 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"time"
+
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/debug"
+	"github.com/docker/scan-cli-plugin/internal/invocation"
+)
+
+// invocationPath is the log where the runs of the provider are recorded, for the support bundles
+var invocationPath = invocation.DefaultPath
+
+// recordInvocation adds a run of the provider to the invocation log, with its secrets redacted. The error is the
+// failure to run the provider, when it couldn't exit with an exit code.
+func recordInvocation(args []string, start time.Time, exitCode int, err error, stderr string) {
+	if config.ReadOnly() {
+		return
+	}
+	record := invocation.Invocation{
+		Time:     start.UTC(),
+		Command:  debug.Redact(args),
+		Duration: time.Since(start).Round(time.Millisecond).String(),
+		ExitCode: exitCode,
+		Stderr:   stderr,
+	}
+	if err != nil {
+		record.ExitCode = -1
+		record.Error = err.Error()
+	}
+	if err := invocation.Append(invocationPath(), record); err != nil {
+		debug.Log("failed to record the provider invocation", "error", err)
+	}
+}
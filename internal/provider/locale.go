@@ -0,0 +1,59 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// providerLocale runs the providers in the POSIX locale, so they print their messages in English and format the numbers
+// and dates the same way whatever the locale of the user, the plugin matching their messages
+var providerLocale = []string{"LC_ALL=C", "LANG=C"}
+
+// terminalSequence matches the color and cursor sequences of the provider messages written to a terminal
+var terminalSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// providerEnvironment returns the environment of the provider processes: the variables of the plugin passing the filter,
+// without its locale settings, in the POSIX locale and with the given variables
+func providerEnvironment(filter EnvironmentFilter, variables ...string) []string {
+	var env []string
+	for _, variable := range os.Environ() {
+		if !isLocaleVariable(variable) && filter.passes(strings.SplitN(variable, "=", 2)[0]) {
+			env = append(env, variable)
+		}
+	}
+	env = append(env, providerLocale...)
+	return append(env, variables...)
+}
+
+func isLocaleVariable(variable string) bool {
+	name := strings.SplitN(variable, "=", 2)[0]
+	return name == "LANG" || name == "LANGUAGE" || strings.HasPrefix(name, "LC_")
+}
+
+// normalizeMessage removes the terminal decorations from a line of the provider output, the color sequences and the
+// text a spinner overwrote before the last carriage return, so the messages are matched as printed
+func normalizeMessage(line []byte) []byte {
+	line = terminalSequence.ReplaceAll(bytes.TrimRight(line, "\r"), nil)
+	if index := bytes.LastIndexByte(line, '\r'); index >= 0 {
+		line = line[index+1:]
+	}
+	return line
+}
@@ -0,0 +1,58 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestProviderEnvironment(t *testing.T) {
+	t.Setenv("LANG", "fr_FR.UTF-8")
+	t.Setenv("LC_MESSAGES", "de_DE.UTF-8")
+	t.Setenv("LANGUAGE", "fr:en")
+	t.Setenv("SNYK_TEST_VARIABLE", "kept")
+
+	env := providerEnvironment(EnvironmentFilter{}, "NO_UPDATE_NOTIFIER=true")
+	assert.Assert(t, containsString(env, "SNYK_TEST_VARIABLE=kept"))
+	assert.Assert(t, !containsString(env, "LANG=fr_FR.UTF-8"))
+	assert.Assert(t, !containsString(env, "LC_MESSAGES=de_DE.UTF-8"))
+	assert.Assert(t, !containsString(env, "LANGUAGE=fr:en"))
+	assert.DeepEqual(t, env[len(env)-3:], []string{"LC_ALL=C", "LANG=C", "NO_UPDATE_NOTIFIER=true"})
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	testCases := []struct {
+		line     string
+		expected string
+	}{
+		{line: "Error: Test limit reached", expected: "Error: Test limit reached"},
+		{line: "\x1b[31m\x1b[1mTest limit reached\x1b[22m\x1b[39m\r", expected: "Test limit reached"},
+		{line: "⠋ Testing myapp...\r\x1b[2K⠙ Testing myapp...\r\x1b[2KMonthly limit reached", expected: "Monthly limit reached"},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, string(normalizeMessage([]byte(testCase.line))), testCase.expected)
+	}
+}
+
+func TestDetectorNormalizesMessages(t *testing.T) {
+	detector := newQuotaDetector()
+	_, err := detector.Write([]byte("Testing myapp...\r\x1b[31mTest limit reached for this month\x1b[39m\r\n"))
+	assert.NilError(t, err)
+	assert.Equal(t, detector.Message(), "Test limit reached for this month")
+}
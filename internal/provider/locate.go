@@ -0,0 +1,141 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// Sources of the Snyk binary candidates, from the most to the least preferred
+const (
+	// SourcePath is a Snyk binary installed by the user in the PATH, used when its version is supported
+	SourcePath = "PATH"
+	// SourceConfig is the Snyk binary set in the docker scan configuration
+	SourceConfig = "configuration"
+	// SourceDownloaded is the Snyk binary downloaded by the plugin
+	SourceDownloaded = "downloaded"
+	// SourceDesktop is the Snyk binary bundled with Docker Desktop
+	SourceDesktop = "Docker Desktop"
+)
+
+// BinaryCandidate is a location probed for the Snyk binary
+type BinaryCandidate struct {
+	Source string `json:"source"`
+	Path   string `json:"path"`
+	Found  bool   `json:"found"`
+}
+
+// BinaryResolution lists the locations probed for the Snyk binary, and the path of the one chosen if any
+type BinaryResolution struct {
+	Path       string            `json:"path"`
+	Source     string            `json:"source,omitempty"`
+	Candidates []BinaryCandidate `json:"candidates"`
+}
+
+// ResolveBinary probes the locations of the Snyk binary: the PATH, the path of the configuration, the binary downloaded
+// by the plugin and the install locations of Docker Desktop, including its WSL 2 integration. The first binary found
+// is chosen.
+func ResolveBinary(configPath string) BinaryResolution {
+	candidates := binaryCandidates(configPath, DownloadedBinaryPath(), runtime.GOOS, os.Getenv, exec.LookPath)
+	return resolveBinary(candidates, usableBinary)
+}
+
+func binaryCandidates(configPath, downloadedPath, goos string, getenv func(string) string, lookPath func(string) (string, error)) []BinaryCandidate {
+	var candidates []BinaryCandidate
+	if p, err := lookPath("snyk"); err == nil {
+		candidates = append(candidates, BinaryCandidate{Source: SourcePath, Path: p})
+	}
+	if configPath != "" {
+		candidates = append(candidates, BinaryCandidate{Source: SourceConfig, Path: configPath})
+	}
+	candidates = append(candidates, BinaryCandidate{Source: SourceDownloaded, Path: downloadedPath})
+	for _, p := range desktopBinaryPaths(goos, getenv) {
+		candidates = append(candidates, BinaryCandidate{Source: SourceDesktop, Path: p})
+	}
+	return candidates
+}
+
+// desktopBinaryPaths returns the locations of the Snyk binary bundled with Docker Desktop, for a machine wide or a per
+// user install. In a WSL 2 distribution, the binary is reached through the Docker Desktop integration or the Windows
+// drive.
+func desktopBinaryPaths(goos string, getenv func(string) string) []string {
+	var paths []string
+	switch goos {
+	case "windows":
+		seen := map[string]bool{}
+		for _, programFiles := range []string{getenv("ProgramFiles"), getenv("ProgramW6432")} {
+			if programFiles == "" {
+				continue
+			}
+			if p := windowsPath(programFiles, "Docker", "Docker", "resources", "bin", "snyk.exe"); !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+		if localAppData := getenv("LOCALAPPDATA"); localAppData != "" {
+			paths = append(paths, windowsPath(localAppData, "Programs", "Docker", "Docker", "resources", "bin", "snyk.exe"))
+		}
+	case "darwin":
+		paths = append(paths, "/Applications/Docker.app/Contents/Resources/bin/snyk")
+		if home := getenv("HOME"); home != "" {
+			paths = append(paths, path.Join(home, "Applications", "Docker.app", "Contents", "Resources", "bin", "snyk"))
+		}
+	case "linux":
+		if getenv("WSL_DISTRO_NAME") != "" {
+			paths = append(paths,
+				"/mnt/wsl/docker-desktop/cli-tools/usr/bin/snyk",
+				"/mnt/c/Program Files/Docker/Docker/resources/bin/snyk.exe")
+		}
+		paths = append(paths, "/opt/docker-desktop/bin/snyk")
+	}
+	return paths
+}
+
+// windowsPath joins the elements of a Windows path whatever the platform of the plugin
+func windowsPath(elem ...string) string {
+	for i := range elem {
+		elem[i] = strings.TrimRight(elem[i], `\/`)
+	}
+	return strings.Join(elem, `\`)
+}
+
+// resolveBinary probes all the candidates, to report them, and chooses the first usable one
+func resolveBinary(candidates []BinaryCandidate, usable func(BinaryCandidate) bool) BinaryResolution {
+	resolution := BinaryResolution{Candidates: []BinaryCandidate{}}
+	for _, candidate := range candidates {
+		candidate.Found = usable(candidate)
+		if candidate.Found && resolution.Path == "" {
+			resolution.Path = candidate.Path
+			resolution.Source = candidate.Source
+		}
+		resolution.Candidates = append(resolution.Candidates, candidate)
+	}
+	return resolution
+}
+
+// usableBinary returns true if the candidate binary exists, the one in the PATH must also have a supported version
+func usableBinary(candidate BinaryCandidate) bool {
+	info, err := os.Stat(candidate.Path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return candidate.Source != SourcePath || checkUserSnykBinaryVersion(candidate.Path)
+}
@@ -0,0 +1,74 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDesktopBinaryPaths(t *testing.T) {
+	env := map[string]string{
+		"ProgramFiles":    `C:\Program Files`,
+		"ProgramW6432":    `C:\Program Files\`,
+		"LOCALAPPDATA":    `C:\Users\me\AppData\Local`,
+		"HOME":            "/Users/me",
+		"WSL_DISTRO_NAME": "Ubuntu",
+	}
+	getenv := func(name string) string { return env[name] }
+	assert.DeepEqual(t, desktopBinaryPaths("windows", getenv), []string{
+		`C:\Program Files\Docker\Docker\resources\bin\snyk.exe`,
+		`C:\Users\me\AppData\Local\Programs\Docker\Docker\resources\bin\snyk.exe`,
+	})
+	assert.DeepEqual(t, desktopBinaryPaths("darwin", getenv), []string{
+		"/Applications/Docker.app/Contents/Resources/bin/snyk",
+		"/Users/me/Applications/Docker.app/Contents/Resources/bin/snyk",
+	})
+	assert.DeepEqual(t, desktopBinaryPaths("linux", getenv), []string{
+		"/mnt/wsl/docker-desktop/cli-tools/usr/bin/snyk",
+		"/mnt/c/Program Files/Docker/Docker/resources/bin/snyk.exe",
+		"/opt/docker-desktop/bin/snyk",
+	})
+	assert.DeepEqual(t, desktopBinaryPaths("linux", func(string) string { return "" }), []string{"/opt/docker-desktop/bin/snyk"})
+}
+
+func TestResolveBinary(t *testing.T) {
+	getenv := func(string) string { return "" }
+	lookPath := func(string) (string, error) { return "/usr/local/bin/snyk", nil }
+	candidates := binaryCandidates("/etc/snyk", "/home/me/.docker/scan/provider/snyk", "linux", getenv, lookPath)
+	assert.DeepEqual(t, candidates, []BinaryCandidate{
+		{Source: SourcePath, Path: "/usr/local/bin/snyk"},
+		{Source: SourceConfig, Path: "/etc/snyk"},
+		{Source: SourceDownloaded, Path: "/home/me/.docker/scan/provider/snyk"},
+		{Source: SourceDesktop, Path: "/opt/docker-desktop/bin/snyk"},
+	})
+
+	found := map[string]bool{"/home/me/.docker/scan/provider/snyk": true, "/opt/docker-desktop/bin/snyk": true}
+	resolution := resolveBinary(candidates, func(candidate BinaryCandidate) bool { return found[candidate.Path] })
+	assert.Equal(t, resolution.Path, "/home/me/.docker/scan/provider/snyk")
+	assert.Equal(t, resolution.Source, SourceDownloaded)
+	assert.Equal(t, len(resolution.Candidates), 4)
+	assert.Assert(t, !resolution.Candidates[0].Found)
+	assert.Assert(t, resolution.Candidates[3].Found)
+
+	notFound := func(string) (string, error) { return "", errors.New("not found") }
+	resolution = resolveBinary(binaryCandidates("", "/downloaded/snyk", "linux", getenv, notFound), func(BinaryCandidate) bool { return false })
+	assert.Equal(t, resolution.Path, "")
+	assert.Equal(t, resolution.Candidates[0].Source, SourceDownloaded)
+}
@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// maxTagValue is the longest value of a Snyk project tag
+const maxTagValue = 256
+
+// invalidTagCharacters are the characters refused in the values of the Snyk project tags
+var invalidTagCharacters = regexp.MustCompile(`[^A-Za-z0-9_\-:?#@&+%/.]`)
+
+// monitorFlags are the prefixes of the scan flags applying to the monitored projects
+var monitorFlags = []string{"--org=", "--project-name=", "--file=", "--platform=", "--exclude-base-image-vulns", "--app-vulns",
+	"--exclude-app-vulns", "--json"}
+
+// MonitorMetadata describes where the monitored image comes from, saved as tags of its Snyk projects
+type MonitorMetadata struct {
+	// GitRemote is the URL of the git repository the image is built from, its credentials are removed
+	GitRemote string
+	// Dockerfile is the path of the Dockerfile of the image in the repository
+	Dockerfile string
+}
+
+// Monitor is implemented by the providers registering the images for continuous monitoring, so that the vulnerabilities
+// disclosed after the image is shipped trigger alerts
+type Monitor interface {
+	Monitor(image string, metadata MonitorMetadata) error
+}
+
+// Monitor runs snyk container monitor, creating or updating the Snyk projects of the image with a snapshot of its
+// packages. It requires a Snyk token, the DockerScanID can't monitor images.
+func (s *snykProvider) Monitor(image string, metadata MonitorMetadata) error {
+	token, err := apiToken(s.Options)
+	if err != nil {
+		return err
+	}
+	cmd := s.newCommand(append(monitorArgs(s.flags, metadata), image)...)
+	cmd.Env = append(cmd.Env, "SNYK_TOKEN="+token)
+	cmd.Stdout = s.out
+	cmd.Stderr = s.err
+	defer logCommand(cmd.Args)()
+	err = runCommand(s.context, cmd)
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return &providerFailedError{exitCode: exitErr.ExitCode()}
+	}
+	return checkCommandErr(err)
+}
+
+// monitorArgs returns the arguments of snyk container monitor: the scan flags applying to the projects, and the
+// metadata as project tags
+func monitorArgs(flags []string, metadata MonitorMetadata) []string {
+	args := []string{"container", "monitor"}
+	for _, flag := range flags {
+		for _, prefix := range monitorFlags {
+			if strings.HasPrefix(flag, prefix) {
+				args = append(args, flag)
+				break
+			}
+		}
+	}
+	var tags []string
+	if remote := redactURL(metadata.GitRemote); remote != "" {
+		tags = append(tags, "git-remote="+tagValue(remote))
+	}
+	if metadata.Dockerfile != "" {
+		tags = append(tags, "dockerfile="+tagValue(metadata.Dockerfile))
+	}
+	if len(tags) > 0 {
+		args = append(args, "--project-tags="+strings.Join(tags, ","))
+	}
+	return args
+}
+
+// redactURL removes the credentials of an URL, the scp-like git remotes being kept as is
+func redactURL(remote string) string {
+	parsed, err := url.Parse(remote)
+	if err != nil || parsed.User == nil || parsed.Scheme == "" {
+		return remote
+	}
+	parsed.User = nil
+	return parsed.String()
+}
+
+// tagValue replaces the characters refused in the Snyk project tags
+func tagValue(value string) string {
+	value = invalidTagCharacters.ReplaceAllString(value, "-")
+	if len(value) > maxTagValue {
+		value = value[:maxTagValue]
+	}
+	return value
+}
+
+// String describes the metadata saved with the monitored projects
+func (m MonitorMetadata) String() string {
+	var parts []string
+	if remote := redactURL(m.GitRemote); remote != "" {
+		parts = append(parts, "git remote "+remote)
+	}
+	if m.Dockerfile != "" {
+		parts = append(parts, "Dockerfile "+m.Dockerfile)
+	}
+	if len(parts) == 0 {
+		return "no metadata"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,51 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import "fmt"
+
+var (
+	// snykArtifacts maps a GOOS/GOARCH platform to the Snyk release artifact to download,
+	// Windows and macOS on arm64 run the amd64 binaries through emulation
+	snykArtifacts = map[string]string{
+		"linux/amd64":   "snyk-linux",
+		"linux/arm64":   "snyk-linux-arm64",
+		"darwin/amd64":  "snyk-macos",
+		"darwin/arm64":  "snyk-macos",
+		"windows/amd64": "snyk-win.exe",
+		"windows/arm64": "snyk-win.exe",
+	}
+	// containerizedPlatforms lists the architectures the snyk/snyk image is published for
+	containerizedPlatforms = map[string]bool{
+		"amd64": true,
+		"arm64": true,
+	}
+)
+
+// SnykArtifact returns the name of the Snyk release artifact matching the given platform
+func SnykArtifact(goos, goarch string) (string, error) {
+	artifact, ok := snykArtifacts[goos+"/"+goarch]
+	if !ok {
+		return "", fmt.Errorf("there is no Snyk release for %s/%s, please install Snyk using npm (npm install -g snyk)", goos, goarch)
+	}
+	return artifact, nil
+}
+
+// SupportsContainerizedProvider returns true if the Snyk image can run on the given architecture
+func SupportsContainerizedProvider(goarch string) bool {
+	return containerizedPlatforms[goarch]
+}
@@ -0,0 +1,57 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSnykArtifact(t *testing.T) {
+	testCases := []struct {
+		goos     string
+		goarch   string
+		expected string
+	}{
+		{goos: "linux", goarch: "amd64", expected: "snyk-linux"},
+		{goos: "linux", goarch: "arm64", expected: "snyk-linux-arm64"},
+		{goos: "darwin", goarch: "amd64", expected: "snyk-macos"},
+		{goos: "windows", goarch: "amd64", expected: "snyk-win.exe"},
+		{goos: "windows", goarch: "arm64", expected: "snyk-win.exe"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.goos+"/"+testCase.goarch, func(t *testing.T) {
+			artifact, err := SnykArtifact(testCase.goos, testCase.goarch)
+			assert.NilError(t, err)
+			assert.Equal(t, artifact, testCase.expected)
+		})
+	}
+}
+
+func TestSnykArtifactUnsupportedPlatform(t *testing.T) {
+	_, err := SnykArtifact("linux", "s390x")
+	assert.ErrorContains(t, err, "there is no Snyk release for linux/s390x")
+	_, err = SnykArtifact("linux", "ppc64le")
+	assert.ErrorContains(t, err, "there is no Snyk release for linux/ppc64le")
+}
+
+func TestSupportsContainerizedProvider(t *testing.T) {
+	assert.Assert(t, SupportsContainerizedProvider("amd64"))
+	assert.Assert(t, !SupportsContainerizedProvider("s390x"))
+	assert.Assert(t, !SupportsContainerizedProvider("ppc64le"))
+}
@@ -0,0 +1,72 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"time"
+)
+
+// terminationGracePeriod is the time left to the provider to exit after being asked to, before it is killed
+var terminationGracePeriod = 5 * time.Second
+
+// runCommand runs the command, stopping it with its child processes when the context is done.
+// The context error is returned when the command is stopped. A large error output is spooled to a temporary file,
+// and the run is added to the invocation log.
+func runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	var spool *stderrSpool
+	if cmd.Stderr != nil && cmd.Stderr != ioutil.Discard {
+		spool = newStderrSpool(cmd.Stderr)
+		defer spool.Close() //nolint:errcheck
+		cmd.Stderr = spool
+	}
+	start := time.Now()
+	err := waitCommand(ctx, cmd)
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		recordInvocation(cmd.Args, start, exitErr.ExitCode(), nil, spool.Tail())
+	} else {
+		recordInvocation(cmd.Args, start, 0, err, spool.Tail())
+	}
+	return err
+}
+
+// waitCommand starts the command and waits for it to exit, or stops it when the context is done
+func waitCommand(ctx context.Context, cmd *exec.Cmd) error {
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	exited := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			terminateProcessGroup(cmd, exited)
+		case <-exited:
+		}
+	}()
+	err := cmd.Wait()
+	close(exited)
+	<-stopped
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
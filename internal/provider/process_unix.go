@@ -0,0 +1,43 @@
+//go:build !windows
+// +build !windows
+
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup runs the command in its own process group, so that its child processes can be stopped with it
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateProcessGroup asks the processes of the command group to exit, and kills them if they are
+// still running after the grace period
+func terminateProcessGroup(cmd *exec.Cmd, exited <-chan struct{}) {
+	pgid := -cmd.Process.Pid
+	_ = syscall.Kill(pgid, syscall.SIGTERM)
+	select {
+	case <-exited:
+	case <-time.After(terminationGracePeriod):
+		_ = syscall.Kill(pgid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,115 @@
+//go:build !windows
+// +build !windows
+
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/invocation"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/poll"
+)
+
+func TestRunCommandTerminatesProcessGroup(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// the child process is left running by the shell, it must be stopped with it
+	cmd := exec.Command("sh", "-c", "sleep 30 & echo $! > "+dir.Join("child")+"; wait")
+	start := time.Now()
+	err := runCommand(ctx, cmd)
+	assert.Equal(t, err, context.DeadlineExceeded)
+	assert.Assert(t, time.Since(start) < terminationGracePeriod)
+	assertProcessStopped(t, dir.Join("child"))
+}
+
+func TestRunCommandKillsProcessGroupAfterGracePeriod(t *testing.T) {
+	defer func(period time.Duration) { terminationGracePeriod = period }(terminationGracePeriod)
+	terminationGracePeriod = 200 * time.Millisecond
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// SIGTERM is ignored by the shell and by its child, which inherits the ignored signals
+	cmd := exec.Command("sh", "-c", "trap '' TERM; sleep 30 & echo $! > "+dir.Join("child")+"; wait")
+	err := runCommand(ctx, cmd)
+	assert.Equal(t, err, context.DeadlineExceeded)
+	assert.Equal(t, cmd.ProcessState.Sys().(syscall.WaitStatus).Signal(), syscall.SIGKILL)
+	assertProcessStopped(t, dir.Join("child"))
+}
+
+func TestRunCommandCompletes(t *testing.T) {
+	assert.NilError(t, runCommand(context.Background(), exec.Command("sh", "-c", "exit 0")))
+	err := runCommand(context.Background(), exec.Command("sh", "-c", "exit 3"))
+	assert.Equal(t, err.(*exec.ExitError).ExitCode(), 3)
+}
+
+func assertProcessStopped(t *testing.T, pidFile string) {
+	t.Helper()
+	buf, err := ioutil.ReadFile(filepath.Clean(pidFile))
+	assert.NilError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf)))
+	assert.NilError(t, err)
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		// signal 0 fails once the child is gone, it may also remain a zombie until reaped by init
+		if err := syscall.Kill(pid, 0); err != nil || isZombie(pid) {
+			return poll.Success()
+		}
+		return poll.Continue("child process %d is still running", pid)
+	}, poll.WithTimeout(5*time.Second))
+}
+
+func isZombie(pid int) bool {
+	buf, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	// the state follows the command name, which is between parentheses
+	stat := string(buf)
+	return strings.HasPrefix(stat[strings.LastIndex(stat, ")")+1:], " Z")
+}
+
+func TestRunCommandRecordsInvocation(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "echo 'Error: invalid image reference' >&2; exit 2", "--token", snykToken)
+	cmd.Stderr = &bytes.Buffer{}
+	err := runCommand(context.Background(), cmd)
+	assert.ErrorContains(t, err, "exit status 2")
+
+	invocations, err := invocation.Read(invocationPath())
+	assert.NilError(t, err)
+	last, ok := invocation.LastFailed(invocations)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, last.Command, []string{"sh", "-c", "echo 'Error: invalid image reference' >&2; exit 2", "--token", "*****"})
+	assert.Equal(t, last.ExitCode, 2)
+	assert.Equal(t, last.Stderr, "Error: invalid image reference\n")
+}
@@ -0,0 +1,53 @@
+//go:build windows
+// +build windows
+
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestRunCommandKillsProcessTree(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// the child process would be orphaned if only powershell was killed
+	cmd := exec.Command("powershell", "-NoProfile", "-Command",
+		"$p = Start-Process ping -ArgumentList '-n','60','127.0.0.1' -PassThru -NoNewWindow; "+
+			"Set-Content -Path '"+dir.Join("child")+"' -Value $p.Id; $p.WaitForExit()")
+	err := runCommand(ctx, cmd)
+	assert.Equal(t, err, context.DeadlineExceeded)
+
+	buf, err := ioutil.ReadFile(dir.Join("child"))
+	assert.NilError(t, err)
+	pid := strings.TrimSpace(string(buf))
+	out, err := exec.Command("tasklist", "/FI", "PID eq "+pid, "/NH").Output()
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(strings.ToLower(string(out)), "ping.exe"), "child process %s is still running: %s", pid, out)
+}
@@ -20,14 +20,15 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
-	"os/exec"
-
-	"github.com/docker/scan-cli-plugin/internal/authentication"
+	"path/filepath"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/scan-cli-plugin/internal/hub"
+	"github.com/docker/scan-cli-plugin/internal/proxy"
 )
 
 // Provider abstracts a scan provider
@@ -37,22 +38,49 @@ type Provider interface {
 	Version() (string, error)
 }
 
+// TokenStore persists the provider authentication token
+type TokenStore interface {
+	Get() (string, error)
+	Store(token string) error
+	Erase() error
+	Migrate() error
+}
+
+type emptyTokenStore struct{}
+
+func (emptyTokenStore) Get() (string, error)     { return "", nil }
+func (emptyTokenStore) Store(token string) error { return nil }
+func (emptyTokenStore) Erase() error             { return nil }
+func (emptyTokenStore) Migrate() error           { return nil }
+
 // Options default options for all provider types
 type Options struct {
-	flags   []string
-	auth    types.AuthConfig
-	context context.Context
-	out     io.Writer
-	err     io.Writer
-	path    string
+	flags      []string
+	auth       types.AuthConfig
+	context    context.Context
+	out        io.Writer
+	err        io.Writer
+	path       string
+	tokenStore TokenStore
+	httpClient *http.Client
+	caCert     string
+	org        string
+	retry      RetryPolicy
+	// environment selects the variables of the plugin passed to the provider processes
+	environment EnvironmentFilter
 }
 
 // NewProvider returns default provider options setup with the give options
 func NewProvider(options ...Ops) (Options, error) {
+	// the client can only fail to load a CA certificate
+	httpClient, _ := proxy.NewHTTPClient("")
 	provider := Options{
-		flags: []string{"container", "test"},
-		out:   os.Stdout,
-		err:   os.Stderr,
+		flags:      []string{"container", "test"},
+		context:    context.Background(),
+		out:        os.Stdout,
+		err:        os.Stderr,
+		tokenStore: emptyTokenStore{},
+		httpClient: httpClient,
 	}
 	for _, op := range options {
 		if err := op(&provider); err != nil {
@@ -62,6 +90,20 @@ func NewProvider(options ...Ops) (Options, error) {
 	return provider, nil
 }
 
+// VersionKey identifies the version of a provider without running it, when its binary or its image is enough
+// to identify it
+func VersionKey(p Provider) (string, error) {
+	switch p := p.(type) {
+	case *snykProvider:
+		if info, err := os.Stat(p.path); err == nil {
+			return fmt.Sprintf("%s %d %d", p.path, info.Size(), info.ModTime().UnixNano()), nil
+		}
+	case *dockerSnykProvider:
+		return image, nil
+	}
+	return p.Version()
+}
+
 // UseExternalBinary return true if the provider path option is setup
 func UseExternalBinary(providerOpts Options) bool {
 	return providerOpts.path != ""
@@ -95,6 +137,35 @@ func WithStreams(out, err io.Writer) Ops {
 	}
 }
 
+// WithTokenStore sets the store used to persist the provider authentication token
+func WithTokenStore(store TokenStore) Ops {
+	return func(provider *Options) error {
+		provider.tokenStore = store
+		return nil
+	}
+}
+
+// WithCACert trusts the certificates of the given CA bundle for all the outbound calls,
+// including the ones made by the provider
+func WithCACert(path string) Ops {
+	return func(provider *Options) error {
+		if path == "" {
+			return nil
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		client, err := proxy.NewHTTPClient(path)
+		if err != nil {
+			return err
+		}
+		provider.httpClient = client
+		provider.caCert = path
+		return nil
+	}
+}
+
 // WithJSON set JSONFormat to display scan result in JSON
 func WithJSON() Ops {
 	return func(provider *Options) error {
@@ -143,35 +214,71 @@ func WithSeverity(severity string) Ops {
 	}
 }
 
-// WithGroupIssues groups same issues in a single one when using --json flag
-func WithGroupIssues() Ops {
+// WithAppVulns also reports the vulnerabilities of the application dependencies
+func WithAppVulns() Ops {
 	return func(provider *Options) error {
-		provider.flags = append(provider.flags, "--group-issues")
+		provider.flags = append(provider.flags, "--app-vulns")
 		return nil
 	}
 }
 
-// WithPath update the provider binary with the path from the configuration
-func WithPath(path string) Ops {
+// WithoutAppVulns only reports the vulnerabilities of the OS packages
+func WithoutAppVulns() Ops {
+	return func(provider *Options) error {
+		provider.flags = append(provider.flags, "--exclude-app-vulns")
+		return nil
+	}
+}
+
+// WithOrg runs the scans in the given Snyk organization instead of the default one of the account, it replaces the
+// organization of a previous WithOrg
+func WithOrg(org string) Ops {
+	return func(provider *Options) error {
+		provider.flags = append(withoutFlag(provider.flags, "--org="), "--org="+org)
+		provider.org = org
+		return nil
+	}
+}
+
+// WithProjectName names the Snyk project of the image instead of naming it after the image
+func WithProjectName(name string) Ops {
 	return func(provider *Options) error {
-		if p, err := exec.LookPath("snyk"); err == nil && checkUserSnykBinaryVersion(p) {
-			path = p
+		provider.flags = append(withoutFlag(provider.flags, "--project-name="), "--project-name="+name)
+		return nil
+	}
+}
+
+// withoutFlag removes the values of a flag from the provider flags
+func withoutFlag(flags []string, prefix string) []string {
+	var kept []string
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, prefix) {
+			kept = append(kept, flag)
 		}
-		provider.path = path
+	}
+	return kept
+}
+
+// WithPlatform scans the image of the given platform of a multi-platform image
+func WithPlatform(platform string) Ops {
+	return func(provider *Options) error {
+		provider.flags = append(provider.flags, "--platform="+platform)
 		return nil
 	}
 }
 
-func getToken(opts Options) (string, error) {
-	if opts.auth.Username == "" {
-		return "", fmt.Errorf(`You need to be logged in to Docker Hub to use scan feature.
-please login to Docker Hub using the Docker Login command`)
+// WithGroupIssues groups same issues in a single one when using --json flag
+func WithGroupIssues() Ops {
+	return func(provider *Options) error {
+		provider.flags = append(provider.flags, "--group-issues")
+		return nil
 	}
-	h := hub.GetInstance()
-	jwks, err := h.FetchJwks()
-	if err != nil {
-		return "", err
+}
+
+// WithPath update the provider binary with the path resolved from the configuration
+func WithPath(path string) Ops {
+	return func(provider *Options) error {
+		provider.path = path
+		return nil
 	}
-	authenticator := authentication.NewAuthenticator(jwks, h.APIHubBaseURL)
-	return authenticator.GetToken(opts.auth)
 }
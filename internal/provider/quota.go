@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/config"
+	"github.com/docker/scan-cli-plugin/internal/debug"
+	"github.com/docker/scan-cli-plugin/internal/usage"
+)
+
+// usagePath is where the scans made with the DockerScanID are counted
+var usagePath = usage.DefaultPath
+
+// maxQuotaLine is the length of the output lines kept to look for a message, longer lines are truncated
+const maxQuotaLine = 4096
+
+// quotaMessage matches the messages of Snyk when the test limit of the account is reached, like
+// "You have reached your monthly limit of 200 private tests for your docker-desktop-test org."
+var quotaMessage = regexp.MustCompile(`(?i)(monthly limit|test limit|limit of \d+ (private )?tests|quota (has been )?(reached|exceeded))`)
+
+// messageDetector looks for a message matching its pattern in the output of the provider, while it is written to
+// the user
+type messageDetector struct {
+	pattern *regexp.Regexp
+	mu      sync.Mutex
+	line    []byte
+	message string
+}
+
+// newQuotaDetector returns a detector of the quota messages
+func newQuotaDetector() *messageDetector {
+	return &messageDetector{pattern: quotaMessage}
+}
+
+// wrap returns a writer writing to out, the output being checked by the detector
+func (q *messageDetector) wrap(out io.Writer) io.Writer {
+	return io.MultiWriter(out, q)
+}
+
+func (q *messageDetector) Write(p []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, b := range p {
+		if b != '\n' {
+			if len(q.line) < maxQuotaLine {
+				q.line = append(q.line, b)
+			}
+			continue
+		}
+		q.check()
+	}
+	return len(p), nil
+}
+
+// Message returns the message found in the output, empty if there is none
+func (q *messageDetector) Message() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.check()
+	return q.message
+}
+
+// check looks for a message in the current line, and starts a new line
+func (q *messageDetector) check() {
+	line := normalizeMessage(q.line)
+	if q.message == "" && q.pattern.Match(line) {
+		q.message = strings.TrimSpace(string(bytes.TrimPrefix(bytes.TrimSpace(line), []byte("Error:"))))
+		// the JSON output holds the message in its error field
+		if index := strings.Index(q.message, `"error":`); index >= 0 {
+			q.message = strings.Trim(strings.TrimSpace(q.message[index+len(`"error":`):]), `",}`)
+		}
+	}
+	q.line = q.line[:0]
+}
+
+// quotaResult returns the quota error when the provider failed because of a quota message, the result of the exit
+// code otherwise
+func quotaResult(exitCode int, detector *messageDetector) error {
+	err := scanResult(exitCode)
+	if IsProviderFailedError(err) {
+		if message := detector.Message(); message != "" {
+			return &quotaExceededError{message: message}
+		}
+	}
+	return err
+}
+
+// recordUsage counts the scans made with the DockerScanID, whose number is limited each month, and the scans refused
+// because of the quota
+func recordUsage(opts Options, token string, err error) {
+	if !strings.HasPrefix(token, dockerScanIDEnv) || config.ReadOnly() {
+		return
+	}
+	message := ""
+	switch quotaErr := err.(type) {
+	case *quotaExceededError:
+		message = quotaErr.message
+	case nil, *vulnerabilitiesFoundError:
+	default:
+		return
+	}
+	if err := usage.Record(usagePath(), opts.auth.Username, time.Now(), message); err != nil {
+		debug.Log("failed to count the scan", "error", err)
+	}
+}
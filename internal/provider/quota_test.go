@@ -0,0 +1,81 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/scan-cli-plugin/internal/usage"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestQuotaDetector(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	quota := newQuotaDetector()
+	writer := quota.wrap(out)
+	fmt.Fprint(writer, "Testing alpine...\n\nError: You have reached your monthly ")
+	fmt.Fprint(writer, "limit of 200 private tests for your docker-desktop-test org.\n")
+	assert.Equal(t, out.String(), "Testing alpine...\n\nError: You have reached your monthly limit of 200 private tests for your docker-desktop-test org.\n")
+	assert.Equal(t, quota.Message(), "You have reached your monthly limit of 200 private tests for your docker-desktop-test org.")
+
+	quota = newQuotaDetector()
+	fmt.Fprint(quota, `{
+  "ok": false,
+  "error": "You have reached your monthly limit of 200 private tests for your docker-desktop-test org.",
+  "path": "alpine"
+}`)
+	assert.Equal(t, quota.Message(), "You have reached your monthly limit of 200 private tests for your docker-desktop-test org.")
+
+	quota = newQuotaDetector()
+	fmt.Fprint(quota, "Tested 16 dependencies for known issues, found 2 issues.\n")
+	assert.Equal(t, quota.Message(), "")
+}
+
+func TestQuotaResult(t *testing.T) {
+	quota := newQuotaDetector()
+	fmt.Fprint(quota, "Error: You have reached your monthly limit of 200 private tests\n")
+	assert.Assert(t, IsQuotaExceededError(quotaResult(2, quota)))
+	assert.Assert(t, IsVulnerabilitiesFoundError(quotaResult(1, quota)))
+	assert.Assert(t, IsProviderFailedError(quotaResult(2, newQuotaDetector())))
+}
+
+func TestRecordUsage(t *testing.T) {
+	dir := fs.NewDir(t, "usage")
+	defer dir.Remove()
+	defer func(saved func() string) { usagePath = saved }(usagePath)
+	usagePath = func() string { return dir.Join("usage.json") }
+	opts := Options{auth: types.AuthConfig{Username: "myuser"}}
+
+	recordUsage(opts, dockerScanIDEnv+"token", nil)
+	recordUsage(opts, dockerScanIDEnv+"token", &vulnerabilitiesFoundError{})
+	recordUsage(opts, dockerScanIDEnv+"token", &providerFailedError{exitCode: 2})
+	recordUsage(opts, "SNYK_TOKEN="+snykToken, nil)
+	scans, err := usage.Get(dir.Join("usage.json"), "myuser", time.Now())
+	assert.NilError(t, err)
+	assert.Equal(t, scans.Scans, 2)
+
+	recordUsage(opts, dockerScanIDEnv+"token", &quotaExceededError{message: "You have reached your monthly limit of 10 private tests"})
+	scans, err = usage.Get(dir.Join("usage.json"), "myuser", time.Now())
+	assert.NilError(t, err)
+	assert.Assert(t, scans.Exceeded)
+	assert.Equal(t, scans.Remaining(), 0)
+}
@@ -0,0 +1,128 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/debug"
+)
+
+// transientMessage matches the network failures worth trying again: timeouts, reset connections, DNS resolution
+// failures and the HTTP 5xx responses, as reported by Go or by the Node.js runtime of Snyk
+var transientMessage = regexp.MustCompile(`(?i)(timeout|timed out|ETIMEDOUT|ESOCKETTIMEDOUT|ECONNRESET|ECONNREFUSED|EAI_AGAIN|` +
+	`socket hang up|connection reset|connection refused|temporary failure in name resolution|unexpected EOF|` +
+	`\b50[0234]\b|internal server error|bad gateway|service unavailable|gateway time-?out)`)
+
+// RetryPolicy configures how many times and how long after a transient failure is tried again
+type RetryPolicy struct {
+	// Retries is the number of times a failed attempt is tried again, 0 to never try again
+	Retries int
+	// InitialDelay is the wait before the first retry, doubled at each retry
+	InitialDelay time.Duration
+	// MaxDelay caps the wait between two attempts
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy waits 2s, 4s... up to 30s between the attempts
+var DefaultRetryPolicy = RetryPolicy{Retries: 2, InitialDelay: 2 * time.Second, MaxDelay: 30 * time.Second}
+
+// delay returns the wait before the retry following the given attempt, starting from 0
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.InitialDelay
+	for i := 0; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// WithRetryPolicy sets how the DockerScanID retrieval and the scans failing with a transient network error are
+// tried again
+func WithRetryPolicy(policy RetryPolicy) Ops {
+	return func(provider *Options) error {
+		provider.retry = policy
+		return nil
+	}
+}
+
+var sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newTransientDetector returns a detector of the transient network failures
+func newTransientDetector() *messageDetector {
+	return &messageDetector{pattern: transientMessage}
+}
+
+// retry calls f again while it fails with a transient error, at most the retries of the policy
+func retry(opts Options, f func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= opts.retry.Retries || !transientMessage.MatchString(err.Error()) {
+			return err
+		}
+		if err := waitRetry(opts, attempt, err.Error()); err != nil {
+			return err
+		}
+	}
+}
+
+// retryScan runs the scan again when the provider fails with a transient network error. The output of each attempt
+// is held until it completes, so that the output of a failed attempt is dropped and the results are written once.
+func retryScan(opts Options, scan func(out, errOut io.Writer) error) error {
+	if opts.retry.Retries <= 0 {
+		return scan(opts.out, opts.err)
+	}
+	for attempt := 0; ; attempt++ {
+		transient := newTransientDetector()
+		out := bytes.NewBuffer(nil)
+		err := scan(transient.wrap(out), transient.wrap(opts.err))
+		if attempt < opts.retry.Retries && IsProviderFailedError(err) && transient.Message() != "" {
+			if err := waitRetry(opts, attempt, transient.Message()); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, copyErr := io.Copy(opts.out, out); copyErr != nil && err == nil {
+			return copyErr
+		}
+		return err
+	}
+}
+
+// waitRetry tells the user about the failure and waits before the next attempt
+func waitRetry(opts Options, attempt int, reason string) error {
+	delay := opts.retry.delay(attempt)
+	debug.Log("retrying after a transient failure", "reason", reason, "attempt", attempt+1, "delay", delay)
+	fmt.Fprintf(opts.err, "Transient failure: %s, retrying in %s (%d/%d)\n", reason, delay, attempt+1, opts.retry.Retries)
+	return sleep(opts.context, delay)
+}
@@ -0,0 +1,125 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func stubSleep(t *testing.T) *[]time.Duration {
+	var delays []time.Duration
+	saved := sleep
+	sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	t.Cleanup(func() { sleep = saved })
+	return &delays
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{Retries: 5, InitialDelay: 2 * time.Second, MaxDelay: 10 * time.Second}
+	assert.Equal(t, policy.delay(0), 2*time.Second)
+	assert.Equal(t, policy.delay(1), 4*time.Second)
+	assert.Equal(t, policy.delay(2), 8*time.Second)
+	assert.Equal(t, policy.delay(3), 10*time.Second)
+	assert.Equal(t, policy.delay(10), 10*time.Second)
+}
+
+func TestRetry(t *testing.T) {
+	delays := stubSleep(t)
+	errOut := bytes.NewBuffer(nil)
+	opts := Options{context: context.Background(), err: errOut, retry: RetryPolicy{Retries: 2, InitialDelay: time.Second, MaxDelay: time.Minute}}
+
+	calls := 0
+	err := retry(opts, func() error {
+		calls++
+		if calls == 1 {
+			return fmt.Errorf(`bad status code "503 Service Unavailable"`)
+		}
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, calls, 2)
+	assert.DeepEqual(t, *delays, []time.Duration{time.Second})
+	assert.Equal(t, errOut.String(), "Transient failure: bad status code \"503 Service Unavailable\", retrying in 1s (1/2)\n")
+
+	calls = 0
+	err = retry(opts, func() error {
+		calls++
+		return fmt.Errorf("failed to fetch JWKS: dial tcp: i/o timeout")
+	})
+	assert.ErrorContains(t, err, "i/o timeout")
+	assert.Equal(t, calls, 3)
+
+	calls = 0
+	err = retry(opts, func() error {
+		calls++
+		return fmt.Errorf(`bad status code "401 Unauthorized"`)
+	})
+	assert.ErrorContains(t, err, "401")
+	assert.Equal(t, calls, 1)
+}
+
+func TestRetryScan(t *testing.T) {
+	stubSleep(t)
+	out := bytes.NewBuffer(nil)
+	errOut := bytes.NewBuffer(nil)
+	opts := Options{context: context.Background(), out: out, err: errOut, retry: RetryPolicy{Retries: 2, InitialDelay: time.Second}}
+
+	attempts := 0
+	err := retryScan(opts, func(out, errOut io.Writer) error {
+		attempts++
+		if attempts == 1 {
+			fmt.Fprintln(out, `{"ok": false, "error": "connect ETIMEDOUT 104.18.8.125:443"}`)
+			return &providerFailedError{}
+		}
+		fmt.Fprintln(out, `{"ok": true}`)
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, attempts, 2)
+	assert.Equal(t, out.String(), "{\"ok\": true}\n")
+	assert.Equal(t, errOut.String(), "Transient failure: connect ETIMEDOUT 104.18.8.125:443, retrying in 1s (1/2)\n")
+
+	out.Reset()
+	attempts = 0
+	err = retryScan(opts, func(out, errOut io.Writer) error {
+		attempts++
+		fmt.Fprintln(errOut, "Error: invalid image reference")
+		return &providerFailedError{}
+	})
+	assert.Assert(t, IsProviderFailedError(err))
+	assert.Equal(t, attempts, 1)
+
+	attempts = 0
+	err = retryScan(opts, func(out, errOut io.Writer) error {
+		attempts++
+		fmt.Fprintln(out, "Tested 16 dependencies for known issues, found 2 issues.")
+		return &vulnerabilitiesFoundError{}
+	})
+	assert.Assert(t, IsVulnerabilitiesFoundError(err))
+	assert.Equal(t, attempts, 1)
+	assert.Equal(t, out.String(), "Tested 16 dependencies for known issues, found 2 issues.\n")
+}
@@ -0,0 +1,84 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+)
+
+// SBOM formats
+const (
+	CycloneDX = "CycloneDX"
+	SPDX      = "SPDX"
+)
+
+// SBOMScanner is implemented by the providers able to match the packages of an SBOM against the vulnerabilities,
+// without the image
+type SBOMScanner interface {
+	ScanSBOM(path string) error
+}
+
+// SBOMFormat returns the format of a JSON SBOM file, CycloneDX or SPDX
+func SBOMFormat(path string) (string, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var document struct {
+		BOMFormat   string `json:"bomFormat"`
+		SPDXVersion string `json:"spdxVersion"`
+	}
+	if err := json.Unmarshal(buf, &document); err != nil {
+		return "", fmt.Errorf("invalid SBOM %s, only the JSON CycloneDX and SPDX formats are supported: %s", path, err)
+	}
+	switch {
+	case document.BOMFormat == CycloneDX:
+		return CycloneDX, nil
+	case strings.HasPrefix(document.SPDXVersion, "SPDX-"):
+		return SPDX, nil
+	}
+	return "", fmt.Errorf("invalid SBOM %s, only the JSON CycloneDX and SPDX formats are supported", path)
+}
+
+// ScanSBOM runs the experimental Snyk SBOM test. Only the JSON output and the severity threshold
+// flags apply to it.
+func (s *snykProvider) ScanSBOM(path string) error {
+	args := []string{"sbom", "test", "--experimental", "--file=" + path}
+	for _, flag := range s.flags {
+		if flag == "--json" || strings.HasPrefix(flag, "--severity-threshold=") {
+			args = append(args, flag)
+		}
+	}
+	cmd := s.newCommand(args...)
+	token, err := scanTokenEnv(s.Options)
+	if err != nil {
+		return err
+	}
+	cmd.Env = append(cmd.Env, token)
+	cmd.Stdout = s.out
+	cmd.Stderr = s.err
+	defer logCommand(cmd.Args)()
+	err = runCommand(s.context, cmd)
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return scanResult(exitErr.ExitCode())
+	}
+	return checkCommandErr(err)
+}
@@ -0,0 +1,42 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+var (
+	_ SBOMScanner = &snykProvider{}
+	_ SBOMScanner = &trivyProvider{}
+	_ SBOMScanner = &grypeProvider{}
+)
+
+func TestSBOMFormat(t *testing.T) {
+	format, err := SBOMFormat("testdata/sbom/cyclonedx.json")
+	assert.NilError(t, err)
+	assert.Equal(t, format, CycloneDX)
+
+	format, err = SBOMFormat("testdata/sbom/spdx.json")
+	assert.NilError(t, err)
+	assert.Equal(t, format, SPDX)
+
+	_, err = SBOMFormat("testdata/trivy/report.json")
+	assert.ErrorContains(t, err, "only the JSON CycloneDX and SPDX formats are supported")
+}
@@ -18,17 +18,16 @@ package provider
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/docker/scan-cli-plugin/internal/debug"
 	"github.com/google/uuid"
-	"github.com/mitchellh/go-homedir"
 )
 
 const (
@@ -68,26 +67,35 @@ func (s *snykProvider) Authenticate(token string) error {
 		"SNYK_UTM_CAMPAIGN=Docker-Desktop-2020")
 	cmd.Stdout = s.out
 	cmd.Stderr = s.err
-	return checkCommandErr(cmd.Run())
+	defer logCommand(cmd.Args)()
+	if err := checkCommandErr(runCommand(s.context, cmd)); err != nil {
+		return err
+	}
+	return s.tokenStore.Migrate()
 }
 
 func (s *snykProvider) Scan(image string) error {
 	// check snyk token
-	cmd := s.newCommand(append(s.flags, image)...)
-	if authenticated, err := isAuthenticatedOnSnyk(); authenticated == "" || err != nil {
-		var err error
-		token, err := getToken(s.Options)
-		if err != nil {
-			return fmt.Errorf("failed to get DockerScanID: %s", err)
-		}
-		cmd.Env = append(cmd.Env, fmt.Sprintf("SNYK_DOCKER_TOKEN=%s", token))
-	} else {
-		cmd.Env = append(cmd.Env, fmt.Sprintf("SNYK_TOKEN=%s", authenticated))
+	token, err := scanTokenEnv(s.Options)
+	if err != nil {
+		return err
 	}
-
-	cmd.Stdout = s.out
-	cmd.Stderr = s.err
-	return checkCommandErr(cmd.Run())
+	err = retryScan(s.Options, func(out, errOut io.Writer) error {
+		cmd := s.newCommand(append(s.flags, image)...)
+		cmd.Env = append(cmd.Env, token)
+
+		quota := newQuotaDetector()
+		cmd.Stdout = quota.wrap(out)
+		cmd.Stderr = quota.wrap(errOut)
+		defer logCommand(cmd.Args)()
+		err := runCommand(s.context, cmd)
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return quotaResult(exitErr.ExitCode(), quota)
+		}
+		return checkCommandErr(err)
+	})
+	recordUsage(s.Options, token, err)
+	return err
 }
 
 func (s *snykProvider) Version() (string, error) {
@@ -96,7 +104,8 @@ func (s *snykProvider) Version() (string, error) {
 	buffErr := bytes.NewBuffer(nil)
 	cmd.Stdout = buff
 	cmd.Stderr = buffErr
-	if err := cmd.Run(); err != nil {
+	defer logCommand(cmd.Args)()
+	if err := runCommand(s.context, cmd); err != nil {
 		errMsg := fmt.Sprintf("failed to get snyk version: %s", checkCommandErr(err))
 		if buffErr.String() != "" {
 			errMsg = fmt.Sprintf(errMsg+",%s", buffErr.String())
@@ -107,57 +116,42 @@ func (s *snykProvider) Version() (string, error) {
 }
 
 func (s *snykProvider) newCommand(arg ...string) *exec.Cmd {
-	cmd := exec.CommandContext(s.context, s.path, arg...)
-	cmd.Env = append(os.Environ(),
+	cmd := exec.Command(s.path, arg...)
+	cmd.Env = providerEnvironment(s.environment,
 		"NO_UPDATE_NOTIFIER=true",
 		"SNYK_CFG_DISABLESUGGESTIONS=true",
 		"SNYK_INTEGRATION_NAME=DOCKER_DESKTOP")
+	if s.caCert != "" {
+		cmd.Env = append(cmd.Env, "NODE_EXTRA_CA_CERTS="+s.caCert)
+	}
 	return cmd
 }
 
+// logCommand logs the provider command line without its secrets, the returned function logs its duration
+func logCommand(args []string) func() {
+	return debug.Time("running provider", "command", strings.Join(debug.Redact(args), " "))
+}
+
 func checkCommandErr(err error) error {
 	if err == nil {
 		return nil
 	}
 	if err == exec.ErrNotFound {
 		// Could not find Snyk in $PATH
-		return fmt.Errorf("could not find Snyk binary")
+		return classify(ErrBinaryNotFound, err, "could not find Snyk binary")
 	} else if _, ok := err.(*exec.Error); ok {
-		return fmt.Errorf("could not find Snyk binary")
+		return classify(ErrBinaryNotFound, err, "could not find Snyk binary")
 	} else if _, ok := err.(*os.PathError); ok {
 		// The specified path for Snyk binary does not exist
-		return fmt.Errorf("could not find Snyk binary")
+		return classify(ErrBinaryNotFound, err, "could not find Snyk binary")
 	}
 	return err
 }
 
-type snykConfig struct {
-	API string `json:"api,omitempty"`
-}
-
-func isAuthenticatedOnSnyk() (string, error) {
-	home, err := homedir.Dir()
-	if err != nil {
-		return "", err
-	}
-	snykConfFilePath := filepath.Join(home, ".config", "configstore", "snyk.json")
-	buff, err := ioutil.ReadFile(snykConfFilePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return "", nil
-		}
-		return "", err
-	}
-	var config snykConfig
-	if err := json.Unmarshal(buff, &config); err != nil {
-		return "", err
-	}
-
-	return config.API, nil
-}
-
 func checkUserSnykBinaryVersion(path string) bool {
 	cmd := exec.Command(path, "--version")
+	// the binary is probed before the filter of the configuration is known, only the essential variables are needed
+	cmd.Env = providerEnvironment(EnvironmentFilter{Allow: []string{"PATH"}})
 	buff := bytes.NewBuffer(nil)
 	cmd.Stdout = buff
 	cmd.Stderr = ioutil.Discard
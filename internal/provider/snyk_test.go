@@ -26,8 +26,6 @@ import (
 	"testing"
 
 	"gotest.tools/v3/assert"
-	"gotest.tools/v3/env"
-	"gotest.tools/v3/fs"
 )
 
 var (
@@ -65,15 +63,7 @@ func TestSnykScanEnvVars(t *testing.T) {
 		t.Skip("Can't run this test on windows")
 	}
 
-	// Create Snyk config file with token
-	tempDir := fs.NewDir(t, t.Name(),
-		fs.WithDir(".config",
-			fs.WithDir("configstore",
-				fs.WithFile("snyk.json", `{"api":"`+snykToken+`"}`))))
-	defer tempDir.Remove()
-	defer env.Patch(t, "HOME", tempDir.Path())()
-
-	provider, outStream := setupMockSnykBinary(t)
+	provider, outStream := setupMockSnykBinary(t, WithTokenStore(&memoryTokenStore{token: snykToken}))
 
 	err := provider.Scan("image")
 	assert.NilError(t, err)
@@ -84,21 +74,94 @@ func TestSnykScanEnvVars(t *testing.T) {
 	assert.Assert(t, strings.Contains(outStream.String(), "NO_UPDATE_NOTIFIER=true"))
 	// SNYK_CFG_DISABLESUGGESTIONS removes user hints from snyk
 	assert.Assert(t, strings.Contains(outStream.String(), "SNYK_CFG_DISABLESUGGESTIONS=true"))
+	// SNYK_TOKEN comes from the token store
+	assert.Assert(t, strings.Contains(outStream.String(), "SNYK_TOKEN="+snykToken))
 }
 
-func setupMockSnykBinary(t *testing.T) (Provider, *bytes.Buffer) {
+func TestSnykScanCACertEnvVar(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Can't run this test on windows")
+	}
+
+	caCert, err := filepath.Abs(filepath.Join("testdata", "ca.pem"))
+	assert.NilError(t, err)
+	provider, outStream := setupMockSnykBinary(t, WithTokenStore(&memoryTokenStore{token: snykToken}), WithCACert(caCert))
+
+	err = provider.Scan("image")
+	assert.NilError(t, err)
+
+	// NODE_EXTRA_CA_CERTS makes the provider trust the custom CA
+	assert.Assert(t, strings.Contains(outStream.String(), "NODE_EXTRA_CA_CERTS="+caCert))
+}
+
+func TestWithCACertInvalidFile(t *testing.T) {
+	_, err := NewProvider(WithCACert(filepath.Join("testdata", "snyk")))
+	assert.ErrorContains(t, err, "no PEM certificate found")
+}
+
+func TestSnykLoginMigratesToken(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Can't run this test on windows")
+	}
+
+	store := &memoryTokenStore{}
+	provider, _ := setupMockSnykBinary(t, WithTokenStore(store))
+
+	err := provider.Authenticate(snykToken)
+	assert.NilError(t, err)
+	assert.Assert(t, store.migrated)
+}
+
+type memoryTokenStore struct {
+	token    string
+	migrated bool
+}
+
+func (m *memoryTokenStore) Get() (string, error) {
+	return m.token, nil
+}
+
+func (m *memoryTokenStore) Store(token string) error {
+	m.token = token
+	return nil
+}
+
+func (m *memoryTokenStore) Erase() error {
+	m.token = ""
+	return nil
+}
+
+func (m *memoryTokenStore) Migrate() error {
+	m.migrated = true
+	return nil
+}
+
+func setupMockSnykBinary(t *testing.T, ops ...Ops) (Provider, *bytes.Buffer) {
 	pwd, err := os.Getwd()
 	assert.NilError(t, err)
 	snykPath := filepath.Join(pwd, "testdata", "snyk")
 	outStream := bytes.NewBuffer(nil)
 	errStream := bytes.NewBuffer(nil)
 
-	defaultProvider, err := NewProvider(WithContext(context.Background()),
+	ops = append([]Ops{WithContext(context.Background()),
 		WithPath(snykPath),
-		WithStreams(outStream, errStream))
+		WithStreams(outStream, errStream)}, ops...)
+	defaultProvider, err := NewProvider(ops...)
 	assert.NilError(t, err)
 	provider, err := NewSnykProvider(
 		defaultProvider)
 	assert.NilError(t, err)
 	return provider, outStream
 }
+
+func TestVersionKeyDoesNotRunTheBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Can't run this test on windows")
+	}
+	provider, outStream := setupMockSnykBinary(t)
+
+	key, err := VersionKey(provider)
+	assert.NilError(t, err)
+	assert.Assert(t, key != "")
+	assert.Equal(t, outStream.String(), "")
+}
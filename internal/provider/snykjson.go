@@ -0,0 +1,69 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// snykVulnerability and snykResult hold the fields of the Snyk JSON output read by docker scan
+type snykVulnerability struct {
+	ID             string   `json:"id"`
+	Title          string   `json:"title"`
+	Severity       string   `json:"severity"`
+	PackageName    string   `json:"packageName"`
+	Version        string   `json:"version"`
+	From           []string `json:"from"`
+	FixedIn        []string `json:"fixedIn"`
+	PackageManager string   `json:"packageManager"`
+	Path           string   `json:"path,omitempty"`
+	// Layer is the diff ID of the layer which installed the package, when the provider reports it
+	Layer       string              `json:"layer,omitempty"`
+	Identifiers map[string][]string `json:"identifiers"`
+}
+
+type snykResult struct {
+	OK              bool                `json:"ok"`
+	Path            string              `json:"path"`
+	PackageManager  string              `json:"packageManager"`
+	Vulnerabilities []snykVulnerability `json:"vulnerabilities"`
+}
+
+// marshalSnykResults writes the results like Snyk, a single result or a list of results
+func marshalSnykResults(results []snykResult) ([]byte, error) {
+	var out []byte
+	var err error
+	if len(results) == 1 {
+		out, err = json.MarshalIndent(results[0], "", "  ")
+	} else {
+		out, err = json.MarshalIndent(results, "", "  ")
+	}
+	return append(out, '\n'), err
+}
+
+// writeConvertedText prints the converted results like the Snyk text output
+func writeConvertedText(out io.Writer, converted []byte) error {
+	scanReport, err := report.Parse(converted)
+	if err != nil {
+		return err
+	}
+	report.WriteText(out, scanReport)
+	return nil
+}
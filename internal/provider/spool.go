@@ -0,0 +1,119 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// maxProviderStderr is the size of the error output of a provider written as is, beyond it the output is written to a
+// temporary file so that a malfunctioning provider can't exhaust the memory or flood the terminal
+var maxProviderStderr = 64 * 1024
+
+// maxStderrTail is the size of the end of the error output kept in the invocation log
+const maxStderrTail = 4096
+
+// stderrSpool writes the error output of a provider to out up to maxProviderStderr bytes, then spools the whole output
+// to a temporary file
+type stderrSpool struct {
+	mu      sync.Mutex
+	out     io.Writer
+	head    bytes.Buffer
+	tail    []byte
+	file    *os.File
+	fileErr error
+	size    int
+	closed  bool
+}
+
+func newStderrSpool(out io.Writer) *stderrSpool {
+	return &stderrSpool{out: out}
+}
+
+func (s *stderrSpool) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(p)
+	if s.closed {
+		return n, nil
+	}
+	s.size += n
+	s.tail = append(s.tail, p...)
+	if len(s.tail) > maxStderrTail {
+		s.tail = s.tail[len(s.tail)-maxStderrTail:]
+	}
+	if room := maxProviderStderr - s.head.Len(); room > 0 {
+		if room > len(p) {
+			room = len(p)
+		}
+		if _, err := s.out.Write(p[:room]); err != nil {
+			return 0, err
+		}
+		s.head.Write(p[:room])
+		p = p[room:]
+	}
+	if len(p) == 0 || s.fileErr != nil {
+		return n, nil
+	}
+	if s.file == nil {
+		if s.file, s.fileErr = ioutil.TempFile("", "docker-scan-provider-*.log"); s.fileErr != nil {
+			return n, nil
+		}
+		_, s.fileErr = s.file.Write(s.head.Bytes())
+	}
+	if s.fileErr == nil {
+		_, s.fileErr = s.file.Write(p)
+	}
+	return n, nil
+}
+
+// Tail returns the end of the error output, empty for a nil spool
+func (s *stderrSpool) Tail() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return string(s.tail)
+}
+
+// Close tells where the whole error output is when it was truncated, the output written after is dropped
+func (s *stderrSpool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.size <= maxProviderStderr {
+		s.closed = true
+		return nil
+	}
+	s.closed = true
+	if s.file != nil {
+		s.file.Close() //nolint:errcheck
+	}
+	if s.fileErr != nil {
+		_, err := fmt.Fprintf(s.out, "\nThe error output of the provider was truncated after %d of %d bytes: %s\n",
+			maxProviderStderr, s.size, s.fileErr)
+		return err
+	}
+	_, err := fmt.Fprintf(s.out, "\nThe error output of the provider was truncated after %d of %d bytes, the whole output is in %s\n",
+		maxProviderStderr, s.size, s.file.Name())
+	return err
+}
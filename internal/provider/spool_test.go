@@ -0,0 +1,58 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestStderrSpool(t *testing.T) {
+	defer func(saved int) { maxProviderStderr = saved }(maxProviderStderr)
+	maxProviderStderr = 10
+
+	out := bytes.NewBuffer(nil)
+	spool := newStderrSpool(out)
+	fmt.Fprint(spool, "0123456")
+	fmt.Fprint(spool, "789abcdef")
+	fmt.Fprint(spool, "ghij")
+	assert.NilError(t, spool.Close())
+	fmt.Fprint(spool, "dropped")
+
+	lines := strings.Split(out.String(), "\n")
+	assert.Equal(t, lines[0], "0123456789")
+	assert.Assert(t, strings.HasPrefix(lines[1], "The error output of the provider was truncated after 10 of 20 bytes, the whole output is in "))
+	path := strings.TrimPrefix(lines[1], "The error output of the provider was truncated after 10 of 20 bytes, the whole output is in ")
+	defer os.Remove(path) //nolint:errcheck
+	buf, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), "0123456789abcdefghij")
+}
+
+func TestStderrSpoolUnderLimit(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	spool := newStderrSpool(out)
+	fmt.Fprint(spool, "Error: invalid image reference\n")
+	assert.NilError(t, spool.Close())
+	assert.Equal(t, out.String(), "Error: invalid image reference\n")
+}
@@ -0,0 +1,281 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/debug"
+)
+
+// trivySeverities are the Trivy severities reported for each severity threshold
+var trivySeverities = map[string]string{
+	"low":      "UNKNOWN,LOW,MEDIUM,HIGH,CRITICAL",
+	"medium":   "MEDIUM,HIGH,CRITICAL",
+	"high":     "HIGH,CRITICAL",
+	"critical": "CRITICAL",
+}
+
+// trivyPackageManagers maps the Trivy OS families to the package managers reported by Snyk
+var trivyPackageManagers = map[string]string{
+	"alpine":      "apk",
+	"wolfi":       "apk",
+	"debian":      "deb",
+	"ubuntu":      "deb",
+	"distroless":  "deb",
+	"redhat":      "rpm",
+	"centos":      "rpm",
+	"rocky":       "rpm",
+	"alma":        "rpm",
+	"fedora":      "rpm",
+	"amazon":      "rpm",
+	"oracle":      "rpm",
+	"photon":      "rpm",
+	"suse":        "rpm",
+	"opensuse":    "rpm",
+	"cbl-mariner": "rpm",
+}
+
+// trivyVulnerabilitiesExitCode is the exit code Trivy is asked to return when it finds vulnerabilities, as it
+// already exits with 1 on fatal errors like a missing image or a failed database download
+const trivyVulnerabilitiesExitCode = 3
+
+type trivyProvider struct {
+	Options
+}
+
+// NewTrivyProvider returns a Trivy implementation of scan provider, running the trivy binary found in the PATH.
+// The scan flags are translated to Trivy flags and its JSON output to the Snyk JSON output.
+func NewTrivyProvider(defaultProvider Options) (Provider, error) {
+	path, err := exec.LookPath("trivy")
+	if err != nil {
+		return nil, classify(ErrBinaryNotFound, nil, "could not find Trivy binary, please install Trivy: https://aquasecurity.github.io/trivy")
+	}
+	defaultProvider.path = path
+	return &trivyProvider{Options: defaultProvider}, nil
+}
+
+// Authenticate does nothing, Trivy doesn't require any authentication
+func (t *trivyProvider) Authenticate(token string) error {
+	return nil
+}
+
+func (t *trivyProvider) Scan(image string) error {
+	return t.scan("image", image)
+}
+
+// ScanSBOM matches the packages of a CycloneDX or SPDX SBOM against the vulnerabilities
+func (t *trivyProvider) ScanSBOM(path string) error {
+	return t.scan("sbom", path)
+}
+
+// scan runs the given Trivy command on the target
+func (t *trivyProvider) scan(command, target string) error {
+	args, jsonOutput := trivyArgs(command, t.flags)
+	if !jsonOutput {
+		cmd := t.newCommand(append(args, "--exit-code", strconv.Itoa(trivyVulnerabilitiesExitCode), target)...)
+		cmd.Stdout = t.out
+		cmd.Stderr = t.err
+		defer logCommand(cmd.Args)()
+		err := runCommand(t.context, cmd)
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if exitErr.ExitCode() == trivyVulnerabilitiesExitCode {
+				return scanResult(1)
+			}
+			return &providerFailedError{exitCode: exitErr.ExitCode()}
+		}
+		return checkTrivyCommandErr(err)
+	}
+	out := bytes.NewBuffer(nil)
+	cmd := t.newCommand(append(args, "--format", "json", target)...)
+	cmd.Stdout = out
+	cmd.Stderr = t.err
+	done := logCommand(cmd.Args)
+	err := runCommand(t.context, cmd)
+	done()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return &providerFailedError{exitCode: exitErr.ExitCode()}
+	}
+	if err := checkTrivyCommandErr(err); err != nil {
+		return err
+	}
+	converted, found, err := convertTrivyReport(out.Bytes())
+	if err != nil {
+		return err
+	}
+	if _, err := t.out.Write(converted); err != nil {
+		return err
+	}
+	if found {
+		return scanResult(1)
+	}
+	return nil
+}
+
+func (t *trivyProvider) Version() (string, error) {
+	cmd := t.newCommand("--version")
+	buff := bytes.NewBuffer(nil)
+	cmd.Stdout = buff
+	defer logCommand(cmd.Args)()
+	if err := runCommand(t.context, cmd); err != nil {
+		return "", fmt.Errorf("failed to get trivy version: %s", checkTrivyCommandErr(err))
+	}
+	return fmt.Sprintf("Trivy (%s)", trivyVersion(buff.String())), nil
+}
+
+func (t *trivyProvider) newCommand(arg ...string) *exec.Cmd {
+	cmd := exec.Command(t.path, arg...)
+	cmd.Env = providerEnvironment(t.environment)
+	if t.caCert != "" {
+		cmd.Env = append(cmd.Env, "SSL_CERT_FILE="+t.caCert)
+	}
+	return cmd
+}
+
+// trivyArgs translates the Snyk flags of the scan to the given Trivy command,
+// and returns whether the JSON output is expected. Flags without Trivy equivalent are ignored.
+func trivyArgs(command string, flags []string) ([]string, bool) {
+	args := []string{command, "--quiet"}
+	jsonOutput := false
+	for _, flag := range flags {
+		name, value := flag, ""
+		if parts := strings.SplitN(flag, "=", 2); len(parts) == 2 {
+			name, value = parts[0], parts[1]
+		}
+		switch name {
+		case "container", "test":
+		case "--json":
+			jsonOutput = true
+		case "--severity-threshold":
+			args = append(args, "--severity", trivySeverities[value])
+		case "--exclude-app-vulns":
+			args = append(args, "--pkg-types", "os")
+		case "--app-vulns":
+			args = append(args, "--pkg-types", "os,library")
+		case "--platform":
+			args = append(args, "--platform", value)
+		default:
+			debug.Log("ignoring flag without Trivy equivalent", "flag", name)
+		}
+	}
+	return args, jsonOutput
+}
+
+func trivyVersion(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "Version:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Version:"))
+		}
+	}
+	return strings.TrimSpace(output)
+}
+
+func checkTrivyCommandErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*exec.Error); ok {
+		return classify(ErrBinaryNotFound, err, "could not find Trivy binary")
+	}
+	return err
+}
+
+type trivyReport struct {
+	ArtifactName string `json:"ArtifactName"`
+	Results      []struct {
+		Target          string               `json:"Target"`
+		Class           string               `json:"Class"`
+		Type            string               `json:"Type"`
+		Vulnerabilities []trivyVulnerability `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+type trivyVulnerability struct {
+	VulnerabilityID  string   `json:"VulnerabilityID"`
+	PkgName          string   `json:"PkgName"`
+	PkgPath          string   `json:"PkgPath"`
+	InstalledVersion string   `json:"InstalledVersion"`
+	FixedVersion     string   `json:"FixedVersion"`
+	Title            string   `json:"Title"`
+	Severity         string   `json:"Severity"`
+	CweIDs           []string `json:"CweIDs"`
+	Layer            struct {
+		DiffID string `json:"DiffID"`
+	} `json:"Layer"`
+}
+
+// convertTrivyReport converts the Trivy JSON report to the Snyk JSON output, a result per Trivy target,
+// and returns whether vulnerabilities were found
+func convertTrivyReport(document []byte) ([]byte, bool, error) {
+	var report trivyReport
+	if err := json.Unmarshal(document, &report); err != nil {
+		return nil, false, fmt.Errorf("invalid trivy output: %s", err)
+	}
+	results := []snykResult{}
+	found := false
+	for _, target := range report.Results {
+		packageManager := target.Type
+		if target.Class == "os-pkgs" {
+			packageManager = trivyPackageManagers[target.Type]
+		}
+		result := snykResult{Path: report.ArtifactName, PackageManager: packageManager, Vulnerabilities: []snykVulnerability{}}
+		for _, vuln := range target.Vulnerabilities {
+			result.Vulnerabilities = append(result.Vulnerabilities, convertTrivyVulnerability(vuln, packageManager))
+		}
+		result.OK = len(result.Vulnerabilities) == 0
+		found = found || !result.OK
+		results = append(results, result)
+	}
+	out, err := marshalSnykResults(results)
+	return out, found, err
+}
+
+func convertTrivyVulnerability(trivyVuln trivyVulnerability, packageManager string) snykVulnerability {
+	vuln := snykVulnerability{
+		ID:             trivyVuln.VulnerabilityID,
+		Title:          trivyVuln.Title,
+		Severity:       strings.ToLower(trivyVuln.Severity),
+		PackageName:    trivyVuln.PkgName,
+		Version:        trivyVuln.InstalledVersion,
+		From:           []string{trivyVuln.PkgName + "@" + trivyVuln.InstalledVersion},
+		PackageManager: packageManager,
+		Path:           trivyVuln.PkgPath,
+		Layer:          trivyVuln.Layer.DiffID,
+		Identifiers:    map[string][]string{},
+	}
+	if vuln.Title == "" {
+		vuln.Title = vuln.ID
+	}
+	if vuln.Severity == "unknown" || vuln.Severity == "" {
+		vuln.Severity = "low"
+	}
+	if trivyVuln.FixedVersion != "" {
+		vuln.FixedIn = strings.Split(trivyVuln.FixedVersion, ", ")
+	}
+	if strings.HasPrefix(vuln.ID, "CVE-") {
+		vuln.Identifiers["CVE"] = []string{vuln.ID}
+	}
+	if len(trivyVuln.CweIDs) > 0 {
+		vuln.Identifiers["CWE"] = trivyVuln.CweIDs
+	}
+	return vuln
+}
@@ -0,0 +1,137 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+)
+
+var (
+	_ Provider = &trivyProvider{}
+)
+
+func TestTrivyArgs(t *testing.T) {
+	args, jsonOutput := trivyArgs("image", []string{"container", "test", "--json", "--severity-threshold=high", "--exclude-app-vulns", "--print-deps", "--platform=linux/arm64"})
+	assert.Assert(t, jsonOutput)
+	assert.DeepEqual(t, args, []string{"image", "--quiet", "--severity", "HIGH,CRITICAL", "--pkg-types", "os", "--platform", "linux/arm64"})
+
+	args, jsonOutput = trivyArgs("sbom", []string{"container", "test"})
+	assert.Assert(t, !jsonOutput)
+	assert.DeepEqual(t, args, []string{"sbom", "--quiet"})
+}
+
+func TestConvertTrivyReport(t *testing.T) {
+	document, err := ioutil.ReadFile("testdata/trivy/report.json")
+	assert.NilError(t, err)
+	converted, found, err := convertTrivyReport(document)
+	assert.NilError(t, err)
+	assert.Assert(t, found)
+
+	// the converted output is read like the Snyk output
+	scanReport, err := report.Parse(converted)
+	assert.NilError(t, err)
+	assert.Equal(t, scanReport.Path, "myapp:latest")
+	assert.DeepEqual(t, scanReport.Vulnerabilities, []report.Vulnerability{
+		{
+			ID:             "CVE-2021-3711",
+			Title:          "openssl: SM2 Decryption Buffer Overflow",
+			Severity:       "critical",
+			PackageName:    "libssl1.1",
+			Version:        "1.1.1k-r0",
+			From:           []string{"libssl1.1@1.1.1k-r0"},
+			FixedIn:        []string{"1.1.1l-r0"},
+			PackageManager: "apk",
+			Layer:          "sha256:e2eb06d8af8218cfec8210147357a68b7e13f7c485b991c288c2d01dc228bb68",
+			Identifiers:    map[string][]string{"CVE": {"CVE-2021-3711"}, "CWE": {"CWE-120"}},
+		},
+		{
+			ID:             "GHSA-35jh-r3h4-6jhm",
+			Title:          "GHSA-35jh-r3h4-6jhm",
+			Severity:       "low",
+			PackageName:    "lodash",
+			Version:        "4.17.15",
+			From:           []string{"lodash@4.17.15"},
+			FixedIn:        []string{"4.17.21", "4.17.20"},
+			PackageManager: "npm",
+			Path:           "app/node_modules/lodash/package.json",
+			Identifiers:    map[string][]string{},
+		},
+	})
+}
+
+func TestConvertTrivyReportWithoutVulnerabilities(t *testing.T) {
+	converted, found, err := convertTrivyReport([]byte(`{"ArtifactName":"alpine","Results":[{"Target":"alpine","Class":"os-pkgs","Type":"alpine"}]}`))
+	assert.NilError(t, err)
+	assert.Assert(t, !found)
+	scanReport, err := report.Parse(converted)
+	assert.NilError(t, err)
+	assert.Equal(t, len(scanReport.Vulnerabilities), 0)
+}
+
+func TestTrivyVersion(t *testing.T) {
+	assert.Equal(t, trivyVersion("Version: 0.45.1\nVulnerability DB:\n  Version: 2\n"), "0.45.1")
+}
+
+// setupFakeTrivy puts in the PATH a trivy script exiting with the given code
+func setupFakeTrivy(t *testing.T, exitCode string) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho 'FATAL image scan error' >&2\nexit " + exitCode + "\n"
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "trivy"), []byte(script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestTrivyFailureIsNotVulnerabilities(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Can't run this test on windows")
+	}
+	setupFakeTrivy(t, "1")
+	for _, jsonOutput := range []bool{false, true} {
+		ops := []Ops{WithStreams(ioutil.Discard, bytes.NewBuffer(nil))}
+		if jsonOutput {
+			ops = append(ops, WithJSON())
+		}
+		defaultProvider, err := NewProvider(ops...)
+		assert.NilError(t, err)
+		trivy, err := NewTrivyProvider(defaultProvider)
+		assert.NilError(t, err)
+
+		err = trivy.Scan("missing:image")
+		assert.Assert(t, IsProviderFailedError(err), "json output: %t", jsonOutput)
+		assert.Assert(t, !IsVulnerabilitiesFoundError(err), "json output: %t", jsonOutput)
+	}
+}
+
+func TestTrivyVulnerabilitiesExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Can't run this test on windows")
+	}
+	setupFakeTrivy(t, "3")
+	defaultProvider, err := NewProvider(WithStreams(ioutil.Discard, ioutil.Discard))
+	assert.NilError(t, err)
+	trivy, err := NewTrivyProvider(defaultProvider)
+	assert.NilError(t, err)
+
+	assert.Assert(t, IsVulnerabilitiesFoundError(trivy.Scan("alpine")))
+}
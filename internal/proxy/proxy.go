@@ -0,0 +1,170 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/debug"
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/idna"
+)
+
+// EnvVars are the environment variables configuring the proxy used for outbound calls
+var EnvVars = []string{
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+	"http_proxy", "https_proxy", "no_proxy",
+}
+
+// Environment returns the proxy environment variables set for the current process, with their internationalized
+// domain names converted to ASCII for the providers which can't resolve them
+func Environment() []string {
+	var env []string
+	for _, name := range EnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+asciiValue(name, value))
+		}
+	}
+	return env
+}
+
+// FromEnvironment returns the proxy configuration of the environment, with its internationalized domain names
+// converted to ASCII, as the NO_PROXY domains are otherwise compared to the ASCII form of the requested hosts
+func FromEnvironment() *httpproxy.Config {
+	config := httpproxy.FromEnvironment()
+	config.HTTPProxy = asciiProxyURL(config.HTTPProxy)
+	config.HTTPSProxy = asciiProxyURL(config.HTTPSProxy)
+	config.NoProxy = asciiNoProxy(config.NoProxy)
+	return config
+}
+
+// URL returns the proxy used to reach the URL, nil for a direct connection
+func URL(target *url.URL) (*url.URL, error) {
+	return FromEnvironment().ProxyFunc()(target)
+}
+
+// ASCIIHost returns the host, with its port if any, with its internationalized domain name converted to the ASCII
+// form used by DNS. IP addresses, IPv6 literals included, and invalid names are returned unchanged.
+func ASCIIHost(host string) string {
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		name, port = host, ""
+	}
+	if strings.HasPrefix(name, "[") || net.ParseIP(name) != nil {
+		return host
+	}
+	ascii, err := idna.Lookup.ToASCII(name)
+	if err != nil {
+		return host
+	}
+	if port == "" {
+		return ascii
+	}
+	return net.JoinHostPort(ascii, port)
+}
+
+func asciiValue(name, value string) string {
+	if strings.EqualFold(name, "NO_PROXY") {
+		return asciiNoProxy(value)
+	}
+	return asciiProxyURL(value)
+}
+
+// asciiProxyURL converts the host of a proxy URL, keeping the URLs without scheme, like host:3128, without scheme
+func asciiProxyURL(value string) string {
+	if value == "" {
+		return value
+	}
+	scheme := ""
+	if !strings.Contains(value, "://") {
+		scheme = "http://"
+	}
+	proxyURL, err := url.Parse(scheme + value)
+	if err != nil {
+		return value
+	}
+	host := ASCIIHost(proxyURL.Host)
+	if host == proxyURL.Host {
+		return value
+	}
+	proxyURL.Host = host
+	return strings.TrimPrefix(proxyURL.String(), scheme)
+}
+
+func asciiNoProxy(value string) string {
+	entries := strings.Split(value, ",")
+	for i, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		// .example.com matches the subdomains, and the CIDR blocks and * are kept as is
+		if entry == "*" || strings.Contains(entry, "/") {
+			continue
+		}
+		dot := ""
+		if strings.HasPrefix(entry, ".") {
+			dot, entry = ".", entry[1:]
+		}
+		entries[i] = dot + ASCIIHost(entry)
+	}
+	return strings.Join(entries, ",")
+}
+
+// NewHTTPClient returns an HTTP client using the proxy configured in the environment
+// and trusting the certificates of the given CA bundle in addition to the system ones
+func NewHTTPClient(caCert string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	proxyFunc := FromEnvironment().ProxyFunc()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+	if caCert != "" {
+		pool, err := CertPool(caCert)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	return &http.Client{Transport: debug.Transport(transport)}, nil
+}
+
+// CertPool returns the system certificates with the certificates of the given CA bundle,
+// for the outbound calls not made over HTTP
+func CertPool(caCert string) (*x509.CertPool, error) {
+	buf, err := ioutil.ReadFile(caCert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %s", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		// the system pool can't be loaded on every platform
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(buf) {
+		return nil, fmt.Errorf("no PEM certificate found in %s", caCert)
+	}
+	return pool, nil
+}
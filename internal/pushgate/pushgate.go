@@ -0,0 +1,99 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pushgate
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	cliConfig "github.com/docker/cli/cli/config"
+	"github.com/docker/scan-cli-plugin/internal/filelock"
+)
+
+// Result is the outcome of the last scan of an image
+type Result struct {
+	ImageID   string    `json:"imageId"`
+	Ref       string    `json:"ref"`
+	ScannedAt time.Time `json:"scannedAt"`
+	Passed    bool      `json:"passed"`
+	// Filters describes the options which filtered the findings of the scan, like its severity threshold
+	Filters string `json:"filters,omitempty"`
+}
+
+// AllowsPush returns true if the image passed a scan which is not older than maxAge, with the same filters
+// as the ones required to push
+func (r Result) AllowsPush(now time.Time, maxAge time.Duration, filters string) bool {
+	return r.Passed && now.Sub(r.ScannedAt) <= maxAge && r.Filters == filters
+}
+
+// Store persists the scan results, indexed by image ID
+type Store struct {
+	path string
+}
+
+// NewStore returns the store saving the scan results in ${DOCKER_CONFIG}/scan/results.json
+func NewStore() *Store {
+	return &Store{path: filepath.Join(cliConfig.Dir(), "scan", "results.json")}
+}
+
+// Get returns the last scan result of an image
+func (s *Store) Get(imageID string) (Result, bool, error) {
+	results, err := s.read()
+	if err != nil {
+		return Result{}, false, err
+	}
+	result, ok := results[imageID]
+	return result, ok, nil
+}
+
+// Save records the result of a scan, replacing the previous one of the same image
+func (s *Store) Save(result Result) error {
+	unlock, err := filelock.Lock(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	results, err := s.read()
+	if err != nil {
+		return err
+	}
+	results[result.ImageID] = result
+	buf, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	return filelock.WriteFile(s.path, buf, 0644)
+}
+
+func (s *Store) read() (map[string]Result, error) {
+	results := map[string]Result{}
+	buf, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return results, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
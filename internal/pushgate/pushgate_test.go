@@ -0,0 +1,77 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package pushgate
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestStore(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	store := &Store{path: dir.Join("scan", "results.json")}
+
+	_, ok, err := store.Get("sha256:image")
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+
+	scannedAt := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	assert.NilError(t, store.Save(Result{ImageID: "sha256:image", Ref: "myimage", ScannedAt: scannedAt}))
+	assert.NilError(t, store.Save(Result{ImageID: "sha256:image", Ref: "myimage:latest", ScannedAt: scannedAt, Passed: true}))
+	assert.NilError(t, store.Save(Result{ImageID: "sha256:other", Ref: "other", ScannedAt: scannedAt}))
+
+	result, ok, err := store.Get("sha256:image")
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, result, Result{ImageID: "sha256:image", Ref: "myimage:latest", ScannedAt: scannedAt, Passed: true})
+}
+
+func TestAllowsPush(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		result   Result
+		expected bool
+	}{
+		{
+			name:     "fresh passing scan",
+			result:   Result{Passed: true, ScannedAt: now.Add(-time.Hour)},
+			expected: true,
+		},
+		{
+			name:   "outdated passing scan",
+			result: Result{Passed: true, ScannedAt: now.Add(-48 * time.Hour)},
+		},
+		{
+			name:   "fresh failing scan",
+			result: Result{ScannedAt: now},
+		},
+		{
+			name:   "fresh passing scan with other filters",
+			result: Result{Passed: true, ScannedAt: now, Filters: "severity=high"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.result.AllowsPush(now, 24*time.Hour, ""), testCase.expected)
+		})
+	}
+}
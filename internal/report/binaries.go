@@ -0,0 +1,51 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Binary is an executable of the image which is not owned by an OS package
+type Binary struct {
+	Path    string `json:"path"`
+	Layer   string `json:"layer"`
+	Format  string `json:"format"`
+	SHA256  string `json:"sha256"`
+	Project string `json:"project,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// WriteBinaries prints the standalone binaries found in the image as a table
+func WriteBinaries(out io.Writer, binaries []Binary) error {
+	fmt.Fprintf(out, "\nFound %d standalone binaries, not managed by the OS package manager\n", len(binaries))
+	if len(binaries) == 0 {
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "PATH\tPROJECT\tVERSION\tSHA256")
+	for _, binary := range binaries {
+		project, version := binary.Project, binary.Version
+		if project == "" {
+			project, version = "unknown", "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", binary.Path, project, version, binary.SHA256)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,38 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteBinaries(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, WriteBinaries(out, []Binary{
+		{Path: "/usr/local/bin/curl", Project: "curl", Version: "7.79.1", SHA256: "abcd"},
+		{Path: "/app/server", SHA256: "ef01"},
+	}))
+	assert.Equal(t, out.String(), `
+Found 2 standalone binaries, not managed by the OS package manager
+PATH                  PROJECT   VERSION   SHA256
+/usr/local/bin/curl   curl      7.79.1    abcd
+/app/server           unknown   -         ef01
+`)
+}
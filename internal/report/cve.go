@@ -0,0 +1,70 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/nvd"
+)
+
+// CachedMatch lists the findings of a cached scan of an image matching a CVE
+type CachedMatch struct {
+	Image     string          `json:"image"`
+	ScannedAt time.Time       `json:"scannedAt"`
+	Findings  []Vulnerability `json:"findings"`
+}
+
+// WriteCVE prints the details of a CVE and the cached scans reporting it
+func WriteCVE(out io.Writer, cve nvd.CVE, matches []CachedMatch) {
+	fmt.Fprintf(out, "%s\n", cve.ID)
+	if cve.Published != "" {
+		fmt.Fprintf(out, "  Published: %s\n", cve.Published)
+	}
+	if cve.CVSS != nil {
+		fmt.Fprintf(out, "  CVSS %s: %.1f %s (%s)\n", cve.CVSS.Version, cve.CVSS.Score, cve.CVSS.Severity, cve.CVSS.Vector)
+	}
+	fmt.Fprintf(out, "  Description: %s\n", cve.Description)
+	if len(cve.Affected) > 0 {
+		fmt.Fprintln(out, "  Affected:")
+		for _, affected := range cve.Affected {
+			fmt.Fprintf(out, "    %s %s: %s\n", affected.Vendor, affected.Product, affected.Versions)
+		}
+	}
+	for _, reference := range cve.References {
+		fmt.Fprintf(out, "  Reference: %s\n", reference)
+	}
+	if len(matches) == 0 {
+		fmt.Fprintln(out, "\nNot found in the cached scan results")
+		return
+	}
+	scans := "scans"
+	if len(matches) == 1 {
+		scans = "scan"
+	}
+	fmt.Fprintf(out, "\nFound in %d cached %s\n", len(matches), scans)
+	for _, match := range matches {
+		var packages []string
+		for _, finding := range match.Findings {
+			packages = append(packages, packageVersion(finding))
+		}
+		fmt.Fprintf(out, "  ✗ %s (scanned at %s): %s\n", match.Image, match.ScannedAt.Format(time.RFC3339), strings.Join(packages, ", "))
+	}
+}
@@ -0,0 +1,64 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/nvd"
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteCVE(t *testing.T) {
+	cve := nvd.CVE{
+		ID:          "CVE-2021-3711",
+		Published:   "2021-08-24T15:15:09.133",
+		Description: "SM2 Decryption Buffer Overflow",
+		CVSS:        &nvd.CVSS{Version: "3.1", Vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", Score: 9.8, Severity: "critical"},
+		Affected:    []nvd.Affected{{Vendor: "openssl", Product: "openssl", Versions: ">= 1.1.1, < 1.1.1l"}},
+		References:  []string{"https://www.openssl.org/news/secadv/20210824.txt"},
+	}
+	matches := []CachedMatch{{
+		Image:     "myapp:1.0",
+		ScannedAt: time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC),
+		Findings:  []Vulnerability{{ID: "SNYK-DEBIAN10-OPENSSL-1569403", PackageName: "openssl/libssl1.1", Version: "1.1.1d-0+deb10u6"}},
+	}}
+
+	out := bytes.NewBuffer(nil)
+	WriteCVE(out, cve, matches)
+	assert.Equal(t, out.String(), `CVE-2021-3711
+  Published: 2021-08-24T15:15:09.133
+  CVSS 3.1: 9.8 critical (CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H)
+  Description: SM2 Decryption Buffer Overflow
+  Affected:
+    openssl openssl: >= 1.1.1, < 1.1.1l
+  Reference: https://www.openssl.org/news/secadv/20210824.txt
+
+Found in 1 cached scan
+  ✗ myapp:1.0 (scanned at 2021-09-01T10:00:00Z): openssl/libssl1.1@1.1.1d-0+deb10u6
+`)
+
+	out.Reset()
+	WriteCVE(out, nvd.CVE{ID: "CVE-2021-3711", Description: "SM2 Decryption Buffer Overflow"}, nil)
+	assert.Equal(t, out.String(), `CVE-2021-3711
+  Description: SM2 Decryption Buffer Overflow
+
+Not found in the cached scan results
+`)
+}
@@ -0,0 +1,111 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/docker/scan-cli-plugin/internal/theme"
+)
+
+// Diff compares the vulnerabilities of two scans of an image, returning the ones introduced
+// by the current scan and the ones fixed since the previous one
+func Diff(previous, current Report) ([]Vulnerability, []Vulnerability) {
+	previousKeys := vulnerabilityKeys(previous)
+	currentKeys := vulnerabilityKeys(current)
+	return missingVulnerabilities(current, previousKeys), missingVulnerabilities(previous, currentKeys)
+}
+
+// Unchanged returns the vulnerabilities of the current scan also found by the previous one, once per vulnerability
+// and package
+func Unchanged(previous, current Report) []Vulnerability {
+	var unchanged []Vulnerability
+	previousKeys := vulnerabilityKeys(previous)
+	seen := map[string]bool{}
+	for _, vuln := range current.Vulnerabilities {
+		key := vulnerabilityKey(vuln)
+		if !previousKeys[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unchanged = append(unchanged, vuln)
+	}
+	return unchanged
+}
+
+// WriteImageDiff prints the vulnerabilities added, removed and kept by the target image compared to the base image
+func WriteImageDiff(out io.Writer, base, target string, added, removed, unchanged []Vulnerability) {
+	fmt.Fprintf(out, "\nComparing %s to %s: %d added, %d removed, %d unchanged\n", target, base, len(added), len(removed), len(unchanged))
+	for _, vuln := range added {
+		fmt.Fprintf(out, "  + %s %s in %s@%s (%s)\n", severityLabel(vuln), vuln.Title, vuln.PackageName, vuln.Version, vuln.ID)
+	}
+	for _, vuln := range removed {
+		fmt.Fprintf(out, "  - %s %s in %s@%s (%s)\n", severityLabel(vuln), vuln.Title, vuln.PackageName, vuln.Version, vuln.ID)
+	}
+	for _, vuln := range unchanged {
+		fmt.Fprintf(out, "  = %s %s in %s@%s (%s)\n", severityLabel(vuln), vuln.Title, vuln.PackageName, vuln.Version, vuln.ID)
+	}
+}
+
+// WriteDiff prints the introduced and fixed vulnerabilities
+func WriteDiff(out io.Writer, introduced, fixed []Vulnerability) {
+	if len(introduced) == 0 && len(fixed) == 0 {
+		fmt.Fprintln(out, "\nNo changes since the previous scan")
+		return
+	}
+	fmt.Fprintf(out, "\nChanges since the previous scan: %d new, %d fixed\n", len(introduced), len(fixed))
+	for _, vuln := range introduced {
+		fmt.Fprintf(out, "  + %s %s in %s@%s (%s)\n", severityLabel(vuln), vuln.Title, vuln.PackageName, vuln.Version, vuln.ID)
+	}
+	for _, vuln := range fixed {
+		fmt.Fprintf(out, "  - %s %s in %s@%s (%s)\n", severityLabel(vuln), vuln.Title, vuln.PackageName, vuln.Version, vuln.ID)
+	}
+}
+
+// severityLabel returns the severity of the vulnerability between brackets, colored by the theme
+func severityLabel(vuln Vulnerability) string {
+	return theme.Current().Severity(vuln.Severity, "["+vuln.Severity+"]")
+}
+
+// vulnerabilityKey identifies a vulnerability of a package, whatever the dependency path or version of the package
+func vulnerabilityKey(vuln Vulnerability) string {
+	return vuln.ID + "@" + vuln.PackageName
+}
+
+func vulnerabilityKeys(report Report) map[string]bool {
+	keys := map[string]bool{}
+	for _, vuln := range report.Vulnerabilities {
+		keys[vulnerabilityKey(vuln)] = true
+	}
+	return keys
+}
+
+// missingVulnerabilities returns the vulnerabilities of the report whose keys are missing, once per key
+func missingVulnerabilities(report Report, keys map[string]bool) []Vulnerability {
+	var missing []Vulnerability
+	seen := map[string]bool{}
+	for _, vuln := range report.Vulnerabilities {
+		key := vulnerabilityKey(vuln)
+		if keys[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		missing = append(missing, vuln)
+	}
+	return missing
+}
@@ -0,0 +1,73 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDiff(t *testing.T) {
+	opensslOld := Vulnerability{ID: "SNYK-1", Title: "Overflow", Severity: "high", PackageName: "openssl", Version: "1.1.1d"}
+	opensslNew := Vulnerability{ID: "SNYK-1", Title: "Overflow", Severity: "high", PackageName: "openssl", Version: "1.1.1g"}
+	zlib := Vulnerability{ID: "SNYK-2", Title: "Memory corruption", Severity: "medium", PackageName: "zlib", Version: "1.2.11"}
+	curl := Vulnerability{ID: "SNYK-3", Title: "Use after free", Severity: "low", PackageName: "curl", Version: "7.64.0"}
+
+	previous := Report{Vulnerabilities: []Vulnerability{opensslOld, zlib, zlib}}
+	current := Report{Vulnerabilities: []Vulnerability{opensslNew, curl, curl}}
+
+	introduced, fixed := Diff(previous, current)
+	assert.DeepEqual(t, introduced, []Vulnerability{curl})
+	assert.DeepEqual(t, fixed, []Vulnerability{zlib})
+
+	out := bytes.NewBuffer(nil)
+	WriteDiff(out, introduced, fixed)
+	assert.Equal(t, out.String(), `
+Changes since the previous scan: 1 new, 1 fixed
+  + [low] Use after free in curl@7.64.0 (SNYK-3)
+  - [medium] Memory corruption in zlib@1.2.11 (SNYK-2)
+`)
+
+	out.Reset()
+	WriteDiff(out, nil, nil)
+	assert.Equal(t, out.String(), "\nNo changes since the previous scan\n")
+}
+
+func TestImageDiff(t *testing.T) {
+	opensslOld := Vulnerability{ID: "SNYK-1", Title: "Overflow", Severity: "high", PackageName: "openssl", Version: "1.1.1d"}
+	opensslNew := Vulnerability{ID: "SNYK-1", Title: "Overflow", Severity: "high", PackageName: "openssl", Version: "1.1.1g"}
+	zlib := Vulnerability{ID: "SNYK-2", Title: "Memory corruption", Severity: "medium", PackageName: "zlib", Version: "1.2.11"}
+	curl := Vulnerability{ID: "SNYK-3", Title: "Use after free", Severity: "low", PackageName: "curl", Version: "7.64.0"}
+
+	base := Report{Vulnerabilities: []Vulnerability{opensslOld, zlib}}
+	target := Report{Vulnerabilities: []Vulnerability{opensslNew, opensslNew, curl}}
+
+	added, removed := Diff(base, target)
+	unchanged := Unchanged(base, target)
+	assert.DeepEqual(t, unchanged, []Vulnerability{opensslNew})
+
+	out := bytes.NewBuffer(nil)
+	WriteImageDiff(out, "myapp:1.0", "myapp:1.1", added, removed, unchanged)
+	assert.Equal(t, out.String(), `
+Comparing myapp:1.1 to myapp:1.0: 1 added, 1 removed, 1 unchanged
+  + [low] Use after free in curl@7.64.0 (SNYK-3)
+  - [medium] Memory corruption in zlib@1.2.11 (SNYK-2)
+  = [high] Overflow in openssl@1.1.1g (SNYK-1)
+`)
+}
@@ -0,0 +1,52 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import "encoding/json"
+
+// LocateDocument adds the dockerfileStage and dockerfileLine fields to the vulnerabilities of the provider JSON
+// output, the locate function returning the stage and the line of their Dockerfile instruction
+func LocateDocument(document []byte, locate func(instruction string) (string, int, bool)) ([]byte, error) {
+	return rewriteDocument(document, func(result map[string]json.RawMessage) error {
+		raw, ok := result["vulnerabilities"]
+		if !ok {
+			return nil
+		}
+		var vulns []map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &vulns); err != nil {
+			return err
+		}
+		for _, rawVuln := range vulns {
+			var instruction string
+			if rawInstruction, ok := rawVuln["dockerfileInstruction"]; !ok || json.Unmarshal(rawInstruction, &instruction) != nil {
+				continue
+			}
+			stage, line, ok := locate(instruction)
+			if !ok {
+				continue
+			}
+			rawVuln["dockerfileStage"], _ = json.Marshal(stage)
+			rawVuln["dockerfileLine"], _ = json.Marshal(line)
+		}
+		buf, err := json.Marshal(vulns)
+		if err != nil {
+			return err
+		}
+		result["vulnerabilities"] = buf
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLocateDocument(t *testing.T) {
+	document := []byte(`{
+  "vulnerabilities": [
+    {"id": "SNYK-GOLANG-1", "dockerfileInstruction": "COPY --from=builder /app /app"},
+    {"id": "SNYK-ALPINE312-CURL-1", "dockerfileInstruction": "RUN apk add wget"},
+    {"id": "SNYK-ALPINE312-MUSL-1", "dockerBaseImage": "alpine:3.12"}
+  ]
+}`)
+	located, err := LocateDocument(document, func(instruction string) (string, int, bool) {
+		return "builder", 6, instruction == "COPY --from=builder /app /app"
+	})
+	assert.NilError(t, err)
+	var result struct {
+		Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	}
+	assert.NilError(t, json.Unmarshal(located, &result))
+	assert.Equal(t, result.Vulnerabilities[0].DockerfileStage, "builder")
+	assert.Equal(t, result.Vulnerabilities[0].DockerfileLine, 6)
+	assert.Equal(t, result.Vulnerabilities[1].DockerfileLine, 0)
+	assert.Equal(t, result.Vulnerabilities[2].DockerfileStage, "")
+}
+
+func TestWriteTextDockerfileLocation(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	WriteText(out, Report{
+		Path: "myapp:1.0",
+		Vulnerabilities: []Vulnerability{{
+			ID:                    "custom-id",
+			Title:                 "Denial of Service",
+			Severity:              "medium",
+			PackageName:           "golang.org/x/net",
+			Version:               "0.0.1",
+			DockerfileInstruction: "COPY --from=builder /app /app",
+			DockerfileStage:       "builder",
+			DockerfileLine:        6,
+		}},
+	})
+	assert.Equal(t, out.String(), `
+Testing myapp:1.0...
+
+✗ Medium severity vulnerability found in golang.org/x/net
+  Description: Denial of Service
+  Info: custom-id
+  Dockerfile: line 6, stage builder: COPY --from=builder /app /app
+
+Tested myapp:1.0, found 1 issue.
+`)
+}
@@ -0,0 +1,89 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Types of the events streamed with --format ndjson
+const (
+	ScanStartedEvent   = "scan-started"
+	LayerAnalyzedEvent = "layer-analyzed"
+	FindingEvent       = "finding"
+	SummaryEvent       = "summary"
+)
+
+// Event is a step of a scan, streamed as a line of newline-delimited JSON
+type Event struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	Image    string    `json:"image,omitempty"`
+	Provider string    `json:"provider,omitempty"`
+	// Layer is the position of the analyzed layer in the image, starting at 1
+	Layer   int    `json:"layer,omitempty"`
+	LayerID string `json:"layerId,omitempty"`
+	// Findings is the number of findings of the analyzed layer, or of the scan in the summary
+	Findings *int           `json:"findings,omitempty"`
+	Finding  *Vulnerability `json:"finding,omitempty"`
+	Counts   map[string]int `json:"counts,omitempty"`
+}
+
+// WriteEvent writes the event on a line, and flushes it at once, so the consumers can process it before the scan ends
+func WriteEvent(out io.Writer, event Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	return json.NewEncoder(out).Encode(event)
+}
+
+// FindingEvents returns an event per finding
+func FindingEvents(image string, findings []Vulnerability) []Event {
+	var events []Event
+	for i := range findings {
+		events = append(events, Event{Type: FindingEvent, Image: image, Finding: &findings[i]})
+	}
+	return events
+}
+
+// LayerEvents returns an event per analyzed layer, with the number of findings located in the layer
+func LayerEvents(image string, layerIDs []string, findings []Vulnerability) []Event {
+	var events []Event
+	for i, layerID := range layerIDs {
+		count := 0
+		for _, finding := range findings {
+			if finding.Layer == layerID {
+				count++
+			}
+		}
+		events = append(events, Event{Type: LayerAnalyzedEvent, Image: image, Layer: i + 1, LayerID: layerID, Findings: &count})
+	}
+	return events
+}
+
+// SummaryEventOf returns the last event of a scan, with the number of findings per severity
+func SummaryEventOf(image string, findings []Vulnerability) Event {
+	counts := CountBySeverity(findings)
+	total := len(findings)
+	summary := Event{Type: SummaryEvent, Image: image, Findings: &total, Counts: map[string]int{}}
+	for _, severity := range Severities {
+		summary.Counts[severity] = counts[severity]
+	}
+	return summary
+}
@@ -0,0 +1,54 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestEvents(t *testing.T) {
+	findings := []Vulnerability{
+		{ID: "SNYK-1", Severity: "high"},
+		{ID: "secret-1", Type: SecretType, Severity: "critical", Layer: "sha256:app"},
+	}
+	started := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	var events []Event
+	events = append(events, Event{Type: ScanStartedEvent, Time: started, Image: "app:1.0", Provider: "snyk"})
+	events = append(events, FindingEvents("app:1.0", findings)...)
+	events = append(events, LayerEvents("app:1.0", []string{"sha256:base", "sha256:app"}, findings)...)
+	summary := SummaryEventOf("app:1.0", findings)
+	events = append(events, summary)
+
+	out := bytes.NewBuffer(nil)
+	for _, event := range events {
+		if event.Time.IsZero() {
+			event.Time = started
+		}
+		assert.NilError(t, WriteEvent(out, event))
+	}
+	assert.Equal(t, out.String(), `{"type":"scan-started","time":"2021-03-01T10:00:00Z","image":"app:1.0","provider":"snyk"}
+{"type":"finding","time":"2021-03-01T10:00:00Z","image":"app:1.0","finding":{"id":"SNYK-1","title":"","severity":"high","packageName":"","version":""}}
+{"type":"finding","time":"2021-03-01T10:00:00Z","image":"app:1.0","finding":{"id":"secret-1","type":"secret","title":"","severity":"critical","packageName":"","version":"","layer":"sha256:app"}}
+{"type":"layer-analyzed","time":"2021-03-01T10:00:00Z","image":"app:1.0","layer":1,"layerId":"sha256:base","findings":0}
+{"type":"layer-analyzed","time":"2021-03-01T10:00:00Z","image":"app:1.0","layer":2,"layerId":"sha256:app","findings":1}
+{"type":"summary","time":"2021-03-01T10:00:00Z","image":"app:1.0","findings":2,"counts":{"critical":1,"high":1,"low":0,"medium":0}}
+`)
+}
@@ -0,0 +1,135 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Explanation tells which analyzer reported a finding, and the layer of the image which introduced it
+type Explanation struct {
+	Analyzer string        `json:"analyzer"`
+	Finding  Vulnerability `json:"finding"`
+	// Layer is the position of the layer in the image history starting at 1, or 0 if the layer is unknown
+	Layer     int    `json:"layer,omitempty"`
+	CreatedBy string `json:"createdBy,omitempty"`
+	BaseImage string `json:"baseImage,omitempty"`
+}
+
+// MatchesID returns true if the finding has the given ID, or the given CVE identifier
+func MatchesID(finding Vulnerability, id string) bool {
+	if strings.EqualFold(finding.ID, id) {
+		return true
+	}
+	for _, cve := range finding.Identifiers["CVE"] {
+		if strings.EqualFold(cve, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExplainLayerGroups explains the vulnerabilities of the provider matching id, with the layer groups they are attributed to
+func ExplainLayerGroups(groups []LayerGroup, id string) []Explanation {
+	var explanations []Explanation
+	for _, group := range groups {
+		for _, vuln := range group.Vulnerabilities {
+			if MatchesID(vuln, id) {
+				explanations = append(explanations, Explanation{
+					Analyzer:  providerAnalyzer(vuln),
+					Finding:   vuln,
+					Layer:     group.Layer,
+					CreatedBy: group.CreatedBy,
+					BaseImage: group.BaseImage,
+				})
+			}
+		}
+	}
+	return explanations
+}
+
+// providerAnalyzer names the analysis of the provider which reported a vulnerability
+func providerAnalyzer(vuln Vulnerability) string {
+	switch {
+	case vuln.Type == LicenseType:
+		return "Snyk license policy"
+	case vuln.Scope() == ScopeOS:
+		return fmt.Sprintf("Snyk OS packages scan (%s)", vuln.PackageManager)
+	}
+	return fmt.Sprintf("Snyk application dependencies scan (%s)", vuln.PackageManager)
+}
+
+// ExplainFindings explains the findings of an analyzer matching id
+func ExplainFindings(analyzer string, findings []Vulnerability, id string) []Explanation {
+	var explanations []Explanation
+	for _, finding := range findings {
+		if MatchesID(finding, id) {
+			explanations = append(explanations, Explanation{Analyzer: analyzer, Finding: finding})
+		}
+	}
+	return explanations
+}
+
+// WriteExplanations prints where each finding matching id comes from
+func WriteExplanations(out io.Writer, id string, explanations []Explanation) {
+	times := "times"
+	if len(explanations) == 1 {
+		times = "time"
+	}
+	fmt.Fprintf(out, "%s was reported %d %s\n", id, len(explanations), times)
+	for _, explanation := range explanations {
+		finding := explanation.Finding
+		fmt.Fprintf(out, "\n✗ %s severity: %s (%s)\n", strings.Title(finding.Severity), finding.Title, finding.ID)
+		fmt.Fprintf(out, "  Analyzer: %s\n", explanation.Analyzer)
+		if finding.PackageName != "" {
+			fmt.Fprintf(out, "  Package: %s\n", packageVersion(finding))
+		}
+		if len(finding.From) > 1 {
+			fmt.Fprintf(out, "  Introduced through: %s\n", strings.Join(finding.From[1:], " > "))
+		}
+		if finding.Path != "" {
+			fmt.Fprintf(out, "  Path: %s\n", finding.Path)
+		}
+		writeExplanationLayer(out, explanation)
+		if len(finding.FixedIn) > 0 {
+			fmt.Fprintf(out, "  Fixed in: %s\n", strings.Join(finding.FixedIn, ", "))
+		}
+	}
+}
+
+func writeExplanationLayer(out io.Writer, explanation Explanation) {
+	finding := explanation.Finding
+	switch {
+	case explanation.Layer > 0:
+		fmt.Fprintf(out, "  Layer: %d, created by: %s\n", explanation.Layer, explanation.CreatedBy)
+	case explanation.CreatedBy != "":
+		fmt.Fprintf(out, "  Dockerfile instruction: %s\n", explanation.CreatedBy)
+	case explanation.BaseImage != "":
+		fmt.Fprintf(out, "  Base image: %s\n", explanation.BaseImage)
+	case finding.Layer != "":
+		fmt.Fprintf(out, "  Layer: %s\n", finding.Layer)
+	}
+}
+
+func packageVersion(finding Vulnerability) string {
+	if finding.Version == "" {
+		return finding.PackageName
+	}
+	return finding.PackageName + "@" + finding.Version
+}
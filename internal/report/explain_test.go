@@ -0,0 +1,65 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestExplain(t *testing.T) {
+	openssl := Vulnerability{
+		ID:             "SNYK-ALPINE312-OPENSSL-1569447",
+		Title:          "Buffer Overflow",
+		Severity:       "high",
+		PackageName:    "openssl/libssl1.1",
+		Version:        "1.1.1k-r0",
+		From:           []string{"docker-image|myapp", "openssl/libssl1.1@1.1.1k-r0"},
+		FixedIn:        []string{"1.1.1l-r0"},
+		Identifiers:    map[string][]string{"CVE": {"CVE-2021-3711"}},
+		PackageManager: "apk",
+	}
+	groups := []LayerGroup{
+		{Base: true, BaseImage: "alpine:3.12", Vulnerabilities: []Vulnerability{openssl}},
+		{Layer: 3, CreatedBy: "RUN apk add curl", Vulnerabilities: []Vulnerability{{ID: "SNYK-ALPINE312-CURL-1"}}},
+	}
+	secret := Vulnerability{ID: "aws-access-key", Title: "AWS access key", Severity: "critical", Path: "/app/.env", Layer: "sha256:abc"}
+
+	explanations := append(ExplainLayerGroups(groups, "cve-2021-3711"),
+		ExplainFindings("secrets scanner", []Vulnerability{secret}, "CVE-2021-3711")...)
+	assert.DeepEqual(t, explanations, []Explanation{{Analyzer: "Snyk OS packages scan (apk)", Finding: openssl, BaseImage: "alpine:3.12"}})
+	explanations = append(explanations, ExplainFindings("secrets scanner", []Vulnerability{secret}, "aws-access-key")...)
+
+	out := bytes.NewBuffer(nil)
+	WriteExplanations(out, "CVE-2021-3711", explanations)
+	assert.Equal(t, out.String(), `CVE-2021-3711 was reported 2 times
+
+✗ High severity: Buffer Overflow (SNYK-ALPINE312-OPENSSL-1569447)
+  Analyzer: Snyk OS packages scan (apk)
+  Package: openssl/libssl1.1@1.1.1k-r0
+  Introduced through: openssl/libssl1.1@1.1.1k-r0
+  Base image: alpine:3.12
+  Fixed in: 1.1.1l-r0
+
+✗ Critical severity: AWS access key (aws-access-key)
+  Analyzer: secrets scanner
+  Path: /app/.env
+  Layer: sha256:abc
+`)
+}
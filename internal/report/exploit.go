@@ -0,0 +1,99 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SortByEPSS sorts the vulnerabilities from the most to the least likely to be exploited, the vulnerabilities without
+// EPSS score last
+func SortByEPSS(vulns []Vulnerability) {
+	sort.SliceStable(vulns, func(i, j int) bool {
+		return epss(vulns[i]) > epss(vulns[j])
+	})
+}
+
+func epss(vuln Vulnerability) float64 {
+	if vuln.Exploit == nil {
+		return -1
+	}
+	return vuln.Exploit.EPSS
+}
+
+// Describe returns the EPSS score and the known exploited flag in a sentence
+func (e Exploit) Describe() string {
+	description := fmt.Sprintf("EPSS %.1f%% (percentile %.0f)", e.EPSS*100, e.Percentile*100)
+	if e.KnownExploited {
+		description += ", known exploited (CISA KEV)"
+	}
+	return description
+}
+
+// ExploitDocument adds the exploit field of the enriched vulnerabilities to the provider JSON output, and sorts its
+// vulnerabilities by EPSS score when sortByEPSS is true
+func ExploitDocument(document []byte, vulns []Vulnerability, sortByEPSS bool) ([]byte, error) {
+	exploits := map[string]*Exploit{}
+	for _, vuln := range vulns {
+		if vuln.Exploit != nil {
+			exploits[vuln.ID] = vuln.Exploit
+		}
+	}
+	return rewriteDocument(document, func(result map[string]json.RawMessage) error {
+		raw, ok := result["vulnerabilities"]
+		if !ok {
+			return nil
+		}
+		var rawVulns []map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &rawVulns); err != nil {
+			return err
+		}
+		scores := make([]float64, len(rawVulns))
+		for i, rawVuln := range rawVulns {
+			var id string
+			_ = json.Unmarshal(rawVuln["id"], &id)
+			scores[i] = -1
+			if exploit, ok := exploits[id]; ok {
+				rawVuln["exploit"], _ = json.Marshal(exploit)
+				scores[i] = exploit.EPSS
+			}
+		}
+		if sortByEPSS {
+			sort.Stable(byScore{rawVulns, scores})
+		}
+		buf, err := json.Marshal(rawVulns)
+		if err != nil {
+			return err
+		}
+		result["vulnerabilities"] = buf
+		return nil
+	})
+}
+
+type byScore struct {
+	vulns  []map[string]json.RawMessage
+	scores []float64
+}
+
+func (b byScore) Len() int           { return len(b.vulns) }
+func (b byScore) Less(i, j int) bool { return b.scores[i] > b.scores[j] }
+func (b byScore) Swap(i, j int) {
+	b.vulns[i], b.vulns[j] = b.vulns[j], b.vulns[i]
+	b.scores[i], b.scores[j] = b.scores[j], b.scores[i]
+}
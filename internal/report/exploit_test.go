@@ -0,0 +1,49 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSortByEPSS(t *testing.T) {
+	vulns := []Vulnerability{
+		{ID: "SNYK-1"},
+		{ID: "SNYK-2", Exploit: &Exploit{EPSS: 0.01}},
+		{ID: "SNYK-3", Exploit: &Exploit{EPSS: 0.97, KnownExploited: true}},
+	}
+	SortByEPSS(vulns)
+	assert.DeepEqual(t, []string{vulns[0].ID, vulns[1].ID, vulns[2].ID}, []string{"SNYK-3", "SNYK-2", "SNYK-1"})
+	assert.Equal(t, vulns[0].Exploit.Describe(), "EPSS 97.0% (percentile 0), known exploited (CISA KEV)")
+}
+
+func TestExploitDocument(t *testing.T) {
+	document := []byte(`{"vulnerabilities": [{"id": "SNYK-1"}, {"id": "SNYK-2"}]}`)
+	vulns := []Vulnerability{{ID: "SNYK-2", Exploit: &Exploit{EPSS: 0.5, Percentile: 0.9}}}
+	enriched, err := ExploitDocument(document, vulns, true)
+	assert.NilError(t, err)
+	var result struct {
+		Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	}
+	assert.NilError(t, json.Unmarshal(enriched, &result))
+	assert.Equal(t, result.Vulnerabilities[0].ID, "SNYK-2")
+	assert.DeepEqual(t, result.Vulnerabilities[0].Exploit, &Exploit{EPSS: 0.5, Percentile: 0.9})
+	assert.Assert(t, result.Vulnerabilities[1].Exploit == nil)
+}
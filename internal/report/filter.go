@@ -0,0 +1,120 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Filter returns a copy of the report with only the vulnerabilities to keep
+func Filter(report Report, keep func(Vulnerability) bool) Report {
+	filtered := report
+	filtered.Vulnerabilities = []Vulnerability{}
+	for _, vuln := range report.Vulnerabilities {
+		if keep(vuln) {
+			filtered.Vulnerabilities = append(filtered.Vulnerabilities, vuln)
+		}
+	}
+	return filtered
+}
+
+// FilterDocument removes the vulnerabilities not to keep from the provider JSON output,
+// updating the ok and uniqueCount fields. The other fields are kept as is.
+func FilterDocument(document []byte, keep func(Vulnerability) bool) ([]byte, error) {
+	return rewriteDocument(document, func(result map[string]json.RawMessage) error {
+		return filterResult(result, keep)
+	})
+}
+
+// rewriteDocument applies the rewrite to each result of the provider JSON output
+func rewriteDocument(document []byte, rewrite func(map[string]json.RawMessage) error) ([]byte, error) {
+	trimmed := bytes.TrimSpace(document)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var results []map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &results); err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			if err := rewrite(result); err != nil {
+				return nil, err
+			}
+		}
+		return marshalDocument(results)
+	}
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &result); err != nil {
+		return nil, err
+	}
+	if err := rewrite(result); err != nil {
+		return nil, err
+	}
+	return marshalDocument(result)
+}
+
+func filterResult(result map[string]json.RawMessage, keep func(Vulnerability) bool) error {
+	raw, ok := result["vulnerabilities"]
+	if !ok {
+		return nil
+	}
+	var packageManager string
+	if rawPackageManager, ok := result["packageManager"]; ok {
+		_ = json.Unmarshal(rawPackageManager, &packageManager)
+	}
+	var vulns []json.RawMessage
+	if err := json.Unmarshal(raw, &vulns); err != nil {
+		return err
+	}
+	kept := []json.RawMessage{}
+	ids := map[string]bool{}
+	for _, rawVuln := range vulns {
+		var vuln Vulnerability
+		if err := json.Unmarshal(rawVuln, &vuln); err != nil {
+			return err
+		}
+		if vuln.PackageManager == "" {
+			vuln.PackageManager = packageManager
+		}
+		if keep(vuln) {
+			kept = append(kept, rawVuln)
+			ids[vuln.ID] = true
+		}
+	}
+	fields := map[string]interface{}{
+		"vulnerabilities": kept,
+		"ok":              len(kept) == 0,
+	}
+	if _, ok := result["uniqueCount"]; ok {
+		fields["uniqueCount"] = len(ids)
+	}
+	for key, value := range fields {
+		buf, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		result[key] = buf
+	}
+	return nil
+}
+
+func marshalDocument(document interface{}) ([]byte, error) {
+	buf, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, '\n'), nil
+}
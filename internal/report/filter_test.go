@@ -0,0 +1,72 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+const multipleResultsOutput = `[
+  {"ok": false, "path": "node:14", "packageManager": "deb", "uniqueCount": 1,
+   "vulnerabilities": [{"id": "SNYK-DEBIAN10-OPENSSL-1", "packageName": "openssl"}]},
+  {"ok": false, "path": "node:14", "packageManager": "npm", "uniqueCount": 2,
+   "vulnerabilities": [{"id": "SNYK-JS-LODASH-1", "packageName": "lodash"},
+                       {"id": "snyk:lic:npm:gpl:GPL-3.0", "type": "license", "packageName": "gpl-lib"}]}
+]`
+
+func TestScopes(t *testing.T) {
+	report, err := Parse([]byte(multipleResultsOutput))
+	assert.NilError(t, err)
+	var scopes []string
+	for _, vuln := range report.Vulnerabilities {
+		scopes = append(scopes, vuln.Scope())
+	}
+	assert.DeepEqual(t, scopes, []string{ScopeOS, ScopeApp, ScopeLicenses})
+	assert.Equal(t, Vulnerability{Type: MalwareType}.Scope(), "")
+}
+
+func TestFilter(t *testing.T) {
+	report, err := Parse([]byte(multipleResultsOutput))
+	assert.NilError(t, err)
+	filtered := Filter(report, func(vuln Vulnerability) bool { return vuln.Scope() == ScopeApp })
+	assert.Equal(t, len(filtered.Vulnerabilities), 1)
+	assert.Equal(t, filtered.Vulnerabilities[0].ID, "SNYK-JS-LODASH-1")
+	assert.Equal(t, len(report.Vulnerabilities), 3)
+}
+
+func TestFilterDocument(t *testing.T) {
+	document, err := FilterDocument([]byte(multipleResultsOutput), func(vuln Vulnerability) bool { return vuln.Scope() == ScopeApp })
+	assert.NilError(t, err)
+	var results []struct {
+		OK              bool            `json:"ok"`
+		UniqueCount     int             `json:"uniqueCount"`
+		PackageManager  string          `json:"packageManager"`
+		Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	}
+	assert.NilError(t, json.Unmarshal(document, &results))
+	assert.Equal(t, len(results), 2)
+	assert.Assert(t, results[0].OK)
+	assert.Equal(t, results[0].UniqueCount, 0)
+	assert.Equal(t, len(results[0].Vulnerabilities), 0)
+	assert.Assert(t, !results[1].OK)
+	assert.Equal(t, results[1].UniqueCount, 1)
+	assert.Equal(t, results[1].PackageManager, "npm")
+	assert.Equal(t, results[1].Vulnerabilities[0].ID, "SNYK-JS-LODASH-1")
+}
@@ -0,0 +1,236 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+)
+
+const (
+	heatBarWidth       = 10
+	heatTargets        = 3
+	heatCreatedByWidth = 60
+)
+
+// LayerHeat correlates the size of an image layer with the vulnerabilities it introduced
+type LayerHeat struct {
+	// Layer is the position of the layer in the image history starting at 1, or 0 for the base image and the
+	// vulnerabilities which can't be attributed to a layer
+	Layer     int    `json:"layer,omitempty"`
+	CreatedBy string `json:"createdBy,omitempty"`
+	BaseImage string `json:"baseImage,omitempty"`
+	Base      bool   `json:"base"`
+	// Size is the size of the layer in bytes, 0 when unknown
+	Size   int64          `json:"size"`
+	Counts map[string]int `json:"counts"`
+	Total  int            `json:"total"`
+}
+
+func (h *LayerHeat) add(vuln Vulnerability) {
+	h.Counts[strings.ToLower(vuln.Severity)]++
+	h.Total++
+}
+
+// LayerHeatmap attributes the vulnerabilities to the layers of the image, by the digest of the layer reported by the
+// provider or by the Dockerfile instruction which created it. When the provider only reports the base image of the
+// vulnerabilities, the layers of the base image are gathered in a single entry. The layers without size and
+// vulnerabilities, like ENV or LABEL, are left out.
+func LayerHeatmap(report Report, layers []image.Layer, digests []string) []LayerHeat {
+	rows := make([]LayerHeat, len(layers))
+	for i, layer := range layers {
+		rows[i] = LayerHeat{Layer: i + 1, CreatedBy: layer.CreatedBy, Base: layer.Base, Size: layer.Size, Counts: map[string]int{}}
+	}
+	var baseImage, unattributed *LayerHeat
+	for _, vuln := range report.Vulnerabilities {
+		index := heatLayer(vuln, layers, digests)
+		switch {
+		case index >= 0:
+			rows[index].add(vuln)
+		case vuln.DockerBaseImage != "":
+			if baseImage == nil {
+				baseImage = &LayerHeat{Base: true, BaseImage: vuln.DockerBaseImage, Counts: map[string]int{}}
+			}
+			baseImage.add(vuln)
+		default:
+			if unattributed == nil {
+				unattributed = &LayerHeat{Counts: map[string]int{}}
+			}
+			unattributed.add(vuln)
+		}
+	}
+
+	heatmap := []LayerHeat{}
+	if baseImage != nil {
+		heatmap = append(heatmap, *baseImage)
+	}
+	for _, row := range rows {
+		switch {
+		case baseImage != nil && row.Base:
+			heatmap[0].Size += row.Size
+			for severity, count := range row.Counts {
+				heatmap[0].Counts[severity] += count
+			}
+			heatmap[0].Total += row.Total
+		case row.Size > 0 || row.Total > 0:
+			heatmap = append(heatmap, row)
+		}
+	}
+	if unattributed != nil {
+		heatmap = append(heatmap, *unattributed)
+	}
+	return heatmap
+}
+
+// heatLayer returns the index of the layer which introduced the vulnerability, or -1
+func heatLayer(vuln Vulnerability, layers []image.Layer, digests []string) int {
+	if vuln.Layer != "" {
+		for i, digest := range digests {
+			if digest == vuln.Layer && i < len(layers) {
+				return i
+			}
+		}
+	}
+	if vuln.DockerfileInstruction != "" {
+		return findLayer(layers, vuln.DockerfileInstruction)
+	}
+	return -1
+}
+
+// HeatTargets returns the layers to refactor first, the ones with the most severe vulnerabilities, then the largest
+func HeatTargets(heatmap []LayerHeat) []LayerHeat {
+	var targets []LayerHeat
+	for _, row := range heatmap {
+		if row.Total > 0 && (row.Layer > 0 || row.BaseImage != "") {
+			targets = append(targets, row)
+		}
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		for level := len(Severities) - 1; level >= 0; level-- {
+			severity := Severities[level]
+			if targets[i].Counts[severity] != targets[j].Counts[severity] {
+				return targets[i].Counts[severity] > targets[j].Counts[severity]
+			}
+		}
+		return targets[i].Size > targets[j].Size
+	})
+	if len(targets) > heatTargets {
+		targets = targets[:heatTargets]
+	}
+	return targets
+}
+
+// WriteLayerHeatmap prints the size and the vulnerabilities of each layer, with their share of the image, followed by
+// the layers whose removal or refactoring removes the most vulnerabilities
+func WriteLayerHeatmap(out io.Writer, heatmap []LayerHeat) error {
+	var size int64
+	total := 0
+	for _, row := range heatmap {
+		size += row.Size
+		total += row.Total
+	}
+	fmt.Fprintln(out, "\nLayer heatmap")
+	table := bytes.NewBuffer(nil)
+	w := tabwriter.NewWriter(table, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "LAYER\tSIZE\tVULNERABILITIES\tCRITICAL\tHIGH\tMEDIUM\tLOW\tCREATED BY")
+	for _, row := range heatmap {
+		fmt.Fprintf(w, "%s\t%s %s\t%s %d\t%d\t%d\t%d\t%d\t%s\n", heatLabel(row), heatBar(row.Size, size),
+			heatSize(row.Size), heatBar(int64(row.Total), int64(total)), row.Total, row.Counts["critical"],
+			row.Counts["high"], row.Counts["medium"], row.Counts["low"], heatCreatedBy(row))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, table); err != nil {
+		return err
+	}
+	targets := HeatTargets(heatmap)
+	if len(targets) == 0 {
+		return nil
+	}
+	fmt.Fprintln(out, "\nLayers to target first:")
+	for _, target := range targets {
+		fmt.Fprintf(out, "  %s: %d vulnerabilities (%s) in %s\n", heatTitle(target), target.Total,
+			heatCounts(target.Counts), heatSize(target.Size))
+	}
+	return nil
+}
+
+func heatLabel(row LayerHeat) string {
+	switch {
+	case row.BaseImage != "":
+		return "base"
+	case row.Layer == 0:
+		return "unknown"
+	case row.Base:
+		return fmt.Sprintf("%d (base)", row.Layer)
+	}
+	return fmt.Sprint(row.Layer)
+}
+
+func heatTitle(row LayerHeat) string {
+	if row.BaseImage != "" {
+		return "Base image " + row.BaseImage
+	}
+	return fmt.Sprintf("Layer %d (%s)", row.Layer, heatCreatedBy(row))
+}
+
+func heatCreatedBy(row LayerHeat) string {
+	createdBy := row.BaseImage
+	if createdBy == "" {
+		createdBy = normalizeInstruction(row.CreatedBy)
+	}
+	if runes := []rune(createdBy); len(runes) > heatCreatedByWidth {
+		createdBy = string(runes[:heatCreatedByWidth-1]) + "…"
+	}
+	return createdBy
+}
+
+func heatCounts(counts map[string]int) string {
+	var parts []string
+	for level := len(Severities) - 1; level >= 0; level-- {
+		if count := counts[Severities[level]]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, Severities[level]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func heatSize(size int64) string {
+	if size == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f MB", float64(size)/1e6)
+}
+
+// heatBar draws the share of the value in the total, at least one cell for a non zero value
+func heatBar(value, total int64) string {
+	filled := 0
+	if total > 0 {
+		filled = int((value*heatBarWidth + total/2) / total)
+	}
+	if value > 0 && filled == 0 {
+		filled = 1
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", heatBarWidth-filled)
+}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"gotest.tools/v3/assert"
+)
+
+func TestLayerHeatmap(t *testing.T) {
+	layers := []image.Layer{
+		{CreatedBy: "/bin/sh -c #(nop) ADD file:a2c0a9e3 in / ", Size: 5000000, Base: true},
+		{CreatedBy: `/bin/sh -c #(nop)  CMD ["/bin/sh"]`, Base: true},
+		{CreatedBy: "/bin/sh -c apk add --no-cache curl", Size: 3000000},
+		{CreatedBy: "RUN /bin/sh -c apk add git # buildkit", Size: 12000000},
+		{CreatedBy: "ENV PATH=/app", Size: 0},
+	}
+	digests := []string{"sha256:base", "", "sha256:curl", "sha256:git", ""}
+	report := Report{
+		Vulnerabilities: []Vulnerability{
+			{ID: "curl", Severity: "high", Layer: "sha256:curl"},
+			{ID: "git-1", Severity: "critical", DockerfileInstruction: "RUN apk add git"},
+			{ID: "git-2", Severity: "low", DockerfileInstruction: "RUN apk add git"},
+			{ID: "musl", Severity: "medium", Layer: "sha256:base"},
+			{ID: "unknown", Severity: "low"},
+		},
+	}
+
+	heatmap := LayerHeatmap(report, layers, digests)
+	assert.DeepEqual(t, heatmap, []LayerHeat{
+		{Layer: 1, CreatedBy: layers[0].CreatedBy, Base: true, Size: 5000000, Counts: map[string]int{"medium": 1}, Total: 1},
+		{Layer: 3, CreatedBy: layers[2].CreatedBy, Size: 3000000, Counts: map[string]int{"high": 1}, Total: 1},
+		{Layer: 4, CreatedBy: layers[3].CreatedBy, Size: 12000000, Counts: map[string]int{"critical": 1, "low": 1}, Total: 2},
+		{Counts: map[string]int{"low": 1}, Total: 1},
+	})
+
+	targets := HeatTargets(heatmap)
+	assert.Equal(t, len(targets), 3)
+	assert.Equal(t, targets[0].Layer, 4)
+	assert.Equal(t, targets[1].Layer, 3)
+	assert.Equal(t, targets[2].Layer, 1)
+
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, WriteLayerHeatmap(out, heatmap))
+	assert.Assert(t, strings.Contains(out.String(), "1 (base)   ███░░░░░░░ 5.0 MB    ██░░░░░░░░ 1"), out.String())
+	assert.Assert(t, strings.Contains(out.String(), "unknown    ░░░░░░░░░░ -         ██░░░░░░░░ 1"), out.String())
+	assert.Assert(t, strings.Contains(out.String(),
+		"  Layer 4 (apk add git): 2 vulnerabilities (1 critical, 1 low) in 12.0 MB\n"), out.String())
+}
+
+func TestLayerHeatmapBaseImage(t *testing.T) {
+	layers := []image.Layer{
+		{CreatedBy: "/bin/sh -c #(nop) ADD file:a2c0a9e3 in / ", Size: 5000000, Base: true},
+		{CreatedBy: "/bin/sh -c apk add --no-cache curl", Size: 3000000},
+	}
+	report := Report{
+		Vulnerabilities: []Vulnerability{
+			{ID: "musl", Severity: "high", DockerBaseImage: "alpine:3.10.0"},
+			{ID: "zlib", Severity: "high", DockerBaseImage: "alpine:3.10.0"},
+		},
+	}
+
+	heatmap := LayerHeatmap(report, layers, nil)
+	assert.DeepEqual(t, heatmap, []LayerHeat{
+		{BaseImage: "alpine:3.10.0", Base: true, Size: 5000000, Counts: map[string]int{"high": 2}, Total: 2},
+		{Layer: 2, CreatedBy: layers[1].CreatedBy, Size: 3000000, Counts: map[string]int{}},
+	})
+	targets := HeatTargets(heatmap)
+	assert.Equal(t, len(targets), 1)
+	assert.Equal(t, heatTitle(targets[0]), "Base image alpine:3.10.0")
+}
+
+func TestHeatBar(t *testing.T) {
+	assert.Equal(t, heatBar(0, 0), "░░░░░░░░░░")
+	assert.Equal(t, heatBar(1, 1000), "█░░░░░░░░░")
+	assert.Equal(t, heatBar(1, 2), "█████░░░░░")
+	assert.Equal(t, heatBar(3, 3), "██████████")
+}
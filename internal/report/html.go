@@ -0,0 +1,92 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// dateFormat is the format of the scan date in the HTML and PDF reports
+const dateFormat = "2006-01-02 15:04:05 MST"
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date":  formatDate,
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Scan report of {{.Image}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.critical { color: #8b0000; } .high { color: #d00; } .medium { color: #e69500; } .low { color: #555; }
+</style>
+</head>
+<body>
+<h1>Scan report of {{.Image}}</h1>
+<p>Scanned at {{date .ScannedAt}}: {{len .Findings}} findings</p>
+<table>
+<tr>{{range .Severities}}<th class="{{.}}">{{.}}</th>{{end}}</tr>
+<tr>{{range .Counts}}<td>{{.}}</td>{{end}}</tr>
+</table>
+{{if .Findings}}<h2>Findings</h2>
+<table>
+<tr><th>ID</th><th>Severity</th><th>Package</th><th>Version</th><th>Fixed in</th><th>Title</th></tr>
+{{range .Findings}}<tr><td>{{join .CVEs ", "}}</td><td class="{{lower .Severity}}">{{.Severity}}</td><td>{{if .PackageName}}{{.PackageName}}{{else}}{{.Path}}{{end}}</td><td>{{.Version}}</td><td>{{join .FixedIn ", "}}</td><td>{{.Title}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// document is the content of the HTML and PDF reports
+type document struct {
+	Image      string
+	ScannedAt  time.Time
+	Findings   []Vulnerability
+	Severities []string
+	Counts     []int
+}
+
+// newDocument counts the findings per severity, from the most to the least severe
+func newDocument(image string, scannedAt time.Time, findings []Vulnerability) document {
+	counts := CountBySeverity(findings)
+	data := document{
+		Image:     image,
+		ScannedAt: scannedAt,
+		Findings:  findings,
+	}
+	for i := len(Severities) - 1; i >= 0; i-- {
+		data.Severities = append(data.Severities, Severities[i])
+		data.Counts = append(data.Counts, counts[Severities[i]])
+	}
+	return data
+}
+
+func formatDate(date time.Time) string {
+	return date.UTC().Format(dateFormat)
+}
+
+// WriteHTML writes a standalone HTML document reporting the findings of the scan of an image
+func WriteHTML(out io.Writer, image string, scannedAt time.Time, findings []Vulnerability) error {
+	return htmlTemplate.Execute(out, newDocument(image, scannedAt, findings))
+}
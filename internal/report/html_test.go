@@ -0,0 +1,42 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteHTML(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	findings := []Vulnerability{
+		{ID: "SNYK-1", Title: "<script>alert(1)</script>", Severity: "high", PackageName: "openssl", Version: "1.1.1d",
+			FixedIn: []string{"1.1.1g"}, Identifiers: map[string][]string{"CVE": {"CVE-2020-1971"}}},
+	}
+	assert.NilError(t, WriteHTML(out, "myimage:1.0", time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC), findings))
+	html := out.String()
+	assert.Assert(t, strings.Contains(html, "<h1>Scan report of myimage:1.0</h1>"), html)
+	assert.Assert(t, strings.Contains(html, "Scanned at 2021-06-01 12:00:00 UTC: 1 findings"), html)
+	assert.Assert(t, strings.Contains(html, `<tr><td>0</td><td>1</td><td>0</td><td>0</td></tr>`), html)
+	assert.Assert(t, strings.Contains(html, `<td>CVE-2020-1971</td><td class="high">high</td><td>openssl</td><td>1.1.1d</td><td>1.1.1g</td>`), html)
+	// the findings are escaped
+	assert.Assert(t, strings.Contains(html, "&lt;script&gt;alert(1)&lt;/script&gt;"), html)
+}
@@ -0,0 +1,51 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// AppendField adds a top level field to the JSON object produced by the provider,
+// keeping the provider fields untouched and in order
+func AppendField(document []byte, key string, value interface{}) ([]byte, error) {
+	trimmed := bytes.TrimSpace(document)
+	if !bytes.HasPrefix(trimmed, []byte("{")) || !bytes.HasSuffix(trimmed, []byte("}")) {
+		return nil, fmt.Errorf("provider output is not a JSON object")
+	}
+	field, err := json.MarshalIndent(value, "  ", "  ")
+	if err != nil {
+		return nil, err
+	}
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return nil, err
+	}
+	body := bytes.TrimSpace(trimmed[:len(trimmed)-1])
+	out := bytes.NewBuffer(nil)
+	out.Write(body)
+	if !bytes.Equal(body, []byte("{")) {
+		out.WriteString(",")
+	}
+	fmt.Fprintf(out, "\n  %s: %s\n}\n", keyJSON, field)
+	if !json.Valid(out.Bytes()) {
+		return nil, fmt.Errorf("invalid JSON document")
+	}
+	return out.Bytes(), nil
+}
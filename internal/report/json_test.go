@@ -0,0 +1,52 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestAppendField(t *testing.T) {
+	document := []byte(`{
+  "ok": false,
+  "vulnerabilities": []
+}
+`)
+	actual, err := AppendField(document, "extra", map[string]int{"count": 1})
+	assert.NilError(t, err)
+	assert.Equal(t, string(actual), `{
+  "ok": false,
+  "vulnerabilities": [],
+  "extra": {
+    "count": 1
+  }
+}
+`)
+}
+
+func TestAppendFieldEmptyObject(t *testing.T) {
+	actual, err := AppendField([]byte(`{}`), "extra", true)
+	assert.NilError(t, err)
+	assert.Equal(t, string(actual), "{\n  \"extra\": true\n}\n")
+}
+
+func TestAppendFieldNotAnObject(t *testing.T) {
+	_, err := AppendField([]byte(`[{}]`), "extra", true)
+	assert.ErrorContains(t, err, "provider output is not a JSON object")
+}
@@ -0,0 +1,112 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the findings of the scan of an image as a JUnit XML report, for CI servers to render them
+// in their test reports. Each finding is a failed test case of the test suite of the image, an image without
+// findings has a single passed test case.
+func WriteJUnit(out io.Writer, image string, scannedAt time.Time, findings []Vulnerability) error {
+	suite := junitTestSuite{
+		Name:      image,
+		Timestamp: scannedAt.UTC().Format("2006-01-02T15:04:05"),
+	}
+	for _, finding := range findings {
+		pkg := finding.PackageName
+		if pkg == "" {
+			pkg = finding.Path
+		}
+		suite.Cases = append(suite.Cases, junitTestCase{
+			Name:      fmt.Sprintf("%s in %s", strings.Join(finding.CVEs(), ", "), pkg),
+			ClassName: image,
+			Failure: &junitFailure{
+				Message: fmt.Sprintf("%s severity: %s", strings.ToLower(finding.Severity), finding.Title),
+				Type:    strings.ToLower(finding.Severity),
+				Text:    junitDetails(finding),
+			},
+		})
+	}
+	suite.Failures = len(suite.Cases)
+	if len(suite.Cases) == 0 {
+		suite.Cases = append(suite.Cases, junitTestCase{Name: "no findings", ClassName: image})
+	}
+	suite.Tests = len(suite.Cases)
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitTestSuites{
+		Name:     "docker scan",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+func junitDetails(finding Vulnerability) string {
+	details := []string{"ID: " + finding.ID}
+	if finding.Version != "" {
+		details = append(details, "Version: "+finding.Version)
+	}
+	if len(finding.FixedIn) > 0 {
+		details = append(details, "Fixed in: "+strings.Join(finding.FixedIn, ", "))
+	}
+	if finding.Layer != "" {
+		details = append(details, "Layer: "+finding.Layer)
+	}
+	return strings.Join(details, "\n")
+}
@@ -0,0 +1,50 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	scannedAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.NilError(t, WriteJUnit(out, "myimage:1.0", scannedAt, summaryFindings[1:2]))
+	assert.Equal(t, out.String(), `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="docker scan" tests="1" failures="1">
+  <testsuite name="myimage:1.0" tests="1" failures="1" timestamp="2021-06-01T12:00:00">
+    <testcase name="CVE-2020-1971 in openssl" classname="myimage:1.0">
+      <failure message="high severity: NULL Pointer Dereference" type="high">ID: SNYK-DEBIAN10-OPENSSL-2&#xA;Version: 1.1.1d&#xA;Fixed in: 1.1.1g</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`)
+
+	out.Reset()
+	assert.NilError(t, WriteJUnit(out, "myimage:1.0", scannedAt, nil))
+	assert.Equal(t, out.String(), `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="docker scan" tests="1" failures="0">
+  <testsuite name="myimage:1.0" tests="1" failures="0" timestamp="2021-06-01T12:00:00">
+    <testcase name="no findings" classname="myimage:1.0"></testcase>
+  </testsuite>
+</testsuites>
+`)
+}
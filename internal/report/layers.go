@@ -0,0 +1,173 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+)
+
+// LayerGroup gathers the vulnerabilities introduced by the same image layer
+type LayerGroup struct {
+	// Layer is the position of the layer in the image history starting at 1, or 0 if the layer is unknown
+	Layer           int             `json:"layer,omitempty"`
+	CreatedBy       string          `json:"createdBy,omitempty"`
+	BaseImage       string          `json:"baseImage,omitempty"`
+	Base            bool            `json:"base"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+var (
+	buildArgsPrefix = regexp.MustCompile(`^\|\d+( \S+=\S*)* `)
+	whitespaces     = regexp.MustCompile(`\s+`)
+)
+
+// GroupByLayer attributes each vulnerability to the layer which introduced it.
+// The provider reports the Dockerfile instruction or the base image responsible of a vulnerability
+// when the scan is run with a Dockerfile, the instruction is then matched against the image history.
+func GroupByLayer(report Report, layers []image.Layer) []LayerGroup {
+	var (
+		base         *LayerGroup
+		unattributed *LayerGroup
+		byLayer      = map[int]*LayerGroup{}
+		byInstr      = map[string]*LayerGroup{}
+		instructions []string
+	)
+	for _, vuln := range report.Vulnerabilities {
+		switch {
+		case vuln.DockerfileInstruction != "":
+			index := findLayer(layers, vuln.DockerfileInstruction)
+			if index < 0 {
+				if _, ok := byInstr[vuln.DockerfileInstruction]; !ok {
+					byInstr[vuln.DockerfileInstruction] = &LayerGroup{CreatedBy: vuln.DockerfileInstruction}
+					instructions = append(instructions, vuln.DockerfileInstruction)
+				}
+				byInstr[vuln.DockerfileInstruction].Vulnerabilities = append(byInstr[vuln.DockerfileInstruction].Vulnerabilities, vuln)
+				continue
+			}
+			if _, ok := byLayer[index]; !ok {
+				byLayer[index] = &LayerGroup{Layer: index + 1, CreatedBy: layers[index].CreatedBy}
+			}
+			byLayer[index].Vulnerabilities = append(byLayer[index].Vulnerabilities, vuln)
+		case vuln.DockerBaseImage != "":
+			if base == nil {
+				base = &LayerGroup{Base: true, BaseImage: vuln.DockerBaseImage}
+			}
+			base.Vulnerabilities = append(base.Vulnerabilities, vuln)
+		default:
+			if unattributed == nil {
+				unattributed = &LayerGroup{}
+			}
+			unattributed.Vulnerabilities = append(unattributed.Vulnerabilities, vuln)
+		}
+	}
+
+	groups := []LayerGroup{}
+	if base != nil {
+		groups = append(groups, *base)
+	}
+	for index := range layers {
+		if group, ok := byLayer[index]; ok {
+			group.Base = layers[index].Base
+			groups = append(groups, *group)
+		}
+	}
+	for _, instruction := range instructions {
+		groups = append(groups, *byInstr[instruction])
+	}
+	if unattributed != nil {
+		groups = append(groups, *unattributed)
+	}
+	return groups
+}
+
+// FromBaseImage returns whether the vulnerability comes from the base image, and whether it could be attributed at all.
+// The provider reports the base image or the Dockerfile instruction responsible of a vulnerability when the scan is run
+// with a Dockerfile, otherwise the layer which installed the package is looked up in the diff IDs of the base layers.
+func FromBaseImage(vuln Vulnerability, baseLayers map[string]bool) (fromBase bool, attributed bool) {
+	switch {
+	case vuln.DockerBaseImage != "":
+		return true, true
+	case vuln.DockerfileInstruction != "":
+		return false, true
+	case vuln.Layer != "" && len(baseLayers) > 0:
+		return baseLayers[vuln.Layer], true
+	}
+	return false, false
+}
+
+// findLayer returns the index of the most recent layer created by the Dockerfile instruction, or -1
+func findLayer(layers []image.Layer, instruction string) int {
+	command := normalizeInstruction(instruction)
+	if command == "" {
+		return -1
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		if normalizeInstruction(layers[i].CreatedBy) == command {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeInstruction removes the decorations added by the builders to the instructions in the image history
+func normalizeInstruction(instruction string) string {
+	instruction = whitespaces.ReplaceAllString(strings.TrimSpace(instruction), " ")
+	instruction = strings.TrimSuffix(instruction, " # buildkit")
+	instruction = strings.TrimPrefix(instruction, "RUN ")
+	instruction = buildArgsPrefix.ReplaceAllString(instruction, "")
+	instruction = strings.TrimPrefix(instruction, "/bin/sh -c ")
+	instruction = strings.TrimPrefix(instruction, "#(nop) ")
+	return strings.TrimSpace(instruction)
+}
+
+// WriteLayerGroups prints the vulnerabilities grouped by layer
+func WriteLayerGroups(out io.Writer, report Report, groups []LayerGroup) {
+	baseCount, userCount, unknownCount := 0, 0, 0
+	for _, group := range groups {
+		switch {
+		case group.Base && group.BaseImage != "":
+			fmt.Fprintf(out, "\nBase image %s\n", group.BaseImage)
+		case group.Base:
+			fmt.Fprintf(out, "\nLayer %d (base image): %s\n", group.Layer, group.CreatedBy)
+		case group.Layer > 0:
+			fmt.Fprintf(out, "\nLayer %d: %s\n", group.Layer, group.CreatedBy)
+		case group.CreatedBy != "":
+			fmt.Fprintf(out, "\nInstruction: %s\n", group.CreatedBy)
+		default:
+			fmt.Fprintf(out, "\nUnknown layer (use --file to attribute vulnerabilities to Dockerfile instructions)\n")
+		}
+		for _, vuln := range group.Vulnerabilities {
+			fmt.Fprintf(out, "  ✗ %s severity vulnerability found in %s@%s\n", strings.Title(vuln.Severity), vuln.PackageName, vuln.Version)
+			fmt.Fprintf(out, "    %s: %s\n", vuln.ID, vuln.Title)
+		}
+		switch {
+		case group.Base:
+			baseCount += len(group.Vulnerabilities)
+		case group.Layer > 0 || group.CreatedBy != "":
+			userCount += len(group.Vulnerabilities)
+		default:
+			unknownCount += len(group.Vulnerabilities)
+		}
+	}
+	fmt.Fprintf(out, "\nTested %s, found %d vulnerabilities: %d from the base image, %d from other layers, %d unattributed\n",
+		report.Path, baseCount+userCount+unknownCount, baseCount, userCount, unknownCount)
+}
@@ -0,0 +1,90 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"gotest.tools/v3/assert"
+)
+
+func TestGroupByLayer(t *testing.T) {
+	layers := []image.Layer{
+		{CreatedBy: "/bin/sh -c #(nop) ADD file:a2c0a9e3 in / ", Base: true},
+		{CreatedBy: `/bin/sh -c #(nop)  CMD ["/bin/sh"]`, Base: true},
+		{CreatedBy: "/bin/sh -c apk add --no-cache curl"},
+		{CreatedBy: "RUN |1 VERSION=1.2 /bin/sh -c apk add   git # buildkit"},
+	}
+	report := Report{
+		Path: "image",
+		Vulnerabilities: []Vulnerability{
+			{ID: "musl", DockerBaseImage: "alpine:3.10.0"},
+			{ID: "git", DockerfileInstruction: "RUN apk add git"},
+			{ID: "curl", DockerfileInstruction: "RUN apk add --no-cache curl"},
+			{ID: "wget", DockerfileInstruction: "RUN apk add wget"},
+			{ID: "unknown"},
+		},
+	}
+	groups := GroupByLayer(report, layers)
+	assert.DeepEqual(t, groups, []LayerGroup{
+		{Base: true, BaseImage: "alpine:3.10.0", Vulnerabilities: []Vulnerability{report.Vulnerabilities[0]}},
+		{Layer: 3, CreatedBy: layers[2].CreatedBy, Vulnerabilities: []Vulnerability{report.Vulnerabilities[2]}},
+		{Layer: 4, CreatedBy: layers[3].CreatedBy, Vulnerabilities: []Vulnerability{report.Vulnerabilities[1]}},
+		{CreatedBy: "RUN apk add wget", Vulnerabilities: []Vulnerability{report.Vulnerabilities[3]}},
+		{Vulnerabilities: []Vulnerability{report.Vulnerabilities[4]}},
+	})
+
+	out := bytes.NewBuffer(nil)
+	WriteLayerGroups(out, report, groups)
+	assert.Assert(t, strings.Contains(out.String(), "Base image alpine:3.10.0\n"), out.String())
+	assert.Assert(t, strings.Contains(out.String(), "Layer 3: /bin/sh -c apk add --no-cache curl\n"), out.String())
+	assert.Assert(t, strings.Contains(out.String(),
+		"found 5 vulnerabilities: 1 from the base image, 3 from other layers, 1 unattributed"), out.String())
+}
+
+func TestNormalizeInstruction(t *testing.T) {
+	assert.Equal(t, normalizeInstruction("RUN apk add curl"), "apk add curl")
+	assert.Equal(t, normalizeInstruction("/bin/sh -c apk add curl"), "apk add curl")
+	assert.Equal(t, normalizeInstruction("RUN /bin/sh -c apk add curl # buildkit"), "apk add curl")
+	assert.Equal(t, normalizeInstruction("RUN |2 A=1 B= /bin/sh -c apk add curl # buildkit"), "apk add curl")
+	assert.Equal(t, normalizeInstruction(`/bin/sh -c #(nop)  CMD ["/bin/sh"]`), `CMD ["/bin/sh"]`)
+}
+
+func TestFromBaseImage(t *testing.T) {
+	baseLayers := map[string]bool{"sha256:base": true}
+	cases := []struct {
+		vuln       Vulnerability
+		baseLayers map[string]bool
+		fromBase   bool
+		attributed bool
+	}{
+		{vuln: Vulnerability{DockerBaseImage: "alpine:3.10.0"}, fromBase: true, attributed: true},
+		{vuln: Vulnerability{DockerfileInstruction: "RUN apk add curl"}, baseLayers: baseLayers, attributed: true},
+		{vuln: Vulnerability{Layer: "sha256:base"}, baseLayers: baseLayers, fromBase: true, attributed: true},
+		{vuln: Vulnerability{Layer: "sha256:app"}, baseLayers: baseLayers, attributed: true},
+		{vuln: Vulnerability{Layer: "sha256:base"}},
+		{vuln: Vulnerability{}, baseLayers: baseLayers},
+	}
+	for _, c := range cases {
+		fromBase, attributed := FromBaseImage(c.vuln, c.baseLayers)
+		assert.Equal(t, fromBase, c.fromBase, c.vuln)
+		assert.Equal(t, attributed, c.attributed, c.vuln)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// PackageLicense is an OS or application package of the image, with the licenses it declares
+type PackageLicense struct {
+	Name     string   `json:"name"`
+	Version  string   `json:"version"`
+	Type     string   `json:"type"`
+	Licenses []string `json:"licenses"`
+	Path     string   `json:"path"`
+	Layer    string   `json:"layer"`
+}
+
+// MatchesLicense returns true if the license starts with one of the denied licenses, ignoring case,
+// so that GPL denies GPL-2.0 and GPL-3.0 but not LGPL-2.1
+func MatchesLicense(license string, denied []string) bool {
+	for _, d := range denied {
+		if d != "" && strings.HasPrefix(strings.ToLower(license), strings.ToLower(d)) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeniedLicenses returns a license issue for each package declaring a denied license
+func DeniedLicenses(packages []PackageLicense, denied []string) []Vulnerability {
+	issues := []Vulnerability{}
+	for _, pkg := range packages {
+		for _, license := range pkg.Licenses {
+			if !MatchesLicense(license, denied) {
+				continue
+			}
+			issues = append(issues, Vulnerability{
+				ID:             fmt.Sprintf("license:%s:%s:%s", pkg.Type, pkg.Name, license),
+				Type:           LicenseType,
+				Title:          license + " license",
+				Severity:       "high",
+				PackageName:    pkg.Name,
+				Version:        pkg.Version,
+				PackageManager: pkg.Type,
+				Path:           pkg.Path,
+				Layer:          pkg.Layer,
+				License:        license,
+			})
+		}
+	}
+	return issues
+}
+
+// WriteLicenses prints the packages of the image with their licenses as a table
+func WriteLicenses(out io.Writer, packages []PackageLicense) error {
+	fmt.Fprintf(out, "\nFound %d packages\n", len(packages))
+	if len(packages) == 0 {
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tVERSION\tTYPE\tLICENSES")
+	for _, pkg := range packages {
+		licenses := strings.Join(pkg.Licenses, ", ")
+		if licenses == "" {
+			licenses = "unknown"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pkg.Name, pkg.Version, pkg.Type, licenses)
+	}
+	return w.Flush()
+}
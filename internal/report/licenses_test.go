@@ -0,0 +1,48 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestMatchesLicense(t *testing.T) {
+	denied := []string{"GPL", "agpl-3.0"}
+	assert.Assert(t, MatchesLicense("GPL-2.0-only", denied))
+	assert.Assert(t, MatchesLicense("AGPL-3.0", denied))
+	assert.Assert(t, !MatchesLicense("LGPL-2.1", denied))
+	assert.Assert(t, !MatchesLicense("MIT", nil))
+}
+
+func TestWriteLicenses(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, WriteLicenses(out, []PackageLicense{
+		{Name: "musl", Version: "1.1.24-r9", Type: "apk", Licenses: []string{"MIT"}},
+		{Name: "bash", Version: "5.0-4", Type: "deb", Licenses: []string{"GPL-3+", "GPL-2+"}},
+		{Name: "left-pad", Version: "1.3.0", Type: "npm"},
+	}))
+	assert.Equal(t, out.String(), `
+Found 3 packages
+PACKAGE    VERSION     TYPE   LICENSES
+musl       1.1.24-r9   apk    MIT
+bash       5.0-4       deb    GPL-3+, GPL-2+
+left-pad   1.3.0       npm    unknown
+`)
+}
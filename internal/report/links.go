@@ -0,0 +1,99 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// advisoryURLs are the public pages of the vulnerabilities, by prefix of their identifier
+var advisoryURLs = []struct {
+	prefix string
+	url    string
+}{
+	{prefix: "SNYK-", url: "https://snyk.io/vuln/"},
+	{prefix: "CVE-", url: "https://nvd.nist.gov/vuln/detail/"},
+	{prefix: "GHSA-", url: "https://github.com/advisories/"},
+}
+
+// AdvisoryURL returns the public page of the vulnerability with the identifier, empty when it has none
+func AdvisoryURL(id string) string {
+	for _, advisory := range advisoryURLs {
+		if strings.HasPrefix(id, advisory.prefix) {
+			return advisory.url + id
+		}
+	}
+	return ""
+}
+
+// FindingURL returns the link to the finding in the web report of the provider when the image is monitored, otherwise
+// to the advisory of the vulnerability
+func (r Report) FindingURL(vuln Vulnerability) string {
+	return findingURL(r.URL, vuln)
+}
+
+func findingURL(reportURL string, vuln Vulnerability) string {
+	// the findings of the plugin analyzers aren't in the report of the provider
+	if reportURL != "" && (vuln.Type == "" || vuln.Type == VulnerabilityType || vuln.Type == LicenseType) {
+		return strings.SplitN(reportURL, "#", 2)[0] + "#issue-" + vuln.ID
+	}
+	return AdvisoryURL(vuln.ID)
+}
+
+// LinkDocument adds the url field to the vulnerabilities of the provider JSON output, linking them to the web report
+// of the provider or to their advisory
+func LinkDocument(document []byte) ([]byte, error) {
+	return rewriteDocument(document, linkResult)
+}
+
+func linkResult(result map[string]json.RawMessage) error {
+	raw, ok := result["vulnerabilities"]
+	if !ok {
+		return nil
+	}
+	var reportURL string
+	if rawURL, ok := result["uri"]; ok {
+		_ = json.Unmarshal(rawURL, &reportURL)
+	}
+	var vulns []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &vulns); err != nil {
+		return err
+	}
+	for _, rawVuln := range vulns {
+		var vuln Vulnerability
+		_ = json.Unmarshal(rawVuln["id"], &vuln.ID)
+		if rawType, ok := rawVuln["type"]; ok {
+			_ = json.Unmarshal(rawType, &vuln.Type)
+		}
+		url := findingURL(reportURL, vuln)
+		if url == "" {
+			continue
+		}
+		buf, err := json.Marshal(url)
+		if err != nil {
+			return err
+		}
+		rawVuln["url"] = buf
+	}
+	buf, err := json.Marshal(vulns)
+	if err != nil {
+		return err
+	}
+	result["vulnerabilities"] = buf
+	return nil
+}
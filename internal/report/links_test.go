@@ -0,0 +1,86 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestFindingURL(t *testing.T) {
+	assert.Equal(t, AdvisoryURL("SNYK-ALPINE310-MUSL-458116"), "https://snyk.io/vuln/SNYK-ALPINE310-MUSL-458116")
+	assert.Equal(t, AdvisoryURL("CVE-2019-14697"), "https://nvd.nist.gov/vuln/detail/CVE-2019-14697")
+	assert.Equal(t, AdvisoryURL("GHSA-8r8j-xvfj-36f9"), "https://github.com/advisories/GHSA-8r8j-xvfj-36f9")
+	assert.Equal(t, AdvisoryURL("SECRET-PRIVATE-KEY"), "")
+
+	monitored := Report{URL: "https://app.snyk.io/org/acme/project/1234#overview"}
+	assert.Equal(t, monitored.FindingURL(Vulnerability{ID: "SNYK-ALPINE310-MUSL-458116"}),
+		"https://app.snyk.io/org/acme/project/1234#issue-SNYK-ALPINE310-MUSL-458116")
+	assert.Equal(t, monitored.FindingURL(Vulnerability{ID: "CVE-2021-44228", Type: ConfigType}), "https://nvd.nist.gov/vuln/detail/CVE-2021-44228")
+	assert.Equal(t, Report{}.FindingURL(Vulnerability{ID: "SNYK-ALPINE310-MUSL-458116"}), "https://snyk.io/vuln/SNYK-ALPINE310-MUSL-458116")
+}
+
+func TestLinkDocument(t *testing.T) {
+	document := []byte(`{
+  "uri": "https://app.snyk.io/org/acme/project/1234",
+  "vulnerabilities": [
+    {"id": "SNYK-ALPINE310-MUSL-458116", "severity": "high"},
+    {"id": "custom-id", "severity": "low"}
+  ]
+}`)
+	linked, err := LinkDocument(document)
+	assert.NilError(t, err)
+	var result struct {
+		Vulnerabilities []map[string]string `json:"vulnerabilities"`
+	}
+	assert.NilError(t, json.Unmarshal(linked, &result))
+	assert.Equal(t, result.Vulnerabilities[0]["url"], "https://app.snyk.io/org/acme/project/1234#issue-SNYK-ALPINE310-MUSL-458116")
+	assert.Equal(t, result.Vulnerabilities[0]["severity"], "high")
+	assert.Equal(t, result.Vulnerabilities[1]["url"], "https://app.snyk.io/org/acme/project/1234#issue-custom-id")
+
+	scanReport, err := Parse(document)
+	assert.NilError(t, err)
+	assert.Equal(t, scanReport.URL, "https://app.snyk.io/org/acme/project/1234")
+}
+
+func TestWriteTextReportURL(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	WriteText(out, Report{
+		Path: "alpine:3.10.0",
+		URL:  "https://app.snyk.io/org/acme/project/1234",
+		Vulnerabilities: []Vulnerability{{
+			ID:          "SNYK-ALPINE310-MUSL-458116",
+			Title:       "Out-of-bounds Write",
+			Severity:    "high",
+			PackageName: "musl/musl",
+		}},
+	})
+	assert.Equal(t, out.String(), `
+Testing alpine:3.10.0...
+Report: https://app.snyk.io/org/acme/project/1234
+
+✗ High severity vulnerability found in musl/musl
+  Description: Out-of-bounds Write
+  Info: https://snyk.io/vuln/SNYK-ALPINE310-MUSL-458116
+  Report: https://app.snyk.io/org/acme/project/1234#issue-SNYK-ALPINE310-MUSL-458116
+
+Tested alpine:3.10.0, found 1 issue.
+`)
+}
@@ -0,0 +1,31 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriteMalware prints the files matching malware detection rules
+func WriteMalware(out io.Writer, matches []Vulnerability) {
+	fmt.Fprintf(out, "\nFound %d files matching malware detection rules\n", len(matches))
+	for _, match := range matches {
+		fmt.Fprintf(out, "  ✗ %s matched %s\n", match.ID, match.Path)
+		fmt.Fprintf(out, "    Layer: %s\n", match.Layer)
+	}
+}
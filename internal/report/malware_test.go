@@ -0,0 +1,34 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteMalware(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	WriteMalware(out, []Vulnerability{{ID: "Miner", Type: MalwareType, Path: "/usr/bin/xmrig", Layer: "sha256:abcd"}})
+	assert.Equal(t, out.String(), `
+Found 1 files matching malware detection rules
+  ✗ Miner matched /usr/bin/xmrig
+    Layer: sha256:abcd
+`)
+}
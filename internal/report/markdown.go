@@ -0,0 +1,125 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxMarkdownFindings is the number of findings listed in each table of the markdown summary
+const maxMarkdownFindings = 10
+
+// MarkdownSummary is the content of the markdown summary of a scan, like the GitHub Actions job summaries
+type MarkdownSummary struct {
+	Image    string
+	Findings []Vulnerability
+	// Compared is true when the findings are compared with a baseline report, Introduced and Fixed being the changes
+	Compared   bool
+	Introduced []Vulnerability
+	Fixed      []Vulnerability
+}
+
+// WriteMarkdown writes the summary of a scan in GitHub flavored markdown: a table of the counts per severity, the most
+// severe findings and the changes since the baseline
+func WriteMarkdown(out io.Writer, summary MarkdownSummary) {
+	fmt.Fprintf(out, "## Docker Scan results for `%s`\n\n", summary.Image)
+	counts := CountBySeverity(summary.Findings)
+	fmt.Fprintln(out, "| Severity | Findings |")
+	fmt.Fprintln(out, "| --- | ---: |")
+	for i := len(Severities) - 1; i >= 0; i-- {
+		fmt.Fprintf(out, "| %s | %d |\n", Severities[i], counts[Severities[i]])
+	}
+	if counts[InfoSeverity] > 0 {
+		fmt.Fprintf(out, "| %s | %d |\n", InfoSeverity, counts[InfoSeverity])
+	}
+	fmt.Fprintf(out, "| **Total** | **%d** |\n\n", len(summary.Findings))
+
+	if len(summary.Findings) > 0 {
+		fmt.Fprintln(out, "### Top findings")
+		fmt.Fprintln(out)
+		writeMarkdownFindings(out, summary.Findings)
+	}
+	if summary.Compared {
+		fmt.Fprintf(out, "### Changes since the baseline: %d new, %d fixed\n\n", len(summary.Introduced), len(summary.Fixed))
+		if len(summary.Introduced) > 0 {
+			fmt.Fprintln(out, "New findings:")
+			fmt.Fprintln(out)
+			writeMarkdownFindings(out, summary.Introduced)
+		}
+		if len(summary.Fixed) > 0 {
+			fmt.Fprintln(out, "Fixed findings:")
+			fmt.Fprintln(out)
+			writeMarkdownFindings(out, summary.Fixed)
+		}
+	}
+}
+
+// writeMarkdownFindings writes a table of the most severe findings, a line per vulnerability and package
+func writeMarkdownFindings(out io.Writer, findings []Vulnerability) {
+	fmt.Fprintln(out, "| Severity | Vulnerability | Package | Fixed in |")
+	fmt.Fprintln(out, "| --- | --- | --- | --- |")
+	seen := map[string]bool{}
+	written := 0
+	for level := len(Severities) - 1; level >= -1; level-- {
+		for _, finding := range findings {
+			key := vulnerabilityKey(finding)
+			if SeverityLevel(finding.Severity) != level || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if written == maxMarkdownFindings {
+				continue
+			}
+			written++
+			fmt.Fprintf(out, "| %s | %s | %s | %s |\n", finding.Severity, markdownID(finding), markdownPackage(finding),
+				markdownEscape(strings.Join(finding.FixedIn, ", ")))
+		}
+	}
+	if more := len(seen) - written; more > 0 {
+		fmt.Fprintf(out, "\n_and %d more_\n", more)
+	}
+	fmt.Fprintln(out)
+}
+
+// markdownID returns the ID of the finding, linked to its advisory, with its title
+func markdownID(finding Vulnerability) string {
+	id := markdownEscape(finding.ID)
+	if url := AdvisoryURL(finding.ID); url != "" {
+		id = fmt.Sprintf("[%s](%s)", id, url)
+	}
+	if finding.Title == "" {
+		return id
+	}
+	return id + " " + markdownEscape(finding.Title)
+}
+
+func markdownPackage(finding Vulnerability) string {
+	if finding.PackageName == "" {
+		return markdownEscape(finding.Path)
+	}
+	if finding.Version == "" {
+		return markdownEscape(finding.PackageName)
+	}
+	return markdownEscape(finding.PackageName + "@" + finding.Version)
+}
+
+// markdownEscape escapes the characters breaking the markdown tables
+func markdownEscape(text string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ", "\r", "").Replace(text)
+}
@@ -0,0 +1,79 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	WriteMarkdown(out, MarkdownSummary{
+		Image:      "debian:10",
+		Findings:   summaryFindings,
+		Compared:   true,
+		Introduced: summaryFindings[1:2],
+		Fixed:      []Vulnerability{{ID: "SNYK-DEBIAN10-TAR-3", Title: "Path | Traversal", Severity: "medium", PackageName: "tar", Version: "1.30"}},
+	})
+	assert.Equal(t, out.String(), "## Docker Scan results for `debian:10`"+`
+
+| Severity | Findings |
+| --- | ---: |
+| critical | 1 |
+| high | 2 |
+| medium | 0 |
+| low | 1 |
+| **Total** | **4** |
+
+### Top findings
+
+| Severity | Vulnerability | Package | Fixed in |
+| --- | --- | --- | --- |
+| critical | DS002 AWS access key | /app/.env |  |
+| high | [SNYK-DEBIAN10-OPENSSL-2](https://snyk.io/vuln/SNYK-DEBIAN10-OPENSSL-2) NULL Pointer Dereference | openssl@1.1.1d | 1.1.1g |
+| low | [SNYK-DEBIAN10-CURL-1](https://snyk.io/vuln/SNYK-DEBIAN10-CURL-1) Use After Free | curl@7.64.0 |  |
+
+### Changes since the baseline: 1 new, 1 fixed
+
+New findings:
+
+| Severity | Vulnerability | Package | Fixed in |
+| --- | --- | --- | --- |
+| high | [SNYK-DEBIAN10-OPENSSL-2](https://snyk.io/vuln/SNYK-DEBIAN10-OPENSSL-2) NULL Pointer Dereference | openssl@1.1.1d | 1.1.1g |
+
+Fixed findings:
+
+| Severity | Vulnerability | Package | Fixed in |
+| --- | --- | --- | --- |
+| medium | [SNYK-DEBIAN10-TAR-3](https://snyk.io/vuln/SNYK-DEBIAN10-TAR-3) Path \| Traversal | tar@1.30 |  |
+
+`)
+}
+
+func TestWriteMarkdownTruncates(t *testing.T) {
+	var findings []Vulnerability
+	for _, id := range []string{"A", "B", "C", "D", "E", "F", "G", "H", "I", "J", "K", "L"} {
+		findings = append(findings, Vulnerability{ID: id, Severity: "low", PackageName: "pkg"})
+	}
+	out := bytes.NewBuffer(nil)
+	WriteMarkdown(out, MarkdownSummary{Image: "alpine", Findings: findings})
+	assert.Assert(t, bytes.Contains(out.Bytes(), []byte("| low | J | pkg |  |\n\n_and 2 more_\n")))
+	assert.Assert(t, !bytes.Contains(out.Bytes(), []byte("| low | K")))
+}
@@ -0,0 +1,80 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/scan-cli-plugin/internal/theme"
+)
+
+// MatrixColumn is the result of the scan of an image in a comparison matrix
+type MatrixColumn struct {
+	Image  string         `json:"image"`
+	Counts map[string]int `json:"counts,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// NewMatrixColumn counts the findings of an image per severity
+func NewMatrixColumn(image string, findings []Vulnerability) MatrixColumn {
+	counts := CountBySeverity(findings)
+	column := MatrixColumn{Image: image, Counts: map[string]int{}}
+	for _, severity := range Severities {
+		column.Counts[severity] = counts[severity]
+	}
+	return column
+}
+
+// WriteMatrix prints the severity counts of the images side by side, the most severe first
+func WriteMatrix(out io.Writer, columns []MatrixColumn) error {
+	table := bytes.NewBuffer(nil)
+	w := tabwriter.NewWriter(table, 0, 4, 3, ' ', 0)
+	header := []string{"SEVERITY"}
+	for _, column := range columns {
+		header = append(header, column.Image)
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	severities := []string{""}
+	for i := len(Severities) - 1; i >= 0; i-- {
+		writeMatrixRow(w, Severities[i], columns, func(counts map[string]int) int {
+			return counts[Severities[i]]
+		})
+		severities = append(severities, Severities[i])
+	}
+	writeMatrixRow(w, "total", columns, total)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, theme.Current().Lines(table.String(), severities))
+	return err
+}
+
+func writeMatrixRow(out io.Writer, title string, columns []MatrixColumn, value func(map[string]int) int) {
+	row := []string{title}
+	for _, column := range columns {
+		if column.Error != "" {
+			row = append(row, "error")
+			continue
+		}
+		row = append(row, fmt.Sprint(value(column.Counts)))
+	}
+	fmt.Fprintln(out, strings.Join(row, "\t"))
+}
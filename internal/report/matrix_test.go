@@ -0,0 +1,61 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/theme"
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteMatrix(t *testing.T) {
+	columns := []MatrixColumn{
+		NewMatrixColumn("myapp:1.0", summaryFindings),
+		NewMatrixColumn("myapp:1.1", summaryFindings[:1]),
+		{Image: "myapp:1.2", Error: "image not found"},
+	}
+	assert.DeepEqual(t, columns[1].Counts, map[string]int{"low": 1, "medium": 0, "high": 0, "critical": 0})
+
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, WriteMatrix(out, columns))
+	assert.Equal(t, out.String(), `SEVERITY   myapp:1.0   myapp:1.1   myapp:1.2
+critical   1           0           error
+high       2           0           error
+medium     0           0           error
+low        1           1           error
+total      4           1           error
+`)
+}
+
+func TestWriteMatrixTheme(t *testing.T) {
+	colorBlind, err := theme.Get(theme.ColorBlind)
+	assert.NilError(t, err)
+	defer theme.Set(theme.Current())
+	theme.Set(colorBlind)
+
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, WriteMatrix(out, []MatrixColumn{NewMatrixColumn("myapp:1.0", summaryFindings)}))
+	// the rows are colored once aligned
+	assert.Equal(t, out.String(), "SEVERITY   myapp:1.0\n"+
+		"\x1b[1;4;38;5;166mcritical   1\x1b[0m\n"+
+		"\x1b[1;38;5;214mhigh       2\x1b[0m\n"+
+		"\x1b[38;5;74mmedium     0\x1b[0m\n"+
+		"\x1b[38;5;250mlow        1\x1b[0m\n"+
+		"total      4\n")
+}
@@ -0,0 +1,141 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DependencyTree gathers the dependency paths leading to the vulnerable packages of an application manifest, from the
+// direct dependencies to the vulnerable transitive packages
+type DependencyTree struct {
+	Manifest       string            `json:"manifest"`
+	PackageManager string            `json:"packageManager,omitempty"`
+	Dependencies   []*DependencyNode `json:"dependencies"`
+}
+
+// DependencyNode is a package of the dependency paths, with the vulnerabilities found in it
+type DependencyNode struct {
+	Package         string              `json:"package"`
+	Vulnerabilities []DependencyFinding `json:"vulnerabilities,omitempty"`
+	Dependencies    []*DependencyNode   `json:"dependencies,omitempty"`
+}
+
+// DependencyFinding is a vulnerability of a package of the dependency paths
+type DependencyFinding struct {
+	ID       string   `json:"id"`
+	Severity string   `json:"severity"`
+	FixedIn  []string `json:"fixedIn,omitempty"`
+}
+
+// DependencyPaths merges the dependency paths of the application vulnerabilities, reported by the provider in their
+// from field, into a tree per manifest. The first package of a path is the application itself and is left out.
+func DependencyPaths(findings []Vulnerability) []DependencyTree {
+	var trees []*DependencyTree
+	byManifest := map[string]*DependencyTree{}
+	for _, finding := range findings {
+		if finding.Scope() != ScopeApp {
+			continue
+		}
+		tree, ok := byManifest[finding.Path]
+		if !ok {
+			tree = &DependencyTree{Manifest: finding.Path, PackageManager: finding.PackageManager}
+			byManifest[finding.Path] = tree
+			trees = append(trees, tree)
+		}
+		path := []string{finding.PackageName + "@" + finding.Version}
+		if len(finding.From) > 1 {
+			path = finding.From[1:]
+		}
+		nodes := &tree.Dependencies
+		var node *DependencyNode
+		for _, pkg := range path {
+			node = childNode(nodes, pkg)
+			nodes = &node.Dependencies
+		}
+		node.addFinding(finding)
+	}
+	result := []DependencyTree{}
+	for _, tree := range trees {
+		result = append(result, *tree)
+	}
+	return result
+}
+
+// childNode returns the node of the package among the nodes, added if missing
+func childNode(nodes *[]*DependencyNode, pkg string) *DependencyNode {
+	for _, node := range *nodes {
+		if node.Package == pkg {
+			return node
+		}
+	}
+	node := &DependencyNode{Package: pkg}
+	*nodes = append(*nodes, node)
+	return node
+}
+
+func (n *DependencyNode) addFinding(finding Vulnerability) {
+	for _, existing := range n.Vulnerabilities {
+		if existing.ID == finding.ID {
+			return
+		}
+	}
+	n.Vulnerabilities = append(n.Vulnerabilities, DependencyFinding{
+		ID:       finding.ID,
+		Severity: strings.ToLower(finding.Severity),
+		FixedIn:  finding.FixedIn,
+	})
+}
+
+// WriteDependencyPaths prints the dependency trees of the application manifests, the vulnerable packages being marked
+// with their vulnerabilities
+func WriteDependencyPaths(out io.Writer, trees []DependencyTree) {
+	if len(trees) == 0 {
+		fmt.Fprintln(out, "\nDependency paths: no vulnerable application dependency found")
+		return
+	}
+	fmt.Fprintln(out, "\nDependency paths")
+	for _, tree := range trees {
+		fmt.Fprintf(out, "\n%s", tree.Manifest)
+		if tree.PackageManager != "" {
+			fmt.Fprintf(out, " (%s)", tree.PackageManager)
+		}
+		fmt.Fprintln(out)
+		writeDependencyNodes(out, tree.Dependencies, "")
+	}
+}
+
+func writeDependencyNodes(out io.Writer, nodes []*DependencyNode, prefix string) {
+	for i, node := range nodes {
+		branch, indent := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, indent = "└── ", "    "
+		}
+		fmt.Fprintf(out, "%s%s%s", prefix, branch, node.Package)
+		for _, finding := range node.Vulnerabilities {
+			details := finding.Severity
+			if len(finding.FixedIn) > 0 {
+				details += ", fixed in " + strings.Join(finding.FixedIn, ", ")
+			}
+			fmt.Fprintf(out, "  ✗ %s (%s)", finding.ID, details)
+		}
+		fmt.Fprintln(out)
+		writeDependencyNodes(out, node.Dependencies, prefix+indent)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDependencyPaths(t *testing.T) {
+	findings := []Vulnerability{
+		{ID: "SNYK-DEBIAN10-OPENSSL-1", Severity: "high", PackageName: "openssl", Version: "1.1.1d", PackageManager: "deb",
+			From: []string{"docker-image|myapp@latest", "openssl@1.1.1d"}},
+		{ID: "SNYK-JS-QS-1", Severity: "High", PackageName: "qs", Version: "6.7.0", PackageManager: "npm", Path: "/app/package.json",
+			From: []string{"myapp@1.0.0", "express@4.17.1", "body-parser@1.19.0", "qs@6.7.0"}, FixedIn: []string{"6.7.3"}},
+		{ID: "SNYK-JS-QS-1", Severity: "High", PackageName: "qs", Version: "6.7.0", PackageManager: "npm", Path: "/app/package.json",
+			From: []string{"myapp@1.0.0", "express@4.17.1", "qs@6.7.0"}, FixedIn: []string{"6.7.3"}},
+		{ID: "SNYK-JS-LODASH-1", Severity: "medium", PackageName: "lodash", Version: "4.17.15", PackageManager: "npm", Path: "/app/package.json",
+			From: []string{"myapp@1.0.0", "lodash@4.17.15"}},
+		{ID: "SNYK-JS-LODASH-2", Severity: "low", PackageName: "lodash", Version: "4.17.15", PackageManager: "npm", Path: "/app/package.json",
+			From: []string{"myapp@1.0.0", "lodash@4.17.15"}},
+		{ID: "SNYK-GOLANG-1", Severity: "critical", PackageName: "golang.org/x/net", Version: "0.0.1", PackageManager: "gomodules", Path: "/usr/bin/app"},
+	}
+
+	trees := DependencyPaths(findings)
+	assert.Equal(t, len(trees), 2)
+	assert.Equal(t, trees[0].Manifest, "/app/package.json")
+	assert.Equal(t, trees[0].PackageManager, "npm")
+	express := trees[0].Dependencies[0]
+	assert.Equal(t, express.Package, "express@4.17.1")
+	assert.Equal(t, len(express.Dependencies), 2)
+	assert.DeepEqual(t, express.Dependencies[0].Dependencies[0].Vulnerabilities,
+		[]DependencyFinding{{ID: "SNYK-JS-QS-1", Severity: "high", FixedIn: []string{"6.7.3"}}})
+	assert.Equal(t, len(trees[0].Dependencies[1].Vulnerabilities), 2)
+	assert.Equal(t, trees[1].Dependencies[0].Package, "golang.org/x/net@0.0.1")
+
+	out := bytes.NewBuffer(nil)
+	WriteDependencyPaths(out, trees)
+	assert.Equal(t, out.String(), `
+Dependency paths
+
+/app/package.json (npm)
+├── express@4.17.1
+│   ├── body-parser@1.19.0
+│   │   └── qs@6.7.0  ✗ SNYK-JS-QS-1 (high, fixed in 6.7.3)
+│   └── qs@6.7.0  ✗ SNYK-JS-QS-1 (high, fixed in 6.7.3)
+└── lodash@4.17.15  ✗ SNYK-JS-LODASH-1 (medium)  ✗ SNYK-JS-LODASH-2 (low)
+
+/usr/bin/app (gomodules)
+└── golang.org/x/net@0.0.1  ✗ SNYK-GOLANG-1 (critical)
+`)
+
+	out.Reset()
+	WriteDependencyPaths(out, DependencyPaths(findings[:1]))
+	assert.Equal(t, out.String(), "\nDependency paths: no vulnerable application dependency found\n")
+}
@@ -0,0 +1,125 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/pdf"
+)
+
+const (
+	pdfMargin    = 40.0
+	pdfRowHeight = 14.0
+)
+
+var (
+	pdfSeverityColors = map[string]pdf.Color{
+		"critical": {R: 0.55},
+		"high":     {R: 0.87},
+		"medium":   {R: 0.9, G: 0.58},
+		"low":      {R: 0.33, G: 0.33, B: 0.33},
+	}
+	pdfBorderColor = pdf.Color{R: 0.8, G: 0.8, B: 0.8}
+	pdfCellStyle   = pdf.Style{Size: 8}
+	pdfHeaderStyle = pdf.Style{Size: 8, Bold: true}
+)
+
+// pdfColumn is a column of the table of findings, the widths of the columns fill an A4 landscape page
+type pdfColumn struct {
+	title string
+	width float64
+	value func(Vulnerability) string
+}
+
+var pdfColumns = []pdfColumn{
+	{title: "ID", width: 130, value: func(v Vulnerability) string { return strings.Join(v.CVEs(), ", ") }},
+	{title: "Severity", width: 55, value: func(v Vulnerability) string { return v.Severity }},
+	{title: "Package", width: 140, value: func(v Vulnerability) string {
+		if v.PackageName != "" {
+			return v.PackageName
+		}
+		return v.Path
+	}},
+	{title: "Version", width: 85, value: func(v Vulnerability) string { return v.Version }},
+	{title: "Fixed in", width: 85, value: func(v Vulnerability) string { return strings.Join(v.FixedIn, ", ") }},
+	{title: "Title", width: 267, value: func(v Vulnerability) string { return v.Title }},
+}
+
+// WritePDF writes the report of WriteHTML as a static PDF document, on A4 landscape pages
+func WritePDF(out io.Writer, image string, scannedAt time.Time, findings []Vulnerability) error {
+	data := newDocument(image, scannedAt, findings)
+	doc := pdf.New(pdf.A4Height, pdf.A4Width)
+	y := pdf.A4Width - pdfMargin
+	doc.Text(pdfMargin, y, pdf.Style{Size: 16, Bold: true}, "Scan report of "+data.Image)
+	y -= 2 * pdfRowHeight
+	doc.Text(pdfMargin, y, pdf.Style{Size: 10}, fmt.Sprintf("Scanned at %s: %d findings", formatDate(data.ScannedAt), len(data.Findings)))
+	y -= 1.5 * pdfRowHeight
+	x := pdfMargin
+	for i, severity := range data.Severities {
+		text := fmt.Sprintf("%s: %d", severity, data.Counts[i])
+		style := pdf.Style{Size: 10, Bold: true, Color: pdfSeverityColors[severity]}
+		doc.Text(x, y, style, text)
+		x += pdf.TextWidth(text, style) + 20
+	}
+	if len(data.Findings) == 0 {
+		return doc.Write(out)
+	}
+	y -= 2 * pdfRowHeight
+	doc.Text(pdfMargin, y, pdf.Style{Size: 12, Bold: true}, "Findings")
+	y -= pdfRowHeight
+	y = writePDFHeader(doc, y)
+	for _, finding := range data.Findings {
+		if y-pdfRowHeight < pdfMargin {
+			doc.AddPage()
+			y = writePDFHeader(doc, pdf.A4Width-pdfMargin)
+		}
+		y = writePDFRow(doc, y, finding)
+	}
+	return doc.Write(out)
+}
+
+// writePDFHeader writes the header of the table of findings, and returns the position of the next row
+func writePDFHeader(doc *pdf.Document, y float64) float64 {
+	x := pdfMargin
+	for _, column := range pdfColumns {
+		doc.Text(x+2, y-pdfRowHeight+4, pdfHeaderStyle, column.title)
+		x += column.width
+	}
+	return writePDFBorder(doc, y-pdfRowHeight)
+}
+
+func writePDFRow(doc *pdf.Document, y float64, finding Vulnerability) float64 {
+	x := pdfMargin
+	for _, column := range pdfColumns {
+		style := pdfCellStyle
+		if column.title == "Severity" {
+			style.Color = pdfSeverityColors[strings.ToLower(finding.Severity)]
+		}
+		doc.Text(x+2, y-pdfRowHeight+4, style, pdf.Truncate(column.value(finding), style, column.width-4))
+		x += column.width
+	}
+	return writePDFBorder(doc, y-pdfRowHeight)
+}
+
+func writePDFBorder(doc *pdf.Document, y float64) float64 {
+	doc.Line(pdfMargin, y, pdf.A4Height-pdfMargin, y, pdfBorderColor)
+	return y
+}
@@ -0,0 +1,57 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWritePDF(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	findings := []Vulnerability{
+		{ID: "SNYK-1", Title: "Use After Free (CWE-416)", Severity: "high", PackageName: "openssl", Version: "1.1.1d",
+			FixedIn: []string{"1.1.1g"}, Identifiers: map[string][]string{"CVE": {"CVE-2020-1971"}}},
+	}
+	assert.NilError(t, WritePDF(out, "myimage:1.0", time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC), findings))
+	document := out.String()
+	assert.Assert(t, strings.HasPrefix(document, "%PDF-"))
+	assert.Assert(t, strings.Contains(document, "(Scan report of myimage:1.0)"), document)
+	assert.Assert(t, strings.Contains(document, "(Scanned at 2021-06-01 12:00:00 UTC: 1 findings)"), document)
+	assert.Assert(t, strings.Contains(document, "(high: 1)"), document)
+	assert.Assert(t, strings.Contains(document, "(CVE-2020-1971)"), document)
+	assert.Assert(t, strings.Contains(document, "(Use After Free \\(CWE-416\\))"), document)
+	assert.Assert(t, strings.Contains(document, "/Count 1"), document)
+}
+
+func TestWritePDFPages(t *testing.T) {
+	var findings []Vulnerability
+	for i := 0; i < 100; i++ {
+		findings = append(findings, Vulnerability{ID: fmt.Sprintf("SNYK-%d", i), Severity: "low", PackageName: "zlib"})
+	}
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, WritePDF(out, "myimage:1.0", time.Now(), findings))
+	document := out.String()
+	assert.Assert(t, strings.Contains(document, "/Count 4"), document)
+	// the table header is repeated on each page
+	assert.Equal(t, strings.Count(document, "(Fixed in)"), 4)
+}
@@ -0,0 +1,76 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PlatformDifference is a vulnerability of a package found on some of the platforms of a multi-platform image only
+type PlatformDifference struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Severity    string   `json:"severity"`
+	PackageName string   `json:"packageName"`
+	Platforms   []string `json:"platforms"`
+}
+
+// PlatformDifferences returns the vulnerabilities which are not found on all the platforms, the findings being given
+// in the order of the platforms
+func PlatformDifferences(platforms []string, findings [][]Vulnerability) []PlatformDifference {
+	var keys []string
+	byKey := map[string]*PlatformDifference{}
+	for i, platform := range platforms {
+		seen := map[string]bool{}
+		for _, vuln := range findings[i] {
+			key := vulnerabilityKey(vuln)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			difference, ok := byKey[key]
+			if !ok {
+				difference = &PlatformDifference{ID: vuln.ID, Title: vuln.Title, Severity: vuln.Severity, PackageName: vuln.PackageName}
+				byKey[key] = difference
+				keys = append(keys, key)
+			}
+			difference.Platforms = append(difference.Platforms, platform)
+		}
+	}
+	differences := []PlatformDifference{}
+	for _, key := range keys {
+		if len(byKey[key].Platforms) < len(platforms) {
+			differences = append(differences, *byKey[key])
+		}
+	}
+	return differences
+}
+
+// WritePlatformDifferences prints the vulnerabilities found on some platforms only
+func WritePlatformDifferences(out io.Writer, differences []PlatformDifference) {
+	if len(differences) == 0 {
+		fmt.Fprintln(out, "\nAll the platforms have the same vulnerabilities")
+		return
+	}
+	fmt.Fprintf(out, "\nVulnerabilities found on some platforms only: %d\n", len(differences))
+	for _, difference := range differences {
+		fmt.Fprintf(out, "  %s %s in %s (%s): %s\n", severityLabel(Vulnerability{Severity: difference.Severity}),
+			difference.Title, difference.PackageName, difference.ID, strings.Join(difference.Platforms, ", "))
+	}
+}
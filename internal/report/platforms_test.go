@@ -0,0 +1,52 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestPlatformDifferences(t *testing.T) {
+	musl := Vulnerability{ID: "CVE-2019-14697", Title: "Out-of-bounds Write", Severity: "high", PackageName: "musl/musl"}
+	openssl := Vulnerability{ID: "CVE-2021-3711", Title: "Buffer Overflow", Severity: "critical", PackageName: "openssl/libssl1.1"}
+	zlib := Vulnerability{ID: "CVE-2018-25032", Title: "Memory Corruption", Severity: "medium", PackageName: "zlib/zlib"}
+
+	differences := PlatformDifferences([]string{"linux/amd64", "linux/arm64", "linux/arm/v7"}, [][]Vulnerability{
+		{musl, openssl, openssl},
+		{musl, zlib},
+		{musl, openssl},
+	})
+	assert.DeepEqual(t, differences, []PlatformDifference{
+		{ID: "CVE-2021-3711", Title: "Buffer Overflow", Severity: "critical", PackageName: "openssl/libssl1.1", Platforms: []string{"linux/amd64", "linux/arm/v7"}},
+		{ID: "CVE-2018-25032", Title: "Memory Corruption", Severity: "medium", PackageName: "zlib/zlib", Platforms: []string{"linux/arm64"}},
+	})
+
+	out := bytes.NewBuffer(nil)
+	WritePlatformDifferences(out, differences)
+	assert.Equal(t, out.String(), `
+Vulnerabilities found on some platforms only: 2
+  [critical] Buffer Overflow in openssl/libssl1.1 (CVE-2021-3711): linux/amd64, linux/arm/v7
+  [medium] Memory Corruption in zlib/zlib (CVE-2018-25032): linux/arm64
+`)
+
+	out.Reset()
+	WritePlatformDifferences(out, PlatformDifferences([]string{"linux/amd64", "linux/arm64"}, [][]Vulnerability{{musl}, {musl}}))
+	assert.Equal(t, out.String(), "\nAll the platforms have the same vulnerabilities\n")
+}
@@ -0,0 +1,151 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Kinds of base image recommendations
+const (
+	MinorUpgrade     = "minor"
+	MajorUpgrade     = "major"
+	AlternativeImage = "alternative"
+)
+
+// BaseImageRecommendations lists the alternative base images suggested by the provider
+type BaseImageRecommendations struct {
+	BaseImage       string                    `json:"baseImage"`
+	Vulnerabilities int                       `json:"vulnerabilities"`
+	Severities      map[string]int            `json:"severities,omitempty"`
+	Recommendations []BaseImageRecommendation `json:"recommendations"`
+}
+
+// BaseImageRecommendation is an alternative base image, with the vulnerabilities it would fix
+type BaseImageRecommendation struct {
+	Kind            string         `json:"kind"`
+	Image           string         `json:"image"`
+	Vulnerabilities int            `json:"vulnerabilities"`
+	Severities      map[string]int `json:"severities,omitempty"`
+	// Delta is the difference of vulnerabilities with the current base image
+	Delta int `json:"delta"`
+}
+
+var (
+	recommendationRow = regexp.MustCompile(`^(\S+)\s+(\d+)\s+(.*)$`)
+	severityCount     = regexp.MustCompile(`(\d+) (critical|high|medium|low)`)
+	sectionKinds      = map[string]string{
+		"minor upgrades":          MinorUpgrade,
+		"major upgrades":          MajorUpgrade,
+		"alternative image types": AlternativeImage,
+	}
+)
+
+// Recommendations extracts the base image recommendations from the remediation advice of the provider,
+// made of a table for the current base image followed by a table per kind of recommendation
+func Recommendations(report Report) BaseImageRecommendations {
+	recommendations := BaseImageRecommendations{
+		BaseImage:       report.BaseImage,
+		Recommendations: []BaseImageRecommendation{},
+	}
+	kind := ""
+	for _, line := range report.BaseImageAdvice {
+		line = strings.TrimSpace(line)
+		if sectionKind, ok := sectionKinds[strings.ToLower(line)]; ok {
+			kind = sectionKind
+			continue
+		}
+		match := recommendationRow.FindStringSubmatch(line)
+		if match == nil || strings.HasPrefix(line, "Base Image") {
+			continue
+		}
+		count, err := strconv.Atoi(match[2])
+		if err != nil {
+			continue
+		}
+		severities := parseSeverities(match[3])
+		if kind == "" {
+			// the first table describes the current base image
+			if recommendations.BaseImage == "" {
+				recommendations.BaseImage = match[1]
+			}
+			recommendations.Vulnerabilities = count
+			recommendations.Severities = severities
+			continue
+		}
+		recommendations.Recommendations = append(recommendations.Recommendations, BaseImageRecommendation{
+			Kind:            kind,
+			Image:           match[1],
+			Vulnerabilities: count,
+			Severities:      severities,
+		})
+	}
+	for i := range recommendations.Recommendations {
+		recommendations.Recommendations[i].Delta = recommendations.Recommendations[i].Vulnerabilities - recommendations.Vulnerabilities
+	}
+	return recommendations
+}
+
+func parseSeverities(text string) map[string]int {
+	matches := severityCount.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	severities := map[string]int{}
+	for _, match := range matches {
+		count, err := strconv.Atoi(match[1])
+		if err == nil {
+			severities[match[2]] = count
+		}
+	}
+	return severities
+}
+
+// WriteRecommendations prints the base image recommendations as a table
+func WriteRecommendations(out io.Writer, recommendations BaseImageRecommendations) error {
+	if recommendations.BaseImage == "" {
+		fmt.Fprintln(out, "No base image detected, use --file to provide the Dockerfile of the image")
+		return nil
+	}
+	fmt.Fprintf(out, "Base image: %s (%d vulnerabilities)\n\n", recommendations.BaseImage, recommendations.Vulnerabilities)
+	if len(recommendations.Recommendations) == 0 {
+		fmt.Fprintln(out, "No base image upgrade recommended")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "KIND\tIMAGE\tVULNERABILITIES\tDELTA\tSEVERITY")
+	for _, recommendation := range recommendations.Recommendations {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%+d\t%s\n", recommendation.Kind, recommendation.Image,
+			recommendation.Vulnerabilities, recommendation.Delta, formatSeverities(recommendation.Severities))
+	}
+	return w.Flush()
+}
+
+func formatSeverities(severities map[string]int) string {
+	var counts []string
+	for _, severity := range []string{"critical", "high", "medium", "low"} {
+		if count, ok := severities[severity]; ok {
+			counts = append(counts, fmt.Sprintf("%d %s", count, severity))
+		}
+	}
+	return strings.Join(counts, ", ")
+}
@@ -0,0 +1,85 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+const remediationOutput = `{
+  "path": "myimage",
+  "vulnerabilities": [],
+  "docker": {
+    "baseImage": "node:14.1.0",
+    "baseImageRemediation": {
+      "code": "REMEDIATION_AVAILABLE",
+      "advice": [
+        {"message": "Base Image    Vulnerabilities  Severity", "bold": true},
+        {"message": "node:14.1.0   706              60 critical, 200 high, 150 medium, 296 low\n"},
+        {"message": "Recommendations for base image upgrade:\n", "bold": true},
+        {"message": "Minor upgrades", "bold": true},
+        {"message": "Base Image    Vulnerabilities  Severity"},
+        {"message": "node:14.17.0  502              12 critical, 100 high, 100 medium, 290 low\n"},
+        {"message": "Alternative image types", "bold": true},
+        {"message": "Base Image           Vulnerabilities  Severity"},
+        {"message": "node:14-slim         69               0 critical, 1 high, 1 medium, 67 low"},
+        {"message": "node:14-buster-slim  69               0 critical, 1 high, 1 medium, 67 low"}
+      ]
+    }
+  }
+}`
+
+func TestRecommendations(t *testing.T) {
+	report, err := Parse([]byte(remediationOutput))
+	assert.NilError(t, err)
+	recommendations := Recommendations(report)
+	assert.Equal(t, recommendations.BaseImage, "node:14.1.0")
+	assert.Equal(t, recommendations.Vulnerabilities, 706)
+	assert.DeepEqual(t, recommendations.Severities, map[string]int{"critical": 60, "high": 200, "medium": 150, "low": 296})
+	assert.Equal(t, len(recommendations.Recommendations), 3)
+	assert.DeepEqual(t, recommendations.Recommendations[0], BaseImageRecommendation{
+		Kind:            MinorUpgrade,
+		Image:           "node:14.17.0",
+		Vulnerabilities: 502,
+		Severities:      map[string]int{"critical": 12, "high": 100, "medium": 100, "low": 290},
+		Delta:           -204,
+	})
+	assert.Equal(t, recommendations.Recommendations[2].Kind, AlternativeImage)
+	assert.Equal(t, recommendations.Recommendations[2].Image, "node:14-buster-slim")
+
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, WriteRecommendations(out, recommendations))
+	assert.Equal(t, out.String(), `Base image: node:14.1.0 (706 vulnerabilities)
+
+KIND          IMAGE                 VULNERABILITIES   DELTA   SEVERITY
+minor         node:14.17.0          502               -204    12 critical, 100 high, 100 medium, 290 low
+alternative   node:14-slim          69                -637    0 critical, 1 high, 1 medium, 67 low
+alternative   node:14-buster-slim   69                -637    0 critical, 1 high, 1 medium, 67 low
+`)
+}
+
+func TestRecommendationsWithoutAdvice(t *testing.T) {
+	recommendations := Recommendations(Report{})
+	assert.Equal(t, len(recommendations.Recommendations), 0)
+
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, WriteRecommendations(out, recommendations))
+	assert.Equal(t, out.String(), "No base image detected, use --file to provide the Dockerfile of the image\n")
+}
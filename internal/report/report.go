@@ -0,0 +1,219 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Report is the result of a scan, normalized from the provider JSON output
+type Report struct {
+	Path            string
+	BaseImage       string
+	Vulnerabilities []Vulnerability
+	// BaseImageAdvice holds the lines of the base image remediation advice
+	BaseImageAdvice []string
+	// URL is the web report of the scan hosted by the provider, when the image is monitored
+	URL string
+}
+
+// Vulnerability is a finding reported by the scan provider or by an analyzer of the plugin,
+// its type is either a vulnerable package, a license issue, a misconfiguration, a secret or a malware match
+type Vulnerability struct {
+	ID                    string   `json:"id"`
+	Type                  string   `json:"type,omitempty"`
+	Title                 string   `json:"title"`
+	Severity              string   `json:"severity"`
+	PackageName           string   `json:"packageName"`
+	Version               string   `json:"version"`
+	From                  []string `json:"from,omitempty"`
+	FixedIn               []string `json:"fixedIn,omitempty"`
+	DockerfileInstruction string   `json:"dockerfileInstruction,omitempty"`
+	DockerBaseImage       string   `json:"dockerBaseImage,omitempty"`
+	// DockerfileStage is the build stage of the Dockerfile instruction, or the stage a COPY --from instruction copied
+	// the package from
+	DockerfileStage string `json:"dockerfileStage,omitempty"`
+	// DockerfileLine is the line of the Dockerfile instruction
+	DockerfileLine int    `json:"dockerfileLine,omitempty"`
+	PackageManager string `json:"packageManager,omitempty"`
+	Path           string `json:"path,omitempty"`
+	Layer          string `json:"layer,omitempty"`
+	// License is the license of the package of a license issue
+	License string `json:"license,omitempty"`
+	// Identifiers lists the public identifiers of the vulnerability by kind, like CVE or CWE
+	Identifiers map[string][]string `json:"identifiers,omitempty"`
+	// FirstSeen is when the finding was first found in the image repository, when the age of the findings is tracked
+	FirstSeen *time.Time `json:"firstSeen,omitempty"`
+	// VEXStatus is the status of the finding in the VEX documents given with --vex, when it isn't suppressed
+	VEXStatus string `json:"vexStatus,omitempty"`
+	// VEXJustification explains the VEX status
+	VEXJustification string `json:"vexJustification,omitempty"`
+	// Exploit tells how likely the vulnerability is exploited, when the findings are enriched with --enrich
+	Exploit *Exploit `json:"exploit,omitempty"`
+}
+
+// Exploit is the likelihood a vulnerability is exploited
+type Exploit struct {
+	// EPSS is the probability the vulnerability is exploited in the next 30 days, from 0 to 1
+	EPSS float64 `json:"epss"`
+	// Percentile ranks the EPSS score among the scores of all the CVEs, from 0 to 1
+	Percentile float64 `json:"percentile"`
+	// KnownExploited is true if the vulnerability is in the CISA catalog of Known Exploited Vulnerabilities
+	KnownExploited bool `json:"knownExploited"`
+}
+
+// OpenDays returns the number of whole days the finding has been open, 0 if its age is not tracked
+func (v Vulnerability) OpenDays(now time.Time) int {
+	if v.FirstSeen == nil {
+		return 0
+	}
+	return int(now.Sub(*v.FirstSeen).Hours() / 24)
+}
+
+const (
+	// VulnerabilityType is the type of the vulnerabilities reported by the provider
+	VulnerabilityType = "vuln"
+	// LicenseType is the type of the license policy issues reported by the provider
+	LicenseType = "license"
+	// MalwareType is the type of the files matching a malware detection rule
+	MalwareType = "malware"
+	// ConfigType is the type of the image configuration issues
+	ConfigType = "config"
+	// SecretType is the type of the secrets found in the image files
+	SecretType = "secret"
+)
+
+// Scopes of the findings
+const (
+	ScopeOS       = "os"
+	ScopeApp      = "app"
+	ScopeConfig   = "config"
+	ScopeSecrets  = "secrets"
+	ScopeLicenses = "licenses"
+)
+
+// Scopes lists the supported scopes
+var Scopes = []string{ScopeOS, ScopeApp, ScopeConfig, ScopeSecrets, ScopeLicenses}
+
+var osPackageManagers = map[string]bool{
+	"":      true,
+	"apk":   true,
+	"deb":   true,
+	"rpm":   true,
+	"linux": true,
+}
+
+// Scope returns the scope of the finding, malware matches have no scope
+func (v Vulnerability) Scope() string {
+	switch v.Type {
+	case LicenseType:
+		return ScopeLicenses
+	case ConfigType:
+		return ScopeConfig
+	case SecretType:
+		return ScopeSecrets
+	case MalwareType:
+		return ""
+	}
+	if osPackageManagers[v.PackageManager] {
+		return ScopeOS
+	}
+	return ScopeApp
+}
+
+// Fixable returns true if the finding is fixed in a newer version of its package
+func (v Vulnerability) Fixable() bool {
+	return len(v.FixedIn) > 0
+}
+
+// CVEs returns the CVE identifiers of the finding, or its own identifier when it has none
+func (v Vulnerability) CVEs() []string {
+	if ids := v.Identifiers["CVE"]; len(ids) > 0 {
+		return ids
+	}
+	return []string{v.ID}
+}
+
+type snykResult struct {
+	OK              *bool           `json:"ok"`
+	Error           string          `json:"error"`
+	Path            string          `json:"path"`
+	PackageManager  string          `json:"packageManager"`
+	TargetFile      string          `json:"targetFile"`
+	URI             string          `json:"uri"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	Docker          struct {
+		BaseImage            string `json:"baseImage"`
+		BaseImageRemediation struct {
+			Advice []struct {
+				Message string `json:"message"`
+			} `json:"advice"`
+		} `json:"baseImageRemediation"`
+	} `json:"docker"`
+}
+
+// Parse reads the JSON output of the scan provider, which is either a single result
+// or a list of results when the image contains application dependencies
+func Parse(document []byte) (Report, error) {
+	trimmed := bytes.TrimSpace(document)
+	var results []snykResult
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &results); err != nil {
+			return Report{}, fmt.Errorf("invalid provider output: %s", err)
+		}
+	} else {
+		var result snykResult
+		if err := json.Unmarshal(trimmed, &result); err != nil {
+			return Report{}, fmt.Errorf("invalid provider output: %s", err)
+		}
+		results = append(results, result)
+	}
+
+	var report Report
+	for _, result := range results {
+		if result.Error != "" {
+			return Report{}, fmt.Errorf("%s", result.Error)
+		}
+		if report.Path == "" {
+			report.Path = result.Path
+		}
+		if report.BaseImage == "" {
+			report.BaseImage = result.Docker.BaseImage
+		}
+		if report.URL == "" {
+			report.URL = result.URI
+		}
+		for _, vuln := range result.Vulnerabilities {
+			if vuln.PackageManager == "" {
+				vuln.PackageManager = result.PackageManager
+			}
+			// the application dependencies are located by the manifest file they were found in
+			if vuln.Path == "" {
+				vuln.Path = result.TargetFile
+			}
+			report.Vulnerabilities = append(report.Vulnerabilities, vuln)
+		}
+		for _, advice := range result.Docker.BaseImageRemediation.Advice {
+			report.BaseImageAdvice = append(report.BaseImageAdvice, strings.Split(advice.Message, "\n")...)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,72 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParse(t *testing.T) {
+	report, err := Parse([]byte(`{
+  "vulnerabilities": [
+    {"id": "SNYK-ALPINE310-MUSL-458116", "title": "Out-of-bounds Write", "severity": "high",
+     "packageName": "musl", "version": "1.1.22-r2", "from": ["docker-image|alpine@3.10.0", "musl@1.1.22-r2"],
+     "fixedIn": ["1.1.22-r4"], "dockerBaseImage": "alpine:3.10.0"}
+  ],
+  "ok": false,
+  "path": "alpine:3.10.0",
+  "docker": {"baseImage": "alpine:3.10.0"}
+}`))
+	assert.NilError(t, err)
+	assert.Equal(t, report.Path, "alpine:3.10.0")
+	assert.Equal(t, report.BaseImage, "alpine:3.10.0")
+	assert.DeepEqual(t, report.Vulnerabilities, []Vulnerability{{
+		ID:              "SNYK-ALPINE310-MUSL-458116",
+		Title:           "Out-of-bounds Write",
+		Severity:        "high",
+		PackageName:     "musl",
+		Version:         "1.1.22-r2",
+		From:            []string{"docker-image|alpine@3.10.0", "musl@1.1.22-r2"},
+		FixedIn:         []string{"1.1.22-r4"},
+		DockerBaseImage: "alpine:3.10.0",
+	}})
+}
+
+func TestParseMultipleResults(t *testing.T) {
+	report, err := Parse([]byte(`[
+  {"path": "node:14", "vulnerabilities": [{"id": "SNYK-1", "packageName": "openssl"}]},
+  {"path": "node:14", "vulnerabilities": [{"id": "SNYK-JS-2", "packageName": "lodash"}]}
+]`))
+	assert.NilError(t, err)
+	assert.Equal(t, len(report.Vulnerabilities), 2)
+	assert.Equal(t, report.Vulnerabilities[1].ID, "SNYK-JS-2")
+}
+
+func TestParseProviderError(t *testing.T) {
+	_, err := Parse([]byte(`{"ok": false, "error": "manifest unknown", "path": "scratch"}`))
+	assert.Error(t, err, "manifest unknown")
+
+	_, err = Parse([]byte(`Testing scratch...`))
+	assert.ErrorContains(t, err, "invalid provider output")
+}
+
+func TestFixable(t *testing.T) {
+	assert.Assert(t, Vulnerability{ID: "SNYK-1", FixedIn: []string{"1.1.1g"}}.Fixable())
+	assert.Assert(t, !Vulnerability{ID: "SNYK-2"}.Fixable())
+}
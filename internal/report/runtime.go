@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+)
+
+// InfoSeverity is the severity of the findings outside of the runtime attack surface of the image,
+// lower than all the Severities so they are not counted by the severity thresholds
+const InfoSeverity = "info"
+
+// IsInformational returns true if the finding has been downgraded to the informational severity
+func IsInformational(vuln Vulnerability) bool {
+	return strings.EqualFold(vuln.Severity, InfoSeverity)
+}
+
+// MatchesPath returns true if the file matches one of the patterns. A pattern without a slash matches the
+// file name, like "*.md", a pattern ending with "/**" matches all the files of a directory, like "/usr/share/doc/**",
+// any other pattern matches the whole path like "/usr/src/*/test/*".
+func MatchesPath(patterns []string, file string) bool {
+	if file == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		switch {
+		case !strings.Contains(pattern, "/"):
+			if matched, _ := path.Match(pattern, path.Base(file)); matched {
+				return true
+			}
+		case strings.HasSuffix(pattern, "/**"):
+			dir := strings.TrimSuffix(pattern, "/**")
+			for parent := path.Dir(file); parent != "/" && parent != "."; parent = path.Dir(parent) {
+				if matched, _ := path.Match(dir, parent); matched {
+					return true
+				}
+			}
+		default:
+			if matched, _ := path.Match(pattern, file); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Downgrade returns a copy of the report with the vulnerabilities found in the non runtime paths
+// marked as informational
+func Downgrade(report Report, patterns []string) Report {
+	downgraded := report
+	downgraded.Vulnerabilities = make([]Vulnerability, len(report.Vulnerabilities))
+	for i, vuln := range report.Vulnerabilities {
+		if MatchesPath(patterns, vuln.Path) {
+			vuln.Severity = InfoSeverity
+		}
+		downgraded.Vulnerabilities[i] = vuln
+	}
+	return downgraded
+}
+
+// DowngradeDocument marks as informational the vulnerabilities of the provider JSON output found in
+// the non runtime paths. The other fields are kept as is.
+func DowngradeDocument(document []byte, patterns []string) ([]byte, error) {
+	return rewriteDocument(document, func(result map[string]json.RawMessage) error {
+		return downgradeResult(result, patterns)
+	})
+}
+
+func downgradeResult(result map[string]json.RawMessage, patterns []string) error {
+	raw, ok := result["vulnerabilities"]
+	if !ok {
+		return nil
+	}
+	var targetFile string
+	if rawTargetFile, ok := result["targetFile"]; ok {
+		_ = json.Unmarshal(rawTargetFile, &targetFile)
+	}
+	var vulns []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &vulns); err != nil {
+		return err
+	}
+	severity, err := json.Marshal(InfoSeverity)
+	if err != nil {
+		return err
+	}
+	for _, vuln := range vulns {
+		file := targetFile
+		if rawPath, ok := vuln["path"]; ok {
+			_ = json.Unmarshal(rawPath, &file)
+		}
+		if MatchesPath(patterns, file) {
+			vuln["severity"] = severity
+		}
+	}
+	buf, err := json.Marshal(vulns)
+	if err != nil {
+		return err
+	}
+	result["vulnerabilities"] = buf
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestMatchesPath(t *testing.T) {
+	patterns := []string{"*.md", "/usr/share/doc/**", "/app/*/test/*"}
+	assert.Assert(t, MatchesPath(patterns, "/app/README.md"))
+	assert.Assert(t, MatchesPath(patterns, "/usr/share/doc/openssl/changelog.gz"))
+	assert.Assert(t, MatchesPath(patterns, "/app/node_modules/test/package.json"))
+	assert.Assert(t, !MatchesPath(patterns, "/usr/share/docs/index.html"))
+	assert.Assert(t, !MatchesPath(patterns, "/app/package.json"))
+	assert.Assert(t, !MatchesPath(patterns, ""))
+}
+
+func TestDowngrade(t *testing.T) {
+	report := Report{Vulnerabilities: []Vulnerability{
+		{ID: "SNYK-1", Severity: "high", Path: "/usr/share/doc/tool/examples/package.json"},
+		{ID: "SNYK-2", Severity: "high", Path: "/app/package.json"},
+	}}
+	downgraded := Downgrade(report, []string{"/usr/share/doc/**"})
+	assert.Equal(t, downgraded.Vulnerabilities[0].Severity, InfoSeverity)
+	assert.Assert(t, IsInformational(downgraded.Vulnerabilities[0]))
+	assert.Equal(t, downgraded.Vulnerabilities[1].Severity, "high")
+	assert.Equal(t, report.Vulnerabilities[0].Severity, "high")
+	assert.Assert(t, !AtLeast("low")(downgraded.Vulnerabilities[0]))
+}
+
+func TestParseTargetFile(t *testing.T) {
+	report, err := Parse([]byte(`{"ok": false, "targetFile": "/docs/site/package.json",
+  "vulnerabilities": [{"id": "SNYK-JS-LODASH-1", "severity": "high"}]}`))
+	assert.NilError(t, err)
+	assert.Equal(t, report.Vulnerabilities[0].Path, "/docs/site/package.json")
+}
+
+func TestDowngradeDocument(t *testing.T) {
+	document := `[
+  {"ok": false, "targetFile": "/docs/site/package.json", "vulnerabilities": [{"id": "SNYK-JS-LODASH-1", "severity": "high", "title": "Prototype Pollution"}]},
+  {"ok": false, "targetFile": "/app/package.json", "vulnerabilities": [{"id": "SNYK-JS-LODASH-1", "severity": "high"}]}
+]`
+	downgraded, err := DowngradeDocument([]byte(document), []string{"/docs/**"})
+	assert.NilError(t, err)
+	var results []struct {
+		Vulnerabilities []map[string]string `json:"vulnerabilities"`
+	}
+	assert.NilError(t, json.Unmarshal(downgraded, &results))
+	assert.Equal(t, results[0].Vulnerabilities[0]["severity"], InfoSeverity)
+	assert.Equal(t, results[0].Vulnerabilities[0]["title"], "Prototype Pollution")
+	assert.Equal(t, results[1].Vulnerabilities[0]["severity"], "high")
+}
@@ -0,0 +1,142 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// purlTypes maps the package managers of the findings and of the license scanner to the package URL types
+var purlTypes = map[string]string{
+	"apk":       "apk",
+	"deb":       "deb",
+	"rpm":       "rpm",
+	"npm":       "npm",
+	"pip":       "pypi",
+	"python":    "pypi",
+	"maven":     "maven",
+	"gomodules": "golang",
+	"golang":    "golang",
+	"rubygems":  "gem",
+	"nuget":     "nuget",
+	"cargo":     "cargo",
+	"composer":  "composer",
+}
+
+type cycloneDXComponent struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+// SBOM returns a CycloneDX SBOM of the packages of a JSON report of docker scan, to scan them again against the
+// current vulnerabilities. The packages are the vulnerable ones and, when the report lists them, the packages found
+// by the license scanner, so the SBOM of a report without packages misses the packages without vulnerabilities.
+func SBOM(document []byte) ([]byte, error) {
+	scanReport, err := Parse(document)
+	if err != nil {
+		return nil, err
+	}
+	var extra struct {
+		Packages      []PackageLicense `json:"packages"`
+		ImageMetadata struct {
+			OS struct {
+				ID        string `json:"id"`
+				VersionID string `json:"versionId"`
+			} `json:"os"`
+		} `json:"imageMetadata"`
+	}
+	if trimmed := bytes.TrimSpace(document); bytes.HasPrefix(trimmed, []byte("{")) {
+		if err := json.Unmarshal(trimmed, &extra); err != nil {
+			return nil, err
+		}
+	}
+	osID, osVersion := extra.ImageMetadata.OS.ID, extra.ImageMetadata.OS.VersionID
+	components := map[string]cycloneDXComponent{}
+	add := func(packageManager, name, version string) {
+		component := cycloneDXComponent{Type: "library", Name: name, Version: version, PURL: purl(packageManager, name, version, osID, osVersion)}
+		components[component.PURL+"|"+name+"@"+version] = component
+	}
+	for _, vuln := range scanReport.Vulnerabilities {
+		if vuln.Type == "" || vuln.Type == VulnerabilityType {
+			add(vuln.PackageManager, vuln.PackageName, vuln.Version)
+		}
+	}
+	for _, pkg := range extra.Packages {
+		add(pkg.Type, pkg.Name, pkg.Version)
+	}
+	keys := make([]string, 0, len(components))
+	for key := range components {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	sbom := struct {
+		BOMFormat   string               `json:"bomFormat"`
+		SpecVersion string               `json:"specVersion"`
+		Version     int                  `json:"version"`
+		Components  []cycloneDXComponent `json:"components"`
+	}{BOMFormat: "CycloneDX", SpecVersion: "1.4", Version: 1, Components: []cycloneDXComponent{}}
+	if osID != "" {
+		sbom.Components = append(sbom.Components, cycloneDXComponent{Type: "operating-system", Name: osID, Version: osVersion})
+	}
+	for _, key := range keys {
+		sbom.Components = append(sbom.Components, components[key])
+	}
+	return json.MarshalIndent(sbom, "", "  ")
+}
+
+// purl returns the package URL of a package, the OS packages are namespaced by the distribution of the image
+func purl(packageManager, name, version, osID, osVersion string) string {
+	purlType, ok := purlTypes[packageManager]
+	if !ok {
+		return ""
+	}
+	switch purlType {
+	case "apk", "deb", "rpm":
+		// the provider names the OS packages after their source package, like openssl/libssl1.1
+		name = name[strings.LastIndex(name, "/")+1:]
+		namespace := osID
+		if namespace == "" && purlType == "apk" {
+			namespace = "alpine"
+		}
+		p := fmt.Sprintf("pkg:%s/%s/%s@%s", purlType, namespace, purlEscape(name), purlEscape(version))
+		if osID != "" && osVersion != "" {
+			p += "?distro=" + url.QueryEscape(osID+"-"+osVersion)
+		}
+		return p
+	case "maven":
+		if parts := strings.SplitN(name, ":", 2); len(parts) == 2 {
+			return fmt.Sprintf("pkg:maven/%s/%s@%s", purlEscape(parts[0]), purlEscape(parts[1]), purlEscape(version))
+		}
+	}
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = purlEscape(segment)
+	}
+	return fmt.Sprintf("pkg:%s/%s@%s", purlType, strings.Join(segments, "/"), purlEscape(version))
+}
+
+// purlEscape percent-encodes a segment of a package URL, including the @ of the scoped npm packages
+func purlEscape(segment string) string {
+	return strings.Replace(url.PathEscape(segment), "@", "%40", -1)
+}
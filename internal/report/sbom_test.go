@@ -0,0 +1,61 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSBOM(t *testing.T) {
+	document := []byte(`{
+  "ok": false,
+  "path": "myapp:latest",
+  "packageManager": "deb",
+  "vulnerabilities": [
+    {"id": "SNYK-DEBIAN10-OPENSSL-1075326", "title": "Integer Overflow", "severity": "high", "packageName": "openssl/libssl1.1", "version": "1.1.1d-0+deb10u3"},
+    {"id": "SNYK-DEBIAN10-OPENSSL-1075326", "title": "Integer Overflow", "severity": "high", "packageName": "openssl/libssl1.1", "version": "1.1.1d-0+deb10u3"},
+    {"id": "snyk:lic:deb:bash:GPL-3.0", "type": "license", "title": "GPL-3.0 license", "severity": "medium", "packageName": "bash", "version": "5.0-4"}
+  ],
+  "packages": [
+    {"name": "@types/node", "version": "14.0.0", "type": "npm", "licenses": ["MIT"]}
+  ],
+  "imageMetadata": {"os": {"id": "debian", "versionId": "10"}}
+}`)
+	buf, err := SBOM(document)
+	assert.NilError(t, err)
+	var sbom struct {
+		BOMFormat  string
+		Components []cycloneDXComponent
+	}
+	assert.NilError(t, json.Unmarshal(buf, &sbom))
+	assert.Equal(t, sbom.BOMFormat, "CycloneDX")
+	assert.DeepEqual(t, sbom.Components, []cycloneDXComponent{
+		{Type: "operating-system", Name: "debian", Version: "10"},
+		{Type: "library", Name: "openssl/libssl1.1", Version: "1.1.1d-0+deb10u3", PURL: "pkg:deb/debian/libssl1.1@1.1.1d-0+deb10u3?distro=debian-10"},
+		{Type: "library", Name: "@types/node", Version: "14.0.0", PURL: "pkg:npm/%40types/node@14.0.0"},
+	})
+}
+
+func TestPURL(t *testing.T) {
+	assert.Equal(t, purl("apk", "openssl/libssl1.1", "1.1.1k-r0", "", ""), "pkg:apk/alpine/libssl1.1@1.1.1k-r0")
+	assert.Equal(t, purl("maven", "org.apache.logging.log4j:log4j-core", "2.14.1", "", ""), "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1")
+	assert.Equal(t, purl("gomodules", "golang.org/x/text", "v0.3.5", "", ""), "pkg:golang/golang.org/x/text@v0.3.5")
+	assert.Equal(t, purl("unknown", "thing", "1.0", "", ""), "")
+}
@@ -0,0 +1,50 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import "strings"
+
+// Severities lists the severities of the findings, from the lowest to the highest
+var Severities = []string{"low", "medium", "high", "critical"}
+
+// SeverityLevel returns the rank of the severity in Severities, or -1 for an unknown severity
+func SeverityLevel(severity string) int {
+	severity = strings.ToLower(severity)
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// AtLeast returns a filter keeping the findings of the given severity or higher
+func AtLeast(severity string) func(Vulnerability) bool {
+	level := SeverityLevel(severity)
+	return func(vuln Vulnerability) bool {
+		return SeverityLevel(vuln.Severity) >= level
+	}
+}
+
+// CountBySeverity returns the number of findings of each severity, keyed by the lower case severity
+func CountBySeverity(findings []Vulnerability) map[string]int {
+	counts := map[string]int{}
+	for _, finding := range findings {
+		counts[strings.ToLower(finding.Severity)]++
+	}
+	return counts
+}
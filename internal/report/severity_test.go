@@ -0,0 +1,36 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSeverityLevel(t *testing.T) {
+	assert.Equal(t, SeverityLevel("low"), 0)
+	assert.Equal(t, SeverityLevel("Critical"), 3)
+	assert.Equal(t, SeverityLevel("unknown"), -1)
+}
+
+func TestAtLeast(t *testing.T) {
+	keep := AtLeast("high")
+	assert.Assert(t, keep(Vulnerability{Severity: "critical"}))
+	assert.Assert(t, keep(Vulnerability{Severity: "high"}))
+	assert.Assert(t, !keep(Vulnerability{Severity: "medium"}))
+}
@@ -0,0 +1,74 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+)
+
+// SquashSimulation estimates what squashing the image, or moving its build steps to another stage, would remove:
+// the files replaced or deleted by a more recent layer, and the findings located in these files
+type SquashSimulation struct {
+	Files    int             `json:"files"`
+	Size     int64           `json:"size"`
+	Findings []Vulnerability `json:"findings"`
+	Binaries []Binary        `json:"binaries,omitempty"`
+}
+
+// SimulateSquash returns the findings and binaries of the layers located in the hidden files
+func SimulateSquash(hidden []image.HiddenFile, findings []Vulnerability, binaries []Binary) SquashSimulation {
+	simulation := SquashSimulation{Files: len(hidden), Findings: []Vulnerability{}}
+	hiddenFiles := map[string]bool{}
+	for _, file := range hidden {
+		simulation.Size += file.Size
+		hiddenFiles[file.Layer+":"+file.Path] = true
+	}
+	for _, finding := range findings {
+		if finding.Layer != "" && hiddenFiles[finding.Layer+":"+finding.Path] {
+			simulation.Findings = append(simulation.Findings, finding)
+		}
+	}
+	for _, binary := range binaries {
+		if hiddenFiles[binary.Layer+":"+binary.Path] {
+			simulation.Binaries = append(simulation.Binaries, binary)
+		}
+	}
+	return simulation
+}
+
+// WriteSquashSimulation prints the files and findings which would disappear if the image were squashed
+func WriteSquashSimulation(out io.Writer, simulation SquashSimulation) {
+	fmt.Fprintf(out, "\nSquashing the image would remove %d files (%.1f MB) replaced or deleted by a more recent layer\n",
+		simulation.Files, float64(simulation.Size)/1e6)
+	if len(simulation.Findings) == 0 && len(simulation.Binaries) == 0 {
+		fmt.Fprintln(out, "  No finding is located in these files")
+		return
+	}
+	for _, finding := range simulation.Findings {
+		fmt.Fprintf(out, "  ✗ %s severity: %s (%s)\n", strings.Title(finding.Severity), finding.Title, finding.ID)
+		fmt.Fprintf(out, "    Path: %s\n", finding.Path)
+		fmt.Fprintf(out, "    Layer: %s\n", finding.Layer)
+	}
+	for _, binary := range simulation.Binaries {
+		fmt.Fprintf(out, "  ✗ Standalone binary: %s\n", binary.Path)
+		fmt.Fprintf(out, "    Layer: %s\n", binary.Layer)
+	}
+}
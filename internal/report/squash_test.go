@@ -0,0 +1,62 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"gotest.tools/v3/assert"
+)
+
+func TestSimulateSquash(t *testing.T) {
+	hidden := []image.HiddenFile{
+		{Layer: "sha256:build", Path: "/root/.ssh/id_rsa", Size: 1500000},
+		{Layer: "sha256:build", Path: "/src/build/tool", Size: 2000000},
+	}
+	key := Vulnerability{ID: "SECRET-PRIVATE-KEY", Type: SecretType, Title: "Private key", Severity: "high", Path: "/root/.ssh/id_rsa", Layer: "sha256:build"}
+	token := Vulnerability{ID: "SECRET-GITHUB-TOKEN", Type: SecretType, Title: "GitHub token", Severity: "high", Path: "/app/.env", Layer: "sha256:app"}
+	tool := Binary{Path: "/src/build/tool", Layer: "sha256:build"}
+	server := Binary{Path: "/app/server", Layer: "sha256:app"}
+
+	simulation := SimulateSquash(hidden, []Vulnerability{key, token}, []Binary{tool, server})
+	assert.DeepEqual(t, simulation, SquashSimulation{
+		Files:    2,
+		Size:     3500000,
+		Findings: []Vulnerability{key},
+		Binaries: []Binary{tool},
+	})
+
+	out := bytes.NewBuffer(nil)
+	WriteSquashSimulation(out, simulation)
+	assert.Equal(t, out.String(), `
+Squashing the image would remove 2 files (3.5 MB) replaced or deleted by a more recent layer
+  ✗ High severity: Private key (SECRET-PRIVATE-KEY)
+    Path: /root/.ssh/id_rsa
+    Layer: sha256:build
+  ✗ Standalone binary: /src/build/tool
+    Layer: sha256:build
+`)
+
+	out.Reset()
+	WriteSquashSimulation(out, SimulateSquash(nil, []Vulnerability{token}, nil))
+	assert.Equal(t, out.String(), `
+Squashing the image would remove 0 files (0.0 MB) replaced or deleted by a more recent layer
+  No finding is located in these files
+`)
+}
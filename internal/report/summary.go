@@ -0,0 +1,75 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/scan-cli-plugin/internal/theme"
+)
+
+// WriteCounts prints the number of findings per severity on a single line
+func WriteCounts(out io.Writer, findings []Vulnerability) {
+	counts := CountBySeverity(findings)
+	var parts []string
+	for i := len(Severities) - 1; i >= 0; i-- {
+		parts = append(parts, theme.Current().Severity(Severities[i], fmt.Sprintf("%s: %d", Severities[i], counts[Severities[i]])))
+	}
+	if counts[InfoSeverity] > 0 {
+		parts = append(parts, fmt.Sprintf("%s: %d", InfoSeverity, counts[InfoSeverity]))
+	}
+	fmt.Fprintf(out, "%d findings (%s)\n", len(findings), strings.Join(parts, ", "))
+}
+
+// WriteSummary prints a table with a line per CVE and package, the most severe first
+func WriteSummary(out io.Writer, findings []Vulnerability) error {
+	table := bytes.NewBuffer(nil)
+	w := tabwriter.NewWriter(table, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "ID\tSEVERITY\tPACKAGE\tVERSION\tFIXED IN\tTITLE")
+	severities := []string{""}
+	seen := map[string]bool{}
+	for level := len(Severities) - 1; level >= -1; level-- {
+		for _, finding := range findings {
+			if SeverityLevel(finding.Severity) != level {
+				continue
+			}
+			pkg := finding.PackageName
+			if pkg == "" {
+				pkg = finding.Path
+			}
+			for _, id := range finding.CVEs() {
+				key := id + "@" + pkg
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", id, finding.Severity, pkg, finding.Version,
+					strings.Join(finding.FixedIn, ", "), finding.Title)
+				severities = append(severities, finding.Severity)
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, theme.Current().Lines(table.String(), severities))
+	return err
+}
@@ -0,0 +1,50 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+var summaryFindings = []Vulnerability{
+	{ID: "SNYK-DEBIAN10-CURL-1", Title: "Use After Free", Severity: "low", PackageName: "curl", Version: "7.64.0",
+		Identifiers: map[string][]string{"CVE": {"CVE-2019-5436"}}},
+	{ID: "SNYK-DEBIAN10-OPENSSL-2", Title: "NULL Pointer Dereference", Severity: "high", PackageName: "openssl",
+		Version: "1.1.1d", FixedIn: []string{"1.1.1g"}, Identifiers: map[string][]string{"CVE": {"CVE-2020-1971"}}},
+	{ID: "SNYK-DEBIAN10-OPENSSL-2", Title: "NULL Pointer Dereference", Severity: "high", PackageName: "openssl",
+		Version: "1.1.1d", FixedIn: []string{"1.1.1g"}, Identifiers: map[string][]string{"CVE": {"CVE-2020-1971"}}},
+	{ID: "DS002", Type: SecretType, Title: "AWS access key", Severity: "critical", Path: "/app/.env"},
+}
+
+func TestWriteCounts(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	WriteCounts(out, summaryFindings)
+	assert.Equal(t, out.String(), "4 findings (critical: 1, high: 2, medium: 0, low: 1)\n")
+}
+
+func TestWriteSummary(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, WriteSummary(out, summaryFindings))
+	assert.Equal(t, out.String(), `ID              SEVERITY   PACKAGE     VERSION   FIXED IN   TITLE
+DS002           critical   /app/.env                        AWS access key
+CVE-2020-1971   high       openssl     1.1.1d    1.1.1g     NULL Pointer Dereference
+CVE-2019-5436   low        curl        7.64.0               Use After Free
+`)
+}
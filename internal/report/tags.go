@@ -0,0 +1,139 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// RecommendTag returns the scanned image with the fewest critical findings, then the fewest high findings,
+// then the fewest findings. On a tie the first image is kept, the tags being ordered from the most recent.
+func RecommendTag(columns []MatrixColumn) (MatrixColumn, bool) {
+	var best MatrixColumn
+	found := false
+	for _, column := range columns {
+		if column.Error != "" {
+			continue
+		}
+		if !found || fewerFindings(column, best) {
+			best = column
+			found = true
+		}
+	}
+	return best, found
+}
+
+func fewerFindings(column, other MatrixColumn) bool {
+	for _, severity := range []string{"critical", "high"} {
+		if column.Counts[severity] != other.Counts[severity] {
+			return column.Counts[severity] < other.Counts[severity]
+		}
+	}
+	return total(column.Counts) < total(other.Counts)
+}
+
+func total(counts map[string]int) int {
+	sum := 0
+	for _, count := range counts {
+		sum += count
+	}
+	return sum
+}
+
+// WriteTagRecommendation prints the severity counts of each scanned tag and the recommended one
+func WriteTagRecommendation(out io.Writer, columns []MatrixColumn) error {
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	header := []string{"IMAGE"}
+	for i := len(Severities) - 1; i >= 0; i-- {
+		header = append(header, strings.ToUpper(Severities[i]))
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, column := range columns {
+		row := []string{column.Image}
+		for i := len(Severities) - 1; i >= 0; i-- {
+			if column.Error != "" {
+				row = append(row, "error")
+				continue
+			}
+			row = append(row, fmt.Sprint(column.Counts[Severities[i]]))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	best, ok := RecommendTag(columns)
+	if !ok {
+		fmt.Fprintln(out, "\nNo tag could be scanned")
+		return nil
+	}
+	fmt.Fprintf(out, "\nRecommended tag: %s (%d critical, %d high)\n", best.Image, best.Counts["critical"], best.Counts["high"])
+	return nil
+}
+
+// SafeTag tells whether the image was scanned without findings of the severity or higher
+func SafeTag(column MatrixColumn, severity string) bool {
+	if column.Error != "" {
+		return false
+	}
+	for level := SeverityLevel(severity); level >= 0 && level < len(Severities); level++ {
+		if column.Counts[Severities[level]] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTagMatrix prints the severity counts of each tag of a repository, and whether it is safe to keep: scanned
+// without findings of the severity or higher
+func WriteTagMatrix(out io.Writer, columns []MatrixColumn, severity string) error {
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	header := []string{"TAG"}
+	for i := len(Severities) - 1; i >= 0; i-- {
+		header = append(header, strings.ToUpper(Severities[i]))
+	}
+	fmt.Fprintln(w, strings.Join(append(header, "STATUS"), "\t"))
+	safe := 0
+	for _, column := range columns {
+		row := []string{column.Image}
+		for i := len(Severities) - 1; i >= 0; i-- {
+			if column.Error != "" {
+				row = append(row, "-")
+				continue
+			}
+			row = append(row, fmt.Sprint(column.Counts[Severities[i]]))
+		}
+		switch {
+		case column.Error != "":
+			row = append(row, "error: "+column.Error)
+		case SafeTag(column, severity):
+			row = append(row, "safe")
+			safe++
+		default:
+			row = append(row, "unsafe")
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "\n%d of %d tags have no vulnerabilities of %s severity or higher\n", safe, len(columns), severity)
+	return err
+}
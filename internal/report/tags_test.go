@@ -0,0 +1,72 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestRecommendTag(t *testing.T) {
+	columns := []MatrixColumn{
+		{Image: "alpine:edge", Error: "scan failed"},
+		{Image: "alpine:3.14", Counts: map[string]int{"critical": 0, "high": 2, "medium": 0, "low": 0}},
+		{Image: "alpine:3.13", Counts: map[string]int{"critical": 0, "high": 1, "medium": 5, "low": 9}},
+		{Image: "alpine:3.12", Counts: map[string]int{"critical": 0, "high": 1, "medium": 5, "low": 9}},
+		{Image: "alpine:3.11", Counts: map[string]int{"critical": 1, "high": 0, "medium": 0, "low": 0}},
+	}
+	best, ok := RecommendTag(columns)
+	assert.Assert(t, ok)
+	assert.Equal(t, best.Image, "alpine:3.13")
+
+	_, ok = RecommendTag(columns[:1])
+	assert.Assert(t, !ok)
+
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, WriteTagRecommendation(out, columns[:3]))
+	assert.Equal(t, out.String(), `IMAGE         CRITICAL   HIGH    MEDIUM   LOW
+alpine:edge   error      error   error    error
+alpine:3.14   0          2       0        0
+alpine:3.13   0          1       5        9
+
+Recommended tag: alpine:3.13 (0 critical, 1 high)
+`)
+}
+
+func TestWriteTagMatrix(t *testing.T) {
+	columns := []MatrixColumn{
+		{Image: "1.0", Counts: map[string]int{"critical": 1, "high": 0, "medium": 0, "low": 3}},
+		{Image: "1.1", Counts: map[string]int{"critical": 0, "high": 0, "medium": 2, "low": 3}},
+		{Image: "1.2", Error: "manifest unknown"},
+	}
+	assert.Assert(t, !SafeTag(columns[0], "high"))
+	assert.Assert(t, SafeTag(columns[1], "high"))
+	assert.Assert(t, !SafeTag(columns[1], "medium"))
+	assert.Assert(t, !SafeTag(columns[2], "low"))
+
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, WriteTagMatrix(out, columns, "high"))
+	assert.Equal(t, out.String(), `TAG   CRITICAL   HIGH   MEDIUM   LOW   STATUS
+1.0   1          0      0        3     unsafe
+1.1   0          0      2        3     safe
+1.2   -          -      -        -     error: manifest unknown
+
+1 of 3 tags have no vulnerabilities of high severity or higher
+`)
+}
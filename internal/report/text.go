@@ -0,0 +1,98 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/theme"
+)
+
+// WriteText prints the vulnerabilities of the report, in the same format as the provider
+func WriteText(out io.Writer, report Report) {
+	fmt.Fprintf(out, "\nTesting %s...\n", report.Path)
+	if report.URL != "" {
+		fmt.Fprintf(out, "Report: %s\n", report.URL)
+	}
+	for _, vuln := range report.Vulnerabilities {
+		writeVulnerability(out, report, vuln)
+	}
+	issues := "issues"
+	if len(report.Vulnerabilities) == 1 {
+		issues = "issue"
+	}
+	fmt.Fprintf(out, "\nTested %s, found %d %s.\n", report.Path, len(report.Vulnerabilities), issues)
+}
+
+func writeVulnerability(out io.Writer, report Report, vuln Vulnerability) {
+	kind := "vulnerability"
+	if vuln.Type == LicenseType {
+		kind = "license issue"
+	}
+	title := fmt.Sprintf("✗ %s severity %s found in %s", strings.Title(vuln.Severity), kind, vuln.PackageName)
+	fmt.Fprintf(out, "\n%s\n", theme.Current().Severity(vuln.Severity, title))
+	fmt.Fprintf(out, "  Description: %s\n", vuln.Title)
+	if url := AdvisoryURL(vuln.ID); url != "" {
+		fmt.Fprintf(out, "  Info: %s\n", url)
+	} else {
+		fmt.Fprintf(out, "  Info: %s\n", vuln.ID)
+	}
+	if report.URL != "" {
+		fmt.Fprintf(out, "  Report: %s\n", report.FindingURL(vuln))
+	}
+	if len(vuln.From) > 1 {
+		fmt.Fprintf(out, "  Introduced through: %s\n", vuln.From[1])
+		fmt.Fprintf(out, "  From: %s\n", strings.Join(vuln.From[1:], " > "))
+	}
+	if vuln.DockerfileLine > 0 {
+		fmt.Fprintf(out, "  Dockerfile: line %d, stage %s: %s\n", vuln.DockerfileLine, vuln.DockerfileStage, vuln.DockerfileInstruction)
+	}
+	if len(vuln.FixedIn) > 0 {
+		fmt.Fprintf(out, "  Fixed in: %s\n", strings.Join(vuln.FixedIn, ", "))
+	}
+	if vuln.Exploit != nil {
+		fmt.Fprintf(out, "  Exploit: %s\n", vuln.Exploit.Describe())
+	}
+	if vuln.FirstSeen != nil {
+		fmt.Fprintf(out, "  First seen: %s (%d days ago)\n", vuln.FirstSeen.Format("2006-01-02"), vuln.OpenDays(time.Now()))
+	}
+	if vuln.VEXStatus != "" {
+		status := vuln.VEXStatus
+		if vuln.VEXJustification != "" {
+			status += " (" + vuln.VEXJustification + ")"
+		}
+		fmt.Fprintf(out, "  VEX: %s\n", status)
+	}
+}
+
+// WriteFindings prints the findings of the plugin analyzers
+func WriteFindings(out io.Writer, title string, findings []Vulnerability) {
+	fmt.Fprintf(out, "\n%s: %d found\n", title, len(findings))
+	for _, finding := range findings {
+		title := fmt.Sprintf("✗ %s severity: %s (%s)", strings.Title(finding.Severity), finding.Title, finding.ID)
+		fmt.Fprintf(out, "  %s\n", theme.Current().Severity(finding.Severity, title))
+		if finding.Path != "" {
+			fmt.Fprintf(out, "    Path: %s\n", finding.Path)
+		}
+		if finding.Layer != "" {
+			fmt.Fprintf(out, "    Layer: %s\n", finding.Layer)
+		}
+	}
+}
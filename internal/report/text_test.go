@@ -0,0 +1,95 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteText(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	WriteText(out, Report{
+		Path: "alpine:3.10.0",
+		Vulnerabilities: []Vulnerability{{
+			ID:          "SNYK-ALPINE310-MUSL-458116",
+			Title:       "Out-of-bounds Write",
+			Severity:    "high",
+			PackageName: "musl/musl",
+			Version:     "1.1.22-r2",
+			From:        []string{"docker-image|alpine@3.10.0", "musl/musl@1.1.22-r2"},
+			FixedIn:     []string{"1.1.22-r4"},
+		}},
+	})
+	assert.Equal(t, out.String(), `
+Testing alpine:3.10.0...
+
+✗ High severity vulnerability found in musl/musl
+  Description: Out-of-bounds Write
+  Info: https://snyk.io/vuln/SNYK-ALPINE310-MUSL-458116
+  Introduced through: musl/musl@1.1.22-r2
+  From: musl/musl@1.1.22-r2
+  Fixed in: 1.1.22-r4
+
+Tested alpine:3.10.0, found 1 issue.
+`)
+}
+
+func TestWriteFindings(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	WriteFindings(out, "Secrets", []Vulnerability{{
+		ID:       "SECRET-PRIVATE-KEY",
+		Title:    "Private key",
+		Severity: "high",
+		Path:     "/root/.ssh/id_rsa",
+		Layer:    "sha256:abcd",
+	}})
+	assert.Equal(t, out.String(), `
+Secrets: 1 found
+  ✗ High severity: Private key (SECRET-PRIVATE-KEY)
+    Path: /root/.ssh/id_rsa
+    Layer: sha256:abcd
+`)
+}
+
+func TestWriteTextVEX(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	WriteText(out, Report{
+		Path: "alpine:3.10.0",
+		Vulnerabilities: []Vulnerability{{
+			ID:               "CVE-2019-14697",
+			Title:            "Out-of-bounds Write",
+			Severity:         "high",
+			PackageName:      "musl/musl",
+			Version:          "1.1.22-r2",
+			VEXStatus:        "under_investigation",
+			VEXJustification: "vulnerable_code_not_in_execute_path",
+		}},
+	})
+	assert.Equal(t, out.String(), `
+Testing alpine:3.10.0...
+
+✗ High severity vulnerability found in musl/musl
+  Description: Out-of-bounds Write
+  Info: https://nvd.nist.gov/vuln/detail/CVE-2019-14697
+  VEX: under_investigation (vulnerable_code_not_in_execute_path)
+
+Tested alpine:3.10.0, found 1 issue.
+`)
+}
@@ -0,0 +1,153 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLookahead bounds the search of the next run, an expression like "0 0 31 2 *" never matching
+const maxLookahead = 5 * 366 * 24 * time.Hour
+
+// cronField is the range of a field of a cron expression
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// Cron is a parsed cron expression of 5 fields: minute, hour, day of month, month and day of week, each field being
+// *, a value, a range like 1-5, a step like */15 or 0-30/10, or a list of them like 1,15
+type Cron struct {
+	expression string
+	values     [5]map[int]bool
+	// anyDay are true when the day of month or the day of week is *, as cron runs on the days matching any of the
+	// two fields when both are restricted
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+// ParseCron parses a cron expression
+func ParseCron(expression string) (Cron, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return Cron{}, fmt.Errorf("invalid cron expression %q: expected 5 fields, minute hour day-of-month month day-of-week", expression)
+	}
+	cron := Cron{expression: expression, anyDayOfMonth: fields[2] == "*", anyDayOfWeek: fields[4] == "*"}
+	for i, field := range fields {
+		values, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return Cron{}, fmt.Errorf("invalid cron expression %q: %s", expression, err)
+		}
+		cron.values[i] = values
+	}
+	// 7 is also Sunday
+	if cron.values[4][7] {
+		cron.values[4][0] = true
+	}
+	return cron, nil
+}
+
+func parseCronField(field string, bounds cronField) (map[int]bool, error) {
+	values := map[int]bool{}
+	max := bounds.max
+	if bounds.name == "day of week" {
+		max = 7
+	}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step %q of the %s", part[i+1:], bounds.name)
+			}
+			part = part[:i]
+		}
+		from, to := bounds.min, max
+		if part != "*" {
+			var err error
+			if from, to, err = parseCronRange(part); err != nil {
+				return nil, fmt.Errorf("invalid %s %q", bounds.name, part)
+			}
+		}
+		if from < bounds.min || to > max || from > to {
+			return nil, fmt.Errorf("%s %q out of range %d-%d", bounds.name, part, bounds.min, max)
+		}
+		for value := from; value <= to; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
+
+func parseCronRange(part string) (int, int, error) {
+	bounds := strings.SplitN(part, "-", 2)
+	from, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(bounds) == 1 {
+		return from, from, nil
+	}
+	to, err := strconv.Atoi(bounds[1])
+	return from, to, err
+}
+
+// String returns the expression
+func (c Cron) String() string {
+	return c.expression
+}
+
+// Next returns the first time matching the expression strictly after the given time, in its location, or the zero
+// time if the expression never matches
+func (c Cron) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(maxLookahead)
+	for t.Before(limit) {
+		switch {
+		case !c.values[3][int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !c.values[1][t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !c.values[0][t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c Cron) matchesDay(t time.Time) bool {
+	dayOfMonth := c.values[2][t.Day()]
+	dayOfWeek := c.values[4][int(t.Weekday())]
+	if c.anyDayOfMonth || c.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
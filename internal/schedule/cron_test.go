@@ -0,0 +1,63 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCronNext(t *testing.T) {
+	// Monday March 1st 2021
+	now := time.Date(2021, time.March, 1, 6, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"0 6 * * *", time.Date(2021, time.March, 2, 6, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, time.March, 1, 6, 45, 0, 0, time.UTC)},
+		{"30 6 * * *", time.Date(2021, time.March, 2, 6, 30, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2021, time.March, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2021, time.March, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, time.March, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		// restricted days of month and of week match any of the two
+		{"0 0 13 * 5", time.Date(2021, time.March, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2024, time.February, 29, 12, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.expression, func(t *testing.T) {
+			cron, err := ParseCron(testCase.expression)
+			assert.NilError(t, err)
+			assert.Equal(t, cron.Next(now), testCase.expected)
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	_, err := ParseCron("0 6 * *")
+	assert.ErrorContains(t, err, "expected 5 fields")
+	_, err = ParseCron("60 * * * *")
+	assert.ErrorContains(t, err, `minute "60" out of range 0-59`)
+	_, err = ParseCron("*/0 * * * *")
+	assert.ErrorContains(t, err, "invalid step")
+	_, err = ParseCron("0 6 * JAN *")
+	assert.ErrorContains(t, err, `invalid month "JAN"`)
+}
@@ -0,0 +1,177 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	cliConfig "github.com/docker/cli/cli/config"
+	"github.com/docker/scan-cli-plugin/internal/filelock"
+	"github.com/google/uuid"
+)
+
+// maxRuns is the number of runs kept per schedule, the results of the older runs being removed
+const maxRuns = 30
+
+// Schedule is an image scanned periodically by the runner
+type Schedule struct {
+	ID      string    `json:"id"`
+	Image   string    `json:"image"`
+	Cron    string    `json:"cron"`
+	Created time.Time `json:"created"`
+	Runs    []Run     `json:"runs,omitempty"`
+}
+
+// Run is a scan of a schedule, with the counts of its findings per severity
+type Run struct {
+	Time    time.Time      `json:"time"`
+	Counts  map[string]int `json:"counts,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Results string         `json:"results,omitempty"`
+}
+
+// DefaultPath returns the file of the docker scan configuration where the schedules are recorded
+func DefaultPath() string {
+	return filepath.Join(cliConfig.Dir(), "scan", "schedules.json")
+}
+
+// ResultsPath returns the file where the JSON results of a run are stored, next to the schedules file
+func ResultsPath(path, id string, at time.Time) string {
+	return filepath.Join(filepath.Dir(path), "schedules", id, at.UTC().Format("20060102T150405Z")+".json")
+}
+
+// LastRun returns the time of the last run, or the creation of the schedule if it never ran
+func (s Schedule) LastRun() time.Time {
+	if len(s.Runs) == 0 {
+		return s.Created
+	}
+	return s.Runs[len(s.Runs)-1].Time
+}
+
+// Next returns the time of the next run, or the zero time if the expression never matches
+func (s Schedule) Next() (time.Time, error) {
+	cron, err := ParseCron(s.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cron.Next(s.LastRun().Local()), nil
+}
+
+// Due returns true if a run time of the schedule passed since its last run, the runs missed while the runner wasn't
+// started being run only once
+func (s Schedule) Due(now time.Time) bool {
+	next, err := s.Next()
+	return err == nil && !next.IsZero() && !next.After(now)
+}
+
+// Load returns the schedules, none if the file doesn't exist
+func Load(path string) ([]Schedule, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return unmarshal(buf)
+}
+
+// Add records a new schedule of the image
+func Add(path, image, cron string, now time.Time) (Schedule, error) {
+	if _, err := ParseCron(cron); err != nil {
+		return Schedule{}, err
+	}
+	schedule := Schedule{ID: uuid.New().String()[:8], Image: image, Cron: cron, Created: now.UTC()}
+	return schedule, update(path, func(schedules []Schedule) ([]Schedule, error) {
+		return append(schedules, schedule), nil
+	})
+}
+
+// Remove removes a schedule and the results of its runs
+func Remove(path, id string) error {
+	err := update(path, func(schedules []Schedule) ([]Schedule, error) {
+		for i, schedule := range schedules {
+			if schedule.ID == id {
+				return append(schedules[:i], schedules[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("no schedule with ID %q", id)
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(filepath.Dir(path), "schedules", id))
+}
+
+// Record appends a run to a schedule, removing the results of the runs beyond the last 30
+func Record(path, id string, run Run) error {
+	var expired []Run
+	err := update(path, func(schedules []Schedule) ([]Schedule, error) {
+		for i, schedule := range schedules {
+			if schedule.ID != id {
+				continue
+			}
+			runs := append(schedule.Runs, run)
+			if len(runs) > maxRuns {
+				expired = runs[:len(runs)-maxRuns]
+				runs = runs[len(runs)-maxRuns:]
+			}
+			schedules[i].Runs = runs
+			return schedules, nil
+		}
+		return nil, fmt.Errorf("no schedule with ID %q", id)
+	})
+	if err != nil {
+		return err
+	}
+	for _, run := range expired {
+		if run.Results != "" {
+			_ = os.Remove(run.Results)
+		}
+	}
+	return nil
+}
+
+func update(path string, change func([]Schedule) ([]Schedule, error)) error {
+	return filelock.Update(path, 0644, func(current []byte) ([]byte, error) {
+		schedules, err := unmarshal(current)
+		if err != nil {
+			return nil, err
+		}
+		if schedules, err = change(schedules); err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(schedules, "", "  ")
+	})
+}
+
+func unmarshal(buf []byte) ([]Schedule, error) {
+	var schedules []Schedule
+	if len(buf) == 0 {
+		return schedules, nil
+	}
+	if err := json.Unmarshal(buf, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
@@ -0,0 +1,89 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schedule
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestScheduleDue(t *testing.T) {
+	created := time.Date(2021, time.March, 1, 7, 0, 0, 0, time.Local)
+	schedule := Schedule{Image: "alpine:3.13", Cron: "0 6 * * *", Created: created}
+	assert.Assert(t, !schedule.Due(created.Add(time.Hour)))
+	assert.Assert(t, schedule.Due(time.Date(2021, time.March, 2, 6, 0, 0, 0, time.Local)))
+	// the missed runs are run once
+	assert.Assert(t, schedule.Due(time.Date(2021, time.March, 5, 12, 0, 0, 0, time.Local)))
+
+	schedule.Runs = []Run{{Time: time.Date(2021, time.March, 5, 12, 0, 0, 0, time.Local)}}
+	assert.Assert(t, !schedule.Due(time.Date(2021, time.March, 5, 13, 0, 0, 0, time.Local)))
+	assert.Assert(t, schedule.Due(time.Date(2021, time.March, 6, 6, 1, 0, 0, time.Local)))
+}
+
+func TestAddRecordRemove(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	defer dir.Remove()
+	path := dir.Join("scan", "schedules.json")
+	now := time.Date(2021, time.March, 1, 7, 0, 0, 0, time.UTC)
+
+	_, err := Add(path, "alpine:3.13", "0 25 * * *", now)
+	assert.ErrorContains(t, err, "out of range")
+	schedule, err := Add(path, "alpine:3.13", "0 6 * * *", now)
+	assert.NilError(t, err)
+	_, err = Add(path, "nginx:1.19", "*/30 * * * *", now)
+	assert.NilError(t, err)
+
+	var results []string
+	for i := 0; i < maxRuns+2; i++ {
+		at := now.Add(time.Duration(i+1) * 24 * time.Hour)
+		result := ResultsPath(path, schedule.ID, at)
+		assert.NilError(t, os.MkdirAll(dir.Join("scan", "schedules", schedule.ID), 0755))
+		assert.NilError(t, ioutil.WriteFile(result, []byte("{}"), 0644))
+		results = append(results, result)
+		assert.NilError(t, Record(path, schedule.ID, Run{Time: at, Counts: map[string]int{"high": i}, Results: result}))
+	}
+	schedules, err := Load(path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(schedules), 2)
+	assert.Equal(t, len(schedules[0].Runs), maxRuns)
+	assert.Equal(t, schedules[0].Runs[0].Counts["high"], 2)
+	_, err = os.Stat(results[0])
+	assert.Assert(t, os.IsNotExist(err))
+	_, err = os.Stat(results[2])
+	assert.NilError(t, err)
+
+	assert.ErrorContains(t, Record(path, "unknown", Run{Time: now}), `no schedule with ID "unknown"`)
+	assert.ErrorContains(t, Remove(path, "unknown"), `no schedule with ID "unknown"`)
+	assert.NilError(t, Remove(path, schedule.ID))
+	schedules, err = Load(path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(schedules), 1)
+	assert.Equal(t, schedules[0].Image, "nginx:1.19")
+	_, err = os.Stat(results[2])
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestLoadMissing(t *testing.T) {
+	schedules, err := Load("/does/not/exist/schedules.json")
+	assert.NilError(t, err)
+	assert.Equal(t, len(schedules), 0)
+}
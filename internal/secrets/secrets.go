@@ -0,0 +1,118 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package secrets
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// maxFileSize is the maximum size of the files searched for secrets
+const maxFileSize = 10 * 1024 * 1024
+
+type rule struct {
+	id       string
+	title    string
+	severity string
+	pattern  *regexp.Regexp
+}
+
+var rules = []rule{
+	{
+		id:       "SECRET-PRIVATE-KEY",
+		title:    "Private key",
+		severity: "high",
+		pattern:  regexp.MustCompile(`-----BEGIN (RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY( BLOCK)?-----`),
+	},
+	{
+		id:       "SECRET-AWS-ACCESS-KEY",
+		title:    "AWS access key ID",
+		severity: "high",
+		pattern:  regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+	},
+	{
+		id:       "SECRET-GITHUB-TOKEN",
+		title:    "GitHub token",
+		severity: "high",
+		pattern:  regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36}\b`),
+	},
+	{
+		id:       "SECRET-SLACK-TOKEN",
+		title:    "Slack token",
+		severity: "high",
+		pattern:  regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),
+	},
+	{
+		id:       "SECRET-DOCKER-CONFIG",
+		title:    "Docker registry credentials",
+		severity: "high",
+		pattern:  regexp.MustCompile(`"auths"\s*:\s*\{[^}]*"auth"\s*:\s*"[A-Za-z0-9+/=]+"`),
+	},
+}
+
+// Scan searches the files of every layer of the extracted image for secrets. A secret removed
+// by a more recent layer is still reported, as it can be read from the layer which added it.
+// The secrets themselves are never reported, only the files containing them.
+func Scan(extracted *image.ExtractedImage) ([]report.Vulnerability, error) {
+	findings := []report.Vulnerability{}
+	for index, layerID := range extracted.LayerIDs {
+		root := extracted.LayerDir(index)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() || info.Size() > maxFileSize {
+				return nil
+			}
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if bytes.IndexByte(content, 0) >= 0 {
+				// skip binary files
+				return nil
+			}
+			relPath, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			for _, rule := range rules {
+				if rule.pattern.Match(content) {
+					findings = append(findings, report.Vulnerability{
+						ID:       rule.id,
+						Type:     report.SecretType,
+						Title:    rule.title,
+						Severity: rule.severity,
+						Path:     "/" + filepath.ToSlash(relPath),
+						Layer:    layerID,
+					})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,355 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// jobsPerImage is the number of jobs kept for each image, the oldest ones being dropped
+	jobsPerImage = 5
+	// finishedJobTTL is how long the finished jobs and their results are kept
+	finishedJobTTL = time.Hour
+)
+
+// Job statuses
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Request asks to scan an image
+type Request struct {
+	Image      string `json:"image"`
+	Severity   string `json:"severity,omitempty"`
+	Dockerfile string `json:"dockerfile,omitempty"`
+}
+
+// Scanner scans the image of the request, writing the JSON results of the provider to out
+type Scanner func(ctx context.Context, request Request, out *Output) error
+
+// Job is a scan requested to the server
+type Job struct {
+	ID       string          `json:"id"`
+	Image    string          `json:"image"`
+	Status   string          `json:"status"`
+	Error    string          `json:"error,omitempty"`
+	Started  time.Time       `json:"started"`
+	Finished *time.Time      `json:"finished,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"`
+}
+
+// Output collects the output of a scan, which can be streamed while the scan runs
+type Output struct {
+	mutex   sync.Mutex
+	buf     bytes.Buffer
+	done    bool
+	updated chan struct{}
+}
+
+func newOutput() *Output {
+	return &Output{updated: make(chan struct{})}
+}
+
+func (o *Output) Write(p []byte) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	n, err := o.buf.Write(p)
+	o.notify()
+	return n, err
+}
+
+func (o *Output) close() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.done = true
+	o.notify()
+}
+
+// notify wakes up the readers waiting for more output, the caller must hold the lock
+func (o *Output) notify() {
+	close(o.updated)
+	o.updated = make(chan struct{})
+}
+
+// read returns the output written after offset, whether the scan is done and a channel closed on the next update
+func (o *Output) read(offset int) ([]byte, bool, <-chan struct{}) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return append([]byte(nil), o.buf.Bytes()[offset:]...), o.done, o.updated
+}
+
+type job struct {
+	Job
+	output *Output
+}
+
+// Server runs the scans requested through its REST API and keeps their results
+type Server struct {
+	ctx     context.Context
+	scanner Scanner
+	// token authenticates the requests as a Bearer token, the requests are not authenticated when empty
+	token string
+	mutex sync.Mutex
+	jobs  map[string]*job
+	// images are the IDs of the jobs kept for each image, from the oldest to the most recent
+	images map[string][]string
+	// now returns the current time, to expire the finished jobs
+	now func() time.Time
+}
+
+// New returns a server running the scans with the scanner, until the context is canceled. The requests must be
+// authenticated with the token as a Bearer token, unless it is empty.
+func New(ctx context.Context, scanner Scanner, token string) *Server {
+	return &Server{ctx: ctx, scanner: scanner, token: token, jobs: map[string]*job{}, images: map[string][]string{}, now: time.Now}
+}
+
+// NewToken returns a random token authenticating the requests to a server
+func NewToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Handler returns the handler of the REST API:
+//
+//	POST /v1/scans starts a scan and returns its job
+//	GET /v1/scans/{id} returns the job and its results once completed
+//	GET /v1/scans/{id}/output streams the output of the scan while it runs
+//	GET /v1/results?image=IMAGE returns the job of the most recent scan of the image
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/scans", s.handleScans)
+	mux.HandleFunc("/v1/scans/", s.handleScan)
+	mux.HandleFunc("/v1/results", s.handleResults)
+	return s.authenticate(mux)
+}
+
+// authenticate rejects the requests without the token of the server
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleScans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// a JSON content type can't be sent cross-origin by a web page without a preflight request
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		http.Error(w, "unsupported content type, application/json is expected", http.StatusUnsupportedMediaType)
+		return
+	}
+	var request Request
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid scan request: %s", err), http.StatusBadRequest)
+		return
+	}
+	if request.Image == "" {
+		http.Error(w, "invalid scan request: missing image", http.StatusBadRequest)
+		return
+	}
+	started, err := s.start(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, started)
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/v1/scans/")
+	stream := strings.HasSuffix(id, "/output")
+	id = strings.TrimSuffix(id, "/output")
+	s.mutex.Lock()
+	s.prune("")
+	j, ok := s.jobs[id]
+	var snapshot Job
+	if ok {
+		snapshot = j.Job
+	}
+	s.mutex.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("no scan %s", id), http.StatusNotFound)
+		return
+	}
+	if stream {
+		streamOutput(r.Context(), w, j.output)
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	image := r.URL.Query().Get("image")
+	s.mutex.Lock()
+	s.prune("")
+	var latest string
+	if ids := s.images[image]; len(ids) > 0 {
+		latest = ids[len(ids)-1]
+	}
+	j, ok := s.jobs[latest]
+	var snapshot Job
+	if ok {
+		snapshot = j.Job
+	}
+	s.mutex.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("no scan of %s", image), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+// start runs the scan in the background and returns its job
+func (s *Server) start(request Request) (Job, error) {
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+	j := &job{
+		Job:    Job{ID: id, Image: request.Image, Status: StatusRunning, Started: time.Now()},
+		output: newOutput(),
+	}
+	s.mutex.Lock()
+	s.prune(request.Image)
+	s.jobs[id] = j
+	s.images[request.Image] = append(s.images[request.Image], id)
+	snapshot := j.Job
+	s.mutex.Unlock()
+
+	go func() {
+		err := s.scanner(s.ctx, request, j.output)
+		j.output.close()
+		result, _, _ := j.output.read(0)
+		finished := time.Now()
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		j.Finished = &finished
+		switch {
+		case err != nil:
+			j.Status = StatusFailed
+			j.Error = err.Error()
+		case !json.Valid(result):
+			j.Status = StatusFailed
+			j.Error = "the scan provider output is not valid JSON"
+		default:
+			j.Status = StatusCompleted
+			j.Result = result
+		}
+	}()
+	return snapshot, nil
+}
+
+// prune drops the jobs finished for longer than their time to live, and the oldest jobs of the images having more
+// jobs than kept, leaving room for a job of the added image if not empty. The caller must hold the lock.
+func (s *Server) prune(added string) {
+	expired := s.now().Add(-finishedJobTTL)
+	for image, ids := range s.images {
+		limit := jobsPerImage
+		if image == added {
+			limit--
+		}
+		var kept []string
+		for index, id := range ids {
+			j := s.jobs[id]
+			if index < len(ids)-limit || (j.Finished != nil && j.Finished.Before(expired)) {
+				delete(s.jobs, id)
+				continue
+			}
+			kept = append(kept, id)
+		}
+		if len(kept) == 0 {
+			delete(s.images, image)
+		} else {
+			s.images[image] = kept
+		}
+	}
+}
+
+// streamOutput writes the output of the scan as it is produced, until the scan is done or the client leaves
+func streamOutput(ctx context.Context, w http.ResponseWriter, output *Output) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	flusher, _ := w.(http.Flusher)
+	offset := 0
+	for {
+		chunk, done, updated := output.read(offset)
+		if len(chunk) > 0 {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			offset += len(chunk)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if done {
+			return
+		}
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
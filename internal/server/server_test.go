@@ -0,0 +1,167 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+func TestScan(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(New(context.Background(), func(ctx context.Context, request Request, out *Output) error {
+		fmt.Fprint(out, `{"ok":false,`)
+		<-release
+		fmt.Fprintf(out, `"path":%q}`, request.Image)
+		return nil
+	}, "").Handler())
+	defer server.Close()
+
+	started := postScan(t, server.URL, `{"image":"alpine:3.14"}`)
+	assert.Equal(t, started.Status, StatusRunning)
+	assert.Equal(t, started.Image, "alpine:3.14")
+
+	// the output is streamed until the scan completes
+	resp, err := http.Get(server.URL + "/v1/scans/" + started.ID + "/output")
+	assert.NilError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+	close(release)
+	output, err := ioutil.ReadAll(resp.Body)
+	assert.NilError(t, err)
+	assert.Equal(t, string(output), `{"ok":false,"path":"alpine:3.14"}`)
+
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		job := getJob(t, server.URL+"/v1/scans/"+started.ID)
+		if job.Status == StatusRunning {
+			return poll.Continue("scan still running")
+		}
+		assert.Equal(t, job.Status, StatusCompleted)
+		assert.Equal(t, string(job.Result), `{"ok":false,"path":"alpine:3.14"}`)
+		return poll.Success()
+	}, poll.WithDelay(10*time.Millisecond))
+
+	latest := getJob(t, server.URL+"/v1/results?image=alpine:3.14")
+	assert.Equal(t, latest.ID, started.ID)
+}
+
+func TestFailedScan(t *testing.T) {
+	server := httptest.NewServer(New(context.Background(), func(ctx context.Context, request Request, out *Output) error {
+		return fmt.Errorf("image not found")
+	}, "").Handler())
+	defer server.Close()
+
+	started := postScan(t, server.URL, `{"image":"missing"}`)
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		job := getJob(t, server.URL+"/v1/scans/"+started.ID)
+		if job.Status == StatusRunning {
+			return poll.Continue("scan still running")
+		}
+		assert.Equal(t, job.Status, StatusFailed)
+		assert.Equal(t, job.Error, "image not found")
+		return poll.Success()
+	}, poll.WithDelay(10*time.Millisecond))
+}
+
+func TestInvalidRequests(t *testing.T) {
+	server := httptest.NewServer(New(context.Background(), nil, "").Handler())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/v1/scans", "application/json", bytes.NewBufferString(`{}`))
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusBadRequest)
+	resp, err = http.Post(server.URL+"/v1/scans", "text/plain", bytes.NewBufferString(`{"image":"alpine"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusUnsupportedMediaType)
+	resp, err = http.Get(server.URL + "/v1/scans/unknown")
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusNotFound)
+	resp, err = http.Get(server.URL + "/v1/results?image=alpine")
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusNotFound)
+}
+
+func TestAuthenticatedRequests(t *testing.T) {
+	server := httptest.NewServer(New(context.Background(), func(ctx context.Context, request Request, out *Output) error {
+		return nil
+	}, "secret").Handler())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/v1/scans", "application/json", bytes.NewBufferString(`{"image":"alpine"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusUnauthorized)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/scans", bytes.NewBufferString(`{"image":"alpine"}`))
+	assert.NilError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusAccepted)
+}
+
+func TestPruneJobs(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := New(context.Background(), nil, "")
+	s.now = func() time.Time { return now }
+	finished := now.Add(-2 * finishedJobTTL)
+	s.jobs["expired"] = &job{Job: Job{ID: "expired", Finished: &finished}}
+	s.jobs["running"] = &job{Job: Job{ID: "running"}}
+	s.images["alpine"] = []string{"expired", "running"}
+	for i := 0; i < jobsPerImage; i++ {
+		id := fmt.Sprintf("debian-%d", i)
+		s.jobs[id] = &job{Job: Job{ID: id}}
+		s.images["debian"] = append(s.images["debian"], id)
+	}
+
+	s.prune("debian")
+	assert.DeepEqual(t, s.images["alpine"], []string{"running"})
+	assert.Equal(t, len(s.images["debian"]), jobsPerImage-1)
+	assert.Equal(t, s.images["debian"][0], "debian-1")
+	assert.Equal(t, len(s.jobs), jobsPerImage)
+}
+
+func postScan(t *testing.T, url, request string) Job {
+	t.Helper()
+	resp, err := http.Post(url+"/v1/scans", "application/json", bytes.NewBufferString(request))
+	assert.NilError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+	assert.Equal(t, resp.StatusCode, http.StatusAccepted)
+	var job Job
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&job))
+	return job
+}
+
+func getJob(t *testing.T, url string) Job {
+	t.Helper()
+	resp, err := http.Get(url)
+	assert.NilError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	var job Job
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&job))
+	return job
+}
@@ -0,0 +1,112 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package theme
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// Default colors the severities like Snyk does
+	Default = "default"
+	// ColorBlind uses the Okabe-Ito palette, distinguishable with all the forms of color blindness, and makes the
+	// critical and high severities bold, so they don't rely on color alone
+	ColorBlind = "colorblind"
+	// None disables the colors
+	None = "none"
+)
+
+// Names are the themes selectable with docker scan config set theme=NAME
+var Names = []string{Default, ColorBlind, None}
+
+// themes are the SGR parameters of the ANSI escape sequence coloring each severity
+var themes = map[string]map[string]string{
+	Default: {
+		"critical": "1;35",
+		"high":     "31",
+		"medium":   "33",
+		"low":      "34",
+	},
+	ColorBlind: {
+		"critical": "1;4;38;5;166",
+		"high":     "1;38;5;214",
+		"medium":   "38;5;74",
+		"low":      "38;5;250",
+	},
+	None: {},
+}
+
+// Theme colors the severities in the terminal output
+type Theme struct {
+	name   string
+	colors map[string]string
+}
+
+// current is the theme of the output, without colors until the plugin selects one for a terminal
+var current = Theme{name: None}
+
+// Get returns a theme by name, the default one when the name is empty
+func Get(name string) (Theme, error) {
+	if name == "" {
+		name = Default
+	}
+	colors, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(Names, ", "))
+	}
+	return Theme{name: name, colors: colors}, nil
+}
+
+// Set selects the theme of the output
+func Set(theme Theme) {
+	current = theme
+}
+
+// Current returns the theme of the output
+func Current() Theme {
+	return current
+}
+
+// Name returns the name of the theme
+func (t Theme) Name() string {
+	return t.name
+}
+
+// Severity colors the text with the color of the severity, the text is returned as is when the theme has no color
+// for it
+func (t Theme) Severity(severity, text string) string {
+	color, ok := t.colors[strings.ToLower(severity)]
+	if !ok {
+		return text
+	}
+	return "\x1b[" + color + "m" + text + "\x1b[0m"
+}
+
+// Lines colors each line of the text with the severity of its index, the lines without severity being kept as is. The
+// tables are colored once aligned, as the escape sequences would be counted in the width of their columns.
+func (t Theme) Lines(text string, severities []string) string {
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if i >= len(severities) || severities[i] == "" {
+			continue
+		}
+		content := strings.TrimSuffix(line, "\n")
+		lines[i] = t.Severity(severities[i], content) + line[len(content):]
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,58 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package theme
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGet(t *testing.T) {
+	theme, err := Get("")
+	assert.NilError(t, err)
+	assert.Equal(t, theme.Name(), Default)
+	for _, name := range Names {
+		theme, err := Get(name)
+		assert.NilError(t, err)
+		assert.Equal(t, theme.Name(), name)
+	}
+	_, err = Get("neon")
+	assert.Error(t, err, `unknown theme "neon", expected one of default, colorblind, none`)
+}
+
+func TestSeverity(t *testing.T) {
+	theme, err := Get(ColorBlind)
+	assert.NilError(t, err)
+	assert.Equal(t, theme.Severity("High", "High severity"), "\x1b[1;38;5;214mHigh severity\x1b[0m")
+	assert.Equal(t, theme.Severity("info", "Info"), "Info")
+
+	theme, err = Get(None)
+	assert.NilError(t, err)
+	assert.Equal(t, theme.Severity("critical", "critical"), "critical")
+
+	// no colors until a theme is selected
+	assert.Equal(t, Current().Severity("critical", "critical"), "critical")
+}
+
+func TestLines(t *testing.T) {
+	theme, err := Get(Default)
+	assert.NilError(t, err)
+	table := "SEVERITY   COUNT\ncritical   1\nlow        2\n"
+	assert.Equal(t, theme.Lines(table, []string{"", "critical", "low"}),
+		"SEVERITY   COUNT\n\x1b[1;35mcritical   1\x1b[0m\n\x1b[34mlow        2\x1b[0m\n")
+}
@@ -0,0 +1,138 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	cliConfig "github.com/docker/cli/cli/config"
+	"github.com/docker/scan-cli-plugin/internal/filelock"
+)
+
+// DefaultMonthlyLimit is the number of scans the Docker Hub users scanning with their DockerScanID get each month
+const DefaultMonthlyLimit = 10
+
+// monthFormat identifies the month the scans are counted in, in UTC
+const monthFormat = "2006-01"
+
+// limitMessage extracts the limit from the provider message refusing a scan, like
+// "You have reached your monthly limit of 10 private tests for your docker-desktop-test org."
+var limitMessage = regexp.MustCompile(`limit of (\d+)`)
+
+// Usage counts the scans of a Docker Hub user during a month
+type Usage struct {
+	Month string `json:"month"`
+	Scans int    `json:"scans"`
+	Limit int    `json:"limit"`
+	// Exceeded is true once the provider refused a scan because of the quota
+	Exceeded bool `json:"exceeded,omitempty"`
+	// Message is the message of the provider refusing the scan
+	Message string `json:"message,omitempty"`
+}
+
+// Remaining returns the number of scans left this month
+func (u Usage) Remaining() int {
+	if u.Exceeded || u.Scans >= u.Limit {
+		return 0
+	}
+	return u.Limit - u.Scans
+}
+
+// ResetsOn returns when the scans are counted again from 0, the first day of the next month
+func (u Usage) ResetsOn() time.Time {
+	month, err := time.Parse(monthFormat, u.Month)
+	if err != nil {
+		return time.Time{}
+	}
+	return month.AddDate(0, 1, 0)
+}
+
+// DefaultPath returns the file of the docker scan configuration where the scans are counted
+func DefaultPath() string {
+	return filepath.Join(cliConfig.Dir(), "scan", "usage.json")
+}
+
+// Get returns the scans of the user during the month of now
+func Get(path, user string, now time.Time) (Usage, error) {
+	users, err := read(path)
+	if err != nil {
+		return Usage{}, err
+	}
+	return current(users[user], now), nil
+}
+
+// Record counts a scan of the user, or records the provider refused it with the given quota message
+func Record(path, user string, now time.Time, quotaMessage string) error {
+	unlock, err := filelock.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	users, err := read(path)
+	if err != nil {
+		return err
+	}
+	usage := current(users[user], now)
+	if quotaMessage == "" {
+		usage.Scans++
+	} else {
+		usage.Exceeded = true
+		usage.Message = quotaMessage
+		if match := limitMessage.FindStringSubmatch(quotaMessage); match != nil {
+			usage.Limit, _ = strconv.Atoi(match[1])
+		}
+	}
+	users[user] = usage
+	buf, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		return err
+	}
+	return filelock.WriteFile(path, buf, 0644)
+}
+
+// current returns the usage of the month of now, starting a new count when the month changed
+func current(usage Usage, now time.Time) Usage {
+	month := now.UTC().Format(monthFormat)
+	if usage.Month != month {
+		return Usage{Month: month, Limit: DefaultMonthlyLimit}
+	}
+	if usage.Limit == 0 {
+		usage.Limit = DefaultMonthlyLimit
+	}
+	return usage
+}
+
+func read(path string) (map[string]Usage, error) {
+	users := map[string]Usage{}
+	buf, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return users, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// an invalid file is replaced with a new count
+	_ = json.Unmarshal(buf, &users)
+	return users, nil
+}
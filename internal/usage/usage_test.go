@@ -0,0 +1,59 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package usage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestRecord(t *testing.T) {
+	dir := fs.NewDir(t, "usage")
+	defer dir.Remove()
+	path := filepath.Join(dir.Path(), "scan", "usage.json")
+	now := time.Date(2021, time.March, 12, 10, 0, 0, 0, time.UTC)
+
+	usage, err := Get(path, "myuser", now)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, usage, Usage{Month: "2021-03", Limit: DefaultMonthlyLimit})
+	assert.Equal(t, usage.Remaining(), 10)
+	assert.Equal(t, usage.ResetsOn(), time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.NilError(t, Record(path, "myuser", now, ""))
+	assert.NilError(t, Record(path, "myuser", now, ""))
+	assert.NilError(t, Record(path, "otheruser", now, ""))
+	usage, err = Get(path, "myuser", now)
+	assert.NilError(t, err)
+	assert.Equal(t, usage.Scans, 2)
+	assert.Equal(t, usage.Remaining(), 8)
+
+	message := "You have reached your monthly limit of 5 private tests for your docker-desktop-test org."
+	assert.NilError(t, Record(path, "myuser", now, message))
+	usage, err = Get(path, "myuser", now)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, usage, Usage{Month: "2021-03", Scans: 2, Limit: 5, Exceeded: true, Message: message})
+	assert.Equal(t, usage.Remaining(), 0)
+
+	// the scans are counted again the next month
+	usage, err = Get(path, "myuser", now.AddDate(0, 1, 0))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, usage, Usage{Month: "2021-04", Limit: DefaultMonthlyLimit})
+}
@@ -0,0 +1,123 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package vex
+
+import (
+	"encoding/json"
+)
+
+// csafInput is the part of a CSAF VEX document telling the status of the vulnerabilities in the products
+type csafInput struct {
+	ProductTree     csafBranch `json:"product_tree"`
+	Vulnerabilities []struct {
+		CVE string `json:"cve"`
+		IDs []struct {
+			Text string `json:"text"`
+		} `json:"ids"`
+		ProductStatus map[string][]string `json:"product_status"`
+		Flags         []struct {
+			Label      string   `json:"label"`
+			ProductIDs []string `json:"product_ids"`
+		} `json:"flags"`
+		Threats []struct {
+			Category   string   `json:"category"`
+			Details    string   `json:"details"`
+			ProductIDs []string `json:"product_ids"`
+		} `json:"threats"`
+	} `json:"vulnerabilities"`
+}
+
+type csafBranch struct {
+	Branches         []csafBranch  `json:"branches"`
+	Product          *csafProduct  `json:"product"`
+	FullProductNames []csafProduct `json:"full_product_names"`
+}
+
+type csafProduct struct {
+	ProductID string `json:"product_id"`
+	Name      string `json:"name"`
+	Helper    struct {
+		PURL string `json:"purl"`
+	} `json:"product_identification_helper"`
+}
+
+// csafStatuses maps the CSAF product statuses to the VEX statuses
+var csafStatuses = [][2]string{
+	{"under_investigation", UnderInvestigation},
+	{"known_affected", Affected},
+	{"fixed", Fixed},
+	{"known_not_affected", NotAffected},
+}
+
+func parseCSAF(buf []byte) (Document, error) {
+	var input csafInput
+	if err := json.Unmarshal(buf, &input); err != nil {
+		return Document{}, err
+	}
+	products := map[string]string{}
+	collectCSAFProducts(input.ProductTree, products)
+	var document Document
+	for _, vulnerability := range input.Vulnerabilities {
+		name := vulnerability.CVE
+		var aliases []string
+		for _, id := range vulnerability.IDs {
+			if name == "" {
+				name = id.Text
+			} else {
+				aliases = append(aliases, id.Text)
+			}
+		}
+		for _, csafStatus := range csafStatuses {
+			status := csafStatus[1]
+			for _, productID := range vulnerability.ProductStatus[csafStatus[0]] {
+				statement := Statement{Vulnerability: name, Aliases: aliases, Status: status, Products: []Product{{ID: products[productID]}}}
+				if statement.Products[0].ID == "" {
+					statement.Products[0].ID = productID
+				}
+				for _, flag := range vulnerability.Flags {
+					if contains(flag.ProductIDs, productID) {
+						statement.Justification = flag.Label
+					}
+				}
+				for _, threat := range vulnerability.Threats {
+					if threat.Category == "impact" && contains(threat.ProductIDs, productID) {
+						statement.ImpactStatement = threat.Details
+					}
+				}
+				document.Statements = append(document.Statements, statement)
+			}
+		}
+	}
+	return document, nil
+}
+
+// collectCSAFProducts maps the product IDs of the product tree to their purl, or to their name without purl
+func collectCSAFProducts(branch csafBranch, products map[string]string) {
+	all := branch.FullProductNames
+	if branch.Product != nil {
+		all = append(all, *branch.Product)
+	}
+	for _, product := range all {
+		products[product.ProductID] = product.Helper.PURL
+		if product.Helper.PURL == "" {
+			products[product.ProductID] = product.Name
+		}
+	}
+	for _, child := range branch.Branches {
+		collectCSAFProducts(child, products)
+	}
+}
@@ -0,0 +1,264 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package vex
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"github.com/google/uuid"
+)
+
+// OpenVEXContext is the version of the OpenVEX specification of the generated documents
+const OpenVEXContext = "https://openvex.dev/ns/v0.2.0"
+
+// OpenVEX is an OpenVEX document
+type OpenVEX struct {
+	Context    string             `json:"@context"`
+	ID         string             `json:"@id"`
+	Author     string             `json:"author"`
+	Timestamp  time.Time          `json:"timestamp"`
+	Version    int                `json:"version"`
+	Tooling    string             `json:"tooling,omitempty"`
+	Statements []OpenVEXStatement `json:"statements"`
+}
+
+// OpenVEXStatement is a statement of an OpenVEX document
+type OpenVEXStatement struct {
+	Vulnerability   OpenVEXVulnerability `json:"vulnerability"`
+	Products        []OpenVEXComponent   `json:"products,omitempty"`
+	Status          string               `json:"status"`
+	Justification   string               `json:"justification,omitempty"`
+	ImpactStatement string               `json:"impact_statement,omitempty"`
+}
+
+// OpenVEXVulnerability identifies the vulnerability of a statement
+type OpenVEXVulnerability struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// OpenVEXComponent is a product or a subcomponent of a statement
+type OpenVEXComponent struct {
+	ID            string             `json:"@id"`
+	Subcomponents []OpenVEXComponent `json:"subcomponents,omitempty"`
+}
+
+// openVEXInput reads the statements of all the versions of the specification, the vulnerabilities and the products
+// being plain strings in the first version
+type openVEXInput struct {
+	Statements []struct {
+		Vulnerability   json.RawMessage   `json:"vulnerability"`
+		Products        []json.RawMessage `json:"products"`
+		Subcomponents   []json.RawMessage `json:"subcomponents"`
+		Status          string            `json:"status"`
+		Justification   string            `json:"justification"`
+		ImpactStatement string            `json:"impact_statement"`
+	} `json:"statements"`
+}
+
+func parseOpenVEX(buf []byte) (Document, error) {
+	var input openVEXInput
+	if err := json.Unmarshal(buf, &input); err != nil {
+		return Document{}, err
+	}
+	var document Document
+	for i, raw := range input.Statements {
+		statement := Statement{Status: raw.Status, Justification: raw.Justification, ImpactStatement: raw.ImpactStatement}
+		if err := decodeVulnerability(raw.Vulnerability, &statement); err != nil {
+			return Document{}, fmt.Errorf("statement %d: %s", i+1, err)
+		}
+		subcomponents, err := decodeComponentIDs(raw.Subcomponents)
+		if err != nil {
+			return Document{}, fmt.Errorf("statement %d: %s", i+1, err)
+		}
+		for _, rawProduct := range raw.Products {
+			product, err := decodeComponent(rawProduct)
+			if err != nil {
+				return Document{}, fmt.Errorf("statement %d: %s", i+1, err)
+			}
+			product.Subcomponents = append(product.Subcomponents, subcomponents...)
+			statement.Products = append(statement.Products, product)
+		}
+		document.Statements = append(document.Statements, statement)
+	}
+	return document, nil
+}
+
+func decodeVulnerability(raw json.RawMessage, statement *Statement) error {
+	if err := json.Unmarshal(raw, &statement.Vulnerability); err == nil {
+		return nil
+	}
+	var vulnerability struct {
+		ID      string   `json:"@id"`
+		Name    string   `json:"name"`
+		Aliases []string `json:"aliases"`
+	}
+	if err := json.Unmarshal(raw, &vulnerability); err != nil {
+		return fmt.Errorf("invalid vulnerability: %s", err)
+	}
+	statement.Vulnerability = vulnerability.Name
+	if statement.Vulnerability == "" {
+		statement.Vulnerability = vulnerability.ID
+	}
+	statement.Aliases = vulnerability.Aliases
+	return nil
+}
+
+func decodeComponent(raw json.RawMessage) (Product, error) {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return Product{ID: id}, nil
+	}
+	var component struct {
+		ID            string            `json:"@id"`
+		Subcomponents []json.RawMessage `json:"subcomponents"`
+	}
+	if err := json.Unmarshal(raw, &component); err != nil {
+		return Product{}, fmt.Errorf("invalid product: %s", err)
+	}
+	subcomponents, err := decodeComponentIDs(component.Subcomponents)
+	return Product{ID: component.ID, Subcomponents: subcomponents}, err
+}
+
+func decodeComponentIDs(raws []json.RawMessage) ([]string, error) {
+	var ids []string
+	for _, raw := range raws {
+		component, err := decodeComponent(raw)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, component.ID)
+	}
+	return ids, nil
+}
+
+// Generate returns an OpenVEX document with a statement per vulnerability of the image, under investigation, to be
+// completed with the status of each vulnerability
+func Generate(author, product string, findings []report.Vulnerability, now time.Time) OpenVEX {
+	document := OpenVEX{
+		Context:    OpenVEXContext,
+		ID:         "urn:uuid:" + uuid.New().String(),
+		Author:     author,
+		Timestamp:  now.UTC(),
+		Version:    1,
+		Tooling:    "docker scan",
+		Statements: []OpenVEXStatement{},
+	}
+	statements := map[string]*OpenVEXStatement{}
+	var names []string
+	for _, finding := range findings {
+		if finding.Type != "" && finding.Type != report.VulnerabilityType {
+			continue
+		}
+		ids := finding.CVEs()
+		statement, ok := statements[ids[0]]
+		if !ok {
+			statement = &OpenVEXStatement{
+				Vulnerability: OpenVEXVulnerability{Name: ids[0], Aliases: aliases(finding, ids[0])},
+				Products:      []OpenVEXComponent{{ID: product}},
+				Status:        UnderInvestigation,
+			}
+			statements[ids[0]] = statement
+			names = append(names, ids[0])
+		}
+		if id := PackagePURL(finding); id != "" && !hasComponent(statement.Products[0].Subcomponents, id) {
+			statement.Products[0].Subcomponents = append(statement.Products[0].Subcomponents, OpenVEXComponent{ID: id})
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		document.Statements = append(document.Statements, *statements[name])
+	}
+	return document
+}
+
+func aliases(finding report.Vulnerability, name string) []string {
+	var aliases []string
+	if finding.ID != name {
+		aliases = append(aliases, finding.ID)
+	}
+	return aliases
+}
+
+func hasComponent(components []OpenVEXComponent, id string) bool {
+	for _, component := range components {
+		if component.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// purlTypes are the purl types and namespaces of the package managers reported by the providers
+var purlTypes = map[string]string{
+	"deb":       "deb/debian",
+	"apk":       "apk/alpine",
+	"rpm":       "rpm",
+	"npm":       "npm",
+	"pip":       "pypi",
+	"maven":     "maven",
+	"gomodules": "golang",
+	"golang":    "golang",
+	"rubygems":  "gem",
+	"nuget":     "nuget",
+	"composer":  "composer",
+	"cargo":     "cargo",
+}
+
+// PackagePURL returns the purl of the package of a finding, empty when its package manager is unknown
+func PackagePURL(finding report.Vulnerability) string {
+	kind, ok := purlTypes[finding.PackageManager]
+	if !ok || finding.PackageName == "" {
+		return ""
+	}
+	name := finding.PackageName
+	if finding.PackageManager == "deb" || finding.PackageManager == "apk" || finding.PackageManager == "rpm" {
+		// the binary package of the source/binary names of the operating system packages
+		name = name[strings.LastIndex(name, "/")+1:]
+	}
+	id := "pkg:" + kind + "/" + name
+	if finding.Version != "" {
+		id += "@" + finding.Version
+	}
+	return id
+}
+
+// ImagePURL returns the pkg:oci purl of an image, of its manifest digest when known, otherwise of its tag
+func ImagePURL(image, digest string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	name := reference.Path(named)
+	name = name[strings.LastIndex(name, "/")+1:]
+	id := "pkg:oci/" + name
+	if digest != "" {
+		// the colon of the digest is escaped in the purl version
+		id += "@" + strings.Replace(digest, ":", "%3A", 1)
+	}
+	id += "?repository_url=" + named.Name()
+	if tagged, ok := reference.TagNameOnly(named).(reference.Tagged); ok && digest == "" {
+		id += "&tag=" + tagged.Tag()
+	}
+	return id, nil
+}
@@ -0,0 +1,71 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package vex
+
+import (
+	"net/url"
+	"strings"
+)
+
+// purl is a package URL, like pkg:deb/debian/openssl@1.1.1d-0+deb10u3 or pkg:oci/alpine@sha256:...
+type purl struct {
+	kind       string
+	namespace  string
+	name       string
+	version    string
+	qualifiers map[string]string
+}
+
+func parsePURL(id string) (purl, bool) {
+	if !strings.HasPrefix(id, "pkg:") {
+		return purl{}, false
+	}
+	rest := strings.TrimPrefix(id, "pkg:")
+	if i := strings.Index(rest, "#"); i >= 0 {
+		rest = rest[:i]
+	}
+	p := purl{qualifiers: map[string]string{}}
+	if i := strings.Index(rest, "?"); i >= 0 {
+		query, err := url.ParseQuery(rest[i+1:])
+		if err != nil {
+			return purl{}, false
+		}
+		for key := range query {
+			p.qualifiers[key] = query.Get(key)
+		}
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		p.version = unescape(rest[i+1:])
+		rest = rest[:i]
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) < 2 {
+		return purl{}, false
+	}
+	p.kind = strings.ToLower(parts[0])
+	p.namespace = unescape(strings.Join(parts[1:len(parts)-1], "/"))
+	p.name = unescape(parts[len(parts)-1])
+	return p, p.name != ""
+}
+
+func unescape(value string) string {
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		return unescaped
+	}
+	return value
+}
@@ -0,0 +1,239 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package vex
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// The statuses of the VEX statements
+const (
+	NotAffected        = "not_affected"
+	Affected           = "affected"
+	Fixed              = "fixed"
+	UnderInvestigation = "under_investigation"
+)
+
+// Statement tells the status of a vulnerability in products, the image or its packages
+type Statement struct {
+	Vulnerability   string    `json:"vulnerability"`
+	Aliases         []string  `json:"aliases,omitempty"`
+	Products        []Product `json:"products,omitempty"`
+	Status          string    `json:"status"`
+	Justification   string    `json:"justification,omitempty"`
+	ImpactStatement string    `json:"impactStatement,omitempty"`
+}
+
+// Product is an image or a package, given by its purl, restricted to the subcomponents packages when any
+type Product struct {
+	ID            string   `json:"id"`
+	Subcomponents []string `json:"subcomponents,omitempty"`
+}
+
+// Document holds the statements of VEX documents, the statements of the last documents overriding the previous ones
+type Document struct {
+	Statements []Statement
+}
+
+// Target is the scanned image, with the digests of its manifests when it was pulled or pushed
+type Target struct {
+	Image   string
+	Digests []string
+}
+
+// Suppresses returns true if the vulnerability doesn't need to be reported, the vendor telling it's not exploitable
+// or fixed
+func (s Statement) Suppresses() bool {
+	return s.Status == NotAffected || s.Status == Fixed
+}
+
+// Load reads OpenVEX and CSAF VEX documents
+func Load(paths []string) (Document, error) {
+	var document Document
+	for _, path := range paths {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return Document{}, fmt.Errorf("failed to read VEX document: %s", err)
+		}
+		parsed, err := Parse(buf)
+		if err != nil {
+			return Document{}, fmt.Errorf("invalid VEX document %s: %s", path, err)
+		}
+		document.Statements = append(document.Statements, parsed.Statements...)
+	}
+	return document, nil
+}
+
+// Parse reads an OpenVEX or a CSAF VEX document
+func Parse(buf []byte) (Document, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(buf, &fields); err != nil {
+		return Document{}, err
+	}
+	switch {
+	case fields["statements"] != nil:
+		return parseOpenVEX(buf)
+	case fields["vulnerabilities"] != nil && fields["document"] != nil:
+		return parseCSAF(buf)
+	}
+	return Document{}, fmt.Errorf("neither an OpenVEX nor a CSAF document")
+}
+
+// Lookup returns the last statement applying to the finding of the image
+func (d Document) Lookup(target Target, finding report.Vulnerability) (Statement, bool) {
+	ids := findingIDs(finding)
+	for i := len(d.Statements) - 1; i >= 0; i-- {
+		statement := d.Statements[i]
+		if !ids[statement.Vulnerability] && !anyID(ids, statement.Aliases) {
+			continue
+		}
+		if len(statement.Products) == 0 {
+			return statement, true
+		}
+		for _, product := range statement.Products {
+			if matchesProduct(product, target, finding) {
+				return statement, true
+			}
+		}
+	}
+	return Statement{}, false
+}
+
+func findingIDs(finding report.Vulnerability) map[string]bool {
+	ids := map[string]bool{finding.ID: true}
+	for _, values := range finding.Identifiers {
+		for _, id := range values {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+func anyID(ids map[string]bool, aliases []string) bool {
+	for _, alias := range aliases {
+		if ids[alias] {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesProduct returns true if the product is the image, restricted to its subcomponents if any, or the package
+// of the finding
+func matchesProduct(product Product, target Target, finding report.Vulnerability) bool {
+	if matchesImage(product.ID, target) {
+		if len(product.Subcomponents) == 0 {
+			return true
+		}
+		for _, subcomponent := range product.Subcomponents {
+			if matchesPackage(subcomponent, finding) {
+				return true
+			}
+		}
+		return false
+	}
+	return matchesPackage(product.ID, finding)
+}
+
+// matchesImage compares the image to a pkg:oci purl or to an image reference. The purl of a manifest digest only
+// matches the images known to have that digest.
+func matchesImage(id string, target Target) bool {
+	if id == target.Image {
+		return true
+	}
+	named, err := reference.ParseNormalizedNamed(target.Image)
+	if err != nil {
+		return false
+	}
+	if p, ok := parsePURL(id); ok {
+		if p.kind != "oci" {
+			return false
+		}
+		repository := p.qualifiers["repository_url"]
+		if repository == "" {
+			repository = p.name
+		}
+		if !sameRepository(repository, named) || !sameTag(p.qualifiers["tag"], named) {
+			return false
+		}
+		return p.version == "" || contains(target.Digests, p.version)
+	}
+	product, err := reference.ParseNormalizedNamed(id)
+	if err != nil || product.Name() != named.Name() {
+		return false
+	}
+	if tagged, ok := product.(reference.Tagged); ok {
+		return sameTag(tagged.Tag(), named)
+	}
+	if digested, ok := product.(reference.Digested); ok {
+		return contains(target.Digests, digested.Digest().String())
+	}
+	return true
+}
+
+func sameRepository(repository string, named reference.Named) bool {
+	product, err := reference.ParseNormalizedNamed(repository)
+	if err != nil {
+		return false
+	}
+	// index.docker.io is the registry of the docker.io images
+	return strings.TrimPrefix(product.Name(), "index.") == named.Name()
+}
+
+func sameTag(tag string, named reference.Named) bool {
+	if tag == "" {
+		return true
+	}
+	tagged, ok := reference.TagNameOnly(named).(reference.Tagged)
+	return ok && tagged.Tag() == tag
+}
+
+// matchesPackage compares the package of the finding to a purl, the source and binary packages of Snyk like
+// openssl/libssl1.1 matching both names
+func matchesPackage(id string, finding report.Vulnerability) bool {
+	p, ok := parsePURL(id)
+	if !ok || p.kind == "oci" {
+		return false
+	}
+	if p.version != "" && finding.Version != "" && p.version != finding.Version {
+		return false
+	}
+	if p.name == finding.PackageName {
+		return true
+	}
+	for _, name := range strings.Split(finding.PackageName, "/") {
+		if p.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
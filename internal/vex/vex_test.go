@@ -0,0 +1,177 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package vex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+const openVEXDocument = `{
+  "@context": "https://openvex.dev/ns/v0.2.0",
+  "@id": "https://example.com/vex/myapp-1",
+  "author": "Example Security",
+  "timestamp": "2023-06-01T00:00:00Z",
+  "version": 1,
+  "statements": [
+    {
+      "vulnerability": {"name": "CVE-2021-3711"},
+      "products": [{"@id": "pkg:oci/myapp?repository_url=docker.io/library/myapp", "subcomponents": [{"@id": "pkg:deb/debian/openssl"}]}],
+      "status": "not_affected",
+      "justification": "vulnerable_code_not_in_execute_path"
+    },
+    {
+      "vulnerability": {"name": "CVE-2021-22945"},
+      "products": [{"@id": "pkg:oci/myapp@sha256%3A1234?repository_url=docker.io/library/myapp"}],
+      "status": "fixed"
+    },
+    {
+      "vulnerability": {"name": "CVE-2021-22946"},
+      "products": [{"@id": "myapp:1.0"}],
+      "status": "under_investigation"
+    }
+  ]
+}`
+
+const openVEXFirstVersion = `{
+  "@context": "https://openvex.dev/ns",
+  "statements": [
+    {"vulnerability": "CVE-2021-3712", "products": ["pkg:deb/debian/openssl@1.1.1d-0+deb10u7"], "status": "not_affected"}
+  ]
+}`
+
+const csafDocument = `{
+  "document": {"category": "csaf_vex", "csaf_version": "2.0"},
+  "product_tree": {
+    "branches": [{"branches": [{"product": {"product_id": "CSAFPID-1", "name": "myapp 1.0",
+      "product_identification_helper": {"purl": "pkg:oci/myapp?repository_url=docker.io/library/myapp&tag=1.0"}}}]}]
+  },
+  "vulnerabilities": [
+    {
+      "cve": "CVE-2021-3449",
+      "product_status": {"known_not_affected": ["CSAFPID-1"]},
+      "flags": [{"label": "component_not_present", "product_ids": ["CSAFPID-1"]}],
+      "threats": [{"category": "impact", "details": "TLS renegotiation is disabled", "product_ids": ["CSAFPID-1"]}]
+    }
+  ]
+}`
+
+var (
+	openssl = report.Vulnerability{ID: "SNYK-DEBIAN10-OPENSSL-1569403", PackageName: "openssl/libssl1.1", Version: "1.1.1d-0+deb10u7",
+		Identifiers: map[string][]string{"CVE": {"CVE-2021-3711"}}}
+	curl = report.Vulnerability{ID: "SNYK-DEBIAN10-CURL-1585138", PackageName: "curl", Version: "7.64.0-4+deb10u2",
+		Identifiers: map[string][]string{"CVE": {"CVE-2021-22945"}}}
+)
+
+func TestLookupOpenVEX(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithFile("app.vex.json", openVEXDocument), fs.WithFile("old.vex.json", openVEXFirstVersion))
+	defer dir.Remove()
+	document, err := Load([]string{dir.Join("app.vex.json"), dir.Join("old.vex.json")})
+	assert.NilError(t, err)
+	assert.Equal(t, len(document.Statements), 4)
+
+	target := Target{Image: "myapp:1.0"}
+	statement, ok := document.Lookup(target, openssl)
+	assert.Assert(t, ok)
+	assert.Equal(t, statement.Status, NotAffected)
+	assert.Equal(t, statement.Justification, "vulnerable_code_not_in_execute_path")
+	assert.Assert(t, statement.Suppresses())
+
+	// the subcomponents restrict the statement to their packages
+	_, ok = document.Lookup(target, report.Vulnerability{ID: "SNYK-DEBIAN10-CURL-1", PackageName: "curl",
+		Identifiers: map[string][]string{"CVE": {"CVE-2021-3711"}}})
+	assert.Assert(t, !ok)
+
+	// the statements of a digest only apply to the images with that digest
+	_, ok = document.Lookup(target, curl)
+	assert.Assert(t, !ok)
+	statement, ok = document.Lookup(Target{Image: "myapp:1.0", Digests: []string{"sha256:1234"}}, curl)
+	assert.Assert(t, ok)
+	assert.Equal(t, statement.Status, Fixed)
+
+	statement, ok = document.Lookup(target, report.Vulnerability{ID: "CVE-2021-22946"})
+	assert.Assert(t, ok)
+	assert.Assert(t, !statement.Suppresses())
+	_, ok = document.Lookup(Target{Image: "myapp:2.0"}, report.Vulnerability{ID: "CVE-2021-22946"})
+	assert.Assert(t, !ok)
+
+	// the package statements apply to the package version in any image
+	statement, ok = document.Lookup(Target{Image: "other"}, report.Vulnerability{ID: "CVE-2021-3712", PackageName: "openssl", Version: "1.1.1d-0+deb10u7"})
+	assert.Assert(t, ok)
+	assert.Equal(t, statement.Status, NotAffected)
+	_, ok = document.Lookup(Target{Image: "other"}, report.Vulnerability{ID: "CVE-2021-3712", PackageName: "openssl", Version: "1.1.1k-1"})
+	assert.Assert(t, !ok)
+}
+
+func TestLookupCSAF(t *testing.T) {
+	document, err := Parse([]byte(csafDocument))
+	assert.NilError(t, err)
+	finding := report.Vulnerability{ID: "SNYK-DEBIAN10-OPENSSL-1075326", Identifiers: map[string][]string{"CVE": {"CVE-2021-3449"}}}
+	statement, ok := document.Lookup(Target{Image: "docker.io/library/myapp:1.0"}, finding)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, statement, Statement{
+		Vulnerability:   "CVE-2021-3449",
+		Products:        []Product{{ID: "pkg:oci/myapp?repository_url=docker.io/library/myapp&tag=1.0"}},
+		Status:          NotAffected,
+		Justification:   "component_not_present",
+		ImpactStatement: "TLS renegotiation is disabled",
+	})
+	_, ok = document.Lookup(Target{Image: "myapp:1.1"}, finding)
+	assert.Assert(t, !ok)
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := Parse([]byte(`{"bomFormat": "CycloneDX"}`))
+	assert.Error(t, err, "neither an OpenVEX nor a CSAF document")
+}
+
+func TestGenerate(t *testing.T) {
+	now := time.Date(2021, time.March, 1, 9, 0, 0, 0, time.UTC)
+	product, err := ImagePURL("myapp:1.0", "")
+	assert.NilError(t, err)
+	assert.Equal(t, product, "pkg:oci/myapp?repository_url=docker.io/library/myapp&tag=1.0")
+	digested, err := ImagePURL("myorg/myapp:1.0", "sha256:1234")
+	assert.NilError(t, err)
+	assert.Equal(t, digested, "pkg:oci/myapp@sha256%3A1234?repository_url=docker.io/myorg/myapp")
+
+	opensslDeb := openssl
+	opensslDeb.PackageManager = "deb"
+	libcrypto := opensslDeb
+	libcrypto.PackageName = "openssl/libcrypto1.1"
+	license := report.Vulnerability{ID: "snyk:lic:deb:gpl", Type: report.LicenseType}
+	document := Generate("Example Security", product, []report.Vulnerability{curl, opensslDeb, libcrypto, license}, now)
+	assert.Equal(t, document.Context, OpenVEXContext)
+	assert.DeepEqual(t, document.Statements, []OpenVEXStatement{
+		{
+			Vulnerability: OpenVEXVulnerability{Name: "CVE-2021-22945", Aliases: []string{"SNYK-DEBIAN10-CURL-1585138"}},
+			Products:      []OpenVEXComponent{{ID: product}},
+			Status:        UnderInvestigation,
+		},
+		{
+			Vulnerability: OpenVEXVulnerability{Name: "CVE-2021-3711", Aliases: []string{"SNYK-DEBIAN10-OPENSSL-1569403"}},
+			Products: []OpenVEXComponent{{ID: product, Subcomponents: []OpenVEXComponent{
+				{ID: "pkg:deb/debian/libssl1.1@1.1.1d-0+deb10u7"},
+				{ID: "pkg:deb/debian/libcrypto1.1@1.1.1d-0+deb10u7"},
+			}}},
+			Status: UnderInvestigation,
+		},
+	})
+}
@@ -0,0 +1,143 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package waiver
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	cliConfig "github.com/docker/cli/cli/config"
+	"github.com/docker/scan-cli-plugin/internal/attest"
+	"github.com/docker/scan-cli-plugin/internal/filelock"
+)
+
+// PayloadType is the type of the waivers signed in the DSSE envelopes
+const PayloadType = "application/vnd.docker.scan.waiver+json"
+
+// Waiver permits the image with the digest to pass the gate despite its violations, until it expires
+type Waiver struct {
+	// Digest is the image ID or the repository digest of the image
+	Digest   string    `json:"digest"`
+	Reason   string    `json:"reason"`
+	Issuer   string    `json:"issuer,omitempty"`
+	IssuedAt time.Time `json:"issuedAt"`
+	Expires  time.Time `json:"expires"`
+}
+
+// Issue signs the waiver with the key of the security team
+func Issue(key *ecdsa.PrivateKey, waiver Waiver) (attest.Envelope, error) {
+	payload, err := json.Marshal(waiver)
+	if err != nil {
+		return attest.Envelope{}, err
+	}
+	return attest.SignPayload(key, PayloadType, payload)
+}
+
+// Load reads the signed waiver file
+func Load(path string) (attest.Envelope, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return attest.Envelope{}, fmt.Errorf("failed to read the waiver: %s", err)
+	}
+	var envelope attest.Envelope
+	if err := json.Unmarshal(buf, &envelope); err != nil {
+		return attest.Envelope{}, fmt.Errorf("invalid waiver %s: %s", path, err)
+	}
+	return envelope, nil
+}
+
+// Verify returns the waiver of the envelope when it is signed with the key, covers one of the digests of the image
+// and has not expired
+func Verify(envelope attest.Envelope, key *ecdsa.PublicKey, digests []string, now time.Time) (Waiver, error) {
+	if envelope.PayloadType != PayloadType {
+		return Waiver{}, fmt.Errorf("not a waiver: unexpected payload type %q", envelope.PayloadType)
+	}
+	payload, err := envelope.Verify(key)
+	if err != nil {
+		return Waiver{}, err
+	}
+	var waiver Waiver
+	if err := json.Unmarshal(payload, &waiver); err != nil {
+		return Waiver{}, fmt.Errorf("invalid waiver: %s", err)
+	}
+	if !covers(waiver, digests) {
+		return waiver, fmt.Errorf("the waiver is issued for %s, not for this image", waiver.Digest)
+	}
+	if !now.Before(waiver.Expires) {
+		return waiver, fmt.Errorf("the waiver expired on %s", waiver.Expires.Format(time.RFC3339))
+	}
+	return waiver, nil
+}
+
+func covers(waiver Waiver, digests []string) bool {
+	for _, digest := range digests {
+		if digest != "" && digest == waiver.Digest {
+			return true
+		}
+	}
+	return false
+}
+
+// Record is an entry of the audit log, a waiver presented to let an image pass the gate
+type Record struct {
+	Time    time.Time `json:"time"`
+	Image   string    `json:"image"`
+	Digests []string  `json:"digests,omitempty"`
+	// File is the path of the waiver file
+	File     string    `json:"file"`
+	Digest   string    `json:"digest,omitempty"`
+	Issuer   string    `json:"issuer,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	Expires  time.Time `json:"expires"`
+	Accepted bool      `json:"accepted"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// AuditPath returns the audit log of the docker scan configuration
+func AuditPath() string {
+	return filepath.Join(cliConfig.Dir(), "scan", "audit.jsonl")
+}
+
+// Audit appends the record to the audit log, which is never truncated
+func Audit(path string, record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	unlock, err := filelock.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close() //nolint:errcheck
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,86 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package waiver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+const imageDigest = "sha256:6d1ef012b5674ad8a127ecfa9b5e6f5178d171b90ee462846974177fd9bdd39f"
+
+func TestIssueAndVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	issued := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	envelope, err := Issue(key, Waiver{
+		Digest:   imageDigest,
+		Reason:   "CVE-2021-3449 is not reachable",
+		Issuer:   "security@example.com",
+		IssuedAt: issued,
+		Expires:  issued.Add(30 * 24 * time.Hour),
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, envelope.PayloadType, PayloadType)
+
+	path := filepath.Join(t.TempDir(), "waiver.json")
+	buf, err := json.Marshal(envelope)
+	assert.NilError(t, err)
+	assert.NilError(t, ioutil.WriteFile(path, buf, 0644))
+	envelope, err = Load(path)
+	assert.NilError(t, err)
+
+	waiver, err := Verify(envelope, &key.PublicKey, []string{"sha256:other", imageDigest}, issued.Add(time.Hour))
+	assert.NilError(t, err)
+	assert.Equal(t, waiver.Issuer, "security@example.com")
+
+	_, err = Verify(envelope, &other.PublicKey, []string{imageDigest}, issued)
+	assert.ErrorContains(t, err, "no signature matches the key")
+	_, err = Verify(envelope, &key.PublicKey, []string{"sha256:other"}, issued)
+	assert.ErrorContains(t, err, "the waiver is issued for "+imageDigest)
+	_, err = Verify(envelope, &key.PublicKey, []string{imageDigest}, issued.Add(31*24*time.Hour))
+	assert.ErrorContains(t, err, "the waiver expired on 2021-03-31T10:00:00Z")
+
+	envelope.PayloadType = "application/vnd.in-toto+json"
+	_, err = Verify(envelope, &key.PublicKey, []string{imageDigest}, issued)
+	assert.ErrorContains(t, err, "not a waiver")
+}
+
+func TestAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan", "audit.jsonl")
+	assert.NilError(t, Audit(path, Record{Image: "app:1.0", File: "waiver.json", Accepted: true}))
+	assert.NilError(t, Audit(path, Record{Image: "app:1.1", File: "waiver.json", Error: "expired"}))
+	buf, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	assert.Equal(t, len(lines), 2)
+	var record Record
+	assert.NilError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, record.Image, "app:1.1")
+	assert.Equal(t, record.Error, "expired")
+}
@@ -0,0 +1,98 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package yara
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/scan-cli-plugin/internal/image"
+	"github.com/docker/scan-cli-plugin/internal/report"
+)
+
+// Scanner runs user supplied YARA rules over the files of the image layers, using the yara binary
+type Scanner struct {
+	path  string
+	rules []string
+}
+
+// NewScanner checks the rule files and looks for the yara binary in the PATH
+func NewScanner(rules []string) (*Scanner, error) {
+	for _, rule := range rules {
+		if _, err := os.Stat(rule); err != nil {
+			return nil, fmt.Errorf("invalid YARA rules file: %s", err)
+		}
+	}
+	path, err := exec.LookPath("yara")
+	if err != nil {
+		return nil, fmt.Errorf("could not find yara binary, please install YARA to scan images with YARA rules")
+	}
+	return &Scanner{path: path, rules: rules}, nil
+}
+
+// ScanLayers reports the files of the extracted image layers matching the rules
+func (s *Scanner) ScanLayers(ctx context.Context, extracted *image.ExtractedImage) ([]report.Vulnerability, error) {
+	args := append([]string{"--recursive", "--no-warnings"}, s.rules...)
+	cmd := exec.CommandContext(ctx, s.path, append(args, extracted.Dir)...)
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("yara failed: %s %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseMatches(stdout.String(), extracted.Dir, extracted.LayerIDs), nil
+}
+
+// parseMatches converts the yara output lines "RULE PATH" to findings, the files being
+// extracted to a directory per layer
+func parseMatches(output string, dir string, layers []string) []report.Vulnerability {
+	var matches []report.Vulnerability
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		rule, matchPath := fields[0], fields[1]
+		relPath, err := filepath.Rel(dir, matchPath)
+		if err != nil || strings.HasPrefix(relPath, "..") {
+			continue
+		}
+		parts := strings.SplitN(filepath.ToSlash(relPath), "/", 2)
+		index, err := strconv.Atoi(parts[0])
+		if err != nil || index >= len(layers) || len(parts) != 2 {
+			continue
+		}
+		matches = append(matches, report.Vulnerability{
+			ID:       rule,
+			Type:     report.MalwareType,
+			Title:    fmt.Sprintf("File matching YARA rule %s", rule),
+			Severity: "high",
+			Path:     "/" + parts[1],
+			Layer:    layers[index],
+		})
+	}
+	return matches
+}
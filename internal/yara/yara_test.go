@@ -0,0 +1,58 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package yara
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/scan-cli-plugin/internal/report"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseMatches(t *testing.T) {
+	dir := filepath.FromSlash("/tmp/docker-scan-yara")
+	output := "Miner " + filepath.Join(dir, "1", "usr", "bin", "xmrig") + "\n" +
+		"Webshell " + filepath.Join(dir, "0", "var", "www", "shell file.php") + "\n" +
+		"Invalid " + filepath.Join(dir, "2", "bin", "sh") + "\n" +
+		"error scanning /proc: could not open file\n"
+
+	matches := parseMatches(output, dir, []string{"sha256:base", "sha256:user"})
+	assert.DeepEqual(t, matches, []report.Vulnerability{
+		{
+			ID:       "Miner",
+			Type:     report.MalwareType,
+			Title:    "File matching YARA rule Miner",
+			Severity: "high",
+			Path:     "/usr/bin/xmrig",
+			Layer:    "sha256:user",
+		},
+		{
+			ID:       "Webshell",
+			Type:     report.MalwareType,
+			Title:    "File matching YARA rule Webshell",
+			Severity: "high",
+			Path:     "/var/www/shell file.php",
+			Layer:    "sha256:base",
+		},
+	})
+}
+
+func TestNewScannerChecksRules(t *testing.T) {
+	_, err := NewScanner([]string{"does-not-exist.yar"})
+	assert.ErrorContains(t, err, "invalid YARA rules file")
+}
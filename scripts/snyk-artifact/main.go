@@ -0,0 +1,39 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Command snyk-artifact prints the name of the Snyk release artifact of a GOOS/GOARCH platform, as downloaded
+// by the plugin, so that the build downloads the same artifacts. It fails if there is no Snyk release for the platform.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/docker/scan-cli-plugin/internal/provider"
+)
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: snyk-artifact GOOS GOARCH")
+		os.Exit(2)
+	}
+	artifact, err := provider.SnykArtifact(os.Args[1], os.Args[2])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(artifact)
+}
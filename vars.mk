@@ -4,16 +4,17 @@ SNYK_USER_VERSION=1.460.0
 SNYK_OLD_VERSION=1.382.1
 # Digest of the 1.563.0 snyk/snyk:docker image
 SNYK_IMAGE_DIGEST=sha256:defb5ba5517a29a78736d919d3dc0568f555980a43daefe1ac8a1e7fc0924f25
-GO_VERSION=1.15.0
+GO_VERSION=1.17.13
 CLI_VERSION=19.03.9
 ALPINE_VERSION=3.12.0
 GOLANGCI_LINT_VERSION=v1.27.0-alpine
 GOTESTSUM_VERSION=0.5.2
 
 GOOS ?= $(shell go env GOOS)
+GOARCH ?= $(shell go env GOARCH)
 BINARY_EXT=
 ifeq ($(GOOS),windows)
 	BINARY_EXT=.exe
 endif
-PLATFORM_BINARY?=docker-scan_$(GOOS)_amd64$(BINARY_EXT)
+PLATFORM_BINARY?=docker-scan_$(GOOS)_$(GOARCH)$(BINARY_EXT)
 BINARY=docker-scan$(BINARY_EXT)
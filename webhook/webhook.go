@@ -0,0 +1,91 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package webhook signs the scan results delivered by docker scan webhook notifications, and lets their receivers
+// verify them
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader is the HMAC-SHA256 signature of the delivery, as sha256=HEX
+	SignatureHeader = "X-Docker-Scan-Signature"
+	// TimestampHeader is the Unix time of the delivery, part of the signed content to prevent replays
+	TimestampHeader = "X-Docker-Scan-Timestamp"
+	// SecretEnvVar is the environment variable holding the secret shared with the webhook receivers
+	SecretEnvVar = "DOCKER_SCAN_WEBHOOK_SECRET"
+	// DefaultTolerance is the maximum age of a delivery accepted by VerifyRequest
+	DefaultTolerance = 5 * time.Minute
+
+	signaturePrefix = "sha256="
+)
+
+// Sign returns the signature of the body delivered at the timestamp
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body) //nolint: errcheck
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest sets the timestamp and signature headers of a delivery
+func SignRequest(req *http.Request, secret []byte, body []byte, now time.Time) {
+	timestamp := now.Unix()
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+}
+
+// Verify checks the signature of the body delivered at the timestamp, and that the delivery is not older than the
+// tolerance, the age is not checked when the tolerance is 0
+func Verify(secret []byte, body []byte, signature, timestamp string, tolerance time.Duration, now time.Time) error {
+	if signature == "" {
+		return fmt.Errorf("the delivery is not signed")
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return fmt.Errorf("unsupported signature %q, expected %sHEX", signature, signaturePrefix)
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q: %s", timestamp, err)
+	}
+	if !hmac.Equal([]byte(Sign(secret, unix, body)), []byte(signature)) {
+		return fmt.Errorf("the signature doesn't match the content of the delivery")
+	}
+	if age := now.Sub(time.Unix(unix, 0)); tolerance > 0 && (age > tolerance || age < -tolerance) {
+		return fmt.Errorf("the delivery is too old: signed %s ago, the tolerance is %s", age.Round(time.Second), tolerance)
+	}
+	return nil
+}
+
+// VerifyRequest reads the body of a delivery received by a webhook endpoint, and checks its signature with the
+// default tolerance
+func VerifyRequest(req *http.Request, secret []byte) ([]byte, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	return body, Verify(secret, body, req.Header.Get(SignatureHeader), req.Header.Get(TimestampHeader), DefaultTolerance, time.Now())
+}
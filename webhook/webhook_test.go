@@ -0,0 +1,61 @@
+/*
+   Copyright 2020 Docker Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestVerify(t *testing.T) {
+	secret := []byte("s3cr3t")
+	body := []byte(`{"image":"myapp:1.0","total":1}`)
+	now := time.Unix(1700000000, 0)
+	signature := Sign(secret, now.Unix(), body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	assert.NilError(t, Verify(secret, body, signature, timestamp, DefaultTolerance, now.Add(time.Minute)))
+	assert.Error(t, Verify(secret, []byte(`{"image":"myapp:1.0","total":0}`), signature, timestamp, DefaultTolerance, now),
+		"the signature doesn't match the content of the delivery")
+	assert.Error(t, Verify([]byte("other"), body, signature, timestamp, DefaultTolerance, now),
+		"the signature doesn't match the content of the delivery")
+	// the timestamp is signed, it can't be replaced to replay an old delivery
+	assert.Error(t, Verify(secret, body, signature, strconv.FormatInt(now.Unix()+3600, 10), DefaultTolerance, now.Add(time.Hour)),
+		"the signature doesn't match the content of the delivery")
+	assert.Error(t, Verify(secret, body, signature, timestamp, DefaultTolerance, now.Add(time.Hour)),
+		"the delivery is too old: signed 1h0m0s ago, the tolerance is 5m0s")
+	assert.NilError(t, Verify(secret, body, signature, timestamp, 0, now.Add(time.Hour)))
+	assert.Error(t, Verify(secret, body, "", timestamp, 0, now), "the delivery is not signed")
+	assert.Error(t, Verify(secret, body, "sha1=abc", timestamp, 0, now), `unsupported signature "sha1=abc", expected sha256=HEX`)
+}
+
+func TestVerifyRequest(t *testing.T) {
+	secret := []byte("s3cr3t")
+	body := []byte(`{"image":"myapp:1.0"}`)
+	req := httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(body))
+	SignRequest(req, secret, body, time.Now())
+
+	received, err := VerifyRequest(req, secret)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, received, body)
+}